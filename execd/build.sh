@@ -28,6 +28,9 @@ docker buildx ls
 docker buildx build \
   -t opensandbox/execd:${TAG} \
   -t sandbox-registry.cn-zhangjiakou.cr.aliyuncs.com/opensandbox/execd:${TAG} \
+  --build-arg VERSION=${TAG} \
+  --build-arg GIT_COMMIT=$(git rev-parse HEAD 2>/dev/null) \
+  --build-arg BUILD_DATE=$(date -u +%Y-%m-%dT%H:%M:%SZ) \
   --platform linux/amd64,linux/arm64 \
   --push \
   .
@@ -16,11 +16,13 @@ package main
 
 import (
 	"fmt"
+	"os"
 
 	_ "go.uber.org/automaxprocs/maxprocs"
 
 	"github.com/alibaba/opensandbox/execd/pkg/flag"
 	"github.com/alibaba/opensandbox/execd/pkg/log"
+	"github.com/alibaba/opensandbox/execd/pkg/runtime"
 	_ "github.com/alibaba/opensandbox/execd/pkg/util/safego"
 	"github.com/alibaba/opensandbox/execd/pkg/web"
 	"github.com/alibaba/opensandbox/execd/pkg/web/controller"
@@ -28,15 +30,41 @@ import (
 
 // main initializes and starts the execd server.
 func main() {
+	// commands run under resource limits start through execd itself.
+	runtime.RunResourceLimitHelper(os.Args)
+
 	flag.InitFlags()
 
-	log.SetLevel(flag.ServerLogLevel)
+	if err := log.SetLevels(flag.ServerLogLevel); err != nil {
+		log.Error("failed to set log levels: %v", err)
+		os.Exit(1)
+	}
+	if err := log.Configure(log.Options{
+		File:       flag.LogFile,
+		MaxSize:    flag.LogMaxSize,
+		MaxBackups: flag.LogMaxBackups,
+		MaxAge:     flag.LogMaxAge,
+		Stdout:     flag.LogStdout,
+	}); err != nil {
+		log.Error("failed to configure logging: %v", err)
+		os.Exit(1)
+	}
 
-	controller.InitCodeRunner()
-	engine := web.NewRouter(flag.ServerAccessToken)
+	if err := controller.InitCodeRunner(); err != nil {
+		log.Error("failed to init code runner: %v", err)
+		os.Exit(1)
+	}
+	controller.InitCPUSampler()
+	controller.InitMetricsHistory()
+	engine, err := web.NewRouter(flag.ServerAccessToken)
+	if err != nil {
+		log.Error("failed to build execd router: %v", err)
+		os.Exit(1)
+	}
 	addr := fmt.Sprintf(":%d", flag.ServerPort)
 	log.Info("execd listening on %s", addr)
-	if err := engine.Run(addr); err != nil {
+	server := web.NewServer(addr, engine)
+	if err := server.ListenAndServe(); err != nil {
 		log.Error("failed to start execd server: %v", err)
 	}
 }
@@ -0,0 +1,239 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/alibaba/opensandbox/execd/pkg/log"
+)
+
+const (
+	configEnv = "EXECD_CONFIG"
+	// envPrefix names the variable of every flag, e.g. EXECD_PORT for --port.
+	envPrefix = "EXECD_"
+)
+
+// legacyEnv lists variables read before the EXECD_* names existed. The EXECD_*
+// variable wins when both are set.
+var legacyEnv = map[string]string{
+	"jupyter-host":              "JUPYTER_HOST",
+	"jupyter-token":             "JUPYTER_TOKEN",
+	"graceful-shutdown-timeout": "EXECD_API_GRACE_SHUTDOWN",
+}
+
+// secretFlags are masked by --print-config and GET /debug/config.
+var secretFlags = map[string]bool{
+	"jupyter-token": true,
+	"access-token":  true,
+	"admin-token":   true,
+	// injected headers and data source names typically carry credentials.
+	"proxy-inject-headers": true,
+	"sql-connections":      true,
+}
+
+// load parses args into fs and fills every flag not given on the command line
+// from the config file and then the environment. All invalid settings are
+// reported together.
+func load(fs *flag.FlagSet, args []string, lookupEnv func(string) (string, bool)) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+
+	var errs []error
+	path := ConfigFile
+	if !explicit["config"] {
+		if value, ok := lookupEnv(configEnv); ok {
+			path = value
+		}
+	}
+	if path != "" {
+		errs = append(errs, applyConfigFile(fs, path, explicit)...)
+	}
+	errs = append(errs, applyEnv(fs, lookupEnv, explicit)...)
+	errs = append(errs, validate()...)
+	return errors.Join(errs...)
+}
+
+// applyConfigFile sets the flags named by the keys of a YAML file. Lists are
+// joined with commas, matching the flags that take comma separated values.
+func applyConfigFile(fs *flag.FlagSet, path string, explicit map[string]bool) []error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return []error{fmt.Errorf("config %s: %w", path, err)}
+	}
+	var settings map[string]any
+	if err := yaml.Unmarshal(data, &settings); err != nil {
+		return []error{fmt.Errorf("config %s: %w", path, err)}
+	}
+
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for _, name := range names {
+		if name == "config" || fs.Lookup(name) == nil {
+			errs = append(errs, fmt.Errorf("config %s: unknown setting %q", path, name))
+			continue
+		}
+		if explicit[name] {
+			continue
+		}
+		value, err := configValue(settings[name])
+		if err == nil {
+			err = fs.Set(name, value)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("config %s: %s: %w", path, name, err))
+		}
+	}
+	return errs
+}
+
+func configValue(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			s, err := configValue(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, s)
+		}
+		return strings.Join(items, ","), nil
+	case map[string]any:
+		return "", errors.New("expected a scalar or a list")
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
+
+// applyEnv sets flags from their EXECD_* variables, or the legacy ones.
+func applyEnv(fs *flag.FlagSet, lookupEnv func(string) (string, bool), explicit map[string]bool) []error {
+	var errs []error
+	fs.VisitAll(func(f *flag.Flag) {
+		if explicit[f.Name] || f.Name == "config" {
+			return
+		}
+		for _, env := range []string{legacyEnv[f.Name], envName(f.Name)} {
+			if env == "" {
+				continue
+			}
+			value, ok := lookupEnv(env)
+			if !ok {
+				continue
+			}
+			if err := fs.Set(f.Name, value); err != nil {
+				errs = append(errs, fmt.Errorf("env %s: %w", env, err))
+			}
+		}
+	})
+	return errs
+}
+
+// envName returns the environment variable of a flag, e.g. EXECD_LOG_LEVEL for log-level.
+func envName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// validate checks the loaded settings that flag parsing can't.
+func validate() []error {
+	var errs []error
+	if JupyterServerHost != "" && !strings.HasPrefix(JupyterServerHost, "http://") && !strings.HasPrefix(JupyterServerHost, "https://") {
+		errs = append(errs, fmt.Errorf("jupyter-host: %q must start with http:// or https://", JupyterServerHost))
+	}
+	if _, err := log.ParseLevels(ServerLogLevel); err != nil {
+		errs = append(errs, fmt.Errorf("log-level: %w", err))
+	}
+	if ServerPort < 1 || ServerPort > 65535 {
+		errs = append(errs, fmt.Errorf("port: %d is not between 1 and 65535", ServerPort))
+	}
+	nonNegative := []struct {
+		name  string
+		value int64
+	}{
+		{"log-max-size", LogMaxSize},
+		{"command-log-segment-size", CommandLogSegmentSize},
+		{"log-max-backups", int64(LogMaxBackups)},
+		{"log-stream-event-sample", int64(LogStreamEventSample)},
+		{"stream-max-output-rate", int64(StreamMaxOutputRate)},
+		{"metrics-history-size", int64(MetricsHistorySize)},
+		{"jupyter-connect-retries", int64(JupyterConnectRetries)},
+		{"jupyter-connect-retry-delay", int64(JupyterConnectRetryDelay)},
+	}
+	for _, setting := range nonNegative {
+		if setting.value < 0 {
+			errs = append(errs, fmt.Errorf("%s: %d must not be negative", setting.name, setting.value))
+		}
+	}
+	if ServerHandlerTimeout < 0 {
+		errs = append(errs, fmt.Errorf("handler-timeout: %v must not be negative", ServerHandlerTimeout))
+	}
+	if MetricsWatchMinInterval > MetricsWatchMaxInterval {
+		errs = append(errs, fmt.Errorf("metrics-watch-min-interval: %v exceeds metrics-watch-max-interval %v", MetricsWatchMinInterval, MetricsWatchMaxInterval))
+	}
+	return errs
+}
+
+// printConfig writes the effective settings as a YAML config file, with secrets masked.
+func printConfig(w io.Writer, fs *flag.FlagSet) error {
+	return yaml.NewEncoder(w).Encode(settings(fs))
+}
+
+// Effective returns the loaded settings keyed by flag name, with secrets
+// masked and durations formatted like "1m30s".
+func Effective() map[string]any {
+	fs := flag.NewFlagSet("execd", flag.ContinueOnError)
+	define(fs)
+	return settings(fs)
+}
+
+// settings collects the values of the flags of fs, with secrets masked.
+func settings(fs *flag.FlagSet) map[string]any {
+	settings := make(map[string]any)
+	fs.VisitAll(func(f *flag.Flag) {
+		if f.Name == "config" || f.Name == "print-config" || f.Name == "version" {
+			return
+		}
+		value := f.Value.(flag.Getter).Get()
+		switch v := value.(type) {
+		case time.Duration:
+			value = v.String()
+		case string:
+			if secretFlags[f.Name] && v != "" {
+				value = "[REDACTED]"
+			}
+		}
+		settings[f.Name] = value
+	})
+	return settings
+}
@@ -0,0 +1,143 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func loadForTest(t *testing.T, config string, env map[string]string, args ...string) error {
+	t.Helper()
+	if config != "" {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+			t.Fatalf("write config: %v", err)
+		}
+		env = mergeEnv(env, map[string]string{configEnv: path})
+	}
+	fs := flag.NewFlagSet("execd", flag.ContinueOnError)
+	fs.SetOutput(&bytes.Buffer{})
+	register(fs)
+	return load(fs, args, func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	})
+}
+
+func mergeEnv(env, extra map[string]string) map[string]string {
+	merged := make(map[string]string, len(env)+len(extra))
+	for k, v := range env {
+		merged[k] = v
+	}
+	for k, v := range extra {
+		merged[k] = v
+	}
+	return merged
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	config := strings.Join([]string{
+		"port: 1000",
+		"log-level: 7",
+		"sse-write-timeout: 5s",
+		"proxy-allowed-ports: [3000, 8080-8090]",
+		"jupyter-host: http://config:8888",
+	}, "\n")
+	env := map[string]string{
+		"EXECD_PORT":      "2000",
+		"EXECD_LOG_LEVEL": "4",
+		"JUPYTER_HOST":    "http://legacy:8888",
+	}
+
+	if err := loadForTest(t, config, env, "--port=3000"); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if ServerPort != 3000 {
+		t.Fatalf("expected the flag to win, port=%d", ServerPort)
+	}
+	if ServerLogLevel != "4" {
+		t.Fatalf("expected the env to override the config, log-level=%s", ServerLogLevel)
+	}
+	if ApiSSEWriteTimeout != 5*time.Second || ProxyAllowedPorts != "3000,8080-8090" {
+		t.Fatalf("expected config values, got %v and %q", ApiSSEWriteTimeout, ProxyAllowedPorts)
+	}
+	if JupyterServerHost != "http://legacy:8888" {
+		t.Fatalf("expected the legacy env to override the config, got %q", JupyterServerHost)
+	}
+	if ServerIdleTimeout != 120*time.Second {
+		t.Fatalf("expected unset settings to keep their default, got %v", ServerIdleTimeout)
+	}
+
+	env["EXECD_JUPYTER_HOST"] = "http://execd:8888"
+	if err := loadForTest(t, config, env); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if JupyterServerHost != "http://execd:8888" || ServerPort != 2000 {
+		t.Fatalf("expected EXECD_* variables to win, got host %q port %d", JupyterServerHost, ServerPort)
+	}
+}
+
+func TestLoad_ReportsAllErrors(t *testing.T) {
+	config := "port: 70000\nunknown-setting: 1\nlog-max-age: soon\n"
+	env := map[string]string{
+		"EXECD_LOG_MAX_BACKUPS": "many",
+		"JUPYTER_HOST":          "localhost:8888",
+		"EXECD_LOG_LEVEL":       "proxy=loud",
+	}
+
+	err := loadForTest(t, config, env)
+	if err == nil {
+		t.Fatalf("expected errors")
+	}
+	for _, want := range []string{"unknown-setting", "log-max-age", "EXECD_LOG_MAX_BACKUPS", "jupyter-host", "port: 70000", "log-level: proxy"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in:\n%v", want, err)
+		}
+	}
+}
+
+func TestPrintConfig_MasksSecretsAndRoundTrips(t *testing.T) {
+	fs := flag.NewFlagSet("execd", flag.ContinueOnError)
+	register(fs)
+	if err := fs.Parse([]string{"--access-token=s3cr3t", "--port=4000", "--proxy-dial-timeout=2s"}); err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := printConfig(&out, fs); err != nil {
+		t.Fatalf("print: %v", err)
+	}
+	printed := out.String()
+	if strings.Contains(printed, "s3cr3t") || !strings.Contains(printed, "access-token: '[REDACTED]'") {
+		t.Fatalf("expected the access token to be masked:\n%s", printed)
+	}
+	if !strings.Contains(printed, "port: 4000") || strings.Contains(printed, "print-config") {
+		t.Fatalf("unexpected output:\n%s", printed)
+	}
+
+	// the output is itself a valid config file.
+	if err := loadForTest(t, printed, nil); err != nil {
+		t.Fatalf("load printed config: %v", err)
+	}
+	if ServerPort != 4000 || ProxyDialTimeout != 2*time.Second {
+		t.Fatalf("expected printed values to load back, got port %d dial timeout %v", ServerPort, ProxyDialTimeout)
+	}
+}
@@ -17,21 +17,187 @@ package flag
 import "time"
 
 var (
+	// ConfigFile is a YAML file of settings keyed by flag name.
+	ConfigFile string
+
+	// PrintConfig prints the effective configuration and exits.
+	PrintConfig bool
+
+	// ShowVersion prints the build info and exits.
+	ShowVersion bool
+
 	// JupyterServerHost points to the target Jupyter instance.
 	JupyterServerHost string
 
 	// JupyterServerToken authenticates requests to the Jupyter server.
 	JupyterServerToken string
 
+	// JupyterConnectRetries bounds the retries of a failed connection to a kernel's websocket.
+	JupyterConnectRetries int
+
+	// JupyterConnectRetryDelay is the wait before the first connection retry, doubled after each one.
+	JupyterConnectRetryDelay time.Duration
+
 	// ServerPort controls the HTTP listener port.
 	ServerPort int
 
-	// ServerLogLevel controls the server log verbosity.
-	ServerLogLevel int
+	// ServerLogLevel controls the server log verbosity: a level, or comma separated
+	// component=level pairs such as "proxy=debug,default=info".
+	ServerLogLevel string
+
+	// LogFile writes logs to this file instead of stdout when set.
+	LogFile string
+
+	// LogMaxSize rotates the log file once it would exceed this many bytes; 0 disables rotation.
+	LogMaxSize int64
+
+	// LogMaxBackups is the number of rotated log files kept; 0 keeps all.
+	LogMaxBackups int
+
+	// LogMaxAge removes rotated log files older than this; 0 keeps them regardless of age.
+	LogMaxAge time.Duration
+
+	// LogStdout also writes logs to stdout when LogFile is set.
+	LogStdout bool
+
+	// LogRedactEnv names environment variables whose values are masked in logs, comma separated.
+	LogRedactEnv string
+
+	// LogStreamEventSample logs one in this many stream events of a run; 0 disables event logging.
+	LogStreamEventSample int
 
 	// ServerAccessToken guards API entrypoints when set.
 	ServerAccessToken string
 
+	// ServerAdminToken grants admin requests, such as revealing the values of
+	// the env file, to clients sending it; admin requests fail when unset.
+	ServerAdminToken string
+
+	// ServerPublicInfo serves /info without the access token.
+	ServerPublicInfo bool
+
 	// ApiGracefulShutdownTimeout waits before tearing down SSE streams.
 	ApiGracefulShutdownTimeout time.Duration
+
+	// ApiSSEWriteTimeout bounds a single SSE event write before the client is treated as gone.
+	ApiSSEWriteTimeout time.Duration
+
+	// StreamMaxOutputRate caps stdout and stderr events per second of one stream; 0 is unlimited.
+	StreamMaxOutputRate int
+
+	// ServerStrictJSON rejects request bodies containing unknown fields.
+	ServerStrictJSON bool
+
+	// ServerLogBodies logs request and response bodies, redacted and capped, for debugging.
+	ServerLogBodies bool
+
+	// ServerLogBodyLimit caps how many bytes of each logged body are kept.
+	ServerLogBodyLimit int
+
+	// ServerReadHeaderTimeout bounds how long a client may take to send request headers.
+	ServerReadHeaderTimeout time.Duration
+
+	// ServerIdleTimeout closes keep-alive connections idle for longer than this.
+	ServerIdleTimeout time.Duration
+
+	// ServerMaxHeaderBytes caps the size of request headers.
+	ServerMaxHeaderBytes int
+
+	// ServerWriteTimeout bounds writing non-streaming responses; streaming routes are exempt.
+	ServerWriteTimeout time.Duration
+
+	// ServerHandlerTimeout cancels requests of non-streaming routes running longer than this; 0 disables it.
+	ServerHandlerTimeout time.Duration
+
+	// ServerEnableH2C serves HTTP/2 over cleartext for clients behind trusted proxies.
+	ServerEnableH2C bool
+
+	// ServerBasePath mounts every route, /proxy included, under this prefix (e.g. "/execd").
+	ServerBasePath string
+
+	// CommandMaxAddressSpace caps the virtual memory of shell commands in bytes; negative is unlimited.
+	CommandMaxAddressSpace int64
+
+	// CommandMaxCPUSeconds caps the CPU time of shell commands in seconds; negative is unlimited.
+	CommandMaxCPUSeconds int64
+
+	// CommandMaxOpenFiles caps the open file descriptors of shell commands; negative is unlimited.
+	CommandMaxOpenFiles int64
+
+	// CommandMaxCoreSize caps core dumps of shell commands in bytes; 0 disables them, negative is unlimited.
+	CommandMaxCoreSize int64
+
+	// CommandLogSegmentSize splits background command logs into segments of this many bytes; 0 keeps one file.
+	CommandLogSegmentSize int64
+
+	// CommandLogCompress gzips full background command log segments.
+	CommandLogCompress bool
+
+	// CommandForwardSignals lists the signals, comma separated, execd relays to the process group of foreground commands.
+	CommandForwardSignals string
+
+	// CwdAllowedRoot restricts the working directories of commands and contexts to this directory; empty allows any.
+	CwdAllowedRoot string
+
+	// CwdCreateMode is the octal permissions of working directories created for requests setting create_cwd.
+	CwdCreateMode string
+
+	// SQLConnections registers databases sql code may pick, as "NAME=DRIVER:DSN" entries separated by ";".
+	SQLConnections string
+
+	// ContextPreambles names per language files whose code runs in every new context, as "LANGUAGE=FILE" entries separated by ",".
+	ContextPreambles string
+
+	// MetricsDiskPaths lists the comma separated paths whose disk usage metrics report; empty means / and the working directory.
+	MetricsDiskPaths string
+
+	// MetricsWatchMinInterval and MetricsWatchMaxInterval bound the interval query of /metrics/watch.
+	MetricsWatchMinInterval time.Duration
+	MetricsWatchMaxInterval time.Duration
+
+	// MetricsHistorySize is how many samples /metrics/history keeps; 0 disables the sampler.
+	MetricsHistorySize int
+
+	// MetricsHistoryInterval is the period between two samples of /metrics/history.
+	MetricsHistoryInterval time.Duration
+
+	// WebSocketAllowedOrigins lists the origins, such as https://app.example.com, allowed to open
+	// websockets through execd; "*" allows any. Same-origin and Origin-less requests are always allowed.
+	WebSocketAllowedOrigins string
+
+	// ProxyAllowedPorts restricts /proxy targets to these ports (e.g. "3000-3999,8080"); empty allows all.
+	ProxyAllowedPorts string
+
+	// ProxyDeniedPorts lists ports /proxy must never reach; execd's own port is always denied.
+	ProxyDeniedPorts string
+
+	// ProxyAllowedHosts lists hostnames, IPs and CIDRs /proxy may reach besides localhost.
+	ProxyAllowedHosts string
+
+	// ProxyInjectBaseHref adds a <base href> to proxied HTML pages using absolute asset URLs.
+	ProxyInjectBaseHref bool
+
+	// ProxyInjectHeaders adds static headers per local port, as "PORT:Name=Value" entries separated by ";".
+	ProxyInjectHeaders string
+
+	// ProxyDialTimeout bounds connecting to a proxy upstream.
+	ProxyDialTimeout time.Duration
+
+	// ProxyResponseHeaderTimeout bounds waiting for upstream response headers; 0 waits forever.
+	ProxyResponseHeaderTimeout time.Duration
+
+	// ProxyIdleTimeout closes pooled upstream connections idle for longer than this.
+	ProxyIdleTimeout time.Duration
+
+	// ProxyFlushInterval flushes proxied responses of known length periodically.
+	ProxyFlushInterval time.Duration
+
+	// ProxyMaxTimeout caps the per-request X-Proxy-Timeout header; 0 leaves it uncapped.
+	ProxyMaxTimeout time.Duration
+
+	// ProxyTLSInsecure skips certificate verification of HTTPS upstreams reached via /proxy/https/.
+	ProxyTLSInsecure bool
+
+	// ProxyTLSCAFile is a PEM bundle trusted for HTTPS upstreams in addition to the system roots.
+	ProxyTLSCAFile string
 )
@@ -16,61 +16,179 @@ package flag
 
 import (
 	"flag"
-	stdlog "log"
+	"fmt"
 	"os"
 	"strings"
 	"time"
 
 	"github.com/alibaba/opensandbox/execd/pkg/log"
+	"github.com/alibaba/opensandbox/execd/pkg/version"
 )
 
-const (
-	jupyterHostEnv             = "JUPYTER_HOST"
-	jupyterTokenEnv            = "JUPYTER_TOKEN"
-	gracefulShutdownTimeoutEnv = "EXECD_API_GRACE_SHUTDOWN"
-)
-
-// InitFlags registers CLI flags and env overrides.
+// InitFlags registers CLI flags and loads the configuration. Each setting comes
+// from, in increasing precedence: its default, the --config file, its EXECD_*
+// environment variable, and the command line.
 func InitFlags() {
-	// Set default values
-	ServerPort = 44772
-	ServerLogLevel = 6
-	ServerAccessToken = ""
-	ApiGracefulShutdownTimeout = time.Second * 1
-
-	// First, set default values from environment variables
-	if jupyterFromEnv := os.Getenv(jupyterHostEnv); jupyterFromEnv != "" {
-		if !strings.HasPrefix(jupyterFromEnv, "http://") && !strings.HasPrefix(jupyterFromEnv, "https://") {
-			stdlog.Panic("Invalid JUPYTER_HOST format: must start with http:// or https://")
-		}
-		JupyterServerHost = jupyterFromEnv
+	register(flag.CommandLine)
+	err := load(flag.CommandLine, os.Args[1:], os.LookupEnv)
+	// the version is printed even when the rest of the configuration is broken.
+	if ShowVersion {
+		fmt.Println(version.Get())
+		os.Exit(0)
 	}
-
-	if jupyterTokenFromEnv := os.Getenv(jupyterTokenEnv); jupyterTokenFromEnv != "" {
-		JupyterServerToken = jupyterTokenFromEnv
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid configuration:\n%v\n", err)
+		os.Exit(2)
 	}
-
-	// Then define flags with current values as defaults
-	flag.StringVar(&JupyterServerHost, "jupyter-host", JupyterServerHost, "Jupyter server host address (e.g., http://localhost, http://192.168.1.100)")
-	flag.StringVar(&JupyterServerToken, "jupyter-token", JupyterServerToken, "Jupyter server authentication token")
-	flag.IntVar(&ServerPort, "port", ServerPort, "Server listening port (default: 44772)")
-	flag.IntVar(&ServerLogLevel, "log-level", ServerLogLevel, "Server log level (0=LevelEmergency, 1=LevelAlert, 2=LevelCritical, 3=LevelError, 4=LevelWarning, 5=LevelNotice, 6=LevelInformational, 7=LevelDebug, default: 6)")
-	flag.StringVar(&ServerAccessToken, "access-token", ServerAccessToken, "Server access token for API authentication")
-
-	if graceShutdownTimeout := os.Getenv(gracefulShutdownTimeoutEnv); graceShutdownTimeout != "" {
-		duration, err := time.ParseDuration(graceShutdownTimeout)
-		if err != nil {
-			stdlog.Panicf("Failed to parse graceful shutdown timeout from env: %v", err)
+	if PrintConfig {
+		if err := printConfig(os.Stdout, flag.CommandLine); err != nil {
+			fmt.Fprintf(os.Stderr, "print configuration: %v\n", err)
+			os.Exit(1)
 		}
-		ApiGracefulShutdownTimeout = duration
+		os.Exit(0)
 	}
 
-	flag.DurationVar(&ApiGracefulShutdownTimeout, "graceful-shutdown-timeout", ApiGracefulShutdownTimeout, "API graceful shutdown timeout duration (default: 3s)")
-
-	// Parse flags - these will override environment variables if provided
-	flag.Parse()
+	// the tokens and configured variables never show up in logs.
+	log.AddSecrets(JupyterServerToken, ServerAccessToken, ServerAdminToken)
+	log.RedactEnv(strings.Split(LogRedactEnv, ",")...)
 
 	// Log final values
 	log.Info("Jupyter server host is: %s", JupyterServerHost)
 	log.Info("Jupyter server token is: %s", JupyterServerToken)
 }
+
+// register resets every setting to its default and defines its flag on fs.
+func register(fs *flag.FlagSet) {
+	// Set default values
+	JupyterServerHost = ""
+	JupyterServerToken = ""
+	JupyterConnectRetries = 3
+	JupyterConnectRetryDelay = time.Millisecond * 200
+	ConfigFile = ""
+	PrintConfig = false
+	ShowVersion = false
+	ServerPort = 44772
+	ServerLogLevel = "info"
+	LogFile = ""
+	LogMaxSize = 100 << 20
+	LogMaxBackups = 5
+	LogMaxAge = 0
+	LogStdout = false
+	LogRedactEnv = ""
+	LogStreamEventSample = 1
+	ServerAccessToken = ""
+	ServerAdminToken = ""
+	ServerPublicInfo = false
+	ApiGracefulShutdownTimeout = time.Second * 1
+	ApiSSEWriteTimeout = time.Second * 10
+	StreamMaxOutputRate = 0
+	ServerReadHeaderTimeout = time.Second * 10
+	ServerIdleTimeout = time.Second * 120
+	ServerMaxHeaderBytes = 1 << 20
+	ServerWriteTimeout = time.Second * 60
+	ServerHandlerTimeout = 0
+	ServerEnableH2C = false
+	ServerBasePath = ""
+	CommandMaxAddressSpace = -1
+	CommandMaxCPUSeconds = -1
+	CommandMaxOpenFiles = -1
+	CommandMaxCoreSize = -1
+	ServerStrictJSON = false
+	ServerLogBodies = false
+	ServerLogBodyLimit = 4096
+	CommandLogSegmentSize = 0
+	CommandLogCompress = false
+	CommandForwardSignals = "INT,TERM,HUP,QUIT"
+	ContextPreambles = ""
+	CwdAllowedRoot = ""
+	CwdCreateMode = "0755"
+	SQLConnections = ""
+	MetricsDiskPaths = ""
+	MetricsWatchMinInterval = time.Second
+	MetricsWatchMaxInterval = time.Minute
+	MetricsHistorySize = 120
+	MetricsHistoryInterval = time.Second * 5
+	WebSocketAllowedOrigins = ""
+	ProxyAllowedPorts = ""
+	ProxyDeniedPorts = ""
+	ProxyAllowedHosts = ""
+	ProxyInjectBaseHref = false
+	ProxyInjectHeaders = ""
+	ProxyDialTimeout = time.Second * 30
+	ProxyResponseHeaderTimeout = 0
+	ProxyIdleTimeout = time.Second * 600
+	ProxyFlushInterval = time.Millisecond * 200
+	ProxyMaxTimeout = time.Hour
+	ProxyTLSInsecure = false
+	ProxyTLSCAFile = ""
+
+	define(fs)
+}
+
+// define defines the flag of every setting on fs, bound to its variable and
+// defaulting to its current value.
+func define(fs *flag.FlagSet) {
+	fs.StringVar(&ConfigFile, "config", ConfigFile, "YAML file of settings keyed by flag name, e.g. \"port: 44772\" (default: $EXECD_CONFIG)")
+	fs.BoolVar(&PrintConfig, "print-config", PrintConfig, "Print the effective configuration as YAML, with secrets masked, and exit")
+	fs.BoolVar(&ShowVersion, "version", ShowVersion, "Print the build version and exit")
+	fs.StringVar(&JupyterServerHost, "jupyter-host", JupyterServerHost, "Jupyter server host address (e.g., http://localhost, http://192.168.1.100)")
+	fs.StringVar(&JupyterServerToken, "jupyter-token", JupyterServerToken, "Jupyter server authentication token")
+	fs.IntVar(&JupyterConnectRetries, "jupyter-connect-retries", JupyterConnectRetries, "Retries of a failed connection to a kernel's websocket, such as a 503 right after the kernel started, 0 disables them (default: 3)")
+	fs.DurationVar(&JupyterConnectRetryDelay, "jupyter-connect-retry-delay", JupyterConnectRetryDelay, "Wait before the first kernel connection retry, doubled after each one (default: 200ms)")
+	fs.IntVar(&ServerPort, "port", ServerPort, "Server listening port (default: 44772)")
+	fs.StringVar(&ServerLogLevel, "log-level", ServerLogLevel, "Log level (debug, info, warn, error, or the legacy 0-7), or per component levels such as proxy=debug,runtime=warn,default=info (default: info)")
+	fs.StringVar(&LogFile, "log-file", LogFile, "Write logs to this file instead of stdout")
+	fs.Int64Var(&LogMaxSize, "log-max-size", LogMaxSize, "Rotate the log file once it would exceed this many bytes, 0 disables rotation (default: 104857600)")
+	fs.IntVar(&LogMaxBackups, "log-max-backups", LogMaxBackups, "Number of rotated log files kept, 0 keeps all (default: 5)")
+	fs.DurationVar(&LogMaxAge, "log-max-age", LogMaxAge, "Remove rotated log files older than this, 0 keeps them regardless of age (default: 0)")
+	fs.BoolVar(&LogStdout, "log-stdout", LogStdout, "Also write logs to stdout when --log-file is set")
+	fs.StringVar(&LogRedactEnv, "log-redact-env", LogRedactEnv, "Comma separated environment variable names whose values are masked in logs, e.g. OPENAI_API_KEY,GITHUB_TOKEN")
+	fs.IntVar(&LogStreamEventSample, "log-stream-event-sample", LogStreamEventSample, "Log one in N events of each code or command stream, 0 disables event logging (default: 1)")
+	fs.StringVar(&ServerAccessToken, "access-token", ServerAccessToken, "Server access token for API authentication")
+	fs.StringVar(&ServerAdminToken, "admin-token", ServerAdminToken, "Token sent in X-EXECD-ADMIN-TOKEN to authorize admin requests such as GET /env?reveal=true (default: admin requests disabled)")
+	fs.BoolVar(&ServerPublicInfo, "public-info", ServerPublicInfo, "Serve GET /info without the access token")
+
+	fs.DurationVar(&ApiGracefulShutdownTimeout, "graceful-shutdown-timeout", ApiGracefulShutdownTimeout, "API graceful shutdown timeout duration (default: 3s)")
+
+	fs.DurationVar(&ApiSSEWriteTimeout, "sse-write-timeout", ApiSSEWriteTimeout, "Deadline for writing a single SSE event before the client is treated as disconnected, 0 disables it (default: 10s)")
+	fs.IntVar(&StreamMaxOutputRate, "stream-max-output-rate", StreamMaxOutputRate, "Maximum stdout and stderr events per second of a code or command stream; excess output is dropped and reported, 0 is unlimited (default: 0)")
+	fs.DurationVar(&ServerReadHeaderTimeout, "read-header-timeout", ServerReadHeaderTimeout, "Maximum duration for reading request headers (default: 10s)")
+	fs.DurationVar(&ServerIdleTimeout, "idle-timeout", ServerIdleTimeout, "Maximum idle duration of keep-alive connections (default: 120s)")
+	fs.IntVar(&ServerMaxHeaderBytes, "max-header-bytes", ServerMaxHeaderBytes, "Maximum size of request headers in bytes (default: 1048576)")
+	fs.DurationVar(&ServerWriteTimeout, "write-timeout", ServerWriteTimeout, "Write deadline for non-streaming responses, 0 disables it (default: 60s)")
+	fs.DurationVar(&ServerHandlerTimeout, "handler-timeout", ServerHandlerTimeout, "Cancel requests of non-streaming routes running longer than this with a 503, 0 disables it (default: 0)")
+	fs.BoolVar(&ServerStrictJSON, "strict-json", ServerStrictJSON, "Reject request bodies with unknown JSON fields (per request via X-Strict-Validation header)")
+	fs.BoolVar(&ServerLogBodies, "log-bodies", ServerLogBodies, "Log request and response bodies with secrets redacted; file transfers, streams and the proxy are never logged")
+	fs.IntVar(&ServerLogBodyLimit, "log-body-limit", ServerLogBodyLimit, "Maximum bytes of each body logged by --log-bodies (default: 4096)")
+	fs.BoolVar(&ServerEnableH2C, "enable-h2c", ServerEnableH2C, "Serve HTTP/2 over cleartext (h2c) for clients behind trusted proxies")
+	fs.StringVar(&ServerBasePath, "base-path", ServerBasePath, "Path prefix all routes are mounted under, e.g. /execd (default: none)")
+	fs.Int64Var(&CommandMaxAddressSpace, "command-max-address-space", CommandMaxAddressSpace, "Default and maximum virtual memory of shell commands in bytes, -1 is unlimited (default: -1)")
+	fs.Int64Var(&CommandMaxCPUSeconds, "command-max-cpu-seconds", CommandMaxCPUSeconds, "Default and maximum CPU seconds of shell commands, -1 is unlimited (default: -1)")
+	fs.Int64Var(&CommandMaxOpenFiles, "command-max-open-files", CommandMaxOpenFiles, "Default and maximum open files of shell commands, -1 is unlimited (default: -1)")
+	fs.Int64Var(&CommandMaxCoreSize, "command-max-core-size", CommandMaxCoreSize, "Default and maximum core dump size of shell commands in bytes, 0 disables core dumps, -1 is unlimited (default: -1)")
+	fs.Int64Var(&CommandLogSegmentSize, "command-log-segment-size", CommandLogSegmentSize, "Split background command logs into segments of this many bytes, 0 keeps a single file (default: 0)")
+	fs.BoolVar(&CommandLogCompress, "command-log-compress", CommandLogCompress, "Gzip full background command log segments; reads decompress them transparently")
+	fs.StringVar(&CommandForwardSignals, "command-forward-signals", CommandForwardSignals, "Comma separated signals execd relays to the process group of foreground commands, empty forwards none (default: INT,TERM,HUP,QUIT)")
+	fs.StringVar(&CwdAllowedRoot, "cwd-allowed-root", CwdAllowedRoot, "Directory the working directories of commands and contexts must lie within; empty allows any")
+	fs.StringVar(&CwdCreateMode, "cwd-create-mode", CwdCreateMode, "Octal permissions of working directories created for requests setting create_cwd (default: 0755)")
+	fs.StringVar(&SQLConnections, "sql-connections", SQLConnections, "Databases sql code may pick by name besides the local default, e.g. \"analytics=mysql:user:pass@tcp(db:3306)/analytics;reports=mysql:...\"")
+	fs.StringVar(&ContextPreambles, "context-preambles", ContextPreambles, "Code files run in every new context of a language, e.g. python=/etc/execd/preamble.py,bash=/etc/execd/preamble.sh")
+	fs.StringVar(&MetricsDiskPaths, "metrics-disk-paths", MetricsDiskPaths, "Comma separated paths whose disk usage is reported by the metrics API (default: / and the working directory)")
+	fs.DurationVar(&MetricsWatchMinInterval, "metrics-watch-min-interval", MetricsWatchMinInterval, "Smallest interval a /metrics/watch client may request (default: 1s)")
+	fs.DurationVar(&MetricsWatchMaxInterval, "metrics-watch-max-interval", MetricsWatchMaxInterval, "Largest interval a /metrics/watch client may request (default: 1m)")
+	fs.IntVar(&MetricsHistorySize, "metrics-history-size", MetricsHistorySize, "Number of metric samples kept for /metrics/history, 0 disables the history (default: 120)")
+	fs.DurationVar(&MetricsHistoryInterval, "metrics-history-interval", MetricsHistoryInterval, "Interval between metric samples kept for /metrics/history, at least 1s (default: 5s)")
+	fs.StringVar(&WebSocketAllowedOrigins, "websocket-allowed-origins", WebSocketAllowedOrigins, "Comma separated origins allowed to open websockets, e.g. https://app.example.com, or * for any (default: same origin only)")
+	fs.StringVar(&ProxyAllowedPorts, "proxy-allowed-ports", ProxyAllowedPorts, "Comma separated ports or ranges the proxy may reach, e.g. 3000-3999,8080 (default: all)")
+	fs.StringVar(&ProxyDeniedPorts, "proxy-denied-ports", ProxyDeniedPorts, "Comma separated ports or ranges the proxy must not reach; execd's own port is always denied")
+	fs.StringVar(&ProxyAllowedHosts, "proxy-allowed-hosts", ProxyAllowedHosts, "Comma separated hostnames, IPs or CIDRs reachable via /proxy/host:port/ (default: none, localhost only)")
+	fs.BoolVar(&ProxyInjectBaseHref, "proxy-inject-base-href", ProxyInjectBaseHref, "Inject <base href=\"/proxy/<target>/\"> into proxied HTML pages")
+	fs.StringVar(&ProxyInjectHeaders, "proxy-inject-headers", ProxyInjectHeaders, "Static headers added to requests proxied to a local port, e.g. \"3000:Authorization=Bearer abc;8080:X-Token=t\"")
+	fs.DurationVar(&ProxyDialTimeout, "proxy-dial-timeout", ProxyDialTimeout, "Timeout for connecting to a proxy upstream (default: 30s)")
+	fs.DurationVar(&ProxyResponseHeaderTimeout, "proxy-response-header-timeout", ProxyResponseHeaderTimeout, "Timeout for upstream response headers, 0 disables it (default: 0)")
+	fs.DurationVar(&ProxyIdleTimeout, "proxy-idle-timeout", ProxyIdleTimeout, "Idle timeout of pooled upstream connections (default: 600s)")
+	fs.DurationVar(&ProxyFlushInterval, "proxy-flush-interval", ProxyFlushInterval, "Flush interval for proxied responses of known length; event streams always flush immediately (default: 200ms)")
+	fs.DurationVar(&ProxyMaxTimeout, "proxy-max-timeout", ProxyMaxTimeout, "Upper bound for the per-request X-Proxy-Timeout header, 0 means uncapped (default: 1h)")
+	fs.BoolVar(&ProxyTLSInsecure, "proxy-tls-insecure", ProxyTLSInsecure, "Skip certificate verification of HTTPS upstreams reached via /proxy/https/<target>/")
+	fs.StringVar(&ProxyTLSCAFile, "proxy-tls-ca-file", ProxyTLSCAFile, "PEM CA bundle trusted for HTTPS upstreams in addition to the system roots")
+}
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"time"
 
 	"github.com/alibaba/opensandbox/execd/pkg/jupyter/auth"
 	"github.com/alibaba/opensandbox/execd/pkg/jupyter/execute"
@@ -35,6 +36,10 @@ type Client struct {
 	sessionClient *session.Client
 	executeClient *execute.Client
 	authClient    *auth.Client
+
+	// connectRetries and connectRetryDelay bound the retries of ConnectToKernel.
+	connectRetries    int
+	connectRetryDelay time.Duration
 }
 
 type ClientOption func(*Client)
@@ -61,6 +66,16 @@ func WithBasicAuth(username, password string) ClientOption {
 	}
 }
 
+// WithConnectRetry makes ConnectToKernel retry a failed connection up to
+// retries times, waiting delay before the first retry and doubling it after
+// each one. The channels endpoint of a kernel that just started may answer 503.
+func WithConnectRetry(retries int, delay time.Duration) ClientOption {
+	return func(c *Client) {
+		c.connectRetries = retries
+		c.connectRetryDelay = delay
+	}
+}
+
 // NewClient creates a new Jupyter client instance.
 func NewClient(baseURL string, options ...ClientOption) *Client {
 	client := &Client{
@@ -132,6 +147,12 @@ func (c *Client) InterruptKernel(kernelId string) error {
 	return c.kernelClient.InterruptKernel(kernelId)
 }
 
+// InterruptExecution sends an interrupt_request over the control channel of the
+// connected kernel, for kernels that are interrupted by message rather than signal.
+func (c *Client) InterruptExecution(timeout time.Duration) error {
+	return c.executeClient.Interrupt(timeout)
+}
+
 // ShutdownKernel shuts down (and optionally restarts) the specified kernel.
 func (c *Client) ShutdownKernel(kernelId string, restart bool) error {
 	return c.kernelClient.ShutdownKernel(kernelId, restart)
@@ -162,7 +183,8 @@ func (c *Client) DeleteSession(sessionId string) error {
 	return c.sessionClient.DeleteSession(sessionId)
 }
 
-// ConnectToKernel establishes a websocket connection to the kernel.
+// ConnectToKernel establishes a websocket connection to the kernel, retrying
+// failed attempts as configured by WithConnectRetry.
 func (c *Client) ConnectToKernel(kernelId string) error {
 	parsedURL, err := url.Parse(c.BaseURL)
 	if err != nil {
@@ -180,7 +202,26 @@ func (c *Client) ConnectToKernel(kernelId string) error {
 		wsURL = fmt.Sprintf("%s?token=%s", wsURL, c.Auth.Token)
 	}
 
-	return c.executeClient.Connect(wsURL)
+	delay := c.connectRetryDelay
+	for attempt := 0; ; attempt++ {
+		err = c.executeClient.Connect(wsURL)
+		if err == nil || attempt >= c.connectRetries || !retryableConnectError(err) {
+			return err
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// retryableConnectError reports whether a failed kernel connection may succeed
+// later: network errors and 5xx answers may, rejected credentials or an
+// unknown kernel won't.
+func retryableConnectError(err error) bool {
+	var upgradeErr *execute.UpgradeError
+	if errors.As(err, &upgradeErr) {
+		return upgradeErr.StatusCode >= http.StatusInternalServerError
+	}
+	return true
 }
 
 // DisconnectFromKernel closes the websocket connection.
@@ -188,6 +229,12 @@ func (c *Client) DisconnectFromKernel(kernelId string) {
 	c.executeClient.Disconnect()
 }
 
+// Closed returns a channel closed once the websocket connection to the
+// kernel stops receiving messages.
+func (c *Client) Closed() <-chan struct{} {
+	return c.executeClient.Closed()
+}
+
 // ExecuteCodeStream streams execution results into resultChan.
 func (c *Client) ExecuteCodeStream(kernelId, code string, resultChan chan *execute.ExecutionResult) error {
 	return c.executeClient.ExecuteCodeStream(code, resultChan)
@@ -197,3 +244,33 @@ func (c *Client) ExecuteCodeStream(kernelId, code string, resultChan chan *execu
 func (c *Client) ExecuteCodeWithCallback(code string, handler execute.CallbackHandler) error {
 	return c.executeClient.ExecuteCodeWithCallback(code, handler)
 }
+
+// KernelInfo queries language and implementation details of the connected kernel.
+func (c *Client) KernelInfo(timeout time.Duration) (*execute.KernelInfoReply, error) {
+	return c.executeClient.KernelInfo(timeout)
+}
+
+// Complete returns completion matches for the cursor position in code.
+func (c *Client) Complete(code string, cursorPos int, timeout time.Duration) (*execute.CompleteReply, error) {
+	return c.executeClient.Complete(code, cursorPos, timeout)
+}
+
+// Inspect returns documentation of the object at the cursor position in code.
+func (c *Client) Inspect(code string, cursorPos, detailLevel int, timeout time.Duration) (*execute.InspectReply, error) {
+	return c.executeClient.Inspect(code, cursorPos, detailLevel, timeout)
+}
+
+// History returns entries of the kernel's execution history.
+func (c *Client) History(request *execute.HistoryRequest, timeout time.Duration) ([]execute.HistoryEntry, error) {
+	return c.executeClient.History(request, timeout)
+}
+
+// EvaluateExpressions evaluates user expressions without touching execution history.
+func (c *Client) EvaluateExpressions(expressions map[string]string, timeout time.Duration) (map[string]execute.UserExpressionResult, error) {
+	return c.executeClient.EvaluateExpressions(expressions, timeout)
+}
+
+// ExecuteSilently runs setup code on the kernel without publishing outputs.
+func (c *Client) ExecuteSilently(code string, timeout time.Duration) error {
+	return c.executeClient.ExecuteSilently(code, timeout)
+}
@@ -0,0 +1,99 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jupyter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// newFlakyChannelsServer answers the first failures upgrades of the kernel
+// channels with status, then accepts them, counting the attempts.
+func newFlakyChannelsServer(t *testing.T, failures int32, status int, attempts *atomic.Int32) *httptest.Server {
+	t.Helper()
+	upgrader := websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) <= failures {
+			http.Error(w, "kernel not ready", status)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestConnectToKernel_RetriesUnavailableChannels(t *testing.T) {
+	var attempts atomic.Int32
+	server := newFlakyChannelsServer(t, 2, http.StatusServiceUnavailable, &attempts)
+
+	client := NewClient(server.URL, WithToken("token"), WithConnectRetry(3, time.Millisecond))
+	if err := client.ConnectToKernel("kernel-1"); err != nil {
+		t.Fatalf("expected the connection to succeed after retries, got %v", err)
+	}
+	defer client.DisconnectFromKernel("kernel-1")
+	if got := attempts.Load(); got != 3 {
+		t.Fatalf("expected 3 attempts, got %d", got)
+	}
+}
+
+func TestConnectToKernel_BoundsRetries(t *testing.T) {
+	var attempts atomic.Int32
+	server := newFlakyChannelsServer(t, 10, http.StatusServiceUnavailable, &attempts)
+
+	client := NewClient(server.URL, WithToken("token"), WithConnectRetry(2, time.Millisecond))
+	if err := client.ConnectToKernel("kernel-1"); err == nil {
+		t.Fatal("expected the connection to fail once the retries ran out")
+	}
+	if got := attempts.Load(); got != 3 {
+		t.Fatalf("expected 1 attempt and 2 retries, got %d attempts", got)
+	}
+
+	// without the option a connection is attempted once.
+	attempts.Store(0)
+	if err := NewClient(server.URL).ConnectToKernel("kernel-1"); err == nil {
+		t.Fatal("expected the connection to fail")
+	}
+	if got := attempts.Load(); got != 1 {
+		t.Fatalf("expected a single attempt, got %d", got)
+	}
+}
+
+func TestConnectToKernel_DoesNotRetryClientErrors(t *testing.T) {
+	var attempts atomic.Int32
+	server := newFlakyChannelsServer(t, 10, http.StatusForbidden, &attempts)
+
+	client := NewClient(server.URL, WithToken("wrong"), WithConnectRetry(3, time.Millisecond))
+	if err := client.ConnectToKernel("kernel-1"); err == nil {
+		t.Fatal("expected the connection to be rejected")
+	}
+	if got := attempts.Load(); got != 1 {
+		t.Fatalf("a rejected connection must not be retried, got %d attempts", got)
+	}
+}
@@ -54,6 +54,24 @@ type Client struct {
 
 	// WebSocket URL for kernel connection
 	wsURL string
+
+	// closed is closed once the current connection stops receiving messages
+	closed chan struct{}
+}
+
+// UpgradeError is returned by Connect when the server answered the websocket
+// upgrade with an HTTP error instead of switching protocols
+type UpgradeError struct {
+	StatusCode int
+	Err        error
+}
+
+func (e *UpgradeError) Error() string {
+	return fmt.Sprintf("%v (HTTP %d)", e.Err, e.StatusCode)
+}
+
+func (e *UpgradeError) Unwrap() error {
+	return e.Err
 }
 
 // NewClient creates a new code execution client
@@ -78,6 +96,7 @@ func (c *Client) Connect(wsURL string) error {
 	conn, resp, err := websocket.DefaultDialer.Dial(wsURL, nil)
 	if resp != nil && err != nil {
 		resp.Body.Close()
+		return fmt.Errorf("failed to connect to kernel: %w", &UpgradeError{StatusCode: resp.StatusCode, Err: err})
 	}
 	if err != nil {
 		return fmt.Errorf("failed to connect to kernel: %w", err)
@@ -88,7 +107,8 @@ func (c *Client) Connect(wsURL string) error {
 	c.registerDefaultHandlers()
 
 	// Start message receiving goroutine
-	go c.receiveMessages()
+	c.closed = make(chan struct{})
+	go c.receiveMessages(c.closed)
 
 	return nil
 }
@@ -111,6 +131,14 @@ func (c *Client) IsConnected() bool {
 	return c.conn != nil
 }
 
+// Closed returns a channel closed once the current connection stops
+// receiving messages, because it was disconnected or the kernel dropped it
+func (c *Client) Closed() <-chan struct{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
 // ExecuteCodeStream executes code in streaming mode, sending results to the provided channel
 func (c *Client) ExecuteCodeStream(code string, resultChan chan *ExecutionResult) error {
 	if !c.IsConnected() {
@@ -250,6 +278,14 @@ func (c *Client) ExecuteCodeStream(code string, resultChan chan *ExecutionResult
 			return
 		}
 
+		if status.ExecutionState == StateDead || status.ExecutionState == StateRestarting {
+			// the kernel died, no reply of this execution will follow
+			resultMutex.Lock()
+			resultChan <- &ExecutionResult{Status: string(status.ExecutionState)}
+			resultMutex.Unlock()
+			return
+		}
+
 		if status.ExecutionState == StateIdle {
 			executeMutex.Lock()
 
@@ -427,7 +463,8 @@ func (c *Client) clearTemporaryHandlers() {
 }
 
 // Receive WebSocket messages
-func (c *Client) receiveMessages() {
+func (c *Client) receiveMessages(closed chan struct{}) {
+	defer close(closed)
 	for {
 		c.mu.Lock()
 		conn := c.conn
@@ -156,3 +156,179 @@ func TestExecuteCodeStream(t *testing.T) {
 		t.Errorf("expected at least 4 results, got %d", resultCount)
 	}
 }
+
+// Test completion and inspection requests
+func TestCompleteAndInspect(t *testing.T) {
+	server := createTestServer(t, func(conn *websocket.Conn) {
+		for {
+			var request Message
+			if err := conn.ReadJSON(&request); err != nil {
+				return
+			}
+
+			var msgType MessageType
+			var content interface{}
+			switch MessageType(request.Header.MessageType) {
+			case MsgCompleteRequest:
+				var complete CompleteRequest
+				if err := json.Unmarshal(request.Content, &complete); err != nil {
+					t.Errorf("failed to parse complete request: %v", err)
+					return
+				}
+				if complete.Code != "import o" || complete.CursorPos != 8 {
+					t.Errorf("unexpected complete request: %+v", complete)
+				}
+				msgType = MsgCompleteReply
+				content = CompleteReply{Status: "ok", Matches: []string{"os", "operator"}, CursorStart: 7, CursorEnd: 8}
+			case MsgInspectRequest:
+				var inspect InspectRequest
+				if err := json.Unmarshal(request.Content, &inspect); err != nil {
+					t.Errorf("failed to parse inspect request: %v", err)
+					return
+				}
+				if inspect.DetailLevel != 1 {
+					t.Errorf("unexpected inspect request: %+v", inspect)
+				}
+				msgType = MsgInspectReply
+				content = InspectReply{Status: "ok", Found: true, Data: map[string]interface{}{"text/plain": "Signature: len(obj, /)"}}
+			default:
+				t.Errorf("unexpected message type %s", request.Header.MessageType)
+				return
+			}
+
+			raw, _ := json.Marshal(content)
+			conn.WriteJSON(Message{
+				Header: Header{
+					MessageID:   "reply-" + request.Header.MessageID,
+					Session:     request.Header.Session,
+					MessageType: string(msgType),
+				},
+				ParentHeader: request.Header,
+				Content:      json.RawMessage(raw),
+			})
+		}
+	})
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/kernels/test-kernel-id/channels"
+	client := NewClient("", nil)
+	if err := client.Connect(wsURL); err != nil {
+		t.Fatalf("failed to connect to WebSocket: %v", err)
+	}
+	defer client.Disconnect()
+
+	complete, err := client.Complete("import o", 8, 5*time.Second)
+	if err != nil {
+		t.Fatalf("complete failed: %v", err)
+	}
+	if len(complete.Matches) != 2 || complete.Matches[0] != "os" || complete.CursorStart != 7 || complete.CursorEnd != 8 {
+		t.Errorf("unexpected complete reply: %+v", complete)
+	}
+
+	inspect, err := client.Inspect("len(", 3, 1, 5*time.Second)
+	if err != nil {
+		t.Fatalf("inspect failed: %v", err)
+	}
+	if !inspect.Found || inspect.Data["text/plain"] != "Signature: len(obj, /)" {
+		t.Errorf("unexpected inspect reply: %+v", inspect)
+	}
+}
+
+// Test fetching the execution history
+func TestHistory(t *testing.T) {
+	server := createTestServer(t, func(conn *websocket.Conn) {
+		var request Message
+		if err := conn.ReadJSON(&request); err != nil {
+			t.Errorf("failed to read history request: %v", err)
+			return
+		}
+		if request.Header.MessageType != string(MsgHistoryRequest) {
+			t.Errorf("unexpected message type %s", request.Header.MessageType)
+			return
+		}
+		var history HistoryRequest
+		if err := json.Unmarshal(request.Content, &history); err != nil {
+			t.Errorf("failed to parse history request: %v", err)
+			return
+		}
+		if history.AccessType != HistoryTail || history.N != 3 || !history.Output {
+			t.Errorf("unexpected history request: %+v", history)
+		}
+
+		conn.WriteJSON(Message{
+			Header: Header{
+				MessageID:   "history-reply-id",
+				Session:     request.Header.Session,
+				MessageType: string(MsgHistoryReply),
+			},
+			ParentHeader: request.Header,
+			Content: json.RawMessage(`{"status": "ok", "history": [
+				[1, 1, ["a = 1", null]],
+				[1, 2, ["a + 1", "2"]],
+				[2, 1, "print(a)"]
+			]}`),
+		})
+	})
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/kernels/test-kernel-id/channels"
+	client := NewClient("", nil)
+	if err := client.Connect(wsURL); err != nil {
+		t.Fatalf("failed to connect to WebSocket: %v", err)
+	}
+	defer client.Disconnect()
+
+	entries, err := client.History(&HistoryRequest{AccessType: HistoryTail, N: 3, Output: true}, 5*time.Second)
+	if err != nil {
+		t.Fatalf("history failed: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 history entries, got %d", len(entries))
+	}
+	if entries[0].Session != 1 || entries[0].LineNumber != 1 || entries[0].Input != "a = 1" || entries[0].Output != nil {
+		t.Errorf("unexpected first entry: %+v", entries[0])
+	}
+	if entries[1].Input != "a + 1" || entries[1].Output == nil || *entries[1].Output != "2" {
+		t.Errorf("unexpected second entry: %+v", entries[1])
+	}
+	if entries[2].Session != 2 || entries[2].Input != "print(a)" || entries[2].Output != nil {
+		t.Errorf("unexpected third entry: %+v", entries[2])
+	}
+}
+
+func TestInterrupt(t *testing.T) {
+	server := createTestServer(t, func(conn *websocket.Conn) {
+		var request Message
+		if err := conn.ReadJSON(&request); err != nil {
+			t.Errorf("failed to read interrupt request: %v", err)
+			return
+		}
+		if request.Header.MessageType != string(MsgInterruptRequest) || request.Channel != "control" {
+			t.Errorf("unexpected %s message on channel %s", request.Header.MessageType, request.Channel)
+			return
+		}
+
+		conn.WriteJSON(Message{
+			Header: Header{
+				MessageID:   "interrupt-reply-id",
+				Session:     request.Header.Session,
+				MessageType: string(MsgInterruptReply),
+			},
+			ParentHeader: request.Header,
+			Content:      json.RawMessage(`{"status": "ok"}`),
+			Channel:      "control",
+		})
+	})
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/kernels/test-kernel-id/channels"
+	client := NewClient("", nil)
+	if err := client.Connect(wsURL); err != nil {
+		t.Fatalf("failed to connect to WebSocket: %v", err)
+	}
+	defer client.Disconnect()
+
+	if err := client.Interrupt(5 * time.Second); err != nil {
+		t.Fatalf("interrupt failed: %v", err)
+	}
+}
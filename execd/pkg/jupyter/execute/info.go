@@ -0,0 +1,244 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package execute
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// KernelInfo sends a kernel_info_request and waits for the matching reply
+func (c *Client) KernelInfo(timeout time.Duration) (*KernelInfoReply, error) {
+	msg, err := c.newShellMessage(MsgKernelInfo, struct{}{})
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := c.request(msg, MsgKernelInfoReply, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	var info KernelInfoReply
+	if err := json.Unmarshal(reply.Content, &info); err != nil {
+		return nil, fmt.Errorf("failed to parse kernel info reply: %w", err)
+	}
+	return &info, nil
+}
+
+// Complete sends a complete_request for the cursor position in code and waits for the reply
+func (c *Client) Complete(code string, cursorPos int, timeout time.Duration) (*CompleteReply, error) {
+	msg, err := c.newShellMessage(MsgCompleteRequest, &CompleteRequest{Code: code, CursorPos: cursorPos})
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := c.request(msg, MsgCompleteReply, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	var complete CompleteReply
+	if err := json.Unmarshal(reply.Content, &complete); err != nil {
+		return nil, fmt.Errorf("failed to parse complete reply: %w", err)
+	}
+	if complete.Status == "error" {
+		return nil, fmt.Errorf("completion failed: %s: %s", complete.EName, complete.EValue)
+	}
+	return &complete, nil
+}
+
+// Inspect sends an inspect_request for the object at the cursor position in code and waits for the reply
+func (c *Client) Inspect(code string, cursorPos, detailLevel int, timeout time.Duration) (*InspectReply, error) {
+	msg, err := c.newShellMessage(MsgInspectRequest, &InspectRequest{Code: code, CursorPos: cursorPos, DetailLevel: detailLevel})
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := c.request(msg, MsgInspectReply, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	var inspect InspectReply
+	if err := json.Unmarshal(reply.Content, &inspect); err != nil {
+		return nil, fmt.Errorf("failed to parse inspect reply: %w", err)
+	}
+	if inspect.Status == "error" {
+		return nil, fmt.Errorf("inspection failed: %s: %s", inspect.EName, inspect.EValue)
+	}
+	return &inspect, nil
+}
+
+// History sends a history_request and returns the entries of the history_reply
+func (c *Client) History(request *HistoryRequest, timeout time.Duration) ([]HistoryEntry, error) {
+	msg, err := c.newShellMessage(MsgHistoryRequest, request)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := c.request(msg, MsgHistoryReply, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	var history HistoryReply
+	if err := json.Unmarshal(reply.Content, &history); err != nil {
+		return nil, fmt.Errorf("failed to parse history reply: %w", err)
+	}
+	if history.Status == "error" {
+		return nil, fmt.Errorf("history request failed: %s: %s", history.EName, history.EValue)
+	}
+	return history.History, nil
+}
+
+// EvaluateExpressions evaluates user expressions through a silent execute_request,
+// leaving the execution counter and history of the kernel untouched
+func (c *Client) EvaluateExpressions(expressions map[string]string, timeout time.Duration) (map[string]UserExpressionResult, error) {
+	reply, err := c.executeSilently("", expressions, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return reply.UserExpressions, nil
+}
+
+// ExecuteSilently runs setup code without publishing outputs or recording history
+func (c *Client) ExecuteSilently(code string, timeout time.Duration) error {
+	_, err := c.executeSilently(code, nil, timeout)
+	return err
+}
+
+// executeSilently sends a silent execute_request and waits for its execute_reply
+func (c *Client) executeSilently(code string, expressions map[string]string, timeout time.Duration) (*ExecuteReply, error) {
+	if expressions == nil {
+		expressions = make(map[string]string)
+	}
+	request := &ExecuteRequest{
+		Code:            code,
+		Silent:          true,
+		StoreHistory:    false,
+		UserExpressions: expressions,
+		AllowStdin:      false,
+		StopOnError:     false,
+	}
+
+	msg, err := c.newShellMessage(MsgExecuteRequest, request)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := c.request(msg, MsgExecuteReply, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	var execReply ExecuteReply
+	if err := json.Unmarshal(reply.Content, &execReply); err != nil {
+		return nil, fmt.Errorf("failed to parse execute reply: %w", err)
+	}
+	if execReply.Status == "error" {
+		return nil, fmt.Errorf("silent execution failed: %s: %s", execReply.EName, execReply.EValue)
+	}
+	return &execReply, nil
+}
+
+// Interrupt sends an interrupt_request on the control channel and waits for the reply.
+// Unlike the REST interrupt it also reaches kernels whose interrupt mode is "message"
+func (c *Client) Interrupt(timeout time.Duration) error {
+	msg, err := c.newMessage("control", MsgInterruptRequest, struct{}{})
+	if err != nil {
+		return err
+	}
+
+	reply, err := c.request(msg, MsgInterruptReply, timeout)
+	if err != nil {
+		return err
+	}
+
+	var interrupt InterruptReply
+	if err := json.Unmarshal(reply.Content, &interrupt); err != nil {
+		return fmt.Errorf("failed to parse interrupt reply: %w", err)
+	}
+	if interrupt.Status == "error" {
+		return fmt.Errorf("interrupt failed: %s: %s", interrupt.EName, interrupt.EValue)
+	}
+	return nil
+}
+
+// newShellMessage builds a shell channel message carrying the given content
+func (c *Client) newShellMessage(msgType MessageType, content interface{}) (*Message, error) {
+	return c.newMessage("shell", msgType, content)
+}
+
+// newMessage builds a message for the given channel carrying the given content
+func (c *Client) newMessage(channel string, msgType MessageType, content interface{}) (*Message, error) {
+	raw, err := json.Marshal(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize request: %w", err)
+	}
+
+	return &Message{
+		Header: Header{
+			MessageID:   c.nextMessageID(),
+			Username:    "go-client",
+			Session:     c.session,
+			Date:        time.Now().Format(time.RFC3339),
+			MessageType: string(msgType),
+			Version:     "5.3",
+		},
+		ParentHeader: Header{},
+		Metadata:     make(map[string]interface{}),
+		Content:      raw,
+		Channel:      channel,
+	}, nil
+}
+
+// request sends msg and waits for the reply of replyType whose parent is msg
+func (c *Client) request(msg *Message, replyType MessageType, timeout time.Duration) (*Message, error) {
+	if !c.IsConnected() {
+		return nil, errors.New("not connected to kernel, please call Connect method")
+	}
+
+	replies := make(chan *Message, 1)
+	c.registerHandler(replyType, func(reply *Message) {
+		if reply.ParentHeader.MessageID != msg.Header.MessageID {
+			return
+		}
+		select {
+		case replies <- reply:
+		default:
+		}
+	})
+	defer c.registerHandler(replyType, nil)
+
+	c.mu.Lock()
+	err := c.conn.WriteJSON(msg)
+	c.mu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to send %s: %w", msg.Header.MessageType, err)
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case reply := <-replies:
+		return reply, nil
+	case <-timer.C:
+		return nil, fmt.Errorf("timed out waiting for %s", replyType)
+	}
+}
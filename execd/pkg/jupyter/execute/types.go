@@ -69,6 +69,44 @@ const (
 	MsgKernelInfoReply MessageType = "kernel_info_reply"
 
 	MsgExecuteReply MessageType = "execute_reply"
+
+	// MsgCompleteRequest requests completions at a cursor position
+	MsgCompleteRequest MessageType = "complete_request"
+
+	// MsgCompleteReply represents completion matches
+	MsgCompleteReply MessageType = "complete_reply"
+
+	// MsgInspectRequest requests documentation of the object at a cursor position
+	MsgInspectRequest MessageType = "inspect_request"
+
+	// MsgInspectReply represents object documentation
+	MsgInspectReply MessageType = "inspect_reply"
+
+	// MsgHistoryRequest requests entries of the execution history
+	MsgHistoryRequest MessageType = "history_request"
+
+	// MsgHistoryReply represents execution history entries
+	MsgHistoryReply MessageType = "history_reply"
+
+	// MsgInterruptRequest asks the kernel to interrupt the running execution
+	MsgInterruptRequest MessageType = "interrupt_request"
+
+	// MsgInterruptReply acknowledges an interrupt_request
+	MsgInterruptReply MessageType = "interrupt_reply"
+)
+
+// HistoryAccessType selects which history entries a history_request returns
+type HistoryAccessType string
+
+const (
+	// HistoryRange returns the lines between Start and Stop of a session
+	HistoryRange HistoryAccessType = "range"
+
+	// HistoryTail returns the last N lines
+	HistoryTail HistoryAccessType = "tail"
+
+	// HistorySearch returns the lines matching Pattern
+	HistorySearch HistoryAccessType = "search"
 )
 
 // StreamType representsoutput stream type
@@ -94,6 +132,12 @@ const (
 
 	// StateStarting representskernel is starting
 	StateStarting ExecutionState = "starting"
+
+	// StateRestarting is sent by the server when it restarts a kernel that died
+	StateRestarting ExecutionState = "restarting"
+
+	// StateDead is sent by the server when a kernel died and won't be restarted
+	StateDead ExecutionState = "dead"
 )
 
 // Header defines Jupyter message header
@@ -187,6 +231,201 @@ type ExecuteReply struct {
 	Status string `json:"status"`
 
 	ErrorOutput `json:",inline"`
+
+	// UserExpressions contains the evaluated user expressions keyed by name
+	UserExpressions map[string]UserExpressionResult `json:"user_expressions,omitempty"`
+}
+
+// UserExpressionResult represents a single evaluated user expression
+type UserExpressionResult struct {
+	// Status is "ok" or "error"
+	Status string `json:"status"`
+
+	// Data contains the expression value in different formats
+	Data map[string]interface{} `json:"data,omitempty"`
+
+	ErrorOutput `json:",inline"`
+}
+
+// LanguageInfo describes the language implemented by a kernel
+type LanguageInfo struct {
+	// Name is the programming language name
+	Name string `json:"name"`
+
+	// Version is the language version
+	Version string `json:"version"`
+
+	// MimeType is the mimetype for script files in this language
+	MimeType string `json:"mimetype,omitempty"`
+
+	// FileExtension is the extension for script files in this language
+	FileExtension string `json:"file_extension,omitempty"`
+}
+
+// KernelInfoReply represents the content of a kernel_info_reply message
+type KernelInfoReply struct {
+	// Status is "ok" when the request succeeded
+	Status string `json:"status"`
+
+	// ProtocolVersion is the messaging protocol version implemented by the kernel
+	ProtocolVersion string `json:"protocol_version"`
+
+	// Implementation is the kernel implementation name (e.g. ipython)
+	Implementation string `json:"implementation"`
+
+	// ImplementationVersion is the kernel implementation version
+	ImplementationVersion string `json:"implementation_version"`
+
+	// LanguageInfo describes the language of the kernel
+	LanguageInfo LanguageInfo `json:"language_info"`
+
+	// Banner is the kernel startup banner
+	Banner string `json:"banner,omitempty"`
+}
+
+// CompleteRequest represents the content of a complete_request message
+type CompleteRequest struct {
+	// Code is the context the completion is requested in
+	Code string `json:"code"`
+
+	// CursorPos is the cursor position in code, in unicode code points
+	CursorPos int `json:"cursor_pos"`
+}
+
+// CompleteReply represents the content of a complete_reply message
+type CompleteReply struct {
+	// Status is "ok" when the request succeeded
+	Status string `json:"status"`
+
+	// Matches lists the candidate completions
+	Matches []string `json:"matches"`
+
+	// CursorStart and CursorEnd delimit the text replaced by a match
+	CursorStart int `json:"cursor_start"`
+	CursorEnd   int `json:"cursor_end"`
+
+	// Metadata carries kernel specific details, such as match types
+	Metadata map[string]interface{} `json:"metadata"`
+
+	ErrorOutput `json:",inline"`
+}
+
+// InspectRequest represents the content of an inspect_request message
+type InspectRequest struct {
+	// Code is the context the object is looked up in
+	Code string `json:"code"`
+
+	// CursorPos is the cursor position in code, in unicode code points
+	CursorPos int `json:"cursor_pos"`
+
+	// DetailLevel is 0 for a summary or 1 for more detail, e.g. source code
+	DetailLevel int `json:"detail_level"`
+}
+
+// InspectReply represents the content of an inspect_reply message
+type InspectReply struct {
+	// Status is "ok" when the request succeeded
+	Status string `json:"status"`
+
+	// Found tells whether an object was found at the cursor
+	Found bool `json:"found"`
+
+	// Data contains the documentation in different formats
+	Data map[string]interface{} `json:"data"`
+
+	// Metadata contains metadata about the documentation
+	Metadata map[string]interface{} `json:"metadata"`
+
+	ErrorOutput `json:",inline"`
+}
+
+// HistoryRequest represents the content of a history_request message
+type HistoryRequest struct {
+	// Output asks for the outputs along with the inputs
+	Output bool `json:"output"`
+
+	// Raw asks for the raw input rather than the transformed one
+	Raw bool `json:"raw"`
+
+	// AccessType selects between range, tail and search access
+	AccessType HistoryAccessType `json:"hist_access_type"`
+
+	// Session, Start and Stop select the lines of a range access; a Session of 0
+	// is the current session and negative values count back from it
+	Session int `json:"session,omitempty"`
+	Start   int `json:"start,omitempty"`
+	Stop    int `json:"stop,omitempty"`
+
+	// N is the number of lines of a tail or search access
+	N int `json:"n,omitempty"`
+
+	// Pattern is the glob of a search access
+	Pattern string `json:"pattern,omitempty"`
+
+	// Unique drops duplicate lines of a search access
+	Unique bool `json:"unique,omitempty"`
+}
+
+// HistoryEntry is a single line of the execution history
+type HistoryEntry struct {
+	// Session is the number of the session the line was executed in
+	Session int `json:"session"`
+
+	// LineNumber is the execution counter of the line within its session
+	LineNumber int `json:"line_number"`
+
+	// Input is the executed code
+	Input string `json:"input"`
+
+	// Output is the output of the line, only set when it was requested
+	Output *string `json:"output,omitempty"`
+}
+
+// UnmarshalJSON decodes the (session, line, input) or (session, line, (input, output))
+// tuples a kernel replies with
+func (e *HistoryEntry) UnmarshalJSON(data []byte) error {
+	var tuple []json.RawMessage
+	if err := json.Unmarshal(data, &tuple); err != nil {
+		return err
+	}
+	if len(tuple) != 3 {
+		return fmt.Errorf("history entry has %d fields, expected 3", len(tuple))
+	}
+	if err := json.Unmarshal(tuple[0], &e.Session); err != nil {
+		return fmt.Errorf("invalid history session: %w", err)
+	}
+	if err := json.Unmarshal(tuple[1], &e.LineNumber); err != nil {
+		return fmt.Errorf("invalid history line number: %w", err)
+	}
+
+	if err := json.Unmarshal(tuple[2], &e.Input); err == nil {
+		return nil
+	}
+	var inout []*string
+	if err := json.Unmarshal(tuple[2], &inout); err != nil || len(inout) != 2 || inout[0] == nil {
+		return fmt.Errorf("invalid history input: %s", tuple[2])
+	}
+	e.Input, e.Output = *inout[0], inout[1]
+	return nil
+}
+
+// HistoryReply represents the content of a history_reply message
+type HistoryReply struct {
+	// Status is "ok" when the request succeeded
+	Status string `json:"status"`
+
+	// History lists the requested entries in execution order
+	History []HistoryEntry `json:"history"`
+
+	ErrorOutput `json:",inline"`
+}
+
+// InterruptReply represents the content of an interrupt_reply message
+type InterruptReply struct {
+	// Status is "ok" when the request succeeded
+	Status string `json:"status"`
+
+	ErrorOutput `json:",inline"`
 }
 
 // DisplayData representsdata to display
@@ -18,11 +18,15 @@ package kernel
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 )
 
+// ErrKernelNotFound is returned when the Jupyter server doesn't know a kernel.
+var ErrKernelNotFound = errors.New("kernel not found")
+
 // Client is the client for kernel management
 type Client struct {
 	// baseURL is the base URL of the Jupyter server
@@ -117,6 +121,9 @@ func (c *Client) GetKernel(kernelId string) (*Kernel, error) {
 	defer resp.Body.Close()
 
 	// Check response status
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrKernelNotFound
+	}
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("server returned error status code: %d", resp.StatusCode)
 	}
@@ -0,0 +1,293 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// DefaultComponent names the logger behind the package-level functions.
+const DefaultComponent = "default"
+
+// Logger logs for one component of execd, e.g. "proxy". A component logs at
+// the default level until its own level is set.
+type Logger struct {
+	name  string
+	level zap.AtomicLevel
+	// inherit is set while the component follows the default level.
+	inherit atomic.Bool
+	named   atomic.Pointer[namedSugar]
+
+	// mu guards a temporary level set by SetLevelName and the level it reverts
+	// to, where a nil previous level reverts to following the default.
+	mu       sync.Mutex
+	timer    *time.Timer
+	previous *zapcore.Level
+	revertAt time.Time
+}
+
+// namedSugar caches a component's logger derived from the current output.
+type namedSugar struct {
+	parent *zap.SugaredLogger
+	logger *zap.SugaredLogger
+}
+
+var (
+	std = &Logger{name: DefaultComponent, level: atomicLevel}
+
+	registry = struct {
+		sync.Mutex
+		loggers map[string]*Logger
+	}{loggers: map[string]*Logger{DefaultComponent: std}}
+)
+
+// For returns the logger of a component, registering it on first use.
+func For(component string) *Logger {
+	registry.Lock()
+	defer registry.Unlock()
+
+	if l, ok := registry.loggers[component]; ok {
+		return l
+	}
+	l := &Logger{name: component, level: zap.NewAtomicLevel()}
+	l.inherit.Store(true)
+	registry.loggers[component] = l
+	return l
+}
+
+// Lookup returns the logger of a component registered with For.
+func Lookup(component string) (*Logger, bool) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	l, ok := registry.loggers[component]
+	return l, ok
+}
+
+// Components returns every registered logger, sorted by name.
+func Components() []*Logger {
+	registry.Lock()
+	defer registry.Unlock()
+
+	loggers := make([]*Logger, 0, len(registry.loggers))
+	for _, l := range registry.loggers {
+		loggers = append(loggers, l)
+	}
+	sort.Slice(loggers, func(i, j int) bool { return loggers[i].name < loggers[j].name })
+	return loggers
+}
+
+// ParseLevels parses a --log-level value: a level, or comma separated
+// component=level pairs where "default" sets the level of the package-level
+// functions and of components without their own. Levels are names such as
+// debug, or the legacy numbers 0-7 accepted by SetLevel.
+func ParseLevels(spec string) (map[string]zapcore.Level, error) {
+	levels := make(map[string]zapcore.Level)
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		component, name, found := strings.Cut(item, "=")
+		if !found {
+			component, name = DefaultComponent, item
+		}
+		component = strings.TrimSpace(component)
+		if component == "" {
+			return nil, fmt.Errorf("missing component in %q", item)
+		}
+		level, err := parseLevel(strings.TrimSpace(name))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", component, err)
+		}
+		levels[component] = level
+	}
+	return levels, nil
+}
+
+// SetLevels permanently applies the levels of a --log-level value; see ParseLevels.
+func SetLevels(spec string) error {
+	levels, err := ParseLevels(spec)
+	if err != nil {
+		return err
+	}
+	for component, level := range levels {
+		For(component).setLevel(&level, 0)
+	}
+	return nil
+}
+
+func parseLevel(name string) (zapcore.Level, error) {
+	if n, err := strconv.Atoi(name); err == nil {
+		return mapLevel(n), nil
+	}
+	return zapcore.ParseLevel(name)
+}
+
+// Level returns the name of the default level, e.g. "info" or "debug".
+func Level() string {
+	return std.Level()
+}
+
+// RevertAt returns when a temporary default level set by SetLevelName reverts,
+// or the zero time when the current level is permanent.
+func RevertAt() time.Time {
+	return std.RevertAt()
+}
+
+// SetLevelName changes the default level by name; see Logger.SetLevelName.
+func SetLevelName(name string, duration time.Duration) error {
+	return std.SetLevelName(name, duration)
+}
+
+// Name returns the component of the logger.
+func (l *Logger) Name() string {
+	return l.name
+}
+
+// Level returns the name of the level the component logs at.
+func (l *Logger) Level() string {
+	return l.current().String()
+}
+
+// Inherited reports whether the component follows the default level.
+func (l *Logger) Inherited() bool {
+	return l.inherit.Load()
+}
+
+// RevertAt returns when a temporary level set by SetLevelName reverts, or the
+// zero time when the current level is permanent.
+func (l *Logger) RevertAt() time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.revertAt
+}
+
+// SetLevelName changes the level by name (debug, info, warn, error). A positive
+// duration reverts to the level in effect before the first of consecutive
+// temporary changes once it elapses; otherwise the change is permanent.
+func (l *Logger) SetLevelName(name string, duration time.Duration) error {
+	level, err := zapcore.ParseLevel(name)
+	if err != nil {
+		return err
+	}
+	l.setLevel(&level, duration)
+	return nil
+}
+
+func (l *Logger) current() zapcore.Level {
+	if l.inherit.Load() {
+		return atomicLevel.Level()
+	}
+	return l.level.Level()
+}
+
+// setLevel sets the level of the component, or makes it follow the default
+// level when level is nil.
+func (l *Logger) setLevel(level *zapcore.Level, duration time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var previous *zapcore.Level
+	if !l.inherit.Load() {
+		current := l.level.Level()
+		previous = &current
+	}
+	if l.timer != nil {
+		l.timer.Stop()
+		previous = l.previous
+	}
+	l.timer = nil
+	l.revertAt = time.Time{}
+	l.apply(level)
+
+	if duration > 0 {
+		var timer *time.Timer
+		timer = time.AfterFunc(duration, func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			// a later change replaced this timer.
+			if l.timer != timer {
+				return
+			}
+			l.apply(l.previous)
+			l.timer = nil
+			l.revertAt = time.Time{}
+		})
+		l.timer = timer
+		l.previous = previous
+		l.revertAt = time.Now().Add(duration)
+	}
+}
+
+func (l *Logger) apply(level *zapcore.Level) {
+	if level == nil {
+		l.inherit.Store(true)
+		return
+	}
+	l.level.SetLevel(*level)
+	l.inherit.Store(false)
+}
+
+// sugared returns the output logger, named after the component.
+func (l *Logger) sugared() *zap.SugaredLogger {
+	if l == std {
+		return sugar
+	}
+	if cached := l.named.Load(); cached != nil && cached.parent == sugar {
+		return cached.logger
+	}
+	named := &namedSugar{parent: sugar, logger: sugar.Named(l.name)}
+	l.named.Store(named)
+	return named.logger
+}
+
+func (l *Logger) Debug(format string, args ...any) {
+	l.logf(zapcore.DebugLevel, format, args)
+}
+
+func (l *Logger) Info(format string, args ...any) {
+	l.logf(zapcore.InfoLevel, format, args)
+}
+
+func (l *Logger) Warn(format string, args ...any) {
+	l.logf(zapcore.WarnLevel, format, args)
+}
+
+// Warning is an alias to Warn for compatibility.
+func (l *Logger) Warning(format string, args ...any) {
+	l.logf(zapcore.WarnLevel, format, args)
+}
+
+func (l *Logger) Error(format string, args ...any) {
+	l.logf(zapcore.ErrorLevel, format, args)
+}
+
+// logf must be called directly by the exported logging functions, the
+// output skips both frames when reporting the caller.
+func (l *Logger) logf(level zapcore.Level, format string, args []any) {
+	if l.current().Enabled(level) {
+		l.sugared().Log(level, message(format, args))
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSetLevelName_RevertsToLevelBeforeTemporaryChanges(t *testing.T) {
+	t.Cleanup(func() { _ = SetLevelName("info", 0) })
+	if err := SetLevelName("info", 0); err != nil {
+		t.Fatalf("set info: %v", err)
+	}
+
+	if err := SetLevelName("debug", time.Hour); err != nil {
+		t.Fatalf("set debug: %v", err)
+	}
+	// a second temporary change replaces the timer but keeps the original level.
+	if err := SetLevelName("warn", 50*time.Millisecond); err != nil {
+		t.Fatalf("set warn: %v", err)
+	}
+	if Level() != "warn" || RevertAt().IsZero() {
+		t.Fatalf("expected temporary warn level, got %s (revert at %v)", Level(), RevertAt())
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for Level() != "info" {
+		if time.Now().After(deadline) {
+			t.Fatalf("level did not revert, still %s", Level())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !RevertAt().IsZero() {
+		t.Fatalf("expected no pending revert, got %v", RevertAt())
+	}
+}
+
+func TestSetLevelName_PermanentChangeCancelsRevert(t *testing.T) {
+	t.Cleanup(func() { _ = SetLevelName("info", 0) })
+	if err := SetLevelName("debug", 20*time.Millisecond); err != nil {
+		t.Fatalf("set debug: %v", err)
+	}
+	if err := SetLevelName("error", 0); err != nil {
+		t.Fatalf("set error: %v", err)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if Level() != "error" {
+		t.Fatalf("expected the permanent level to stay, got %s", Level())
+	}
+
+	if err := SetLevelName("verbose", 0); err == nil {
+		t.Fatalf("expected an unknown level to be rejected")
+	}
+}
+
+func TestComponentLevels(t *testing.T) {
+	t.Cleanup(func() { _ = SetLevels("info") })
+	proxy := For("test-proxy")
+	if For("test-proxy") != proxy {
+		t.Fatalf("expected For to return the registered logger")
+	}
+
+	if err := SetLevels("warn"); err != nil {
+		t.Fatalf("set levels: %v", err)
+	}
+	if !proxy.Inherited() || proxy.Level() != "warn" {
+		t.Fatalf("expected the component to follow the default level, got %s", proxy.Level())
+	}
+
+	if err := SetLevels("test-proxy=debug, default=error"); err != nil {
+		t.Fatalf("set levels: %v", err)
+	}
+	if proxy.Level() != "debug" || Level() != "error" || proxy.Inherited() {
+		t.Fatalf("expected independent levels, got component %s and default %s", proxy.Level(), Level())
+	}
+
+	// a temporary level of a component that followed the default reverts to following it.
+	other := For("test-runtime")
+	if err := other.SetLevelName("debug", 30*time.Millisecond); err != nil {
+		t.Fatalf("set component level: %v", err)
+	}
+	if other.Level() != "debug" || other.RevertAt().IsZero() {
+		t.Fatalf("expected a temporary debug level, got %s", other.Level())
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for !other.Inherited() {
+		if time.Now().After(deadline) {
+			t.Fatalf("component level did not revert, still %s", other.Level())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if other.Level() != "error" {
+		t.Fatalf("expected the reverted component to follow the default, got %s", other.Level())
+	}
+
+	if _, ok := Lookup("test-proxy"); !ok {
+		t.Fatalf("expected the component to be registered")
+	}
+	if _, ok := Lookup("test-missing"); ok {
+		t.Fatalf("Lookup must not register components")
+	}
+}
+
+func TestParseLevels(t *testing.T) {
+	levels, err := ParseLevels("6")
+	if err != nil || len(levels) != 1 || levels[DefaultComponent].String() != "info" {
+		t.Fatalf("expected a legacy number to set the default level, got %v, %v", levels, err)
+	}
+	levels, err = ParseLevels("proxy=debug,runtime=3,info")
+	if err != nil || levels["proxy"].String() != "debug" || levels["runtime"].String() != "error" || levels[DefaultComponent].String() != "info" {
+		t.Fatalf("unexpected levels %v, %v", levels, err)
+	}
+	for _, spec := range []string{"proxy=verbose", "=debug", "loud"} {
+		if _, err := ParseLevels(spec); err == nil {
+			t.Fatalf("expected %q to be rejected", spec)
+		}
+	}
+}
@@ -17,24 +17,32 @@ package log
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
-const logFileEnvKey = "EXECD_LOG_FILE"
+// FileEnvKey names the environment variable with the path logs are written to.
+const FileEnvKey = "EXECD_LOG_FILE"
+
+// callerSkip skips the logging function and Logger.logf in reported callers.
+const callerSkip = 2
 
 var (
+	// atomicLevel is the default level. The output itself accepts every level,
+	// each Logger filters by its own.
 	atomicLevel = zap.NewAtomicLevelAt(zap.InfoLevel)
 	base        *zap.Logger
 	sugar       *zap.SugaredLogger
+	output      *rotatingFile
 )
 
 func init() {
 	cfg := zap.NewProductionConfig()
-	cfg.Level = atomicLevel
+	cfg.Level = zap.NewAtomicLevelAt(zap.DebugLevel)
 
-	logFile := os.Getenv(logFileEnvKey)
+	logFile := os.Getenv(FileEnvKey)
 	if logFile != "" {
 		cfg.OutputPaths = []string{logFile}
 		cfg.ErrorOutputPaths = []string{logFile}
@@ -44,7 +52,7 @@ func init() {
 		cfg.ErrorOutputPaths = []string{"stdout"}
 	}
 
-	logger, err := cfg.Build()
+	logger, err := cfg.Build(zap.AddCallerSkip(callerSkip))
 	if err != nil {
 		panic(fmt.Sprintf("failed to init logger: %v", err))
 	}
@@ -52,10 +60,56 @@ func init() {
 	sugar = base.Sugar()
 }
 
-// SetLevel maps legacy Beego log levels to zap levels.
+// Options selects where logs are written once flags are parsed.
+type Options struct {
+	// File is the log file path; empty keeps logging to stdout.
+	File string
+	// MaxSize rotates the file once it would exceed this many bytes; 0 disables rotation.
+	MaxSize int64
+	// MaxBackups is the number of rotated files kept; 0 keeps all.
+	MaxBackups int
+	// MaxAge removes rotated files older than this; 0 keeps them regardless of age.
+	MaxAge time.Duration
+	// Stdout also writes logs to stdout when File is set.
+	Stdout bool
+}
+
+// Configure replaces the output chosen at startup. It is meant to be called
+// once from main before the server starts handling requests.
+func Configure(opts Options) error {
+	if opts.File == "" {
+		return nil
+	}
+	file, err := openRotatingFile(opts.File, opts.MaxSize, opts.MaxBackups, opts.MaxAge)
+	if err != nil {
+		return fmt.Errorf("open log file %s: %w", opts.File, err)
+	}
+
+	var sink zapcore.WriteSyncer = file
+	if opts.Stdout {
+		sink = zapcore.NewMultiWriteSyncer(file, zapcore.Lock(os.Stdout))
+	}
+
+	cfg := zap.NewProductionConfig()
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(cfg.EncoderConfig), sink, zapcore.DebugLevel)
+	core = zapcore.NewSamplerWithOptions(core, time.Second, cfg.Sampling.Initial, cfg.Sampling.Thereafter)
+
+	_ = base.Sync()
+	previous := output
+	base = zap.New(core, zap.AddCaller(), zap.AddCallerSkip(callerSkip), zap.AddStacktrace(zapcore.ErrorLevel), zap.ErrorOutput(sink))
+	sugar = base.Sugar()
+	output = file
+	if previous != nil {
+		_ = previous.Close()
+	}
+	return nil
+}
+
+// SetLevel sets the default level from a legacy Beego log level.
 // 0/1/2 => Fatal, 3 => Error, 4 => Warn, 5/6 => Info, 7+ => Debug.
 func SetLevel(level int) {
-	atomicLevel.SetLevel(mapLevel(level))
+	zapLevel := mapLevel(level)
+	std.setLevel(&zapLevel, 0)
 }
 
 func mapLevel(level int) zapcore.Level {
@@ -77,23 +131,32 @@ func Sync() {
 	_ = base.Sync()
 }
 
+// message formats and redacts a log line; like zap, a format without args is used verbatim.
+func message(format string, args []any) string {
+	if len(args) > 0 {
+		format = fmt.Sprintf(format, args...)
+	}
+	return Redact(format)
+}
+
+// Debug, Info, Warn and Error log for the default component.
 func Debug(format string, args ...any) {
-	sugar.Debugf(format, args...)
+	std.logf(zapcore.DebugLevel, format, args)
 }
 
 func Info(format string, args ...any) {
-	sugar.Infof(format, args...)
+	std.logf(zapcore.InfoLevel, format, args)
 }
 
 func Warn(format string, args ...any) {
-	sugar.Warnf(format, args...)
+	std.logf(zapcore.WarnLevel, format, args)
 }
 
 // Warning is an alias to Warn for compatibility.
 func Warning(format string, args ...any) {
-	Warn(format, args...)
+	std.logf(zapcore.WarnLevel, format, args)
 }
 
 func Error(format string, args ...any) {
-	sugar.Errorf(format, args...)
+	std.logf(zapcore.ErrorLevel, format, args)
 }
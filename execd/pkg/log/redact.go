@@ -0,0 +1,156 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const redacted = "[REDACTED]"
+
+// minSecretLength keeps short values such as "1" or "on" from masking unrelated text.
+const minSecretLength = 4
+
+// secretNameWords are the words that make a field or variable name suggest a secret.
+const secretNameWords = `password|passwd|secret|token|authorization|api[_-]?key|credential`
+
+var (
+	// tokenQueryPattern matches URL query parameters that commonly carry credentials.
+	tokenQueryPattern = regexp.MustCompile(
+		`(?i)([?&](?:token|access_token|auth|api_key|apikey|key|password|secret|signature|sig)=)[^&\s"'#]+`,
+	)
+	// bearerPattern matches bearer credentials, e.g. from an echoed Authorization header.
+	bearerPattern = regexp.MustCompile(`(?i)(\bbearer\s+)[A-Za-z0-9._~+/=-]+`)
+	// secretNamePattern matches names that suggest a secret.
+	secretNamePattern = regexp.MustCompile(`(?i)` + secretNameWords)
+	// secretFieldPattern matches JSON string fields whose names suggest a secret.
+	secretFieldPattern = regexp.MustCompile(
+		`(?i)("[^"]*(?:` + secretNameWords + `)[^"]*"\s*:\s*)"(?:[^"\\]|\\.)*"`,
+	)
+)
+
+// redactor masks secrets in messages before they are written.
+var redactor struct {
+	mu sync.RWMutex
+	// secrets holds literal values masked wherever they appear.
+	secrets map[string]struct{}
+	// envNames are the variables whose NAME=value and "NAME": "value" forms are masked.
+	envNames []string
+	replacer *strings.Replacer
+	envRule  *regexp.Regexp
+	envJSON  *regexp.Regexp
+}
+
+// AddSecrets masks every occurrence of the given values in later log messages.
+func AddSecrets(values ...string) {
+	redactor.mu.Lock()
+	defer redactor.mu.Unlock()
+
+	if redactor.secrets == nil {
+		redactor.secrets = make(map[string]struct{})
+	}
+	for _, value := range values {
+		if len(value) >= minSecretLength {
+			redactor.secrets[value] = struct{}{}
+		}
+	}
+	rebuildReplacer()
+}
+
+// RemoveSecrets stops masking values registered with AddSecrets.
+func RemoveSecrets(values ...string) {
+	redactor.mu.Lock()
+	defer redactor.mu.Unlock()
+
+	for _, value := range values {
+		delete(redactor.secrets, value)
+	}
+	rebuildReplacer()
+}
+
+// IsSecretName reports whether a field or variable name suggests its value is
+// a secret, e.g. DB_PASSWORD or apiKey.
+func IsSecretName(name string) bool {
+	return secretNamePattern.MatchString(name)
+}
+
+// RedactSecretFields masks the values of the JSON string fields of text whose
+// names suggest a secret.
+func RedactSecretFields(text string) string {
+	return secretFieldPattern.ReplaceAllString(text, `${1}"`+redacted+`"`)
+}
+
+// RedactEnv masks the values of the named environment variables: their current
+// values wherever they appear, and any NAME=value or "NAME": "value" assignment
+// of them, e.g. in a command that exports a key.
+func RedactEnv(names ...string) {
+	var values []string
+	redactor.mu.Lock()
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		redactor.envNames = append(redactor.envNames, regexp.QuoteMeta(name))
+		values = append(values, os.Getenv(name))
+	}
+	if len(redactor.envNames) > 0 {
+		alternatives := strings.Join(redactor.envNames, "|")
+		redactor.envRule = regexp.MustCompile(`\b(` + alternatives + `)=(?:"[^"]*"|'[^']*'|[^\s"'&;\\]+)`)
+		redactor.envJSON = regexp.MustCompile(`("(?:` + alternatives + `)"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+	}
+	redactor.mu.Unlock()
+
+	AddSecrets(values...)
+}
+
+// rebuildReplacer prepares the literal replacements, longest secret first so a
+// secret containing another is masked whole. Callers must hold redactor.mu.
+func rebuildReplacer() {
+	secrets := make([]string, 0, len(redactor.secrets))
+	for secret := range redactor.secrets {
+		secrets = append(secrets, secret)
+	}
+	sort.Slice(secrets, func(i, j int) bool { return len(secrets[i]) > len(secrets[j]) })
+
+	pairs := make([]string, 0, 2*len(secrets))
+	for _, secret := range secrets {
+		pairs = append(pairs, secret, redacted)
+	}
+	redactor.replacer = strings.NewReplacer(pairs...)
+}
+
+// Redact masks known secrets, credential query parameters, bearer tokens and
+// assignments of the configured environment variables in message.
+func Redact(message string) string {
+	message = tokenQueryPattern.ReplaceAllString(message, "${1}"+redacted)
+	message = bearerPattern.ReplaceAllString(message, "${1}"+redacted)
+
+	redactor.mu.RLock()
+	defer redactor.mu.RUnlock()
+
+	if redactor.envRule != nil {
+		message = redactor.envRule.ReplaceAllString(message, "${1}="+redacted)
+		message = redactor.envJSON.ReplaceAllString(message, `${1}"`+redacted+`"`)
+	}
+	if redactor.replacer != nil {
+		message = redactor.replacer.Replace(message)
+	}
+	return message
+}
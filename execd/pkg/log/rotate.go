@@ -0,0 +1,175 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rotatingFile is a log file that is renamed to "<path>.1" once it would grow
+// beyond maxSize, shifting older backups to "<path>.2", "<path>.3" and so on.
+// It is safe for concurrent use.
+type rotatingFile struct {
+	path       string
+	maxSize    int64         // 0 disables rotation
+	maxBackups int           // 0 keeps every backup
+	maxAge     time.Duration // 0 keeps backups regardless of age
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+func openRotatingFile(path string, maxSize int64, maxBackups int, maxAge time.Duration) (*rotatingFile, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+	}
+	f := &rotatingFile{path: path, maxSize: maxSize, maxBackups: maxBackups, maxAge: maxAge}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *rotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+// Write appends p, rotating first when p would push the file past maxSize.
+// A single write larger than maxSize still lands in one file.
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+	if f.maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+func (f *rotatingFile) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+	return f.file.Sync()
+}
+
+func (f *rotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+// rotate moves the current file to the first backup slot and reopens path.
+// Callers must hold mu.
+func (f *rotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	f.file = nil
+
+	backups := f.backups()
+	for i := len(backups) - 1; i >= 0; i-- {
+		if err := os.Rename(f.backupPath(backups[i]), f.backupPath(backups[i]+1)); err != nil {
+			return err
+		}
+	}
+	if err := os.Rename(f.path, f.backupPath(1)); err != nil {
+		return err
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+	f.prune()
+	return nil
+}
+
+// prune removes backups beyond maxBackups or older than maxAge. Failures are
+// left for the next rotation to retry.
+func (f *rotatingFile) prune() {
+	now := time.Now()
+	for _, idx := range f.backups() {
+		path := f.backupPath(idx)
+		if f.maxBackups > 0 && idx > f.maxBackups {
+			_ = os.Remove(path)
+			continue
+		}
+		if f.maxAge > 0 {
+			if info, err := os.Stat(path); err == nil && now.Sub(info.ModTime()) > f.maxAge {
+				_ = os.Remove(path)
+			}
+		}
+	}
+}
+
+// backups returns the indexes of existing backups in ascending order.
+func (f *rotatingFile) backups() []int {
+	entries, _ := os.ReadDir(filepath.Dir(f.path))
+	prefix := filepath.Base(f.path) + "."
+	var indexes []int
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		idx, err := strconv.Atoi(strings.TrimPrefix(name, prefix))
+		if err != nil || idx < 1 {
+			continue
+		}
+		indexes = append(indexes, idx)
+	}
+	sort.Ints(indexes)
+	return indexes
+}
+
+func (f *rotatingFile) backupPath(idx int) string {
+	return fmt.Sprintf("%s.%d", f.path, idx)
+}
@@ -0,0 +1,152 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRotatingFile_RotatesAndKeepsBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "execd.log")
+	f, err := openRotatingFile(path, 10, 2, 0)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer f.Close()
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatalf("write %q: %v", line, err)
+		}
+	}
+
+	expected := map[string]string{
+		path:        "fourth\n",
+		path + ".1": "third\n",
+		path + ".2": "second\n",
+	}
+	for p, want := range expected {
+		got, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatalf("read %s: %v", p, err)
+		}
+		if string(got) != want {
+			t.Fatalf("%s = %q, want %q", p, got, want)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Fatalf("expected backup beyond max to be removed, stat err=%v", err)
+	}
+}
+
+func TestRotatingFile_RemovesExpiredBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "execd.log")
+	old := path + ".1"
+	if err := os.WriteFile(old, []byte("old\n"), 0o644); err != nil {
+		t.Fatalf("seed backup: %v", err)
+	}
+	stale := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(old, stale, stale); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+
+	f, err := openRotatingFile(path, 4, 0, time.Hour)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer f.Close()
+	for _, line := range []string{"abc\n", "def\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	// the stale backup was shifted to .2 and then removed for its age.
+	if _, err := os.Stat(path + ".2"); !os.IsNotExist(err) {
+		t.Fatalf("expected expired backup to be removed, stat err=%v", err)
+	}
+	got, err := os.ReadFile(path + ".1")
+	if err != nil || string(got) != "abc\n" {
+		t.Fatalf("backup = %q, %v", got, err)
+	}
+}
+
+func TestRotatingFile_ConcurrentWritesKeepLinesWhole(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "execd.log")
+	f, err := openRotatingFile(path, 256, 0, 0)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	const writers, lines = 8, 50
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < lines; i++ {
+				if _, err := fmt.Fprintf(f, "writer-%d line-%03d\n", w, i); err != nil {
+					t.Errorf("write: %v", err)
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	if err := f.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	var all []byte
+	files := append([]string{path}, backupPaths(t, f)...)
+	for _, p := range files {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatalf("read %s: %v", p, err)
+		}
+		if len(data) > 256 {
+			t.Fatalf("%s has %d bytes, want at most 256", p, len(data))
+		}
+		all = append(all, data...)
+	}
+	got := bytes.Split(bytes.TrimSuffix(all, []byte("\n")), []byte("\n"))
+	if len(got) != writers*lines {
+		t.Fatalf("expected %d lines, got %d", writers*lines, len(got))
+	}
+	for _, line := range got {
+		var w, i int
+		if n, _ := fmt.Sscanf(string(line), "writer-%d line-%03d", &w, &i); n != 2 {
+			t.Fatalf("torn line %q", line)
+		}
+	}
+}
+
+func backupPaths(t *testing.T, f *rotatingFile) []string {
+	t.Helper()
+	var paths []string
+	for _, idx := range f.backups() {
+		paths = append(paths, f.backupPath(idx))
+	}
+	if len(paths) == 0 {
+		t.Fatalf("expected the file to rotate")
+	}
+	return paths
+}
@@ -0,0 +1,121 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/alibaba/opensandbox/execd/pkg/jupyter/execute"
+)
+
+// ExecuteBatchRequest runs an ordered list of snippets on a single kernel.
+type ExecuteBatchRequest struct {
+	Language    Language      `json:"language"`
+	Context     string        `json:"context"`
+	Codes       []string      `json:"codes"`
+	StopOnError bool          `json:"stop_on_error"`
+	Timeout     time.Duration `json:"timeout"`
+	// DisplayDataDir saves large display data to files, see ExecuteCodeRequest.
+	DisplayDataDir string `json:"display_data_dir"`
+	// OnSnippetStart is invoked before the snippet at index starts executing.
+	OnSnippetStart func(index int)
+	// OnSnippetEnd is invoked once the snippet at index ran, failed telling
+	// whether it raised an error.
+	OnSnippetEnd func(index int, elapsed time.Duration, failed bool)
+	Hooks        ExecuteResultHook
+}
+
+// ExecuteBatch executes the snippets sequentially in the same Jupyter session.
+// Once ctx is done, typically because the client went away, the running
+// snippet is interrupted, the rest are skipped and the kernel is released.
+func (c *Controller) ExecuteBatch(ctx context.Context, request *ExecuteBatchRequest) error {
+	if len(request.Codes) == 0 {
+		return errors.New("no code snippets to execute")
+	}
+
+	language := request.Language
+	if language == "" && request.Context != "" {
+		if kernel := c.getJupyterKernel(request.Context); kernel != nil {
+			language = kernel.language
+		}
+	}
+	switch language {
+	case Bash, Python, Java, JavaScript, TypeScript, Go:
+	default:
+		return fmt.Errorf("batch execution is not supported for language: %s", language)
+	}
+
+	var cancel context.CancelFunc
+	if request.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, request.Timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	targetSessionID, kernel, err := c.resolveJupyterKernel(language, request.Context)
+	if err != nil {
+		return err
+	}
+
+	// the kernel stays locked for the whole batch so no other execution can
+	// interleave with the snippets.
+	if !kernel.tryLock() {
+		return ErrSessionBusy
+	}
+	defer kernel.unlock()
+
+	snippet := &ExecuteCodeRequest{
+		Language:       language,
+		Context:        targetSessionID,
+		DisplayDataDir: request.DisplayDataDir,
+		Hooks:          request.Hooks,
+	}
+	snippet.SetDefaultHooks()
+	snippet.Hooks.OnExecuteInit(targetSessionID)
+
+	failed := false
+	onError := snippet.Hooks.OnExecuteError
+	snippet.Hooks.OnExecuteError = func(err *execute.ErrorOutput) {
+		failed = true
+		onError(err)
+	}
+
+	for index, code := range request.Codes {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("snippet %d: %w", index, err)
+		}
+		if request.OnSnippetStart != nil {
+			request.OnSnippetStart(index)
+		}
+
+		failed = false
+		snippet.Code = code
+		start := time.Now()
+		if err := c.streamJupyterCode(ctx, kernel, snippet); err != nil {
+			return fmt.Errorf("snippet %d: %w", index, err)
+		}
+		if request.OnSnippetEnd != nil {
+			request.OnSnippetEnd(index, time.Since(start), failed)
+		}
+		if failed && request.StopOnError {
+			break
+		}
+	}
+	return nil
+}
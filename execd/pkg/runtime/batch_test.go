@@ -0,0 +1,196 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/alibaba/opensandbox/execd/pkg/jupyter/execute"
+)
+
+// newEchoKernel answers every execute_request by echoing its code to stdout;
+// code "fail" produces an error instead.
+func newEchoKernel(t *testing.T) *Controller {
+	t.Helper()
+	count := 0
+	server := newMockJupyter(t, func(conn *websocket.Conn, msg *execute.Message) {
+		if execute.MessageType(msg.Header.MessageType) != execute.MsgExecuteRequest {
+			return
+		}
+		var req execute.ExecuteRequest
+		if err := json.Unmarshal(msg.Content, &req); err != nil {
+			t.Errorf("unmarshal execute request: %v", err)
+			return
+		}
+
+		count++
+		if req.Code == "fail" {
+			errOutput := execute.ErrorOutput{EName: "RuntimeError", EValue: "boom"}
+			replyMessage(t, conn, msg, execute.MsgError, errOutput)
+			replyMessage(t, conn, msg, execute.MsgExecuteReply, execute.ExecuteReply{Status: "error", ExecutionCount: count, ErrorOutput: errOutput})
+		} else {
+			replyMessage(t, conn, msg, execute.MsgStream, execute.StreamOutput{Name: execute.StreamStdout, Text: req.Code})
+			replyMessage(t, conn, msg, execute.MsgExecuteReply, execute.ExecuteReply{Status: "ok", ExecutionCount: count})
+		}
+		replyMessage(t, conn, msg, execute.MsgStatus, execute.StatusUpdate{ExecutionState: execute.StateIdle})
+	})
+	t.Cleanup(server.Close)
+
+	c := NewController(server.URL, "token")
+	c.storeJupyterKernel("session-1", &jupyterKernel{kernelID: mockKernelID, client: c.jupyterClient(), language: Python})
+	return c
+}
+
+// runBatch executes codes and returns stdout and errors tagged with their snippet index.
+func runBatch(t *testing.T, c *Controller, codes []string, stopOnError bool) ([]string, error) {
+	t.Helper()
+	index := -1
+	var events []string
+	err := c.ExecuteBatch(context.Background(), &ExecuteBatchRequest{
+		Context:        "session-1",
+		Codes:          codes,
+		StopOnError:    stopOnError,
+		OnSnippetStart: func(i int) { index = i },
+		Hooks: ExecuteResultHook{
+			OnExecuteInit:     func(string) {},
+			OnExecuteStdout:   func(text string) { events = append(events, fmt.Sprintf("%d:%s", index, text)) },
+			OnExecuteError:    func(err *execute.ErrorOutput) { events = append(events, fmt.Sprintf("%d:%s", index, err.EName)) },
+			OnExecuteStatus:   func(string) {},
+			OnExecuteComplete: func(time.Duration) {},
+		},
+	})
+	return events, err
+}
+
+func TestExecuteBatch_RunsSnippetsInOrder(t *testing.T) {
+	c := newEchoKernel(t)
+
+	events, err := runBatch(t, c, []string{"first", "second", "third"}, false)
+	if err != nil {
+		t.Fatalf("ExecuteBatch returned error: %v", err)
+	}
+
+	expected := []string{"0:first", "1:second", "2:third"}
+	if !reflect.DeepEqual(events, expected) {
+		t.Fatalf("unexpected events: got %v want %v", events, expected)
+	}
+}
+
+func TestExecuteBatch_StopOnError(t *testing.T) {
+	c := newEchoKernel(t)
+
+	events, err := runBatch(t, c, []string{"first", "fail", "third"}, true)
+	if err != nil {
+		t.Fatalf("ExecuteBatch returned error: %v", err)
+	}
+
+	expected := []string{"0:first", "1:RuntimeError"}
+	if !reflect.DeepEqual(events, expected) {
+		t.Fatalf("unexpected events: got %v want %v", events, expected)
+	}
+}
+
+func TestExecuteBatch_HoldsKernelBetweenSnippets(t *testing.T) {
+	c := newEchoKernel(t)
+	kernel := c.getJupyterKernel("session-1")
+
+	var lockedBetween []bool
+	err := c.ExecuteBatch(context.Background(), &ExecuteBatchRequest{
+		Context: "session-1",
+		Codes:   []string{"first", "second"},
+		OnSnippetStart: func(int) {
+			acquired := kernel.tryLock()
+			if acquired {
+				kernel.unlock()
+			}
+			lockedBetween = append(lockedBetween, !acquired)
+		},
+		Hooks: ExecuteResultHook{OnExecuteInit: func(string) {}},
+	})
+	if err != nil {
+		t.Fatalf("ExecuteBatch returned error: %v", err)
+	}
+	if !reflect.DeepEqual(lockedBetween, []bool{true, true}) {
+		t.Fatalf("expected the kernel to stay locked for the whole batch, got %v", lockedBetween)
+	}
+
+	if !kernel.tryLock() {
+		t.Fatalf("expected the kernel to be released after the batch")
+	}
+	kernel.unlock()
+}
+
+func TestExecuteBatch_Timeout(t *testing.T) {
+	server := newMockJupyter(t, func(*websocket.Conn, *execute.Message) {
+		// never replies, so the batch can only end through its timeout.
+	})
+	t.Cleanup(server.Close)
+	c := NewController(server.URL, "token")
+	c.storeJupyterKernel("session-1", &jupyterKernel{kernelID: mockKernelID, client: c.jupyterClient(), language: Python})
+
+	start := time.Now()
+	err := c.ExecuteBatch(context.Background(), &ExecuteBatchRequest{
+		Context: "session-1",
+		Codes:   []string{"hang", "never"},
+		Timeout: 100 * time.Millisecond,
+		Hooks:   ExecuteResultHook{OnExecuteInit: func(string) {}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "snippet 0") {
+		t.Fatalf("expected the first snippet to be cancelled, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("batch was not bounded by its timeout, took %v", elapsed)
+	}
+}
+
+func TestExecuteBatch_CancelReleasesKernel(t *testing.T) {
+	c := newEchoKernel(t)
+	kernel := c.getJupyterKernel("session-1")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var started []int
+	err := c.ExecuteBatch(ctx, &ExecuteBatchRequest{
+		Context: "session-1",
+		Codes:   []string{"first", "second", "third"},
+		OnSnippetStart: func(index int) {
+			started = append(started, index)
+		},
+		OnSnippetEnd: func(int, time.Duration, bool) {
+			// the client goes away once the first snippet ran.
+			cancel()
+		},
+		Hooks: ExecuteResultHook{OnExecuteInit: func(string) {}},
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the batch to end with context.Canceled, got %v", err)
+	}
+	if !reflect.DeepEqual(started, []int{0}) {
+		t.Fatalf("expected the snippets after the cancellation to be skipped, got %v", started)
+	}
+	if !kernel.mu.TryLock() {
+		t.Fatalf("expected the kernel to be released once the batch was cancelled")
+	}
+	kernel.mu.Unlock()
+}
@@ -0,0 +1,128 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"fmt"
+	"net"
+	"os/exec"
+	"slices"
+	"time"
+)
+
+// localSQLAddress is where the default SQL connection expects the sandbox database.
+const localSQLAddress = "127.0.0.1:3306"
+
+// LanguageSupport tells whether code of a language can run, and on which kernel.
+type LanguageSupport struct {
+	Language  Language `json:"language"`
+	Available bool     `json:"available"`
+	// Kernel names the kernel spec running the language, for kernel languages.
+	Kernel string `json:"kernel,omitempty"`
+	// Reason tells why an unavailable language can't run.
+	Reason string `json:"reason,omitempty"`
+}
+
+// ProbeLanguages reports which languages can run: the kernel languages per
+// the kernel specs of the Jupyter server, commands when their shell is
+// installed and sql when a database is reachable. A successful probe is kept
+// until refresh asks for a new one; a failed probe of the Jupyter server is
+// retried on the next call.
+func (c *Controller) ProbeLanguages(refresh bool) []LanguageSupport {
+	c.mu.RLock()
+	cached := c.languageProbe
+	c.mu.RUnlock()
+	if cached != nil && !refresh {
+		return slices.Clone(cached)
+	}
+
+	languages, complete := c.probeLanguages()
+	c.mu.Lock()
+	if complete {
+		c.languageProbe = languages
+	} else {
+		c.languageProbe = nil
+	}
+	c.mu.Unlock()
+	return slices.Clone(languages)
+}
+
+// probeLanguages probes every language, reporting whether the Jupyter server answered.
+func (c *Controller) probeLanguages() ([]LanguageSupport, bool) {
+	languages := make([]LanguageSupport, 0, len(Languages))
+
+	shell := LanguageSupport{Available: true}
+	if _, err := exec.LookPath(defaultCommandShell); err != nil {
+		shell = LanguageSupport{Reason: fmt.Sprintf("%s is not installed", defaultCommandShell)}
+	}
+
+	kernels, kernelErr := c.kernelLanguages()
+	for _, language := range Languages {
+		support := LanguageSupport{Language: language}
+		switch language {
+		case Command, BackgroundCommand:
+			support.Available, support.Reason = shell.Available, shell.Reason
+		case SQL:
+			support.Available, support.Reason = c.sqlAvailable()
+		default:
+			switch kernel, ok := kernels[language]; {
+			case kernelErr != nil:
+				support.Reason = fmt.Sprintf("error listing kernel specs: %v", kernelErr)
+			case !ok:
+				support.Reason = fmt.Sprintf("%v: %s", ErrNoKernelForLanguage, language)
+			default:
+				support.Available, support.Kernel = true, kernel
+			}
+		}
+		languages = append(languages, support)
+	}
+	return languages, kernelErr == nil
+}
+
+// kernelLanguages maps the languages of the kernel specs to the kernel
+// searchKernel picks for them.
+func (c *Controller) kernelLanguages() (map[Language]string, error) {
+	if c.baseURL == "" {
+		return nil, fmt.Errorf("no Jupyter server is configured")
+	}
+	specs, err := c.jupyterClient().GetKernelSpecs()
+	if err != nil {
+		return nil, err
+	}
+	kernels := make(map[Language]string)
+	for _, language := range Languages {
+		if kernel := kernelForLanguage(specs, language); kernel != "" {
+			kernels[language] = kernel
+		}
+	}
+	return kernels, nil
+}
+
+// sqlAvailable reports whether sql code has a database: a registered
+// connection or the local sandbox database accepting connections.
+func (c *Controller) sqlAvailable() (bool, string) {
+	c.mu.RLock()
+	registered := len(c.sqlConnections)
+	c.mu.RUnlock()
+	if registered > 0 {
+		return true, ""
+	}
+	conn, err := net.DialTimeout("tcp", localSQLAddress, 500*time.Millisecond)
+	if err != nil {
+		return false, fmt.Sprintf("no database is listening on %s and no connection is registered", localSQLAddress)
+	}
+	_ = conn.Close()
+	return true, ""
+}
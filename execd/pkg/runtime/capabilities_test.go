@@ -0,0 +1,76 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/alibaba/opensandbox/execd/pkg/jupyter/execute"
+)
+
+func TestProbeLanguages_CachesUntilRefresh(t *testing.T) {
+	var specLookups atomic.Int32
+	var down atomic.Bool
+	handler := mockJupyterHandler(t, func(*websocket.Conn, *execute.Message) {})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/kernelspecs" {
+			specLookups.Add(1)
+			if down.Load() {
+				http.Error(w, "unavailable", http.StatusServiceUnavailable)
+				return
+			}
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	c := NewController(server.URL, "token")
+	languages := c.ProbeLanguages(false)
+	if len(languages) != len(Languages) {
+		t.Fatalf("expected %d languages, got %+v", len(Languages), languages)
+	}
+	byLanguage := make(map[Language]LanguageSupport)
+	for _, support := range languages {
+		byLanguage[support.Language] = support
+	}
+	if python := byLanguage[Python]; !python.Available || python.Kernel != "ipython" {
+		t.Fatalf("expected python on the ipython kernel, got %+v", python)
+	}
+	if java := byLanguage[Java]; java.Available || java.Reason == "" {
+		t.Fatalf("expected java unavailable with a reason, got %+v", java)
+	}
+
+	c.ProbeLanguages(false)
+	if got := specLookups.Load(); got != 1 {
+		t.Fatalf("expected the probe to be cached, got %d kernel spec lookups", got)
+	}
+
+	down.Store(true)
+	for _, support := range c.ProbeLanguages(true) {
+		byLanguage[support.Language] = support
+	}
+	if python := byLanguage[Python]; python.Available {
+		t.Fatalf("expected python unavailable once the server fails, got %+v", python)
+	}
+	c.ProbeLanguages(false)
+	if got := specLookups.Load(); got != 3 {
+		t.Fatalf("expected a failed probe not to be cached, got %d kernel spec lookups", got)
+	}
+}
@@ -25,23 +25,40 @@ import (
 	"os/exec"
 	"os/signal"
 	"strconv"
-	"sync"
+	"strings"
 	"syscall"
 	"time"
 
+	"golang.org/x/sys/unix"
+
 	"github.com/alibaba/opensandbox/execd/pkg/jupyter/execute"
-	"github.com/alibaba/opensandbox/execd/pkg/log"
 	"github.com/alibaba/opensandbox/execd/pkg/util/safego"
 )
 
+// commandKillGrace is how long a cancelled command gets to exit on SIGTERM
+// before its process group is killed.
+const commandKillGrace = 3 * time.Second
+
 // runCommand executes shell commands and streams their output.
 func (c *Controller) runCommand(ctx context.Context, request *ExecuteCodeRequest) error {
+	request.SetDefaultHooks()
 	session := c.newContextID()
 
+	if err := ValidateCommandShell(request.Shell); err != nil {
+		return err
+	}
+	credential, err := resolveCredential(request.User, request.Group)
+	if err != nil {
+		return err
+	}
+
 	signals := make(chan os.Signal, 1)
 	defer close(signals)
-	signal.Notify(signals)
-	defer signal.Reset()
+	// Notify with no signals would relay them all.
+	if forwarded := c.forwardedSignals(); len(forwarded) > 0 {
+		signal.Notify(signals, forwarded...)
+		defer signal.Stop(signals)
+	}
 
 	stdout, stderr, err := c.stdLogDescriptor(session)
 	if err != nil {
@@ -51,36 +68,52 @@ func (c *Controller) runCommand(ctx context.Context, request *ExecuteCodeRequest
 	stderrPath := c.stderrFileName(session)
 
 	startAt := time.Now()
-	log.Info("received command: %v", request.Code)
+	logger.Info("received command: %v", request.Code)
 	cmd := exec.CommandContext(ctx, "bash", "-c", request.Code)
+	// the signal forwarding goroutine below terminates the whole process group
+	// once ctx is done, rather than os/exec killing the shell alone.
+	cmd.Cancel = func() error { return nil }
 
 	cmd.Stdout = stdout
 	cmd.Stderr = stderr
+	if request.Stdin != "" {
+		cmd.Stdin = strings.NewReader(request.Stdin)
+	}
 	cmd.Env = mergeEnvs(os.Environ(), loadExtraEnvFromFile())
-
-	done := make(chan struct{}, 1)
-	var wg sync.WaitGroup
-	wg.Add(2)
-	safego.Go(func() {
-		defer wg.Done()
-		c.tailStdPipe(stdoutPath, request.Hooks.OnExecuteStdout, done)
-	})
-	safego.Go(func() {
-		defer wg.Done()
-		c.tailStdPipe(stderrPath, request.Hooks.OnExecuteStderr, done)
-	})
+	limitStatus, err := applyResourceLimits(cmd, request.Limits)
+	if err != nil {
+		return err
+	}
+	defer limitStatus.Close()
 
 	cmd.Dir = request.Cwd
 	// use a dedicated process group so signals propagate to children.
-	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true, Credential: credential}
+
+	var term *terminal
+	if request.PTY != nil {
+		term, err = attachTerminal(cmd, *request.PTY, credential)
+		if err != nil {
+			return fmt.Errorf("failed to attach pseudo-terminal: %w", err)
+		}
+	}
+
+	done := make(chan struct{}, 1)
+	waitTailers := c.startTailers(request, stdoutPath, stderrPath, done)
 
 	err = cmd.Start()
 	if err != nil {
+		if term != nil {
+			term.abort()
+		}
 		request.Hooks.OnExecuteInit(session)
 		request.Hooks.OnExecuteError(&execute.ErrorOutput{EName: "CommandExecError", EValue: err.Error()})
-		log.Error("CommandExecError: error starting commands: %v", err)
+		logger.Error("CommandExecError: error starting commands: %v", err)
 		return nil
 	}
+	if term != nil {
+		term.started(stdout, request.Stdin)
+	}
 
 	kernel := &commandKernel{
 		pid:          cmd.Process.Pid,
@@ -94,43 +127,61 @@ func (c *Controller) runCommand(ctx context.Context, request *ExecuteCodeRequest
 	c.storeCommandKernel(session, kernel)
 	request.Hooks.OnExecuteInit(session)
 
+	exited := make(chan struct{})
 	go func() {
 		for {
 			select {
+			case <-exited:
+				return
 			case <-ctx.Done():
+				terminateProcessGroup(cmd.Process.Pid, exited)
 				return
 			case sig := <-signals:
 				if sig == nil {
 					continue
 				}
-				// DO NOT forward syscall.SIGURG to children processes.
-				if sig != syscall.SIGCHLD && sig != syscall.SIGURG {
-					_ = syscall.Kill(-cmd.Process.Pid, sig.(syscall.Signal))
-				}
+				_ = syscall.Kill(-cmd.Process.Pid, sig.(syscall.Signal))
 			}
 		}
 	}()
 
 	err = cmd.Wait()
+	close(exited)
+	if term != nil {
+		term.Close()
+	}
 	close(done)
-	wg.Wait()
+	if tailErr := waitTailers(); tailErr != nil {
+		c.reportLostOutput(session, request, err, tailErr)
+		return nil
+	}
 	if err != nil {
 		var eName, eValue string
-		var eCode int
 		var traceback []string
 
+		status := commandExitStatus(err)
+		status.successCodes = request.SuccessExitCodes
 		var exitError *exec.ExitError
+		if errors.As(err, &exitError) && status.Success() {
+			c.markCommandFinished(session, status.ExitCode, "")
+			request.Hooks.OnExecuteComplete(time.Since(startAt))
+			request.Hooks.OnCommandExit(status)
+			return nil
+		}
 		if errors.As(err, &exitError) {
-			exitCode := exitError.ExitCode()
 			eName = "CommandExecError"
-			eValue = strconv.Itoa(exitCode)
-			eCode = exitCode
+			eValue = strconv.Itoa(status.ExitCode)
 		} else {
 			eName = "CommandExecError"
 			eValue = err.Error()
-			eCode = 1
 		}
 		traceback = []string{err.Error()}
+		message := err.Error()
+		if reason := describeLimitExit(err, request.Limits, stderrPath, limitStatus); reason != "" {
+			eName = "ResourceLimitExceeded"
+			traceback = []string{reason, err.Error()}
+			message = reason
+		}
 
 		request.Hooks.OnExecuteError(&execute.ErrorOutput{
 			EName:     eName,
@@ -138,62 +189,103 @@ func (c *Controller) runCommand(ctx context.Context, request *ExecuteCodeRequest
 			Traceback: traceback,
 		})
 
-		log.Error("CommandExecError: error running commands: %v", err)
-		c.markCommandFinished(session, eCode, err.Error())
+		logger.Error("CommandExecError: error running commands: %v", err)
+		c.markCommandFinished(session, status.ExitCode, message)
+		request.Hooks.OnCommandExit(status)
 		return nil
 	}
 
 	c.markCommandFinished(session, 0, "")
 	request.Hooks.OnExecuteComplete(time.Since(startAt))
+	request.Hooks.OnCommandExit(CommandExitStatus{})
 	return nil
 }
 
+// terminateProcessGroup sends SIGTERM to the process group led by pid, then
+// SIGKILL once the leader exited or commandKillGrace elapsed, so that
+// descendants ignoring SIGTERM don't outlive the command.
+func terminateProcessGroup(pid int, exited <-chan struct{}) {
+	logger.Warning("Terminating the process group of command %d", pid)
+	_ = syscall.Kill(-pid, syscall.SIGTERM)
+	select {
+	case <-exited:
+	case <-time.After(commandKillGrace):
+		logger.Warning("Process group %d did not terminate after SIGTERM, using SIGKILL", pid)
+	}
+	_ = syscall.Kill(-pid, syscall.SIGKILL)
+}
+
+// signalName returns the conventional name of sig, such as SIGKILL.
+func signalName(sig syscall.Signal) string {
+	if name := unix.SignalName(sig); name != "" {
+		return name
+	}
+	return sig.String()
+}
+
 // runBackgroundCommand executes shell commands in detached mode.
 func (c *Controller) runBackgroundCommand(_ context.Context, request *ExecuteCodeRequest) error {
+	if err := ValidateCommandShell(request.Shell); err != nil {
+		return err
+	}
+	credential, err := resolveCredential(request.User, request.Group)
+	if err != nil {
+		return err
+	}
+
 	session := c.newContextID()
 	request.Hooks.OnExecuteInit(session)
 
-	pipe, err := c.combinedOutputDescriptor(session)
+	output, err := c.backgroundOutputDescriptor(session, request.SeparateStreams)
 	if err != nil {
-		return fmt.Errorf("failed to get combined output descriptor: %w", err)
+		return fmt.Errorf("failed to get background output descriptor: %w", err)
 	}
-	stdoutPath := c.combinedOutputFileName(session)
-	stderrPath := c.combinedOutputFileName(session)
-
-	signals := make(chan os.Signal, 1)
-	defer close(signals)
-	signal.Notify(signals)
-	defer signal.Reset()
 
 	startAt := time.Now()
-	log.Info("received command: %v", request.Code)
+	logger.Info("received command: %v", request.Code)
 	cmd := exec.CommandContext(context.Background(), "bash", "-c", request.Code)
 
 	cmd.Dir = request.Cwd
-	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
-	cmd.Stdout = pipe
-	cmd.Stderr = pipe
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true, Credential: credential}
+	cmd.Stdout = output.stdout
+	cmd.Stderr = output.stderr
 	cmd.Env = mergeEnvs(os.Environ(), loadExtraEnvFromFile())
+	limitStatus, err := applyResourceLimits(cmd, request.Limits)
+	if err != nil {
+		output.Close()
+		return err
+	}
 
 	// use DevNull as stdin so interactive programs exit immediately.
-	cmd.Stdin = os.NewFile(uintptr(syscall.Stdin), os.DevNull)
+	devNull, err := os.Open(os.DevNull)
+	if err != nil {
+		output.Close()
+		limitStatus.Close()
+		return fmt.Errorf("failed to open %s: %w", os.DevNull, err)
+	}
+	cmd.Stdin = devNull
 
 	safego.Go(func() {
-		defer pipe.Close()
+		defer output.Close()
+		defer devNull.Close()
+		defer limitStatus.Close()
 
 		err := cmd.Start()
 		kernel := &commandKernel{
-			pid:          -1,
-			stdoutPath:   stdoutPath,
-			stderrPath:   stderrPath,
-			startedAt:    startAt,
-			running:      true,
-			content:      request.Code,
-			isBackground: true,
+			pid:             -1,
+			stdoutPath:      output.stdoutPath,
+			stderrPath:      output.stderrPath,
+			combinedPath:    output.combinedPath,
+			logs:            output.logs,
+			separateStreams: request.SeparateStreams,
+			startedAt:       startAt,
+			running:         true,
+			content:         request.Code,
+			isBackground:    true,
 		}
 
 		if err != nil {
-			log.Error("CommandExecError: error starting commands: %v", err)
+			logger.Error("CommandExecError: error starting commands: %v", err)
 			kernel.running = false
 			c.storeCommandKernel(session, kernel)
 			c.markCommandFinished(session, 255, err.Error())
@@ -205,14 +297,19 @@ func (c *Controller) runBackgroundCommand(_ context.Context, request *ExecuteCod
 		c.storeCommandKernel(session, kernel)
 
 		err = cmd.Wait()
+		output.drain(backgroundOutputDrainTimeout)
 		if err != nil {
-			log.Error("CommandExecError: error running commands: %v", err)
+			logger.Error("CommandExecError: error running commands: %v", err)
 			exitCode := 1
 			var exitError *exec.ExitError
 			if errors.As(err, &exitError) {
 				exitCode = exitError.ExitCode()
 			}
-			c.markCommandFinished(session, exitCode, err.Error())
+			message := err.Error()
+			if reason := describeLimitExit(err, request.Limits, output.stderrPath, limitStatus); reason != "" {
+				message = reason
+			}
+			c.markCommandFinished(session, exitCode, message)
 			return
 		}
 		c.markCommandFinished(session, 0, "")
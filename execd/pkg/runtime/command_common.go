@@ -17,16 +17,47 @@ package runtime
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"errors"
 	"io"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"sync"
+	"syscall"
 	"time"
+
+	"github.com/alibaba/opensandbox/execd/pkg/jupyter/execute"
+	"github.com/alibaba/opensandbox/execd/pkg/util/safego"
 )
 
-// tailStdPipe streams appended log data until the process finishes.
-func (c *Controller) tailStdPipe(file string, onExecute func(text string), done <-chan struct{}) {
-	lastPos := int64(0)
+// commandExitStatus describes how a command whose Wait failed with err ended.
+func commandExitStatus(err error) CommandExitStatus {
+	status := CommandExitStatus{ExitCode: 1}
+	var exitError *exec.ExitError
+	if errors.As(err, &exitError) {
+		status.ExitCode = exitError.ExitCode()
+		if ws, ok := exitError.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+			status.Signal = signalName(ws.Signal())
+		}
+	}
+	return status
+}
+
+// tailStdPipe streams appended log data until the process finishes, numbering
+// the lines from 1.
+func (c *Controller) tailStdPipe(file string, onLine func(text string, position OutputPosition), done <-chan struct{}) {
+	var line int64
+	c.tailLog(file, 0, func(text string, next int64) {
+		line++
+		onLine(text, OutputPosition{Line: line, Offset: next})
+	}, done)
+}
+
+// tailLog streams the lines of a log from startPos on, each with the position
+// following it, until done is closed. Every call tails with its own position.
+func (c *Controller) tailLog(file string, startPos int64, onLine func(text string, next int64), done <-chan struct{}) {
+	lastPos := startPos
 	ticker := time.NewTicker(100 * time.Millisecond)
 	defer ticker.Stop()
 
@@ -34,15 +65,86 @@ func (c *Controller) tailStdPipe(file string, onExecute func(text string), done
 	for {
 		select {
 		case <-done:
-			c.readFromPos(mutex, file, lastPos, onExecute, true)
+			c.readLinesFromPos(mutex, file, lastPos, onLine, true)
 			return
 		case <-ticker.C:
-			newPos := c.readFromPos(mutex, file, lastPos, onExecute, false)
+			newPos := c.readLinesFromPos(mutex, file, lastPos, onLine, false)
 			lastPos = newPos
 		}
 	}
 }
 
+// startTailers streams the stdout and stderr files of a command to its hooks,
+// parsing progress if requested, until done is closed. The returned wait
+// blocks until both tailers stop and returns the *safego.PanicError of one
+// that panicked.
+func (c *Controller) startTailers(request *ExecuteCodeRequest, stdoutPath, stderrPath string, done <-chan struct{}) (wait func() error) {
+	onStdout := lineHook(request.Hooks.OnCommandStdout, request.Hooks.OnExecuteStdout)
+	onStderr := lineHook(request.Hooks.OnCommandStderr, request.Hooks.OnExecuteStderr)
+	if len(request.Progress) > 0 {
+		parser := newProgressParser(request.Progress, request.Hooks.OnExecuteProgress)
+		onStdout, onStderr = parser.wrap(onStdout), parser.wrap(onStderr)
+	}
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	var wg sync.WaitGroup
+	for _, tail := range []struct {
+		path      string
+		onExecute func(text string, position OutputPosition)
+	}{
+		{stdoutPath, onStdout},
+		{stderrPath, onStderr},
+	} {
+		wg.Add(1)
+		// a panic skips the wg.Done below, so the panic path records the cause first.
+		failed := func(cause error) {
+			cancel(cause)
+			wg.Done()
+		}
+		safego.GoWithContext(ctx, failed, func(context.Context) {
+			c.tailStdPipe(tail.path, tail.onExecute, done)
+			wg.Done()
+		})
+	}
+
+	return func() error {
+		wg.Wait()
+		defer cancel(nil)
+		var panicErr *safego.PanicError
+		if errors.As(context.Cause(ctx), &panicErr) {
+			return panicErr
+		}
+		return nil
+	}
+}
+
+// lineHook returns onLine, or one passing the text alone to onText when
+// onLine is unset.
+func lineHook(onLine func(text string, position OutputPosition), onText func(text string)) func(string, OutputPosition) {
+	if onLine != nil {
+		return onLine
+	}
+	return func(text string, _ OutputPosition) { onText(text) }
+}
+
+// reportLostOutput ends a command whose output tailer panicked with an error
+// event, since part of its output never reached the client. runErr is the
+// result of waiting for the command.
+func (c *Controller) reportLostOutput(session string, request *ExecuteCodeRequest, runErr, tailErr error) {
+	status := CommandExitStatus{}
+	if runErr != nil {
+		status = commandExitStatus(runErr)
+	}
+	request.Hooks.OnExecuteError(&execute.ErrorOutput{
+		EName:     "OutputStreamError",
+		EValue:    "command output was lost",
+		Traceback: []string{tailErr.Error()},
+	})
+	logger.Error("OutputStreamError: output tailer of %s panicked: %v", session, tailErr)
+	c.markCommandFinished(session, status.ExitCode, tailErr.Error())
+	request.Hooks.OnCommandExit(status)
+}
+
 // getCommandKernel retrieves a command execution context.
 func (c *Controller) getCommandKernel(sessionID string) *commandKernel {
 	c.mu.RLock()
@@ -56,6 +158,12 @@ func (c *Controller) storeCommandKernel(sessionID string, kernel *commandKernel)
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	if kernel.finished == nil {
+		kernel.finished = make(chan struct{})
+	}
+	if !kernel.running {
+		kernel.finish()
+	}
 	c.commandClientMap[sessionID] = kernel
 }
 
@@ -73,10 +181,155 @@ func (c *Controller) stdLogDescriptor(session string) (io.WriteCloser, io.WriteC
 	return stdout, stderr, nil
 }
 
-func (c *Controller) combinedOutputDescriptor(session string) (io.WriteCloser, error) {
+func (c *Controller) combinedOutputDescriptor(session string) (*os.File, error) {
 	return os.OpenFile(c.combinedOutputFileName(session), os.O_RDWR|os.O_CREATE|os.O_TRUNC, os.ModePerm)
 }
 
+// backgroundOutputDrainTimeout bounds how long a finished background command
+// waits for its buffered output to reach the log files.
+const backgroundOutputDrainTimeout = time.Second
+
+// backgroundOutput wires the log files of a background command.
+type backgroundOutput struct {
+	// stdout and stderr are handed to the command. They are always files, so
+	// os/exec doesn't start copy goroutines that daemonized children would
+	// keep cmd.Wait blocked on.
+	stdout       *os.File
+	stderr       *os.File
+	stdoutPath   string
+	stderrPath   string
+	combinedPath string
+	// logs holds the segmented logs by path when log rotation is enabled.
+	logs    map[string]*segmentedLog
+	closers []io.Closer
+	// copied is closed once every writer of the piped streams has gone away.
+	copied chan struct{}
+}
+
+// Close releases the descriptors held for the command. Log files fed through
+// pipes stay open until the last process writing to them, daemons included, exits.
+func (o *backgroundOutput) Close() {
+	for _, closer := range o.closers {
+		_ = closer.Close()
+	}
+}
+
+// drain closes the descriptors held for the command and waits up to timeout
+// for output it already wrote to reach the log files.
+func (o *backgroundOutput) drain(timeout time.Duration) {
+	o.Close()
+	if o.copied == nil {
+		return
+	}
+	select {
+	case <-o.copied:
+	case <-time.After(timeout):
+	}
+}
+
+// backgroundOutputDescriptor opens the log files of a background command. By default
+// both streams share the combined log; with separate set stdout and stderr are also
+// written to their own files so they can be read independently.
+func (c *Controller) backgroundOutputDescriptor(session string, separate bool) (*backgroundOutput, error) {
+	if segmentSize, compress := c.commandLogRotation(); segmentSize > 0 {
+		return c.segmentedOutputDescriptor(session, separate, segmentSize, compress)
+	}
+
+	combined, err := c.combinedOutputDescriptor(session)
+	if err != nil {
+		return nil, err
+	}
+
+	combinedPath := c.combinedOutputFileName(session)
+	if !separate {
+		return &backgroundOutput{
+			stdout:       combined,
+			stderr:       combined,
+			stdoutPath:   combinedPath,
+			stderrPath:   combinedPath,
+			combinedPath: combinedPath,
+			closers:      []io.Closer{combined},
+		}, nil
+	}
+
+	stdout, stderr, err := c.stdLogDescriptor(session)
+	if err != nil {
+		combined.Close()
+		return nil, err
+	}
+
+	output, err := teeStreams(stdout, stderr, combined)
+	if err != nil {
+		return nil, err
+	}
+	output.stdoutPath = c.stdoutFileName(session)
+	output.stderrPath = c.stderrFileName(session)
+	output.combinedPath = combinedPath
+	return output, nil
+}
+
+// teeStreams pipes stdout and stderr of a command into their own logs and the
+// shared combined log, closing the logs once every writer has gone away.
+func teeStreams(stdout, stderr, combined io.WriteCloser) (*backgroundOutput, error) {
+	stdoutReader, stdoutWriter, err := os.Pipe()
+	if err != nil {
+		closeAll(stdout, stderr, combined)
+		return nil, err
+	}
+	stderrReader, stderrWriter, err := os.Pipe()
+	if err != nil {
+		closeAll(stdout, stderr, combined, stdoutReader, stdoutWriter)
+		return nil, err
+	}
+
+	// both streams are teed into their own log and the shared combined log.
+	shared := &syncWriter{w: combined}
+	copied := make(chan struct{})
+	var copies sync.WaitGroup
+	copies.Add(2)
+	for _, stream := range []struct {
+		reader *os.File
+		log    io.WriteCloser
+	}{{stdoutReader, stdout}, {stderrReader, stderr}} {
+		safego.Go(func() {
+			defer copies.Done()
+			_, _ = io.Copy(io.MultiWriter(stream.log, shared), stream.reader)
+			closeAll(stream.reader, stream.log)
+		})
+	}
+	safego.Go(func() {
+		copies.Wait()
+		_ = combined.Close()
+		close(copied)
+	})
+
+	return &backgroundOutput{
+		stdout:  stdoutWriter,
+		stderr:  stderrWriter,
+		closers: []io.Closer{stdoutWriter, stderrWriter},
+		copied:  copied,
+	}, nil
+}
+
+// closeAll closes every closer, ignoring errors.
+func closeAll(closers ...io.Closer) {
+	for _, closer := range closers {
+		_ = closer.Close()
+	}
+}
+
+// syncWriter serializes writes to the underlying writer.
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
+
 // stdoutFileName constructs the stdout log path.
 func (c *Controller) stdoutFileName(session string) string {
 	return filepath.Join(os.TempDir(), session+".stdout")
@@ -93,6 +346,11 @@ func (c *Controller) combinedOutputFileName(session string) string {
 
 // readFromPos streams new content from a file starting at startPos.
 func (c *Controller) readFromPos(mutex *sync.Mutex, filepath string, startPos int64, onExecute func(string), flushIncomplete bool) int64 {
+	return c.readLinesFromPos(mutex, filepath, startPos, func(text string, _ int64) { onExecute(text) }, flushIncomplete)
+}
+
+// readLinesFromPos is readFromPos also passing the position following each line.
+func (c *Controller) readLinesFromPos(mutex *sync.Mutex, filepath string, startPos int64, onExecute func(string, int64), flushIncomplete bool) int64 {
 	if !mutex.TryLock() {
 		return -1
 	}
@@ -116,7 +374,7 @@ func (c *Controller) readFromPos(mutex *sync.Mutex, filepath string, startPos in
 			if err == io.EOF {
 				// If buffer has content but no newline, flush if needed, otherwise wait for next read
 				if flushIncomplete && buffer.Len() > 0 {
-					onExecute(buffer.String())
+					onExecute(buffer.String(), currentPos)
 					buffer.Reset()
 				}
 			}
@@ -128,7 +386,7 @@ func (c *Controller) readFromPos(mutex *sync.Mutex, filepath string, startPos in
 		if b == '\n' || b == '\r' {
 			// If buffer has content, output this line
 			if buffer.Len() > 0 {
-				onExecute(buffer.String())
+				onExecute(buffer.String(), currentPos)
 				buffer.Reset()
 			}
 			// Skip line terminator
@@ -145,3 +403,27 @@ func (c *Controller) readFromPos(mutex *sync.Mutex, filepath string, startPos in
 	}
 	return endPos
 }
+
+// defaultForwardedSignals are relayed to the process group of foreground
+// commands until SetForwardedSignals configures others.
+var defaultForwardedSignals = []os.Signal{syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT}
+
+// SetForwardedSignals sets the signals execd relays to the process group of
+// foreground commands while they run; other signals keep their default
+// handling. An empty list forwards none.
+func (c *Controller) SetForwardedSignals(signals []os.Signal) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.forwardSignals = append(make([]os.Signal, 0, len(signals)), signals...)
+}
+
+func (c *Controller) forwardedSignals() []os.Signal {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.forwardSignals == nil {
+		return defaultForwardedSignals
+	}
+	return c.forwardSignals
+}
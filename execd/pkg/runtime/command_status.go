@@ -15,10 +15,16 @@
 package runtime
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"time"
+
+	"github.com/alibaba/opensandbox/execd/pkg/util/safego"
 )
 
 // CommandStatus describes the lifecycle state of a command.
@@ -71,20 +77,74 @@ func (c *Controller) GetCommandStatus(session string) (*CommandStatus, error) {
 	return status, nil
 }
 
+// OutputStream selects which log of a command to read.
+type OutputStream string
+
+const (
+	OutputStreamCombined OutputStream = "combined"
+	OutputStreamStdout   OutputStream = "stdout"
+	OutputStreamStderr   OutputStream = "stderr"
+)
+
+// outputPath resolves the log file backing stream. Foreground commands only
+// record stdout and stderr, each in its own log.
+func (k *commandKernel) outputPath(stream OutputStream) (string, error) {
+	switch stream {
+	case OutputStreamCombined:
+		if !k.isBackground {
+			return "", fmt.Errorf("%s is not available for foreground commands, read stdout or stderr", stream)
+		}
+		if k.separateStreams {
+			return k.combinedPath, nil
+		}
+		// without separation both streams share a single log.
+		return k.stdoutPath, nil
+	case OutputStreamStdout, OutputStreamStderr:
+		if k.isBackground && !k.separateStreams {
+			return "", fmt.Errorf("%s is not available, command output was not separated", stream)
+		}
+		if stream == OutputStreamStdout {
+			return k.stdoutPath, nil
+		}
+		return k.stderrPath, nil
+	default:
+		return "", fmt.Errorf("unknown output stream: %s", stream)
+	}
+}
+
 // SeekBackgroundCommandOutput returns accumulated stdout/stderr and status for a session.
 func (c *Controller) SeekBackgroundCommandOutput(session string, cursor int64) ([]byte, int64, error) {
+	return c.SeekCommandStream(session, OutputStreamCombined, cursor)
+}
+
+// SeekCommandStream returns the output of one stream from cursor on, plus the next cursor.
+// Each stream is a separate log, so cursors are not interchangeable between streams.
+// Foreground commands can be read too, e.g. by clients that missed their live output.
+func (c *Controller) SeekCommandStream(session string, stream OutputStream, cursor int64) ([]byte, int64, error) {
 	kernel := c.commandSnapshot(session)
 	if kernel == nil {
 		return nil, -1, fmt.Errorf("command not found: %s", session)
 	}
 
-	if !kernel.isBackground {
-		return nil, -1, fmt.Errorf("command %s is not running in background", session)
+	if stream == "" {
+		stream = OutputStreamCombined
+	}
+	path, err := kernel.outputPath(stream)
+	if err != nil {
+		return nil, -1, err
 	}
 
-	file, err := os.Open(kernel.stdoutPath)
+	if segmented := kernel.logs[path]; segmented != nil {
+		data, next, err := segmented.ReadFrom(cursor)
+		if err != nil {
+			return nil, -1, commandOutputOpenError(session, stream, err)
+		}
+		return data, next, nil
+	}
+
+	file, err := os.Open(path)
 	if err != nil {
-		return nil, -1, fmt.Errorf("error open combined output file for command %s: %w", session, err)
+		return nil, -1, commandOutputOpenError(session, stream, err)
 	}
 	defer file.Close()
 
@@ -109,6 +169,117 @@ func (c *Controller) SeekBackgroundCommandOutput(session string, cursor int64) (
 	return data, currentPos, nil
 }
 
+// CommandLog is one log of a command opened for reading from its start. The
+// ModTime of a rotated log is zero.
+type CommandLog struct {
+	io.ReadSeeker
+	Name    string
+	ModTime time.Time
+	closer  io.Closer
+}
+
+// Close releases the log file.
+func (l *CommandLog) Close() error {
+	if l.closer == nil {
+		return nil
+	}
+	return l.closer.Close()
+}
+
+// OpenCommandStream opens the log backing one stream of a command, e.g. to
+// serve ranges of it. A rotated log is read into memory whole.
+func (c *Controller) OpenCommandStream(session string, stream OutputStream) (*CommandLog, error) {
+	kernel := c.commandSnapshot(session)
+	if kernel == nil {
+		return nil, fmt.Errorf("command not found: %s", session)
+	}
+	path, err := kernel.outputPath(stream)
+	if err != nil {
+		return nil, err
+	}
+	name := fmt.Sprintf("%s.%s", session, stream)
+
+	if segmented := kernel.logs[path]; segmented != nil {
+		data, _, err := segmented.ReadFrom(0)
+		if err != nil {
+			return nil, commandOutputOpenError(session, stream, err)
+		}
+		return &CommandLog{ReadSeeker: bytes.NewReader(data), Name: name}, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, commandOutputOpenError(session, stream, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error stat %s output file for command %s: %w", stream, session, err)
+	}
+	return &CommandLog{ReadSeeker: file, Name: name, ModTime: info.ModTime(), closer: file}, nil
+}
+
+// commandOutputOpenError wraps an error opening a command log, reporting logs
+// deleted from disk, e.g. by a temporary file cleaner, as ErrCommandOutputRemoved.
+func commandOutputOpenError(session string, stream OutputStream, err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w: %s output of command %s", ErrCommandOutputRemoved, stream, session)
+	}
+	return fmt.Errorf("error open %s output of command %s: %w", stream, session, err)
+}
+
+// CommandFollower tails one log of a command as it grows.
+type CommandFollower struct {
+	c        *Controller
+	session  string
+	path     string
+	cursor   int64
+	finished <-chan struct{}
+}
+
+// FollowCommandStream prepares to tail the log of stream from cursor on.
+// Rotated logs can't be followed; read them with SeekCommandStream instead.
+func (c *Controller) FollowCommandStream(session string, stream OutputStream, cursor int64) (*CommandFollower, error) {
+	kernel := c.commandSnapshot(session)
+	if kernel == nil {
+		return nil, fmt.Errorf("command not found: %s", session)
+	}
+
+	if stream == "" {
+		stream = OutputStreamCombined
+	}
+	path, err := kernel.outputPath(stream)
+	if err != nil {
+		return nil, err
+	}
+	if kernel.logs[path] != nil {
+		return nil, fmt.Errorf("the %s output of command %s is rotated and can't be followed, read it with cursors", stream, session)
+	}
+	if _, err := os.Stat(path); err != nil {
+		return nil, commandOutputOpenError(session, stream, err)
+	}
+	return &CommandFollower{c: c, session: session, path: path, cursor: cursor, finished: kernel.finished}, nil
+}
+
+// Follow calls onLine with every line of the log and the cursor following it
+// until the command finishes, then returns its final status. It returns
+// ctx.Err() when ctx is done first.
+func (f *CommandFollower) Follow(ctx context.Context, onLine func(text string, next int64)) (*CommandStatus, error) {
+	done := make(chan struct{})
+	safego.Go(func() {
+		defer close(done)
+		select {
+		case <-f.finished:
+		case <-ctx.Done():
+		}
+	})
+	f.c.tailLog(f.path, f.cursor, onLine, done)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return f.c.GetCommandStatus(f.session)
+}
+
 // markCommandFinished updates bookkeeping when a command exits.
 func (c *Controller) markCommandFinished(session string, exitCode int, errMsg string) {
 	now := time.Now()
@@ -125,4 +296,5 @@ func (c *Controller) markCommandFinished(session string, exitCode int, errMsg st
 	kernel.errMsg = errMsg
 	kernel.running = false
 	kernel.finishedAt = &now
+	kernel.finish()
 }
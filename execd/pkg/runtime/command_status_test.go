@@ -16,8 +16,12 @@ package runtime
 
 import (
 	"context"
+	"errors"
+	"io"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -185,3 +189,293 @@ func TestSeekBackgroundCommandOutput_WithRunBackgroundCommand(t *testing.T) {
 		t.Fatalf("cursor should not move backwards: got %d < %d", cursor2, cursor)
 	}
 }
+
+func TestSeekCommandStream_SeparatedStreams(t *testing.T) {
+	c := NewController("", "")
+
+	var session string
+	req := &ExecuteCodeRequest{
+		Language:        BackgroundCommand,
+		Code:            "echo out1; echo err1 >&2; echo out2",
+		SeparateStreams: true,
+		Hooks: ExecuteResultHook{
+			OnExecuteInit:     func(id string) { session = id },
+			OnExecuteComplete: func(executionTime time.Duration) {},
+		},
+	}
+	if err := c.runBackgroundCommand(context.Background(), req); err != nil {
+		t.Fatalf("runBackgroundCommand error: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if status, err := c.GetCommandStatus(session); err == nil && !status.Running {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	stdout, stdoutCursor, err := c.SeekCommandStream(session, OutputStreamStdout, 0)
+	if err != nil {
+		t.Fatalf("seek stdout: %v", err)
+	}
+	if string(stdout) != "out1\nout2\n" {
+		t.Fatalf("unexpected stdout: %q", string(stdout))
+	}
+
+	stderr, stderrCursor, err := c.SeekCommandStream(session, OutputStreamStderr, 0)
+	if err != nil {
+		t.Fatalf("seek stderr: %v", err)
+	}
+	if string(stderr) != "err1\n" {
+		t.Fatalf("unexpected stderr: %q", string(stderr))
+	}
+	if stdoutCursor != int64(len(stdout)) || stderrCursor != int64(len(stderr)) {
+		t.Fatalf("expected independent cursors, got stdout=%d stderr=%d", stdoutCursor, stderrCursor)
+	}
+
+	combined, _, err := c.SeekBackgroundCommandOutput(session, 0)
+	if err != nil {
+		t.Fatalf("seek combined: %v", err)
+	}
+	if len(combined) != len(stdout)+len(stderr) {
+		t.Fatalf("expected combined log to hold both streams, got %q", string(combined))
+	}
+}
+
+func TestRunBackgroundCommand_SeparatedStreamsDoNotWaitForDaemons(t *testing.T) {
+	c := NewController("", "")
+	pidFile := filepath.Join(t.TempDir(), "daemon.pid")
+	t.Cleanup(func() {
+		if pid, err := os.ReadFile(pidFile); err == nil {
+			_ = exec.Command("kill", strings.TrimSpace(string(pid))).Run()
+		}
+	})
+
+	var session string
+	req := &ExecuteCodeRequest{
+		Language: BackgroundCommand,
+		// the daemon inherits stdout and stderr and outlives the shell.
+		Code:            "echo started; sleep 30 & echo $! > " + pidFile,
+		SeparateStreams: true,
+		Hooks: ExecuteResultHook{
+			OnExecuteInit:     func(id string) { session = id },
+			OnExecuteComplete: func(executionTime time.Duration) {},
+		},
+	}
+	if err := c.runBackgroundCommand(context.Background(), req); err != nil {
+		t.Fatalf("runBackgroundCommand error: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		status, err := c.GetCommandStatus(session)
+		if err == nil && !status.Running {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("command still running while its daemon holds the output, status=%+v err=%v", status, err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	stdout, _, err := c.SeekCommandStream(session, OutputStreamStdout, 0)
+	if err != nil {
+		t.Fatalf("seek stdout: %v", err)
+	}
+	if string(stdout) != "started\n" {
+		t.Fatalf("unexpected stdout: %q", string(stdout))
+	}
+}
+
+func TestSeekCommandStream_RequiresSeparation(t *testing.T) {
+	c := NewController("", "")
+	c.storeCommandKernel("sess", &commandKernel{stdoutPath: filepath.Join(t.TempDir(), "out"), isBackground: true})
+
+	if _, _, err := c.SeekCommandStream("sess", OutputStreamStderr, 0); err == nil {
+		t.Fatalf("expected error reading stderr of a combined-only command")
+	}
+}
+
+func TestSeekCommandStream_ForegroundCommand(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not found in PATH")
+	}
+	c := NewController("", "")
+
+	var session string
+	req := &ExecuteCodeRequest{
+		Language: Command,
+		Code:     "echo out1; echo err1 >&2; echo out2",
+		Hooks: ExecuteResultHook{
+			OnExecuteInit: func(id string) { session = id },
+		},
+	}
+	if err := c.runCommand(context.Background(), req); err != nil {
+		t.Fatalf("runCommand error: %v", err)
+	}
+
+	stdout, cursor, err := c.SeekCommandStream(session, OutputStreamStdout, 0)
+	if err != nil {
+		t.Fatalf("seek stdout: %v", err)
+	}
+	if string(stdout) != "out1\nout2\n" || cursor != int64(len(stdout)) {
+		t.Fatalf("unexpected stdout %q at cursor %d", string(stdout), cursor)
+	}
+
+	stderr, _, err := c.SeekCommandStream(session, OutputStreamStderr, 0)
+	if err != nil {
+		t.Fatalf("seek stderr: %v", err)
+	}
+	if string(stderr) != "err1\n" {
+		t.Fatalf("unexpected stderr: %q", string(stderr))
+	}
+
+	if _, _, err := c.SeekCommandStream(session, OutputStreamCombined, 0); err == nil {
+		t.Fatalf("expected combined output to be unavailable for a foreground command")
+	}
+}
+
+func TestOpenCommandStream_ReportsRemovedLogs(t *testing.T) {
+	c := NewController("", "")
+	stdoutPath := filepath.Join(t.TempDir(), "sess.stdout")
+	if err := os.WriteFile(stdoutPath, []byte("hello"), 0o644); err != nil {
+		t.Fatalf("write stdout: %v", err)
+	}
+	c.storeCommandKernel("sess", &commandKernel{stdoutPath: stdoutPath})
+
+	log, err := c.OpenCommandStream("sess", OutputStreamStdout)
+	if err != nil {
+		t.Fatalf("open stdout: %v", err)
+	}
+	data, err := io.ReadAll(log)
+	log.Close()
+	if err != nil || string(data) != "hello" || log.Name != "sess.stdout" {
+		t.Fatalf("unexpected log %q named %s: %v", string(data), log.Name, err)
+	}
+
+	if err := os.Remove(stdoutPath); err != nil {
+		t.Fatalf("remove stdout: %v", err)
+	}
+	if _, err := c.OpenCommandStream("sess", OutputStreamStdout); !errors.Is(err, ErrCommandOutputRemoved) {
+		t.Fatalf("expected ErrCommandOutputRemoved opening a removed log, got %v", err)
+	}
+	if _, _, err := c.SeekCommandStream("sess", OutputStreamStdout, 0); !errors.Is(err, ErrCommandOutputRemoved) {
+		t.Fatalf("expected ErrCommandOutputRemoved seeking a removed log, got %v", err)
+	}
+}
+
+func TestFollowCommandStream_ConcurrentFollowers(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not found in PATH")
+	}
+	c := NewController("", "")
+
+	var session string
+	req := &ExecuteCodeRequest{
+		Language: BackgroundCommand,
+		Code:     "echo first; sleep 0.3; echo second; exit 2",
+		Hooks: ExecuteResultHook{
+			OnExecuteInit:     func(id string) { session = id },
+			OnExecuteComplete: func(time.Duration) {},
+		},
+	}
+	if err := c.runBackgroundCommand(context.Background(), req); err != nil {
+		t.Fatalf("runBackgroundCommand error: %v", err)
+	}
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+		if _, err := c.GetCommandStatus(session); err == nil {
+			break
+		}
+	}
+
+	type line struct {
+		text string
+		next int64
+	}
+	follow := func(cursor int64, lines *[]line, status **CommandStatus, errs chan<- error) {
+		follower, err := c.FollowCommandStream(session, OutputStreamCombined, cursor)
+		if err == nil {
+			*status, err = follower.Follow(context.Background(), func(text string, next int64) {
+				*lines = append(*lines, line{text, next})
+			})
+		}
+		errs <- err
+	}
+	var fromStart, fromCursor []line
+	var startStatus, cursorStatus *CommandStatus
+	errs := make(chan error, 2)
+	go follow(0, &fromStart, &startStatus, errs)
+	go follow(int64(len("first\n")), &fromCursor, &cursorStatus, errs)
+	for range 2 {
+		if err := <-errs; err != nil {
+			t.Fatalf("follow: %v", err)
+		}
+	}
+
+	if len(fromStart) != 2 || fromStart[0] != (line{"first", 6}) || fromStart[1] != (line{"second", 13}) {
+		t.Fatalf("unexpected lines from the start: %+v", fromStart)
+	}
+	if len(fromCursor) != 1 || fromCursor[0] != (line{"second", 13}) {
+		t.Fatalf("unexpected lines from the cursor: %+v", fromCursor)
+	}
+	for _, status := range []*CommandStatus{startStatus, cursorStatus} {
+		if status.Running || status.ExitCode == nil || *status.ExitCode != 2 {
+			t.Fatalf("unexpected final status %+v", status)
+		}
+	}
+}
+
+func TestRunCommand_OutputPositionsAreCursors(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not found in PATH")
+	}
+	c := NewController("", "")
+
+	type line struct {
+		text     string
+		position OutputPosition
+	}
+	var session string
+	var stdout, stderr []line
+	req := &ExecuteCodeRequest{
+		Language: Command,
+		Code:     `printf 'one\n\ntwo\r\nthree'; echo err >&2`,
+		Hooks: ExecuteResultHook{
+			OnExecuteInit:   func(id string) { session = id },
+			OnCommandStdout: func(text string, position OutputPosition) { stdout = append(stdout, line{text, position}) },
+			OnCommandStderr: func(text string, position OutputPosition) { stderr = append(stderr, line{text, position}) },
+		},
+	}
+	if err := c.runCommand(context.Background(), req); err != nil {
+		t.Fatalf("runCommand error: %v", err)
+	}
+
+	expected := []line{{"one", OutputPosition{Line: 1, Offset: 4}}, {"two", OutputPosition{Line: 2, Offset: 9}}, {"three", OutputPosition{Line: 3, Offset: 15}}}
+	if !slices.Equal(stdout, expected) {
+		t.Fatalf("unexpected stdout lines %+v, want %+v", stdout, expected)
+	}
+	if len(stderr) != 1 || stderr[0] != (line{"err", OutputPosition{Line: 1, Offset: 4}}) {
+		t.Fatalf("unexpected stderr lines %+v", stderr)
+	}
+
+	log, end, err := c.SeekCommandStream(session, OutputStreamStdout, 0)
+	if err != nil {
+		t.Fatalf("seek stdout: %v", err)
+	}
+	if end != stdout[len(stdout)-1].position.Offset {
+		t.Fatalf("expected the last offset %d to be the end cursor %d", stdout[len(stdout)-1].position.Offset, end)
+	}
+	for _, l := range stdout {
+		if !strings.HasSuffix(strings.TrimRight(string(log[:l.position.Offset]), "\r\n"), l.text) {
+			t.Fatalf("offset %d doesn't follow line %q in %q", l.position.Offset, l.text, log)
+		}
+		rest, _, err := c.SeekCommandStream(session, OutputStreamStdout, l.position.Offset)
+		if err != nil {
+			t.Fatalf("seek stdout from %d: %v", l.position.Offset, err)
+		}
+		if string(rest) != string(log[l.position.Offset:]) {
+			t.Fatalf("seeking from offset %d returned %q, want %q", l.position.Offset, rest, log[l.position.Offset:])
+		}
+	}
+}
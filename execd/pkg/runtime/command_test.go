@@ -16,9 +16,12 @@ package runtime
 
 import (
 	"context"
+	"errors"
 	"os"
 	"os/exec"
+	"os/user"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"testing"
@@ -27,6 +30,7 @@ import (
 	goruntime "runtime"
 
 	"github.com/alibaba/opensandbox/execd/pkg/jupyter/execute"
+	"github.com/shirou/gopsutil/process"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -188,6 +192,48 @@ func TestRunCommand_Echo(t *testing.T) {
 	}
 }
 
+func TestRunCommand_TailerPanicReportsLostOutput(t *testing.T) {
+	if goruntime.GOOS == "windows" {
+		t.Skip("bash not available on windows")
+	}
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not found in PATH")
+	}
+
+	c := NewController("", "")
+
+	var (
+		errOutput *execute.ErrorOutput
+		exit      *CommandExitStatus
+	)
+	req := &ExecuteCodeRequest{
+		Code: `echo "hello"; exit 3`,
+		Cwd:  t.TempDir(),
+		Hooks: ExecuteResultHook{
+			OnExecuteStdout: func(string) { panic("stdout hook failed") },
+			OnExecuteError:  func(err *execute.ErrorOutput) { errOutput = err },
+			OnExecuteComplete: func(time.Duration) {
+				t.Fatalf("a command whose output was lost must not complete normally")
+			},
+			OnCommandExit: func(status CommandExitStatus) { exit = &status },
+		},
+	}
+
+	if err := c.runCommand(context.Background(), req); err != nil {
+		t.Fatalf("runCommand returned error: %v", err)
+	}
+
+	if errOutput == nil || errOutput.EName != "OutputStreamError" {
+		t.Fatalf("expected an OutputStreamError, got %+v", errOutput)
+	}
+	if len(errOutput.Traceback) == 0 || !strings.Contains(errOutput.Traceback[0], "stdout hook failed") {
+		t.Fatalf("traceback does not carry the panic: %+v", errOutput.Traceback)
+	}
+	if exit == nil || exit.ExitCode != 3 {
+		t.Fatalf("expected exit code 3, got %+v", exit)
+	}
+}
+
 func TestRunCommand_Error(t *testing.T) {
 	if goruntime.GOOS == "windows" {
 		t.Skip("bash not available on windows")
@@ -253,3 +299,175 @@ func TestRunCommand_Error(t *testing.T) {
 		t.Fatalf("unexpected error payload: %+v", gotErr)
 	}
 }
+
+func TestRunCommand_AsUser(t *testing.T) {
+	if goruntime.GOOS == "windows" {
+		t.Skip("bash not available on windows")
+	}
+	if os.Geteuid() != 0 {
+		t.Skip("switching users requires root")
+	}
+	nobody, err := user.Lookup("nobody")
+	if err != nil {
+		t.Skip("user nobody not available")
+	}
+
+	c := NewController("", "")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var (
+		stdoutLines []string
+		completeCh  = make(chan struct{}, 1)
+	)
+	req := &ExecuteCodeRequest{
+		Code:    `id -u; id -g`,
+		Cwd:     "/",
+		User:    "nobody",
+		Timeout: 5 * time.Second,
+		Hooks: ExecuteResultHook{
+			OnExecuteInit:   func(string) {},
+			OnExecuteStdout: func(s string) { stdoutLines = append(stdoutLines, s) },
+			OnExecuteStderr: func(string) {},
+			OnExecuteError: func(err *execute.ErrorOutput) {
+				t.Fatalf("unexpected error hook: %+v", err)
+			},
+			OnExecuteComplete: func(_ time.Duration) { completeCh <- struct{}{} },
+		},
+	}
+
+	if err := c.runCommand(ctx, req); err != nil {
+		t.Fatalf("runCommand returned error: %v", err)
+	}
+	select {
+	case <-completeCh:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timeout waiting for completion hook")
+	}
+
+	if len(stdoutLines) != 2 || stdoutLines[0] != nobody.Uid || stdoutLines[1] != nobody.Gid {
+		t.Fatalf("expected uid %s and gid %s, got %#v", nobody.Uid, nobody.Gid, stdoutLines)
+	}
+}
+
+func TestExecute_TimeoutTerminatesProcessGroup(t *testing.T) {
+	if goruntime.GOOS == "windows" {
+		t.Skip("bash not available on windows")
+	}
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not found in PATH")
+	}
+
+	c := NewController("", "")
+	pidFile := filepath.Join(t.TempDir(), "child.pid")
+	var status CommandExitStatus
+	start := time.Now()
+	err := c.Execute(&ExecuteCodeRequest{
+		Language: Command,
+		Code:     "sleep 30 & echo $! > " + pidFile + "; wait",
+		Cwd:      t.TempDir(),
+		Timeout:  time.Second,
+		Hooks: ExecuteResultHook{
+			OnCommandExit: func(s CommandExitStatus) { status = s },
+		},
+	})
+	assert.NoError(t, err)
+	assert.Less(t, time.Since(start), 3*time.Second, "the command outlived its timeout")
+	assert.False(t, status.Success(), "unexpected exit status %+v", status)
+
+	data, err := os.ReadFile(pidFile)
+	if err != nil {
+		t.Fatalf("the command did not start its child: %v", err)
+	}
+	child, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		t.Fatalf("invalid child pid %q", data)
+	}
+	assert.Eventually(t, func() bool {
+		p, err := process.NewProcess(int32(child)) //nolint:gosec // pids fit in int32
+		if err != nil {
+			return true
+		}
+		state, err := p.Status()
+		// the child may linger as a zombie where nothing reaps orphans.
+		return err != nil || state == "Z"
+	}, 5*time.Second, 50*time.Millisecond, "the child of the command survived the timeout")
+}
+
+func TestValidateCommandUser(t *testing.T) {
+	if goruntime.GOOS == "windows" {
+		t.Skip("users are not supported on windows")
+	}
+
+	assert.NoError(t, ValidateCommandUser("", ""))
+
+	err := ValidateCommandUser("no-such-user-for-execd", "")
+	assert.True(t, errors.Is(err, ErrCommandUserNotFound), "unexpected error: %v", err)
+
+	err = ValidateCommandUser("", "no-such-group-for-execd")
+	assert.True(t, errors.Is(err, ErrCommandGroupNotFound), "unexpected error: %v", err)
+
+	current, err := user.Current()
+	if err != nil {
+		t.Skipf("current user unavailable: %v", err)
+	}
+	assert.NoError(t, ValidateCommandUser(current.Username, ""))
+
+	if os.Geteuid() != 0 {
+		err = ValidateCommandUser("0", "")
+		assert.True(t, errors.Is(err, ErrCommandUserNotPermitted), "unexpected error: %v", err)
+	}
+}
+
+func TestValidateCommandShell(t *testing.T) {
+	supported, unsupported := []string{"", ShellBash}, []string{ShellCmd, ShellPowerShell, ShellPwsh}
+	if goruntime.GOOS == "windows" {
+		supported, unsupported = []string{"", ShellCmd, ShellPowerShell, ShellPwsh}, []string{ShellBash}
+	}
+	for _, shell := range supported {
+		assert.NoError(t, ValidateCommandShell(shell), shell)
+	}
+	for _, shell := range append(unsupported, "zsh") {
+		err := ValidateCommandShell(shell)
+		assert.True(t, errors.Is(err, ErrShellUnsupported), "unexpected error for %q: %v", shell, err)
+	}
+}
+
+func TestInterrupt_RunningCommand(t *testing.T) {
+	if goruntime.GOOS == "windows" {
+		t.Skip("bash not available on windows")
+	}
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not found in PATH")
+	}
+
+	c := NewController("", "")
+	sessions := make(chan string, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- c.runCommand(context.Background(), &ExecuteCodeRequest{
+			Code: "sleep 30",
+			Cwd:  t.TempDir(),
+			Hooks: ExecuteResultHook{
+				OnExecuteInit: func(s string) { sessions <- s },
+			},
+		})
+	}()
+	session := <-sessions
+
+	interrupted, err := c.Interrupt(session)
+	assert.NoError(t, err)
+	assert.True(t, interrupted)
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("interrupted command did not exit")
+	}
+
+	interrupted, err = c.Interrupt(session)
+	assert.NoError(t, err)
+	assert.False(t, interrupted, "the command already exited")
+}
@@ -24,65 +24,99 @@ import (
 	"os"
 	"os/exec"
 	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/alibaba/opensandbox/execd/pkg/jupyter/execute"
-	"github.com/alibaba/opensandbox/execd/pkg/log"
 	"github.com/alibaba/opensandbox/execd/pkg/util/safego"
 )
 
 // runCommand executes shell commands and streams their output on Windows.
 func (c *Controller) runCommand(ctx context.Context, request *ExecuteCodeRequest) error {
+	if err := ValidateCommandUser(request.User, request.Group); err != nil {
+		return err
+	}
+	if request.PTY != nil {
+		return ErrPTYUnsupported
+	}
+	cmd, err := shellCommand(ctx, request.Shell, request.Code)
+	if err != nil {
+		return err
+	}
+
+	request.SetDefaultHooks()
 	session := c.newContextID()
-	request.Hooks.OnExecuteInit(session)
 
 	stdout, stderr, err := c.stdLogDescriptor(session)
 	if err != nil {
 		return fmt.Errorf("failed to get stdlog descriptor: %w", err)
 	}
+	stdoutPath := c.stdoutFileName(session)
+	stderrPath := c.stderrFileName(session)
 
 	startAt := time.Now()
-	log.Info("received command: %v", request.Code)
-	cmd := exec.CommandContext(ctx, "cmd", "/C", request.Code)
+	logger.Info("received command: %v", request.Code)
 
 	cmd.Stdout = stdout
 	cmd.Stderr = stderr
+	if request.Stdin != "" {
+		cmd.Stdin = strings.NewReader(request.Stdin)
+	}
 	cmd.Dir = request.Cwd
 	cmd.Env = mergeEnvs(os.Environ(), loadExtraEnvFromFile())
+	if _, err := applyResourceLimits(cmd, request.Limits); err != nil {
+		return err
+	}
 
 	done := make(chan struct{}, 1)
-	safego.Go(func() {
-		c.tailStdPipe(c.stdoutFileName(session), request.Hooks.OnExecuteStdout, done)
-	})
-	safego.Go(func() {
-		c.tailStdPipe(c.stderrFileName(session), request.Hooks.OnExecuteStderr, done)
-	})
+	waitTailers := c.startTailers(request, stdoutPath, stderrPath, done)
 
-	err = cmd.Start()
+	// a job object stands in for the process group, so that interrupts and
+	// timeouts terminate the descendants of the command too.
+	job, err := startInJob(cmd)
 	if err != nil {
+		request.Hooks.OnExecuteInit(session)
 		request.Hooks.OnExecuteError(&execute.ErrorOutput{EName: "CommandExecError", EValue: err.Error()})
-		log.Error("CommandExecError: error starting commands: %v", err)
+		logger.Error("CommandExecError: error starting commands: %v", err)
 		return nil
 	}
 
 	kernel := &commandKernel{
 		pid:          cmd.Process.Pid,
+		stdoutPath:   stdoutPath,
+		stderrPath:   stderrPath,
+		startedAt:    startAt,
+		running:      true,
 		content:      request.Code,
 		isBackground: false,
 	}
 	c.storeCommandKernel(session, kernel)
+	request.Hooks.OnExecuteInit(session)
 
 	err = cmd.Wait()
+	job.release()
 	close(done)
+	if tailErr := waitTailers(); tailErr != nil {
+		c.reportLostOutput(session, request, err, tailErr)
+		return nil
+	}
 	if err != nil {
 		var eName, eValue string
 		var traceback []string
 
+		status := commandExitStatus(err)
+		status.successCodes = request.SuccessExitCodes
 		var exitError *exec.ExitError
+		if errors.As(err, &exitError) && status.Success() {
+			c.markCommandFinished(session, status.ExitCode, "")
+			request.Hooks.OnExecuteComplete(time.Since(startAt))
+			request.Hooks.OnCommandExit(status)
+			return nil
+		}
 		if errors.As(err, &exitError) {
-			exitCode := exitError.ExitCode()
 			eName = "CommandExecError"
-			eValue = strconv.Itoa(exitCode)
+			eValue = strconv.Itoa(status.ExitCode)
 		} else {
 			eName = "CommandExecError"
 			eValue = err.Error()
@@ -95,62 +129,83 @@ func (c *Controller) runCommand(ctx context.Context, request *ExecuteCodeRequest
 			Traceback: traceback,
 		})
 
-		log.Error("CommandExecError: error running commands: %v", err)
+		logger.Error("CommandExecError: error running commands: %v", err)
+		c.markCommandFinished(session, status.ExitCode, err.Error())
+		request.Hooks.OnCommandExit(status)
 		return nil
 	}
+	c.markCommandFinished(session, 0, "")
 	request.Hooks.OnExecuteComplete(time.Since(startAt))
+	request.Hooks.OnCommandExit(CommandExitStatus{})
 	return nil
 }
 
+// signalName returns the name of sig; Windows processes are not ended by signals.
+func signalName(sig syscall.Signal) string {
+	return sig.String()
+}
+
 // runBackgroundCommand executes shell commands in detached mode on Windows.
 func (c *Controller) runBackgroundCommand(_ context.Context, request *ExecuteCodeRequest) error {
+	if err := ValidateCommandUser(request.User, request.Group); err != nil {
+		return err
+	}
+	cmd, err := shellCommand(context.Background(), request.Shell, request.Code)
+	if err != nil {
+		return err
+	}
+
 	session := c.newContextID()
 	request.Hooks.OnExecuteInit(session)
 
-	pipe, err := c.combinedOutputDescriptor(session)
+	output, err := c.backgroundOutputDescriptor(session, request.SeparateStreams)
 	if err != nil {
-		return fmt.Errorf("failed to get combined output descriptor: %w", err)
+		return fmt.Errorf("failed to get background output descriptor: %w", err)
 	}
-	stdoutPath := c.combinedOutputFileName(session)
-	stderrPath := c.combinedOutputFileName(session)
 
 	startAt := time.Now()
-	log.Info("received command: %v", request.Code)
-	cmd := exec.CommandContext(context.Background(), "cmd", "/C", request.Code)
+	logger.Info("received command: %v", request.Code)
 
 	cmd.Dir = request.Cwd
-	cmd.Stdout = pipe
-	cmd.Stderr = pipe
+	cmd.Stdout = output.stdout
+	cmd.Stderr = output.stderr
 	cmd.Env = mergeEnvs(os.Environ(), loadExtraEnvFromFile())
+	if _, err := applyResourceLimits(cmd, request.Limits); err != nil {
+		return err
+	}
 
 	devNull, _ := os.OpenFile(os.DevNull, os.O_RDWR, 0) // best-effort, ignore error
 	cmd.Stdin = devNull
 
 	safego.Go(func() {
-		err := cmd.Start()
+		job, err := startInJob(cmd)
 		if err != nil {
-			log.Error("CommandExecError: error starting commands: %v", err)
-			pipe.Close() // best-effort
+			logger.Error("CommandExecError: error starting commands: %v", err)
+			output.Close() // best-effort
 			return
 		}
 
 		kernel := &commandKernel{
-			pid:          cmd.Process.Pid,
-			content:      request.Code,
-			stdoutPath:   stdoutPath,
-			stderrPath:   stderrPath,
-			startedAt:    startAt,
-			running:      true,
-			isBackground: true,
+			pid:             cmd.Process.Pid,
+			content:         request.Code,
+			stdoutPath:      output.stdoutPath,
+			stderrPath:      output.stderrPath,
+			combinedPath:    output.combinedPath,
+			logs:            output.logs,
+			separateStreams: request.SeparateStreams,
+			startedAt:       startAt,
+			running:         true,
+			isBackground:    true,
 		}
 		c.storeCommandKernel(session, kernel)
 
 		err = cmd.Wait()
-		pipe.Close()    // best-effort
+		job.release()
+		output.drain(backgroundOutputDrainTimeout)
 		devNull.Close() // best-effort
 
 		if err != nil {
-			log.Error("CommandExecError: error running commands: %v", err)
+			logger.Error("CommandExecError: error running commands: %v", err)
 			exitCode := 1
 			var exitError *exec.ExitError
 			if errors.As(err, &exitError) {
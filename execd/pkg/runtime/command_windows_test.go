@@ -0,0 +1,145 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build windows
+// +build windows
+
+package runtime
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shirou/gopsutil/process"
+	"github.com/stretchr/testify/assert"
+)
+
+// powerShell returns the PowerShell available to run tests with.
+func powerShell(t *testing.T) string {
+	t.Helper()
+	for _, shell := range []string{ShellPwsh, ShellPowerShell} {
+		if _, err := exec.LookPath(shell); err == nil {
+			return shell
+		}
+	}
+	t.Skip("PowerShell not found in PATH")
+	return ""
+}
+
+func TestRunCommand_PowerShellKeepsMultiLineScripts(t *testing.T) {
+	c := NewController("", "")
+
+	var stdout []string
+	var status CommandExitStatus
+	err := c.runCommand(context.Background(), &ExecuteCodeRequest{
+		Shell: powerShell(t),
+		Code:  "$greeting = \"it's `\"quoted`\"\"\nWrite-Output $greeting\nWrite-Output 'second line'",
+		Cwd:   t.TempDir(),
+		Hooks: ExecuteResultHook{
+			OnExecuteStdout: func(s string) { stdout = append(stdout, s) },
+			OnCommandExit:   func(s CommandExitStatus) { status = s },
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{`it's "quoted"`, "second line"}, stdout)
+	assert.True(t, status.Success(), "unexpected exit status %+v", status)
+}
+
+func TestRunCommand_PowerShellExitCodes(t *testing.T) {
+	shell := powerShell(t)
+	c := NewController("", "")
+
+	cases := []struct {
+		code     string
+		exitCode int
+	}{
+		{"Write-Output before\nexit 3", 3},
+		{"cmd /c exit 5", 5},
+		{"throw 'failed'", 1},
+		{"cmd /c exit 5\nWrite-Output recovered", 0},
+	}
+	for _, tc := range cases {
+		var session string
+		var status CommandExitStatus
+		err := c.runCommand(context.Background(), &ExecuteCodeRequest{
+			Shell: shell,
+			Code:  tc.code,
+			Cwd:   t.TempDir(),
+			Hooks: ExecuteResultHook{
+				OnExecuteInit: func(s string) { session = s },
+				OnCommandExit: func(s CommandExitStatus) { status = s },
+			},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, tc.exitCode, status.ExitCode, "%q", tc.code)
+
+		commandStatus, err := c.GetCommandStatus(session)
+		if assert.NoError(t, err) && assert.NotNil(t, commandStatus.ExitCode) {
+			assert.Equal(t, tc.exitCode, *commandStatus.ExitCode, "%q", tc.code)
+		}
+	}
+}
+
+func TestInterrupt_TerminatesCommandDescendants(t *testing.T) {
+	shell := powerShell(t)
+	c := NewController("", "")
+
+	pidFile := filepath.Join(t.TempDir(), "child.pid")
+	sessions := make(chan string, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- c.runCommand(context.Background(), &ExecuteCodeRequest{
+			Shell: shell,
+			Code: "$child = Start-Process -PassThru -NoNewWindow ping -ArgumentList '-n','60','127.0.0.1'\n" +
+				"Set-Content -Path '" + pidFile + "' -Value $child.Id\n" +
+				"Wait-Process -Id $child.Id",
+			Cwd: t.TempDir(),
+			Hooks: ExecuteResultHook{
+				OnExecuteInit: func(s string) { sessions <- s },
+			},
+		})
+	}()
+	session := <-sessions
+
+	var childPid int
+	assert.Eventually(t, func() bool {
+		data, err := os.ReadFile(pidFile)
+		if err != nil {
+			return false
+		}
+		childPid, err = strconv.Atoi(strings.TrimSpace(string(data)))
+		return err == nil
+	}, 30*time.Second, 100*time.Millisecond, "the command did not start its child")
+
+	interrupted, err := c.Interrupt(session)
+	assert.NoError(t, err)
+	assert.True(t, interrupted)
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatalf("interrupted command did not exit")
+	}
+	assert.Eventually(t, func() bool {
+		exists, err := process.PidExists(int32(childPid)) //nolint:gosec // pids fit in int32
+		return err == nil && !exists
+	}, 5*time.Second, 100*time.Millisecond, "the child of the command survived the interrupt")
+}
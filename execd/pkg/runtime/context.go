@@ -15,65 +15,181 @@
 package runtime
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io/fs"
+	"maps"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
-	"k8s.io/client-go/util/retry"
+	"k8s.io/apimachinery/pkg/util/wait"
 
 	"github.com/alibaba/opensandbox/execd/pkg/jupyter"
+	jupyterkernel "github.com/alibaba/opensandbox/execd/pkg/jupyter/kernel"
 	jupytersession "github.com/alibaba/opensandbox/execd/pkg/jupyter/session"
-	"github.com/alibaba/opensandbox/execd/pkg/log"
 )
 
-// CreateContext provisions a kernel-backed session and returns its ID.
-func (c *Controller) CreateContext(req *CreateContextRequest) (string, error) {
-	var (
-		client  *jupyter.Client
-		session *jupytersession.Session
-		err     error
-	)
+// CreateContext provisions a kernel-backed session and returns its ID. Requests
+// repeating a recent IdempotencyKey get the context created for the first one.
+// Cancelling ctx stops the retries and removes any partially created session.
+func (c *Controller) CreateContext(ctx context.Context, req *CreateContextRequest) (string, error) {
+	sessionID, _, err := c.CreateOrReuseContext(ctx, req)
+	return sessionID, err
+}
 
-	err = retry.OnError(kernelWaitingBackoff, func(err error) bool {
-		log.Error("failed to create session, retrying: %v", err)
-		return err != nil
-	}, func() error {
-		client, session, err = c.createContext(*req)
-		return err
-	})
+// CreateOrReuseContext is CreateContext also reporting whether the context was
+// created for an earlier request with the same IdempotencyKey. Concurrent
+// requests with one key wait for a single creation.
+func (c *Controller) CreateOrReuseContext(ctx context.Context, req *CreateContextRequest) (string, bool, error) {
+	if req.IdempotencyKey == "" {
+		sessionID, err := c.provisionContext(ctx, req)
+		return sessionID, false, err
+	}
+
+	entry, owner := c.claimIdempotencyKey(req.IdempotencyKey, req.Language)
+	if !owner {
+		if entry.language != req.Language {
+			return "", false, ErrIdempotencyKeyConflict
+		}
+		select {
+		case <-entry.done:
+			return entry.sessionID, entry.err == nil, entry.err
+		case <-ctx.Done():
+			return "", false, ctx.Err()
+		}
+	}
+
+	sessionID, err := c.provisionContext(ctx, req)
+	c.releaseIdempotencyKey(req.IdempotencyKey, entry, sessionID, err)
+	return sessionID, false, err
+}
+
+// provisionContext creates the Jupyter session backing a new context.
+func (c *Controller) provisionContext(ctx context.Context, req *CreateContextRequest) (string, error) {
+	created, err := c.retryCreateContext(ctx, *req)
 	if err != nil {
 		return "", err
 	}
 
-	kernel := &jupyterKernel{
-		kernelID: session.Kernel.ID,
-		client:   client,
-		language: req.Language,
+	session := created.session
+	kernel := created.newKernel(req.Language)
+	if req.Cwd != "" {
+		if kernel.cwd, err = filepath.Abs(req.Cwd); err != nil {
+			kernel.cwd = req.Cwd
+		}
+	}
+	if req.EnvSnapshot {
+		kernel.environment, err = c.captureEnvironment(kernel)
+		if err != nil {
+			logger.Warning("failed to capture environment of context %s: %v", session.ID, err)
+		}
 	}
 	c.storeJupyterKernel(session.ID, kernel)
 
 	err = c.setWorkingDir(kernel, req)
 	if err != nil {
+		c.discardContext(session.ID)
 		return "", fmt.Errorf("failed to setup working dir: %w", err)
 	}
 
+	// the env file is read anew so contexts pick up its changes like commands do.
+	env := loadExtraEnvFromFile()
+	if env == nil && len(req.Env) > 0 {
+		env = make(map[string]string, len(req.Env))
+	}
+	maps.Copy(env, req.Env)
+	err = c.setEnv(kernel, env)
+	if err != nil {
+		c.discardContext(session.ID)
+		return "", fmt.Errorf("failed to set environment: %w", err)
+	}
+
+	err = c.runPreamble(ctx, kernel)
+	if err != nil {
+		c.discardContext(session.ID)
+		return "", err
+	}
+
+	setup, err := c.runSetup(ctx, kernel, req.Setup)
+	if err != nil {
+		c.discardContext(session.ID)
+		return "", err
+	}
+	if setup != nil && setup.Error != nil && !req.KeepOnSetupFailure {
+		c.discardContext(session.ID)
+		return "", setup.Error
+	}
+	c.mu.Lock()
+	kernel.setup = setup
+	c.mu.Unlock()
+
+	// the request may have gone away while the context was being set up.
+	if err := ctx.Err(); err != nil {
+		c.discardContext(session.ID)
+		return "", err
+	}
+
 	return session.ID, nil
 }
 
-func (c *Controller) DeleteContext(session string) error {
-	return c.deleteSessionAndCleanup(session)
+// DeleteContext deletes a context along with its notebook file, unless
+// keepNotebook is set, and the working directory created for it once empty.
+func (c *Controller) DeleteContext(session string, keepNotebook bool) error {
+	return c.deleteSessionAndCleanup(session, keepNotebook)
 }
 
-func (c *Controller) GetContext(session string) CodeContext {
+// GetContext describes a context, asking the Jupyter server for the state of
+// its kernel when refresh is set.
+func (c *Controller) GetContext(session string, refresh bool) (CodeContext, error) {
 	kernel := c.getJupyterKernel(session)
-	return CodeContext{
-		ID:       session,
-		Language: kernel.language,
+	if kernel == nil {
+		return CodeContext{}, ErrContextNotFound
+	}
+
+	run := kernel.state()
+	codeContext := CodeContext{
+		ID:             session,
+		Language:       kernel.language,
+		Cwd:            kernel.cwd,
+		CwdCreated:     kernel.createdDir != "",
+		Environment:    kernel.environment,
+		Setup:          c.contextSetup(kernel),
+		Busy:           run.busy,
+		ExecutionCount: run.executionCount,
+	}
+	if !run.lastUsedAt.IsZero() {
+		codeContext.LastUsedAt = &run.lastUsedAt
+	}
+	if !refresh {
+		return codeContext, nil
+	}
+
+	state, err := kernel.client.GetKernel(kernel.kernelID)
+	switch {
+	case errors.Is(err, jupyterkernel.ErrKernelNotFound):
+		codeContext.ExecutionState = KernelStateDead
+	case err != nil:
+		logger.Warning("failed to get kernel %s of context %s: %v", kernel.kernelID, session, err)
+	default:
+		codeContext.ExecutionState = state.ExecutionState
+		codeContext.Connections = state.Connections
+		if !state.LastActivity.IsZero() {
+			codeContext.LastActivity = &state.LastActivity
+		}
 	}
+	return codeContext, nil
+}
+
+// contextSetup returns how the setup cells of the context of kernel ran.
+func (c *Controller) contextSetup(kernel *jupyterKernel) *ContextSetup {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return kernel.setup
 }
 
 func (c *Controller) ListContext(language string) ([]CodeContext, error) {
@@ -100,15 +216,16 @@ func (c *Controller) DeleteLanguageContext(language Language) error {
 		}
 		seen[context.ID] = struct{}{}
 
-		if err := c.deleteSessionAndCleanup(context.ID); err != nil {
+		if err := c.deleteSessionAndCleanup(context.ID, false); err != nil {
 			return fmt.Errorf("error deleting context %s: %w", context.ID, err)
 		}
 	}
 	return nil
 }
 
-func (c *Controller) deleteSessionAndCleanup(session string) error {
-	if c.getJupyterKernel(session) == nil {
+func (c *Controller) deleteSessionAndCleanup(session string, keepNotebook bool) error {
+	kernel := c.getJupyterKernel(session)
+	if kernel == nil {
 		return ErrContextNotFound
 	}
 
@@ -125,16 +242,35 @@ func (c *Controller) deleteSessionAndCleanup(session string) error {
 			delete(c.defaultLanguageJupyterSessions, lang)
 		}
 	}
+	kernel.outputs().close()
+	kernel.removeFiles(keepNotebook)
 	return nil
 }
 
+// discardContext drops a context that failed to provision, deleting its
+// Jupyter session so the kernel doesn't outlive it.
+func (c *Controller) discardContext(session string) {
+	c.mu.Lock()
+	kernel := c.jupyterClientMap[session]
+	delete(c.jupyterClientMap, session)
+	c.mu.Unlock()
+
+	if err := c.jupyterClient().DeleteSession(session); err != nil {
+		logger.Error("failed to delete session %s of discarded context: %v", session, err)
+	}
+	if kernel != nil {
+		kernel.removeFiles(false)
+	}
+}
+
 func (c *Controller) newContextID() string {
 	return strings.ReplaceAll(uuid.New().String(), "-", "")
 }
 
 func (c *Controller) newIpynbPath(sessionID, cwd string) (string, error) {
 	if cwd != "" {
-		err := os.MkdirAll(cwd, os.ModePerm)
+		_, perm := c.cwdPolicy()
+		err := os.MkdirAll(cwd, perm)
 		if err != nil {
 			return "", err
 		}
@@ -143,62 +279,148 @@ func (c *Controller) newIpynbPath(sessionID, cwd string) (string, error) {
 	return filepath.Join(cwd, fmt.Sprintf("%s.ipynb", sessionID)), nil
 }
 
+// removeFiles deletes the notebook of the kernel, unless keepNotebook is set,
+// and the directory created for it when nothing else was put there.
+func (k *jupyterKernel) removeFiles(keepNotebook bool) {
+	if k.notebook != "" && !keepNotebook {
+		if err := os.Remove(k.notebook); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			logger.Warning("failed to remove notebook %s: %v", k.notebook, err)
+		}
+	}
+	if k.createdDir != "" {
+		// Remove refuses directories that aren't empty.
+		_ = os.Remove(k.createdDir)
+	}
+}
+
 // createDefaultLanguageContext prewarms a session for stateless execution.
 func (c *Controller) createDefaultLanguageContext(language Language) error {
-	var (
-		client  *jupyter.Client
-		session *jupytersession.Session
-		err     error
-	)
-	err = retry.OnError(kernelWaitingBackoff, func(err error) bool {
-		log.Error("failed to create context, retrying: %v", err)
-		return err != nil
-	}, func() error {
-		client, session, err = c.createContext(CreateContextRequest{
-			Language: language,
-			Cwd:      "",
-		})
-		return err
+	ctx := context.Background()
+	created, err := c.retryCreateContext(ctx, CreateContextRequest{
+		Language: language,
+		Cwd:      "",
 	})
 	if err != nil {
 		return err
 	}
 
+	session := created.session
+	kernel := created.newKernel(language)
+	if err := c.runPreamble(ctx, kernel); err != nil {
+		c.discardContext(session.ID)
+		return err
+	}
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	c.defaultLanguageJupyterSessions[language] = session.ID
-	c.jupyterClientMap[session.ID] = &jupyterKernel{
-		kernelID: session.Kernel.ID,
-		client:   client,
-		language: language,
-	}
+	c.jupyterClientMap[session.ID] = kernel
 	return nil
 }
 
-// createContext performs the actual context creation workflow.
-func (c *Controller) createContext(request CreateContextRequest) (*jupyter.Client, *jupytersession.Session, error) {
+// retryCreateContext calls createContext with kernelWaitingBackoff until it
+// succeeds, the backoff runs out or ctx is done. Running out of attempts
+// reports the last creation error, a done ctx reports ctx.Err(). Languages
+// without a kernel fail at once with ErrNoKernelForLanguage.
+func (c *Controller) retryCreateContext(ctx context.Context, request CreateContextRequest) (*createdContext, error) {
+	var (
+		created *createdContext
+		lastErr error
+	)
+
+	// the first attempt creates a missing cwd, tell it apart before.
+	createdDir := ""
+	if request.Cwd != "" {
+		_, missing, err := c.checkCwd(request.Cwd, request.CreateCwd)
+		if err != nil {
+			return nil, err
+		}
+		if missing {
+			createdDir = request.Cwd
+		}
+	}
+
+	err := wait.ExponentialBackoffWithContext(ctx, kernelWaitingBackoff, func(ctx context.Context) (bool, error) {
+		created, lastErr = c.createContext(ctx, request)
+		if errors.Is(lastErr, ErrNoKernelForLanguage) {
+			return false, lastErr
+		}
+		if lastErr != nil {
+			logger.Error("failed to create session, retrying: %v", lastErr)
+			return false, nil
+		}
+		return true, nil
+	})
+	if err != nil {
+		if ctx.Err() == nil && lastErr != nil {
+			return nil, lastErr
+		}
+		return nil, err
+	}
+	created.createdDir = createdDir
+	return created, nil
+}
+
+// createdContext is a Jupyter session created for a context, with the files
+// created along with it.
+type createdContext struct {
+	client  *jupyter.Client
+	session *jupytersession.Session
+	// notebook is the path of the session's .ipynb file.
+	notebook string
+	// createdDir is the working directory created for the context, if any.
+	createdDir string
+}
+
+// newKernel returns the kernel of the created session.
+func (c *createdContext) newKernel(language Language) *jupyterKernel {
+	return &jupyterKernel{
+		kernelID:   c.session.Kernel.ID,
+		client:     c.client,
+		language:   language,
+		notebook:   c.notebook,
+		createdDir: c.createdDir,
+	}
+}
+
+// createContext performs the actual context creation workflow, deleting the
+// session it created when a later step fails or ctx is done.
+func (c *Controller) createContext(ctx context.Context, request CreateContextRequest) (*createdContext, error) {
 	client := c.jupyterClient()
 
 	kernel, err := c.searchKernel(client, request.Language)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	sessionID := c.newContextID()
 	ipynb, err := c.newIpynbPath(sessionID, request.Cwd)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	jupyterSession, err := client.CreateSession(sessionID, ipynb, kernel)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
+	}
+
+	cleanup := func(err error) (*createdContext, error) {
+		if derr := client.DeleteSession(jupyterSession.ID); derr != nil {
+			logger.Error("failed to delete session %s of failed context: %v", jupyterSession.ID, derr)
+		}
+		if rerr := os.Remove(ipynb); rerr != nil && !errors.Is(rerr, fs.ErrNotExist) {
+			logger.Warning("failed to remove notebook %s of failed context: %v", ipynb, rerr)
+		}
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return cleanup(err)
 	}
 
 	kernels, err := client.ListKernels()
 	if err != nil {
-		return nil, nil, err
+		return cleanup(err)
 	}
 
 	found := false
@@ -209,10 +431,13 @@ func (c *Controller) createContext(request CreateContextRequest) (*jupyter.Clien
 		}
 	}
 	if !found {
-		return nil, nil, errors.New("kernel not found")
+		return cleanup(errors.New("kernel not found"))
+	}
+	if err := ctx.Err(); err != nil {
+		return cleanup(err)
 	}
 
-	return client, jupyterSession, nil
+	return &createdContext{client: client, session: jupyterSession, notebook: ipynb}, nil
 }
 
 // storeJupyterKernel caches a session -> kernel mapping.
@@ -231,9 +456,25 @@ func (c *Controller) jupyterClient() *jupyter.Client {
 		},
 	}
 
+	c.mu.RLock()
+	retries, delay := c.connectRetries, c.connectRetryDelay
+	c.mu.RUnlock()
+
 	return jupyter.NewClient(c.baseURL,
 		jupyter.WithToken(c.token),
-		jupyter.WithHTTPClient(httpClient))
+		jupyter.WithHTTPClient(httpClient),
+		jupyter.WithConnectRetry(retries, delay))
+}
+
+// SetKernelConnectRetry makes the connections to kernels retry up to retries
+// times, waiting delay before the first retry and doubling it after each one.
+// Contexts created before keep their setting.
+func (c *Controller) SetKernelConnectRetry(retries int, delay time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.connectRetries = retries
+	c.connectRetryDelay = delay
 }
 
 func (c *Controller) listAllContexts() ([]CodeContext, error) {
@@ -15,13 +15,24 @@
 package runtime
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/alibaba/opensandbox/execd/pkg/jupyter/execute"
 )
 
 func TestListContextsAndNewIpynbPath(t *testing.T) {
@@ -103,7 +114,7 @@ func TestListContextUnsupportedLanguage(t *testing.T) {
 
 func TestDeleteContext_NotFound(t *testing.T) {
 	c := NewController("", "")
-	err := c.DeleteContext("missing")
+	err := c.DeleteContext("missing", false)
 	if err == nil {
 		t.Fatalf("expected ErrContextNotFound")
 	}
@@ -131,7 +142,7 @@ func TestDeleteContext_RemovesCacheOnSuccess(t *testing.T) {
 	c.jupyterClientMap[sessionID] = &jupyterKernel{language: Python}
 	c.defaultLanguageJupyterSessions[Python] = sessionID
 
-	if err := c.DeleteContext(sessionID); err != nil {
+	if err := c.DeleteContext(sessionID, false); err != nil {
 		t.Fatalf("DeleteContext returned error: %v", err)
 	}
 
@@ -187,3 +198,539 @@ func TestDeleteLanguageContext_RemovesCacheOnSuccess(t *testing.T) {
 		t.Fatalf("unexpected delete calls: %+v", deleteCalls)
 	}
 }
+
+func TestCreateContext_EnvSnapshotReportedByGetContext(t *testing.T) {
+	server := newMockJupyter(t, func(conn *websocket.Conn, msg *execute.Message) {
+		switch execute.MessageType(msg.Header.MessageType) {
+		case execute.MsgKernelInfo:
+			replyMessage(t, conn, msg, execute.MsgKernelInfoReply, execute.KernelInfoReply{
+				Status:                "ok",
+				ProtocolVersion:       "5.3",
+				Implementation:        "ipython",
+				ImplementationVersion: "8.18.1",
+				LanguageInfo:          execute.LanguageInfo{Name: "python", Version: "3.11.4"},
+			})
+		case execute.MsgExecuteRequest:
+			var req execute.ExecuteRequest
+			if err := json.Unmarshal(msg.Content, &req); err != nil {
+				t.Errorf("unmarshal execute request: %v", err)
+				return
+			}
+			if !req.Silent || req.StoreHistory {
+				t.Errorf("expected silent probe without history, got %+v", req)
+			}
+			replyMessage(t, conn, msg, execute.MsgExecuteReply, execute.ExecuteReply{
+				Status: "ok",
+				UserExpressions: map[string]execute.UserExpressionResult{
+					packagesExpression: {Status: "ok", Data: map[string]interface{}{"text/plain": `'{"numpy": "1.26.0"}'`}},
+				},
+			})
+		}
+	})
+	defer server.Close()
+
+	c := NewController(server.URL, "token")
+	id, err := c.CreateContext(context.Background(), &CreateContextRequest{Language: Python, EnvSnapshot: true})
+	if err != nil {
+		t.Fatalf("CreateContext returned error: %v", err)
+	}
+
+	codeContext, err := c.GetContext(id, false)
+	if err != nil {
+		t.Fatalf("GetContext returned error: %v", err)
+	}
+	env := codeContext.Environment
+	if env == nil {
+		t.Fatalf("expected environment snapshot")
+	}
+	if env.Language != "python" || env.LanguageVersion != "3.11.4" {
+		t.Fatalf("unexpected language info: %+v", env)
+	}
+	if env.Implementation != "ipython" || env.ImplementationVersion != "8.18.1" {
+		t.Fatalf("unexpected implementation: %+v", env)
+	}
+	if env.Packages["numpy"] != "1.26.0" {
+		t.Fatalf("unexpected packages: %+v", env.Packages)
+	}
+}
+
+func TestParsePackageVersions(t *testing.T) {
+	cases := []struct {
+		repr     string
+		expected map[string]string
+	}{
+		{`'{"numpy": "1.26.0"}'`, map[string]string{"numpy": "1.26.0"}},
+		{`'{"it\'s": "1.0"}'`, map[string]string{"it's": "1.0"}},
+		{`'{"a\\\\b": "2.0"}'`, map[string]string{`a\b`: "2.0"}},
+		{`'{"del\x7f": "3.0"}'`, map[string]string{"del\x7f": "3.0"}},
+		{`"{\"quoted\": \"4.0\"}"`, map[string]string{"quoted": "4.0"}},
+	}
+	for _, tc := range cases {
+		packages, err := parsePackageVersions(map[string]any{"text/plain": tc.repr})
+		if err != nil {
+			t.Fatalf("parsePackageVersions(%s) returned error: %v", tc.repr, err)
+		}
+		if !reflect.DeepEqual(packages, tc.expected) {
+			t.Fatalf("parsePackageVersions(%s) = %v, want %v", tc.repr, packages, tc.expected)
+		}
+	}
+
+	for _, repr := range []string{`{"numpy": "1.26.0"}`, `'not json'`, `'unterminated`} {
+		if _, err := parsePackageVersions(map[string]any{"text/plain": repr}); err == nil {
+			t.Fatalf("expected %s to be rejected", repr)
+		}
+	}
+}
+
+func TestCreateContext_NotebookCodeRunsInCwd(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		sent []execute.ExecuteRequest
+	)
+	server := newMockJupyter(t, func(conn *websocket.Conn, msg *execute.Message) {
+		if execute.MessageType(msg.Header.MessageType) != execute.MsgExecuteRequest {
+			return
+		}
+		var req execute.ExecuteRequest
+		if err := json.Unmarshal(msg.Content, &req); err != nil {
+			t.Errorf("unmarshal execute request: %v", err)
+			return
+		}
+		mu.Lock()
+		sent = append(sent, req)
+		mu.Unlock()
+
+		replyMessage(t, conn, msg, execute.MsgExecuteReply, execute.ExecuteReply{Status: "ok", ExecutionCount: 1})
+		if !req.Silent {
+			replyMessage(t, conn, msg, execute.MsgStatus, execute.StatusUpdate{ExecutionState: execute.StateIdle})
+		}
+	})
+	defer server.Close()
+
+	cwd := filepath.Join(t.TempDir(), "work")
+	c := NewController(server.URL, "token")
+	id, err := c.CreateContext(context.Background(), &CreateContextRequest{Language: Python, Cwd: cwd, CreateCwd: true})
+	if err != nil {
+		t.Fatalf("CreateContext returned error: %v", err)
+	}
+
+	err = c.Execute(&ExecuteCodeRequest{
+		Language: Python,
+		Context:  id,
+		Code:     `open("out.txt", "w").write("ok")`,
+		Hooks:    ExecuteResultHook{OnExecuteInit: func(string) {}},
+	})
+	if err != nil {
+		t.Fatalf("Execute returned error: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(sent) != 2 {
+		t.Fatalf("expected chdir and user code to be executed, got %+v", sent)
+	}
+	want := "import os\nos.chdir(" + strconv.Quote(cwd) + ")"
+	if sent[0].Code != want || !sent[0].Silent {
+		t.Fatalf("expected silent chdir %q before user code, got %q (silent=%v)", want, sent[0].Code, sent[0].Silent)
+	}
+	if sent[1].Code != `open("out.txt", "w").write("ok")` {
+		t.Fatalf("unexpected user code: %q", sent[1].Code)
+	}
+}
+
+func TestCreateContext_DiscardsSessionWhenChdirFails(t *testing.T) {
+	var deleted atomic.Int32
+	handler := mockJupyterHandler(t, func(conn *websocket.Conn, msg *execute.Message) {
+		if execute.MessageType(msg.Header.MessageType) != execute.MsgExecuteRequest {
+			return
+		}
+		replyMessage(t, conn, msg, execute.MsgExecuteReply, execute.ExecuteReply{
+			Status:      "error",
+			ErrorOutput: execute.ErrorOutput{EName: "PermissionError", EValue: "permission denied"},
+		})
+	})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete && r.URL.Path == "/api/sessions/session-1" {
+			deleted.Add(1)
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	c := NewController(server.URL, "token")
+	_, err := c.CreateContext(context.Background(), &CreateContextRequest{Language: Python, Cwd: t.TempDir()})
+	if err == nil || !strings.Contains(err.Error(), "working dir") {
+		t.Fatalf("expected working dir error, got %v", err)
+	}
+
+	if deleted.Load() != 1 {
+		t.Fatalf("expected the Jupyter session to be deleted once, got %d", deleted.Load())
+	}
+	if c.getJupyterKernel("session-1") != nil {
+		t.Fatalf("expected failed context to be removed")
+	}
+}
+
+func TestCreateContext_IdempotencyKeyReusesContext(t *testing.T) {
+	var sessionsCreated atomic.Int32
+	handler := mockJupyterHandler(t, func(*websocket.Conn, *execute.Message) {})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && r.URL.Path == "/api/sessions" {
+			sessionsCreated.Add(1)
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	c := NewController(server.URL, "token")
+	req := &CreateContextRequest{Language: Python, IdempotencyKey: "retry-1"}
+
+	first, err := c.CreateContext(context.Background(), req)
+	if err != nil {
+		t.Fatalf("first CreateContext returned error: %v", err)
+	}
+	second, err := c.CreateContext(context.Background(), req)
+	if err != nil {
+		t.Fatalf("second CreateContext returned error: %v", err)
+	}
+
+	if first != second {
+		t.Fatalf("expected the same context for a repeated key, got %s and %s", first, second)
+	}
+	if n := sessionsCreated.Load(); n != 1 {
+		t.Fatalf("expected one session to be created, got %d", n)
+	}
+
+	_, err = c.CreateContext(context.Background(), &CreateContextRequest{Language: Go, IdempotencyKey: "retry-1"})
+	if !errors.Is(err, ErrIdempotencyKeyConflict) {
+		t.Fatalf("expected conflict for a reused key with another language, got %v", err)
+	}
+}
+
+func TestCreateOrReuseContext_CoalescesConcurrentRequests(t *testing.T) {
+	var sessionsCreated atomic.Int32
+	handler := mockJupyterHandler(t, func(*websocket.Conn, *execute.Message) {})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && r.URL.Path == "/api/sessions" {
+			sessionsCreated.Add(1)
+			// keeps the first creation running while the duplicates arrive.
+			time.Sleep(50 * time.Millisecond)
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	c := NewController(server.URL, "token")
+	const requests = 5
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		sessions = map[string]int{}
+		reused   int
+	)
+	for range requests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			session, ok, err := c.CreateOrReuseContext(context.Background(), &CreateContextRequest{Language: Python, IdempotencyKey: "agent-retry"})
+			if err != nil {
+				t.Errorf("CreateOrReuseContext returned error: %v", err)
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			sessions[session]++
+			if ok {
+				reused++
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := sessionsCreated.Load(); n != 1 {
+		t.Fatalf("expected one session to be created, got %d", n)
+	}
+	if len(sessions) != 1 || reused != requests-1 {
+		t.Fatalf("expected every duplicate to reuse one context, got %v with %d reused", sessions, reused)
+	}
+}
+
+func TestCreateContext_UnsupportedLanguageFailsWithoutRetrying(t *testing.T) {
+	var specLookups atomic.Int32
+	handler := mockJupyterHandler(t, func(*websocket.Conn, *execute.Message) {})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/kernelspecs" {
+			specLookups.Add(1)
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	c := NewController(server.URL, "token")
+	start := time.Now()
+	_, err := c.CreateContext(context.Background(), &CreateContextRequest{Language: Go})
+	if !errors.Is(err, ErrNoKernelForLanguage) {
+		t.Fatalf("expected ErrNoKernelForLanguage, got %v", err)
+	}
+	if err := c.createDefaultLanguageContext(Java); !errors.Is(err, ErrNoKernelForLanguage) {
+		t.Fatalf("expected ErrNoKernelForLanguage for the default context, got %v", err)
+	}
+
+	if n := specLookups.Load(); n != 2 {
+		t.Fatalf("expected one kernel spec lookup per request, got %d", n)
+	}
+	if elapsed := time.Since(start); elapsed > kernelWaitingBackoff.Duration {
+		t.Fatalf("expected the unsupported languages to fail at once, took %s", elapsed)
+	}
+}
+
+func TestGetContext_ReportsKernelState(t *testing.T) {
+	var kernelGone atomic.Bool
+	handler := mockJupyterHandler(t, func(conn *websocket.Conn, msg *execute.Message) {
+		if execute.MessageType(msg.Header.MessageType) != execute.MsgExecuteRequest {
+			return
+		}
+		replyMessage(t, conn, msg, execute.MsgExecuteReply, execute.ExecuteReply{Status: "ok", ExecutionCount: 1})
+		replyMessage(t, conn, msg, execute.MsgStatus, execute.StatusUpdate{ExecutionState: execute.StateIdle})
+	})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && r.URL.Path == "/api/kernels/"+mockKernelID {
+			if kernelGone.Load() {
+				http.NotFound(w, r)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"id": mockKernelID, "name": "ipython", "execution_state": "idle",
+				"connections": 2, "last_activity": "2026-01-02T03:04:05Z",
+			})
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	c := NewController(server.URL, "token")
+	id, err := c.CreateContext(context.Background(), &CreateContextRequest{Language: Python})
+	if err != nil {
+		t.Fatalf("CreateContext returned error: %v", err)
+	}
+	if _, err := c.GetContext("missing", true); !errors.Is(err, ErrContextNotFound) {
+		t.Fatalf("expected ErrContextNotFound, got %v", err)
+	}
+
+	codeContext, _ := c.GetContext(id, false)
+	if codeContext.Busy || codeContext.LastUsedAt != nil || codeContext.ExecutionState != "" {
+		t.Fatalf("unexpected state of an unused context without refresh: %+v", codeContext)
+	}
+
+	if err := c.Execute(&ExecuteCodeRequest{Language: Python, Context: id, Code: "1"}); err != nil {
+		t.Fatalf("Execute returned error: %v", err)
+	}
+	codeContext, _ = c.GetContext(id, true)
+	if codeContext.LastUsedAt == nil || codeContext.ExecutionState != "idle" || codeContext.Connections != 2 {
+		t.Fatalf("unexpected refreshed state: %+v", codeContext)
+	}
+	if codeContext.LastActivity == nil || !codeContext.LastActivity.Equal(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Fatalf("unexpected last activity %v", codeContext.LastActivity)
+	}
+
+	kernelGone.Store(true)
+	if codeContext, _ = c.GetContext(id, true); codeContext.ExecutionState != KernelStateDead {
+		t.Fatalf("expected a missing kernel to be reported dead, got %+v", codeContext)
+	}
+}
+
+func TestCreateContext_CancelStopsRetriesWithoutOrphans(t *testing.T) {
+	var created, deleted atomic.Int32
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	handler := mockJupyterHandler(t, func(*websocket.Conn, *execute.Message) {})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/sessions":
+			if created.Add(1) == 2 {
+				cancel()
+			}
+		case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/api/sessions/"):
+			deleted.Add(1)
+		case r.URL.Path == "/api/kernels":
+			// the new kernel never shows up, so every attempt is retried.
+			_ = json.NewEncoder(w).Encode([]map[string]any{})
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	c := NewController(server.URL, "token")
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.CreateContext(ctx, &CreateContextRequest{Language: Python})
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context.Canceled, got %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("CreateContext did not return after cancellation")
+	}
+
+	if created.Load() != 2 {
+		t.Fatalf("expected retries to stop after cancellation, got %d sessions", created.Load())
+	}
+	if created.Load() != deleted.Load() {
+		t.Fatalf("expected every created session to be deleted, created %d deleted %d", created.Load(), deleted.Load())
+	}
+	if contexts, _ := c.ListContext(""); len(contexts) != 0 {
+		t.Fatalf("expected no context to be stored, got %+v", contexts)
+	}
+}
+
+func TestDeleteContext_RemovesNotebookAndCreatedCwd(t *testing.T) {
+	url, _ := newPreambleKernel(t)
+	c := NewController(url, "token")
+	cwd := filepath.Join(t.TempDir(), "work")
+
+	session, err := c.CreateContext(context.Background(), &CreateContextRequest{Language: Python, Cwd: cwd, CreateCwd: true})
+	if err != nil {
+		t.Fatalf("CreateContext returned error: %v", err)
+	}
+	notebook := c.getJupyterKernel(session).notebook
+	if filepath.Dir(notebook) != cwd {
+		t.Fatalf("expected the notebook in %s, got %s", cwd, notebook)
+	}
+	// Jupyter saves the notebook once the session runs.
+	if err := os.WriteFile(notebook, []byte("{}"), 0o644); err != nil {
+		t.Fatalf("write notebook: %v", err)
+	}
+
+	if err := c.DeleteContext(session, false); err != nil {
+		t.Fatalf("DeleteContext returned error: %v", err)
+	}
+	if _, err := os.Stat(cwd); !os.IsNotExist(err) {
+		t.Fatalf("expected the created cwd to be removed, got %v", err)
+	}
+}
+
+func TestDeleteContext_KeepsNotebookAndUserFiles(t *testing.T) {
+	url, _ := newPreambleKernel(t)
+	c := NewController(url, "token")
+	cwd := filepath.Join(t.TempDir(), "work")
+
+	session, err := c.CreateContext(context.Background(), &CreateContextRequest{Language: Python, Cwd: cwd, CreateCwd: true})
+	if err != nil {
+		t.Fatalf("CreateContext returned error: %v", err)
+	}
+	notebook := c.getJupyterKernel(session).notebook
+	if err := os.WriteFile(notebook, []byte("{}"), 0o644); err != nil {
+		t.Fatalf("write notebook: %v", err)
+	}
+
+	if err := c.DeleteContext(session, true); err != nil {
+		t.Fatalf("DeleteContext returned error: %v", err)
+	}
+	if _, err := os.Stat(notebook); err != nil {
+		t.Fatalf("expected the notebook to be kept: %v", err)
+	}
+
+	// a cwd that already existed stays, even empty.
+	existing := t.TempDir()
+	session, err = c.CreateContext(context.Background(), &CreateContextRequest{Language: Python, Cwd: existing})
+	if err != nil {
+		t.Fatalf("CreateContext returned error: %v", err)
+	}
+	if err := c.DeleteContext(session, false); err != nil {
+		t.Fatalf("DeleteContext returned error: %v", err)
+	}
+	if _, err := os.Stat(existing); err != nil {
+		t.Fatalf("expected the existing cwd to be kept: %v", err)
+	}
+}
+
+func TestContextState_ListingWhileExecuting(t *testing.T) {
+	c := newEchoKernel(t)
+	hooks := ExecuteResultHook{
+		OnExecuteInit:     func(string) {},
+		OnExecuteResult:   func(map[string]any, int) {},
+		OnExecuteStatus:   func(string) {},
+		OnExecuteStdout:   func(string) {},
+		OnExecuteStderr:   func(string) {},
+		OnExecuteError:    func(*execute.ErrorOutput) {},
+		OnExecuteComplete: func(time.Duration) {},
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if _, err := c.ListContext(""); err != nil {
+					t.Errorf("ListContext returned error: %v", err)
+				}
+				if _, err := c.GetContext("session-1", false); err != nil {
+					t.Errorf("GetContext returned error: %v", err)
+				}
+			}
+		}()
+	}
+
+	// describing the context must never make it look busy to a run.
+	for i := range 20 {
+		err := c.Execute(&ExecuteCodeRequest{Language: Python, Context: "session-1", Code: strconv.Itoa(i), Hooks: hooks})
+		if err != nil {
+			t.Errorf("Execute %d returned error: %v", i, err)
+		}
+	}
+	close(done)
+	wg.Wait()
+
+	codeContext, err := c.GetContext("session-1", false)
+	if err != nil {
+		t.Fatalf("GetContext returned error: %v", err)
+	}
+	if codeContext.Busy || codeContext.LastUsedAt == nil {
+		t.Fatalf("unexpected state after the runs: %+v", codeContext)
+	}
+}
+
+func TestKernelState_BusyWhileLocked(t *testing.T) {
+	kernel := &jupyterKernel{language: Python}
+	if kernel.state().busy {
+		t.Fatalf("expected a fresh kernel to be idle")
+	}
+
+	kernel.lock()
+	if !kernel.state().busy || kernel.tryLock() {
+		t.Fatalf("expected a locked kernel to be busy and not lockable")
+	}
+	waiting := make(chan struct{})
+	go func() {
+		kernel.lock()
+		close(waiting)
+	}()
+	kernel.unlock()
+	<-waiting
+	if !kernel.state().busy {
+		t.Fatalf("expected the kernel to stay busy for the waiting run")
+	}
+	kernel.unlock()
+	if kernel.state().busy {
+		t.Fatalf("expected the kernel to be idle once freed")
+	}
+
+	kernel.setExecutionCount(3)
+	if count := kernel.state().executionCount; count != 3 {
+		t.Fatalf("expected execution count 3, got %d", count)
+	}
+}
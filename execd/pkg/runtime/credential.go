@@ -0,0 +1,103 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package runtime
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+	"syscall"
+)
+
+// ValidateCommandUser reports whether commands can be run as the given user and group.
+func ValidateCommandUser(userName, groupName string) error {
+	_, err := resolveCredential(userName, groupName)
+	return err
+}
+
+// resolveCredential looks up the user and group, by name or numeric id, a command
+// should run as. It returns nil when neither is set. The group defaults to the
+// user's primary group and the user to the current one.
+func resolveCredential(userName, groupName string) (*syscall.Credential, error) {
+	if userName == "" && groupName == "" {
+		return nil, nil
+	}
+
+	uid, gid := uint32(os.Geteuid()), uint32(os.Getegid()) //nolint:gosec // ids are non-negative
+	var groups []uint32
+
+	if userName != "" {
+		u, err := lookupUser(userName)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrCommandUserNotFound, userName)
+		}
+		if uid, err = parseID(u.Uid); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrCommandUserNotFound, userName)
+		}
+		if gid, err = parseID(u.Gid); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrCommandUserNotFound, userName)
+		}
+		if ids, err := u.GroupIds(); err == nil {
+			for _, id := range ids {
+				if parsed, err := parseID(id); err == nil {
+					groups = append(groups, parsed)
+				}
+			}
+		}
+	}
+
+	if groupName != "" {
+		g, err := lookupGroup(groupName)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrCommandGroupNotFound, groupName)
+		}
+		if gid, err = parseID(g.Gid); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrCommandGroupNotFound, groupName)
+		}
+	}
+
+	credential := &syscall.Credential{Uid: uid, Gid: gid, Groups: groups}
+	if os.Geteuid() != 0 {
+		// without root only the current identity can be kept.
+		if uid != uint32(os.Geteuid()) || gid != uint32(os.Getegid()) { //nolint:gosec // ids are non-negative
+			return nil, ErrCommandUserNotPermitted
+		}
+		credential.NoSetGroups = true
+	}
+	return credential, nil
+}
+
+func lookupUser(name string) (*user.User, error) {
+	if _, err := strconv.ParseUint(name, 10, 32); err == nil {
+		return user.LookupId(name)
+	}
+	return user.Lookup(name)
+}
+
+func lookupGroup(name string) (*user.Group, error) {
+	if _, err := strconv.ParseUint(name, 10, 32); err == nil {
+		return user.LookupGroupId(name)
+	}
+	return user.LookupGroup(name)
+}
+
+func parseID(id string) (uint32, error) {
+	parsed, err := strconv.ParseUint(id, 10, 32)
+	return uint32(parsed), err
+}
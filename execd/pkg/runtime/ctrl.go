@@ -18,12 +18,15 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"k8s.io/apimachinery/pkg/util/wait"
 
 	"github.com/alibaba/opensandbox/execd/pkg/jupyter"
+	"github.com/alibaba/opensandbox/execd/pkg/log"
 )
 
 var kernelWaitingBackoff = wait.Backoff{
@@ -33,6 +36,9 @@ var kernelWaitingBackoff = wait.Backoff{
 	Jitter:   0.1,
 }
 
+// logger logs for command and code execution.
+var logger = log.For("runtime")
+
 // Controller manages code execution across runtimes.
 type Controller struct {
 	baseURL                        string
@@ -41,15 +47,127 @@ type Controller struct {
 	jupyterClientMap               map[string]*jupyterKernel
 	defaultLanguageJupyterSessions map[Language]string
 	commandClientMap               map[string]*commandKernel
+	sqlQueryMap                    map[string]*sqlQuery
+	idempotencyKeys                map[string]*idempotencyEntry
+	contextPreambles               map[Language]string
+	logSegmentSize                 int64
+	logCompress                    bool
+	connectRetries                 int
+	connectRetryDelay              time.Duration
+	forwardSignals                 []os.Signal
 	db                             *sql.DB
 	dbOnce                         sync.Once
+	sqlConnections                 map[string]*sqlConnection
+	languageProbe                  []LanguageSupport
+	cwdRoot                        string
+	cwdPerm                        os.FileMode
 }
 
+// jupyterKernel is a context backed by a Jupyter kernel. kernelID, client,
+// language, cwd, notebook and createdDir are set when the context is created and
+// never change; setup is guarded by Controller.mu and the run state by stateMu.
 type jupyterKernel struct {
+	// mu serializes the code run on the kernel; take it with lock or tryLock
+	// so the kernel reports busy.
 	mu       sync.Mutex
 	kernelID string
 	client   *jupyter.Client
 	language Language
+	// cwd is the absolute working directory the context was created in, empty
+	// when it runs in the directory of the Jupyter server.
+	cwd string
+
+	environment *EnvironmentSnapshot
+	setup       *ContextSetup
+
+	// notebook is the .ipynb file of the session, createdDir the working
+	// directory created for the context; both are removed with it.
+	notebook   string
+	createdDir string
+
+	// running is set while code streams on the kernel, interrupted once
+	// Interrupt stopped that code.
+	running     atomic.Bool
+	interrupted atomic.Bool
+
+	stateMu sync.Mutex
+	// pending counts the runs holding or waiting for mu.
+	pending int
+	// lastUsedAt is when code last started or ended on the kernel.
+	lastUsedAt time.Time
+	// executionCount is the execution count of the last result of the kernel.
+	executionCount int
+
+	outputOnce sync.Once
+	output     *outputBuffer
+
+	// executions records the code run on the kernel, for exporting it.
+	executions executionHistory
+}
+
+// outputs returns the buffer of the recent output of the kernel.
+func (k *jupyterKernel) outputs() *outputBuffer {
+	k.outputOnce.Do(func() { k.output = newOutputBuffer() })
+	return k.output
+}
+
+// kernelState is a snapshot of the run state of a kernel.
+type kernelState struct {
+	busy           bool
+	lastUsedAt     time.Time
+	executionCount int
+}
+
+// lock waits until the kernel is free to run code.
+func (k *jupyterKernel) lock() {
+	k.stateMu.Lock()
+	k.pending++
+	k.stateMu.Unlock()
+	k.mu.Lock()
+}
+
+// tryLock takes the kernel only when it is free, reporting whether it did.
+func (k *jupyterKernel) tryLock() bool {
+	if !k.mu.TryLock() {
+		return false
+	}
+	k.stateMu.Lock()
+	k.pending++
+	k.stateMu.Unlock()
+	return true
+}
+
+// unlock frees the kernel taken with lock or tryLock.
+func (k *jupyterKernel) unlock() {
+	k.stateMu.Lock()
+	k.pending--
+	k.stateMu.Unlock()
+	k.mu.Unlock()
+}
+
+// touch records that the kernel is being used now.
+func (k *jupyterKernel) touch() {
+	k.stateMu.Lock()
+	defer k.stateMu.Unlock()
+	k.lastUsedAt = time.Now()
+}
+
+// setExecutionCount records the execution count the kernel reported.
+func (k *jupyterKernel) setExecutionCount(count int) {
+	k.stateMu.Lock()
+	defer k.stateMu.Unlock()
+	k.executionCount = count
+}
+
+// state returns the run state of the kernel.
+func (k *jupyterKernel) state() kernelState {
+	k.stateMu.Lock()
+	defer k.stateMu.Unlock()
+	return kernelState{
+		busy:           k.pending > 0,
+		lastUsedAt:     k.lastUsedAt,
+		executionCount: k.executionCount,
+	}
 }
 
 type commandKernel struct {
@@ -63,6 +181,32 @@ type commandKernel struct {
 	running      bool
 	isBackground bool
 	content      string
+
+	// separateStreams marks background commands logging stdout and stderr to
+	// their own files; combinedPath then holds the interleaved log.
+	separateStreams bool
+	combinedPath    string
+
+	// logs holds the segmented logs of a background command by path, nil
+	// when its logs are plain files.
+	logs map[string]*segmentedLog
+
+	// finished is closed once the command is no longer running.
+	finished chan struct{}
+
+	// cancel stops a task run by execd itself, such as a file fetch, which
+	// has no process of its own; nil for commands.
+	cancel context.CancelFunc
+}
+
+// finish closes finished unless it already is. Callers must hold the
+// controller lock.
+func (k *commandKernel) finish() {
+	select {
+	case <-k.finished:
+	default:
+		close(k.finished)
+	}
 }
 
 // NewController creates a runtime controller.
@@ -74,10 +218,13 @@ func NewController(baseURL, token string) *Controller {
 		jupyterClientMap:               make(map[string]*jupyterKernel),
 		defaultLanguageJupyterSessions: make(map[Language]string),
 		commandClientMap:               make(map[string]*commandKernel),
+		sqlQueryMap:                    make(map[string]*sqlQuery),
+		sqlConnections:                 make(map[string]*sqlConnection),
+		idempotencyKeys:                make(map[string]*idempotencyEntry),
 	}
 }
 
-// Execute dispatches a request to the correct backend.
+// Execute dispatches a request to the executor registered for its language.
 func (c *Controller) Execute(request *ExecuteCodeRequest) error {
 	var cancel context.CancelFunc
 	var ctx context.Context
@@ -88,16 +235,9 @@ func (c *Controller) Execute(request *ExecuteCodeRequest) error {
 	}
 	defer cancel()
 
-	switch request.Language {
-	case Command:
-		return c.runCommand(ctx, request)
-	case BackgroundCommand:
-		return c.runBackgroundCommand(ctx, request)
-	case Bash, Python, Java, JavaScript, TypeScript, Go:
-		return c.runJupyter(ctx, request)
-	case SQL:
-		return c.runSQL(ctx, request)
-	default:
+	executor, ok := lookupExecutor(request.Language)
+	if !ok {
 		return fmt.Errorf("unknown language: %s", request.Language)
 	}
+	return executor(c, ctx, request)
 }
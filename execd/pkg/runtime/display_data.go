@@ -0,0 +1,153 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// displayDataFileThreshold is the payload size above which display data
+	// is saved to a file instead of being sent inline.
+	displayDataFileThreshold = 4 << 10
+	// displayDataMaxAttempts bounds the search for a free file name.
+	displayDataMaxAttempts = 10000
+)
+
+// displayDataExtensions names the files of the common display data types.
+var displayDataExtensions = map[string]string{
+	"image/png":     ".png",
+	"image/jpeg":    ".jpg",
+	"image/gif":     ".gif",
+	"image/webp":    ".webp",
+	"image/svg+xml": ".svg",
+	"text/html":     ".html",
+}
+
+// displayDataSaver writes large image and HTML payloads of results to files
+// under dir.
+type displayDataSaver struct {
+	dir  string
+	next int
+}
+
+// newDisplayDataSaver saves display data under dir, relative to the working
+// directory of kernel when it isn't absolute.
+func newDisplayDataSaver(kernel *jupyterKernel, dir string) *displayDataSaver {
+	if !filepath.IsAbs(dir) && kernel.cwd != "" {
+		dir = filepath.Join(kernel.cwd, dir)
+	}
+	return &displayDataSaver{dir: dir, next: 1}
+}
+
+// wrap returns hooks reporting results with their large display data
+// replaced by {"file": path, "mime": type}.
+func (s *displayDataSaver) wrap(hooks ExecuteResultHook) ExecuteResultHook {
+	wrapped := hooks
+	wrapped.OnExecuteResult = func(result map[string]any, count int) {
+		hooks.OnExecuteResult(s.save(result), count)
+	}
+	return wrapped
+}
+
+// save returns result with each large image/* and text/html payload written
+// to a file. Payloads failing to decode or save stay inline.
+func (s *displayDataSaver) save(result map[string]any) map[string]any {
+	var saved map[string]any
+	for mimeType, value := range result {
+		if !strings.HasPrefix(mimeType, "image/") && mimeType != "text/html" {
+			continue
+		}
+		payload, ok := value.(string)
+		if !ok || len(payload) <= displayDataFileThreshold {
+			continue
+		}
+
+		path, err := s.write(mimeType, payload)
+		if err != nil {
+			logger.Warning("failed to save %s display data under %s: %v", mimeType, s.dir, err)
+			continue
+		}
+		if saved == nil {
+			saved = make(map[string]any, len(result))
+			for k, v := range result {
+				saved[k] = v
+			}
+		}
+		saved[mimeType] = map[string]any{"file": path, "mime": mimeType}
+	}
+	if saved == nil {
+		return result
+	}
+	return saved
+}
+
+// write saves one payload to a new file and returns its path.
+func (s *displayDataSaver) write(mimeType, payload string) (string, error) {
+	data := []byte(payload)
+	// SVG and HTML are sent as text, other images base64 encoded.
+	if mimeType != "image/svg+xml" && mimeType != "text/html" {
+		decoded, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(payload), ""))
+		if err != nil {
+			return "", fmt.Errorf("invalid base64 payload: %w", err)
+		}
+		data = decoded
+	}
+
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return "", err
+	}
+
+	prefix, ext := "display", displayDataExtensions[mimeType]
+	if strings.HasPrefix(mimeType, "image/") {
+		prefix = "plot"
+	}
+	if ext == "" {
+		if exts, _ := mime.ExtensionsByType(mimeType); len(exts) > 0 {
+			ext = exts[0]
+		} else {
+			ext = ".bin"
+		}
+	}
+
+	for attempts := 0; attempts < displayDataMaxAttempts; attempts++ {
+		path := filepath.Join(s.dir, fmt.Sprintf("%s-%03d%s", prefix, s.next, ext))
+		s.next++
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		_, err = file.Write(data)
+		if cerr := file.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			_ = os.Remove(path)
+			return "", err
+		}
+		return path, nil
+	}
+	return "", fmt.Errorf("no free file name after %d attempts", displayDataMaxAttempts)
+}
@@ -0,0 +1,81 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDisplayDataSaver_SavesLargePayloads(t *testing.T) {
+	cwd := t.TempDir()
+	saver := newDisplayDataSaver(&jupyterKernel{cwd: cwd}, "out")
+	dir := filepath.Join(cwd, "out")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// an existing file keeps its name.
+	if err := os.WriteFile(filepath.Join(dir, "plot-001.png"), []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	png := bytes.Repeat([]byte{0x89, 'P', 'N', 'G'}, displayDataFileThreshold)
+	html := "<div>" + strings.Repeat("x", displayDataFileThreshold) + "</div>"
+	result := map[string]any{
+		"image/png":  base64.StdEncoding.EncodeToString(png),
+		"text/html":  html,
+		"text/plain": "<Figure size 640x480>",
+	}
+	saved := saver.save(result)
+
+	ref, ok := saved["image/png"].(map[string]any)
+	if !ok || ref["file"] != filepath.Join(dir, "plot-002.png") || ref["mime"] != "image/png" {
+		t.Fatalf("expected the png to be saved as plot-002.png, got %+v", saved["image/png"])
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "plot-002.png")); err != nil || !bytes.Equal(data, png) {
+		t.Fatalf("expected the decoded png in the file, err %v", err)
+	}
+	htmlRef, ok := saved["text/html"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected the html to be saved, got %T", saved["text/html"])
+	}
+	if data, err := os.ReadFile(htmlRef["file"].(string)); err != nil || string(data) != html {
+		t.Fatalf("expected the html in the file, err %v", err)
+	}
+	if saved["text/plain"] != "<Figure size 640x480>" {
+		t.Fatalf("expected text/plain to stay inline, got %v", saved["text/plain"])
+	}
+	if _, ok := result["image/png"].(string); !ok {
+		t.Fatal("expected the original result to be left untouched")
+	}
+}
+
+func TestDisplayDataSaver_KeepsSmallAndInvalidPayloadsInline(t *testing.T) {
+	saver := newDisplayDataSaver(&jupyterKernel{}, t.TempDir())
+
+	small := map[string]any{"image/png": base64.StdEncoding.EncodeToString([]byte("tiny"))}
+	if saved := saver.save(small); saved["image/png"] != small["image/png"] {
+		t.Fatalf("expected a small payload to stay inline, got %v", saved["image/png"])
+	}
+
+	invalid := map[string]any{"image/png": strings.Repeat("!", displayDataFileThreshold+1)}
+	if saved := saver.save(invalid); saved["image/png"] != invalid["image/png"] {
+		t.Fatalf("expected an undecodable payload to stay inline, got %v", saved["image/png"])
+	}
+}
@@ -18,8 +18,6 @@ import (
 	"fmt"
 	"os"
 	"strings"
-
-	"github.com/alibaba/opensandbox/execd/pkg/log"
 )
 
 // loadExtraEnvFromFile reads key=value lines from EXECD_ENVS (if set).
@@ -32,7 +30,7 @@ func loadExtraEnvFromFile() map[string]string {
 
 	data, err := os.ReadFile(path)
 	if err != nil {
-		log.Warn("EXECD_ENVS: failed to read file %s: %v", path, err)
+		logger.Warn("EXECD_ENVS: failed to read file %s: %v", path, err)
 		return nil
 	}
 
@@ -45,7 +43,7 @@ func loadExtraEnvFromFile() map[string]string {
 		}
 		kv := strings.SplitN(line, "=", 2)
 		if len(kv) != 2 {
-			log.Warn("EXECD_ENVS: skip malformed line: %s", line)
+			logger.Warn("EXECD_ENVS: skip malformed line: %s", line)
 			continue
 		}
 		envs[kv[0]] = os.ExpandEnv(kv[1])
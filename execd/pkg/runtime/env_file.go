@@ -0,0 +1,208 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/alibaba/opensandbox/execd/pkg/log"
+)
+
+// envFileMu serializes the updates of the env file.
+var envFileMu sync.Mutex
+
+// envFilePath returns the env file named by EXECD_ENVS.
+func envFilePath() (string, error) {
+	path := os.Getenv("EXECD_ENVS")
+	if path == "" {
+		return "", ErrEnvFileNotConfigured
+	}
+	return path, nil
+}
+
+// ReadEnvFile returns the variables of the EXECD_ENVS file with their values
+// as written, before expansion. A missing file holds no variables.
+func ReadEnvFile() (map[string]string, error) {
+	path, err := envFilePath()
+	if err != nil {
+		return nil, err
+	}
+	lines, err := readEnvLines(path)
+	if err != nil {
+		return nil, err
+	}
+
+	envs := make(map[string]string)
+	for _, line := range lines {
+		if key, value, ok := parseEnvLine(line); ok {
+			envs[key] = value
+		}
+	}
+	return envs, nil
+}
+
+// UpdateEnvFile sets and unsets variables of the EXECD_ENVS file, dropping
+// every other variable when replace is set, and returns the resulting
+// variables. Comments and the order of kept variables are preserved; new ones
+// are appended. A key listed more than once keeps its last line, as commands
+// would see it. The file is replaced atomically, so commands and contexts
+// started meanwhile read either the old or the new variables.
+func UpdateEnvFile(set map[string]string, unset []string, replace bool) (map[string]string, error) {
+	path, err := envFilePath()
+	if err != nil {
+		return nil, err
+	}
+
+	envFileMu.Lock()
+	defer envFileMu.Unlock()
+
+	lines, err := readEnvLines(path)
+	if err != nil {
+		return nil, err
+	}
+
+	last := make(map[string]int)
+	previous := make(map[string]string)
+	for i, line := range lines {
+		if key, value, ok := parseEnvLine(line); ok {
+			last[key] = i
+			previous[key] = value
+		}
+	}
+
+	envs := make(map[string]string)
+	kept := make([]string, 0, len(lines)+len(set))
+	for i, line := range lines {
+		key, value, ok := parseEnvLine(line)
+		if !ok {
+			kept = append(kept, line)
+			continue
+		}
+		if last[key] != i || slices.Contains(unset, key) {
+			continue
+		}
+		if newValue, ok := set[key]; ok {
+			value = newValue
+		} else if replace {
+			continue
+		}
+		envs[key] = value
+		kept = append(kept, key+"="+value)
+	}
+	for _, key := range slices.Sorted(maps.Keys(set)) {
+		if _, ok := envs[key]; ok {
+			continue
+		}
+		envs[key] = set[key]
+		kept = append(kept, key+"="+set[key])
+	}
+
+	content := strings.Join(kept, "\n")
+	if content != "" {
+		content += "\n"
+	}
+	if err := writeFileAtomic(path, []byte(content)); err != nil {
+		return nil, err
+	}
+	registerEnvSecrets(previous, envs)
+	return envs, nil
+}
+
+// registerEnvSecrets masks in logs the values of the env file variables whose
+// names suggest a secret, and stops masking those the update removed.
+func registerEnvSecrets(previous, current map[string]string) {
+	secrets := make(map[string]bool)
+	for key, value := range current {
+		if log.IsSecretName(key) {
+			secrets[value] = true
+			log.AddSecrets(value)
+		}
+	}
+	for key, value := range previous {
+		if log.IsSecretName(key) && !secrets[value] {
+			log.RemoveSecrets(value)
+		}
+	}
+}
+
+// readEnvLines returns the lines of the env file, none when it doesn't exist.
+func readEnvLines(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read env file %s: %w", path, err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) == 1 && lines[0] == "" {
+		return nil, nil
+	}
+	return lines, nil
+}
+
+// parseEnvLine splits a KEY=value line the way loadExtraEnvFromFile does,
+// reporting false for blank, comment and malformed lines.
+func parseEnvLine(line string) (string, string, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", "", false
+	}
+	return strings.Cut(line, "=")
+}
+
+// writeFileAtomic replaces path with data through a temporary file renamed
+// over it, keeping the mode of the file it replaces.
+func writeFileAtomic(path string, data []byte) error {
+	mode := os.FileMode(0o600)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
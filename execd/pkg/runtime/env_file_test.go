@@ -0,0 +1,138 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/alibaba/opensandbox/execd/pkg/log"
+)
+
+func TestUpdateEnvFile_KeepsCommentsAndOrder(t *testing.T) {
+	envFile := filepath.Join(t.TempDir(), ".env")
+	t.Setenv("EXECD_ENVS", envFile)
+	if err := os.WriteFile(envFile, []byte("# provisioned\nB=2\nA=$HOME/x\nC=3\n"), 0o640); err != nil {
+		t.Fatal(err)
+	}
+
+	envs, err := UpdateEnvFile(map[string]string{"A": "1", "D": "$PATH"}, []string{"C"}, false)
+	if err != nil {
+		t.Fatalf("UpdateEnvFile returned error: %v", err)
+	}
+	if len(envs) != 3 || envs["A"] != "1" || envs["B"] != "2" || envs["D"] != "$PATH" {
+		t.Fatalf("unexpected variables %v", envs)
+	}
+
+	data, err := os.ReadFile(envFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "# provisioned\nB=2\nA=1\nD=$PATH\n" {
+		t.Fatalf("unexpected file content %q", data)
+	}
+	if info, _ := os.Stat(envFile); info.Mode().Perm() != 0o640 {
+		t.Fatalf("expected the file mode to be kept, got %v", info.Mode().Perm())
+	}
+
+	read, err := ReadEnvFile()
+	if err != nil || read["D"] != "$PATH" {
+		t.Fatalf("expected values as written, got %v, %v", read, err)
+	}
+	if loaded := loadExtraEnvFromFile(); loaded["D"] != os.Getenv("PATH") {
+		t.Fatalf("expected commands to get expanded values, got %q", loaded["D"])
+	}
+
+	envs, err = UpdateEnvFile(map[string]string{"E": "5"}, nil, true)
+	if err != nil || len(envs) != 1 || envs["E"] != "5" {
+		t.Fatalf("expected replace to keep only E, got %v, %v", envs, err)
+	}
+}
+
+func TestUpdateEnvFile_DuplicateKeysKeepLastLine(t *testing.T) {
+	envFile := filepath.Join(t.TempDir(), ".env")
+	t.Setenv("EXECD_ENVS", envFile)
+	if err := os.WriteFile(envFile, []byte("A=first\nB=2\nA=last\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	envs, err := UpdateEnvFile(map[string]string{"C": "3"}, nil, false)
+	if err != nil {
+		t.Fatalf("UpdateEnvFile returned error: %v", err)
+	}
+	if envs["A"] != "last" {
+		t.Fatalf("expected the last A to win, got %v", envs)
+	}
+	data, err := os.ReadFile(envFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "B=2\nA=last\nC=3\n" {
+		t.Fatalf("unexpected file content %q", data)
+	}
+}
+
+func TestUpdateEnvFile_RegistersSecretValuesOnly(t *testing.T) {
+	t.Setenv("EXECD_ENVS", filepath.Join(t.TempDir(), ".env"))
+
+	if _, err := UpdateEnvFile(map[string]string{"DB_PASSWORD": "hunter2-db", "GREETING": "hello-world"}, nil, false); err != nil {
+		t.Fatalf("UpdateEnvFile returned error: %v", err)
+	}
+	if got := log.Redact("hunter2-db hello-world"); got != "[REDACTED] hello-world" {
+		t.Fatalf("expected only the password to be masked, got %q", got)
+	}
+
+	if _, err := UpdateEnvFile(nil, []string{"DB_PASSWORD"}, false); err != nil {
+		t.Fatalf("UpdateEnvFile returned error: %v", err)
+	}
+	if got := log.Redact("hunter2-db"); got != "hunter2-db" {
+		t.Fatalf("expected a deleted secret to be unmasked, got %q", got)
+	}
+}
+
+func TestUpdateEnvFile_SerializesConcurrentWrites(t *testing.T) {
+	t.Setenv("EXECD_ENVS", filepath.Join(t.TempDir(), "nested", ".env"))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := UpdateEnvFile(map[string]string{fmt.Sprintf("K%d", i): "v"}, nil, false); err != nil {
+				t.Errorf("UpdateEnvFile returned error: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	envs, err := ReadEnvFile()
+	if err != nil || len(envs) != 20 {
+		t.Fatalf("expected every write to be kept, got %d variables, %v", len(envs), err)
+	}
+}
+
+func TestEnvFile_NotConfigured(t *testing.T) {
+	t.Setenv("EXECD_ENVS", "")
+	if _, err := ReadEnvFile(); !errors.Is(err, ErrEnvFileNotConfigured) {
+		t.Fatalf("expected ErrEnvFileNotConfigured, got %v", err)
+	}
+	if _, err := UpdateEnvFile(map[string]string{"A": "1"}, nil, false); !errors.Is(err, ErrEnvFileNotConfigured) {
+		t.Fatalf("expected ErrEnvFileNotConfigured, got %v", err)
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	environmentProbeTimeout = 10 * time.Second
+	packagesExpression      = "packages"
+)
+
+// environmentPreambles lists user expressions evaluated to collect package versions.
+// The expression must render a JSON document as its text/plain representation.
+var environmentPreambles = map[Language]string{
+	Python: "__import__('json').dumps({d.metadata['Name']: d.version " +
+		"for d in __import__('importlib.metadata', fromlist=['distributions']).distributions()})",
+}
+
+// EnvironmentSnapshot describes the interpreter backing a context.
+type EnvironmentSnapshot struct {
+	Language              string            `json:"language"`
+	LanguageVersion       string            `json:"language_version"`
+	Implementation        string            `json:"implementation,omitempty"`
+	ImplementationVersion string            `json:"implementation_version,omitempty"`
+	ProtocolVersion       string            `json:"protocol_version,omitempty"`
+	Packages              map[string]string `json:"packages,omitempty"`
+	CapturedAt            time.Time         `json:"captured_at"`
+}
+
+// captureEnvironment queries the kernel for interpreter and package versions.
+func (c *Controller) captureEnvironment(kernel *jupyterKernel) (*EnvironmentSnapshot, error) {
+	kernel.lock()
+	defer kernel.unlock()
+
+	err := kernel.client.ConnectToKernel(kernel.kernelID)
+	if err != nil {
+		return nil, err
+	}
+	defer kernel.client.DisconnectFromKernel(kernel.kernelID)
+
+	info, err := kernel.client.KernelInfo(environmentProbeTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &EnvironmentSnapshot{
+		Language:              info.LanguageInfo.Name,
+		LanguageVersion:       info.LanguageInfo.Version,
+		Implementation:        info.Implementation,
+		ImplementationVersion: info.ImplementationVersion,
+		ProtocolVersion:       info.ProtocolVersion,
+		CapturedAt:            time.Now(),
+	}
+
+	preamble, ok := environmentPreambles[kernel.language]
+	if !ok {
+		return snapshot, nil
+	}
+
+	// package versions are best effort, the kernel info alone is still useful.
+	results, err := kernel.client.EvaluateExpressions(map[string]string{packagesExpression: preamble}, environmentProbeTimeout)
+	if err != nil {
+		logger.Warning("failed to probe package versions of kernel %s: %v", kernel.kernelID, err)
+		return snapshot, nil
+	}
+	result, ok := results[packagesExpression]
+	if !ok || result.Status != "ok" {
+		logger.Warning("package probe of kernel %s failed: %s: %s", kernel.kernelID, result.EName, result.EValue)
+		return snapshot, nil
+	}
+	snapshot.Packages, err = parsePackageVersions(result.Data)
+	if err != nil {
+		logger.Warning("failed to parse package versions of kernel %s: %v", kernel.kernelID, err)
+	}
+
+	return snapshot, nil
+}
+
+// parsePackageVersions decodes the text/plain repr of the JSON string produced by the preamble.
+func parsePackageVersions(data map[string]any) (map[string]string, error) {
+	text, ok := data["text/plain"].(string)
+	if !ok {
+		return nil, errors.New("no text/plain representation")
+	}
+
+	document, err := unquotePythonString(text)
+	if err != nil {
+		return nil, err
+	}
+	packages := make(map[string]string)
+	if err := json.Unmarshal([]byte(document), &packages); err != nil {
+		return nil, err
+	}
+	return packages, nil
+}
+
+// unquotePythonString decodes the repr of a Python str, which is quoted with
+// either ' or " and uses backslash escapes close to Go's.
+func unquotePythonString(repr string) (string, error) {
+	if len(repr) < 2 || repr[0] != repr[len(repr)-1] || (repr[0] != '\'' && repr[0] != '"') {
+		return "", fmt.Errorf("not a quoted Python string: %.40q", repr)
+	}
+
+	// rewrite the body as a Go double-quoted literal and let strconv decode it.
+	var b strings.Builder
+	b.WriteByte('"')
+	body := repr[1 : len(repr)-1]
+	for i := 0; i < len(body); i++ {
+		switch ch := body[i]; {
+		case ch == '"':
+			b.WriteString(`\"`)
+		case ch == '\\' && i+1 < len(body):
+			i++
+			switch next := body[i]; next {
+			case '\'':
+				b.WriteByte('\'')
+			case 'x':
+				// \xhh is a code point in Python but a raw byte in Go.
+				b.WriteString(`\u00`)
+			default:
+				b.WriteByte('\\')
+				b.WriteByte(next)
+			}
+		default:
+			b.WriteByte(ch)
+		}
+	}
+	b.WriteByte('"')
+
+	return strconv.Unquote(b.String())
+}
@@ -16,4 +16,33 @@ package runtime
 
 import "errors"
 
-var ErrContextNotFound = errors.New("context not found")
+var (
+	ErrContextNotFound         = errors.New("context not found")
+	ErrSessionBusy             = errors.New("session is busy")
+	ErrSQLQueryNotFound        = errors.New("sql query not found")
+	ErrSQLConnectionNotFound   = errors.New("sql connection not found")
+	ErrSQLConnectionExists     = errors.New("sql connection already exists")
+	ErrSQLDriverUnsupported    = errors.New("sql driver is not supported")
+	ErrSQLConnectionBuiltin    = errors.New("sql connection is built in")
+	ErrIdempotencyKeyConflict  = errors.New("idempotency key was already used for a different request")
+	ErrCommandUserNotFound     = errors.New("command user not found")
+	ErrCommandGroupNotFound    = errors.New("command group not found")
+	ErrCommandUserNotPermitted = errors.New("execd lacks the privilege to run commands as another user")
+	ErrPTYUnsupported          = errors.New("pseudo-terminals are not supported on this platform")
+	ErrShellUnsupported        = errors.New("shell is not supported on this platform")
+	ErrPortsUnsupported        = errors.New("listing listening ports is not supported on this platform")
+	ErrVariablesUnsupported    = errors.New("listing variables is not supported for this language")
+	ErrResetUnsupported        = errors.New("resetting the namespace is not supported for this language")
+	ErrEnvFileNotConfigured    = errors.New("no env file is configured, set EXECD_ENVS")
+	ErrExecutionTimeout        = errors.New("execution timed out")
+	ErrKernelDied              = errors.New("the kernel died during execution")
+	ErrProcessNotFound         = errors.New("process not found")
+	ErrProcessProtected        = errors.New("signalling execd itself or pid 1 is not allowed")
+	ErrUnsupportedSignal       = errors.New("unsupported signal")
+	ErrCommandOutputRemoved    = errors.New("command output was already removed")
+	ErrCwdNotFound             = errors.New("working directory does not exist")
+	ErrCwdNotDirectory         = errors.New("working directory is not a directory")
+	ErrCwdOutsideRoot          = errors.New("working directory is outside the allowed root")
+	// ErrNoKernelForLanguage is permanent: no installed kernel spec runs the language.
+	ErrNoKernelForLanguage = errors.New("no kernel matches the language")
+)
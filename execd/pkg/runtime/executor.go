@@ -0,0 +1,62 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// Executor runs a request of one language on a controller.
+type Executor func(c *Controller, ctx context.Context, request *ExecuteCodeRequest) error
+
+var (
+	executorsMu sync.RWMutex
+	// executors maps each language to the executor Execute dispatches it to.
+	executors = make(map[Language]Executor)
+)
+
+func init() {
+	RegisterExecutor(Command, (*Controller).runCommand)
+	RegisterExecutor(BackgroundCommand, (*Controller).runBackgroundCommand)
+	for _, language := range []Language{Bash, Python, Java, JavaScript, TypeScript, Go} {
+		RegisterExecutor(language, (*Controller).runJupyter)
+	}
+	RegisterExecutor(SQL, (*Controller).runSQL)
+}
+
+// RegisterExecutor makes Execute run the requests of language with executor,
+// replacing any executor registered for it before. A language new to
+// Languages is added to it, so that requests may name it. Register executors
+// from init functions, before requests are served.
+func RegisterExecutor(language Language, executor Executor) {
+	executorsMu.Lock()
+	defer executorsMu.Unlock()
+
+	executors[language] = executor
+	if !slices.Contains(Languages, language) {
+		Languages = append(Languages, language)
+	}
+}
+
+// lookupExecutor returns the executor registered for language.
+func lookupExecutor(language Language) (Executor, bool) {
+	executorsMu.RLock()
+	defer executorsMu.RUnlock()
+
+	executor, ok := executors[language]
+	return executor, ok
+}
@@ -0,0 +1,64 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestExecute_RoutesToRegisteredExecutor(t *testing.T) {
+	const repl Language = "repl"
+	languages := slices.Clone(Languages)
+	t.Cleanup(func() {
+		executorsMu.Lock()
+		delete(executors, repl)
+		executorsMu.Unlock()
+		Languages = languages
+	})
+
+	errRan := errors.New("ran")
+	var got *ExecuteCodeRequest
+	var deadline time.Time
+	RegisterExecutor(repl, func(_ *Controller, ctx context.Context, request *ExecuteCodeRequest) error {
+		got = request
+		deadline, _ = ctx.Deadline()
+		return errRan
+	})
+	if !slices.Contains(Languages, repl) {
+		t.Fatalf("expected %s to be added to Languages", repl)
+	}
+
+	request := &ExecuteCodeRequest{Language: repl, Code: "1 + 1", Timeout: time.Minute}
+	if err := NewController("", "").Execute(request); !errors.Is(err, errRan) {
+		t.Fatalf("expected the fake executor's error, got %v", err)
+	}
+	if got != request {
+		t.Fatalf("the fake executor did not receive the request")
+	}
+	if time.Until(deadline) <= 0 || time.Until(deadline) > time.Minute {
+		t.Fatalf("expected the request timeout as context deadline, got %v", deadline)
+	}
+}
+
+func TestExecute_UnknownLanguage(t *testing.T) {
+	err := NewController("", "").Execute(&ExecuteCodeRequest{Language: "cobol"})
+	if err == nil || err.Error() != "unknown language: cobol" {
+		t.Fatalf("expected an unknown language error, got %v", err)
+	}
+}
@@ -18,12 +18,20 @@ import (
 	"context"
 	"database/sql"
 	"database/sql/driver"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/alibaba/opensandbox/execd/pkg/jupyter/execute"
 )
 
 type stubDriver struct {
@@ -33,6 +41,7 @@ type stubDriver struct {
 	queryErr         error
 	execErr          error
 	pingErr          error
+	queryDelay       time.Duration
 	execCalled       int32
 	queryCalled      int32
 }
@@ -57,8 +66,15 @@ func (c *stubConn) ExecContext(_ context.Context, _ string, _ []driver.NamedValu
 	return driver.RowsAffected(c.d.execRowsAffected), nil
 }
 
-func (c *stubConn) QueryContext(_ context.Context, _ string, _ []driver.NamedValue) (driver.Rows, error) {
+func (c *stubConn) QueryContext(ctx context.Context, _ string, _ []driver.NamedValue) (driver.Rows, error) {
 	atomic.AddInt32(&c.d.queryCalled, 1)
+	if c.d.queryDelay > 0 {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(c.d.queryDelay):
+		}
+	}
 	if c.d.queryErr != nil {
 		return nil, c.d.queryErr
 	}
@@ -104,13 +120,91 @@ func (c *stubConnector) Open(string) (driver.Conn, error) {
 	return &stubConn{d: c.d}, nil
 }
 
-func newStubDB(t *testing.T, d *stubDriver) *sql.DB {
-	t.Helper()
+// registerStubDriver registers d as a database/sql driver under a new name.
+func registerStubDriver(d *stubDriver) string {
 	driverName := fmt.Sprintf("stub-%d", time.Now().UnixNano())
 	sql.Register(driverName, &stubConnector{d: d})
-	db, err := sql.Open(driverName, "")
+	return driverName
+}
+
+func newStubDB(t *testing.T, d *stubDriver) *sql.DB {
+	t.Helper()
+	db, err := sql.Open(registerStubDriver(d), "")
 	if err != nil {
 		t.Fatalf("open stub db: %v", err)
 	}
 	return db
 }
+
+const mockKernelID = "kernel-1"
+
+// newMockJupyter serves the subset of the Jupyter REST and channel APIs used by contexts.
+// onShell is invoked for every message sent on the kernel channels websocket.
+func newMockJupyter(t *testing.T, onShell func(conn *websocket.Conn, msg *execute.Message)) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(mockJupyterHandler(t, onShell))
+}
+
+// mockJupyterHandler is the handler behind newMockJupyter, for tests that wrap it.
+func mockJupyterHandler(t *testing.T, onShell func(conn *websocket.Conn, msg *execute.Message)) http.Handler {
+	t.Helper()
+	upgrader := websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/kernelspecs":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"default": "ipython",
+				"kernelspecs": map[string]any{
+					"ipython": map[string]any{"name": "ipython", "spec": map[string]any{"display_name": "Python", "language": "python"}},
+				},
+			})
+		case r.URL.Path == "/api/sessions" && r.Method == http.MethodPost:
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": "session-1", "kernel": map[string]any{"id": mockKernelID, "name": "ipython"}})
+		case strings.HasPrefix(r.URL.Path, "/api/sessions/") && r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		case strings.HasSuffix(r.URL.Path, "/interrupt") && r.Method == http.MethodPost:
+			w.WriteHeader(http.StatusNoContent)
+		case r.URL.Path == "/api/kernels":
+			_ = json.NewEncoder(w).Encode([]map[string]any{{"id": mockKernelID, "name": "ipython"}})
+		case strings.HasSuffix(r.URL.Path, "/channels"):
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				t.Errorf("upgrade websocket: %v", err)
+				return
+			}
+			defer conn.Close()
+			for {
+				var msg execute.Message
+				if err := conn.ReadJSON(&msg); err != nil {
+					return
+				}
+				onShell(conn, &msg)
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// replyMessage writes a kernel message whose parent is the given request.
+func replyMessage(t *testing.T, conn *websocket.Conn, parent *execute.Message, msgType execute.MessageType, content any) {
+	t.Helper()
+	raw, err := json.Marshal(content)
+	if err != nil {
+		t.Fatalf("marshal reply: %v", err)
+	}
+	err = conn.WriteJSON(execute.Message{
+		Header: execute.Header{
+			MessageID:   parent.Header.MessageID + "-" + string(msgType),
+			Session:     parent.Header.Session,
+			MessageType: string(msgType),
+		},
+		ParentHeader: parent.Header,
+		Content:      raw,
+	})
+	if err != nil {
+		t.Errorf("write reply: %v", err)
+	}
+}
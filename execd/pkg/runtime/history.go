@@ -0,0 +1,149 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"sync"
+	"time"
+
+	"github.com/alibaba/opensandbox/execd/pkg/jupyter/execute"
+)
+
+const (
+	// contextHistoryCapacity is how many executions a context remembers.
+	contextHistoryCapacity = 500
+	// executionOutputLimit caps the output bytes kept for one execution.
+	executionOutputLimit = 1 << 20
+)
+
+// Execution is one run of code in a context along with its output.
+type Execution struct {
+	Code string
+	// ExecutionCount is the count the kernel gave the run, zero when it gave none.
+	ExecutionCount int
+	StartedAt      time.Time
+	// Outputs holds the stdout, stderr, result and error events of the run in
+	// order; consecutive stream text of one name is merged.
+	Outputs []OutputEvent
+	// OmittedBytes counts the output dropped once executionOutputLimit was reached.
+	OmittedBytes int
+}
+
+// executionHistory keeps the recent executions of a context, oldest first.
+type executionHistory struct {
+	mu         sync.Mutex
+	executions []*Execution
+}
+
+// record starts a new execution of code and returns hooks also adding the
+// output reported to hooks to it.
+func (h *executionHistory) record(code string, hooks ExecuteResultHook) ExecuteResultHook {
+	execution := &Execution{Code: code, StartedAt: time.Now()}
+	h.mu.Lock()
+	if len(h.executions) == contextHistoryCapacity {
+		h.executions = append(h.executions[:0], h.executions[1:]...)
+	}
+	h.executions = append(h.executions, execution)
+	h.mu.Unlock()
+
+	size := 0
+	teed := hooks
+	teed.OnExecuteResult = func(result map[string]any, count int) {
+		h.mu.Lock()
+		if count > 0 {
+			execution.ExecutionCount = count
+		}
+		if len(result) > 0 {
+			resultSize := 0
+			for _, value := range result {
+				if s, ok := value.(string); ok {
+					resultSize += len(s)
+				}
+			}
+			if size+resultSize > executionOutputLimit {
+				execution.OmittedBytes += resultSize
+			} else {
+				size += resultSize
+				execution.Outputs = append(execution.Outputs, OutputEvent{Type: OutputResult, Results: result, ExecutionCount: count})
+			}
+		}
+		h.mu.Unlock()
+		hooks.OnExecuteResult(result, count)
+	}
+	stream := func(name, text string) {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if size+len(text) > executionOutputLimit {
+			kept := executionOutputLimit - size
+			execution.OmittedBytes += len(text) - kept
+			text = text[:kept]
+		}
+		if text == "" {
+			return
+		}
+		size += len(text)
+		if last := len(execution.Outputs) - 1; last >= 0 && execution.Outputs[last].Type == name {
+			execution.Outputs[last].Text += text
+			return
+		}
+		execution.Outputs = append(execution.Outputs, OutputEvent{Type: name, Text: text})
+	}
+	teed.OnExecuteStdout = func(text string) {
+		stream(OutputStdout, text)
+		hooks.OnExecuteStdout(text)
+	}
+	teed.OnExecuteStderr = func(text string) {
+		stream(OutputStderr, text)
+		hooks.OnExecuteStderr(text)
+	}
+	teed.OnExecuteError = func(err *execute.ErrorOutput) {
+		h.mu.Lock()
+		execution.Outputs = append(execution.Outputs, OutputEvent{Type: OutputError, Error: err})
+		h.mu.Unlock()
+		hooks.OnExecuteError(err)
+	}
+	return teed
+}
+
+// list returns copies of the recorded executions, oldest first.
+func (h *executionHistory) list() []Execution {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	executions := make([]Execution, 0, len(h.executions))
+	for _, execution := range h.executions {
+		copied := *execution
+		copied.Outputs = append([]OutputEvent(nil), execution.Outputs...)
+		executions = append(executions, copied)
+	}
+	return executions
+}
+
+// clear forgets the recorded executions.
+func (h *executionHistory) clear() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.executions = nil
+}
+
+// ContextHistory returns the code run in a context with its output, oldest
+// first. Code run before the last contextHistoryCapacity executions is forgotten.
+func (c *Controller) ContextHistory(session string) ([]Execution, error) {
+	kernel := c.getJupyterKernel(session)
+	if kernel == nil {
+		return nil, ErrContextNotFound
+	}
+	return kernel.executions.list(), nil
+}
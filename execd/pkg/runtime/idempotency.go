@@ -0,0 +1,73 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"time"
+)
+
+// idempotencyKeyTTL is how long a context creation key is remembered.
+const idempotencyKeyTTL = 10 * time.Minute
+
+// idempotencyEntry records the outcome of a context creation keyed by the client.
+type idempotencyEntry struct {
+	// done is closed once the creation owning the key has finished.
+	done      chan struct{}
+	language  Language
+	sessionID string
+	err       error
+	expiresAt time.Time
+}
+
+// claimIdempotencyKey returns the entry for key and whether the caller owns it.
+// Owners must create the context and call releaseIdempotencyKey; other callers
+// wait on entry.done and reuse its result.
+func (c *Controller) claimIdempotencyKey(key string, language Language) (*idempotencyEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for k, entry := range c.idempotencyKeys {
+		if !entry.expiresAt.IsZero() && now.After(entry.expiresAt) {
+			delete(c.idempotencyKeys, k)
+		}
+	}
+
+	if entry, ok := c.idempotencyKeys[key]; ok {
+		// a context deleted since it was created can't be handed out again.
+		if entry.expiresAt.IsZero() || c.jupyterClientMap[entry.sessionID] != nil {
+			return entry, false
+		}
+	}
+
+	entry := &idempotencyEntry{done: make(chan struct{}), language: language}
+	c.idempotencyKeys[key] = entry
+	return entry, true
+}
+
+// releaseIdempotencyKey publishes the creation result. Failed creations forget
+// the key so the client can retry with it.
+func (c *Controller) releaseIdempotencyKey(key string, entry *idempotencyEntry, sessionID string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry.sessionID = sessionID
+	entry.err = err
+	entry.expiresAt = time.Now().Add(idempotencyKeyTTL)
+	if err != nil && c.idempotencyKeys[key] == entry {
+		delete(c.idempotencyKeys, key)
+	}
+	close(entry.done)
+}
@@ -18,44 +18,26 @@
 package runtime
 
 import (
-	"errors"
 	"fmt"
 	"os"
 	"strings"
 	"syscall"
 	"time"
-
-	"github.com/alibaba/opensandbox/execd/pkg/log"
 )
 
-// Interrupt stops execution in the specified session.
-func (c *Controller) Interrupt(sessionID string) error {
-	switch {
-	case c.getJupyterKernel(sessionID) != nil:
-		kernel := c.getJupyterKernel(sessionID)
-		log.Warning("Interrupting Jupyter kernel %s", kernel.kernelID)
-		return kernel.client.InterruptKernel(kernel.kernelID)
-	case c.getCommandKernel(sessionID) != nil:
-		kernel := c.getCommandKernel(sessionID)
-		return c.killPid(kernel.pid)
-	default:
-		return errors.New("no such session")
-	}
-}
-
 // killPid sends SIGTERM followed by SIGKILL if needed.
 func (c *Controller) killPid(pid int) error {
 	process, err := os.FindProcess(pid)
 	if err != nil {
 		return err
 	}
-	log.Warning("Attempting to terminate process %d", pid)
+	logger.Warning("Attempting to terminate process %d", pid)
 
 	if err := process.Signal(syscall.SIGTERM); err != nil {
 		if strings.Contains(err.Error(), "already finished") {
 			return nil
 		}
-		log.Warning("SIGTERM failed for pid %d: %v, trying SIGKILL", pid, err)
+		logger.Warning("SIGTERM failed for pid %d: %v, trying SIGKILL", pid, err)
 	} else {
 		done := make(chan error, 1)
 		go func() {
@@ -66,11 +48,11 @@ func (c *Controller) killPid(pid int) error {
 		select {
 		case err := <-done:
 			if err == nil {
-				log.Info("Process %d terminated gracefully", pid)
+				logger.Info("Process %d terminated gracefully", pid)
 				return nil
 			}
 		case <-time.After(3 * time.Second):
-			log.Warning("Process %d did not terminate after SIGTERM, using SIGKILL", pid)
+			logger.Warning("Process %d did not terminate after SIGTERM, using SIGKILL", pid)
 		}
 	}
 
@@ -85,7 +67,7 @@ func (c *Controller) killPid(pid int) error {
 		if err := process.Signal(syscall.Signal(0)); err != nil {
 			if strings.Contains(err.Error(), "already finished") ||
 				strings.Contains(err.Error(), "no such process") {
-				log.Info("Process %d confirmed terminated", pid)
+				logger.Info("Process %d confirmed terminated", pid)
 				return nil
 			}
 		}
@@ -0,0 +1,106 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"errors"
+	"time"
+
+	"github.com/alibaba/opensandbox/execd/pkg/jupyter/execute"
+)
+
+// interruptReplyTimeout bounds the wait for the kernel to acknowledge an
+// interrupt_request on its control channel.
+const interruptReplyTimeout = 5 * time.Second
+
+// InterruptedErrorName names the error event ending a run that was interrupted.
+const InterruptedErrorName = "Interrupted"
+
+// TimeoutErrorName names the error event ending a run stopped by its timeout.
+const TimeoutErrorName = "Timeout"
+
+// Interrupt stops execution in a session. The id is a context ID, the session
+// ID sent in the init event of a run, or a language such as "python" standing
+// for its default context. It reports whether anything was running.
+func (c *Controller) Interrupt(id string) (bool, error) {
+	session := c.resolveDefaultSession(id)
+	if kernel := c.getJupyterKernel(session); kernel != nil {
+		return c.interruptJupyter(kernel)
+	}
+	if kernel := c.commandSnapshot(session); kernel != nil {
+		if !kernel.running {
+			return false, nil
+		}
+		if kernel.cancel != nil {
+			kernel.cancel()
+			return true, nil
+		}
+		return true, c.killPid(kernel.pid)
+	}
+	return false, ErrContextNotFound
+}
+
+// resolveDefaultSession maps a language to the session of its default
+// context, leaving any other id untouched.
+func (c *Controller) resolveDefaultSession(id string) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if session, ok := c.defaultLanguageJupyterSessions[Language(id)]; ok {
+		return session
+	}
+	return id
+}
+
+// interruptJupyter interrupts the code running on kernel, if any. The REST
+// interrupt signals the kernel process while the control channel message
+// reaches kernels interrupted by message, so one of them failing is only logged.
+func (c *Controller) interruptJupyter(kernel *jupyterKernel) (bool, error) {
+	if !kernel.running.Load() {
+		return false, nil
+	}
+	kernel.interrupted.Store(true)
+	logger.Warning("Interrupting Jupyter kernel %s", kernel.kernelID)
+
+	restErr := kernel.client.InterruptKernel(kernel.kernelID)
+	controlErr := kernel.client.InterruptExecution(interruptReplyTimeout)
+	if restErr != nil && controlErr != nil {
+		kernel.interrupted.Store(false)
+		return false, errors.Join(restErr, controlErr)
+	}
+	if restErr != nil {
+		logger.Warning("REST interrupt of kernel %s failed: %v", kernel.kernelID, restErr)
+	}
+	if controlErr != nil {
+		logger.Warning("control channel interrupt of kernel %s failed: %v", kernel.kernelID, controlErr)
+	}
+	return true, nil
+}
+
+// interruptedError is the last error event of an interrupted run.
+func interruptedError() *execute.ErrorOutput {
+	return &execute.ErrorOutput{
+		EName:  InterruptedErrorName,
+		EValue: "execution was interrupted",
+	}
+}
+
+// timeoutError is the last error event of a run stopped by its timeout.
+func timeoutError() *execute.ErrorOutput {
+	return &execute.ErrorOutput{
+		EName:  TimeoutErrorName,
+		EValue: "execution timed out, the kernel was interrupted",
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/alibaba/opensandbox/execd/pkg/jupyter/execute"
+)
+
+// newBlockingKernel holds every execute_request until an interrupt_request
+// arrives, then ends it with a KeyboardInterrupt like IPython does.
+func newBlockingKernel(t *testing.T) *Controller {
+	t.Helper()
+	var pending *execute.Message
+	server := newMockJupyter(t, func(conn *websocket.Conn, msg *execute.Message) {
+		switch execute.MessageType(msg.Header.MessageType) {
+		case execute.MsgExecuteRequest:
+			pending = msg
+		case execute.MsgInterruptRequest:
+			replyMessage(t, conn, msg, execute.MsgInterruptReply, execute.InterruptReply{Status: "ok"})
+			if pending == nil {
+				return
+			}
+			errOutput := execute.ErrorOutput{EName: "KeyboardInterrupt"}
+			replyMessage(t, conn, pending, execute.MsgError, errOutput)
+			replyMessage(t, conn, pending, execute.MsgExecuteReply, execute.ExecuteReply{Status: "error", ErrorOutput: errOutput})
+			replyMessage(t, conn, pending, execute.MsgStatus, execute.StatusUpdate{ExecutionState: execute.StateIdle})
+			pending = nil
+		}
+	})
+	t.Cleanup(server.Close)
+
+	c := NewController(server.URL, "token")
+	c.storeJupyterKernel("session-1", &jupyterKernel{kernelID: mockKernelID, client: c.jupyterClient(), language: Python})
+	c.defaultLanguageJupyterSessions[Python] = "session-1"
+	return c
+}
+
+func TestInterrupt_DefaultLanguageSession(t *testing.T) {
+	c := newBlockingKernel(t)
+
+	interrupted, err := c.Interrupt("python")
+	assert.NoError(t, err)
+	assert.False(t, interrupted, "nothing is running yet")
+
+	sessions := make(chan string, 1)
+	errs := make(chan string, 2)
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Execute(&ExecuteCodeRequest{
+			Language: Python,
+			Code:     "while True: pass",
+			Hooks: ExecuteResultHook{
+				OnExecuteInit:  func(session string) { sessions <- session },
+				OnExecuteError: func(err *execute.ErrorOutput) { errs <- err.EName },
+			},
+		})
+	}()
+	assert.Equal(t, "session-1", <-sessions)
+
+	if !assert.Eventually(t, func() bool {
+		interrupted, err = c.Interrupt("python")
+		return err == nil && interrupted
+	}, 5*time.Second, 10*time.Millisecond) {
+		t.FailNow()
+	}
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the interrupted run did not end")
+	}
+	assert.Equal(t, "KeyboardInterrupt", <-errs)
+	assert.Equal(t, InterruptedErrorName, <-errs, "the run must end with the interrupted event")
+
+	interrupted, err = c.Interrupt("session-1")
+	assert.NoError(t, err)
+	assert.False(t, interrupted)
+}
+
+func TestInterrupt_UnknownSession(t *testing.T) {
+	c := NewController("", "")
+
+	_, err := c.Interrupt("missing")
+	assert.True(t, errors.Is(err, ErrContextNotFound))
+}
+
+func TestExecute_TimeoutInterruptsKernel(t *testing.T) {
+	var interrupts atomic.Int32
+	handler := mockJupyterHandler(t, func(*websocket.Conn, *execute.Message) {
+		// never replies, so the run can only end through its timeout.
+	})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/interrupt") {
+			interrupts.Add(1)
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	c := NewController(server.URL, "token")
+	c.storeJupyterKernel("session-1", &jupyterKernel{kernelID: mockKernelID, client: c.jupyterClient(), language: Python})
+
+	var errs []string
+	start := time.Now()
+	err := c.Execute(&ExecuteCodeRequest{
+		Language: Python,
+		Context:  "session-1",
+		Code:     "import time; time.sleep(60)",
+		Timeout:  100 * time.Millisecond,
+		Hooks: ExecuteResultHook{
+			OnExecuteInit:  func(string) {},
+			OnExecuteError: func(err *execute.ErrorOutput) { errs = append(errs, err.EName) },
+		},
+	})
+
+	assert.ErrorIs(t, err, ErrExecutionTimeout)
+	assert.Less(t, time.Since(start), 5*time.Second, "the run was not bounded by its timeout")
+	assert.Equal(t, []string{TimeoutErrorName}, errs)
+	assert.EqualValues(t, 1, interrupts.Load(), "the kernel must be interrupted on timeout")
+	assert.False(t, c.getJupyterKernel("session-1").running.Load())
+}
@@ -18,39 +18,28 @@
 package runtime
 
 import (
-	"errors"
 	"fmt"
 	"os"
 	"time"
-
-	"github.com/alibaba/opensandbox/execd/pkg/log"
 )
 
-// Interrupt stops execution in the specified session.
-func (c *Controller) Interrupt(sessionID string) error {
-	switch {
-	case c.getJupyterKernel(sessionID) != nil:
-		kernel := c.getJupyterKernel(sessionID)
-		log.Warning("Interrupting Jupyter kernel %s", kernel.kernelID)
-		return kernel.client.InterruptKernel(kernel.kernelID)
-	case c.getCommandKernel(sessionID) != nil:
-		kernel := c.getCommandKernel(sessionID)
-		return c.killPid(kernel.pid)
-	default:
-		return errors.New("no such session")
-	}
-}
-
-// killPid terminates a process on Windows.
+// killPid terminates a process on Windows, along with its descendants when it
+// is a command running in a job object.
 func (c *Controller) killPid(pid int) error {
 	process, err := os.FindProcess(pid)
 	if err != nil {
 		return err
 	}
-	log.Warning("Attempting to terminate process %d", pid)
+	logger.Warning("Attempting to terminate process %d", pid)
 
-	if err := process.Kill(); err != nil {
-		return fmt.Errorf("failed to kill process %d: %w", pid, err)
+	inJob, err := terminateJob(pid)
+	if err != nil {
+		logger.Warning("failed to terminate the job of process %d: %v", pid, err)
+	}
+	if !inJob || err != nil {
+		if err := process.Kill(); err != nil {
+			return fmt.Errorf("failed to kill process %d: %w", pid, err)
+		}
 	}
 
 	// Best-effort wait to reduce zombies; os.Process.Wait only works for child processes.
@@ -63,7 +52,7 @@ func (c *Controller) killPid(pid int) error {
 	select {
 	case <-done:
 	case <-time.After(3 * time.Second):
-		log.Warning("Process %d kill wait timed out", pid)
+		logger.Warning("Process %d kill wait timed out", pid)
 	}
 
 	return nil
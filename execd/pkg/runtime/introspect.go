@@ -0,0 +1,99 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/alibaba/opensandbox/execd/pkg/jupyter/execute"
+)
+
+const introspectionTimeout = 10 * time.Second
+
+// IntrospectRequest asks the kernel of a context about the code around a cursor.
+type IntrospectRequest struct {
+	Language Language `json:"language"`
+	Context  string   `json:"context"`
+	Code     string   `json:"code"`
+	// CursorPos is the cursor offset in Code, counted in unicode code points.
+	CursorPos int `json:"cursor_pos"`
+	// DetailLevel only applies to inspection, 1 asks for more detail such as source code.
+	DetailLevel int `json:"detail_level"`
+}
+
+// Complete returns the kernel's completion matches at the cursor.
+func (c *Controller) Complete(request *IntrospectRequest) (*execute.CompleteReply, error) {
+	kernel, err := c.introspectionKernel(request)
+	if err != nil {
+		return nil, err
+	}
+	defer kernel.unlock()
+
+	err = kernel.client.ConnectToKernel(kernel.kernelID)
+	if err != nil {
+		return nil, err
+	}
+	defer kernel.client.DisconnectFromKernel(kernel.kernelID)
+
+	return kernel.client.Complete(request.Code, request.CursorPos, introspectionTimeout)
+}
+
+// Inspect returns the kernel's documentation of the object at the cursor.
+func (c *Controller) Inspect(request *IntrospectRequest) (*execute.InspectReply, error) {
+	kernel, err := c.introspectionKernel(request)
+	if err != nil {
+		return nil, err
+	}
+	defer kernel.unlock()
+
+	err = kernel.client.ConnectToKernel(kernel.kernelID)
+	if err != nil {
+		return nil, err
+	}
+	defer kernel.client.DisconnectFromKernel(kernel.kernelID)
+
+	return kernel.client.Inspect(request.Code, request.CursorPos, request.DetailLevel, introspectionTimeout)
+}
+
+// introspectionKernel resolves and locks the kernel of request. A kernel that is
+// executing code answers shell requests only once it is done, so a busy kernel
+// is reported instead of waited for.
+func (c *Controller) introspectionKernel(request *IntrospectRequest) (*jupyterKernel, error) {
+	language := request.Language
+	if request.Context != "" {
+		kernel := c.getJupyterKernel(request.Context)
+		if kernel == nil {
+			return nil, ErrContextNotFound
+		}
+		if language == "" {
+			language = kernel.language
+		}
+	}
+	switch language {
+	case Bash, Python, Java, JavaScript, TypeScript, Go:
+	default:
+		return nil, fmt.Errorf("introspection is not supported for language: %s", language)
+	}
+
+	_, kernel, err := c.resolveJupyterKernel(language, request.Context)
+	if err != nil {
+		return nil, err
+	}
+	if !kernel.tryLock() {
+		return nil, ErrSessionBusy
+	}
+	return kernel, nil
+}
@@ -0,0 +1,99 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/alibaba/opensandbox/execd/pkg/jupyter/execute"
+)
+
+func newIntrospectionJupyter(t *testing.T) func(conn *websocket.Conn, msg *execute.Message) {
+	return func(conn *websocket.Conn, msg *execute.Message) {
+		switch execute.MessageType(msg.Header.MessageType) {
+		case execute.MsgCompleteRequest:
+			var req execute.CompleteRequest
+			if err := json.Unmarshal(msg.Content, &req); err != nil {
+				t.Errorf("unmarshal complete request: %v", err)
+				return
+			}
+			replyMessage(t, conn, msg, execute.MsgCompleteReply, execute.CompleteReply{
+				Status:      "ok",
+				Matches:     []string{"print", "property"},
+				CursorStart: 0,
+				CursorEnd:   req.CursorPos,
+			})
+		case execute.MsgInspectRequest:
+			replyMessage(t, conn, msg, execute.MsgInspectReply, execute.InspectReply{
+				Status: "ok",
+				Found:  true,
+				Data:   map[string]interface{}{"text/plain": "Docstring: Prints the values to a stream."},
+			})
+		}
+	}
+}
+
+func TestCompleteAndInspectContext(t *testing.T) {
+	server := newMockJupyter(t, newIntrospectionJupyter(t))
+	defer server.Close()
+
+	c := NewController(server.URL, "token")
+	id, err := c.CreateContext(context.Background(), &CreateContextRequest{Language: Python})
+	if err != nil {
+		t.Fatalf("CreateContext returned error: %v", err)
+	}
+
+	complete, err := c.Complete(&IntrospectRequest{Context: id, Code: "pr", CursorPos: 2})
+	if err != nil {
+		t.Fatalf("Complete returned error: %v", err)
+	}
+	if len(complete.Matches) != 2 || complete.Matches[0] != "print" || complete.CursorEnd != 2 {
+		t.Fatalf("unexpected completion: %+v", complete)
+	}
+
+	inspect, err := c.Inspect(&IntrospectRequest{Context: id, Code: "print", CursorPos: 5})
+	if err != nil {
+		t.Fatalf("Inspect returned error: %v", err)
+	}
+	if !inspect.Found || inspect.Data["text/plain"] == nil {
+		t.Fatalf("unexpected inspection: %+v", inspect)
+	}
+}
+
+func TestCompleteRejectsUnknownAndBusyContexts(t *testing.T) {
+	server := newMockJupyter(t, newIntrospectionJupyter(t))
+	defer server.Close()
+
+	c := NewController(server.URL, "token")
+	if _, err := c.Complete(&IntrospectRequest{Context: "missing", Code: "pr", CursorPos: 2}); !errors.Is(err, ErrContextNotFound) {
+		t.Fatalf("expected ErrContextNotFound, got %v", err)
+	}
+
+	id, err := c.CreateContext(context.Background(), &CreateContextRequest{Language: Python})
+	if err != nil {
+		t.Fatalf("CreateContext returned error: %v", err)
+	}
+	kernel := c.getJupyterKernel(id)
+	kernel.lock()
+	defer kernel.unlock()
+	if _, err := c.Complete(&IntrospectRequest{Context: id, Code: "pr", CursorPos: 2}); !errors.Is(err, ErrSessionBusy) {
+		t.Fatalf("expected ErrSessionBusy while the kernel runs code, got %v", err)
+	}
+}
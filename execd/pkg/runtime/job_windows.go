@@ -0,0 +1,96 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build windows
+// +build windows
+
+package runtime
+
+import (
+	"os"
+	"os/exec"
+	"sync"
+
+	"golang.org/x/sys/windows"
+)
+
+// commandJobs holds the job objects of the running commands by pid.
+var commandJobs sync.Map
+
+// commandJob is a job object holding a command and every process it starts,
+// so that they can be terminated together like a process group.
+type commandJob struct {
+	handle windows.Handle
+	pid    int
+}
+
+// startInJob starts cmd in a new job object registered under its pid, so that
+// killPid and the cancellation of its context terminate its descendants too.
+// Processes the command starts before it joins the job escape it.
+func startInJob(cmd *exec.Cmd) (*commandJob, error) {
+	handle, err := windows.CreateJobObject(nil, nil)
+	if err != nil {
+		return nil, os.NewSyscallError("CreateJobObject", err)
+	}
+	job := &commandJob{handle: handle}
+	if cmd.Cancel != nil {
+		cmd.Cancel = func() error {
+			_ = job.terminate()
+			return cmd.Process.Kill()
+		}
+	}
+
+	if err := cmd.Start(); err != nil {
+		_ = windows.CloseHandle(handle)
+		return nil, err
+	}
+	job.pid = cmd.Process.Pid
+	if err := job.assign(cmd.Process.Pid); err != nil {
+		logger.Warning("failed to assign command %d to a job object, its descendants won't be terminated with it: %v", cmd.Process.Pid, err)
+	}
+	commandJobs.Store(job.pid, job)
+	return job, nil
+}
+
+// assign adds the process pid to the job.
+func (j *commandJob) assign(pid int) error {
+	process, err := windows.OpenProcess(windows.PROCESS_SET_QUOTA|windows.PROCESS_TERMINATE, false, uint32(pid)) //nolint:gosec // pids are non-negative
+	if err != nil {
+		return os.NewSyscallError("OpenProcess", err)
+	}
+	defer windows.CloseHandle(process) //nolint:errcheck
+	return os.NewSyscallError("AssignProcessToJobObject", windows.AssignProcessToJobObject(j.handle, process))
+}
+
+// terminate ends every process of the job with exit code 1.
+func (j *commandJob) terminate() error {
+	return os.NewSyscallError("TerminateJobObject", windows.TerminateJobObject(j.handle, 1))
+}
+
+// release unregisters the job once its command has exited. Descendants still
+// running, such as daemons of background commands, keep running.
+func (j *commandJob) release() {
+	commandJobs.CompareAndDelete(j.pid, j)
+	_ = windows.CloseHandle(j.handle)
+}
+
+// terminateJob ends the job of the command pid, reporting false when the
+// command has no job.
+func terminateJob(pid int) (bool, error) {
+	job, ok := commandJobs.Load(pid)
+	if !ok {
+		return false, nil
+	}
+	return true, job.(*commandJob).terminate()
+}
@@ -17,53 +17,77 @@ package runtime
 import (
 	"context"
 	"errors"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/alibaba/opensandbox/execd/pkg/jupyter"
 	"github.com/alibaba/opensandbox/execd/pkg/jupyter/execute"
-	"github.com/alibaba/opensandbox/execd/pkg/log"
+	jupyterkernel "github.com/alibaba/opensandbox/execd/pkg/jupyter/kernel"
 )
 
+const workingDirTimeout = 10 * time.Second
+
 // runJupyter executes code through a Jupyter kernel.
 func (c *Controller) runJupyter(ctx context.Context, request *ExecuteCodeRequest) error {
+	targetSessionID, kernel, err := c.resolveJupyterKernel(request.Language, request.Context)
+	if err != nil {
+		return err
+	}
+
+	request.SetDefaultHooks()
+	request.Hooks.OnExecuteInit(targetSessionID)
+
+	return c.runJupyterCode(ctx, kernel, request)
+}
+
+// resolveJupyterKernel returns the kernel for the given session, falling back to
+// the language default session (created on demand) when no context is given.
+func (c *Controller) resolveJupyterKernel(language Language, sessionID string) (string, *jupyterKernel, error) {
 	if c.baseURL == "" || c.token == "" {
-		return errors.New("language runtime server not configured, please check your image runtime")
+		return "", nil, errors.New("language runtime server not configured, please check your image runtime")
 	}
-	if request.Context == "" {
-		if _, exists := c.defaultLanguageJupyterSessions[request.Language]; !exists {
-			err := c.createDefaultLanguageContext(request.Language)
+	if sessionID == "" {
+		if _, exists := c.defaultLanguageJupyterSessions[language]; !exists {
+			err := c.createDefaultLanguageContext(language)
 			if err != nil {
-				return err
+				return "", nil, err
 			}
 		}
 	}
 
 	var targetSessionID string
-	if request.Context == "" {
-		targetSessionID = c.defaultLanguageJupyterSessions[request.Language]
+	if sessionID == "" {
+		targetSessionID = c.defaultLanguageJupyterSessions[language]
 	} else {
-		targetSessionID = request.Context
+		targetSessionID = sessionID
 	}
 
 	kernel := c.getJupyterKernel(targetSessionID)
 	if kernel == nil {
-		return ErrContextNotFound
+		return "", nil, ErrContextNotFound
 	}
-
-	request.SetDefaultHooks()
-	request.Hooks.OnExecuteInit(targetSessionID)
-
-	return c.runJupyterCode(ctx, kernel, request)
+	return targetSessionID, kernel, nil
 }
 
 // runJupyterCode streams execution results for a single kernel.
-//
-//nolint:gocognit // complex due to hook handling; refactor later
 func (c *Controller) runJupyterCode(ctx context.Context, kernel *jupyterKernel, request *ExecuteCodeRequest) error {
-	if !kernel.mu.TryLock() {
-		return errors.New("session is busy")
+	if !kernel.tryLock() {
+		return ErrSessionBusy
 	}
-	defer kernel.mu.Unlock()
+	defer kernel.unlock()
 
+	return c.streamJupyterCode(ctx, kernel, request)
+}
+
+// streamJupyterCode runs request on a kernel whose lock the caller holds.
+//
+//nolint:gocognit // complex due to hook handling; refactor later
+func (c *Controller) streamJupyterCode(ctx context.Context, kernel *jupyterKernel, request *ExecuteCodeRequest) error {
 	err := kernel.client.ConnectToKernel(kernel.kernelID)
 	if err != nil {
 		return err
@@ -71,55 +95,93 @@ func (c *Controller) runJupyterCode(ctx context.Context, kernel *jupyterKernel,
 	defer kernel.client.DisconnectFromKernel(kernel.kernelID)
 
 	results := make(chan *execute.ExecutionResult, 10)
+	hooks := request.Hooks
+	if !request.quiet {
+		hooks = kernel.outputs().tee(hooks)
+		hooks = kernel.executions.record(request.Code, hooks)
+	}
+	if request.DisplayDataDir != "" {
+		hooks = newDisplayDataSaver(kernel, request.DisplayDataDir).wrap(hooks)
+	}
+
+	kernel.interrupted.Store(false)
+	kernel.running.Store(true)
+	kernel.touch()
+	defer func() {
+		kernel.running.Store(false)
+		kernel.touch()
+	}()
 
 	err = kernel.client.ExecuteCodeStream(kernel.kernelID, request.Code, results)
 	if err != nil {
 		return err
 	}
 
+	closed := kernel.client.Closed()
+	var closedGrace <-chan time.Time
 	for {
 		select {
 		case result := <-results:
 			if result == nil {
+				if kernel.interrupted.Swap(false) {
+					hooks.OnExecuteError(interruptedError())
+				}
 				return nil
 			}
 
 			if result.ExecutionCount > 0 || len(result.ExecutionData) > 0 {
-				request.Hooks.OnExecuteResult(result.ExecutionData, result.ExecutionCount)
+				if result.ExecutionCount > 0 {
+					kernel.setExecutionCount(result.ExecutionCount)
+				}
+				hooks.OnExecuteResult(result.ExecutionData, result.ExecutionCount)
 			}
 
+			if isKernelDeathStatus(result.Status) {
+				return c.kernelDied(kernel, hooks, "the kernel is "+result.Status)
+			}
 			if result.Status != "" {
-				request.Hooks.OnExecuteStatus(result.Status)
+				hooks.OnExecuteStatus(result.Status)
 			}
 
 			if result.ExecutionTime > 0 {
-				request.Hooks.OnExecuteComplete(result.ExecutionTime)
+				hooks.OnExecuteComplete(result.ExecutionTime)
 			}
 
 			if result.Error != nil {
-				request.Hooks.OnExecuteError(result.Error)
+				hooks.OnExecuteError(result.Error)
 			}
 
 			if len(result.Stream) > 0 {
 				for _, stream := range result.Stream {
 					switch stream.Name {
 					case execute.StreamStdout:
-						request.Hooks.OnExecuteStdout(stream.Text)
+						hooks.OnExecuteStdout(stream.Text)
 					case execute.StreamStderr:
-						request.Hooks.OnExecuteStderr(stream.Text)
+						hooks.OnExecuteStderr(stream.Text)
 					default:
 					}
 				}
 			}
 
+		case <-closed:
+			// the last messages of a run may still be on their way.
+			closed, closedGrace = nil, time.After(kernelDeathGrace)
+
+		case <-closedGrace:
+			return c.kernelDied(kernel, hooks, "the connection to the kernel was closed")
+
 		case <-ctx.Done():
-			log.Warning("context cancelled, try to interrupt kernel")
+			logger.Warning("context cancelled, try to interrupt kernel")
 			err = kernel.client.InterruptKernel(kernel.kernelID)
 			if err != nil {
-				log.Error("interrupt kernel failed: %v", err)
+				logger.Error("interrupt kernel failed: %v", err)
 			}
 
-			request.Hooks.OnExecuteError(&execute.ErrorOutput{
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				hooks.OnExecuteError(timeoutError())
+				return ErrExecutionTimeout
+			}
+			hooks.OnExecuteError(&execute.ErrorOutput{
 				EName:  "ContextCancelled",
 				EValue: "Interrupt kernel",
 			})
@@ -128,9 +190,118 @@ func (c *Controller) runJupyterCode(ctx context.Context, kernel *jupyterKernel,
 	}
 }
 
-// setWorkingDir configures the working directory for a kernel session.
-func (c *Controller) setWorkingDir(_ *jupyterKernel, _ *CreateContextRequest) error {
-	return nil
+// kernelDied ends a run whose kernel died with a KernelDied error event and
+// forgets the kernel.
+func (c *Controller) kernelDied(kernel *jupyterKernel, hooks ExecuteResultHook, reason string) error {
+	logger.Error("kernel %s died during execution: %s", kernel.kernelID, reason)
+	hooks.OnExecuteError(kernelDiedError(reason))
+	c.forgetDeadKernel(kernel)
+	return ErrKernelDied
+}
+
+// setWorkingDir moves the kernel process into the directory holding the session notebook,
+// so relative paths in notebook code resolve against the requested cwd.
+func (c *Controller) setWorkingDir(kernel *jupyterKernel, req *CreateContextRequest) error {
+	if req.Cwd == "" {
+		return nil
+	}
+
+	cwd, err := filepath.Abs(req.Cwd)
+	if err != nil {
+		return err
+	}
+
+	code, ok := chdirCode(kernel.language, cwd)
+	if !ok {
+		logger.Warning("changing working directory is not supported for language %s, keeping kernel default", kernel.language)
+		return nil
+	}
+
+	kernel.lock()
+	defer kernel.unlock()
+
+	err = kernel.client.ConnectToKernel(kernel.kernelID)
+	if err != nil {
+		return err
+	}
+	defer kernel.client.DisconnectFromKernel(kernel.kernelID)
+
+	return kernel.client.ExecuteSilently(code, workingDirTimeout)
+}
+
+// chdirCode returns the snippet changing the process working directory in the given language.
+func chdirCode(language Language, dir string) (string, bool) {
+	switch language {
+	case Python:
+		return fmt.Sprintf("import os\nos.chdir(%s)", strconv.Quote(dir)), true
+	case JavaScript, TypeScript:
+		return fmt.Sprintf("process.chdir(%s)", strconv.Quote(dir)), true
+	case Go:
+		return fmt.Sprintf("import \"os\"\nos.Chdir(%s)", strconv.Quote(dir)), true
+	case Bash:
+		return "cd " + shellQuote(dir), true
+	default:
+		return "", false
+	}
+}
+
+// setEnv exports env into the kernel process of a new context, before any of
+// its code runs.
+func (c *Controller) setEnv(kernel *jupyterKernel, env map[string]string) error {
+	if len(env) == 0 {
+		return nil
+	}
+
+	code, ok := envCode(kernel.language, env)
+	if !ok {
+		logger.Warning("setting environment variables is not supported for language %s, ignoring them", kernel.language)
+		return nil
+	}
+
+	kernel.lock()
+	defer kernel.unlock()
+
+	err := kernel.client.ConnectToKernel(kernel.kernelID)
+	if err != nil {
+		return err
+	}
+	defer kernel.client.DisconnectFromKernel(kernel.kernelID)
+
+	return kernel.client.ExecuteSilently(code, workingDirTimeout)
+}
+
+// envCode returns the snippet setting the process environment variables in the given language.
+func envCode(language Language, env map[string]string) (string, bool) {
+	var b strings.Builder
+	keys := slices.Sorted(maps.Keys(env))
+	switch language {
+	case Python:
+		b.WriteString("import os\n")
+		for _, key := range keys {
+			fmt.Fprintf(&b, "os.environ[%s] = %s\n", strconv.Quote(key), strconv.Quote(env[key]))
+		}
+	case JavaScript, TypeScript:
+		for _, key := range keys {
+			fmt.Fprintf(&b, "process.env[%s] = %s\n", strconv.Quote(key), strconv.Quote(env[key]))
+		}
+	case Go:
+		b.WriteString("import \"os\"\n")
+		for _, key := range keys {
+			fmt.Fprintf(&b, "os.Setenv(%s, %s)\n", strconv.Quote(key), strconv.Quote(env[key]))
+		}
+	case Bash:
+		for _, key := range keys {
+			fmt.Fprintf(&b, "export %s=%s\n", key, shellQuote(env[key]))
+		}
+	default:
+		return "", false
+	}
+	return b.String(), true
+}
+
+// shellQuote quotes s as a single bash word.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
 }
 
 // getJupyterKernel retrieves a kernel connection from the session map.
@@ -152,6 +323,16 @@ func (c *Controller) searchKernel(client *jupyter.Client, language Language) (st
 		return "", errors.New("no kernel specs found")
 	}
 
+	kernelName := kernelForLanguage(specs, language)
+	if kernelName == "" {
+		return "", fmt.Errorf("%w: %s", ErrNoKernelForLanguage, language)
+	}
+
+	return kernelName, nil
+}
+
+// kernelForLanguage names the kernel spec running language, or "" when none does.
+func kernelForLanguage(specs *jupyterkernel.KernelSpecs, language Language) string {
 	var kernelName string
 	for name, spec := range specs.Kernelspecs {
 		if name == "python3" {
@@ -162,9 +343,5 @@ func (c *Controller) searchKernel(client *jupyter.Client, language Language) (st
 			kernelName = name
 		}
 	}
-	if kernelName == "" {
-		return "", errors.New("no kernel specs found")
-	}
-
-	return kernelName, nil
+	return kernelName
 }
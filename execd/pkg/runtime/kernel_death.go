@@ -0,0 +1,80 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"time"
+
+	"github.com/alibaba/opensandbox/execd/pkg/jupyter/execute"
+)
+
+// kernelDeathGrace is how long a run whose kernel connection closed may still
+// complete before its kernel is deemed dead.
+const kernelDeathGrace = time.Second
+
+// KernelDiedErrorName names the error event ending a run whose kernel died.
+const KernelDiedErrorName = "KernelDied"
+
+// kernelDiedError is the last error event of a run whose kernel died.
+func kernelDiedError(reason string) *execute.ErrorOutput {
+	return &execute.ErrorOutput{
+		EName:  KernelDiedErrorName,
+		EValue: "the kernel died during execution: " + reason,
+	}
+}
+
+// isKernelDeathStatus reports whether a status sent while code runs means the
+// kernel died; a restarted kernel lost the state of the context as well.
+func isKernelDeathStatus(status string) bool {
+	switch execute.ExecutionState(status) {
+	case execute.StateDead, execute.StateRestarting:
+		return true
+	default:
+		return false
+	}
+}
+
+// forgetDeadKernel drops the contexts backed by a kernel that died, so later
+// runs fail with ErrContextNotFound, or get a new default context, instead of
+// hanging on it. Its Jupyter session is deleted in case the server kept it.
+func (c *Controller) forgetDeadKernel(kernel *jupyterKernel) {
+	var sessions []string
+	c.mu.Lock()
+	for session, k := range c.jupyterClientMap {
+		if k != kernel {
+			continue
+		}
+		sessions = append(sessions, session)
+		delete(c.jupyterClientMap, session)
+		for lang, id := range c.defaultLanguageJupyterSessions {
+			if id == session {
+				delete(c.defaultLanguageJupyterSessions, lang)
+			}
+		}
+	}
+	c.mu.Unlock()
+
+	if len(sessions) == 0 {
+		return
+	}
+	kernel.outputs().close()
+	kernel.removeFiles(false)
+	for _, session := range sessions {
+		logger.Warning("kernel %s of context %s died, forgetting the context", kernel.kernelID, session)
+		if err := c.jupyterClient().DeleteSession(session); err != nil {
+			logger.Warning("failed to delete session %s of dead kernel: %v", session, err)
+		}
+	}
+}
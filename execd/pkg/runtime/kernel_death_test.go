@@ -0,0 +1,84 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/alibaba/opensandbox/execd/pkg/jupyter/execute"
+)
+
+// runOnDyingKernel runs code on a mock kernel answering its execute_request
+// with die, and returns the names of the error events of the run and its error.
+func runOnDyingKernel(t *testing.T, die func(conn *websocket.Conn, msg *execute.Message)) (*Controller, []string, error) {
+	t.Helper()
+	server := newMockJupyter(t, func(conn *websocket.Conn, msg *execute.Message) {
+		if execute.MessageType(msg.Header.MessageType) == execute.MsgExecuteRequest {
+			die(conn, msg)
+		}
+	})
+	t.Cleanup(server.Close)
+	c := NewController(server.URL, "token")
+	c.storeJupyterKernel("session-1", &jupyterKernel{kernelID: mockKernelID, client: c.jupyterClient(), language: Python})
+	c.defaultLanguageJupyterSessions[Python] = "session-1"
+
+	var errs []string
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Execute(&ExecuteCodeRequest{
+			Language: Python,
+			Context:  "session-1",
+			Code:     "import os; os._exit(1)",
+			Hooks: ExecuteResultHook{
+				OnExecuteInit:  func(string) {},
+				OnExecuteError: func(err *execute.ErrorOutput) { errs = append(errs, err.EName) },
+			},
+		})
+	}()
+
+	select {
+	case err := <-done:
+		return c, errs, err
+	case <-time.After(5 * time.Second):
+		t.Fatal("the run on the dead kernel did not end")
+		return nil, nil, nil
+	}
+}
+
+func TestExecute_ConnectionClosedMidExecution(t *testing.T) {
+	c, errs, err := runOnDyingKernel(t, func(conn *websocket.Conn, msg *execute.Message) {
+		replyMessage(t, conn, msg, execute.MsgStatus, execute.StatusUpdate{ExecutionState: execute.StateBusy})
+		_ = conn.Close()
+	})
+
+	assert.ErrorIs(t, err, ErrKernelDied)
+	assert.Equal(t, []string{KernelDiedErrorName}, errs)
+	assert.Nil(t, c.getJupyterKernel("session-1"), "the dead context must be forgotten")
+	assert.Empty(t, c.defaultLanguageJupyterSessions)
+}
+
+func TestExecute_DeadStatusMidExecution(t *testing.T) {
+	c, errs, err := runOnDyingKernel(t, func(conn *websocket.Conn, msg *execute.Message) {
+		replyMessage(t, conn, msg, execute.MsgStatus, execute.StatusUpdate{ExecutionState: execute.StateDead})
+	})
+
+	assert.ErrorIs(t, err, ErrKernelDied)
+	assert.Equal(t, []string{KernelDiedErrorName}, errs)
+	assert.Nil(t, c.getJupyterKernel("session-1"))
+}
@@ -29,6 +29,9 @@ const (
 	BackgroundCommand Language = "background-command"
 )
 
+// Languages lists every language a context or execution request may name.
+var Languages = []Language{Command, Bash, Python, Java, JavaScript, TypeScript, Go, SQL, BackgroundCommand}
+
 // String returns the string representation of the language
 func (l Language) String() string {
 	return string(l)
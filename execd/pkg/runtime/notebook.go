@@ -0,0 +1,185 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// notebook is an nbformat v4 document.
+type notebook struct {
+	Cells         []notebookCell `json:"cells"`
+	Metadata      map[string]any `json:"metadata"`
+	NBFormat      int            `json:"nbformat"`
+	NBFormatMinor int            `json:"nbformat_minor"`
+}
+
+type notebookCell struct {
+	CellType       string           `json:"cell_type"`
+	ExecutionCount *int             `json:"execution_count"`
+	Metadata       map[string]any   `json:"metadata"`
+	Source         []string         `json:"source"`
+	Outputs        []map[string]any `json:"outputs"`
+}
+
+// ExportNotebook assembles the executions of a context into an nbformat v4
+// notebook, one code cell with its outputs per execution. Output dropped when
+// it was recorded is replaced by a marker on stderr.
+func ExportNotebook(language Language, executions []Execution) ([]byte, error) {
+	nb := notebook{
+		Cells: make([]notebookCell, 0, len(executions)),
+		Metadata: map[string]any{
+			"kernelspec": map[string]any{
+				"name":         language.String(),
+				"display_name": language.String(),
+				"language":     language.String(),
+			},
+			"language_info": map[string]any{"name": language.String()},
+		},
+		NBFormat:      4,
+		NBFormatMinor: 5,
+	}
+
+	for _, execution := range executions {
+		cell := notebookCell{
+			CellType: "code",
+			Metadata: map[string]any{},
+			Source:   notebookLines(execution.Code),
+			Outputs:  make([]map[string]any, 0, len(execution.Outputs)),
+		}
+		if execution.ExecutionCount > 0 {
+			count := execution.ExecutionCount
+			cell.ExecutionCount = &count
+		}
+
+		for _, output := range execution.Outputs {
+			switch output.Type {
+			case OutputStdout, OutputStderr:
+				cell.Outputs = append(cell.Outputs, map[string]any{
+					"output_type": "stream",
+					"name":        output.Type,
+					"text":        notebookLines(output.Text),
+				})
+			case OutputResult:
+				if output.ExecutionCount > 0 {
+					cell.Outputs = append(cell.Outputs, map[string]any{
+						"output_type":     "execute_result",
+						"execution_count": output.ExecutionCount,
+						"data":            output.Results,
+						"metadata":        map[string]any{},
+					})
+				} else {
+					cell.Outputs = append(cell.Outputs, map[string]any{
+						"output_type": "display_data",
+						"data":        output.Results,
+						"metadata":    map[string]any{},
+					})
+				}
+			case OutputError:
+				if output.Error == nil {
+					continue
+				}
+				traceback := output.Error.Traceback
+				if traceback == nil {
+					traceback = []string{}
+				}
+				cell.Outputs = append(cell.Outputs, map[string]any{
+					"output_type": "error",
+					"ename":       output.Error.EName,
+					"evalue":      output.Error.EValue,
+					"traceback":   traceback,
+				})
+			}
+		}
+		if execution.OmittedBytes > 0 {
+			cell.Outputs = append(cell.Outputs, map[string]any{
+				"output_type": "stream",
+				"name":        OutputStderr,
+				"text":        []string{fmt.Sprintf("... [%d bytes of output truncated]\n", execution.OmittedBytes)},
+			})
+		}
+		nb.Cells = append(nb.Cells, cell)
+	}
+
+	return json.MarshalIndent(nb, "", " ")
+}
+
+// ExportScript concatenates the code of the executions of a context into a
+// plain script, each cell headed by a comment with its execution count.
+func ExportScript(language Language, executions []Execution) []byte {
+	comment := scriptComment(language)
+
+	var b strings.Builder
+	for i, execution := range executions {
+		if i > 0 {
+			b.WriteString("\n\n")
+		}
+		if execution.ExecutionCount > 0 {
+			fmt.Fprintf(&b, "%s In[%d]:\n", comment, execution.ExecutionCount)
+		} else {
+			fmt.Fprintf(&b, "%s In[ ]:\n", comment)
+		}
+		b.WriteString(execution.Code)
+		if !strings.HasSuffix(execution.Code, "\n") {
+			b.WriteString("\n")
+		}
+	}
+	return []byte(b.String())
+}
+
+// ScriptExtension returns the file extension of scripts in language.
+func ScriptExtension(language Language) string {
+	switch language {
+	case Python:
+		return "py"
+	case JavaScript:
+		return "js"
+	case TypeScript:
+		return "ts"
+	case Go:
+		return "go"
+	case Java:
+		return "java"
+	case Bash:
+		return "sh"
+	default:
+		return "txt"
+	}
+}
+
+// scriptComment returns the line comment prefix of language.
+func scriptComment(language Language) string {
+	switch language {
+	case JavaScript, TypeScript, Go, Java:
+		return "//"
+	default:
+		return "#"
+	}
+}
+
+// notebookLines splits text into the line list of a multiline notebook
+// string, each line keeping its newline.
+func notebookLines(text string) []string {
+	if text == "" {
+		return []string{}
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
@@ -0,0 +1,112 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestExportNotebook_CellsFromHistory(t *testing.T) {
+	c := newEchoKernel(t)
+
+	if _, err := runBatch(t, c, []string{"first\n", "fail"}, false); err != nil {
+		t.Fatalf("ExecuteBatch returned error: %v", err)
+	}
+
+	executions, err := c.ContextHistory("session-1")
+	if err != nil {
+		t.Fatalf("ContextHistory returned error: %v", err)
+	}
+	if len(executions) != 2 {
+		t.Fatalf("expected 2 executions, got %d", len(executions))
+	}
+
+	data, err := ExportNotebook(Python, executions)
+	if err != nil {
+		t.Fatalf("ExportNotebook returned error: %v", err)
+	}
+	var nb notebook
+	if err := json.Unmarshal(data, &nb); err != nil {
+		t.Fatalf("invalid notebook JSON: %v", err)
+	}
+	if nb.NBFormat != 4 || len(nb.Cells) != 2 {
+		t.Fatalf("unexpected notebook %+v", nb)
+	}
+
+	first := nb.Cells[0]
+	if strings.Join(first.Source, "") != "first\n" {
+		t.Fatalf("unexpected source %q", first.Source)
+	}
+	if len(first.Outputs) != 1 || first.Outputs[0]["output_type"] != "stream" || first.Outputs[0]["name"] != "stdout" {
+		t.Fatalf("expected one stdout output, got %+v", first.Outputs)
+	}
+
+	second := nb.Cells[1]
+	if len(second.Outputs) != 1 || second.Outputs[0]["output_type"] != "error" || second.Outputs[0]["ename"] != "RuntimeError" {
+		t.Fatalf("expected the error output, got %+v", second.Outputs)
+	}
+
+	if _, err := c.ContextHistory("missing"); err != ErrContextNotFound {
+		t.Fatalf("expected ErrContextNotFound, got %v", err)
+	}
+}
+
+func TestExecutionHistory_TruncatesLargeOutput(t *testing.T) {
+	var h executionHistory
+	req := &ExecuteCodeRequest{}
+	req.SetDefaultHooks()
+	req.Hooks.OnExecuteStdout = func(string) {}
+	hooks := h.record("spam()", req.Hooks)
+
+	hooks.OnExecuteStdout(strings.Repeat("x", executionOutputLimit-10))
+	hooks.OnExecuteStdout(strings.Repeat("y", 30))
+
+	executions := h.list()
+	if len(executions) != 1 {
+		t.Fatalf("expected 1 execution, got %d", len(executions))
+	}
+	execution := executions[0]
+	if len(execution.Outputs) != 1 || len(execution.Outputs[0].Text) != executionOutputLimit {
+		t.Fatalf("expected the stdout merged and capped at the limit, got %d outputs", len(execution.Outputs))
+	}
+	if execution.OmittedBytes != 20 {
+		t.Fatalf("expected 20 omitted bytes, got %d", execution.OmittedBytes)
+	}
+
+	data, err := ExportNotebook(Python, executions)
+	if err != nil {
+		t.Fatalf("ExportNotebook returned error: %v", err)
+	}
+	if !strings.Contains(string(data), "20 bytes of output truncated") {
+		t.Fatal("expected a truncation marker in the notebook")
+	}
+}
+
+func TestExportScript(t *testing.T) {
+	executions := []Execution{
+		{Code: "a = 1", ExecutionCount: 1},
+		{Code: "print(a)\n", ExecutionCount: 2},
+	}
+	got := string(ExportScript(Python, executions))
+	want := "# In[1]:\na = 1\n\n\n# In[2]:\nprint(a)\n"
+	if got != want {
+		t.Fatalf("unexpected script:\n%q\nwant\n%q", got, want)
+	}
+	if ScriptExtension(JavaScript) != "js" || !strings.HasPrefix(string(ExportScript(Go, executions)), "// In[1]:") {
+		t.Fatal("expected language-specific extension and comments")
+	}
+}
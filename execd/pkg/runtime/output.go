@@ -0,0 +1,186 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"sync"
+	"time"
+
+	"github.com/alibaba/opensandbox/execd/pkg/jupyter/execute"
+)
+
+const (
+	// contextOutputCapacity is how many recent output events a context keeps
+	// for clients attaching late.
+	contextOutputCapacity = 1000
+	// outputSubscriberBuffer is how many live events a subscriber may lag
+	// behind before its subscription is closed.
+	outputSubscriberBuffer = 256
+)
+
+// Output event types, named after the stream events they mirror.
+const (
+	OutputStdout   = "stdout"
+	OutputStderr   = "stderr"
+	OutputResult   = "result"
+	OutputError    = "error"
+	OutputComplete = "execution_complete"
+)
+
+// OutputEvent is one output event of code run in a context. Seq numbers the
+// events of a context from 1 without gaps.
+type OutputEvent struct {
+	Seq            int64                `json:"seq"`
+	Type           string               `json:"type"`
+	Text           string               `json:"text,omitempty"`
+	Results        map[string]any       `json:"results,omitempty"`
+	ExecutionCount int                  `json:"execution_count,omitempty"`
+	Error          *execute.ErrorOutput `json:"error,omitempty"`
+	ExecutionTime  time.Duration        `json:"execution_time,omitempty"`
+	Time           time.Time            `json:"time"`
+}
+
+// OutputSubscription replays the recent output of a context, then follows it.
+type OutputSubscription struct {
+	// Replay holds the buffered events, oldest first.
+	Replay []OutputEvent
+	// Events delivers the events published after Replay. It is closed when the
+	// context is deleted or the subscriber falls outputSubscriberBuffer events
+	// behind; the subscriber may attach again after the last Seq it saw.
+	Events <-chan OutputEvent
+
+	buffer *outputBuffer
+	ch     chan OutputEvent
+}
+
+// Close stops the subscription.
+func (s *OutputSubscription) Close() {
+	s.buffer.unsubscribe(s.ch)
+}
+
+// outputBuffer keeps the recent output of a context and fans it out to subscribers.
+type outputBuffer struct {
+	mu          sync.Mutex
+	events      []OutputEvent
+	seq         int64
+	closed      bool
+	subscribers map[chan OutputEvent]struct{}
+}
+
+func newOutputBuffer() *outputBuffer {
+	return &outputBuffer{subscribers: make(map[chan OutputEvent]struct{})}
+}
+
+func (b *outputBuffer) publish(event OutputEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+
+	b.seq++
+	event.Seq = b.seq
+	event.Time = time.Now()
+	if len(b.events) == contextOutputCapacity {
+		b.events = append(b.events[:0], b.events[1:]...)
+	}
+	b.events = append(b.events, event)
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// subscribe returns the buffered events after since and registers for the
+// following ones; no event is missed or repeated in between.
+func (b *outputBuffer) subscribe(since int64) *OutputSubscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var replay []OutputEvent
+	for _, event := range b.events {
+		if event.Seq > since {
+			replay = append(replay, event)
+		}
+	}
+	ch := make(chan OutputEvent, outputSubscriberBuffer)
+	if b.closed {
+		close(ch)
+	} else {
+		b.subscribers[ch] = struct{}{}
+	}
+	return &OutputSubscription{Replay: replay, Events: ch, buffer: b, ch: ch}
+}
+
+func (b *outputBuffer) unsubscribe(ch chan OutputEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subscribers[ch]; ok {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+}
+
+// close ends every subscription, once the context is gone.
+func (b *outputBuffer) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for ch := range b.subscribers {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+}
+
+// tee returns hooks also publishing the output reported to hooks.
+func (b *outputBuffer) tee(hooks ExecuteResultHook) ExecuteResultHook {
+	teed := hooks
+	teed.OnExecuteResult = func(result map[string]any, count int) {
+		b.publish(OutputEvent{Type: OutputResult, Results: result, ExecutionCount: count})
+		hooks.OnExecuteResult(result, count)
+	}
+	teed.OnExecuteStdout = func(text string) {
+		b.publish(OutputEvent{Type: OutputStdout, Text: text})
+		hooks.OnExecuteStdout(text)
+	}
+	teed.OnExecuteStderr = func(text string) {
+		b.publish(OutputEvent{Type: OutputStderr, Text: text})
+		hooks.OnExecuteStderr(text)
+	}
+	teed.OnExecuteError = func(err *execute.ErrorOutput) {
+		b.publish(OutputEvent{Type: OutputError, Error: err})
+		hooks.OnExecuteError(err)
+	}
+	teed.OnExecuteComplete = func(executionTime time.Duration) {
+		b.publish(OutputEvent{Type: OutputComplete, ExecutionTime: executionTime})
+		hooks.OnExecuteComplete(executionTime)
+	}
+	return teed
+}
+
+// AttachOutput subscribes to the output of a context, replaying the buffered
+// events numbered above since first. Callers must Close the subscription.
+func (c *Controller) AttachOutput(session string, since int64) (*OutputSubscription, error) {
+	kernel := c.getJupyterKernel(session)
+	if kernel == nil {
+		return nil, ErrContextNotFound
+	}
+	return kernel.outputs().subscribe(since), nil
+}
@@ -0,0 +1,124 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"testing"
+	"time"
+)
+
+// outputTypes lists the type and text of events, for comparing streams.
+func outputTypes(events []OutputEvent) []string {
+	var types []string
+	for _, event := range events {
+		types = append(types, event.Type+":"+event.Text)
+	}
+	return types
+}
+
+func TestAttachOutput_ReplaysToLateSubscriber(t *testing.T) {
+	c := newEchoKernel(t)
+
+	if _, err := runBatch(t, c, []string{"first", "fail"}, false); err != nil {
+		t.Fatalf("ExecuteBatch returned error: %v", err)
+	}
+
+	subscription, err := c.AttachOutput("session-1", 0)
+	if err != nil {
+		t.Fatalf("AttachOutput returned error: %v", err)
+	}
+	defer subscription.Close()
+
+	replay := outputTypes(subscription.Replay)
+	if len(replay) == 0 || replay[0] != "stdout:first" {
+		t.Fatalf("expected the replay to start with the first output, got %v", replay)
+	}
+	var sawError bool
+	for i, event := range subscription.Replay {
+		if event.Seq != int64(i+1) {
+			t.Fatalf("expected gapless seqs, got %d at %d", event.Seq, i)
+		}
+		sawError = sawError || event.Type == OutputError
+	}
+	if !sawError {
+		t.Fatalf("expected the error to be replayed, got %v", replay)
+	}
+
+	if _, err := runBatch(t, c, []string{"live"}, false); err != nil {
+		t.Fatalf("ExecuteBatch returned error: %v", err)
+	}
+	select {
+	case event := <-subscription.Events:
+		if event.Type != OutputStdout || event.Text != "live" || event.Seq != int64(len(replay)+1) {
+			t.Fatalf("unexpected live event %+v", event)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for the live output")
+	}
+
+	resumed, err := c.AttachOutput("session-1", int64(len(replay)))
+	if err != nil {
+		t.Fatalf("AttachOutput returned error: %v", err)
+	}
+	defer resumed.Close()
+	if len(resumed.Replay) == 0 || resumed.Replay[0].Text != "live" {
+		t.Fatalf("expected only the events after since, got %v", outputTypes(resumed.Replay))
+	}
+
+	if err := c.deleteSessionAndCleanup("session-1", false); err != nil {
+		t.Fatalf("delete context: %v", err)
+	}
+	for range subscription.Events {
+	}
+}
+
+func TestAttachOutput_UnknownContext(t *testing.T) {
+	c := NewController("http://127.0.0.1:0", "token")
+	if _, err := c.AttachOutput("missing", 0); err != ErrContextNotFound {
+		t.Fatalf("expected ErrContextNotFound, got %v", err)
+	}
+}
+
+func TestOutputBuffer_KeepsRecentEvents(t *testing.T) {
+	b := newOutputBuffer()
+	for range contextOutputCapacity + 5 {
+		b.publish(OutputEvent{Type: OutputStdout, Text: "x"})
+	}
+
+	subscription := b.subscribe(0)
+	defer subscription.Close()
+	if len(subscription.Replay) != contextOutputCapacity || subscription.Replay[0].Seq != 6 {
+		t.Fatalf("expected the last %d events from seq 6, got %d from %d",
+			contextOutputCapacity, len(subscription.Replay), subscription.Replay[0].Seq)
+	}
+}
+
+func TestOutputBuffer_DropsLaggingSubscriber(t *testing.T) {
+	b := newOutputBuffer()
+	subscription := b.subscribe(0)
+	defer subscription.Close()
+
+	for range outputSubscriberBuffer + 1 {
+		b.publish(OutputEvent{Type: OutputStdout, Text: "x"})
+	}
+
+	received := 0
+	for range subscription.Events {
+		received++
+	}
+	if received != outputSubscriberBuffer {
+		t.Fatalf("expected %d events before the subscription closed, got %d", outputSubscriberBuffer, received)
+	}
+}
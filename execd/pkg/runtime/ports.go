@@ -0,0 +1,52 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"cmp"
+	"slices"
+)
+
+const (
+	ProtocolTCP = "tcp"
+	ProtocolUDP = "udp"
+
+	FamilyIPv4 = "ipv4"
+	FamilyIPv6 = "ipv6"
+)
+
+// ListeningPort is a TCP socket listening for connections, or a UDP socket
+// bound to a port without a peer.
+type ListeningPort struct {
+	Port     int
+	Protocol string
+	Family   string
+	Address  string
+	// PID and Process identify the owner, unset when execd may not inspect it.
+	PID     int
+	Process string
+}
+
+// sortListeningPorts orders ports by port, protocol, family and address.
+func sortListeningPorts(ports []ListeningPort) {
+	slices.SortFunc(ports, func(a, b ListeningPort) int {
+		return cmp.Or(
+			cmp.Compare(a.Port, b.Port),
+			cmp.Compare(a.Protocol, b.Protocol),
+			cmp.Compare(a.Family, b.Family),
+			cmp.Compare(a.Address, b.Address),
+		)
+	})
+}
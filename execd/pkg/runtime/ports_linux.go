@@ -0,0 +1,199 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package runtime
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// tcpListenState is the TCP_LISTEN state in /proc/net/tcp.
+	tcpListenState = "0A"
+	// udpUnconnectedState is the TCP_CLOSE state of UDP sockets without a peer.
+	udpUnconnectedState = "07"
+)
+
+// procNetTables lists the socket tables read by ListListeningPorts.
+var procNetTables = []struct {
+	file     string
+	protocol string
+	family   string
+	state    string
+}{
+	{"tcp", ProtocolTCP, FamilyIPv4, tcpListenState},
+	{"tcp6", ProtocolTCP, FamilyIPv6, tcpListenState},
+	{"udp", ProtocolUDP, FamilyIPv4, udpUnconnectedState},
+	{"udp6", ProtocolUDP, FamilyIPv6, udpUnconnectedState},
+}
+
+// ListListeningPorts returns the listening sockets of /proc/net/{tcp,udp}{,6},
+// ordered by port, with the processes owning them found through /proc/*/fd.
+func ListListeningPorts() ([]ListeningPort, error) {
+	return listListeningPorts("/proc")
+}
+
+func listListeningPorts(procRoot string) ([]ListeningPort, error) {
+	var ports []ListeningPort
+	inodes := make(map[string][]int)
+	for _, table := range procNetTables {
+		file, err := os.Open(filepath.Join(procRoot, "net", table.file))
+		if os.IsNotExist(err) {
+			// IPv6 may be disabled.
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read socket table %s: %w", table.file, err)
+		}
+		sockets, err := parseSocketTable(file, table.state)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse socket table %s: %w", table.file, err)
+		}
+		for _, socket := range sockets {
+			inodes[socket.inode] = append(inodes[socket.inode], len(ports))
+			ports = append(ports, ListeningPort{
+				Port:     socket.port,
+				Protocol: table.protocol,
+				Family:   table.family,
+				Address:  socket.address,
+			})
+		}
+	}
+
+	if len(ports) > 0 {
+		owners := socketOwners(procRoot, inodes)
+		for inode, indexes := range inodes {
+			owner, ok := owners[inode]
+			if !ok {
+				continue
+			}
+			for _, i := range indexes {
+				ports[i].PID = owner.pid
+				ports[i].Process = owner.name
+			}
+		}
+	}
+	sortListeningPorts(ports)
+	return ports, nil
+}
+
+// procSocket is a socket of a /proc/net table.
+type procSocket struct {
+	address string
+	port    int
+	inode   string
+}
+
+// parseSocketTable returns the sockets of a /proc/net/{tcp,udp}{,6} table in state.
+func parseSocketTable(file *os.File, state string) ([]procSocket, error) {
+	var sockets []procSocket
+	scanner := bufio.NewScanner(file)
+	// the first line names the columns.
+	scanner.Scan()
+	for scanner.Scan() {
+		// sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid timeout inode
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 || fields[3] != state || fields[9] == "0" {
+			continue
+		}
+		address, port, err := parseHexAddress(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		sockets = append(sockets, procSocket{address: address, port: port, inode: fields[9]})
+	}
+	return sockets, scanner.Err()
+}
+
+// parseHexAddress decodes an address:port of a /proc/net table, whose address
+// is written as 32-bit words in host byte order.
+func parseHexAddress(value string) (string, int, error) {
+	hexIP, hexPort, ok := strings.Cut(value, ":")
+	if !ok {
+		return "", 0, fmt.Errorf("invalid socket address %q", value)
+	}
+	port, err := strconv.ParseUint(hexPort, 16, 16)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid socket port %q", value)
+	}
+	raw, err := hex.DecodeString(hexIP)
+	if err != nil || (len(raw) != net.IPv4len && len(raw) != net.IPv6len) {
+		return "", 0, fmt.Errorf("invalid socket address %q", value)
+	}
+	ip := make(net.IP, len(raw))
+	for word := 0; word < len(raw); word += 4 {
+		// little endian words, as on every architecture Linux sandboxes run on.
+		ip[word], ip[word+1], ip[word+2], ip[word+3] = raw[word+3], raw[word+2], raw[word+1], raw[word]
+	}
+	return ip.String(), int(port), nil
+}
+
+// socketOwner is a process holding a socket.
+type socketOwner struct {
+	pid  int
+	name string
+}
+
+// socketOwners finds the processes holding the sockets of inodes through
+// their file descriptors. Processes execd may not inspect are skipped.
+func socketOwners(procRoot string, inodes map[string][]int) map[string]socketOwner {
+	owners := make(map[string]socketOwner)
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return owners
+	}
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		fdDir := filepath.Join(procRoot, entry.Name(), "fd")
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue
+		}
+		name := ""
+		for _, fd := range fds {
+			link, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			if err != nil || !strings.HasPrefix(link, "socket:[") {
+				continue
+			}
+			inode := strings.TrimSuffix(strings.TrimPrefix(link, "socket:["), "]")
+			if _, ok := inodes[inode]; !ok {
+				continue
+			}
+			if owner, ok := owners[inode]; ok && owner.pid < pid {
+				// forked children share the socket, keep the lowest pid.
+				continue
+			}
+			if name == "" {
+				comm, _ := os.ReadFile(filepath.Join(procRoot, entry.Name(), "comm"))
+				name = strings.TrimSpace(string(comm))
+			}
+			owners[inode] = socketOwner{pid: pid, name: name}
+		}
+	}
+	return owners
+}
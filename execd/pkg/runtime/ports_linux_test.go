@@ -0,0 +1,93 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package runtime
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListListeningPorts_ParsesProcTables(t *testing.T) {
+	root := t.TempDir()
+	writeFile := func(path, content string) {
+		t.Helper()
+		path = filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	header := "  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode\n"
+	writeFile("net/tcp", header+
+		"   0: 0100007F:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 4242 1 0 100 0 0 10 0\n"+
+		"   1: 0100007F:A1B2 0100007F:1F90 01 00000000:00000000 00:00000000 00000000  1000        0 4343 1 0 100 0 0 10 0\n")
+	writeFile("net/tcp6", header+
+		"   0: 00000000000000000000000000000000:0BB8 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 5151 1 0 100 0 0 10 0\n")
+	writeFile("net/udp", header+
+		"   0: 00000000:0035 00000000:0000 07 00000000:00000000 00:00000000 00000000     0        0 6161 2 0 0\n")
+	writeFile("321/comm", "node\n")
+	if err := os.MkdirAll(filepath.Join(root, "321", "fd"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("socket:[4242]", filepath.Join(root, "321", "fd", "7")); err != nil {
+		t.Fatal(err)
+	}
+
+	ports, err := listListeningPorts(root)
+	assert.NoError(t, err)
+	assert.Equal(t, []ListeningPort{
+		{Port: 53, Protocol: ProtocolUDP, Family: FamilyIPv4, Address: "0.0.0.0"},
+		{Port: 3000, Protocol: ProtocolTCP, Family: FamilyIPv6, Address: "::"},
+		{Port: 8080, Protocol: ProtocolTCP, Family: FamilyIPv4, Address: "127.0.0.1", PID: 321, Process: "node"},
+	}, ports, "the established connection must be left out")
+}
+
+func TestListListeningPorts_FindsOwnListener(t *testing.T) {
+	listener, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	ports, err := ListListeningPorts()
+	assert.NoError(t, err)
+	assert.Contains(t, ports, ListeningPort{
+		Port:     port,
+		Protocol: ProtocolTCP,
+		Family:   FamilyIPv4,
+		Address:  "127.0.0.1",
+		PID:      os.Getpid(),
+		Process:  processComm(t),
+	})
+}
+
+func processComm(t *testing.T) string {
+	t.Helper()
+	comm, err := os.ReadFile("/proc/self/comm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(comm[:len(comm)-1])
+}
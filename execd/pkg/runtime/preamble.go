@@ -0,0 +1,132 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/alibaba/opensandbox/execd/pkg/jupyter/execute"
+)
+
+// contextPreambleTimeout bounds the preamble run when a context is created.
+const contextPreambleTimeout = 5 * time.Minute
+
+// PreambleError reports a context preamble that raised in the kernel.
+type PreambleError struct {
+	Language Language
+	Output   *execute.ErrorOutput
+}
+
+func (e *PreambleError) Error() string {
+	return fmt.Sprintf("%s context preamble failed: %s: %s", e.Language, e.Output.EName, e.Output.EValue)
+}
+
+// ParseContextPreambles reads the preamble files named by a "LANGUAGE=FILE"
+// list separated by commas, e.g. "python=/etc/execd/preamble.py".
+func ParseContextPreambles(spec string) (map[Language]string, error) {
+	preambles := make(map[Language]string)
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		name, file, ok := strings.Cut(item, "=")
+		language := Language(strings.ToLower(strings.TrimSpace(name)))
+		file = strings.TrimSpace(file)
+		if !ok || file == "" {
+			return nil, fmt.Errorf("invalid context preamble %q, expected LANGUAGE=FILE", item)
+		}
+		if !slices.Contains(Languages, language) || language == Command || language == BackgroundCommand || language == SQL {
+			return nil, fmt.Errorf("invalid context preamble %q, %s contexts are not kernel backed", item, language)
+		}
+
+		code, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s context preamble: %w", language, err)
+		}
+		preambles[language] = string(code)
+	}
+	return preambles, nil
+}
+
+// SetContextPreambles sets the code executed in every new context of a language.
+func (c *Controller) SetContextPreambles(preambles map[Language]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.contextPreambles = preambles
+}
+
+// runPreamble executes the preamble configured for the language of a new
+// context, failing with a PreambleError when the code raises.
+func (c *Controller) runPreamble(ctx context.Context, kernel *jupyterKernel) error {
+	c.mu.RLock()
+	code := c.contextPreambles[kernel.language]
+	c.mu.RUnlock()
+	if strings.TrimSpace(code) == "" {
+		return nil
+	}
+
+	raised, err := c.runQuietly(ctx, kernel, code)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err != nil && raised == nil {
+		return fmt.Errorf("failed to run %s context preamble: %w", kernel.language, err)
+	}
+	if raised != nil {
+		return &PreambleError{Language: kernel.language, Output: raised}
+	}
+	return nil
+}
+
+// runQuietly executes code on the kernel within contextPreambleTimeout,
+// discarding its output, and returns the first error it raised.
+func (c *Controller) runQuietly(ctx context.Context, kernel *jupyterKernel, code string) (*execute.ErrorOutput, error) {
+	kernel.lock()
+	defer kernel.unlock()
+
+	runCtx, cancel := context.WithTimeout(ctx, contextPreambleTimeout)
+	defer cancel()
+
+	var raised *execute.ErrorOutput
+	request := &ExecuteCodeRequest{
+		Language: kernel.language,
+		Code:     code,
+		quiet:    true,
+		// the output is discarded, only whether the code raised matters.
+		Hooks: ExecuteResultHook{
+			OnExecuteResult:   func(map[string]any, int) {},
+			OnExecuteStatus:   func(string) {},
+			OnExecuteStdout:   func(string) {},
+			OnExecuteStderr:   func(string) {},
+			OnExecuteComplete: func(time.Duration) {},
+			OnExecuteError: func(err *execute.ErrorOutput) {
+				if raised == nil {
+					raised = err
+				}
+			},
+		},
+	}
+
+	err := c.streamJupyterCode(runCtx, kernel, request)
+	return raised, err
+}
@@ -0,0 +1,129 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/alibaba/opensandbox/execd/pkg/jupyter/execute"
+)
+
+// newPreambleKernel serves a mock kernel recording executed code; code containing
+// "raise" fails with a NameError.
+func newPreambleKernel(t *testing.T) (string, func() []string) {
+	t.Helper()
+	var (
+		mu   sync.Mutex
+		sent []string
+	)
+	server := newMockJupyter(t, func(conn *websocket.Conn, msg *execute.Message) {
+		if execute.MessageType(msg.Header.MessageType) != execute.MsgExecuteRequest {
+			return
+		}
+		var req execute.ExecuteRequest
+		if err := json.Unmarshal(msg.Content, &req); err != nil {
+			t.Errorf("unmarshal execute request: %v", err)
+			return
+		}
+		mu.Lock()
+		sent = append(sent, req.Code)
+		mu.Unlock()
+
+		if strings.Contains(req.Code, "raise") {
+			replyMessage(t, conn, msg, execute.MsgError, execute.ErrorOutput{EName: "NameError", EValue: "name 'pd' is not defined"})
+		} else {
+			replyMessage(t, conn, msg, execute.MsgExecuteReply, execute.ExecuteReply{Status: "ok", ExecutionCount: 1})
+		}
+		replyMessage(t, conn, msg, execute.MsgStatus, execute.StatusUpdate{ExecutionState: execute.StateIdle})
+	})
+	t.Cleanup(server.Close)
+
+	return server.URL, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), sent...)
+	}
+}
+
+func TestCreateContext_RunsPreamble(t *testing.T) {
+	url, sent := newPreambleKernel(t)
+	c := NewController(url, "token")
+	c.SetContextPreambles(map[Language]string{Python: "import pandas as pd"})
+
+	if _, err := c.CreateContext(context.Background(), &CreateContextRequest{Language: Python}); err != nil {
+		t.Fatalf("CreateContext returned error: %v", err)
+	}
+	if got := sent(); len(got) != 1 || got[0] != "import pandas as pd" {
+		t.Fatalf("expected the preamble to run once, got %q", got)
+	}
+}
+
+func TestCreateContext_PreambleErrorDiscardsContext(t *testing.T) {
+	url, _ := newPreambleKernel(t)
+	c := NewController(url, "token")
+	c.SetContextPreambles(map[Language]string{Python: "raise pd"})
+
+	_, err := c.CreateContext(context.Background(), &CreateContextRequest{Language: Python})
+	var preambleErr *PreambleError
+	if !errors.As(err, &preambleErr) || preambleErr.Output.EName != "NameError" {
+		t.Fatalf("expected a preamble error, got %v", err)
+	}
+	if c.getJupyterKernel("session-1") != nil {
+		t.Fatalf("expected the failed context to be discarded")
+	}
+}
+
+func TestCreateContext_PreambleOnlyForItsLanguage(t *testing.T) {
+	url, sent := newPreambleKernel(t)
+	c := NewController(url, "token")
+	c.SetContextPreambles(map[Language]string{Bash: "set -e"})
+
+	if _, err := c.CreateContext(context.Background(), &CreateContextRequest{Language: Python}); err != nil {
+		t.Fatalf("CreateContext returned error: %v", err)
+	}
+	if got := sent(); len(got) != 0 {
+		t.Fatalf("expected no code to run in a python context, got %q", got)
+	}
+}
+
+func TestParseContextPreambles(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "preamble.py")
+	if err := os.WriteFile(file, []byte("import numpy as np\n"), 0o644); err != nil {
+		t.Fatalf("write preamble: %v", err)
+	}
+
+	preambles, err := ParseContextPreambles(" Python=" + file + " ,")
+	if err != nil {
+		t.Fatalf("ParseContextPreambles returned error: %v", err)
+	}
+	if preambles[Python] != "import numpy as np\n" || len(preambles) != 1 {
+		t.Fatalf("unexpected preambles %v", preambles)
+	}
+
+	for _, spec := range []string{"python", "command=" + file, "ruby=" + file, "python=" + file + ".missing"} {
+		if _, err := ParseContextPreambles(spec); err == nil {
+			t.Fatalf("expected %q to be rejected", spec)
+		}
+	}
+}
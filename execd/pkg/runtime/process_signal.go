@@ -0,0 +1,65 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package runtime
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/shirou/gopsutil/process"
+)
+
+// processSignals maps the signal names accepted by SignalProcess.
+var processSignals = map[string]syscall.Signal{
+	"HUP":  syscall.SIGHUP,
+	"INT":  syscall.SIGINT,
+	"QUIT": syscall.SIGQUIT,
+	"KILL": syscall.SIGKILL,
+	"USR1": syscall.SIGUSR1,
+	"USR2": syscall.SIGUSR2,
+	"TERM": syscall.SIGTERM,
+	"CONT": syscall.SIGCONT,
+	"STOP": syscall.SIGSTOP,
+}
+
+// parseSignal resolves a signal name, with or without its SIG prefix, or number.
+func parseSignal(signal string) (syscall.Signal, error) {
+	if number, err := strconv.Atoi(signal); err == nil && number > 0 && number < 65 {
+		return syscall.Signal(number), nil
+	}
+	if sig, ok := processSignals[strings.TrimPrefix(strings.ToUpper(signal), "SIG")]; ok {
+		return sig, nil
+	}
+	return 0, ErrUnsupportedSignal
+}
+
+func signalProcess(p *process.Process, signal string) error {
+	sig, err := parseSignal(signal)
+	if err != nil {
+		return err
+	}
+	if err := p.SendSignal(sig); err != nil {
+		if errors.Is(err, syscall.ESRCH) {
+			return ErrProcessNotFound
+		}
+		return err
+	}
+	return nil
+}
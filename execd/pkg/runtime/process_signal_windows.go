@@ -0,0 +1,35 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build windows
+// +build windows
+
+package runtime
+
+import (
+	"strings"
+
+	"github.com/shirou/gopsutil/process"
+)
+
+// signalProcess terminates p like taskkill /F does: Windows has no signals, so
+// TERM, KILL and INT all end the process.
+func signalProcess(p *process.Process, signal string) error {
+	switch strings.TrimPrefix(strings.ToUpper(signal), "SIG") {
+	case "TERM", "KILL", "INT", "15", "9", "2":
+		return p.Kill()
+	default:
+		return ErrUnsupportedSignal
+	}
+}
@@ -0,0 +1,237 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/process"
+)
+
+// ProcessKind tells what a ProcessUsage entry is tracking.
+type ProcessKind string
+
+const (
+	ProcessKindCommand           ProcessKind = "command"
+	ProcessKindBackgroundCommand ProcessKind = "background-command"
+	ProcessKindKernel            ProcessKind = "kernel"
+)
+
+// ProcessUsage describes the resources used by a tracked command or kernel,
+// its child processes included.
+type ProcessUsage struct {
+	Session    string
+	Kind       ProcessKind
+	PID        int
+	Running    bool
+	CPUPercent float64
+	RSSBytes   uint64
+	Threads    int32
+	Uptime     time.Duration
+	// ExitCode, FinishedAt and Error hold the final status of exited commands.
+	ExitCode   *int
+	FinishedAt *time.Time
+	Error      string
+}
+
+// trackedProcess is a tracked entry before its processes are sampled.
+type trackedProcess struct {
+	usage   ProcessUsage
+	started time.Time
+	// tree lists the process and its descendants.
+	tree []*process.Process
+	// cpuSeconds is the CPU time of tree at the first sample.
+	cpuSeconds float64
+}
+
+// ProcessUsage samples the CPU usage of every tracked command and kernel over
+// interval and returns the entries sorted by CPU usage, highest first.
+func (c *Controller) ProcessUsage(interval time.Duration) ([]ProcessUsage, error) {
+	procs, err := process.Processes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processes: %w", err)
+	}
+	children := make(map[int32][]*process.Process)
+	for _, p := range procs {
+		if ppid, err := p.Ppid(); err == nil {
+			children[ppid] = append(children[ppid], p)
+		}
+	}
+
+	tracked := append(c.trackedCommands(), c.trackedKernels(procs)...)
+	sampling := false
+	for _, t := range tracked {
+		if !t.usage.Running || t.usage.PID <= 0 {
+			continue
+		}
+		root, err := process.NewProcess(int32(t.usage.PID))
+		if err != nil {
+			t.usage.Running = false
+			continue
+		}
+		t.tree = processTree(root, children)
+		t.cpuSeconds = cpuSeconds(t.tree)
+		sampling = true
+	}
+
+	start := time.Now()
+	if sampling {
+		time.Sleep(interval)
+	}
+	elapsed := time.Since(start).Seconds()
+
+	usages := make([]ProcessUsage, 0, len(tracked))
+	for _, t := range tracked {
+		if len(t.tree) > 0 {
+			root := t.tree[0]
+			if running, err := root.IsRunning(); err != nil || !running {
+				// the process went away between the samples, its final status follows shortly.
+				t.usage.Running = false
+			} else {
+				t.usage.CPUPercent = (cpuSeconds(t.tree) - t.cpuSeconds) / elapsed * 100
+				for _, p := range t.tree {
+					if mem, err := p.MemoryInfo(); err == nil {
+						t.usage.RSSBytes += mem.RSS
+					}
+					if threads, err := p.NumThreads(); err == nil {
+						t.usage.Threads += threads
+					}
+				}
+				if created, err := root.CreateTime(); err == nil {
+					t.started = time.UnixMilli(created)
+				}
+			}
+		}
+
+		switch {
+		case t.usage.FinishedAt != nil:
+			t.usage.Uptime = t.usage.FinishedAt.Sub(t.started)
+		case !t.started.IsZero():
+			t.usage.Uptime = time.Since(t.started)
+		}
+		usages = append(usages, t.usage)
+	}
+
+	sort.SliceStable(usages, func(i, j int) bool {
+		return usages[i].CPUPercent > usages[j].CPUPercent
+	})
+	return usages, nil
+}
+
+// trackedCommands lists the commands of the controller with their last known status.
+func (c *Controller) trackedCommands() []*trackedProcess {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	tracked := make([]*trackedProcess, 0, len(c.commandClientMap))
+	for session, kernel := range c.commandClientMap {
+		if kernel.cancel != nil {
+			// tasks run inside execd.
+			continue
+		}
+		kind := ProcessKindCommand
+		if kernel.isBackground {
+			kind = ProcessKindBackgroundCommand
+		}
+		tracked = append(tracked, &trackedProcess{
+			usage: ProcessUsage{
+				Session:    session,
+				Kind:       kind,
+				PID:        kernel.pid,
+				Running:    kernel.running,
+				ExitCode:   kernel.exitCode,
+				FinishedAt: kernel.finishedAt,
+				Error:      kernel.errMsg,
+			},
+			started: kernel.startedAt,
+		})
+	}
+	return tracked
+}
+
+// trackedKernels lists the Jupyter kernels of the controller. Kernels are found
+// by their connection file, named after the kernel id, on the command line.
+func (c *Controller) trackedKernels(procs []*process.Process) []*trackedProcess {
+	c.mu.RLock()
+	kernels := make(map[string]string, len(c.jupyterClientMap))
+	for session, kernel := range c.jupyterClientMap {
+		kernels[session] = kernel.kernelID
+	}
+	c.mu.RUnlock()
+	if len(kernels) == 0 {
+		return nil
+	}
+
+	// kernels are launched with their connection file, kernel-<id>.json, as an argument.
+	self := int32(os.Getpid())
+	pids := make(map[string]int32, len(kernels))
+	for _, p := range procs {
+		if p.Pid == self {
+			continue
+		}
+		args, err := p.CmdlineSlice()
+		if err != nil {
+			continue
+		}
+		for _, arg := range args {
+			base := filepath.Base(arg)
+			if !strings.HasPrefix(base, "kernel-") || !strings.HasSuffix(base, ".json") {
+				continue
+			}
+			kernelID := strings.TrimSuffix(strings.TrimPrefix(base, "kernel-"), ".json")
+			if _, ok := pids[kernelID]; !ok {
+				pids[kernelID] = p.Pid
+			}
+		}
+	}
+
+	tracked := make([]*trackedProcess, 0, len(kernels))
+	for session, kernelID := range kernels {
+		usage := ProcessUsage{Session: session, Kind: ProcessKindKernel}
+		if pid, ok := pids[kernelID]; ok {
+			usage.PID = int(pid)
+			usage.Running = true
+		} else {
+			usage.Error = "kernel process not found on this host"
+		}
+		tracked = append(tracked, &trackedProcess{usage: usage})
+	}
+	return tracked
+}
+
+// processTree returns root followed by its descendants.
+func processTree(root *process.Process, children map[int32][]*process.Process) []*process.Process {
+	tree := []*process.Process{root}
+	for i := 0; i < len(tree); i++ {
+		tree = append(tree, children[tree[i].Pid]...)
+	}
+	return tree
+}
+
+// cpuSeconds sums the user and system CPU time of procs.
+func cpuSeconds(procs []*process.Process) float64 {
+	var total float64
+	for _, p := range procs {
+		if times, err := p.Times(); err == nil {
+			total += times.User + times.System
+		}
+	}
+	return total
+}
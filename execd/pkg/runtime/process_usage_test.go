@@ -0,0 +1,90 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"fmt"
+	"os/exec"
+	goruntime "runtime"
+	"testing"
+	"time"
+)
+
+func startProcess(t *testing.T, script string, args ...string) *exec.Cmd {
+	t.Helper()
+	cmd := exec.Command("bash", append([]string{"-c", script}, args...)...)
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("start %q: %v", script, err)
+	}
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+	return cmd
+}
+
+func TestProcessUsage(t *testing.T) {
+	if goruntime.GOOS == "windows" {
+		t.Skip("bash not available on windows")
+	}
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not found in PATH")
+	}
+
+	busy := startProcess(t, "while :; do :; done")
+	idle := startProcess(t, "sleep 30")
+	// the trailing no-op keeps bash from exec'ing sleep, so the connection file stays on its command line.
+	kernelID := fmt.Sprintf("kernel-%d", time.Now().UnixNano())
+	kernel := startProcess(t, "sleep 30; :", "kernel-"+kernelID+".json")
+
+	c := NewController("", "")
+	c.storeCommandKernel("busy", &commandKernel{pid: busy.Process.Pid, running: true, isBackground: true, startedAt: time.Now()})
+	c.storeCommandKernel("idle", &commandKernel{pid: idle.Process.Pid, running: true, startedAt: time.Now()})
+	c.storeCommandKernel("done", &commandKernel{pid: 1 << 22, startedAt: time.Now().Add(-time.Minute)})
+	c.markCommandFinished("done", 3, "exit status 3")
+	c.storeJupyterKernel("session-1", &jupyterKernel{kernelID: kernelID, language: Python})
+	c.storeJupyterKernel("session-2", &jupyterKernel{kernelID: "remote", language: Python})
+
+	usages, err := c.ProcessUsage(200 * time.Millisecond)
+	if err != nil {
+		t.Fatalf("ProcessUsage returned error: %v", err)
+	}
+	if len(usages) != 5 {
+		t.Fatalf("expected 5 entries, got %+v", usages)
+	}
+	bySession := make(map[string]ProcessUsage)
+	for _, usage := range usages {
+		bySession[usage.Session] = usage
+	}
+
+	if usages[0].Session != "busy" || usages[0].CPUPercent < 10 {
+		t.Fatalf("expected the busy loop first, got %+v", usages[0])
+	}
+	if usage := bySession["busy"]; usage.Kind != ProcessKindBackgroundCommand || usage.RSSBytes == 0 || usage.Threads < 1 || usage.Uptime <= 0 {
+		t.Fatalf("unexpected busy usage: %+v", usage)
+	}
+	if usage := bySession["idle"]; !usage.Running || usage.Kind != ProcessKindCommand || usage.CPUPercent > 10 {
+		t.Fatalf("unexpected idle usage: %+v", usage)
+	}
+	if usage := bySession["done"]; usage.Running || usage.ExitCode == nil || *usage.ExitCode != 3 || usage.Error != "exit status 3" || usage.Uptime < time.Minute {
+		t.Fatalf("expected the final status of the finished command, got %+v", usage)
+	}
+	if usage := bySession["session-1"]; usage.Kind != ProcessKindKernel || usage.PID != kernel.Process.Pid || !usage.Running {
+		t.Fatalf("unexpected kernel usage: %+v", usage)
+	}
+	if usage := bySession["session-2"]; usage.Running || usage.Error == "" {
+		t.Fatalf("expected the remote kernel to be reported as not found, got %+v", usage)
+	}
+}
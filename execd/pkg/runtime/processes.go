@@ -0,0 +1,132 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/process"
+)
+
+// ProcessInfo describes a process of the sandbox, tracked by execd or not.
+type ProcessInfo struct {
+	PID     int32
+	PPID    int32
+	Name    string
+	User    string
+	Cmdline string
+	// CPUPercent is the CPU usage over the sampling interval.
+	CPUPercent float64
+	RSSBytes   uint64
+	StartedAt  time.Time
+}
+
+// ProcessFilter selects a page of the processes listed by ListProcesses.
+type ProcessFilter struct {
+	// Name keeps the processes whose name contains it.
+	Name   string
+	Offset int
+	Limit  int
+}
+
+// ListProcesses returns the processes matching filter, ordered by pid, with
+// their CPU usage sampled over interval, and the number of matching processes.
+// Processes exiting meanwhile are left out.
+func ListProcesses(filter ProcessFilter, interval time.Duration) ([]ProcessInfo, int, error) {
+	procs, err := process.Processes()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list processes: %w", err)
+	}
+
+	matching := make([]*process.Process, 0, len(procs))
+	names := make(map[int32]string, len(procs))
+	for _, p := range procs {
+		name, err := p.Name()
+		if err != nil {
+			continue
+		}
+		if filter.Name != "" && !strings.Contains(name, filter.Name) {
+			continue
+		}
+		names[p.Pid] = name
+		matching = append(matching, p)
+	}
+	sort.Slice(matching, func(i, j int) bool { return matching[i].Pid < matching[j].Pid })
+
+	total := len(matching)
+	page := matching[min(filter.Offset, total):]
+	if filter.Limit > 0 && len(page) > filter.Limit {
+		page = page[:filter.Limit]
+	}
+
+	before := make([]float64, len(page))
+	for i, p := range page {
+		before[i] = cpuSeconds([]*process.Process{p})
+	}
+	start := time.Now()
+	if len(page) > 0 {
+		time.Sleep(interval)
+	}
+	elapsed := time.Since(start).Seconds()
+
+	infos := make([]ProcessInfo, 0, len(page))
+	for i, p := range page {
+		if running, err := p.IsRunning(); err != nil || !running {
+			continue
+		}
+		info := ProcessInfo{PID: p.Pid, Name: names[p.Pid]}
+		info.PPID, _ = p.Ppid()
+		info.Cmdline, _ = p.Cmdline()
+		if user, err := p.Username(); err == nil {
+			info.User = user
+		} else if uids, err := p.Uids(); err == nil && len(uids) > 0 {
+			info.User = strconv.Itoa(int(uids[0]))
+		}
+		if elapsed > 0 {
+			info.CPUPercent = (cpuSeconds([]*process.Process{p}) - before[i]) / elapsed * 100
+		}
+		if mem, err := p.MemoryInfo(); err == nil {
+			info.RSSBytes = mem.RSS
+		}
+		if created, err := p.CreateTime(); err == nil {
+			info.StartedAt = time.UnixMilli(created)
+		}
+		infos = append(infos, info)
+	}
+	return infos, total, nil
+}
+
+// SignalProcess sends the named signal, such as TERM, SIGKILL or 9, to a
+// process. execd itself and pid 1 are refused with ErrProcessProtected.
+func SignalProcess(pid int32, signal string) error {
+	if pid <= 1 || pid == int32(os.Getpid()) {
+		return ErrProcessProtected
+	}
+	p, err := process.NewProcess(pid)
+	if err != nil {
+		if errors.Is(err, process.ErrorProcessNotRunning) {
+			return ErrProcessNotFound
+		}
+		return err
+	}
+	logger.Warning("sending signal %s to process %d", signal, pid)
+	return signalProcess(p, signal)
+}
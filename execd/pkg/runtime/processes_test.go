@@ -0,0 +1,92 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	goruntime "runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListProcesses_FiltersAndPages(t *testing.T) {
+	self, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := filepath.Base(self)
+
+	processes, total, err := ListProcesses(ProcessFilter{Name: name}, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.GreaterOrEqual(t, total, 1)
+	var found *ProcessInfo
+	for i := range processes {
+		assert.Contains(t, processes[i].Name, name)
+		if processes[i].PID == int32(os.Getpid()) {
+			found = &processes[i]
+		}
+	}
+	if found == nil {
+		t.Fatal("the test process must be listed")
+	}
+	assert.Equal(t, int32(os.Getppid()), found.PPID)
+	assert.NotZero(t, found.RSSBytes)
+	assert.False(t, found.StartedAt.IsZero())
+
+	all, total, err := ListProcesses(ProcessFilter{Limit: 1}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, all, 1)
+	assert.Greater(t, total, 1)
+
+	none, _, err := ListProcesses(ProcessFilter{Offset: total}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, none)
+}
+
+func TestSignalProcess(t *testing.T) {
+	if goruntime.GOOS == "windows" {
+		t.Skip("sleep not available on windows")
+	}
+	cmd := exec.Command("sleep", "60")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+
+	assert.ErrorIs(t, SignalProcess(int32(cmd.Process.Pid), "BOGUS"), ErrUnsupportedSignal)
+	if err := SignalProcess(int32(cmd.Process.Pid), "SIGTERM"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-exited:
+		assert.Error(t, err, "sleep must have been terminated")
+	case <-time.After(5 * time.Second):
+		t.Fatal("the signalled process did not exit")
+	}
+
+	assert.ErrorIs(t, SignalProcess(1, "TERM"), ErrProcessProtected)
+	assert.ErrorIs(t, SignalProcess(int32(os.Getpid()), "KILL"), ErrProcessProtected)
+}
@@ -0,0 +1,125 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ProgressPattern extracts the progress of a command from its output lines.
+// Regexp may name the groups "percent", or "current" and "total", for the amount
+// and "stage" for the label; Stage labels the progress when there is no stage group.
+type ProgressPattern struct {
+	Regexp *regexp.Regexp
+	Stage  string
+}
+
+// Progress is the state of a command parsed from one output line.
+type Progress struct {
+	// Percent is nil when the line names a stage without an amount.
+	Percent *float64
+	Stage   string
+	Line    string
+}
+
+// parse returns the progress reported by line, if the pattern matches it.
+func (p ProgressPattern) parse(line string) (Progress, bool) {
+	match := p.Regexp.FindStringSubmatch(line)
+	if match == nil {
+		return Progress{}, false
+	}
+	group := func(name string) string {
+		if i := p.Regexp.SubexpIndex(name); i > 0 {
+			return strings.TrimSpace(match[i])
+		}
+		return ""
+	}
+
+	progress := Progress{Stage: p.Stage, Line: line}
+	if stage := group("stage"); stage != "" {
+		progress.Stage = stage
+	}
+	if percent, err := strconv.ParseFloat(group("percent"), 64); err == nil {
+		progress.Percent = &percent
+	} else {
+		current, currentErr := strconv.ParseFloat(group("current"), 64)
+		total, totalErr := strconv.ParseFloat(group("total"), 64)
+		if currentErr == nil && totalErr == nil && total > 0 {
+			percent := current / total * 100
+			progress.Percent = &percent
+		}
+	}
+	if progress.Percent != nil {
+		percent := min(max(*progress.Percent, 0), 100)
+		progress.Percent = &percent
+	}
+	if progress.Percent == nil && progress.Stage == "" {
+		return Progress{}, false
+	}
+	return progress, true
+}
+
+// progressParser reports the progress found in the output of a command through
+// emit, skipping lines that repeat the last progress. The stdout and stderr
+// tailers share one parser.
+type progressParser struct {
+	patterns []ProgressPattern
+	emit     func(Progress)
+
+	mu          sync.Mutex
+	lastPercent *float64
+	lastStage   string
+}
+
+func newProgressParser(patterns []ProgressPattern, emit func(Progress)) *progressParser {
+	return &progressParser{patterns: patterns, emit: emit}
+}
+
+// wrap returns an output hook that calls onExecute and then parses the line.
+func (p *progressParser) wrap(onExecute func(text string, position OutputPosition)) func(string, OutputPosition) {
+	return func(text string, position OutputPosition) {
+		onExecute(text, position)
+		p.parse(text)
+	}
+}
+
+// parse emits the progress of the first pattern matching line.
+func (p *progressParser) parse(line string) {
+	for _, pattern := range p.patterns {
+		progress, ok := pattern.parse(line)
+		if !ok {
+			continue
+		}
+
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if progress.Stage == p.lastStage && samePercent(progress.Percent, p.lastPercent) {
+			return
+		}
+		p.lastPercent, p.lastStage = progress.Percent, progress.Stage
+		p.emit(progress)
+		return
+	}
+}
+
+func samePercent(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
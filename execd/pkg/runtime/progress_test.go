@@ -0,0 +1,120 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"context"
+	"os/exec"
+	"regexp"
+	goruntime "runtime"
+	"testing"
+
+	"github.com/alibaba/opensandbox/execd/pkg/jupyter/execute"
+)
+
+func pipProgressPatterns() []ProgressPattern {
+	return []ProgressPattern{
+		{Regexp: regexp.MustCompile(`^\s*(?P<percent>\d+(?:\.\d+)?)%\|`), Stage: "download"},
+		{Regexp: regexp.MustCompile(`^\[(?P<current>\d+)/(?P<total>\d+)\] (?P<stage>\w+)`)},
+		{Regexp: regexp.MustCompile(`^Collecting (?P<stage>\S+)`)},
+	}
+}
+
+func TestProgressParser_ParsesPercentages(t *testing.T) {
+	var got []Progress
+	parser := newProgressParser(pipProgressPatterns(), func(p Progress) { got = append(got, p) })
+
+	for _, line := range []string{
+		"Collecting numpy",
+		"  Downloading numpy-2.0.0.whl (19.5 MB)",
+		" 12.5%|###        | 2.4/19.5 MB",
+		" 12.5%|###        | 2.4/19.5 MB", // repeated redraw
+		" 100%|##########| 19.5/19.5 MB",
+		"[3/4] building",
+		"[5/4] building", // overshoot is clamped
+		"Successfully installed numpy-2.0.0",
+	} {
+		parser.parse(line)
+	}
+
+	want := []struct {
+		percent float64 // -1 for no amount
+		stage   string
+	}{
+		{-1, "numpy"},
+		{12.5, "download"},
+		{100, "download"},
+		{75, "building"},
+		{100, "building"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d progress events, want %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		switch {
+		case w.percent < 0 && got[i].Percent != nil:
+			t.Fatalf("event %d: unexpected percent %v", i, *got[i].Percent)
+		case w.percent >= 0 && (got[i].Percent == nil || *got[i].Percent != w.percent):
+			t.Fatalf("event %d: percent %v, want %v", i, got[i].Percent, w.percent)
+		case got[i].Stage != w.stage:
+			t.Fatalf("event %d: stage %q, want %q", i, got[i].Stage, w.stage)
+		}
+	}
+}
+
+func TestRunCommand_EmitsProgressAlongsideOutput(t *testing.T) {
+	if goruntime.GOOS == "windows" {
+		t.Skip("bash not available on windows")
+	}
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not found in PATH")
+	}
+
+	c := NewController("", "")
+
+	var (
+		stdout  []string
+		percent []float64
+	)
+	req := &ExecuteCodeRequest{
+		// progress bars redraw the line with carriage returns.
+		Code:     `printf ' 10%%|#   |\r 55%%|##  |\r 100%%|####|\n'; echo done`,
+		Cwd:      t.TempDir(),
+		Progress: pipProgressPatterns(),
+		Hooks: ExecuteResultHook{
+			OnExecuteStdout: func(s string) { stdout = append(stdout, s) },
+			OnExecuteProgress: func(p Progress) {
+				if len(stdout) == 0 || stdout[len(stdout)-1] != p.Line {
+					t.Errorf("progress %q was not preceded by its output line", p.Line)
+				}
+				percent = append(percent, *p.Percent)
+			},
+			OnExecuteError: func(err *execute.ErrorOutput) {
+				t.Fatalf("unexpected error hook: %+v", err)
+			},
+		},
+	}
+
+	if err := c.runCommand(context.Background(), req); err != nil {
+		t.Fatalf("runCommand returned error: %v", err)
+	}
+
+	if len(stdout) != 4 || stdout[3] != "done" {
+		t.Fatalf("raw output must still be streamed, got %#v", stdout)
+	}
+	if len(percent) != 3 || percent[0] != 10 || percent[1] != 55 || percent[2] != 100 {
+		t.Fatalf("unexpected progress: %v", percent)
+	}
+}
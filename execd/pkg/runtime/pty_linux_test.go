@@ -0,0 +1,94 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alibaba/opensandbox/execd/pkg/jupyter/execute"
+)
+
+// runTerminalCommand runs code, in a pseudo-terminal when size is set, and returns its stdout.
+func runTerminalCommand(t *testing.T, code, stdin string, size *TerminalSize) string {
+	t.Helper()
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not found in PATH")
+	}
+
+	c := NewController("", "")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var stdout strings.Builder
+	req := &ExecuteCodeRequest{
+		Code:  code,
+		Cwd:   t.TempDir(),
+		Stdin: stdin,
+		PTY:   size,
+		Hooks: ExecuteResultHook{
+			OnExecuteInit:   func(string) {},
+			OnExecuteStdout: func(s string) { stdout.WriteString(s + "\n") },
+			OnExecuteStderr: func(string) {},
+			OnExecuteError: func(err *execute.ErrorOutput) {
+				t.Errorf("unexpected error: %+v", err)
+			},
+			OnExecuteComplete: func(time.Duration) {},
+		},
+	}
+	if err := c.runCommand(ctx, req); err != nil {
+		if strings.Contains(err.Error(), "/dev/ptmx") {
+			t.Skipf("pseudo-terminals unavailable: %v", err)
+		}
+		t.Fatalf("runCommand returned error: %v", err)
+	}
+	return stdout.String()
+}
+
+func TestRunCommand_PTYIsATerminal(t *testing.T) {
+	const code = `for fd in 0 1 2; do if [ -t $fd ]; then echo "fd$fd:tty"; else echo "fd$fd:notty"; fi; done`
+
+	out := runTerminalCommand(t, code, "", &TerminalSize{})
+	for _, want := range []string{"fd0:tty", "fd1:tty", "fd2:tty"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q under pty, got %q", want, out)
+		}
+	}
+
+	out = runTerminalCommand(t, code, "", nil)
+	if !strings.Contains(out, "fd1:notty") {
+		t.Fatalf("expected no tty without pty, got %q", out)
+	}
+}
+
+func TestRunCommand_PTYMergesStderrAndForwardsStdin(t *testing.T) {
+	out := runTerminalCommand(t, `read -r line; echo "got:$line" >&2; stty size`, "hello\n", &TerminalSize{Rows: 40, Cols: 100})
+	if !strings.Contains(out, "got:hello") {
+		t.Fatalf("expected stdin echoed back through stderr, got %q", out)
+	}
+	if !strings.Contains(out, "40 100") {
+		t.Fatalf("expected terminal size 40x100, got %q", out)
+	}
+}
+
+func TestRunCommand_StdinWithoutPTY(t *testing.T) {
+	out := runTerminalCommand(t, `cat`, "line one\nline two\n", nil)
+	if !strings.Contains(out, "line one\nline two") {
+		t.Fatalf("expected stdin copied to stdout, got %q", out)
+	}
+}
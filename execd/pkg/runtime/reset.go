@@ -0,0 +1,67 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"context"
+	"fmt"
+)
+
+// resetCodes lists the code clearing the user namespace of a kernel, by language.
+var resetCodes = map[Language]string{
+	Python: "%reset -f",
+}
+
+// ResetContext clears the variables of a context without restarting its
+// kernel, then runs the preamble of its language again. The execution count
+// and history of the context start over; the working directory and the
+// environment of the kernel process are kept.
+func (c *Controller) ResetContext(ctx context.Context, session string) error {
+	kernel := c.getJupyterKernel(session)
+	if kernel == nil {
+		return ErrContextNotFound
+	}
+	code, ok := resetCodes[kernel.language]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrResetUnsupported, kernel.language)
+	}
+
+	if err := c.resetKernel(kernel, code); err != nil {
+		return err
+	}
+	return c.runPreamble(ctx, kernel)
+}
+
+// resetKernel runs code resetting the namespace of an idle kernel.
+func (c *Controller) resetKernel(kernel *jupyterKernel, code string) error {
+	if !kernel.tryLock() {
+		return ErrSessionBusy
+	}
+	defer kernel.unlock()
+
+	err := kernel.client.ConnectToKernel(kernel.kernelID)
+	if err != nil {
+		return err
+	}
+	defer kernel.client.DisconnectFromKernel(kernel.kernelID)
+
+	if err := kernel.client.ExecuteSilently(code, workingDirTimeout); err != nil {
+		return err
+	}
+	kernel.setExecutionCount(0)
+	kernel.executions.clear()
+	kernel.touch()
+	return nil
+}
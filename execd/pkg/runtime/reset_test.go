@@ -0,0 +1,88 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/alibaba/opensandbox/execd/pkg/jupyter/execute"
+)
+
+func TestResetContext_SendsResetToKernel(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		codes []string
+	)
+	server := newMockJupyter(t, func(conn *websocket.Conn, msg *execute.Message) {
+		if execute.MessageType(msg.Header.MessageType) != execute.MsgExecuteRequest {
+			return
+		}
+		var req execute.ExecuteRequest
+		if err := json.Unmarshal(msg.Content, &req); err != nil {
+			t.Errorf("unmarshal execute request: %v", err)
+			return
+		}
+		mu.Lock()
+		codes = append(codes, req.Code)
+		mu.Unlock()
+		replyMessage(t, conn, msg, execute.MsgExecuteReply, execute.ExecuteReply{Status: "ok"})
+	})
+	defer server.Close()
+
+	c := NewController(server.URL, "token")
+	kernel := &jupyterKernel{kernelID: mockKernelID, client: c.jupyterClient(), language: Python}
+	kernel.setExecutionCount(7)
+	kernel.executions.record("x = 1", ExecuteResultHook{})
+	c.storeJupyterKernel("session-1", kernel)
+
+	if err := c.ResetContext(context.Background(), "session-1"); err != nil {
+		t.Fatalf("ResetContext returned error: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(codes) != 1 || codes[0] != "%reset -f" {
+		t.Fatalf("expected the kernel to receive %%reset -f, got %q", codes)
+	}
+	if kernel.state().executionCount != 0 || len(kernel.executions.list()) != 0 {
+		t.Fatalf("expected the execution count and history to start over")
+	}
+}
+
+func TestResetContext_Errors(t *testing.T) {
+	c := NewController("", "")
+	if err := c.ResetContext(context.Background(), "missing"); !errors.Is(err, ErrContextNotFound) {
+		t.Fatalf("expected ErrContextNotFound, got %v", err)
+	}
+
+	c.storeJupyterKernel("bash", &jupyterKernel{language: Bash})
+	if err := c.ResetContext(context.Background(), "bash"); !errors.Is(err, ErrResetUnsupported) {
+		t.Fatalf("expected ErrResetUnsupported, got %v", err)
+	}
+
+	busy := &jupyterKernel{language: Python}
+	busy.lock()
+	defer busy.unlock()
+	c.storeJupyterKernel("busy", busy)
+	if err := c.ResetContext(context.Background(), "busy"); !errors.Is(err, ErrSessionBusy) {
+		t.Fatalf("expected ErrSessionBusy, got %v", err)
+	}
+}
@@ -0,0 +1,247 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/alibaba/opensandbox/execd/pkg/log"
+)
+
+const (
+	// ResourceLimitHelperCommand is the hidden argument main hands over to
+	// RunResourceLimitHelper, with which applyResourceLimits re-executes execd.
+	ResourceLimitHelperCommand = "__execd-rlimit-helper"
+	// rlimitSetupExitCode is returned by the helper when a limit cannot be applied.
+	rlimitSetupExitCode = 125
+	// rlimitStderrTail is how much of stderr is searched for allocation failures.
+	rlimitStderrTail = 4096
+)
+
+// resourceLimitHelperPath locates the binary started as the resource limit helper.
+var resourceLimitHelperPath = os.Executable
+
+// rlimitResources maps the helper spec keys to their resources.
+var rlimitResources = map[string]int{
+	"as":     syscall.RLIMIT_AS,
+	"core":   syscall.RLIMIT_CORE,
+	"cpu":    syscall.RLIMIT_CPU,
+	"nofile": syscall.RLIMIT_NOFILE,
+}
+
+// allocationFailures are stderr markers of programs that ran out of address space.
+var allocationFailures = []string{
+	"cannot allocate memory",
+	"cannot allocate",
+	"out of memory",
+	"memoryerror",
+	"bad_alloc",
+}
+
+// RunResourceLimitHelper returns at once unless args, the arguments of the
+// process, invoke ResourceLimitHelperCommand. It then applies the limits and
+// execs the command, never returning; main calls it before anything else.
+//
+// The helper is invoked as: execd ResourceLimitHelperCommand SPEC FD PATH ARGS...
+// and reports why it failed on the status pipe FD, which exec closes.
+func RunResourceLimitHelper(args []string) {
+	if len(args) < 2 || args[1] != ResourceLimitHelperCommand {
+		return
+	}
+	err := errors.New("malformed resource limit helper arguments")
+	if len(args) >= 6 {
+		if fd, convErr := strconv.Atoi(args[3]); convErr == nil {
+			syscall.CloseOnExec(fd)
+			status := os.NewFile(uintptr(fd), "rlimit-status")
+			err = execWithResourceLimits(args[2], args[4], args[5:])
+			fmt.Fprint(status, err)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "execd: %v\n", err)
+	os.Exit(rlimitSetupExitCode)
+}
+
+// limitStatus is the read end of the status pipe of a resource limit helper.
+type limitStatus struct {
+	reader, writer *os.File
+}
+
+// setupFailure returns why the helper failed to apply the limits, "" when it
+// went on to exec the command. It must only be called once the command exited.
+func (s *limitStatus) setupFailure() string {
+	if s == nil {
+		return ""
+	}
+	_ = s.writer.Close()
+	message, _ := io.ReadAll(io.LimitReader(s.reader, rlimitStderrTail))
+	return string(message)
+}
+
+// Close releases the status pipe.
+func (s *limitStatus) Close() {
+	if s == nil {
+		return
+	}
+	_ = s.writer.Close()
+	_ = s.reader.Close()
+}
+
+// applyResourceLimits makes cmd start through the execd binary itself, which
+// setrlimits its own process and then execs the original program, so the
+// limits are in place before any user code runs. Both soft and hard limits are
+// set, so the command can't raise them again. The returned status, nil without
+// limits, tells a helper failure from a command exiting with the same code and
+// must be closed once the command exited.
+func applyResourceLimits(cmd *exec.Cmd, limits ResourceLimits) (*limitStatus, error) {
+	if limits.IsZero() {
+		return nil, nil
+	}
+
+	self, err := resourceLimitHelperPath()
+	if err != nil {
+		return nil, fmt.Errorf("locate execd binary for resource limits: %w", err)
+	}
+
+	var spec []string
+	add := func(key string, value *uint64) {
+		if value != nil {
+			spec = append(spec, key+"="+strconv.FormatUint(*value, 10))
+		}
+	}
+	add("as", limits.AddressSpace)
+	add("core", limits.CoreSize)
+	add("cpu", limits.CPUSeconds)
+	add("nofile", limits.OpenFiles)
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("create resource limit status pipe: %w", err)
+	}
+	cmd.ExtraFiles = append(cmd.ExtraFiles, writer)
+	fd := 2 + len(cmd.ExtraFiles)
+
+	cmd.Args = append([]string{self, ResourceLimitHelperCommand, strings.Join(spec, ","), strconv.Itoa(fd), cmd.Path}, cmd.Args...)
+	cmd.Path = self
+	// the helper initialises execd's packages under the command's credentials,
+	// which may not be allowed to open execd's log file.
+	cmd.Env = withoutEnv(cmd.Env, log.FileEnvKey)
+	return &limitStatus{reader: reader, writer: writer}, nil
+}
+
+// withoutEnv returns env, or the environment of execd when nil, without key.
+func withoutEnv(env []string, key string) []string {
+	if env == nil {
+		env = os.Environ()
+	}
+	filtered := make([]string, 0, len(env))
+	for _, kv := range env {
+		if !strings.HasPrefix(kv, key+"=") {
+			filtered = append(filtered, kv)
+		}
+	}
+	return filtered
+}
+
+// execWithResourceLimits applies the limits in spec and replaces the process
+// with path run with args.
+func execWithResourceLimits(spec, path string, args []string) error {
+	for _, item := range strings.Split(spec, ",") {
+		key, raw, _ := strings.Cut(item, "=")
+		resource, ok := rlimitResources[key]
+		if !ok {
+			return fmt.Errorf("unknown resource limit %q", key)
+		}
+		value, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid %s limit %q", key, raw)
+		}
+		if err := syscall.Setrlimit(resource, &syscall.Rlimit{Cur: value, Max: value}); err != nil {
+			return fmt.Errorf("set %s limit to %d: %w", key, value, err)
+		}
+	}
+	return syscall.Exec(path, args, os.Environ())
+}
+
+// describeLimitExit explains a command failure caused by its resource limits,
+// or returns "" when the failure isn't attributable to them. stderrPath is
+// searched for allocation failures of programs capped in address space, and
+// helper tells whether the limits failed to be applied.
+func describeLimitExit(err error, limits ResourceLimits, stderrPath string, helper *limitStatus) string {
+	if limits.IsZero() {
+		return ""
+	}
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return ""
+	}
+
+	var sig syscall.Signal
+	if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		sig = status.Signal()
+	} else if code := exitErr.ExitCode(); code == rlimitSetupExitCode {
+		if failure := helper.setupFailure(); failure != "" {
+			return "failed to apply resource limits: " + failure
+		}
+	} else if code > 128 {
+		// the shell reports children killed by a signal as 128+signal.
+		sig = syscall.Signal(code - 128)
+	}
+
+	switch {
+	case limits.CPUSeconds != nil && (sig == syscall.SIGXCPU || sig == syscall.SIGKILL):
+		return fmt.Sprintf("command killed by %s after exceeding the CPU time limit of %ds", sig, *limits.CPUSeconds)
+	case limits.AddressSpace != nil && (sig == syscall.SIGSEGV || sig == syscall.SIGBUS || sig == syscall.SIGABRT || sig == syscall.SIGKILL):
+		return fmt.Sprintf("command killed by %s, likely after exceeding the address space limit of %d bytes", sig, *limits.AddressSpace)
+	case limits.AddressSpace != nil && reportsAllocationFailure(stderrPath):
+		return fmt.Sprintf("command terminated after exceeding the address space limit of %d bytes", *limits.AddressSpace)
+	}
+	return ""
+}
+
+// reportsAllocationFailure reports whether the tail of the stderr log mentions a failed allocation.
+func reportsAllocationFailure(stderrPath string) bool {
+	if stderrPath == "" {
+		return false
+	}
+	f, err := os.Open(stderrPath)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	if info, err := f.Stat(); err == nil && info.Size() > rlimitStderrTail {
+		_, _ = f.Seek(-rlimitStderrTail, io.SeekEnd)
+	}
+	tail, err := io.ReadAll(io.LimitReader(f, rlimitStderrTail))
+	if err != nil {
+		return false
+	}
+	tail = bytes.ToLower(tail)
+	for _, marker := range allocationFailures {
+		if bytes.Contains(tail, []byte(marker)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,211 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alibaba/opensandbox/execd/pkg/jupyter/execute"
+	"github.com/alibaba/opensandbox/execd/pkg/log"
+)
+
+// TestMain lets the test binary stand in for execd as the resource limit helper.
+func TestMain(m *testing.M) {
+	RunResourceLimitHelper(os.Args)
+	os.Exit(m.Run())
+}
+
+// runLimitedCommand runs code under limits and returns its stdout lines and error output.
+func runLimitedCommand(t *testing.T, code string, limits ResourceLimits) ([]string, *execute.ErrorOutput) {
+	t.Helper()
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not found in PATH")
+	}
+
+	c := NewController("", "")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var (
+		stdoutLines []string
+		gotErr      *execute.ErrorOutput
+		doneCh      = make(chan struct{}, 1)
+	)
+	req := &ExecuteCodeRequest{
+		Code:   code,
+		Cwd:    t.TempDir(),
+		Limits: limits,
+		Hooks: ExecuteResultHook{
+			OnExecuteInit:   func(string) {},
+			OnExecuteStdout: func(s string) { stdoutLines = append(stdoutLines, s) },
+			OnExecuteStderr: func(string) {},
+			OnExecuteError: func(err *execute.ErrorOutput) {
+				gotErr = err
+				doneCh <- struct{}{}
+			},
+			OnExecuteComplete: func(time.Duration) { doneCh <- struct{}{} },
+		},
+	}
+	if err := c.runCommand(ctx, req); err != nil {
+		t.Fatalf("runCommand returned error: %v", err)
+	}
+	select {
+	case <-doneCh:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout waiting for command to finish")
+	}
+	return stdoutLines, gotErr
+}
+
+func limit(v uint64) *uint64 { return &v }
+
+func TestRunCommand_ResourceLimitsAppliedBeforeExec(t *testing.T) {
+	stdout, gotErr := runLimitedCommand(t, `ulimit -c; ulimit -n; ulimit -H -n`, ResourceLimits{
+		CoreSize:  limit(0),
+		OpenFiles: limit(64),
+	})
+	if gotErr != nil {
+		t.Fatalf("unexpected error: %+v", gotErr)
+	}
+	want := []string{"0", "64", "64"}
+	if len(stdout) != len(want) {
+		t.Fatalf("expected %v, got %v", want, stdout)
+	}
+	for i := range want {
+		if stdout[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, stdout)
+		}
+	}
+}
+
+func TestRunCommand_MemoryLimitTerminatesCommand(t *testing.T) {
+	// building a 256MiB string in bash can't fit into 64MiB of address space.
+	stdout, gotErr := runLimitedCommand(t, `x=$(head -c 268435456 /dev/zero | tr '\0' a); echo survived ${#x}`, ResourceLimits{
+		AddressSpace: limit(64 << 20),
+	})
+	if len(stdout) != 0 {
+		t.Fatalf("command should not survive the memory limit, got stdout %v", stdout)
+	}
+	if gotErr == nil {
+		t.Fatalf("expected the memory-capped command to fail")
+	}
+	if gotErr.EName != "ResourceLimitExceeded" {
+		t.Fatalf("expected ResourceLimitExceeded, got %+v", gotErr)
+	}
+}
+
+func TestRunCommand_CPULimitKillsCommand(t *testing.T) {
+	_, gotErr := runLimitedCommand(t, `while :; do :; done`, ResourceLimits{
+		CPUSeconds: limit(1),
+	})
+	if gotErr == nil || gotErr.EName != "ResourceLimitExceeded" {
+		t.Fatalf("expected ResourceLimitExceeded, got %+v", gotErr)
+	}
+}
+
+func TestRunCommand_ResourceLimitSetupFailure(t *testing.T) {
+	// no process may raise its open files limit beyond the kernel's nr_open.
+	_, gotErr := runLimitedCommand(t, `echo unreachable`, ResourceLimits{OpenFiles: limit(1 << 40)})
+	if gotErr == nil || gotErr.EName != "ResourceLimitExceeded" || !strings.Contains(gotErr.Traceback[0], "failed to apply resource limits: set nofile limit") {
+		t.Fatalf("expected the helper failure to be reported, got %+v", gotErr)
+	}
+}
+
+func TestRunCommand_ExitCode125IsNotALimitFailure(t *testing.T) {
+	_, gotErr := runLimitedCommand(t, `exit 125`, ResourceLimits{CoreSize: limit(0)})
+	if gotErr == nil || gotErr.EName != "CommandExecError" || gotErr.EValue != "125" {
+		t.Fatalf("expected a plain exit 125, got %+v", gotErr)
+	}
+}
+
+func TestRunCommand_ResourceLimitsAsUserWithLogFile(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("switching users requires root")
+	}
+	if _, err := user.Lookup("nobody"); err != nil {
+		t.Skip("user nobody not available")
+	}
+
+	// the helper must be reachable by nobody, unlike the test binary's build directory.
+	dir, err := os.MkdirTemp("", "execd-rlimit-helper")
+	if err != nil {
+		t.Fatalf("create helper dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.Chmod(dir, 0o755); err != nil {
+		t.Fatalf("chmod helper dir: %v", err)
+	}
+	binary, err := os.ReadFile(os.Args[0])
+	if err != nil {
+		t.Fatalf("read test binary: %v", err)
+	}
+	helper := filepath.Join(dir, "execd")
+	if err := os.WriteFile(helper, binary, 0o755); err != nil {
+		t.Fatalf("copy test binary: %v", err)
+	}
+	previous := resourceLimitHelperPath
+	resourceLimitHelperPath = func() (string, error) { return helper, nil }
+	defer func() { resourceLimitHelperPath = previous }()
+
+	// nobody can't open a log file in a directory private to root.
+	t.Setenv(log.FileEnvKey, filepath.Join(t.TempDir(), "execd.log"))
+
+	c := NewController("", "")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var (
+		stdoutLines []string
+		gotErr      *execute.ErrorOutput
+		doneCh      = make(chan struct{}, 1)
+	)
+	req := &ExecuteCodeRequest{
+		Code:   `ulimit -n`,
+		Cwd:    "/",
+		User:   "nobody",
+		Limits: ResourceLimits{OpenFiles: limit(64)},
+		Hooks: ExecuteResultHook{
+			OnExecuteInit:   func(string) {},
+			OnExecuteStdout: func(s string) { stdoutLines = append(stdoutLines, s) },
+			OnExecuteStderr: func(string) {},
+			OnExecuteError: func(err *execute.ErrorOutput) {
+				gotErr = err
+				doneCh <- struct{}{}
+			},
+			OnExecuteComplete: func(time.Duration) { doneCh <- struct{}{} },
+		},
+	}
+	if err := c.runCommand(ctx, req); err != nil {
+		t.Fatalf("runCommand returned error: %v", err)
+	}
+	select {
+	case <-doneCh:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout waiting for command to finish")
+	}
+	if gotErr != nil {
+		t.Fatalf("unexpected error: %+v", gotErr)
+	}
+	if len(stdoutLines) != 1 || stdoutLines[0] != "64" {
+		t.Fatalf("expected the limited command to run as nobody, got %v", stdoutLines)
+	}
+}
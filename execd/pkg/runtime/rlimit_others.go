@@ -0,0 +1,43 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !linux
+// +build !linux
+
+package runtime
+
+import "os/exec"
+
+// ResourceLimitHelperCommand is only handled on Linux, where limits are enforced.
+const ResourceLimitHelperCommand = "__execd-rlimit-helper"
+
+// RunResourceLimitHelper does nothing; resource limits are only enforced on Linux.
+func RunResourceLimitHelper([]string) {}
+
+// limitStatus is never created off Linux.
+type limitStatus struct{}
+
+func (*limitStatus) Close() {}
+
+// applyResourceLimits ignores the limits; they are only enforced on Linux.
+func applyResourceLimits(_ *exec.Cmd, limits ResourceLimits) (*limitStatus, error) {
+	if !limits.IsZero() {
+		logger.Warning("resource limits are only supported on linux, ignoring them")
+	}
+	return nil, nil
+}
+
+func describeLimitExit(error, ResourceLimits, string, *limitStatus) string {
+	return ""
+}
@@ -0,0 +1,286 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/alibaba/opensandbox/execd/pkg/util/safego"
+)
+
+// SetCommandLogRotation splits the logs of background commands into segments of
+// segmentSize bytes, gzipping full segments when compress is set. A segmentSize
+// of 0 keeps each log in a single file.
+func (c *Controller) SetCommandLogRotation(segmentSize int64, compress bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.logSegmentSize = segmentSize
+	c.logCompress = compress
+}
+
+func (c *Controller) commandLogRotation() (int64, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.logSegmentSize, c.logCompress
+}
+
+// segmentedLog is an append-only log kept as a series of files: full segments
+// at "<path>.<n>", or "<path>.<n>.gz" when compressed, and the active segment
+// at path. Offsets are logical, counting every byte ever written, so read
+// cursors stay valid across rotations. It is safe for concurrent use.
+type segmentedLog struct {
+	path        string
+	segmentSize int64
+	compress    bool
+
+	mu       sync.Mutex
+	file     *os.File
+	segments []logSegment
+	// base is the logical offset the active segment starts at, size its length.
+	base int64
+	size int64
+}
+
+// logSegment is a rotated part of a segmentedLog.
+type logSegment struct {
+	path       string
+	start      int64
+	size       int64
+	compressed bool
+}
+
+func openSegmentedLog(path string, segmentSize int64, compress bool) (*segmentedLog, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, os.ModePerm)
+	if err != nil {
+		return nil, err
+	}
+	return &segmentedLog{path: path, segmentSize: segmentSize, compress: compress, file: file}, nil
+}
+
+// Write appends p, starting a new segment whenever the active one is full.
+func (l *segmentedLog) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return 0, os.ErrClosed
+	}
+	written := 0
+	for len(p) > 0 {
+		if l.size >= l.segmentSize {
+			if err := l.rotate(); err != nil {
+				return written, err
+			}
+		}
+		chunk := p[:min(int64(len(p)), l.segmentSize-l.size)]
+		n, err := l.file.Write(chunk)
+		written += n
+		l.size += int64(n)
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
+
+// Close closes the active segment. The log stays readable.
+func (l *segmentedLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// rotate moves the full active segment aside, compressing it if configured,
+// and starts a new one. Callers must hold mu.
+func (l *segmentedLog) rotate() error {
+	if err := l.file.Close(); err != nil {
+		return err
+	}
+	l.file = nil
+
+	segment := logSegment{path: fmt.Sprintf("%s.%d", l.path, len(l.segments)), start: l.base, size: l.size}
+	if err := os.Rename(l.path, segment.path); err != nil {
+		return err
+	}
+	if l.compress {
+		if err := gzipFile(segment.path, segment.path+".gz"); err != nil {
+			return err
+		}
+		if err := os.Remove(segment.path); err != nil {
+			return err
+		}
+		segment.path += ".gz"
+		segment.compressed = true
+	}
+
+	file, err := os.OpenFile(l.path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, os.ModePerm)
+	if err != nil {
+		return err
+	}
+	l.segments = append(l.segments, segment)
+	l.file = file
+	l.base += l.size
+	l.size = 0
+	return nil
+}
+
+// ReadFrom returns the log from the logical offset cursor on, plus the offset
+// to continue from, decompressing the segments it spans.
+func (l *segmentedLog) ReadFrom(cursor int64) ([]byte, int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var out []byte
+	for _, segment := range l.segments {
+		if cursor >= segment.start+segment.size {
+			continue
+		}
+		data, err := segment.read(max(cursor-segment.start, 0))
+		if err != nil {
+			return nil, -1, err
+		}
+		out = append(out, data...)
+	}
+
+	file, err := os.Open(l.path)
+	if err != nil {
+		return nil, -1, err
+	}
+	defer file.Close()
+	if _, err := file.Seek(max(cursor-l.base, 0), io.SeekStart); err != nil {
+		return nil, -1, err
+	}
+	data, err := io.ReadAll(io.LimitReader(file, l.size-max(cursor-l.base, 0)))
+	if err != nil {
+		return nil, -1, err
+	}
+	return append(out, data...), max(cursor, l.base+l.size), nil
+}
+
+// read returns the segment from offset on.
+func (s logSegment) read(offset int64) ([]byte, error) {
+	file, err := os.Open(s.path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if s.compressed {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		reader = gz
+	}
+	if _, err := io.CopyN(io.Discard, reader, offset); err != nil {
+		return nil, err
+	}
+	return io.ReadAll(reader)
+}
+
+// gzipFile writes a gzip compressed copy of src to dst.
+func gzipFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.ModePerm)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(out)
+	if _, err := io.Copy(gz, in); err != nil {
+		closeAll(gz, out)
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// segmentedOutputDescriptor is backgroundOutputDescriptor with segmented logs.
+// The command writes through pipes, since its output has to be split by execd.
+func (c *Controller) segmentedOutputDescriptor(session string, separate bool, segmentSize int64, compress bool) (*backgroundOutput, error) {
+	combinedPath := c.combinedOutputFileName(session)
+	combined, err := openSegmentedLog(combinedPath, segmentSize, compress)
+	if err != nil {
+		return nil, err
+	}
+
+	if !separate {
+		reader, writer, err := os.Pipe()
+		if err != nil {
+			combined.Close()
+			return nil, err
+		}
+		copied := make(chan struct{})
+		safego.Go(func() {
+			_, _ = io.Copy(combined, reader)
+			closeAll(reader, combined)
+			close(copied)
+		})
+		return &backgroundOutput{
+			stdout:       writer,
+			stderr:       writer,
+			stdoutPath:   combinedPath,
+			stderrPath:   combinedPath,
+			combinedPath: combinedPath,
+			logs:         map[string]*segmentedLog{combinedPath: combined},
+			closers:      []io.Closer{writer},
+			copied:       copied,
+		}, nil
+	}
+
+	stdoutPath, stderrPath := c.stdoutFileName(session), c.stderrFileName(session)
+	stdout, err := openSegmentedLog(stdoutPath, segmentSize, compress)
+	if err != nil {
+		combined.Close()
+		return nil, err
+	}
+	stderr, err := openSegmentedLog(stderrPath, segmentSize, compress)
+	if err != nil {
+		closeAll(stdout, combined)
+		return nil, err
+	}
+
+	output, err := teeStreams(stdout, stderr, combined)
+	if err != nil {
+		return nil, err
+	}
+	output.stdoutPath = stdoutPath
+	output.stderrPath = stderrPath
+	output.combinedPath = combinedPath
+	output.logs = map[string]*segmentedLog{stdoutPath: stdout, stderrPath: stderr, combinedPath: combined}
+	return output, nil
+}
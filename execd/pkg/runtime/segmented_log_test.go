@@ -0,0 +1,145 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	goruntime "runtime"
+	"testing"
+	"time"
+)
+
+func TestSegmentedLog_ReadsAcrossSegments(t *testing.T) {
+	var content bytes.Buffer
+	for i := 0; i < 200; i++ {
+		fmt.Fprintf(&content, "line %03d of the log\n", i)
+	}
+
+	for _, compress := range []bool{false, true} {
+		path := filepath.Join(t.TempDir(), "cmd.output")
+		l, err := openSegmentedLog(path, 256, compress)
+		if err != nil {
+			t.Fatalf("open: %v", err)
+		}
+		// uneven writes so segment boundaries fall inside writes.
+		for data := content.Bytes(); len(data) > 0; {
+			n := min(len(data), 97)
+			if _, err := l.Write(data[:n]); err != nil {
+				t.Fatalf("write: %v", err)
+			}
+			data = data[n:]
+		}
+		if err := l.Close(); err != nil {
+			t.Fatalf("close: %v", err)
+		}
+
+		if len(l.segments) < 10 {
+			t.Fatalf("compress=%v: expected the log to rotate, got %d segments", compress, len(l.segments))
+		}
+		if _, err := os.Stat(path + ".0.gz"); (err == nil) != compress {
+			t.Fatalf("compress=%v: unexpected compressed segment, stat err=%v", compress, err)
+		}
+
+		for _, cursor := range []int64{0, 1, 255, 256, 1000, int64(content.Len()) - 1, int64(content.Len()), int64(content.Len()) + 10} {
+			data, next, err := l.ReadFrom(cursor)
+			if err != nil {
+				t.Fatalf("compress=%v cursor=%d: %v", compress, cursor, err)
+			}
+			want := []byte{}
+			if cursor < int64(content.Len()) {
+				want = content.Bytes()[cursor:]
+			}
+			if !bytes.Equal(data, want) {
+				t.Fatalf("compress=%v cursor=%d: read %d bytes, want %d", compress, cursor, len(data), len(want))
+			}
+			if wantNext := max(cursor, int64(content.Len())); next != wantNext {
+				t.Fatalf("compress=%v cursor=%d: next cursor %d, want %d", compress, cursor, next, wantNext)
+			}
+		}
+	}
+}
+
+func TestSeekBackgroundCommandOutput_CompressionIsTransparent(t *testing.T) {
+	if goruntime.GOOS == "windows" {
+		t.Skip("bash not available on windows")
+	}
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not found in PATH")
+	}
+
+	outputs := make(map[bool][]byte)
+	for _, compress := range []bool{false, true} {
+		c := NewController("", "")
+		c.SetCommandLogRotation(512, compress)
+
+		var session string
+		done := make(chan struct{})
+		req := &ExecuteCodeRequest{
+			Language: BackgroundCommand,
+			Code:     "seq 1 2000",
+			Hooks: ExecuteResultHook{
+				OnExecuteInit:     func(id string) { session = id },
+				OnExecuteComplete: func(time.Duration) {},
+			},
+		}
+		if err := c.runBackgroundCommand(context.Background(), req); err != nil {
+			t.Fatalf("runBackgroundCommand: %v", err)
+		}
+
+		go func() {
+			defer close(done)
+			for {
+				if status, err := c.GetCommandStatus(session); err == nil && !status.Running {
+					return
+				}
+				time.Sleep(20 * time.Millisecond)
+			}
+		}()
+		select {
+		case <-done:
+		case <-time.After(10 * time.Second):
+			t.Fatalf("compress=%v: command did not finish", compress)
+		}
+
+		// read in two steps so the cursor crosses segment boundaries.
+		head, cursor, err := c.SeekBackgroundCommandOutput(session, 0)
+		if err != nil {
+			t.Fatalf("compress=%v: %v", compress, err)
+		}
+		tail, _, err := c.SeekBackgroundCommandOutput(session, cursor/3)
+		if err != nil {
+			t.Fatalf("compress=%v: %v", compress, err)
+		}
+		if !bytes.Equal(tail, head[cursor/3:]) {
+			t.Fatalf("compress=%v: reading from a cursor returned different bytes", compress)
+		}
+		outputs[compress] = head
+	}
+
+	var want bytes.Buffer
+	for i := 1; i <= 2000; i++ {
+		fmt.Fprintf(&want, "%d\n", i)
+	}
+	for compress, output := range outputs {
+		if !bytes.Equal(output, want.Bytes()) {
+			t.Fatalf("compress=%v: got %d bytes, want %d", compress, len(output), want.Len())
+		}
+	}
+}
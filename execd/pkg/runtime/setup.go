@@ -0,0 +1,69 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/alibaba/opensandbox/execd/pkg/jupyter/execute"
+)
+
+// ContextSetup reports how the setup cells of a new context ran.
+type ContextSetup struct {
+	// Duration is the time spent running the cells, up to the failing one.
+	Duration time.Duration `json:"duration"`
+	// Error is set when a cell raised; the cells after it were skipped.
+	Error *SetupError `json:"error,omitempty"`
+}
+
+// SetupError reports a setup cell of a new context that raised in the kernel.
+type SetupError struct {
+	// Cell is the index of the cell in the setup list.
+	Cell   int                  `json:"cell"`
+	Output *execute.ErrorOutput `json:"output"`
+}
+
+func (e *SetupError) Error() string {
+	return fmt.Sprintf("context setup cell %d failed: %s: %s", e.Cell, e.Output.EName, e.Output.EValue)
+}
+
+// runSetup executes the setup cells of a new context in order, stopping at
+// the first one that raises. It returns nil when there are no cells.
+func (c *Controller) runSetup(ctx context.Context, kernel *jupyterKernel, cells []string) (*ContextSetup, error) {
+	if len(cells) == 0 {
+		return nil, nil
+	}
+
+	setup := &ContextSetup{}
+	start := time.Now()
+	defer func() { setup.Duration = time.Since(start) }()
+
+	for index, code := range cells {
+		raised, err := c.runQuietly(ctx, kernel, code)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if raised != nil {
+			setup.Error = &SetupError{Cell: index, Output: raised}
+			return setup, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to run context setup cell %d: %w", index, err)
+		}
+	}
+	return setup, nil
+}
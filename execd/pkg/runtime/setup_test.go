@@ -0,0 +1,101 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCreateContext_SetsEnvBeforeSetup(t *testing.T) {
+	url, sent := newPreambleKernel(t)
+	c := NewController(url, "token")
+
+	session, err := c.CreateContext(context.Background(), &CreateContextRequest{
+		Language: Python,
+		Env:      map[string]string{"SEED": "42", "MODE": "test"},
+		Setup:    []string{"import random", "random.seed(42)"},
+	})
+	if err != nil {
+		t.Fatalf("CreateContext returned error: %v", err)
+	}
+
+	expected := []string{
+		"import os\nos.environ[\"MODE\"] = \"test\"\nos.environ[\"SEED\"] = \"42\"\n",
+		"import random",
+		"random.seed(42)",
+	}
+	if got := sent(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("unexpected code sent: got %q want %q", got, expected)
+	}
+	codeContext, _ := c.GetContext(session, false)
+	setup := codeContext.Setup
+	if setup == nil || setup.Error != nil {
+		t.Fatalf("expected a successful setup summary, got %+v", setup)
+	}
+}
+
+func TestCreateContext_SetupErrorDiscardsContext(t *testing.T) {
+	url, sent := newPreambleKernel(t)
+	c := NewController(url, "token")
+
+	_, err := c.CreateContext(context.Background(), &CreateContextRequest{
+		Language: Python,
+		Setup:    []string{"import pandas", "raise pd", "never"},
+	})
+	var setupErr *SetupError
+	if !errors.As(err, &setupErr) || setupErr.Cell != 1 || setupErr.Output.EName != "NameError" {
+		t.Fatalf("expected the second setup cell to fail, got %v", err)
+	}
+	if c.getJupyterKernel("session-1") != nil {
+		t.Fatalf("expected the failed context to be discarded")
+	}
+	if got := sent(); len(got) != 2 {
+		t.Fatalf("expected the cells after the failing one to be skipped, got %q", got)
+	}
+}
+
+func TestCreateContext_KeepOnSetupFailure(t *testing.T) {
+	url, _ := newPreambleKernel(t)
+	c := NewController(url, "token")
+
+	session, err := c.CreateContext(context.Background(), &CreateContextRequest{
+		Language:           Python,
+		Setup:              []string{"raise pd"},
+		KeepOnSetupFailure: true,
+	})
+	if err != nil {
+		t.Fatalf("CreateContext returned error: %v", err)
+	}
+	codeContext, _ := c.GetContext(session, false)
+	setup := codeContext.Setup
+	if setup == nil || setup.Error == nil || setup.Error.Cell != 0 {
+		t.Fatalf("expected the setup error to be reported, got %+v", setup)
+	}
+}
+
+func TestEnvCode(t *testing.T) {
+	env := map[string]string{"B": "it's", "A": "1"}
+
+	code, ok := envCode(Bash, env)
+	if !ok || code != "export A='1'\nexport B='it'\\''s'\n" {
+		t.Fatalf("unexpected bash code %q", code)
+	}
+	if _, ok := envCode(Java, env); ok {
+		t.Fatalf("expected java to be unsupported")
+	}
+}
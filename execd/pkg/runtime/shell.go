@@ -0,0 +1,32 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package runtime
+
+import "fmt"
+
+// defaultCommandShell runs commands requesting no shell.
+const defaultCommandShell = ShellBash
+
+// ValidateCommandShell reports whether commands can be run by shell; only
+// bash, the default, is available outside Windows.
+func ValidateCommandShell(shell string) error {
+	if shell == "" || shell == ShellBash {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrShellUnsupported, shell)
+}
@@ -0,0 +1,63 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build windows
+// +build windows
+
+package runtime
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os/exec"
+	"unicode/utf16"
+)
+
+// defaultCommandShell runs commands requesting no shell.
+const defaultCommandShell = ShellCmd
+
+// powerShellExitTrailer ends PowerShell with the exit code of a failing last
+// native command, which -Command would otherwise report as 1.
+const powerShellExitTrailer = "\nif (-not $?) { if ($LASTEXITCODE) { exit $LASTEXITCODE }; exit 1 }"
+
+// ValidateCommandShell reports whether commands can be run by shell: cmd, the
+// default, powershell or pwsh.
+func ValidateCommandShell(shell string) error {
+	switch shell {
+	case "", ShellCmd, ShellPowerShell, ShellPwsh:
+		return nil
+	default:
+		return fmt.Errorf("%w: %s", ErrShellUnsupported, shell)
+	}
+}
+
+// shellCommand returns the command running code with shell. PowerShell gets
+// the script encoded, so that multi-line scripts and quotes reach it intact.
+func shellCommand(ctx context.Context, shell, code string) (*exec.Cmd, error) {
+	if err := ValidateCommandShell(shell); err != nil {
+		return nil, err
+	}
+	switch shell {
+	case ShellPowerShell, ShellPwsh:
+		script := utf16.Encode([]rune(code + powerShellExitTrailer))
+		encoded := make([]byte, 0, 2*len(script))
+		for _, unit := range script {
+			encoded = append(encoded, byte(unit), byte(unit>>8))
+		}
+		return exec.CommandContext(ctx, shell, "-NoProfile", "-NonInteractive", "-EncodedCommand", base64.StdEncoding.EncodeToString(encoded)), nil
+	default:
+		return exec.CommandContext(ctx, "cmd", "/C", code), nil
+	}
+}
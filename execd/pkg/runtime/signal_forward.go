@@ -0,0 +1,51 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package runtime
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+)
+
+// ParseForwardedSignals resolves the comma separated signal names or numbers
+// relayed to the process group of foreground commands, such as
+// "INT,TERM,HUP,QUIT". An empty spec forwards no signal.
+func ParseForwardedSignals(spec string) ([]os.Signal, error) {
+	signals := make([]os.Signal, 0)
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		sig, err := parseSignal(name)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", err, name)
+		}
+		switch sig {
+		case syscall.SIGKILL, syscall.SIGSTOP:
+			return nil, fmt.Errorf("%s can't be caught to be forwarded", signalName(sig))
+		case syscall.SIGCHLD, syscall.SIGURG:
+			// the runtime relies on these; children must never receive them from execd.
+			return nil, fmt.Errorf("%s must not be forwarded", signalName(sig))
+		}
+		signals = append(signals, sig)
+	}
+	return signals, nil
+}
@@ -0,0 +1,98 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package runtime
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseForwardedSignals(t *testing.T) {
+	signals, err := ParseForwardedSignals("INT, SIGTERM,hup,3")
+	assert.NoError(t, err)
+	assert.Equal(t, []os.Signal{syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT}, signals)
+
+	signals, err = ParseForwardedSignals("")
+	assert.NoError(t, err)
+	assert.Empty(t, signals)
+
+	for _, spec := range []string{"INT,NOPE", "KILL", "STOP", "17"} {
+		_, err := ParseForwardedSignals(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestRunCommand_ForwardsConfiguredSignalsOnly(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not found in PATH")
+	}
+	// SIGUSR2 isn't forwarded; catch it so it doesn't end the test binary.
+	caught := make(chan os.Signal, 1)
+	signal.Notify(caught, syscall.SIGUSR2)
+	defer signal.Stop(caught)
+
+	c := NewController("", "")
+	c.SetForwardedSignals([]os.Signal{syscall.SIGUSR1})
+
+	var mu sync.Mutex
+	var stdout []string
+	ready := make(chan struct{})
+	req := &ExecuteCodeRequest{
+		Language: Command,
+		Code:     "trap 'echo usr1; exit 0' USR1; trap 'echo usr2' USR2; echo ready; for i in $(seq 100); do sleep 0.1; done; exit 3",
+		Cwd:      t.TempDir(),
+		Hooks: ExecuteResultHook{
+			OnExecuteStdout: func(text string) {
+				mu.Lock()
+				defer mu.Unlock()
+				stdout = append(stdout, text)
+				if text == "ready" {
+					close(ready)
+				}
+			},
+		},
+	}
+
+	go func() {
+		select {
+		case <-ready:
+		case <-time.After(5 * time.Second):
+			return
+		}
+		_ = syscall.Kill(os.Getpid(), syscall.SIGUSR2)
+		time.Sleep(300 * time.Millisecond)
+		_ = syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+	}()
+
+	assert.NoError(t, c.runCommand(context.Background(), req))
+
+	mu.Lock()
+	defer mu.Unlock()
+	output := strings.Join(stdout, "\n")
+	assert.Contains(t, output, "usr1", "the configured signal was not forwarded")
+	assert.NotContains(t, output, "usr2", "a signal that isn't configured was forwarded")
+}
@@ -20,6 +20,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -28,7 +29,6 @@ import (
 	_ "github.com/go-sql-driver/mysql"
 
 	"github.com/alibaba/opensandbox/execd/pkg/jupyter/execute"
-	"github.com/alibaba/opensandbox/execd/pkg/log"
 )
 
 // QueryResult represents a SQL query response.
@@ -38,36 +38,55 @@ type QueryResult struct {
 	Error   string   `json:"error,omitempty"`
 }
 
+// SQLQuery describes an in-flight SQL execution.
+type SQLQuery struct {
+	ID        string    `json:"id"`
+	Statement string    `json:"statement"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+type sqlQuery struct {
+	SQLQuery
+	cancel context.CancelFunc
+}
+
 // runSQL executes SQL queries based on their type.
 func (c *Controller) runSQL(ctx context.Context, request *ExecuteCodeRequest) error {
-	request.Hooks.OnExecuteInit(uuid.New().String())
-	err := c.initDB()
+	queryID := uuid.New().String()
+	request.Hooks.OnExecuteInit(queryID)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	c.storeSQLQuery(queryID, request.Code, cancel)
+	defer c.removeSQLQuery(queryID)
+
+	db, err := c.sqlDB(request.SQLConnection)
 	if err != nil {
 		request.Hooks.OnExecuteError(&execute.ErrorOutput{EName: "DBInitError", EValue: err.Error()})
-		log.Error("DBInitError: error initializing db server: %v", err)
+		logger.Error("DBInitError: error initializing db server: %v", err)
 		return err
 	}
 
-	err = c.db.PingContext(ctx)
+	err = db.PingContext(ctx)
 	if err != nil {
 		request.Hooks.OnExecuteError(&execute.ErrorOutput{EName: "DBPingError", EValue: err.Error()})
-		log.Error("DBPingError: error pinging db server: %v", err)
+		logger.Error("DBPingError: error pinging db server: %v", err)
 		return err
 	}
 
 	switch c.getQueryType(request.Code) {
 	case "SELECT":
-		return c.executeSelectSQLQuery(ctx, request)
+		return c.executeSelectSQLQuery(ctx, db, request)
 	default:
-		return c.executeUpdateSQLQuery(ctx, request)
+		return c.executeUpdateSQLQuery(ctx, db, request)
 	}
 }
 
 // executeSelectSQLQuery handles SELECT statements.
-func (c *Controller) executeSelectSQLQuery(ctx context.Context, request *ExecuteCodeRequest) error {
+func (c *Controller) executeSelectSQLQuery(ctx context.Context, db *sql.DB, request *ExecuteCodeRequest) error {
 	startAt := time.Now()
 
-	rows, err := c.db.QueryContext(ctx, request.Code)
+	rows, err := db.QueryContext(ctx, request.Code)
 	if err != nil {
 		request.Hooks.OnExecuteError(&execute.ErrorOutput{EName: "DBQueryError", EValue: err.Error()})
 		return nil
@@ -124,10 +143,10 @@ func (c *Controller) executeSelectSQLQuery(ctx context.Context, request *Execute
 }
 
 // executeUpdateSQLQuery handles non-SELECT statements.
-func (c *Controller) executeUpdateSQLQuery(ctx context.Context, request *ExecuteCodeRequest) error {
+func (c *Controller) executeUpdateSQLQuery(ctx context.Context, db *sql.DB, request *ExecuteCodeRequest) error {
 	startAt := time.Now()
 
-	result, err := c.db.ExecContext(ctx, request.Code)
+	result, err := db.ExecContext(ctx, request.Code)
 	if err != nil {
 		request.Hooks.OnExecuteError(&execute.ErrorOutput{EName: "DBExecError", EValue: err.Error()})
 		return err
@@ -153,6 +172,58 @@ func (c *Controller) executeUpdateSQLQuery(ctx context.Context, request *Execute
 	return nil
 }
 
+// ListSQLQueries returns the SQL executions that are still running, oldest first.
+func (c *Controller) ListSQLQueries() []SQLQuery {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	queries := make([]SQLQuery, 0, len(c.sqlQueryMap))
+	for _, query := range c.sqlQueryMap {
+		queries = append(queries, query.SQLQuery)
+	}
+	sort.Slice(queries, func(i, j int) bool {
+		return queries[i].StartedAt.Before(queries[j].StartedAt)
+	})
+	return queries
+}
+
+// CancelSQLQuery aborts a running SQL execution by cancelling its context.
+func (c *Controller) CancelSQLQuery(id string) error {
+	c.mu.RLock()
+	query, ok := c.sqlQueryMap[id]
+	c.mu.RUnlock()
+	if !ok {
+		return ErrSQLQueryNotFound
+	}
+
+	logger.Warning("Cancelling sql query %s", id)
+	query.cancel()
+	return nil
+}
+
+// storeSQLQuery registers an in-flight SQL execution.
+func (c *Controller) storeSQLQuery(id, statement string, cancel context.CancelFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.sqlQueryMap[id] = &sqlQuery{
+		SQLQuery: SQLQuery{
+			ID:        id,
+			Statement: statement,
+			StartedAt: time.Now(),
+		},
+		cancel: cancel,
+	}
+}
+
+// removeSQLQuery drops bookkeeping for a finished SQL execution.
+func (c *Controller) removeSQLQuery(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.sqlQueryMap, id)
+}
+
 // getQueryType extracts the first token to decide which executor to use.
 func (c *Controller) getQueryType(query string) string {
 	firstWord := strings.ToUpper(strings.Fields(query)[0])
@@ -0,0 +1,146 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultSQLConnection names the local sandbox database SQL runs against when
+// a request picks no connection.
+const DefaultSQLConnection = "default"
+
+// SQLConnection is a named database SQL requests may pick. The DSN holds
+// credentials and is never reported back.
+type SQLConnection struct {
+	Name   string `json:"name"`
+	Driver string `json:"driver"`
+	DSN    string `json:"-"`
+}
+
+// sqlConnection is a registered connection, opened on first use. The
+// *sql.DB it opens is a pool shared by the requests picking it.
+type sqlConnection struct {
+	SQLConnection
+
+	once sync.Once
+	db   *sql.DB
+	err  error
+}
+
+// ParseSQLConnections reads "NAME=DRIVER:DSN" entries separated by ";", such
+// as "analytics=mysql:user:pass@tcp(db:3306)/analytics".
+func ParseSQLConnections(spec string) ([]SQLConnection, error) {
+	var connections []SQLConnection
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, target, ok := strings.Cut(entry, "=")
+		driver, dsn, ok2 := strings.Cut(target, ":")
+		if !ok || !ok2 || strings.TrimSpace(name) == "" || driver == "" {
+			return nil, fmt.Errorf("invalid sql connection %q, expected NAME=DRIVER:DSN", strings.SplitN(entry, ":", 2)[0])
+		}
+		connections = append(connections, SQLConnection{Name: strings.TrimSpace(name), Driver: driver, DSN: dsn})
+	}
+	return connections, nil
+}
+
+// AddSQLConnection registers a connection under its name. The database is
+// only opened when a request first picks it.
+func (c *Controller) AddSQLConnection(connection SQLConnection) error {
+	if connection.Name == DefaultSQLConnection {
+		return fmt.Errorf("%w: %s is the local sandbox database", ErrSQLConnectionExists, connection.Name)
+	}
+	if !slices.Contains(sql.Drivers(), connection.Driver) {
+		return fmt.Errorf("%w: %s, use one of %s", ErrSQLDriverUnsupported, connection.Driver, strings.Join(sql.Drivers(), ", "))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.sqlConnections[connection.Name]; ok {
+		return fmt.Errorf("%w: %s", ErrSQLConnectionExists, connection.Name)
+	}
+	c.sqlConnections[connection.Name] = &sqlConnection{SQLConnection: connection}
+	return nil
+}
+
+// RemoveSQLConnection unregisters a connection and closes its pool, if it
+// was opened, once the queries running on it finish.
+func (c *Controller) RemoveSQLConnection(name string) error {
+	if name == DefaultSQLConnection {
+		return fmt.Errorf("%w: %s is the local sandbox database", ErrSQLConnectionBuiltin, name)
+	}
+
+	c.mu.Lock()
+	connection, ok := c.sqlConnections[name]
+	delete(c.sqlConnections, name)
+	c.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrSQLConnectionNotFound, name)
+	}
+
+	// Wait for an open in flight and keep later ones from reopening it.
+	connection.once.Do(func() {})
+	if connection.db != nil {
+		return connection.db.Close()
+	}
+	return nil
+}
+
+// ListSQLConnections returns the connections SQL requests may pick, the
+// default one first and the others by name.
+func (c *Controller) ListSQLConnections() []SQLConnection {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	connections := make([]SQLConnection, 0, len(c.sqlConnections))
+	for _, connection := range c.sqlConnections {
+		connections = append(connections, connection.SQLConnection)
+	}
+	sort.Slice(connections, func(i, j int) bool {
+		return connections[i].Name < connections[j].Name
+	})
+	return append([]SQLConnection{{Name: DefaultSQLConnection, Driver: "mysql"}}, connections...)
+}
+
+// sqlDB returns the pool of the named connection, opening it on first use;
+// an empty name is the default connection.
+func (c *Controller) sqlDB(name string) (*sql.DB, error) {
+	if name == "" || name == DefaultSQLConnection {
+		if err := c.initDB(); err != nil {
+			return nil, err
+		}
+		return c.db, nil
+	}
+
+	c.mu.RLock()
+	connection, ok := c.sqlConnections[name]
+	c.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrSQLConnectionNotFound, name)
+	}
+	connection.once.Do(func() {
+		connection.db, connection.err = sql.Open(connection.Driver, connection.DSN)
+	})
+	return connection.db, connection.err
+}
@@ -18,6 +18,8 @@ import (
 	"context"
 	"database/sql/driver"
 	"encoding/json"
+	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -58,7 +60,7 @@ func TestExecuteSelectSQLQuery_Success(t *testing.T) {
 		},
 	}
 
-	if err := c.executeSelectSQLQuery(context.Background(), req); err != nil {
+	if err := c.executeSelectSQLQuery(context.Background(), db, req); err != nil {
 		t.Fatalf("executeSelectSQLQuery returned error: %v", err)
 	}
 
@@ -116,7 +118,7 @@ func TestExecuteUpdateSQLQuery_Success(t *testing.T) {
 		},
 	}
 
-	if err := c.executeUpdateSQLQuery(context.Background(), req); err != nil {
+	if err := c.executeUpdateSQLQuery(context.Background(), db, req); err != nil {
 		t.Fatalf("executeUpdateSQLQuery returned error: %v", err)
 	}
 
@@ -143,3 +145,177 @@ func TestExecuteUpdateSQLQuery_Success(t *testing.T) {
 		t.Fatalf("unexpected affected rows: %#v", qr.Rows)
 	}
 }
+
+func TestRunSQL_ListAndCancelRunningQuery(t *testing.T) {
+	driver := &stubDriver{
+		columns:    []string{"id"},
+		queryDelay: 10 * time.Second,
+	}
+	db := newStubDB(t, driver)
+
+	c := NewController("", "")
+	c.db = db
+	c.dbOnce.Do(func() {})
+
+	var gotError *execute.ErrorOutput
+	done := make(chan error, 1)
+	req := &ExecuteCodeRequest{
+		Code: "SELECT SLEEP(10)",
+		Hooks: ExecuteResultHook{
+			OnExecuteInit:   func(string) {},
+			OnExecuteResult: func(map[string]any, int) {},
+			OnExecuteError: func(err *execute.ErrorOutput) {
+				gotError = err
+			},
+			OnExecuteComplete: func(time.Duration) {},
+		},
+	}
+	go func() { done <- c.runSQL(context.Background(), req) }()
+
+	var queries []SQLQuery
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		queries = c.ListSQLQueries()
+		if len(queries) > 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(queries) != 1 || queries[0].Statement != req.Code || queries[0].StartedAt.IsZero() {
+		t.Fatalf("unexpected running queries: %#v", queries)
+	}
+
+	if err := c.CancelSQLQuery(queries[0].ID); err != nil {
+		t.Fatalf("CancelSQLQuery returned error: %v", err)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timeout waiting for cancelled query to return")
+	}
+
+	if gotError == nil || gotError.EName != "DBQueryError" {
+		t.Fatalf("expected query error after cancel, got %+v", gotError)
+	}
+	if queries := c.ListSQLQueries(); len(queries) != 0 {
+		t.Fatalf("expected finished query to be removed, got %#v", queries)
+	}
+	if err := c.CancelSQLQuery(queries[0].ID); !errors.Is(err, ErrSQLQueryNotFound) {
+		t.Fatalf("expected ErrSQLQueryNotFound, got %v", err)
+	}
+}
+
+func TestRunSQL_RoutesToPickedConnection(t *testing.T) {
+	analytics := &stubDriver{columns: []string{"source"}, rows: [][]driver.Value{{"analytics"}}}
+	warehouse := &stubDriver{columns: []string{"source"}, rows: [][]driver.Value{{"warehouse"}}}
+
+	c := NewController("", "")
+	for name, d := range map[string]*stubDriver{"analytics": analytics, "warehouse": warehouse} {
+		if err := c.AddSQLConnection(SQLConnection{Name: name, Driver: registerStubDriver(d), DSN: "user:pass@tcp(db)/" + name}); err != nil {
+			t.Fatalf("AddSQLConnection(%s): %v", name, err)
+		}
+	}
+
+	run := func(connection string) (string, *execute.ErrorOutput) {
+		var result string
+		var gotError *execute.ErrorOutput
+		req := &ExecuteCodeRequest{
+			Code:          "SELECT source FROM t",
+			SQLConnection: connection,
+			Hooks: ExecuteResultHook{
+				OnExecuteInit: func(string) {},
+				OnExecuteResult: func(r map[string]any, _ int) {
+					result, _ = r["text/plain"].(string)
+				},
+				OnExecuteError:    func(err *execute.ErrorOutput) { gotError = err },
+				OnExecuteComplete: func(time.Duration) {},
+			},
+		}
+		_ = c.runSQL(context.Background(), req)
+		return result, gotError
+	}
+
+	for _, name := range []string{"warehouse", "analytics", "warehouse"} {
+		result, gotError := run(name)
+		if gotError != nil {
+			t.Fatalf("%s: unexpected error %+v", name, gotError)
+		}
+		var qr QueryResult
+		if err := json.Unmarshal([]byte(result), &qr); err != nil || len(qr.Rows) != 1 || qr.Rows[0][0] != name {
+			t.Fatalf("%s: query was not routed to its connection, got %s", name, result)
+		}
+	}
+	if analytics.queryCalled != 1 || warehouse.queryCalled != 2 {
+		t.Fatalf("unexpected queries per connection: analytics %d, warehouse %d", analytics.queryCalled, warehouse.queryCalled)
+	}
+
+	if _, gotError := run("missing"); gotError == nil || !strings.Contains(gotError.EValue, ErrSQLConnectionNotFound.Error()) {
+		t.Fatalf("expected an unknown connection to fail, got %+v", gotError)
+	}
+}
+
+func TestAddSQLConnection(t *testing.T) {
+	c := NewController("", "")
+	driverName := registerStubDriver(&stubDriver{})
+
+	if err := c.AddSQLConnection(SQLConnection{Name: "reports", Driver: driverName, DSN: "secret"}); err != nil {
+		t.Fatalf("AddSQLConnection: %v", err)
+	}
+	if err := c.AddSQLConnection(SQLConnection{Name: "reports", Driver: driverName}); !errors.Is(err, ErrSQLConnectionExists) {
+		t.Fatalf("expected ErrSQLConnectionExists, got %v", err)
+	}
+	if err := c.AddSQLConnection(SQLConnection{Name: DefaultSQLConnection, Driver: driverName}); !errors.Is(err, ErrSQLConnectionExists) {
+		t.Fatalf("expected the default connection to be reserved, got %v", err)
+	}
+	if err := c.AddSQLConnection(SQLConnection{Name: "other", Driver: "no-such-driver"}); !errors.Is(err, ErrSQLDriverUnsupported) {
+		t.Fatalf("expected ErrSQLDriverUnsupported, got %v", err)
+	}
+
+	connections := c.ListSQLConnections()
+	if len(connections) != 2 || connections[0].Name != DefaultSQLConnection || connections[1].Name != "reports" {
+		t.Fatalf("unexpected connections %+v", connections)
+	}
+}
+
+func TestRemoveSQLConnection_ClosesPool(t *testing.T) {
+	c := NewController("", "")
+	if err := c.AddSQLConnection(SQLConnection{Name: "reports", Driver: registerStubDriver(&stubDriver{})}); err != nil {
+		t.Fatalf("AddSQLConnection: %v", err)
+	}
+	db, err := c.sqlDB("reports")
+	if err != nil {
+		t.Fatalf("sqlDB: %v", err)
+	}
+
+	if err := c.RemoveSQLConnection("reports"); err != nil {
+		t.Fatalf("RemoveSQLConnection: %v", err)
+	}
+	if err := db.Ping(); err == nil || !strings.Contains(err.Error(), "database is closed") {
+		t.Fatalf("expected the pool to be closed, got %v", err)
+	}
+	if _, err := c.sqlDB("reports"); !errors.Is(err, ErrSQLConnectionNotFound) {
+		t.Fatalf("expected the connection to be gone, got %v", err)
+	}
+	if err := c.RemoveSQLConnection("reports"); !errors.Is(err, ErrSQLConnectionNotFound) {
+		t.Fatalf("expected ErrSQLConnectionNotFound, got %v", err)
+	}
+	if err := c.RemoveSQLConnection(DefaultSQLConnection); !errors.Is(err, ErrSQLConnectionBuiltin) {
+		t.Fatalf("expected the default connection to be kept, got %v", err)
+	}
+}
+
+func TestParseSQLConnections(t *testing.T) {
+	connections, err := ParseSQLConnections("analytics=mysql:user:pass@tcp(db:3306)/analytics; reports=mysql:root@/reports")
+	if err != nil {
+		t.Fatalf("ParseSQLConnections: %v", err)
+	}
+	if len(connections) != 2 || connections[0] != (SQLConnection{Name: "analytics", Driver: "mysql", DSN: "user:pass@tcp(db:3306)/analytics"}) || connections[1].Name != "reports" {
+		t.Fatalf("unexpected connections %+v", connections)
+	}
+	for _, spec := range []string{"analytics", "=mysql:root@/db", "analytics=root@/db"} {
+		if _, err := ParseSQLConnections(spec); err == nil {
+			t.Fatalf("expected %q to be rejected", spec)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Task is work execd does itself, such as fetching a file, tracked like a
+// background command: GetCommandStatus reports it, the lines it logs are its
+// output and interrupting it cancels its context.
+type Task struct {
+	c       *Controller
+	session string
+	cancel  context.CancelFunc
+
+	mu  sync.Mutex
+	log *os.File
+}
+
+// StartTask registers a running task described by content and returns it
+// with the context interrupting the task cancels.
+func (c *Controller) StartTask(ctx context.Context, content string) (*Task, context.Context, error) {
+	session := c.newContextID()
+	path := c.combinedOutputFileName(session)
+	log, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create task log: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	c.storeCommandKernel(session, &commandKernel{
+		stdoutPath:   path,
+		stderrPath:   path,
+		combinedPath: path,
+		startedAt:    time.Now(),
+		running:      true,
+		isBackground: true,
+		content:      content,
+		cancel:       cancel,
+	})
+	return &Task{c: c, session: session, cancel: cancel, log: log}, ctx, nil
+}
+
+// ID is the session the task is reported under.
+func (t *Task) ID() string {
+	return t.session
+}
+
+// Logf appends a line to the output of the task.
+func (t *Task) Logf(format string, args ...any) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.log == nil {
+		return
+	}
+	line := strings.TrimSuffix(fmt.Sprintf(format, args...), "\n") + "\n"
+	if _, err := t.log.WriteString(line); err != nil {
+		logger.Warning("failed to write the log of task %s: %v", t.session, err)
+	}
+}
+
+// Finish ends the task, failed with err unless it is nil, and releases its
+// context. Only the first call counts.
+func (t *Task) Finish(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.log == nil {
+		return
+	}
+	_ = t.log.Close()
+	t.log = nil
+
+	exitCode, errMsg := 0, ""
+	if err != nil {
+		exitCode, errMsg = 1, err.Error()
+	}
+	t.c.markCommandFinished(t.session, exitCode, errMsg)
+	t.cancel()
+}
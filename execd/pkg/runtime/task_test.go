@@ -0,0 +1,56 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTask_ReportedLikeBackgroundCommand(t *testing.T) {
+	c := NewController("", "")
+	task, ctx, err := c.StartTask(context.Background(), "fetch data.bin")
+	if err != nil {
+		t.Fatalf("StartTask: %v", err)
+	}
+	task.Logf("sent %d bytes", 10)
+
+	status, err := c.GetCommandStatus(task.ID())
+	assert.NoError(t, err)
+	assert.True(t, status.Running)
+	assert.Equal(t, "fetch data.bin", status.Content)
+
+	interrupted, err := c.Interrupt(task.ID())
+	assert.NoError(t, err)
+	assert.True(t, interrupted)
+	assert.ErrorIs(t, ctx.Err(), context.Canceled)
+
+	task.Finish(errors.New("fetch interrupted"))
+	task.Finish(nil)
+	status, err = c.GetCommandStatus(task.ID())
+	assert.NoError(t, err)
+	assert.False(t, status.Running)
+	if assert.NotNil(t, status.ExitCode) {
+		assert.Equal(t, 1, *status.ExitCode)
+	}
+	assert.Equal(t, "fetch interrupted", status.Error)
+
+	output, _, err := c.SeekBackgroundCommandOutput(task.ID(), 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "sent 10 bytes\n", string(output))
+}
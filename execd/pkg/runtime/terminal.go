@@ -0,0 +1,92 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package runtime
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+
+	"github.com/alibaba/opensandbox/execd/pkg/util/safego"
+)
+
+// terminalDrainTimeout bounds how long a finished command waits for the
+// output left in its pseudo-terminal, which children may still hold open.
+const terminalDrainTimeout = time.Second
+
+// terminal connects a command to a pseudo-terminal. Everything the command
+// writes to stdout or stderr comes out of the master end, merged.
+type terminal struct {
+	master *os.File
+	tty    *os.File
+	copied chan struct{}
+}
+
+// attachTerminal opens a pseudo-terminal of size and makes it the controlling
+// terminal and the standard streams of cmd.
+func attachTerminal(cmd *exec.Cmd, size TerminalSize, credential *syscall.Credential) (*terminal, error) {
+	master, tty, err := openPTY()
+	if err != nil {
+		return nil, err
+	}
+	if err := resizePTY(master, size.withDefaults()); err != nil {
+		_ = master.Close()
+		_ = tty.Close()
+		return nil, err
+	}
+
+	cmd.Stdin = tty
+	cmd.Stdout = tty
+	cmd.Stderr = tty
+	// a new session is also a new process group, so signals still reach children.
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true, Credential: credential}
+	return &terminal{master: master, tty: tty, copied: make(chan struct{})}, nil
+}
+
+// started releases the slave end held by execd once the command owns it, then
+// copies the terminal output to output and types stdin into the terminal.
+func (t *terminal) started(output io.Writer, stdin string) {
+	_ = t.tty.Close()
+	safego.Go(func() {
+		defer close(t.copied)
+		// reads fail with EIO once the last process holding the terminal exits.
+		_, _ = io.Copy(output, t.master)
+	})
+	if stdin != "" {
+		safego.Go(func() {
+			_, _ = io.WriteString(t.master, stdin)
+		})
+	}
+}
+
+// Close waits up to terminalDrainTimeout for the remaining output and releases the terminal.
+func (t *terminal) Close() {
+	select {
+	case <-t.copied:
+	case <-time.After(terminalDrainTimeout):
+	}
+	_ = t.master.Close()
+}
+
+// abort releases a terminal whose command failed to start.
+func (t *terminal) abort() {
+	_ = t.tty.Close()
+	_ = t.master.Close()
+}
@@ -16,6 +16,7 @@ package runtime
 
 import (
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/alibaba/opensandbox/execd/pkg/jupyter/execute"
@@ -30,17 +31,120 @@ type ExecuteResultHook struct {
 	OnExecuteStderr   func(stderr string) //nolint:predeclared
 	OnExecuteError    func(err *execute.ErrorOutput)
 	OnExecuteComplete func(executionTime time.Duration)
+	// OnCommandExit reports how a foreground command ended, after its last other event.
+	OnCommandExit func(status CommandExitStatus)
+	// OnExecuteProgress reports progress parsed from the output of a foreground
+	// command by its progress patterns, after the line it was parsed from.
+	OnExecuteProgress func(progress Progress)
+	// OnCommandStdout and OnCommandStderr, when set, receive the output lines of
+	// a foreground command instead of OnExecuteStdout and OnExecuteStderr,
+	// along with where each line sits in the log of its stream.
+	OnCommandStdout func(text string, position OutputPosition)
+	OnCommandStderr func(text string, position OutputPosition)
+}
+
+// OutputPosition locates an output line of a command in the log of its stream.
+type OutputPosition struct {
+	// Line numbers the lines of the stream from 1; empty lines are skipped.
+	Line int64
+	// Offset is the byte offset following the line, the cursor SeekCommandStream
+	// reads the output after the line from.
+	Offset int64
+}
+
+// CommandExitStatus describes how a foreground command ended.
+type CommandExitStatus struct {
+	ExitCode int
+	// Signal names the signal that killed the command, e.g. SIGKILL; empty when it exited.
+	Signal string
+	// successCodes are the exit codes besides zero that count as success.
+	successCodes []int
+}
+
+// Success reports whether the command exited with status zero or one of the
+// success exit codes of its request.
+func (s CommandExitStatus) Success() bool {
+	return s.Signal == "" && (s.ExitCode == 0 || slices.Contains(s.successCodes, s.ExitCode))
 }
 
 // ExecuteCodeRequest represents a code execution request with context and hooks.
 type ExecuteCodeRequest struct {
-	Language Language          `json:"language"`
-	Code     string            `json:"code"`
-	Context  string            `json:"context"`
-	Timeout  time.Duration     `json:"timeout"`
-	Cwd      string            `json:"cwd"`
-	Envs     map[string]string `json:"envs"`
-	Hooks    ExecuteResultHook
+	Language        Language          `json:"language"`
+	Code            string            `json:"code"`
+	Context         string            `json:"context"`
+	Timeout         time.Duration     `json:"timeout"`
+	Cwd             string            `json:"cwd"`
+	Envs            map[string]string `json:"envs"`
+	SeparateStreams bool              `json:"separate_streams"`
+	// User and Group, names or numeric ids, drop the privileges of shell commands.
+	User   string         `json:"user"`
+	Group  string         `json:"group"`
+	Limits ResourceLimits `json:"limits"`
+	// Shell runs shell commands, one of the Shell constants available on the
+	// platform; empty is bash, or cmd on Windows.
+	Shell string `json:"shell"`
+	// Stdin is fed to the standard input of a foreground shell command.
+	Stdin string `json:"stdin"`
+	// PTY, when set, runs a foreground shell command in a pseudo-terminal of that size.
+	PTY *TerminalSize `json:"pty"`
+	// Progress patterns turn output lines of a foreground shell command into progress.
+	Progress []ProgressPattern `json:"-"`
+	// SuccessExitCodes are the exit codes besides zero with which a foreground
+	// shell command completes rather than fails.
+	SuccessExitCodes []int `json:"success_exit_codes"`
+	// DisplayDataDir, when set, receives the large image and HTML payloads of
+	// kernel results as files, the results then referencing the files instead.
+	DisplayDataDir string `json:"display_data_dir"`
+	// SQLConnection names the registered connection SQL runs against, the
+	// default one when empty.
+	SQLConnection string `json:"sql_connection"`
+	Hooks         ExecuteResultHook
+
+	// quiet keeps the output of code run on a kernel out of its output buffer.
+	quiet bool
+}
+
+// Shells running shell commands. Only bash is available outside Windows, and
+// only the others on Windows.
+const (
+	ShellBash       = "bash"
+	ShellCmd        = "cmd"
+	ShellPowerShell = "powershell"
+	ShellPwsh       = "pwsh"
+)
+
+// TerminalSize is the window size of a pseudo-terminal; zero fields use 80x24.
+type TerminalSize struct {
+	Rows uint16 `json:"rows"`
+	Cols uint16 `json:"cols"`
+}
+
+// withDefaults fills the unset dimensions with those of a classic terminal.
+func (s TerminalSize) withDefaults() TerminalSize {
+	if s.Rows == 0 {
+		s.Rows = 24
+	}
+	if s.Cols == 0 {
+		s.Cols = 80
+	}
+	return s
+}
+
+// ResourceLimits caps the resources of a shell command; nil fields are unlimited.
+type ResourceLimits struct {
+	// AddressSpace is the maximum virtual memory size in bytes.
+	AddressSpace *uint64 `json:"address_space,omitempty"`
+	// CPUSeconds is the maximum CPU time in seconds.
+	CPUSeconds *uint64 `json:"cpu_seconds,omitempty"`
+	// OpenFiles is the maximum number of open file descriptors.
+	OpenFiles *uint64 `json:"open_files,omitempty"`
+	// CoreSize is the maximum core dump size in bytes; zero disables core dumps.
+	CoreSize *uint64 `json:"core_size,omitempty"`
+}
+
+// IsZero reports whether no limit is set.
+func (l ResourceLimits) IsZero() bool {
+	return l.AddressSpace == nil && l.CPUSeconds == nil && l.OpenFiles == nil && l.CoreSize == nil
 }
 
 // SetDefaultHooks installs stdout logging fallbacks for unset hooks.
@@ -68,15 +172,60 @@ func (req *ExecuteCodeRequest) SetDefaultHooks() {
 	if req.Hooks.OnExecuteInit == nil {
 		req.Hooks.OnExecuteInit = func(session string) { fmt.Printf("OnExecuteInit: %s\n", session) }
 	}
+	if req.Hooks.OnCommandExit == nil {
+		req.Hooks.OnCommandExit = func(status CommandExitStatus) { fmt.Printf("OnCommandExit: %+v\n", status) }
+	}
+	if req.Hooks.OnExecuteProgress == nil {
+		req.Hooks.OnExecuteProgress = func(progress Progress) { fmt.Printf("OnExecuteProgress: %+v\n", progress) }
+	}
 }
 
 // CreateContextRequest represents a stateful session creation request.
 type CreateContextRequest struct {
 	Language Language `json:"language"`
 	Cwd      string   `json:"cwd"`
+	// CreateCwd creates a missing Cwd, which fails the creation otherwise.
+	CreateCwd   bool `json:"create_cwd"`
+	EnvSnapshot bool `json:"env_snapshot"`
+	// Env is exported into the kernel before the preamble and Setup run,
+	// over the variables of the EXECD_ENVS file.
+	Env map[string]string `json:"env"`
+	// Setup lists code cells run in order once the context is created.
+	Setup []string `json:"setup"`
+	// KeepOnSetupFailure keeps the context when a setup cell raises, reporting
+	// the error in its Setup instead of failing the creation.
+	KeepOnSetupFailure bool `json:"keep_on_setup_failure"`
+	// IdempotencyKey deduplicates retried creations of the same context.
+	IdempotencyKey string `json:"-"`
 }
 
+// KernelStateDead is the ExecutionState of a context whose kernel is gone.
+const KernelStateDead = "dead"
+
 type CodeContext struct {
 	ID       string   `json:"id,omitempty"`
 	Language Language `json:"language"`
+	// Cwd is the absolute working directory of the context, unset when it
+	// runs in the directory of the Jupyter server.
+	Cwd string `json:"cwd,omitempty"`
+	// CwdCreated is set when Cwd was created for the context, which removes
+	// it once deleted if it is still empty.
+	CwdCreated  bool                 `json:"cwd_created,omitempty"`
+	Environment *EnvironmentSnapshot `json:"environment,omitempty"`
+	// Setup is set for contexts created with setup cells.
+	Setup *ContextSetup `json:"setup,omitempty"`
+	// Busy is set while code runs or waits to run in the context.
+	Busy bool `json:"busy"`
+	// LastUsedAt is when code last started or ended in the context, unset
+	// before any ran.
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	// ExecutionCount is the execution count of the last result the kernel
+	// returned, unset before any.
+	ExecutionCount int `json:"execution_count,omitempty"`
+	// ExecutionState, LastActivity and Connections describe the kernel as the
+	// Jupyter server reports it. ExecutionState is "dead" once the server
+	// no longer knows the kernel.
+	ExecutionState string     `json:"execution_state,omitempty"`
+	LastActivity   *time.Time `json:"last_activity,omitempty"`
+	Connections    int        `json:"connections,omitempty"`
 }
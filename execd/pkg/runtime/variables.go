@@ -0,0 +1,99 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/alibaba/opensandbox/execd/pkg/jupyter/execute"
+)
+
+const variablesExpression = "variables"
+
+// variablesProbes lists user expressions rendering the user namespace of a kernel
+// as a JSON list of [name, type] pairs in their text/plain representation.
+var variablesProbes = map[Language]string{
+	// %who_ls leaves out IPython's own names, such as In and Out, and private ones.
+	Python: "__import__('json').dumps([[n, type(get_ipython().user_ns[n]).__name__] " +
+		"for n in get_ipython().run_line_magic('who_ls', '')])",
+}
+
+// Variable is a name defined in the namespace of a context.
+type Variable struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// ListVariables returns the variables defined in the namespace of a context,
+// sorted by name.
+func (c *Controller) ListVariables(session string) ([]Variable, error) {
+	kernel := c.getJupyterKernel(session)
+	if kernel == nil {
+		return nil, ErrContextNotFound
+	}
+	probe, ok := variablesProbes[kernel.language]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrVariablesUnsupported, kernel.language)
+	}
+
+	// a kernel answers only once running code is done, see introspectionKernel.
+	if !kernel.tryLock() {
+		return nil, ErrSessionBusy
+	}
+	defer kernel.unlock()
+
+	err := kernel.client.ConnectToKernel(kernel.kernelID)
+	if err != nil {
+		return nil, err
+	}
+	defer kernel.client.DisconnectFromKernel(kernel.kernelID)
+
+	results, err := kernel.client.EvaluateExpressions(map[string]string{variablesExpression: probe}, introspectionTimeout)
+	if err != nil {
+		return nil, err
+	}
+	result, ok := results[variablesExpression]
+	if !ok {
+		return nil, errors.New("kernel did not evaluate the variables probe")
+	}
+	if result.Status != "ok" {
+		return nil, fmt.Errorf("variables probe failed: %s: %s", result.EName, result.EValue)
+	}
+	return parseVariables(result)
+}
+
+// parseVariables decodes the text/plain repr of the JSON string produced by a probe.
+func parseVariables(result execute.UserExpressionResult) ([]Variable, error) {
+	text, ok := result.Data["text/plain"].(string)
+	if !ok {
+		return nil, errors.New("no text/plain representation")
+	}
+	document, err := unquotePythonString(text)
+	if err != nil {
+		return nil, err
+	}
+
+	var pairs [][2]string
+	if err := json.Unmarshal([]byte(document), &pairs); err != nil {
+		return nil, fmt.Errorf("failed to parse variables: %w", err)
+	}
+	variables := make([]Variable, 0, len(pairs))
+	for _, pair := range pairs {
+		variables = append(variables, Variable{Name: pair[0], Type: pair[1]})
+	}
+	return variables, nil
+}
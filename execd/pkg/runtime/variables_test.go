@@ -0,0 +1,94 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/alibaba/opensandbox/execd/pkg/jupyter/execute"
+)
+
+func TestListVariables(t *testing.T) {
+	server := newMockJupyter(t, func(conn *websocket.Conn, msg *execute.Message) {
+		if execute.MessageType(msg.Header.MessageType) != execute.MsgExecuteRequest {
+			return
+		}
+		var req execute.ExecuteRequest
+		if err := json.Unmarshal(msg.Content, &req); err != nil {
+			t.Errorf("unmarshal execute request: %v", err)
+			return
+		}
+		if !req.Silent || !strings.Contains(req.UserExpressions[variablesExpression], "who_ls") {
+			t.Errorf("expected a silent who_ls probe, got %+v", req)
+		}
+		replyMessage(t, conn, msg, execute.MsgExecuteReply, execute.ExecuteReply{
+			Status: "ok",
+			UserExpressions: map[string]execute.UserExpressionResult{
+				variablesExpression: {Status: "ok", Data: map[string]interface{}{
+					"text/plain": `'[["df", "DataFrame"], ["it\'s", "int"], ["np", "module"]]'`,
+				}},
+			},
+		})
+	})
+	defer server.Close()
+
+	c := NewController(server.URL, "token")
+	id, err := c.CreateContext(context.Background(), &CreateContextRequest{Language: Python})
+	if err != nil {
+		t.Fatalf("CreateContext returned error: %v", err)
+	}
+
+	variables, err := c.ListVariables(id)
+	if err != nil {
+		t.Fatalf("ListVariables returned error: %v", err)
+	}
+	expected := []Variable{{Name: "df", Type: "DataFrame"}, {Name: "it's", Type: "int"}, {Name: "np", Type: "module"}}
+	if !reflect.DeepEqual(variables, expected) {
+		t.Fatalf("unexpected variables: %+v", variables)
+	}
+}
+
+func TestListVariables_Errors(t *testing.T) {
+	c := NewController("", "")
+	if _, err := c.ListVariables("missing"); !errors.Is(err, ErrContextNotFound) {
+		t.Fatalf("expected ErrContextNotFound, got %v", err)
+	}
+
+	c.storeJupyterKernel("js", &jupyterKernel{language: JavaScript})
+	if _, err := c.ListVariables("js"); !errors.Is(err, ErrVariablesUnsupported) {
+		t.Fatalf("expected ErrVariablesUnsupported, got %v", err)
+	}
+
+	busy := &jupyterKernel{language: Python}
+	busy.lock()
+	defer busy.unlock()
+	c.storeJupyterKernel("busy", busy)
+	if _, err := c.ListVariables("busy"); !errors.Is(err, ErrSessionBusy) {
+		t.Fatalf("expected ErrSessionBusy, got %v", err)
+	}
+}
+
+func TestParseVariables_Malformed(t *testing.T) {
+	if _, err := parseVariables(execute.UserExpressionResult{Status: "ok", Data: map[string]any{"text/plain": "'not json'"}}); err == nil {
+		t.Fatalf("expected malformed output to be rejected")
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// defaultCwdPerm is the permissions of working directories created on request.
+const defaultCwdPerm os.FileMode = 0o755
+
+// SetCwdPolicy restricts the working directories of commands and contexts to
+// root and its subdirectories, when root is set, and makes the working
+// directories created on request with perm.
+func (c *Controller) SetCwdPolicy(root string, perm os.FileMode) error {
+	if root != "" {
+		abs, err := filepath.Abs(root)
+		if err != nil {
+			return err
+		}
+		root = filepath.Clean(abs)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.cwdRoot = root
+	c.cwdPerm = perm
+	return nil
+}
+
+func (c *Controller) cwdPolicy() (string, os.FileMode) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.cwdPerm == 0 {
+		return c.cwdRoot, defaultCwdPerm
+	}
+	return c.cwdRoot, c.cwdPerm
+}
+
+// PrepareCwd checks that the working directory of a command is a directory
+// within the allowed root. A missing one fails with ErrCwdNotFound, unless
+// create is set: it is then created with its parents, and PrepareCwd reports
+// so. An empty cwd, the directory of execd, is always accepted.
+func (c *Controller) PrepareCwd(cwd string, create bool) (bool, error) {
+	if cwd == "" {
+		return false, nil
+	}
+	abs, missing, err := c.checkCwd(cwd, create)
+	if err != nil || !missing {
+		return false, err
+	}
+	_, perm := c.cwdPolicy()
+	if err := os.MkdirAll(abs, perm); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// checkCwd returns the absolute path of cwd and whether it is missing, which
+// is an error unless create is set. cwd must lie within the allowed root both
+// as written and with links resolved, so links can't lead out of the root; a
+// linked root is compared in each form with the matching form of cwd.
+func (c *Controller) checkCwd(cwd string, create bool) (string, bool, error) {
+	abs, err := filepath.Abs(cwd)
+	if err != nil {
+		return "", false, err
+	}
+	if root, _ := c.cwdPolicy(); root != "" {
+		resolved, err := resolveExisting(abs)
+		if err != nil {
+			return "", false, err
+		}
+		resolvedRoot, err := resolveExisting(root)
+		if err != nil {
+			return "", false, err
+		}
+		if !withinDir(root, abs) || !withinDir(resolvedRoot, resolved) {
+			return "", false, fmt.Errorf("%w: %s is not within %s", ErrCwdOutsideRoot, cwd, root)
+		}
+	}
+
+	info, err := os.Stat(abs)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		if !create {
+			return "", false, fmt.Errorf("%w: %s", ErrCwdNotFound, cwd)
+		}
+		return abs, true, nil
+	case err != nil:
+		return "", false, err
+	case !info.IsDir():
+		return "", false, fmt.Errorf("%w: %s", ErrCwdNotDirectory, cwd)
+	}
+	return abs, false, nil
+}
+
+// resolveExisting resolves the links of the longest existing ancestor of path
+// and appends the rest of path to it.
+func resolveExisting(path string) (string, error) {
+	var missing []string
+	for {
+		resolved, err := filepath.EvalSymlinks(path)
+		if err == nil {
+			return filepath.Join(append([]string{resolved}, missing...)...), nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return "", err
+		}
+		missing = append([]string{filepath.Base(path)}, missing...)
+		path = parent
+	}
+}
+
+// withinDir tells whether path is dir or lies below it; both are absolute.
+func withinDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,158 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	goruntime "runtime"
+	"testing"
+)
+
+func TestPrepareCwd(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file.txt")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	c := NewController("", "")
+	if err := c.SetCwdPolicy("", 0o700); err != nil {
+		t.Fatalf("SetCwdPolicy: %v", err)
+	}
+
+	if created, err := c.PrepareCwd("", false); created || err != nil {
+		t.Fatalf("expected the default cwd accepted, got %v, %v", created, err)
+	}
+	if created, err := c.PrepareCwd(dir, false); created || err != nil {
+		t.Fatalf("expected an existing cwd accepted, got %v, %v", created, err)
+	}
+	missing := filepath.Join(dir, "a", "b")
+	if _, err := c.PrepareCwd(missing, false); !errors.Is(err, ErrCwdNotFound) {
+		t.Fatalf("expected ErrCwdNotFound, got %v", err)
+	}
+	if _, err := c.PrepareCwd(file, true); !errors.Is(err, ErrCwdNotDirectory) {
+		t.Fatalf("expected ErrCwdNotDirectory, got %v", err)
+	}
+
+	created, err := c.PrepareCwd(missing, true)
+	if !created || err != nil {
+		t.Fatalf("expected the cwd created, got %v, %v", created, err)
+	}
+	info, err := os.Stat(missing)
+	if err != nil || !info.IsDir() {
+		t.Fatalf("expected %s to be a directory, got %v", missing, err)
+	}
+	if goruntime.GOOS != "windows" && info.Mode().Perm() != 0o700 {
+		t.Fatalf("expected mode 0700, got %v", info.Mode().Perm())
+	}
+}
+
+func TestPrepareCwd_AllowedRoot(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "root")
+	outside := filepath.Join(dir, "outside")
+	for _, d := range []string{root, outside} {
+		if err := os.Mkdir(d, 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+	}
+	c := NewController("", "")
+	if err := c.SetCwdPolicy(root, 0); err != nil {
+		t.Fatalf("SetCwdPolicy: %v", err)
+	}
+
+	if _, err := c.PrepareCwd(root, false); err != nil {
+		t.Fatalf("expected the root accepted, got %v", err)
+	}
+	if created, err := c.PrepareCwd(filepath.Join(root, "project", "src"), true); !created || err != nil {
+		t.Fatalf("expected a cwd below the root created, got %v, %v", created, err)
+	}
+	for _, cwd := range []string{outside, filepath.Join(root, "..", "outside"), root + "-sibling"} {
+		if _, err := c.PrepareCwd(cwd, true); !errors.Is(err, ErrCwdOutsideRoot) {
+			t.Fatalf("expected ErrCwdOutsideRoot for %s, got %v", cwd, err)
+		}
+	}
+
+	if goruntime.GOOS == "windows" {
+		return
+	}
+	link := filepath.Join(root, "escape")
+	if err := os.Symlink(outside, link); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+	for _, cwd := range []string{link, filepath.Join(link, "new")} {
+		if _, err := c.PrepareCwd(cwd, true); !errors.Is(err, ErrCwdOutsideRoot) {
+			t.Fatalf("expected a link out of the root rejected for %s, got %v", cwd, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(outside, "new")); !os.IsNotExist(err) {
+		t.Fatalf("expected nothing created outside the root, got %v", err)
+	}
+}
+
+func TestPrepareCwd_SymlinkedRoot(t *testing.T) {
+	if goruntime.GOOS == "windows" {
+		t.Skip("symlinks need extra privileges on windows")
+	}
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target")
+	outside := filepath.Join(dir, "outside")
+	for _, d := range []string{target, outside} {
+		if err := os.Mkdir(d, 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+	}
+	root := filepath.Join(dir, "root")
+	if err := os.Symlink(target, root); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+	if err := os.Symlink(outside, filepath.Join(target, "escape")); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+	c := NewController("", "")
+	if err := c.SetCwdPolicy(root, 0); err != nil {
+		t.Fatalf("SetCwdPolicy: %v", err)
+	}
+
+	if _, err := c.PrepareCwd(root, false); err != nil {
+		t.Fatalf("expected the linked root accepted, got %v", err)
+	}
+	if created, err := c.PrepareCwd(filepath.Join(root, "project"), true); !created || err != nil {
+		t.Fatalf("expected a cwd below the linked root created, got %v, %v", created, err)
+	}
+	if _, err := os.Stat(filepath.Join(target, "project")); err != nil {
+		t.Fatalf("expected the cwd created in the link target, got %v", err)
+	}
+	for _, cwd := range []string{outside, filepath.Join(root, "escape"), filepath.Join(root, "escape", "new")} {
+		if _, err := c.PrepareCwd(cwd, true); !errors.Is(err, ErrCwdOutsideRoot) {
+			t.Fatalf("expected ErrCwdOutsideRoot for %s, got %v", cwd, err)
+		}
+	}
+}
+
+func TestCreateContext_MissingCwdFailsUnlessCreated(t *testing.T) {
+	c := NewController("http://127.0.0.1:1", "token")
+	cwd := filepath.Join(t.TempDir(), "typo")
+
+	_, err := c.CreateContext(context.Background(), &CreateContextRequest{Language: Python, Cwd: cwd})
+	if !errors.Is(err, ErrCwdNotFound) {
+		t.Fatalf("expected ErrCwdNotFound, got %v", err)
+	}
+	if _, err := os.Stat(cwd); !os.IsNotExist(err) {
+		t.Fatalf("expected the cwd not to be created, got %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package glob
+
+import "path/filepath"
+
+// ExpandBraces expands the {a,b} alternatives of a pattern into the patterns
+// it is made of, in order, e.g. "{src,test}/*.{go,md}" into "src/*.go",
+// "src/*.md", "test/*.go" and "test/*.md". Nested braces are expanded too and
+// an empty alternative such as in "a{,b}" stands for the text around it.
+// Malformed patterns are rejected with the error of ValidatePattern.
+func ExpandBraces(pattern string) ([]string, error) {
+	if err := ValidatePattern(pattern); err != nil {
+		return nil, err
+	}
+	return expandBraces(pattern, filepath.Separator != '\\'), nil
+}
+
+// expandBraces expands the first alternative of a valid pattern, then the
+// rest of each resulting pattern.
+func expandBraces(pattern string, allowEscaping bool) []string {
+	openIdx := findUnescapedByteIndex(pattern, '{', allowEscaping)
+	if openIdx == -1 {
+		return []string{pattern}
+	}
+	closingIdx := openIdx + 1 + findMatchedClosingAltIndex(pattern[openIdx+1:], allowEscaping)
+	prefix, alts, suffix := pattern[:openIdx], pattern[openIdx+1:closingIdx], pattern[closingIdx+1:]
+
+	var expanded []string
+	for {
+		commaIdx := findNextCommaIndex(alts, allowEscaping)
+		if commaIdx == -1 {
+			break
+		}
+		expanded = append(expanded, expandBraces(prefix+alts[:commaIdx]+suffix, allowEscaping)...)
+		alts = alts[commaIdx+1:]
+	}
+	return append(expanded, expandBraces(prefix+alts+suffix, allowEscaping)...)
+}
@@ -0,0 +1,59 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package glob
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	globutil "github.com/bmatcuk/doublestar/v4"
+)
+
+func TestExpandBraces(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		expanded []string
+	}{
+		{"*.go", []string{"*.go"}},
+		{"{a,b}/*.go", []string{"a/*.go", "b/*.go"}},
+		{"{src,test}/*.{go,md}", []string{"src/*.go", "src/*.md", "test/*.go", "test/*.md"}},
+		{"{a,{b,c}d}e", []string{"ae", "bde", "cde"}},
+		{"x{a,{b,{c,d}}}", []string{"xa", "xb", "xc", "xd"}},
+		{"file{,.bak}", []string{"file", "file.bak"}},
+		{"{,a,}", []string{"", "a", ""}},
+		{"{}x", []string{"x"}},
+		{"\\{a,b\\}", []string{"\\{a,b\\}"}},
+		{"{a\\,b,c}", []string{"a\\,b", "c"}},
+	}
+	for _, tt := range tests {
+		expanded, err := ExpandBraces(tt.pattern)
+		if err != nil {
+			t.Errorf("ExpandBraces(%#q) error: %v", tt.pattern, err)
+			continue
+		}
+		if !reflect.DeepEqual(expanded, tt.expanded) {
+			t.Errorf("ExpandBraces(%#q) = %#q, want %#q", tt.pattern, expanded, tt.expanded)
+		}
+	}
+}
+
+func TestExpandBraces_RejectsMalformedPatterns(t *testing.T) {
+	for _, pattern := range []string{"{a,b", "a}", "{a,[b}"} {
+		if _, err := ExpandBraces(pattern); !errors.Is(err, globutil.ErrBadPattern) {
+			t.Errorf("ExpandBraces(%#q) error = %v, want ErrBadPattern", pattern, err)
+		}
+	}
+}
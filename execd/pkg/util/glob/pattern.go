@@ -14,6 +14,68 @@
 
 package glob
 
+import (
+	"fmt"
+	"path/filepath"
+
+	globutil "github.com/bmatcuk/doublestar/v4"
+)
+
+// ValidatePattern reports why a pattern PathMatch would reject is malformed,
+// wrapping ErrBadPattern, or nil when it is well-formed.
+func ValidatePattern(pattern string) error {
+	if isValidPattern(pattern, filepath.Separator) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", globutil.ErrBadPattern, describeInvalidPattern(pattern, filepath.Separator))
+}
+
+// describeInvalidPattern tells what isValidPattern rejects s for.
+func describeInvalidPattern(s string, separator rune) string {
+	var alts []int
+	l := len(s)
+	for i := 0; i < l; i++ {
+		switch s[i] {
+		case '\\':
+			if separator != '\\' {
+				if i++; i >= l {
+					return "trailing '\\' escapes nothing"
+				}
+			}
+
+		case '[':
+			start := i
+			if i++; i < l && (s[i] == '^' || s[i] == '!') {
+				i++
+			}
+			if i < l && s[i] == ']' {
+				return fmt.Sprintf("empty character class at offset %d", start)
+			}
+			for ; i < l && s[i] != ']'; i++ {
+				if separator != '\\' && s[i] == '\\' {
+					i++
+				}
+			}
+			if i >= l {
+				return fmt.Sprintf("unterminated character class at offset %d", start)
+			}
+
+		case '{':
+			alts = append(alts, i)
+
+		case '}':
+			if len(alts) == 0 {
+				return fmt.Sprintf("unmatched '}' at offset %d", i)
+			}
+			alts = alts[:len(alts)-1]
+		}
+	}
+	if len(alts) > 0 {
+		return fmt.Sprintf("unclosed '{' at offset %d", alts[len(alts)-1])
+	}
+	return "malformed pattern"
+}
+
 // isValidPattern checks whether a glob pattern is well-formed.
 //
 //nolint:gocognit
@@ -0,0 +1,64 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package glob
+
+import (
+	"errors"
+	"testing"
+
+	globutil "github.com/bmatcuk/doublestar/v4"
+)
+
+func TestValidatePattern_AcceptsSupportedSyntax(t *testing.T) {
+	for _, pattern := range []string{"*.go", "**/*.{go,md}", "src/**/test_[a-z]*.py", "[!.]*", "[^0-9]?", "{a,{b,c}}", "\\*literal"} {
+		if err := ValidatePattern(pattern); err != nil {
+			t.Errorf("ValidatePattern(%#q) = %v, want nil", pattern, err)
+		}
+	}
+}
+
+func TestValidatePattern_ExplainsMalformedPatterns(t *testing.T) {
+	tests := []struct {
+		pattern string
+		reason  string
+	}{
+		{"a[", "unterminated character class at offset 1"},
+		{"[]a]", "empty character class at offset 0"},
+		{"{a,b", "unclosed '{' at offset 0"},
+		{"a}", "unmatched '}' at offset 1"},
+		{"{a,{b}", "unclosed '{' at offset 0"},
+	}
+	for _, tt := range tests {
+		err := ValidatePattern(tt.pattern)
+		if !errors.Is(err, globutil.ErrBadPattern) {
+			t.Errorf("ValidatePattern(%#q) = %v, want ErrBadPattern", tt.pattern, err)
+			continue
+		}
+		if got := describeInvalidPattern(tt.pattern, '/'); got != tt.reason {
+			t.Errorf("describeInvalidPattern(%#q) = %q, want %q", tt.pattern, got, tt.reason)
+		}
+	}
+}
+
+func TestDescribeInvalidPattern_NamesEveryRejection(t *testing.T) {
+	for idx, tt := range matchTests {
+		if isValidPattern(tt.pattern, '/') {
+			continue
+		}
+		if reason := describeInvalidPattern(tt.pattern, '/'); reason == "malformed pattern" {
+			t.Errorf("#%v. no reason found for %#q", idx, tt.pattern)
+		}
+	}
+}
@@ -16,13 +16,47 @@ package safego
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"net/http"
 	"runtime"
+	"sync"
+	"sync/atomic"
 
 	runtimeutil "k8s.io/apimachinery/pkg/util/runtime"
 )
 
+var (
+	panics atomic.Uint64
+
+	hooksMu sync.RWMutex
+	hooks   []func(recovered any, stack []byte)
+)
+
+// PanicError is the cause GoWithContext cancels its context with when the
+// goroutine panics.
+type PanicError struct {
+	Recovered any
+	Stack     []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("goroutine panicked: %v", e.Recovered)
+}
+
+// OnPanic registers hook to run whenever a goroutine started by this package
+// panics. Hooks run on the panicking goroutine, after the panic is logged.
+func OnPanic(hook func(recovered any, stack []byte)) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	hooks = append(hooks, hook)
+}
+
+// PanicCount returns the number of panics recovered since startup.
+func PanicCount() uint64 {
+	return panics.Load()
+}
+
 func InitPanicLogger(_ context.Context) {
 	runtimeutil.PanicHandlers = []func(context.Context, any){
 		func(_ context.Context, r any) {
@@ -48,8 +82,53 @@ func init() {
 
 func Go(f func()) {
 	go func() {
-		defer runtimeutil.HandleCrash()
+		defer runtimeutil.HandleCrash(handlePanic(nil))
 
 		f()
 	}()
 }
+
+// GoWithContext runs f like Go, and cancels ctx with a *PanicError when f
+// panics so whoever waits on ctx learns the goroutine died.
+func GoWithContext(ctx context.Context, cancel context.CancelCauseFunc, f func(ctx context.Context)) {
+	go func() {
+		defer runtimeutil.HandleCrash(handlePanic(func(err *PanicError) { cancel(err) }))
+
+		f(ctx)
+	}()
+}
+
+// handlePanic counts a recovered panic and passes it to the registered hooks
+// and then to onPanic.
+func handlePanic(onPanic func(*PanicError)) func(any) {
+	return func(r any) {
+		if r == http.ErrAbortHandler { // nolint:errorlint
+			return
+		}
+
+		const size = 64 << 10
+		stacktrace := make([]byte, size)
+		err := &PanicError{Recovered: r, Stack: stacktrace[:runtime.Stack(stacktrace, false)]}
+
+		panics.Add(1)
+		hooksMu.RLock()
+		registered := hooks
+		hooksMu.RUnlock()
+		for _, hook := range registered {
+			runHook(hook, err)
+		}
+		if onPanic != nil {
+			onPanic(err)
+		}
+	}
+}
+
+// runHook keeps a panicking hook from crashing the process.
+func runHook(hook func(any, []byte), err *PanicError) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("panic hook panicked: %v", r)
+		}
+	}()
+	hook(err.Recovered, err.Stack)
+}
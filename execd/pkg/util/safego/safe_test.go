@@ -16,8 +16,11 @@ package safego
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 )
 
 func Test_Go(t *testing.T) {
@@ -34,3 +37,52 @@ func Test_Go(t *testing.T) {
 	})
 	wg.Wait()
 }
+
+func Test_OnPanicCountsAndNotifies(t *testing.T) {
+	type observed struct {
+		recovered any
+		stack     string
+	}
+	seen := make(chan observed, 1)
+	OnPanic(func(recovered any, stack []byte) {
+		if recovered == "counted" {
+			seen <- observed{recovered, string(stack)}
+		}
+	})
+	// a panicking hook must not take the process down.
+	OnPanic(func(any, []byte) { panic("hook") })
+
+	before := PanicCount()
+	Go(func() { panic("counted") })
+
+	select {
+	case got := <-seen:
+		if !strings.Contains(got.stack, "safe_test.go") {
+			t.Fatalf("stack does not point at the panicking goroutine:\n%s", got.stack)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("panic hook was not called")
+	}
+	if PanicCount() <= before {
+		t.Fatalf("panic count did not increase from %d", before)
+	}
+}
+
+func Test_GoWithContextCancelsOnPanic(t *testing.T) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+
+	GoWithContext(ctx, cancel, func(context.Context) {
+		panic("tailer died")
+	})
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatalf("context was not cancelled")
+	}
+	var panicErr *PanicError
+	if !errors.As(context.Cause(ctx), &panicErr) || panicErr.Recovered != "tailer died" {
+		t.Fatalf("unexpected cause: %v", context.Cause(ctx))
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package version describes the running execd build.
+package version
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"time"
+)
+
+// Set at build time, e.g.
+//
+//	go build -ldflags "-X github.com/alibaba/opensandbox/execd/pkg/version.Version=v1.2.3"
+//
+// Empty values fall back to the module and VCS data embedded by the go tool.
+var (
+	Version   string
+	GitCommit string
+	BuildDate string
+)
+
+var startTime = time.Now()
+
+// Info identifies a build and the platform it runs on.
+type Info struct {
+	Version   string
+	GitCommit string
+	BuildDate string
+	GoVersion string
+	OS        string
+	Arch      string
+}
+
+// Get returns the build info of the running binary.
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		GitCommit: GitCommit,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+	}
+	if build, ok := debug.ReadBuildInfo(); ok {
+		if info.Version == "" && build.Main.Version != "(devel)" {
+			info.Version = build.Main.Version
+		}
+		for _, setting := range build.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && info.GitCommit == "":
+				info.GitCommit = setting.Value
+			case setting.Key == "vcs.time" && info.BuildDate == "":
+				info.BuildDate = setting.Value
+			}
+		}
+	}
+	if info.Version == "" {
+		info.Version = "dev"
+	}
+	if info.GitCommit == "" {
+		info.GitCommit = "unknown"
+	}
+	if info.BuildDate == "" {
+		info.BuildDate = "unknown"
+	}
+	return info
+}
+
+// String formats the info as printed by --version.
+func (i Info) String() string {
+	return fmt.Sprintf("execd %s (commit %s, built %s, %s %s/%s)", i.Version, i.GitCommit, i.BuildDate, i.GoVersion, i.OS, i.Arch)
+}
+
+// StartTime returns when the process started.
+func StartTime() time.Time {
+	return startTime
+}
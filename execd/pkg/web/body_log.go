@@ -0,0 +1,99 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/alibaba/opensandbox/execd/pkg/log"
+)
+
+// bodyLogMiddleware logs request and response bodies, redacted and capped at
+// limit bytes each. Routes opt in by listing it, see registerRoutes.
+func bodyLogMiddleware(limit int, logf func(format string, args ...any)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ctx.Request.Body != nil && ctx.Request.Body != http.NoBody {
+			head, err := io.ReadAll(io.LimitReader(ctx.Request.Body, int64(limit)+1))
+			ctx.Request.Body = readCloser{io.MultiReader(bytes.NewReader(head), ctx.Request.Body), ctx.Request.Body}
+			if err == nil && len(head) > 0 {
+				logf("Request body: %v %v %s", ctx.Request.Method, ctx.Request.URL.Path, redactBody(head, limit))
+			}
+		}
+
+		writer := &bodyLogWriter{ResponseWriter: ctx.Writer, limit: limit}
+		ctx.Writer = writer
+		ctx.Next()
+
+		if writer.body.Len() > 0 && !strings.HasPrefix(writer.Header().Get("Content-Type"), "text/event-stream") {
+			logf("Response body: %v %v %d %s", ctx.Request.Method, ctx.Request.URL.Path, writer.Status(), redactBody(writer.body.Bytes(), limit))
+		}
+	}
+}
+
+// redactBody masks secret JSON fields and marks bodies longer than limit as truncated.
+func redactBody(body []byte, limit int) string {
+	truncated := len(body) > limit
+	if truncated {
+		body = body[:limit]
+	}
+	text := log.RedactSecretFields(string(body))
+	if truncated {
+		text += "...(truncated)"
+	}
+	return text
+}
+
+// readCloser replays the bytes read for logging before the rest of the original body.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// bodyLogWriter keeps the first limit bytes of a response.
+type bodyLogWriter struct {
+	gin.ResponseWriter
+	limit int
+	body  bytes.Buffer
+}
+
+func (w *bodyLogWriter) Write(data []byte) (int, error) {
+	w.capture(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *bodyLogWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+// capture keeps one byte beyond limit so redactBody can tell the body was truncated.
+func (w *bodyLogWriter) capture(data []byte) {
+	if room := w.limit + 1 - w.body.Len(); room > 0 {
+		if len(data) > room {
+			data = data[:room]
+		}
+		w.body.Write(data)
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, e.g. for write deadlines.
+func (w *bodyLogWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
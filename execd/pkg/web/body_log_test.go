@@ -0,0 +1,86 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newBodyLogEngine serves an echo handler behind bodyLogMiddleware and records what it logs.
+func newBodyLogEngine(limit int) (*gin.Engine, *[]string) {
+	gin.SetMode(gin.TestMode)
+	var logged []string
+	r := gin.New()
+	logBody := bodyLogMiddleware(limit, func(format string, args ...any) {
+		logged = append(logged, fmt.Sprintf(format, args...))
+	})
+	echo := func(ctx *gin.Context) {
+		body, _ := io.ReadAll(ctx.Request.Body)
+		ctx.Data(http.StatusOK, "application/json", body)
+	}
+	r.POST("/files/replace", logBody, echo)
+	return r, &logged
+}
+
+func TestBodyLogMiddlewareLogsRedactedBodies(t *testing.T) {
+	r, logged := newBodyLogEngine(4096)
+	body := `{"path":"/tmp/a","envs":{"API_TOKEN":"abc123"},"password":"hunter2"}`
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/files/replace", strings.NewReader(body)))
+
+	if w.Body.String() != body {
+		t.Fatalf("handler must still receive the full body, got %q", w.Body.String())
+	}
+	if len(*logged) != 2 {
+		t.Fatalf("expected request and response bodies to be logged, got %q", *logged)
+	}
+	want := `{"path":"/tmp/a","envs":{"API_TOKEN":"[REDACTED]"},"password":"[REDACTED]"}`
+	if !strings.HasPrefix((*logged)[0], "Request body:") || !strings.HasSuffix((*logged)[0], want) {
+		t.Fatalf("unexpected request log %q", (*logged)[0])
+	}
+	if !strings.HasPrefix((*logged)[1], "Response body:") || !strings.HasSuffix((*logged)[1], want) {
+		t.Fatalf("unexpected response log %q", (*logged)[1])
+	}
+	for _, line := range *logged {
+		if strings.Contains(line, "abc123") || strings.Contains(line, "hunter2") {
+			t.Fatalf("secret leaked into log %q", line)
+		}
+	}
+}
+
+func TestBodyLogMiddlewareCapsBodies(t *testing.T) {
+	r, logged := newBodyLogEngine(8)
+	body := `{"path":"/a/long/path"}`
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/files/replace", strings.NewReader(body)))
+
+	if w.Body.String() != body {
+		t.Fatalf("handler must still receive the full body, got %q", w.Body.String())
+	}
+	for _, line := range *logged {
+		if !strings.HasSuffix(line, `{"path":...(truncated)`) {
+			t.Fatalf("expected capped body, got %q", line)
+		}
+	}
+}
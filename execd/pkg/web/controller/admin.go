@@ -0,0 +1,121 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/alibaba/opensandbox/execd/pkg/flag"
+	"github.com/alibaba/opensandbox/execd/pkg/log"
+	"github.com/alibaba/opensandbox/execd/pkg/web/model"
+)
+
+// AdminController handles runtime administration of execd itself.
+type AdminController struct {
+	*basicController
+}
+
+func NewAdminController(ctx *gin.Context) *AdminController {
+	return &AdminController{basicController: newBasicController(ctx)}
+}
+
+// Authorize answers 403 unless the request carries the admin token, if one is
+// configured, and reports whether the handler may go on.
+func (c *AdminController) Authorize() bool {
+	if flag.ServerAdminToken == "" || c.isAdmin() {
+		return true
+	}
+	c.RespondError(
+		http.StatusForbidden,
+		model.ErrorCodeAdminForbidden,
+		"admin routes require the admin token in "+model.AdminTokenHeader,
+	)
+	return false
+}
+
+// GetLogLevel returns the current log level of execd and of its components.
+func (c *AdminController) GetLogLevel() {
+	c.RespondSuccess(currentLogLevel())
+}
+
+// GetConfig returns the effective configuration keyed by flag name, with
+// secrets masked like --print-config does.
+func (c *AdminController) GetConfig() {
+	c.RespondSuccess(flag.Effective())
+}
+
+// SetLogLevel changes the default or a component's log level without a
+// restart, reverting after the requested duration if one is given.
+func (c *AdminController) SetLogLevel() {
+	var request model.LogLevelRequest
+	if err := c.bindJSON(&request); err != nil {
+		c.RespondError(
+			http.StatusBadRequest,
+			model.ErrorCodeInvalidRequest,
+			fmt.Sprintf("error parsing request, MAYBE invalid body format. %v", err),
+		)
+		return
+	}
+	if err := request.Validate(); err != nil {
+		c.RespondValidationError(err)
+		return
+	}
+
+	var duration time.Duration
+	if request.Duration != "" {
+		duration, _ = time.ParseDuration(request.Duration)
+	}
+	component := request.Component
+	if component == "" {
+		component = log.DefaultComponent
+	}
+	target, ok := log.Lookup(component)
+	if !ok {
+		c.RespondError(http.StatusBadRequest, model.ErrorCodeInvalidRequest, fmt.Sprintf("unknown log component %q", component))
+		return
+	}
+	if err := target.SetLevelName(request.Level, duration); err != nil {
+		c.RespondError(http.StatusBadRequest, model.ErrorCodeInvalidRequest, err.Error())
+		return
+	}
+	logger.Info("log level of %s set to %s by %s (duration: %s)", component, request.Level, c.ctx.ClientIP(), duration)
+
+	c.RespondSuccess(currentLogLevel())
+}
+
+func currentLogLevel() model.LogLevel {
+	level := model.LogLevel{Level: log.Level()}
+	if revertAt := log.RevertAt(); !revertAt.IsZero() {
+		level.RevertAt = &revertAt
+	}
+	for _, l := range log.Components() {
+		if l.Name() == log.DefaultComponent {
+			continue
+		}
+		if level.Components == nil {
+			level.Components = make(map[string]model.ComponentLogLevel)
+		}
+		component := model.ComponentLogLevel{Level: l.Level(), Inherited: l.Inherited()}
+		if revertAt := l.RevertAt(); !revertAt.IsZero() {
+			component.RevertAt = &revertAt
+		}
+		level.Components[l.Name()] = component
+	}
+	return level
+}
@@ -0,0 +1,82 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/alibaba/opensandbox/execd/pkg/log"
+	"github.com/alibaba/opensandbox/execd/pkg/web/model"
+)
+
+func TestAdminControllerSetLogLevel(t *testing.T) {
+	t.Cleanup(func() { _ = log.SetLevelName("info", 0) })
+
+	ctx, rec := newTestContext(http.MethodPut, "/admin/loglevel", []byte(`{"level":"debug","duration":"10m"}`))
+	NewAdminController(ctx).SetLogLevel()
+
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var level model.LogLevel
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &level))
+	assert.Equal(t, "debug", level.Level)
+	assert.NotNil(t, level.RevertAt)
+	assert.Equal(t, "debug", log.Level())
+
+	ctx, rec = newTestContext(http.MethodGet, "/admin/loglevel", nil)
+	NewAdminController(ctx).GetLogLevel()
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &level))
+	assert.Equal(t, "debug", level.Level)
+}
+
+func TestAdminControllerSetLogLevelRejectsInvalidInput(t *testing.T) {
+	t.Cleanup(func() { _ = log.SetLevelName("info", 0) })
+	_ = log.SetLevelName("info", 0)
+
+	for _, body := range []string{`{"level":"verbose"}`, `{"level":"debug","duration":"soon"}`, `{}`} {
+		ctx, rec := newTestContext(http.MethodPut, "/admin/loglevel", []byte(body))
+		NewAdminController(ctx).SetLogLevel()
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code, body)
+		assert.Equal(t, "info", log.Level(), body)
+	}
+}
+
+func TestAdminControllerSetComponentLogLevel(t *testing.T) {
+	t.Cleanup(func() {
+		_ = log.SetLevelName("info", 0)
+		_ = log.SetLevels("controller=info")
+	})
+	_ = log.SetLevelName("info", 0)
+
+	ctx, rec := newTestContext(http.MethodPut, "/admin/loglevel", []byte(`{"level":"debug","component":"controller"}`))
+	NewAdminController(ctx).SetLogLevel()
+
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var level model.LogLevel
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &level))
+	assert.Equal(t, "info", level.Level)
+	assert.Equal(t, model.ComponentLogLevel{Level: "debug"}, level.Components["controller"])
+	assert.Equal(t, "info", log.Level())
+
+	ctx, rec = newTestContext(http.MethodPut, "/admin/loglevel", []byte(`{"level":"debug","component":"nope"}`))
+	NewAdminController(ctx).SetLogLevel()
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "unknown log component")
+}
@@ -0,0 +1,198 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/alibaba/opensandbox/execd/pkg/web/model"
+)
+
+const (
+	// artifactMaxDepth bounds how deep below the working directory files are tracked.
+	artifactMaxDepth = 4
+	// artifactMaxEntries skips tracking directories too large to stat cheaply.
+	artifactMaxEntries = 10000
+)
+
+var errTooManyEntries = errors.New("too many entries")
+
+// fileStamp is what a snapshot compares files by.
+type fileStamp struct {
+	size    int64
+	modTime time.Time
+}
+
+// artifactTracker finds the files created or modified in a directory while
+// code runs, from a snapshot taken before the run.
+type artifactTracker struct {
+	dir     string
+	before  map[string]fileStamp
+	skipped string
+}
+
+// newArtifactTracker snapshots dir; an empty dir is reported as skipped.
+func newArtifactTracker(dir string) *artifactTracker {
+	tracker := &artifactTracker{dir: dir}
+	if dir == "" {
+		tracker.skipped = "the working directory is unknown"
+		return tracker
+	}
+	tracker.before, tracker.skipped = snapshotDir(dir)
+	return tracker
+}
+
+// artifacts compares dir to its snapshot.
+func (t *artifactTracker) artifacts() *model.Artifacts {
+	result := &model.Artifacts{
+		Dir:      t.dir,
+		Created:  []model.FileInfo{},
+		Modified: []model.FileInfo{},
+		Skipped:  t.skipped,
+	}
+	if t.skipped != "" {
+		return result
+	}
+
+	after, skipped := snapshotDir(t.dir)
+	if skipped != "" {
+		result.Skipped = skipped
+		return result
+	}
+
+	paths := make([]string, 0, len(after))
+	for path := range after {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+	for _, path := range paths {
+		stamp := after[path]
+		before, existed := t.before[path]
+		if existed && before == stamp {
+			continue
+		}
+		info, err := GetFileInfo(path)
+		if err != nil {
+			// removed again since the snapshot.
+			continue
+		}
+		if existed {
+			result.Modified = append(result.Modified, info)
+		} else {
+			result.Created = append(result.Created, info)
+		}
+	}
+	return result
+}
+
+// snapshotDir stats the regular files of dir up to artifactMaxDepth levels
+// down, or tells why it didn't. Unreadable subdirectories are left out.
+func snapshotDir(dir string) (map[string]fileStamp, string) {
+	files := make(map[string]fileStamp)
+	entries := 0
+
+	var walk func(path string, depth int) error
+	walk = func(path string, depth int) error {
+		dirEntries, err := os.ReadDir(path)
+		if err != nil {
+			return err
+		}
+		for _, entry := range dirEntries {
+			entries++
+			if entries > artifactMaxEntries {
+				return errTooManyEntries
+			}
+
+			entryPath := filepath.Join(path, entry.Name())
+			if entry.IsDir() {
+				if depth < artifactMaxDepth {
+					if err := walk(entryPath, depth+1); errors.Is(err, errTooManyEntries) {
+						return err
+					}
+				}
+				continue
+			}
+			if !entry.Type().IsRegular() {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			files[entryPath] = fileStamp{size: info.Size(), modTime: info.ModTime()}
+		}
+		return nil
+	}
+
+	if err := walk(dir, 1); err != nil {
+		if errors.Is(err, errTooManyEntries) {
+			return nil, fmt.Sprintf("the working directory holds more than %d entries", artifactMaxEntries)
+		}
+		return nil, fmt.Sprintf("error reading the working directory. %v", err)
+	}
+	return files, ""
+}
+
+// codeArtifactTracker snapshots the working directory of the context code
+// runs in, nil when the request doesn't track artifacts.
+func codeArtifactTracker(request model.RunCodeRequest) *artifactTracker {
+	if !request.TrackArtifacts {
+		return nil
+	}
+	cwd := ""
+	if request.Context.ID != "" {
+		if codeContext, err := codeRunner.GetContext(request.Context.ID, false); err == nil {
+			cwd = codeContext.Cwd
+		}
+	}
+	return newArtifactTracker(cwd)
+}
+
+// commandArtifactTracker snapshots the working directory of a command, nil
+// when the request doesn't track artifacts.
+func commandArtifactTracker(request model.RunCommandRequest) *artifactTracker {
+	if !request.TrackArtifacts {
+		return nil
+	}
+	cwd := request.Cwd
+	if cwd == "" {
+		cwd, _ = os.Getwd()
+	}
+	if cwd != "" {
+		if abs, err := filepath.Abs(cwd); err == nil {
+			cwd = abs
+		}
+	}
+	return newArtifactTracker(cwd)
+}
+
+// writeArtifactsEvent ends the stream of a run tracking artifacts with the
+// files it created or modified.
+func (c *CodeInterpretingController) writeArtifactsEvent(tracker *artifactTracker) {
+	if tracker == nil {
+		return
+	}
+	payload := c.eventPayload(model.ServerStreamEvent{
+		Type:      model.StreamEventTypeArtifacts,
+		Artifacts: tracker.artifacts(),
+		Timestamp: time.Now().UnixMilli(),
+	})
+	c.writeSingleEvent("OnArtifacts", payload, true)
+}
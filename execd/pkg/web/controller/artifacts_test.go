@@ -0,0 +1,114 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	goruntime "runtime"
+	"strings"
+	"testing"
+
+	"github.com/alibaba/opensandbox/execd/pkg/flag"
+	"github.com/alibaba/opensandbox/execd/pkg/runtime"
+	"github.com/alibaba/opensandbox/execd/pkg/web/model"
+)
+
+func TestArtifactTracker_ReportsCreatedAndModifiedFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "kept.txt"), []byte("kept"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "data.csv"), []byte("a"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tracker := newArtifactTracker(dir)
+	if err := os.WriteFile(filepath.Join(dir, "data.csv"), []byte("a,b"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "plots"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "plots", "fig.png"), []byte("png"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	artifacts := tracker.artifacts()
+	if artifacts.Skipped != "" {
+		t.Fatalf("unexpected skip: %s", artifacts.Skipped)
+	}
+	if len(artifacts.Created) != 1 || artifacts.Created[0].Path != filepath.Join(dir, "plots", "fig.png") {
+		t.Fatalf("unexpected created files %+v", artifacts.Created)
+	}
+	if len(artifacts.Modified) != 1 || artifacts.Modified[0].Path != filepath.Join(dir, "data.csv") || artifacts.Modified[0].Size != 3 {
+		t.Fatalf("unexpected modified files %+v", artifacts.Modified)
+	}
+}
+
+func TestArtifactTracker_SkipsLargeDirectories(t *testing.T) {
+	dir := t.TempDir()
+	for i := 0; i <= artifactMaxEntries; i++ {
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%d", i)), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	artifacts := newArtifactTracker(dir).artifacts()
+	if !strings.Contains(artifacts.Skipped, "more than") || len(artifacts.Created) != 0 {
+		t.Fatalf("expected the directory to be skipped, got %+v", artifacts)
+	}
+	if newArtifactTracker("").artifacts().Skipped == "" {
+		t.Fatal("expected an unknown directory to be skipped")
+	}
+}
+
+func TestRunCommand_SummaryListsArtifacts(t *testing.T) {
+	if goruntime.GOOS == "windows" {
+		t.Skip("bash not available on windows")
+	}
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not found in PATH")
+	}
+	originalRunner, originalGrace := codeRunner, flag.ApiGracefulShutdownTimeout
+	previous := [...]int64{flag.CommandMaxAddressSpace, flag.CommandMaxCPUSeconds, flag.CommandMaxOpenFiles, flag.CommandMaxCoreSize}
+	defer func() {
+		codeRunner, flag.ApiGracefulShutdownTimeout = originalRunner, originalGrace
+		flag.CommandMaxAddressSpace, flag.CommandMaxCPUSeconds, flag.CommandMaxOpenFiles, flag.CommandMaxCoreSize = previous[0], previous[1], previous[2], previous[3]
+	}()
+	codeRunner = runtime.NewController("", "")
+	flag.ApiGracefulShutdownTimeout = 0
+	flag.CommandMaxAddressSpace, flag.CommandMaxCPUSeconds, flag.CommandMaxOpenFiles, flag.CommandMaxCoreSize = -1, -1, -1, -1
+
+	dir := t.TempDir()
+	body, _ := json.Marshal(model.RunCommandRequest{Command: "echo 1,2 > out.csv", Cwd: dir, TrackArtifacts: true})
+	ctx, w := newTestContext(http.MethodPost, "/command?stream=false", body)
+	NewCodeInterpretingController(ctx).RunCommand()
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var summary model.ExecutionSummary
+	if err := json.Unmarshal(w.Body.Bytes(), &summary); err != nil {
+		t.Fatalf("invalid summary: %v", err)
+	}
+	if summary.Artifacts == nil || len(summary.Artifacts.Created) != 1 || summary.Artifacts.Created[0].Path != filepath.Join(dir, "out.csv") {
+		t.Fatalf("expected out.csv to be reported, got %+v", summary.Artifacts)
+	}
+}
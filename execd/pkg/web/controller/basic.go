@@ -15,15 +15,23 @@
 package controller
 
 import (
-	"encoding/json"
+	"crypto/subtle"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/alibaba/opensandbox/execd/pkg/flag"
+	"github.com/alibaba/opensandbox/execd/pkg/log"
 	"github.com/alibaba/opensandbox/execd/pkg/web/model"
 )
 
+// logger logs for the HTTP handlers.
+var logger = log.For("controller")
+
 type basicController struct {
 	ctx *gin.Context
 }
@@ -43,6 +51,20 @@ func (c *basicController) RespondError(status int, code model.ErrorCode, message
 	c.ctx.JSON(status, resp)
 }
 
+// RespondValidationError answers a failed request validation with a 400, listing
+// the offending fields when the error carries them.
+func (c *basicController) RespondValidationError(err error) {
+	resp := model.ErrorResponse{
+		Code:    model.ErrorCodeInvalidRequest,
+		Message: fmt.Sprintf("invalid request, validation error %v", err),
+	}
+	var validationErr *model.ValidationError
+	if errors.As(err, &validationErr) {
+		resp.Errors = validationErr.Fields
+	}
+	c.ctx.JSON(http.StatusBadRequest, resp)
+}
+
 func (c *basicController) RespondSuccess(data any) {
 	if data == nil {
 		c.ctx.Status(http.StatusOK)
@@ -59,7 +81,36 @@ func (c *basicController) QueryInt64(query string, defaultValue int64) int64 {
 	return val
 }
 
-func (c *basicController) bindJSON(target any) error {
-	decoder := json.NewDecoder(c.ctx.Request.Body)
-	return decoder.Decode(target)
+// isAdmin reports whether the request carries the configured admin token.
+func (c *basicController) isAdmin() bool {
+	token := c.ctx.GetHeader(model.AdminTokenHeader)
+	return flag.ServerAdminToken != "" &&
+		subtle.ConstantTimeCompare([]byte(token), []byte(flag.ServerAdminToken)) == 1
+}
+
+// clearWriteDeadline removes the per-response write deadline for long-lived responses.
+func (c *basicController) clearWriteDeadline() {
+	rc := http.NewResponseController(c.ctx.Writer)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		logger.Warning("failed to clear write deadline: %v", err)
+	}
+}
+
+// apiVersion returns the API version the client asked for so handlers can
+// branch response shapes. Versioned routes win; legacy routes may opt into a
+// newer shape via the version header.
+func (c *basicController) apiVersion() string {
+	version := c.ctx.GetString(model.APIVersionContextKey)
+	if version == model.APIVersionV1 {
+		return version
+	}
+
+	switch requested := c.ctx.GetHeader(model.ApiVersionHeader); requested {
+	case model.APIVersionLegacy, model.APIVersionV1:
+		return requested
+	}
+	if version == "" {
+		return model.APIVersionLegacy
+	}
+	return version
 }
@@ -128,3 +128,22 @@ func TestQueryInt64(t *testing.T) {
 		})
 	}
 }
+
+func TestAPIVersion(t *testing.T) {
+	ctrl, _ := setupBasicController(http.MethodGet)
+	if got := ctrl.apiVersion(); got != model.APIVersionLegacy {
+		t.Fatalf("expected legacy version by default, got %s", got)
+	}
+
+	ctrl.ctx.Request.Header.Set(model.ApiVersionHeader, model.APIVersionV1)
+	if got := ctrl.apiVersion(); got != model.APIVersionV1 {
+		t.Fatalf("expected header to select v1, got %s", got)
+	}
+
+	ctrl, _ = setupBasicController(http.MethodGet)
+	ctrl.ctx.Set(model.APIVersionContextKey, model.APIVersionV1)
+	ctrl.ctx.Request.Header.Set(model.ApiVersionHeader, model.APIVersionLegacy)
+	if got := ctrl.apiVersion(); got != model.APIVersionV1 {
+		t.Fatalf("expected versioned route to win over header, got %s", got)
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/alibaba/opensandbox/execd/pkg/flag"
+	"github.com/alibaba/opensandbox/execd/pkg/web/model"
+)
+
+// UnknownFieldsError reports request body fields that don't map to the target.
+type UnknownFieldsError struct {
+	Fields []string
+}
+
+func (e *UnknownFieldsError) Error() string {
+	quoted := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		quoted = append(quoted, strconv.Quote(field))
+	}
+	return "unknown fields: " + strings.Join(quoted, ", ")
+}
+
+func (c *basicController) bindJSON(target any) error {
+	if !c.strictBinding() {
+		decoder := json.NewDecoder(c.ctx.Request.Body)
+		return decoder.Decode(target)
+	}
+
+	body, err := io.ReadAll(c.ctx.Request.Body)
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(target); err != nil {
+		if fields := unknownJSONFields(body, reflect.TypeOf(target)); len(fields) > 0 {
+			return &UnknownFieldsError{Fields: fields}
+		}
+		return err
+	}
+	return nil
+}
+
+// strictBinding reports whether unknown fields should be rejected for this request.
+func (c *basicController) strictBinding() bool {
+	if header := c.ctx.GetHeader(model.StrictValidationHeader); header != "" {
+		strict, err := strconv.ParseBool(header)
+		return err == nil && strict
+	}
+	return flag.ServerStrictJSON
+}
+
+// unknownJSONFields lists every field path in body that has no counterpart in typ.
+func unknownJSONFields(body []byte, typ reflect.Type) []string {
+	var raw any
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil
+	}
+
+	var fields []string
+	collectUnknownFields(raw, typ, "", &fields)
+	sort.Strings(fields)
+	return fields
+}
+
+func collectUnknownFields(value any, typ reflect.Type, prefix string, fields *[]string) {
+	for typ != nil && typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if typ == nil {
+		return
+	}
+
+	switch typ.Kind() {
+	case reflect.Struct:
+		object, ok := value.(map[string]any)
+		if !ok {
+			return
+		}
+		known := jsonFieldTypes(typ)
+		for key, v := range object {
+			fieldType, ok := lookupJSONField(known, key)
+			if !ok {
+				*fields = append(*fields, prefix+key)
+				continue
+			}
+			collectUnknownFields(v, fieldType, prefix+key+".", fields)
+		}
+	case reflect.Map:
+		object, ok := value.(map[string]any)
+		if !ok {
+			return
+		}
+		for key, v := range object {
+			collectUnknownFields(v, typ.Elem(), prefix+key+".", fields)
+		}
+	case reflect.Slice, reflect.Array:
+		items, ok := value.([]any)
+		if !ok {
+			return
+		}
+		for i, v := range items {
+			collectUnknownFields(v, typ.Elem(), prefix+strconv.Itoa(i)+".", fields)
+		}
+	default:
+	}
+}
+
+// jsonFieldTypes maps the JSON names of a struct's fields, including promoted ones, to their types.
+func jsonFieldTypes(typ reflect.Type) map[string]reflect.Type {
+	known := make(map[string]reflect.Type)
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				for k, v := range jsonFieldTypes(embedded) {
+					if _, exists := known[k]; !exists {
+						known[k] = v
+					}
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		known[name] = field.Type
+	}
+	return known
+}
+
+// lookupJSONField matches keys the same way encoding/json does: exact first, then case-insensitive.
+func lookupJSONField(known map[string]reflect.Type, key string) (reflect.Type, bool) {
+	if typ, ok := known[key]; ok {
+		return typ, true
+	}
+	for name, typ := range known {
+		if strings.EqualFold(name, key) {
+			return typ, true
+		}
+	}
+	return nil, false
+}
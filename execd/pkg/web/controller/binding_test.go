@@ -0,0 +1,77 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/alibaba/opensandbox/execd/pkg/flag"
+	"github.com/alibaba/opensandbox/execd/pkg/web/model"
+)
+
+func TestBindJSONIgnoresUnknownFieldsByDefault(t *testing.T) {
+	ctx, _ := newTestContext(http.MethodPost, "/command", []byte(`{"command":"ls","comand":"pwd"}`))
+	ctrl := newBasicController(ctx)
+
+	var req model.RunCommandRequest
+	if err := ctrl.bindJSON(&req); err != nil {
+		t.Fatalf("expected lenient binding, got %v", err)
+	}
+	if req.Command != "ls" {
+		t.Fatalf("unexpected command: %q", req.Command)
+	}
+}
+
+func TestBindJSONStrictHeaderListsUnknownFields(t *testing.T) {
+	body := []byte(`{"code":"print(1)","cde":"x","context":{"id":"s1","language":"python","lang":"go"}}`)
+	ctx, _ := newTestContext(http.MethodPost, "/code", body)
+	ctx.Request.Header.Set(model.StrictValidationHeader, "true")
+	ctrl := newBasicController(ctx)
+
+	var req model.RunCodeRequest
+	err := ctrl.bindJSON(&req)
+
+	var unknown *UnknownFieldsError
+	if !errors.As(err, &unknown) {
+		t.Fatalf("expected UnknownFieldsError, got %v", err)
+	}
+	if len(unknown.Fields) != 2 || unknown.Fields[0] != "cde" || unknown.Fields[1] != "context.lang" {
+		t.Fatalf("unexpected unknown fields: %#v", unknown.Fields)
+	}
+}
+
+func TestBindJSONStrictFlagAllowsKnownFields(t *testing.T) {
+	original := flag.ServerStrictJSON
+	flag.ServerStrictJSON = true
+	defer func() { flag.ServerStrictJSON = original }()
+
+	body := []byte(`{"/tmp/a":{"owner":"root","mode":755}}`)
+	ctx, _ := newTestContext(http.MethodPost, "/files/permissions", body)
+	ctrl := newBasicController(ctx)
+
+	var req map[string]model.Permission
+	if err := ctrl.bindJSON(&req); err != nil {
+		t.Fatalf("expected strict binding to accept known fields, got %v", err)
+	}
+
+	ctx, _ = newTestContext(http.MethodPost, "/files/permissions", []byte(`{"/tmp/a":{"mod":755}}`))
+	ctx.Request.Header.Set(model.StrictValidationHeader, "false")
+	ctrl = newBasicController(ctx)
+	if err := ctrl.bindJSON(&req); err != nil {
+		t.Fatalf("expected header to opt out of strict binding, got %v", err)
+	}
+}
@@ -19,7 +19,10 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"os"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -31,8 +34,48 @@ import (
 
 var codeRunner *runtime.Controller
 
-func InitCodeRunner() {
+// init makes request validation accept the languages of the runtime.
+func init() {
+	languages := make([]string, 0, len(runtime.Languages))
+	for _, language := range runtime.Languages {
+		languages = append(languages, language.String())
+	}
+	model.SetLanguages(languages)
+}
+
+// InitCodeRunner creates the runtime controller. It fails when the context preambles can't be loaded
+// or the forwarded signals, SQL connections or working directory settings are invalid.
+func InitCodeRunner() error {
+	preambles, err := runtime.ParseContextPreambles(flag.ContextPreambles)
+	if err != nil {
+		return err
+	}
+	forwardedSignals, err := runtime.ParseForwardedSignals(flag.CommandForwardSignals)
+	if err != nil {
+		return fmt.Errorf("command-forward-signals: %w", err)
+	}
+	sqlConnections, err := runtime.ParseSQLConnections(flag.SQLConnections)
+	if err != nil {
+		return fmt.Errorf("sql-connections: %w", err)
+	}
+	cwdMode, err := strconv.ParseUint(flag.CwdCreateMode, 8, 32)
+	if err != nil || cwdMode == 0 || cwdMode > 0o777 {
+		return fmt.Errorf("cwd-create-mode: invalid permissions %q", flag.CwdCreateMode)
+	}
 	codeRunner = runtime.NewController(flag.JupyterServerHost, flag.JupyterServerToken)
+	if err := codeRunner.SetCwdPolicy(flag.CwdAllowedRoot, os.FileMode(cwdMode)); err != nil {
+		return fmt.Errorf("cwd-allowed-root: %w", err)
+	}
+	for _, connection := range sqlConnections {
+		if err := codeRunner.AddSQLConnection(connection); err != nil {
+			return fmt.Errorf("sql-connections: %w", err)
+		}
+	}
+	codeRunner.SetContextPreambles(preambles)
+	codeRunner.SetCommandLogRotation(flag.CommandLogSegmentSize, flag.CommandLogCompress)
+	codeRunner.SetKernelConnectRetry(flag.JupyterConnectRetries, flag.JupyterConnectRetryDelay)
+	codeRunner.SetForwardedSignals(forwardedSignals)
+	return nil
 }
 
 // CodeInterpretingController handles code execution entrypoints.
@@ -41,6 +84,27 @@ type CodeInterpretingController struct {
 
 	// chunkWriter serializes SSE event writes to prevent interleaved output.
 	chunkWriter sync.Mutex
+
+	// streamAborted is set once a write misses its deadline; later events are dropped.
+	streamAborted atomic.Bool
+
+	// cancelStream stops stream side tasks (e.g. ping) when the client is gone.
+	cancelStream context.CancelFunc
+
+	// outputLimiter caps stdout and stderr events of the stream, nil when unlimited.
+	outputLimiter *outputLimiter
+
+	// verboseEvents counts verbose events for log sampling, guarded by chunkWriter.
+	verboseEvents int
+
+	// ndjson writes events as newline-delimited JSON instead of SSE frames.
+	ndjson bool
+
+	// cellIndex holds the index of the cell currently running, nil outside cells runs.
+	cellIndex atomic.Pointer[int]
+
+	// cwdCreated is set when the working directory of a command was created for it.
+	cwdCreated bool
 }
 
 func NewCodeInterpretingController(ctx *gin.Context) *CodeInterpretingController {
@@ -61,10 +125,63 @@ func (c *CodeInterpretingController) CreateContext() {
 		return
 	}
 
-	session, err := codeRunner.CreateContext(&runtime.CreateContextRequest{
-		Language: runtime.Language(request.Language),
-		Cwd:      request.Cwd,
+	err := request.Validate()
+	if err != nil {
+		c.RespondValidationError(err)
+		return
+	}
+
+	idempotencyKey := c.ctx.GetHeader(model.IdempotencyKeyHeader)
+	if idempotencyKey == "" {
+		idempotencyKey = request.ClientRef
+	}
+	session, reused, err := codeRunner.CreateOrReuseContext(c.ctx.Request.Context(), &runtime.CreateContextRequest{
+		Language:           runtime.Language(request.Language),
+		Cwd:                request.Cwd,
+		CreateCwd:          request.CreateCwd,
+		EnvSnapshot:        request.EnvSnapshot,
+		Env:                request.Env,
+		Setup:              request.Setup,
+		KeepOnSetupFailure: request.KeepOnSetupFailure,
+		IdempotencyKey:     idempotencyKey,
 	})
+	if errors.Is(err, runtime.ErrIdempotencyKeyConflict) {
+		c.RespondError(
+			http.StatusConflict,
+			model.ErrorCodeIdempotencyConflict,
+			err.Error(),
+		)
+		return
+	}
+	if c.respondCwdError(err) {
+		return
+	}
+	if errors.Is(err, runtime.ErrNoKernelForLanguage) {
+		c.RespondError(
+			http.StatusBadRequest,
+			model.ErrorCodeUnsupportedLanguage,
+			err.Error(),
+		)
+		return
+	}
+	var setupErr *runtime.SetupError
+	if errors.As(err, &setupErr) {
+		c.RespondError(
+			http.StatusBadRequest,
+			model.ErrorCodeContextSetupFailed,
+			err.Error(),
+		)
+		return
+	}
+	var preambleErr *runtime.PreambleError
+	if errors.As(err, &preambleErr) {
+		c.RespondError(
+			http.StatusInternalServerError,
+			model.ErrorCodeContextPreambleFailed,
+			err.Error(),
+		)
+		return
+	}
 	if err != nil {
 		c.RespondError(
 			http.StatusInternalServerError,
@@ -74,19 +191,57 @@ func (c *CodeInterpretingController) CreateContext() {
 		return
 	}
 
+	created, _ := codeRunner.GetContext(session, false)
 	resp := model.CodeContext{
 		ID:                 session,
 		CodeContextRequest: request,
+		Setup:              toContextSetup(created.Setup),
+		Reused:             reused,
+		CwdCreated:         created.CwdCreated,
 	}
 	c.RespondSuccess(resp)
 }
 
-// InterruptCode interrupts the execution of running code in a session.
+// respondCwdError answers 400 when err tells the working directory of a
+// request is missing or not a directory, and 403 when it lies outside the
+// allowed root. It reports whether it answered.
+func (c *CodeInterpretingController) respondCwdError(err error) bool {
+	switch {
+	case errors.Is(err, runtime.ErrCwdNotFound), errors.Is(err, runtime.ErrCwdNotDirectory):
+		c.RespondError(http.StatusBadRequest, model.ErrorCodeInvalidCwd, err.Error())
+	case errors.Is(err, runtime.ErrCwdOutsideRoot):
+		c.RespondError(http.StatusForbidden, model.ErrorCodeCwdForbidden, err.Error())
+	default:
+		return false
+	}
+	return true
+}
+
+// toContextSetup converts the setup report of a context to its response, nil
+// when the context had no setup cells.
+func toContextSetup(setup *runtime.ContextSetup) *model.ContextSetup {
+	if setup == nil {
+		return nil
+	}
+	resp := &model.ContextSetup{Duration: setup.Duration.Milliseconds()}
+	if setup.Error != nil {
+		resp.FailedCell = &setup.Error.Cell
+		resp.Error = setup.Error.Output
+	}
+	return resp
+}
+
+// InterruptCode interrupts the execution of running code in a session. The id
+// query takes a context ID, the session ID of the init event, or a language
+// for its default context.
 func (c *CodeInterpretingController) InterruptCode() {
 	c.interrupt()
 }
 
-// RunCode executes code in a context and streams output via SSE.
+// RunCode executes code in a context and streams output via SSE, or NDJSON
+// when the client accepts application/x-ndjson. With ?stream=false or Accept:
+// application/json it answers once with a summary of the run instead. Cells
+// run one after another, see runCells.
 func (c *CodeInterpretingController) RunCode() {
 	var request model.RunCodeRequest
 	if err := c.bindJSON(&request); err != nil {
@@ -100,23 +255,120 @@ func (c *CodeInterpretingController) RunCode() {
 
 	err := request.Validate()
 	if err != nil {
-		c.RespondError(
-			http.StatusBadRequest,
-			model.ErrorCodeInvalidRequest,
-			fmt.Sprintf("invalid request, validation error %v", err),
-		)
+		c.RespondValidationError(err)
+		return
+	}
+
+	if len(request.Cells) > 0 {
+		c.runCells(request)
+		return
+	}
+	if request.Connection != "" && !sqlConnectionExists(request.Connection) {
+		c.RespondError(http.StatusNotFound, model.ErrorCodeSQLConnectionNotFound, fmt.Sprintf("sql connection %s not found", request.Connection))
+		return
+	}
+
+	runCodeRequest := c.buildExecuteCodeRequest(request)
+	tracker := codeArtifactTracker(request)
+	if !c.wantsStream() {
+		c.runToCompletion(runCodeRequest, tracker)
 		return
 	}
 
 	ctx, cancel := context.WithCancel(c.ctx.Request.Context())
 	defer cancel()
-	runCodeRequest := c.buildExecuteCodeRequest(request)
 	eventsHandler := c.setServerEventsHandler(ctx)
 	runCodeRequest.Hooks = eventsHandler
 
-	c.setupSSEResponse()
+	c.setupStreamResponse()
 	err = codeRunner.Execute(runCodeRequest)
+	if err != nil && !endedByErrorEvent(err) {
+		c.RespondError(
+			http.StatusInternalServerError,
+			model.ErrorCodeRuntimeError,
+			fmt.Sprintf("error running codes %v", err),
+		)
+		return
+	}
+	c.writeArtifactsEvent(tracker)
+
+	time.Sleep(flag.ApiGracefulShutdownTimeout)
+}
+
+// endedByErrorEvent reports whether err stopped a run whose stream already
+// ended with an error event telling why: a Timeout or KernelDied one.
+func endedByErrorEvent(err error) bool {
+	return errors.Is(err, runtime.ErrExecutionTimeout) || errors.Is(err, runtime.ErrKernelDied)
+}
+
+// RunCodeBatch runs the snippets of an execute-batch request as the cells of
+// RunCode, which it is deprecated in favor of.
+func (c *CodeInterpretingController) RunCodeBatch() {
+	var request model.RunCodeBatchRequest
+	if err := c.bindJSON(&request); err != nil {
+		c.RespondError(
+			http.StatusBadRequest,
+			model.ErrorCodeInvalidRequest,
+			fmt.Sprintf("error parsing request, MAYBE invalid body format. %v", err),
+		)
+		return
+	}
+
+	err := request.Validate()
 	if err != nil {
+		c.RespondValidationError(err)
+		return
+	}
+
+	c.ctx.Header("Deprecation", "true")
+	c.runCells(model.RunCodeRequest{
+		Context:        request.Context,
+		Cells:          request.Codes,
+		StopOnError:    request.StopOnError,
+		TimeoutSeconds: request.TimeoutSeconds,
+	})
+}
+
+// runCells executes the cells of request in its context, tagging events with
+// cell_index and ending each cell with a cell_complete event. Without streaming
+// it answers with one summary per cell run.
+func (c *CodeInterpretingController) runCells(request model.RunCodeRequest) {
+	batchRequest := &runtime.ExecuteBatchRequest{
+		Language:       runtime.Language(request.Context.Language),
+		Context:        request.Context.ID,
+		Codes:          request.Cells,
+		StopOnError:    request.StopOnError,
+		Timeout:        time.Duration(request.TimeoutSeconds) * time.Second,
+		DisplayDataDir: request.SaveDisplayDataDir,
+	}
+	if !c.wantsStream() {
+		c.runCellsToCompletion(batchRequest)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(c.ctx.Request.Context())
+	defer cancel()
+	batchRequest.OnSnippetStart = func(index int) {
+		c.cellIndex.Store(&index)
+	}
+	batchRequest.OnSnippetEnd = func(_ int, elapsed time.Duration, failed bool) {
+		status := "ok"
+		if failed {
+			status = "error"
+		}
+		payload := c.eventPayload(model.ServerStreamEvent{
+			Type:          model.StreamEventTypeCellComplete,
+			Text:          status,
+			ExecutionTime: elapsed.Milliseconds(),
+			Timestamp:     time.Now().UnixMilli(),
+		})
+		c.writeSingleEvent("OnCellComplete", payload, true)
+	}
+	batchRequest.Hooks = c.setServerEventsHandler(ctx)
+
+	c.setupStreamResponse()
+	err := codeRunner.ExecuteBatch(ctx, batchRequest)
+	if err != nil && !endedByErrorEvent(err) {
 		c.RespondError(
 			http.StatusInternalServerError,
 			model.ErrorCodeRuntimeError,
@@ -128,7 +380,110 @@ func (c *CodeInterpretingController) RunCode() {
 	time.Sleep(flag.ApiGracefulShutdownTimeout)
 }
 
-// GetContext returns a specific code context by id.
+// CompleteCode returns the kernel's completion matches at the cursor of the request.
+func (c *CodeInterpretingController) CompleteCode() {
+	var request model.CodeCompletionRequest
+	if err := c.bindJSON(&request); err != nil {
+		c.RespondError(
+			http.StatusBadRequest,
+			model.ErrorCodeInvalidRequest,
+			fmt.Sprintf("error parsing request, MAYBE invalid body format. %v", err),
+		)
+		return
+	}
+
+	err := request.Validate()
+	if err != nil {
+		c.RespondValidationError(err)
+		return
+	}
+
+	reply, err := codeRunner.Complete(&runtime.IntrospectRequest{
+		Language:  runtime.Language(request.Context.Language),
+		Context:   request.Context.ID,
+		Code:      request.Code,
+		CursorPos: request.CursorPos,
+	})
+	if err != nil {
+		c.respondIntrospectionError(request.Context.ID, err)
+		return
+	}
+
+	matches := reply.Matches
+	if matches == nil {
+		matches = []string{}
+	}
+	c.RespondSuccess(model.CodeCompletion{
+		Matches:     matches,
+		CursorStart: reply.CursorStart,
+		CursorEnd:   reply.CursorEnd,
+		Metadata:    reply.Metadata,
+	})
+}
+
+// InspectCode returns the kernel's documentation of the object at the cursor of the request.
+func (c *CodeInterpretingController) InspectCode() {
+	var request model.CodeInspectionRequest
+	if err := c.bindJSON(&request); err != nil {
+		c.RespondError(
+			http.StatusBadRequest,
+			model.ErrorCodeInvalidRequest,
+			fmt.Sprintf("error parsing request, MAYBE invalid body format. %v", err),
+		)
+		return
+	}
+
+	err := request.Validate()
+	if err != nil {
+		c.RespondValidationError(err)
+		return
+	}
+
+	reply, err := codeRunner.Inspect(&runtime.IntrospectRequest{
+		Language:    runtime.Language(request.Context.Language),
+		Context:     request.Context.ID,
+		Code:        request.Code,
+		CursorPos:   request.CursorPos,
+		DetailLevel: request.DetailLevel,
+	})
+	if err != nil {
+		c.respondIntrospectionError(request.Context.ID, err)
+		return
+	}
+
+	c.RespondSuccess(model.CodeInspection{
+		Found:    reply.Found,
+		Data:     reply.Data,
+		Metadata: reply.Metadata,
+	})
+}
+
+// respondIntrospectionError maps a failed completion or inspection to its response.
+func (c *CodeInterpretingController) respondIntrospectionError(contextID string, err error) {
+	switch {
+	case errors.Is(err, runtime.ErrContextNotFound):
+		c.RespondError(
+			http.StatusNotFound,
+			model.ErrorCodeContextNotFound,
+			fmt.Sprintf("context %s not found", contextID),
+		)
+	case errors.Is(err, runtime.ErrSessionBusy):
+		c.RespondError(
+			http.StatusConflict,
+			model.ErrorCodeContextBusy,
+			"context is busy executing code, retry once it is idle",
+		)
+	default:
+		c.RespondError(
+			http.StatusInternalServerError,
+			model.ErrorCodeRuntimeError,
+			fmt.Sprintf("error querying the kernel. %v", err),
+		)
+	}
+}
+
+// GetContext returns a specific code context by id, with the state of its
+// kernel unless ?refresh=false skips asking the Jupyter server.
 func (c *CodeInterpretingController) GetContext() {
 	contextID := c.ctx.Param("contextId")
 	if contextID == "" {
@@ -137,12 +492,63 @@ func (c *CodeInterpretingController) GetContext() {
 			model.ErrorCodeMissingQuery,
 			"missing path parameter 'contextId'",
 		)
+		return
 	}
 
-	codeContext := codeRunner.GetContext(contextID)
+	refresh := true
+	switch c.ctx.Query("refresh") {
+	case "false", "0":
+		refresh = false
+	}
+	codeContext, err := codeRunner.GetContext(contextID, refresh)
+	if errors.Is(err, runtime.ErrContextNotFound) {
+		c.RespondError(
+			http.StatusNotFound,
+			model.ErrorCodeContextNotFound,
+			fmt.Sprintf("context %s not found", contextID),
+		)
+		return
+	}
 	c.RespondSuccess(codeContext)
 }
 
+// ListContextVariables returns the variables defined in a code context.
+func (c *CodeInterpretingController) ListContextVariables() {
+	contextID := c.ctx.Param("contextId")
+
+	variables, err := codeRunner.ListVariables(contextID)
+	if err != nil {
+		if errors.Is(err, runtime.ErrVariablesUnsupported) {
+			c.RespondError(http.StatusBadRequest, model.ErrorCodeUnsupportedLanguage, err.Error())
+			return
+		}
+		c.respondIntrospectionError(contextID, err)
+		return
+	}
+
+	c.RespondSuccess(variables)
+}
+
+// ResetContext clears the variables of a code context without restarting its kernel.
+func (c *CodeInterpretingController) ResetContext() {
+	contextID := c.ctx.Param("contextId")
+
+	err := codeRunner.ResetContext(c.ctx.Request.Context(), contextID)
+	if err == nil {
+		c.RespondSuccess(nil)
+		return
+	}
+	var preambleErr *runtime.PreambleError
+	switch {
+	case errors.Is(err, runtime.ErrResetUnsupported):
+		c.RespondError(http.StatusBadRequest, model.ErrorCodeUnsupportedLanguage, err.Error())
+	case errors.As(err, &preambleErr):
+		c.RespondError(http.StatusInternalServerError, model.ErrorCodeContextPreambleFailed, err.Error())
+	default:
+		c.respondIntrospectionError(contextID, err)
+	}
+}
+
 // ListContexts returns active code contexts, optionally filtered by language.
 func (c *CodeInterpretingController) ListContexts() {
 	language := c.ctx.Query("language")
@@ -197,7 +603,12 @@ func (c *CodeInterpretingController) DeleteContext() {
 		return
 	}
 
-	err := codeRunner.DeleteContext(contextID)
+	keepNotebook := false
+	switch c.ctx.Query("keep_notebook") {
+	case "true", "1":
+		keepNotebook = true
+	}
+	err := codeRunner.DeleteContext(contextID, keepNotebook)
 	if err != nil {
 		if errors.Is(err, runtime.ErrContextNotFound) {
 			c.RespondError(
@@ -222,9 +633,12 @@ func (c *CodeInterpretingController) DeleteContext() {
 // buildExecuteCodeRequest converts a RunCodeRequest to runtime format.
 func (c *CodeInterpretingController) buildExecuteCodeRequest(request model.RunCodeRequest) *runtime.ExecuteCodeRequest {
 	req := &runtime.ExecuteCodeRequest{
-		Language: runtime.Language(request.Context.Language),
-		Code:     request.Code,
-		Context:  request.Context.ID,
+		Language:       runtime.Language(request.Context.Language),
+		Code:           request.Code,
+		Context:        request.Context.ID,
+		Timeout:        time.Duration(request.TimeoutSeconds) * time.Second,
+		DisplayDataDir: request.SaveDisplayDataDir,
+		SQLConnection:  request.Connection,
 	}
 
 	if req.Language == "" {
@@ -245,7 +659,15 @@ func (c *CodeInterpretingController) interrupt() {
 		return
 	}
 
-	err := codeRunner.Interrupt(session)
+	interrupted, err := codeRunner.Interrupt(session)
+	if errors.Is(err, runtime.ErrContextNotFound) {
+		c.RespondError(
+			http.StatusNotFound,
+			model.ErrorCodeContextNotFound,
+			fmt.Sprintf("session %s not found", session),
+		)
+		return
+	}
 	if err != nil {
 		c.RespondError(
 			http.StatusInternalServerError,
@@ -255,5 +677,5 @@ func (c *CodeInterpretingController) interrupt() {
 		return
 	}
 
-	c.RespondSuccess(nil)
+	c.RespondSuccess(model.InterruptResult{Interrupted: interrupted})
 }
@@ -15,8 +15,21 @@
 package controller
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+
+	"github.com/alibaba/opensandbox/execd/pkg/flag"
+	"github.com/alibaba/opensandbox/execd/pkg/jupyter/execute"
 	"github.com/alibaba/opensandbox/execd/pkg/runtime"
 	"github.com/alibaba/opensandbox/execd/pkg/web/model"
 )
@@ -59,3 +72,432 @@ func TestBuildExecuteCodeRequestRespectsLanguage(t *testing.T) {
 		t.Fatalf("expected python language, got %s", execReq.Language)
 	}
 }
+
+// newEchoJupyter serves a Jupyter API whose single python kernel echoes code to stdout.
+func newEchoJupyter(t *testing.T) *httptest.Server {
+	t.Helper()
+	upgrader := websocket.Upgrader{}
+	reply := func(conn *websocket.Conn, parent *execute.Message, msgType execute.MessageType, content any) {
+		raw, _ := json.Marshal(content)
+		_ = conn.WriteJSON(execute.Message{
+			Header: execute.Header{
+				MessageID:   parent.Header.MessageID + "-" + string(msgType),
+				Session:     parent.Header.Session,
+				MessageType: string(msgType),
+			},
+			ParentHeader: parent.Header,
+			Content:      raw,
+		})
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/kernelspecs":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"default":     "ipython",
+				"kernelspecs": map[string]any{"ipython": map[string]any{"name": "ipython", "spec": map[string]any{"language": "python"}}},
+			})
+		case r.URL.Path == "/api/sessions" && r.Method == http.MethodPost:
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": "session-1", "kernel": map[string]any{"id": "kernel-1", "name": "ipython"}})
+		case r.URL.Path == "/api/kernels":
+			_ = json.NewEncoder(w).Encode([]map[string]any{{"id": "kernel-1", "name": "ipython"}})
+		case strings.HasSuffix(r.URL.Path, "/channels"):
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			for {
+				var msg execute.Message
+				if err := conn.ReadJSON(&msg); err != nil {
+					return
+				}
+				var req execute.ExecuteRequest
+				if execute.MessageType(msg.Header.MessageType) != execute.MsgExecuteRequest || json.Unmarshal(msg.Content, &req) != nil {
+					continue
+				}
+				if req.Code == "die" {
+					return
+				}
+				if req.Code == "raise" {
+					reply(conn, &msg, execute.MsgError, execute.ErrorOutput{EName: "ValueError", EValue: "boom"})
+					reply(conn, &msg, execute.MsgExecuteReply, execute.ExecuteReply{Status: "error", ExecutionCount: 1})
+					reply(conn, &msg, execute.MsgStatus, execute.StatusUpdate{ExecutionState: execute.StateIdle})
+					continue
+				}
+				reply(conn, &msg, execute.MsgStream, execute.StreamOutput{Name: execute.StreamStdout, Text: req.Code})
+				reply(conn, &msg, execute.MsgExecuteReply, execute.ExecuteReply{Status: "ok", ExecutionCount: 1})
+				reply(conn, &msg, execute.MsgStatus, execute.StatusUpdate{ExecutionState: execute.StateIdle})
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRequestsAcceptRuntimeLanguages(t *testing.T) {
+	for _, language := range runtime.Languages {
+		req := model.RunCodeRequest{Code: "1"}
+		req.Context.Language = language.String()
+		if err := req.Validate(); err != nil {
+			t.Fatalf("expected language %s to be accepted, got %v", language, err)
+		}
+	}
+}
+
+func TestRunCodeBatchRunsSnippetsAsCells(t *testing.T) {
+	originalRunner, originalGrace := codeRunner, flag.ApiGracefulShutdownTimeout
+	defer func() { codeRunner, flag.ApiGracefulShutdownTimeout = originalRunner, originalGrace }()
+	codeRunner = runtime.NewController(newEchoJupyter(t).URL, "token")
+	flag.ApiGracefulShutdownTimeout = 0
+
+	session, err := codeRunner.CreateContext(context.Background(), &runtime.CreateContextRequest{Language: runtime.Python})
+	if err != nil {
+		t.Fatalf("CreateContext returned error: %v", err)
+	}
+
+	body, _ := json.Marshal(model.RunCodeBatchRequest{
+		Context: model.CodeContext{ID: session, CodeContextRequest: model.CodeContextRequest{Language: "python"}},
+		Codes:   []string{"first", "second"},
+	})
+	ctx, w := newTestContext(http.MethodPost, "/code/execute-batch", body)
+	NewCodeInterpretingController(ctx).RunCodeBatch()
+	if w.Header().Get("Deprecation") != "true" {
+		t.Fatalf("expected execute-batch to be flagged deprecated")
+	}
+
+	var stdout []string
+	for _, frame := range strings.Split(strings.TrimSpace(w.Body.String()), "\n\n") {
+		var event model.ServerStreamEvent
+		if err := json.Unmarshal([]byte(frame), &event); err != nil {
+			t.Fatalf("invalid SSE frame %q: %v", frame, err)
+		}
+		switch event.Type {
+		case model.StreamEventTypeInit:
+			if event.CellIndex != nil {
+				t.Fatalf("init event must not carry a cell index, got %d", *event.CellIndex)
+			}
+		case model.StreamEventTypeStdout:
+			if event.CellIndex == nil {
+				t.Fatalf("stdout event %q has no cell index", event.Text)
+			}
+			stdout = append(stdout, fmt.Sprintf("%d:%s", *event.CellIndex, event.Text))
+		}
+	}
+
+	expected := []string{"0:first", "1:second"}
+	if !reflect.DeepEqual(stdout, expected) {
+		t.Fatalf("unexpected stdout events: got %v want %v", stdout, expected)
+	}
+}
+
+func TestRunCodeCellsTagsEventsWithCellIndex(t *testing.T) {
+	originalRunner, originalGrace := codeRunner, flag.ApiGracefulShutdownTimeout
+	defer func() { codeRunner, flag.ApiGracefulShutdownTimeout = originalRunner, originalGrace }()
+	codeRunner = runtime.NewController(newEchoJupyter(t).URL, "token")
+	flag.ApiGracefulShutdownTimeout = 0
+
+	session, err := codeRunner.CreateContext(context.Background(), &runtime.CreateContextRequest{Language: runtime.Python})
+	if err != nil {
+		t.Fatalf("CreateContext returned error: %v", err)
+	}
+
+	body, _ := json.Marshal(model.RunCodeRequest{
+		Context:     model.CodeContext{ID: session},
+		Cells:       []string{"first", "raise", "never"},
+		StopOnError: true,
+	})
+	ctx, w := newTestContext(http.MethodPost, "/code", body)
+	NewCodeInterpretingController(ctx).RunCode()
+
+	var events []string
+	for _, frame := range strings.Split(strings.TrimSpace(w.Body.String()), "\n\n") {
+		var event model.ServerStreamEvent
+		if err := json.Unmarshal([]byte(frame), &event); err != nil {
+			t.Fatalf("invalid SSE frame %q: %v", frame, err)
+		}
+		switch event.Type {
+		case model.StreamEventTypeStdout, model.StreamEventTypeCellComplete:
+			if event.CellIndex == nil {
+				t.Fatalf("%s event has no cell index", event.Type)
+			}
+			events = append(events, fmt.Sprintf("%s %d:%s", event.Type, *event.CellIndex, event.Text))
+		case model.StreamEventTypeError:
+			events = append(events, fmt.Sprintf("%s %d:%s", event.Type, *event.CellIndex, event.Error.EName))
+		}
+	}
+
+	expected := []string{"stdout 0:first", "cell_complete 0:ok", "error 1:ValueError", "cell_complete 1:error"}
+	if !reflect.DeepEqual(events, expected) {
+		t.Fatalf("unexpected events: got %v want %v", events, expected)
+	}
+}
+
+func TestRunCodeCellsRespondsWithSummaryPerCell(t *testing.T) {
+	originalRunner := codeRunner
+	defer func() { codeRunner = originalRunner }()
+	codeRunner = runtime.NewController(newEchoJupyter(t).URL, "token")
+
+	session, err := codeRunner.CreateContext(context.Background(), &runtime.CreateContextRequest{Language: runtime.Python})
+	if err != nil {
+		t.Fatalf("CreateContext returned error: %v", err)
+	}
+
+	body, _ := json.Marshal(model.RunCodeRequest{
+		Context: model.CodeContext{ID: session},
+		Cells:   []string{"first", "raise", "third"},
+	})
+	ctx, w := newTestContext(http.MethodPost, "/code?stream=false", body)
+	NewCodeInterpretingController(ctx).RunCode()
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var cells []model.ExecutionSummary
+	if err := json.Unmarshal(w.Body.Bytes(), &cells); err != nil {
+		t.Fatalf("invalid summaries %q: %v", w.Body.String(), err)
+	}
+	if len(cells) != 3 {
+		t.Fatalf("expected a summary per cell, got %d", len(cells))
+	}
+	if cells[0].Stdout != "first" || cells[0].Error != nil {
+		t.Fatalf("unexpected first cell: %+v", cells[0])
+	}
+	if cells[1].Stdout != "" || cells[1].Error == nil || cells[1].Error.EName != "ValueError" {
+		t.Fatalf("unexpected second cell: %+v", cells[1])
+	}
+	if cells[2].Stdout != "third" || cells[2].Error != nil {
+		t.Fatalf("third cell must run without stop_on_error: %+v", cells[2])
+	}
+}
+
+func TestRunCodeCellsEndsWithKernelDiedEventOnly(t *testing.T) {
+	originalRunner, originalGrace := codeRunner, flag.ApiGracefulShutdownTimeout
+	defer func() { codeRunner, flag.ApiGracefulShutdownTimeout = originalRunner, originalGrace }()
+	codeRunner = runtime.NewController(newEchoJupyter(t).URL, "token")
+	flag.ApiGracefulShutdownTimeout = 0
+
+	session, err := codeRunner.CreateContext(context.Background(), &runtime.CreateContextRequest{Language: runtime.Python})
+	if err != nil {
+		t.Fatalf("CreateContext returned error: %v", err)
+	}
+
+	body, _ := json.Marshal(model.RunCodeRequest{Context: model.CodeContext{ID: session}, Cells: []string{"first", "die", "never"}})
+	ctx, w := newTestContext(http.MethodPost, "/code", body)
+	NewCodeInterpretingController(ctx).RunCode()
+
+	frames := strings.Split(strings.TrimSpace(w.Body.String()), "\n\n")
+	var last model.ServerStreamEvent
+	if err := json.Unmarshal([]byte(frames[len(frames)-1]), &last); err != nil {
+		t.Fatalf("invalid last SSE frame %q: %v", frames[len(frames)-1], err)
+	}
+	if last.Type != model.StreamEventTypeError || last.Error == nil || last.Error.EName != "KernelDied" || last.CellIndex == nil || *last.CellIndex != 1 {
+		t.Fatalf("expected the stream to end with the KernelDied event of cell 1, got %s", frames[len(frames)-1])
+	}
+	if strings.Contains(w.Body.String(), string(model.ErrorCodeRuntimeError)) {
+		t.Fatalf("expected no error response written into the stream, got %s", w.Body.String())
+	}
+}
+
+func TestCreateContextReportsSetup(t *testing.T) {
+	originalRunner := codeRunner
+	defer func() { codeRunner = originalRunner }()
+	codeRunner = runtime.NewController(newEchoJupyter(t).URL, "token")
+
+	body, _ := json.Marshal(model.CodeContextRequest{Language: "python", Env: map[string]string{"1BAD": "x"}})
+	ctx, w := newTestContext(http.MethodPost, "/code/context", body)
+	NewCodeInterpretingController(ctx).CreateContext()
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an invalid env name, got %d", w.Code)
+	}
+
+	body, _ = json.Marshal(model.CodeContextRequest{Language: "java"})
+	ctx, w = newTestContext(http.MethodPost, "/code/context", body)
+	NewCodeInterpretingController(ctx).CreateContext()
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), string(model.ErrorCodeUnsupportedLanguage)) {
+		t.Fatalf("expected 400 for a language without kernel, got %d: %s", w.Code, w.Body.String())
+	}
+
+	body, _ = json.Marshal(model.CodeContextRequest{Language: "python", Setup: []string{"import os", "raise"}})
+	ctx, w = newTestContext(http.MethodPost, "/code/context", body)
+	NewCodeInterpretingController(ctx).CreateContext()
+	var errResp model.ErrorResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &errResp)
+	if w.Code != http.StatusBadRequest || errResp.Code != model.ErrorCodeContextSetupFailed {
+		t.Fatalf("expected a setup failure, got %d: %s", w.Code, w.Body.String())
+	}
+
+	body, _ = json.Marshal(model.CodeContextRequest{Language: "python", Setup: []string{"raise"}, KeepOnSetupFailure: true})
+	ctx, w = newTestContext(http.MethodPost, "/code/context", body)
+	NewCodeInterpretingController(ctx).CreateContext()
+	var created model.CodeContext
+	_ = json.Unmarshal(w.Body.Bytes(), &created)
+	if w.Code != http.StatusOK || created.Setup == nil || created.Setup.FailedCell == nil || *created.Setup.FailedCell != 0 {
+		t.Fatalf("expected the kept context to report its setup error, got %d: %s", w.Code, w.Body.String())
+	}
+	if created.Setup.Error == nil || created.Setup.Error.EName != "ValueError" {
+		t.Fatalf("unexpected setup error %+v", created.Setup.Error)
+	}
+}
+
+func TestCreateContextReusesContextForClientRef(t *testing.T) {
+	originalRunner := codeRunner
+	defer func() { codeRunner = originalRunner }()
+	codeRunner = runtime.NewController(newEchoJupyter(t).URL, "token")
+
+	var created []model.CodeContext
+	for range 2 {
+		body, _ := json.Marshal(model.CodeContextRequest{Language: "python", ClientRef: "agent-step-7"})
+		ctx, w := newTestContext(http.MethodPost, "/code/context", body)
+		NewCodeInterpretingController(ctx).CreateContext()
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp model.CodeContext
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		created = append(created, resp)
+	}
+
+	if created[0].Reused || !created[1].Reused || created[0].ID != created[1].ID {
+		t.Fatalf("expected the retry to reuse the first context, got %+v", created)
+	}
+}
+
+func TestGetContextReportsKernelState(t *testing.T) {
+	originalRunner := codeRunner
+	defer func() { codeRunner = originalRunner }()
+	codeRunner = runtime.NewController(newEchoJupyter(t).URL, "token")
+
+	session, err := codeRunner.CreateContext(context.Background(), &runtime.CreateContextRequest{Language: runtime.Python})
+	if err != nil {
+		t.Fatalf("CreateContext returned error: %v", err)
+	}
+
+	ctx, w := newTestContext(http.MethodGet, "/code/contexts/missing", nil)
+	ctx.Params = gin.Params{{Key: "contextId", Value: "missing"}}
+	NewCodeInterpretingController(ctx).GetContext()
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown context, got %d", w.Code)
+	}
+
+	for query, state := range map[string]string{"?refresh=false": "", "": runtime.KernelStateDead} {
+		ctx, w = newTestContext(http.MethodGet, "/code/contexts/"+session+query, nil)
+		ctx.Params = gin.Params{{Key: "contextId", Value: session}}
+		NewCodeInterpretingController(ctx).GetContext()
+		var resp runtime.CodeContext
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		// the mock Jupyter server doesn't know any kernel by id.
+		if w.Code != http.StatusOK || resp.ID != session || resp.Busy || resp.ExecutionState != state {
+			t.Fatalf("%q: unexpected response %d: %s", query, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestCompleteCodeReportsInvalidAndMissingContexts(t *testing.T) {
+	originalRunner := codeRunner
+	defer func() { codeRunner = originalRunner }()
+	codeRunner = runtime.NewController(newEchoJupyter(t).URL, "token")
+
+	body, _ := json.Marshal(model.CodeCompletionRequest{Code: "pr", CursorPos: 3})
+	ctx, w := newTestContext(http.MethodPost, "/code/complete", body)
+	NewCodeInterpretingController(ctx).CompleteCode()
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a cursor past the code, got %d", w.Code)
+	}
+
+	body, _ = json.Marshal(model.CodeCompletionRequest{Context: model.CodeContext{ID: "missing"}, Code: "pr", CursorPos: 2})
+	ctx, w = newTestContext(http.MethodPost, "/code/complete", body)
+	NewCodeInterpretingController(ctx).CompleteCode()
+	var resp model.ErrorResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	if w.Code != http.StatusNotFound || resp.Code != model.ErrorCodeContextNotFound {
+		t.Fatalf("expected 404 %s, got %d %s", model.ErrorCodeContextNotFound, w.Code, resp.Code)
+	}
+}
+
+func TestListContextVariablesReportsMissingContext(t *testing.T) {
+	originalRunner := codeRunner
+	defer func() { codeRunner = originalRunner }()
+	codeRunner = runtime.NewController(newEchoJupyter(t).URL, "token")
+
+	ctx, w := newTestContext(http.MethodGet, "/code/contexts/missing/variables", nil)
+	ctx.Params = gin.Params{{Key: "contextId", Value: "missing"}}
+	NewCodeInterpretingController(ctx).ListContextVariables()
+
+	var resp model.ErrorResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	if w.Code != http.StatusNotFound || resp.Code != model.ErrorCodeContextNotFound {
+		t.Fatalf("expected 404 %s, got %d %s", model.ErrorCodeContextNotFound, w.Code, resp.Code)
+	}
+}
+
+func TestInterruptCodeReportsMissingSession(t *testing.T) {
+	originalRunner := codeRunner
+	defer func() { codeRunner = originalRunner }()
+	codeRunner = runtime.NewController(newEchoJupyter(t).URL, "token")
+
+	ctx, w := newTestContext(http.MethodDelete, "/code?id=missing", nil)
+	NewCodeInterpretingController(ctx).InterruptCode()
+
+	var resp model.ErrorResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	if w.Code != http.StatusNotFound || resp.Code != model.ErrorCodeContextNotFound {
+		t.Fatalf("expected 404 %s, got %d %s", model.ErrorCodeContextNotFound, w.Code, resp.Code)
+	}
+}
+
+func TestAttachContextOutputReplaysBufferedOutput(t *testing.T) {
+	originalRunner := codeRunner
+	defer func() { codeRunner = originalRunner }()
+	codeRunner = runtime.NewController(newEchoJupyter(t).URL, "token")
+
+	session, err := codeRunner.CreateContext(context.Background(), &runtime.CreateContextRequest{Language: runtime.Python})
+	if err != nil {
+		t.Fatalf("CreateContext returned error: %v", err)
+	}
+	err = codeRunner.Execute(&runtime.ExecuteCodeRequest{
+		Language: runtime.Python,
+		Context:  session,
+		Code:     "print('early')",
+		Hooks: runtime.ExecuteResultHook{
+			OnExecuteInit:     func(string) {},
+			OnExecuteResult:   func(map[string]any, int) {},
+			OnExecuteStatus:   func(string) {},
+			OnExecuteStdout:   func(string) {},
+			OnExecuteStderr:   func(string) {},
+			OnExecuteError:    func(*execute.ErrorOutput) {},
+			OnExecuteComplete: func(time.Duration) {},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Execute returned error: %v", err)
+	}
+
+	ctx, w := newTestContext(http.MethodGet, "/code/contexts/missing/output", nil)
+	ctx.Params = gin.Params{{Key: "contextId", Value: "missing"}}
+	NewCodeInterpretingController(ctx).AttachContextOutput()
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown context, got %d", w.Code)
+	}
+
+	ctx, w = newTestContext(http.MethodGet, "/code/contexts/"+session+"/output", nil)
+	ctx.Params = gin.Params{{Key: "contextId", Value: session}}
+	attachCtx, cancel := context.WithTimeout(ctx.Request.Context(), 200*time.Millisecond)
+	defer cancel()
+	ctx.Request = ctx.Request.WithContext(attachCtx)
+	NewCodeInterpretingController(ctx).AttachContextOutput()
+
+	var stdout *model.ServerStreamEvent
+	for _, frame := range strings.Split(strings.TrimSpace(w.Body.String()), "\n\n") {
+		var event model.ServerStreamEvent
+		if json.Unmarshal([]byte(frame), &event) == nil && event.Type == model.StreamEventTypeStdout {
+			stdout = &event
+		}
+	}
+	if stdout == nil || stdout.Text != "print('early')" || stdout.Seq != 1 {
+		t.Fatalf("expected the buffered stdout to be replayed, got %s", w.Body.String())
+	}
+}
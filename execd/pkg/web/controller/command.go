@@ -16,8 +16,11 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"regexp"
 	"strconv"
 	"time"
 
@@ -26,7 +29,9 @@ import (
 	"github.com/alibaba/opensandbox/execd/pkg/web/model"
 )
 
-// RunCommand executes a shell command and streams the output via SSE.
+// RunCommand executes a shell command and streams the output via SSE, or
+// NDJSON when the client accepts application/x-ndjson. With ?stream=false or
+// Accept: application/json it answers once with a summary of the run instead.
 func (c *CodeInterpretingController) RunCommand() {
 	var request model.RunCommandRequest
 	if err := c.bindJSON(&request); err != nil {
@@ -40,22 +45,54 @@ func (c *CodeInterpretingController) RunCommand() {
 
 	err := request.Validate()
 	if err != nil {
-		c.RespondError(
-			http.StatusBadRequest,
-			model.ErrorCodeInvalidRequest,
-			fmt.Sprintf("invalid request, validation error %v", err),
-		)
+		c.RespondValidationError(err)
+		return
+	}
+
+	if err := runtime.ValidateCommandUser(request.User, request.Group); err != nil {
+		if errors.Is(err, runtime.ErrCommandUserNotPermitted) {
+			c.RespondError(http.StatusForbidden, model.ErrorCodeCommandUserForbidden, err.Error())
+		} else {
+			c.RespondError(http.StatusBadRequest, model.ErrorCodeInvalidCommandUser, err.Error())
+		}
+		return
+	}
+	if err := runtime.ValidateCommandShell(request.Shell); err != nil {
+		c.RespondError(http.StatusBadRequest, model.ErrorCodeInvalidRequest, err.Error())
+		return
+	}
+	c.cwdCreated, err = codeRunner.PrepareCwd(request.Cwd, request.CreateCwd)
+	if err != nil {
+		if !c.respondCwdError(err) {
+			c.RespondError(
+				http.StatusInternalServerError,
+				model.ErrorCodeRuntimeError,
+				fmt.Sprintf("error preparing working directory %s. %v", request.Cwd, err),
+			)
+		}
+		return
+	}
+
+	runCodeRequest := c.buildExecuteCommandRequest(request)
+	tracker := commandArtifactTracker(request)
+	if !c.wantsStream() {
+		c.runToCompletion(runCodeRequest, tracker)
 		return
 	}
 
 	ctx, cancel := context.WithCancel(c.ctx.Request.Context())
 	defer cancel()
 
-	runCodeRequest := c.buildExecuteCommandRequest(request)
 	eventsHandler := c.setServerEventsHandler(ctx)
+	if !request.Background {
+		eventsHandler = c.completeOnExit(eventsHandler)
+	}
+	if request.OutputPositions {
+		eventsHandler = c.positionOutput(eventsHandler)
+	}
 	runCodeRequest.Hooks = eventsHandler
 
-	c.setupSSEResponse()
+	c.setupStreamResponse()
 	err = codeRunner.Execute(runCodeRequest)
 	if err != nil {
 		c.RespondError(
@@ -65,10 +102,62 @@ func (c *CodeInterpretingController) RunCommand() {
 		)
 		return
 	}
+	c.writeArtifactsEvent(tracker)
 
 	time.Sleep(flag.ApiGracefulShutdownTimeout)
 }
 
+// completeOnExit holds the execution_complete event of a foreground command
+// back until the command exits, so that the event carries its exit status.
+func (c *CodeInterpretingController) completeOnExit(hooks runtime.ExecuteResultHook) runtime.ExecuteResultHook {
+	var executionTime *time.Duration
+	wrapped := hooks
+	wrapped.OnExecuteComplete = func(elapsed time.Duration) {
+		executionTime = &elapsed
+	}
+	wrapped.OnCommandExit = func(status runtime.CommandExitStatus) {
+		if executionTime != nil {
+			payload := c.eventPayload(model.ServerStreamEvent{
+				Type:          model.StreamEventTypeComplete,
+				ExecutionTime: executionTime.Milliseconds(),
+				Exit:          commandExit(status),
+				Timestamp:     time.Now().UnixMilli(),
+			})
+			c.writeSingleEvent("OnExecuteComplete", payload, true)
+		}
+		hooks.OnCommandExit(status)
+	}
+	return wrapped
+}
+
+// positionOutput reports the output lines of a foreground command with their
+// line number and the log offset following them.
+func (c *CodeInterpretingController) positionOutput(hooks runtime.ExecuteResultHook) runtime.ExecuteResultHook {
+	positioned := func(eventType model.ServerStreamEventType) func(string, runtime.OutputPosition) {
+		return func(text string, position runtime.OutputPosition) {
+			c.writeOutputEvent(model.ServerStreamEvent{
+				Type:   eventType,
+				Text:   text,
+				Line:   position.Line,
+				Offset: position.Offset,
+			})
+		}
+	}
+	wrapped := hooks
+	wrapped.OnCommandStdout = positioned(model.StreamEventTypeStdout)
+	wrapped.OnCommandStderr = positioned(model.StreamEventTypeStderr)
+	return wrapped
+}
+
+// commandExit describes how a command ended for clients.
+func commandExit(status runtime.CommandExitStatus) *model.CommandExit {
+	return &model.CommandExit{
+		ExitCode: status.ExitCode,
+		Signal:   status.Signal,
+		Success:  status.Success(),
+	}
+}
+
 // InterruptCommand stops a running shell command session.
 func (c *CodeInterpretingController) InterruptCommand() {
 	c.interrupt()
@@ -105,8 +194,10 @@ func (c *CodeInterpretingController) GetCommandStatus() {
 	c.RespondSuccess(resp)
 }
 
-// GetBackgroundCommandOutput returns accumulated stdout/stderr for a command session as plain text.
-func (c *CodeInterpretingController) GetBackgroundCommandOutput() {
+// GetCommandOutput returns accumulated output for a command session as plain text.
+// The stream query selects stdout, stderr or combined (default) output, each with its own cursor;
+// finished foreground commands only have stdout and stderr.
+func (c *CodeInterpretingController) GetCommandOutput() {
 	id := c.ctx.Param("id")
 	if id == "" {
 		c.RespondError(http.StatusBadRequest, model.ErrorCodeMissingQuery, "missing command execution id")
@@ -114,9 +205,10 @@ func (c *CodeInterpretingController) GetBackgroundCommandOutput() {
 	}
 
 	cursor := c.QueryInt64(c.ctx.Query("cursor"), 0)
-	output, lastCursor, err := codeRunner.SeekBackgroundCommandOutput(id, cursor)
+	stream := runtime.OutputStream(c.ctx.DefaultQuery("stream", string(runtime.OutputStreamCombined)))
+	output, lastCursor, err := codeRunner.SeekCommandStream(id, stream, cursor)
 	if err != nil {
-		c.RespondError(http.StatusBadRequest, model.ErrorCodeInvalidRequest, err.Error())
+		c.respondCommandOutputError(err)
 		return
 	}
 
@@ -125,18 +217,169 @@ func (c *CodeInterpretingController) GetBackgroundCommandOutput() {
 	c.ctx.String(http.StatusOK, "%s", output)
 }
 
+// commandOutputFollowInterval is how often a followed command log is polled.
+const commandOutputFollowInterval = 200 * time.Millisecond
+
+// DownloadCommandOutput serves one log of a command, foreground ones included,
+// as a file honouring Range requests. With follow=true the log is streamed
+// from cursor on until the command finishes.
+func (c *CodeInterpretingController) DownloadCommandOutput() {
+	id := c.ctx.Param("id")
+	if id == "" {
+		c.RespondError(http.StatusBadRequest, model.ErrorCodeMissingQuery, "missing command execution id")
+		return
+	}
+
+	stream := runtime.OutputStream(c.ctx.DefaultQuery("stream", string(runtime.OutputStreamCombined)))
+	if c.ctx.Query("follow") == "true" {
+		c.followCommandOutput(id, stream, c.QueryInt64(c.ctx.Query("cursor"), 0))
+		return
+	}
+
+	commandLog, err := codeRunner.OpenCommandStream(id, stream)
+	if err != nil {
+		c.respondCommandOutputError(err)
+		return
+	}
+	defer commandLog.Close()
+
+	size, err := commandLog.Seek(0, io.SeekEnd)
+	if err == nil {
+		_, err = commandLog.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		c.RespondError(http.StatusInternalServerError, model.ErrorCodeRuntimeError, err.Error())
+		return
+	}
+	c.ctx.Header("Content-Type", "text/plain; charset=utf-8")
+	c.serveContent(commandLog, "attachment", commandLog.Name, size, commandLog.ModTime)
+}
+
+// followCommandOutput streams a log of a command from cursor on as it grows,
+// until the command finishes or the client goes away.
+func (c *CodeInterpretingController) followCommandOutput(id string, stream runtime.OutputStream, cursor int64) {
+	ticker := time.NewTicker(commandOutputFollowInterval)
+	defer ticker.Stop()
+
+	started := false
+	for {
+		// the status is read first, so output written before the command
+		// finished is sent before the stream ends.
+		status, err := codeRunner.GetCommandStatus(id)
+		var output []byte
+		if err == nil {
+			output, cursor, err = codeRunner.SeekCommandStream(id, stream, cursor)
+		}
+		if err != nil {
+			if !started {
+				c.respondCommandOutputError(err)
+			}
+			return
+		}
+
+		if !started {
+			c.clearWriteDeadline()
+			c.ctx.Header("Content-Type", "text/plain; charset=utf-8")
+			c.ctx.Status(http.StatusOK)
+			started = true
+		}
+		if len(output) > 0 {
+			if _, err := c.ctx.Writer.Write(output); err != nil {
+				return
+			}
+		}
+		c.ctx.Writer.Flush()
+		if !status.Running {
+			return
+		}
+
+		select {
+		case <-c.ctx.Request.Context().Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// respondCommandOutputError answers 410 for logs already removed from disk
+// and 400 for unknown commands or streams.
+func (c *CodeInterpretingController) respondCommandOutputError(err error) {
+	if errors.Is(err, runtime.ErrCommandOutputRemoved) {
+		c.RespondError(http.StatusGone, model.ErrorCodeCommandOutputRemoved, err.Error())
+		return
+	}
+	c.RespondError(http.StatusBadRequest, model.ErrorCodeInvalidRequest, err.Error())
+}
+
 func (c *CodeInterpretingController) buildExecuteCommandRequest(request model.RunCommandRequest) *runtime.ExecuteCodeRequest {
 	if request.Background {
 		return &runtime.ExecuteCodeRequest{
-			Language: runtime.BackgroundCommand,
-			Code:     request.Command,
-			Cwd:      request.Cwd,
+			Language:        runtime.BackgroundCommand,
+			Code:            request.Command,
+			Cwd:             request.Cwd,
+			SeparateStreams: request.SeparateStreams,
+			Shell:           request.Shell,
+			User:            request.User,
+			Group:           request.Group,
+			Limits:          commandLimits(request.Limits),
 		}
 	} else {
-		return &runtime.ExecuteCodeRequest{
+		execRequest := &runtime.ExecuteCodeRequest{
 			Language: runtime.Command,
 			Code:     request.Command,
 			Cwd:      request.Cwd,
+			Shell:    request.Shell,
+			User:     request.User,
+			Group:    request.Group,
+			Limits:   commandLimits(request.Limits),
+			Stdin:    request.Stdin,
+			Progress: progressPatterns(request.Progress),
+			Timeout:  time.Duration(request.TimeoutSeconds) * time.Second,
+
+			SuccessExitCodes: request.SuccessExitCodes,
+		}
+		if request.PTY != nil {
+			execRequest.PTY = &runtime.TerminalSize{Rows: request.PTY.Rows, Cols: request.PTY.Cols}
 		}
+		return execRequest
+	}
+}
+
+// progressPatterns compiles the validated progress patterns of a request.
+func progressPatterns(patterns []model.ProgressPattern) []runtime.ProgressPattern {
+	compiled := make([]runtime.ProgressPattern, 0, len(patterns))
+	for _, pattern := range patterns {
+		compiled = append(compiled, runtime.ProgressPattern{
+			Regexp: regexp.MustCompile(pattern.Regex),
+			Stage:  pattern.Stage,
+		})
+	}
+	return compiled
+}
+
+// commandLimits narrows the server resource limits with the ones requested for a
+// command. A request can tighten a configured limit but never lift it.
+func commandLimits(requested *model.ResourceLimits) runtime.ResourceLimits {
+	if requested == nil {
+		requested = &model.ResourceLimits{}
+	}
+	return runtime.ResourceLimits{
+		AddressSpace: narrowLimit(requested.AddressSpace, flag.CommandMaxAddressSpace),
+		CPUSeconds:   narrowLimit(requested.CPUSeconds, flag.CommandMaxCPUSeconds),
+		OpenFiles:    narrowLimit(requested.OpenFiles, flag.CommandMaxOpenFiles),
+		CoreSize:     narrowLimit(requested.CoreSize, flag.CommandMaxCoreSize),
+	}
+}
+
+// narrowLimit returns the smaller of requested and the server limit, where a
+// nil request or a negative limit means unset.
+func narrowLimit(requested *uint64, limit int64) *uint64 {
+	if limit < 0 {
+		return requested
+	}
+	ceiling := uint64(limit)
+	if requested == nil || *requested > ceiling {
+		return &ceiling
 	}
+	return requested
 }
@@ -0,0 +1,109 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/alibaba/opensandbox/execd/pkg/jupyter/execute"
+	"github.com/alibaba/opensandbox/execd/pkg/runtime"
+	"github.com/alibaba/opensandbox/execd/pkg/util/safego"
+	"github.com/alibaba/opensandbox/execd/pkg/web/model"
+)
+
+// FollowCommandOutput streams the lines of a command log as stdout or stderr
+// events from ?cursor= (or Last-Event-ID) on, until the command finishes; the
+// stream then ends with an execution_complete or error event and an exit
+// event. The seq of each event is the cursor to resume after it. Every client
+// follows the log from its own position.
+func (c *CodeInterpretingController) FollowCommandOutput() {
+	id := c.ctx.Param("id")
+	if id == "" {
+		c.RespondError(http.StatusBadRequest, model.ErrorCodeMissingQuery, "missing command execution id")
+		return
+	}
+
+	cursor := c.QueryInt64(c.ctx.Query("cursor"), -1)
+	if cursor < 0 {
+		cursor = c.QueryInt64(c.ctx.GetHeader("Last-Event-ID"), 0)
+	}
+	stream := runtime.OutputStream(c.ctx.DefaultQuery("stream", string(runtime.OutputStreamCombined)))
+	follower, err := codeRunner.FollowCommandStream(id, stream, cursor)
+	if err != nil {
+		c.respondCommandOutputError(err)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(c.ctx.Request.Context())
+	pinged := make(chan struct{})
+	defer func() {
+		cancel()
+		// the writer must not be used once the handler returns.
+		<-pinged
+	}()
+
+	c.setupStreamResponse()
+	safego.Go(func() {
+		defer close(pinged)
+		c.ping(ctx)
+	})
+
+	eventType := model.StreamEventTypeStdout
+	if stream == runtime.OutputStreamStderr {
+		eventType = model.StreamEventTypeStderr
+	}
+	status, err := follower.Follow(ctx, func(text string, next int64) {
+		c.writeSingleEvent("FollowCommandOutput", model.ServerStreamEvent{
+			Type:      eventType,
+			Text:      text,
+			Stream:    string(stream),
+			Seq:       next,
+			Timestamp: time.Now().UnixMilli(),
+		}.ToJSON(), true)
+	})
+	if err != nil {
+		return
+	}
+
+	exit := runtime.CommandExitStatus{}
+	if status.ExitCode != nil {
+		exit.ExitCode = *status.ExitCode
+	}
+	if exit.Success() {
+		event := model.ServerStreamEvent{Type: model.StreamEventTypeComplete, Timestamp: time.Now().UnixMilli()}
+		if status.FinishedAt != nil {
+			event.ExecutionTime = status.FinishedAt.Sub(status.StartedAt).Milliseconds()
+		}
+		c.writeSingleEvent("FollowCommandOutput", event.ToJSON(), true)
+	} else {
+		c.writeSingleEvent("FollowCommandOutput", model.ServerStreamEvent{
+			Type: model.StreamEventTypeError,
+			Error: &execute.ErrorOutput{
+				EName:     "CommandExecError",
+				EValue:    strconv.Itoa(exit.ExitCode),
+				Traceback: []string{status.Error},
+			},
+			Timestamp: time.Now().UnixMilli(),
+		}.ToJSON(), true)
+	}
+	c.writeSingleEvent("FollowCommandOutput", model.ServerStreamEvent{
+		Type:      model.StreamEventTypeExit,
+		Exit:      &model.CommandExit{ExitCode: exit.ExitCode, Success: exit.Success()},
+		Timestamp: time.Now().UnixMilli(),
+	}.ToJSON(), true)
+}
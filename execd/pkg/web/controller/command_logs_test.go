@@ -0,0 +1,171 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	goruntime "runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/alibaba/opensandbox/execd/pkg/runtime"
+	"github.com/alibaba/opensandbox/execd/pkg/web/model"
+)
+
+// runCommandSession runs code as a command on a fresh codeRunner and returns
+// the session it ran in, once it finished unless background is set.
+func runCommandSession(t *testing.T, code string, background bool) string {
+	t.Helper()
+	if goruntime.GOOS == "windows" {
+		t.Skip("bash not available on windows")
+	}
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not found in PATH")
+	}
+	originalRunner := codeRunner
+	t.Cleanup(func() { codeRunner = originalRunner })
+	codeRunner = runtime.NewController("", "")
+
+	language := runtime.Command
+	if background {
+		language = runtime.BackgroundCommand
+	}
+	var session string
+	request := &runtime.ExecuteCodeRequest{
+		Language: language,
+		Code:     code,
+		Cwd:      t.TempDir(),
+		Hooks:    runtime.ExecuteResultHook{OnExecuteInit: func(id string) { session = id }},
+	}
+	request.SetDefaultHooks()
+	if err := codeRunner.Execute(request); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	for deadline := time.Now().Add(5 * time.Second); background && time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+		if _, err := codeRunner.GetCommandStatus(session); err == nil {
+			break
+		}
+	}
+	return session
+}
+
+func commandLogsContext(session, query string) (*CodeInterpretingController, *httptest.ResponseRecorder) {
+	ctx, w := newTestContext(http.MethodGet, "/command/"+session+"/logs/download?"+query, nil)
+	ctx.Params = gin.Params{{Key: "id", Value: session}}
+	return NewCodeInterpretingController(ctx), w
+}
+
+func TestDownloadCommandOutput_ServesForegroundRanges(t *testing.T) {
+	session := runCommandSession(t, "echo hello world; echo oops >&2", false)
+
+	ctrl, w := commandLogsContext(session, "stream=stdout")
+	ctrl.DownloadCommandOutput()
+	if w.Code != http.StatusOK || w.Body.String() != "hello world\n" {
+		t.Fatalf("unexpected download %d: %q", w.Code, w.Body.String())
+	}
+	if disposition := w.Header().Get("Content-Disposition"); disposition != "attachment; filename="+session+".stdout" {
+		t.Fatalf("unexpected Content-Disposition %q", disposition)
+	}
+
+	ctrl, w = commandLogsContext(session, "stream=stdout")
+	ctrl.ctx.Request.Header.Set("Range", "bytes=6-10")
+	ctrl.DownloadCommandOutput()
+	if w.Code != http.StatusPartialContent || w.Body.String() != "world" {
+		t.Fatalf("unexpected range %d: %q", w.Code, w.Body.String())
+	}
+	if contentRange := w.Header().Get("Content-Range"); contentRange != "bytes 6-10/12" {
+		t.Fatalf("unexpected Content-Range %q", contentRange)
+	}
+}
+
+func TestDownloadCommandOutput_FollowsRunningCommand(t *testing.T) {
+	session := runCommandSession(t, "echo first; sleep 0.5; echo second", true)
+
+	ctrl, w := commandLogsContext(session, "follow=true")
+	ctrl.DownloadCommandOutput()
+	if w.Code != http.StatusOK || w.Body.String() != "first\nsecond\n" {
+		t.Fatalf("expected the whole output once the command finished, got %d: %q", w.Code, w.Body.String())
+	}
+}
+
+func TestDownloadCommandOutput_RemovedLogsAreGone(t *testing.T) {
+	session := runCommandSession(t, "echo hello", false)
+	if err := os.Remove(filepath.Join(os.TempDir(), session+".stdout")); err != nil {
+		t.Fatalf("remove stdout: %v", err)
+	}
+
+	for _, query := range []string{"stream=stdout", "stream=stdout&follow=true"} {
+		ctrl, w := commandLogsContext(session, query)
+		ctrl.DownloadCommandOutput()
+		if w.Code != http.StatusGone {
+			t.Fatalf("%s: expected status %d, got %d: %s", query, http.StatusGone, w.Code, w.Body.String())
+		}
+		var resp model.ErrorResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Code != model.ErrorCodeCommandOutputRemoved {
+			t.Fatalf("%s: unexpected error %s: %v", query, w.Body.String(), err)
+		}
+	}
+}
+
+func TestFollowCommandOutput_StreamsUntilExit(t *testing.T) {
+	session := runCommandSession(t, "echo first; sleep 0.3; echo second; exit 3", true)
+
+	ctx, w := newTestContext(http.MethodGet, "/command/"+session+"/logs/stream?cursor=0", nil)
+	ctx.Params = gin.Params{{Key: "id", Value: session}}
+	NewCodeInterpretingController(ctx).FollowCommandOutput()
+
+	var events []model.ServerStreamEvent
+	for _, frame := range strings.Split(strings.TrimSpace(w.Body.String()), "\n\n") {
+		var event model.ServerStreamEvent
+		if err := json.Unmarshal([]byte(frame), &event); err != nil {
+			t.Fatalf("invalid event %q: %v", frame, err)
+		}
+		if event.Type != model.StreamEventTypePing {
+			events = append(events, event)
+		}
+	}
+	if len(events) != 4 {
+		t.Fatalf("expected 2 lines, an error and an exit event, got %+v", events)
+	}
+	if events[0].Type != model.StreamEventTypeStdout || events[0].Text != "first" || events[0].Seq != 6 || events[0].Stream != "combined" {
+		t.Fatalf("unexpected first line %+v", events[0])
+	}
+	if events[1].Text != "second" || events[1].Seq != 13 {
+		t.Fatalf("unexpected second line %+v", events[1])
+	}
+	if events[2].Type != model.StreamEventTypeError || events[2].Error.EValue != "3" {
+		t.Fatalf("unexpected error event %+v", events[2])
+	}
+	if events[3].Type != model.StreamEventTypeExit || events[3].Exit.ExitCode != 3 || events[3].Exit.Success {
+		t.Fatalf("unexpected exit event %+v", events[3])
+	}
+
+	// a finished command replays from the cursor and ends at once.
+	ctx, w = newTestContext(http.MethodGet, "/command/"+session+"/logs/stream", nil)
+	ctx.Params = gin.Params{{Key: "id", Value: session}}
+	ctx.Request.Header.Set("Last-Event-ID", "6")
+	NewCodeInterpretingController(ctx).FollowCommandOutput()
+	if body := w.Body.String(); strings.Contains(body, `"first"`) || !strings.Contains(body, `"second"`) {
+		t.Fatalf("expected to resume after the first line, got %s", body)
+	}
+}
@@ -18,8 +18,14 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os/exec"
+	"path/filepath"
+	goruntime "runtime"
+	"strings"
 	"testing"
 
+	"github.com/alibaba/opensandbox/execd/pkg/flag"
+	"github.com/alibaba/opensandbox/execd/pkg/runtime"
 	"github.com/alibaba/opensandbox/execd/pkg/web/model"
 )
 
@@ -50,10 +56,10 @@ func TestGetCommandStatus_MissingID(t *testing.T) {
 	}
 }
 
-func TestGetBackgroundCommandOutput_MissingID(t *testing.T) {
+func TestGetCommandOutput_MissingID(t *testing.T) {
 	ctrl, w := setupCommandController(http.MethodGet, "/command/logs/")
 
-	ctrl.GetBackgroundCommandOutput()
+	ctrl.GetCommandOutput()
 
 	if w.Code != http.StatusBadRequest {
 		t.Fatalf("expected status %d, got %d", http.StatusBadRequest, w.Code)
@@ -70,3 +76,485 @@ func TestGetBackgroundCommandOutput_MissingID(t *testing.T) {
 		t.Fatalf("unexpected message: %s", resp.Message)
 	}
 }
+
+func TestRunCommand_ValidationFieldErrors(t *testing.T) {
+	ctx, w := newTestContext(http.MethodPost, "/command", []byte(`{"cwd":"/tmp"}`))
+	ctx.Request.Header.Set("Content-Type", "application/json")
+	ctrl := NewCodeInterpretingController(ctx)
+
+	ctrl.RunCommand()
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	var resp model.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if resp.Code != model.ErrorCodeInvalidRequest {
+		t.Fatalf("unexpected error code: %s", resp.Code)
+	}
+	if resp.Message != "invalid request, validation error command is required" {
+		t.Fatalf("unexpected message: %s", resp.Message)
+	}
+	if len(resp.Errors) != 1 || resp.Errors[0] != (model.FieldError{Field: "command", Message: "is required"}) {
+		t.Fatalf("unexpected field errors: %+v", resp.Errors)
+	}
+}
+
+func TestRunCommand_UnknownUser(t *testing.T) {
+	ctx, w := newTestContext(http.MethodPost, "/command", []byte(`{"command":"id","user":"no-such-user-for-execd"}`))
+	ctx.Request.Header.Set("Content-Type", "application/json")
+	ctrl := NewCodeInterpretingController(ctx)
+
+	ctrl.RunCommand()
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	var resp model.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if resp.Code != model.ErrorCodeInvalidCommandUser {
+		t.Fatalf("unexpected error code: %s", resp.Code)
+	}
+}
+
+func TestRunCommand_UnsupportedShell(t *testing.T) {
+	shell := "powershell"
+	if goruntime.GOOS == "windows" {
+		shell = "bash"
+	}
+	ctx, w := newTestContext(http.MethodPost, "/command", []byte(`{"command":"echo hi","shell":"`+shell+`"}`))
+	ctx.Request.Header.Set("Content-Type", "application/json")
+	NewCodeInterpretingController(ctx).RunCommand()
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+	var resp model.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if resp.Code != model.ErrorCodeInvalidRequest || !strings.Contains(resp.Message, shell) {
+		t.Fatalf("unexpected error response: %+v", resp)
+	}
+}
+
+func TestRunCommand_ValidatesCwd(t *testing.T) {
+	originalRunner := codeRunner
+	defer func() { codeRunner = originalRunner }()
+	dir := t.TempDir()
+	codeRunner = runtime.NewController("", "")
+	if err := codeRunner.SetCwdPolicy(filepath.Join(dir, "root"), 0); err != nil {
+		t.Fatalf("SetCwdPolicy: %v", err)
+	}
+
+	for _, tc := range []struct {
+		cwd    string
+		status int
+		code   model.ErrorCode
+	}{
+		{cwd: filepath.Join(dir, "root", "typo"), status: http.StatusBadRequest, code: model.ErrorCodeInvalidCwd},
+		{cwd: filepath.Join(dir, "elsewhere"), status: http.StatusForbidden, code: model.ErrorCodeCwdForbidden},
+	} {
+		body, _ := json.Marshal(model.RunCommandRequest{Command: "pwd", Cwd: tc.cwd})
+		ctx, w := newTestContext(http.MethodPost, "/command", body)
+		NewCodeInterpretingController(ctx).RunCommand()
+
+		var resp model.ErrorResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("failed to parse response: %v", err)
+		}
+		if w.Code != tc.status || resp.Code != tc.code || !strings.Contains(resp.Message, tc.cwd) {
+			t.Fatalf("%s: expected %d %s naming the cwd, got %d %+v", tc.cwd, tc.status, tc.code, w.Code, resp)
+		}
+	}
+}
+
+func TestRunCommand_CreatesCwd(t *testing.T) {
+	if goruntime.GOOS == "windows" {
+		t.Skip("bash not available on windows")
+	}
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not found in PATH")
+	}
+	originalRunner, originalGrace := codeRunner, flag.ApiGracefulShutdownTimeout
+	previous := [...]int64{flag.CommandMaxAddressSpace, flag.CommandMaxCPUSeconds, flag.CommandMaxOpenFiles, flag.CommandMaxCoreSize}
+	defer func() {
+		codeRunner, flag.ApiGracefulShutdownTimeout = originalRunner, originalGrace
+		flag.CommandMaxAddressSpace, flag.CommandMaxCPUSeconds, flag.CommandMaxOpenFiles, flag.CommandMaxCoreSize = previous[0], previous[1], previous[2], previous[3]
+	}()
+	codeRunner = runtime.NewController("", "")
+	flag.ApiGracefulShutdownTimeout = 0
+	flag.CommandMaxAddressSpace, flag.CommandMaxCPUSeconds, flag.CommandMaxOpenFiles, flag.CommandMaxCoreSize = -1, -1, -1, -1
+
+	cwd := filepath.Join(t.TempDir(), "new", "project")
+	body, _ := json.Marshal(model.RunCommandRequest{Command: "pwd", Cwd: cwd, CreateCwd: true})
+	ctx, w := newTestContext(http.MethodPost, "/command", body)
+	NewCodeInterpretingController(ctx).RunCommand()
+
+	var init model.ServerStreamEvent
+	frame, _, _ := strings.Cut(w.Body.String(), "\n\n")
+	if err := json.Unmarshal([]byte(frame), &init); err != nil {
+		t.Fatalf("invalid SSE frame %q: %v", frame, err)
+	}
+	if init.Type != model.StreamEventTypeInit || !init.CwdCreated {
+		t.Fatalf("expected an init event reporting the created cwd, got %+v", init)
+	}
+	if !strings.Contains(w.Body.String(), cwd) {
+		t.Fatalf("expected the command to run in %s, got %s", cwd, w.Body.String())
+	}
+}
+
+func TestCommandLimitsNarrowServerDefaults(t *testing.T) {
+	previous := [...]int64{flag.CommandMaxAddressSpace, flag.CommandMaxCPUSeconds, flag.CommandMaxOpenFiles, flag.CommandMaxCoreSize}
+	defer func() {
+		flag.CommandMaxAddressSpace, flag.CommandMaxCPUSeconds, flag.CommandMaxOpenFiles, flag.CommandMaxCoreSize = previous[0], previous[1], previous[2], previous[3]
+	}()
+	flag.CommandMaxAddressSpace, flag.CommandMaxCPUSeconds, flag.CommandMaxOpenFiles, flag.CommandMaxCoreSize = 1<<30, -1, 256, -1
+
+	value := func(v uint64) *uint64 { return &v }
+	limits := commandLimits(&model.ResourceLimits{
+		AddressSpace: value(2 << 30),
+		CPUSeconds:   value(5),
+		CoreSize:     value(0),
+	})
+
+	if limits.AddressSpace == nil || *limits.AddressSpace != 1<<30 {
+		t.Fatalf("request must not lift the address space limit: %v", limits.AddressSpace)
+	}
+	if limits.CPUSeconds == nil || *limits.CPUSeconds != 5 {
+		t.Fatalf("unexpected cpu limit: %v", limits.CPUSeconds)
+	}
+	if limits.OpenFiles == nil || *limits.OpenFiles != 256 {
+		t.Fatalf("expected server open files default, got %v", limits.OpenFiles)
+	}
+	if limits.CoreSize == nil || *limits.CoreSize != 0 {
+		t.Fatalf("expected core dumps to be disabled, got %v", limits.CoreSize)
+	}
+
+	if limits := commandLimits(nil); limits.CPUSeconds != nil || limits.CoreSize != nil {
+		t.Fatalf("unset limits must stay unlimited: %+v", limits)
+	}
+}
+
+func TestRunCommand_EndsStreamWithExitStatus(t *testing.T) {
+	if goruntime.GOOS == "windows" {
+		t.Skip("bash not available on windows")
+	}
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not found in PATH")
+	}
+	originalRunner, originalGrace := codeRunner, flag.ApiGracefulShutdownTimeout
+	previous := [...]int64{flag.CommandMaxAddressSpace, flag.CommandMaxCPUSeconds, flag.CommandMaxOpenFiles, flag.CommandMaxCoreSize}
+	defer func() {
+		codeRunner, flag.ApiGracefulShutdownTimeout = originalRunner, originalGrace
+		flag.CommandMaxAddressSpace, flag.CommandMaxCPUSeconds, flag.CommandMaxOpenFiles, flag.CommandMaxCoreSize = previous[0], previous[1], previous[2], previous[3]
+	}()
+	codeRunner = runtime.NewController("", "")
+	flag.ApiGracefulShutdownTimeout = 0
+	flag.CommandMaxAddressSpace, flag.CommandMaxCPUSeconds, flag.CommandMaxOpenFiles, flag.CommandMaxCoreSize = -1, -1, -1, -1
+
+	cases := []struct {
+		command  string
+		expected model.CommandExit
+	}{
+		{"echo done", model.CommandExit{ExitCode: 0, Success: true}},
+		{"echo failing; exit 3", model.CommandExit{ExitCode: 3}},
+		{"kill -KILL $$", model.CommandExit{ExitCode: -1, Signal: "SIGKILL"}},
+	}
+	for _, tc := range cases {
+		body, _ := json.Marshal(model.RunCommandRequest{Command: tc.command, Cwd: t.TempDir()})
+		ctx, w := newTestContext(http.MethodPost, "/command", body)
+		NewCodeInterpretingController(ctx).RunCommand()
+
+		frames := strings.Split(strings.TrimSpace(w.Body.String()), "\n\n")
+		var last model.ServerStreamEvent
+		if err := json.Unmarshal([]byte(frames[len(frames)-1]), &last); err != nil {
+			t.Fatalf("%q: invalid SSE frame: %v", tc.command, err)
+		}
+		if last.Type != model.StreamEventTypeExit || last.Exit == nil {
+			t.Fatalf("%q: expected a terminal exit event, got %+v", tc.command, last)
+		}
+		if *last.Exit != tc.expected {
+			t.Fatalf("%q: unexpected exit status %+v, want %+v", tc.command, *last.Exit, tc.expected)
+		}
+	}
+}
+
+func TestRunCommand_CompletesOnSuccessExitCodes(t *testing.T) {
+	if goruntime.GOOS == "windows" {
+		t.Skip("bash not available on windows")
+	}
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not found in PATH")
+	}
+	originalRunner, originalGrace := codeRunner, flag.ApiGracefulShutdownTimeout
+	previous := [...]int64{flag.CommandMaxAddressSpace, flag.CommandMaxCPUSeconds, flag.CommandMaxOpenFiles, flag.CommandMaxCoreSize}
+	defer func() {
+		codeRunner, flag.ApiGracefulShutdownTimeout = originalRunner, originalGrace
+		flag.CommandMaxAddressSpace, flag.CommandMaxCPUSeconds, flag.CommandMaxOpenFiles, flag.CommandMaxCoreSize = previous[0], previous[1], previous[2], previous[3]
+	}()
+	codeRunner = runtime.NewController("", "")
+	flag.ApiGracefulShutdownTimeout = 0
+	flag.CommandMaxAddressSpace, flag.CommandMaxCPUSeconds, flag.CommandMaxOpenFiles, flag.CommandMaxCoreSize = -1, -1, -1, -1
+
+	body, _ := json.Marshal(model.RunCommandRequest{Command: "echo no match; exit 1", Cwd: t.TempDir(), SuccessExitCodes: []int{1}})
+	ctx, w := newTestContext(http.MethodPost, "/command", body)
+	NewCodeInterpretingController(ctx).RunCommand()
+
+	var events []model.ServerStreamEvent
+	for _, frame := range strings.Split(strings.TrimSpace(w.Body.String()), "\n\n") {
+		var event model.ServerStreamEvent
+		if err := json.Unmarshal([]byte(frame), &event); err != nil {
+			t.Fatalf("invalid SSE frame %q: %v", frame, err)
+		}
+		if event.Type == model.StreamEventTypeError {
+			t.Fatalf("unexpected error event %+v", event)
+		}
+		events = append(events, event)
+	}
+	if len(events) < 2 {
+		t.Fatalf("expected complete and exit events, got %+v", events)
+	}
+	complete, exit := events[len(events)-2], events[len(events)-1]
+	if complete.Type != model.StreamEventTypeComplete || complete.Exit == nil || complete.Exit.ExitCode != 1 {
+		t.Fatalf("expected execution_complete with exit code 1, got %+v", complete)
+	}
+	if exit.Type != model.StreamEventTypeExit || exit.Exit == nil || *exit.Exit != (model.CommandExit{ExitCode: 1, Success: true}) {
+		t.Fatalf("expected a successful exit with code 1, got %+v", exit)
+	}
+}
+
+func TestRunCommand_OutputPositions(t *testing.T) {
+	if goruntime.GOOS == "windows" {
+		t.Skip("bash not available on windows")
+	}
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not found in PATH")
+	}
+	originalRunner, originalGrace := codeRunner, flag.ApiGracefulShutdownTimeout
+	previous := [...]int64{flag.CommandMaxAddressSpace, flag.CommandMaxCPUSeconds, flag.CommandMaxOpenFiles, flag.CommandMaxCoreSize}
+	defer func() {
+		codeRunner, flag.ApiGracefulShutdownTimeout = originalRunner, originalGrace
+		flag.CommandMaxAddressSpace, flag.CommandMaxCPUSeconds, flag.CommandMaxOpenFiles, flag.CommandMaxCoreSize = previous[0], previous[1], previous[2], previous[3]
+	}()
+	codeRunner = runtime.NewController("", "")
+	flag.ApiGracefulShutdownTimeout = 0
+	flag.CommandMaxAddressSpace, flag.CommandMaxCPUSeconds, flag.CommandMaxOpenFiles, flag.CommandMaxCoreSize = -1, -1, -1, -1
+
+	body, _ := json.Marshal(model.RunCommandRequest{Command: "echo first; echo second", Cwd: t.TempDir(), OutputPositions: true})
+	ctx, w := newTestContext(http.MethodPost, "/command", body)
+	NewCodeInterpretingController(ctx).RunCommand()
+
+	var session string
+	var stdout []model.ServerStreamEvent
+	for _, frame := range strings.Split(strings.TrimSpace(w.Body.String()), "\n\n") {
+		var event model.ServerStreamEvent
+		if err := json.Unmarshal([]byte(frame), &event); err != nil {
+			t.Fatalf("invalid SSE frame %q: %v", frame, err)
+		}
+		switch event.Type {
+		case model.StreamEventTypeInit:
+			session = event.Text
+		case model.StreamEventTypeStdout:
+			stdout = append(stdout, event)
+		}
+	}
+	if len(stdout) != 2 || stdout[0].Line != 1 || stdout[0].Offset != 6 || stdout[1].Line != 2 || stdout[1].Offset != 13 {
+		t.Fatalf("unexpected stdout events %+v", stdout)
+	}
+
+	rest, cursor, err := codeRunner.SeekCommandStream(session, runtime.OutputStreamStdout, stdout[0].Offset)
+	if err != nil {
+		t.Fatalf("seek stdout: %v", err)
+	}
+	if string(rest) != "second\n" || cursor != stdout[1].Offset {
+		t.Fatalf("unexpected output %q at cursor %d after the first line", rest, cursor)
+	}
+}
+
+func TestRunCommand_StreamsNDJSON(t *testing.T) {
+	if goruntime.GOOS == "windows" {
+		t.Skip("bash not available on windows")
+	}
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not found in PATH")
+	}
+	originalRunner, originalGrace := codeRunner, flag.ApiGracefulShutdownTimeout
+	previous := [...]int64{flag.CommandMaxAddressSpace, flag.CommandMaxCPUSeconds, flag.CommandMaxOpenFiles, flag.CommandMaxCoreSize}
+	defer func() {
+		codeRunner, flag.ApiGracefulShutdownTimeout = originalRunner, originalGrace
+		flag.CommandMaxAddressSpace, flag.CommandMaxCPUSeconds, flag.CommandMaxOpenFiles, flag.CommandMaxCoreSize = previous[0], previous[1], previous[2], previous[3]
+	}()
+	codeRunner = runtime.NewController("", "")
+	flag.ApiGracefulShutdownTimeout = 0
+	flag.CommandMaxAddressSpace, flag.CommandMaxCPUSeconds, flag.CommandMaxOpenFiles, flag.CommandMaxCoreSize = -1, -1, -1, -1
+
+	body, _ := json.Marshal(model.RunCommandRequest{Command: "printf 'one\\ntwo\\n'", Cwd: t.TempDir()})
+	ctx, w := newTestContext(http.MethodPost, "/command", body)
+	ctx.Request.Header.Set("Accept", "application/x-ndjson")
+	NewCodeInterpretingController(ctx).RunCommand()
+
+	if got := w.Header().Get("Content-Type"); got != "application/x-ndjson" {
+		t.Fatalf("expected NDJSON content type, got %q", got)
+	}
+	output := w.Body.String()
+	if !strings.HasSuffix(output, "\n") || strings.Contains(output, "\n\n") {
+		t.Fatalf("expected one event per line, got %q", output)
+	}
+
+	var events []model.ServerStreamEvent
+	for _, line := range strings.Split(strings.TrimSuffix(output, "\n"), "\n") {
+		var event model.ServerStreamEvent
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("invalid NDJSON line %q: %v", line, err)
+		}
+		events = append(events, event)
+	}
+	if events[0].Type != model.StreamEventTypeInit {
+		t.Fatalf("expected the stream to start with init, got %+v", events[0])
+	}
+	last := events[len(events)-1]
+	if last.Type != model.StreamEventTypeExit || last.Exit == nil || !last.Exit.Success {
+		t.Fatalf("expected a successful exit event last, got %+v", last)
+	}
+	var stdout string
+	for _, event := range events {
+		if event.Type == model.StreamEventTypeStdout {
+			stdout += event.Text
+		}
+	}
+	if !strings.Contains(stdout, "one") || !strings.Contains(stdout, "two") {
+		t.Fatalf("expected stdout in the stream, got %q", stdout)
+	}
+}
+
+func TestRunCommand_StreamsProgressEvents(t *testing.T) {
+	if goruntime.GOOS == "windows" {
+		t.Skip("bash not available on windows")
+	}
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not found in PATH")
+	}
+	originalRunner, originalGrace := codeRunner, flag.ApiGracefulShutdownTimeout
+	previous := [...]int64{flag.CommandMaxAddressSpace, flag.CommandMaxCPUSeconds, flag.CommandMaxOpenFiles, flag.CommandMaxCoreSize}
+	defer func() {
+		codeRunner, flag.ApiGracefulShutdownTimeout = originalRunner, originalGrace
+		flag.CommandMaxAddressSpace, flag.CommandMaxCPUSeconds, flag.CommandMaxOpenFiles, flag.CommandMaxCoreSize = previous[0], previous[1], previous[2], previous[3]
+	}()
+	codeRunner = runtime.NewController("", "")
+	flag.ApiGracefulShutdownTimeout = 0
+	flag.CommandMaxAddressSpace, flag.CommandMaxCPUSeconds, flag.CommandMaxOpenFiles, flag.CommandMaxCoreSize = -1, -1, -1, -1
+
+	body, _ := json.Marshal(model.RunCommandRequest{
+		Command: `printf 'Step 1/4 : FROM alpine\nStep 2/4 : RUN make\nbuilt\n'`,
+		Cwd:     t.TempDir(),
+		Progress: []model.ProgressPattern{
+			{Regex: `^Step (?P<current>\d+)/(?P<total>\d+) : (?P<stage>\w+)`},
+		},
+	})
+	ctx, w := newTestContext(http.MethodPost, "/command", body)
+	ctx.Request.Header.Set("Accept", "application/x-ndjson")
+	NewCodeInterpretingController(ctx).RunCommand()
+
+	var progress []model.ServerStreamEvent
+	for _, line := range strings.Split(strings.TrimSuffix(w.Body.String(), "\n"), "\n") {
+		var event model.ServerStreamEvent
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("invalid NDJSON line %q: %v", line, err)
+		}
+		if event.Type == model.StreamEventTypeProgress {
+			progress = append(progress, event)
+		}
+	}
+	if len(progress) != 2 {
+		t.Fatalf("expected 2 progress events, got %+v", progress)
+	}
+	for i, want := range []struct {
+		percent float64
+		stage   string
+	}{{25, "FROM"}, {50, "RUN"}} {
+		got := progress[i].Progress
+		if got == nil || got.Percent == nil || *got.Percent != want.percent || got.Stage != want.stage {
+			t.Fatalf("progress event %d: got %+v, want %v%% %s", i, got, want.percent, want.stage)
+		}
+	}
+	if !strings.HasPrefix(progress[0].Text, "Step 1/4") {
+		t.Fatalf("progress event should carry its line, got %q", progress[0].Text)
+	}
+}
+
+func TestRunCommand_RespondsWithSummaryWhenNotStreaming(t *testing.T) {
+	if goruntime.GOOS == "windows" {
+		t.Skip("bash not available on windows")
+	}
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not found in PATH")
+	}
+	originalRunner, originalGrace := codeRunner, flag.ApiGracefulShutdownTimeout
+	previous := [...]int64{flag.CommandMaxAddressSpace, flag.CommandMaxCPUSeconds, flag.CommandMaxOpenFiles, flag.CommandMaxCoreSize}
+	defer func() {
+		codeRunner, flag.ApiGracefulShutdownTimeout = originalRunner, originalGrace
+		flag.CommandMaxAddressSpace, flag.CommandMaxCPUSeconds, flag.CommandMaxOpenFiles, flag.CommandMaxCoreSize = previous[0], previous[1], previous[2], previous[3]
+	}()
+	codeRunner = runtime.NewController("", "")
+	flag.ApiGracefulShutdownTimeout = 0
+	flag.CommandMaxAddressSpace, flag.CommandMaxCPUSeconds, flag.CommandMaxOpenFiles, flag.CommandMaxCoreSize = -1, -1, -1, -1
+
+	body, _ := json.Marshal(model.RunCommandRequest{Command: "echo one; echo two; echo oops >&2; exit 3", Cwd: t.TempDir()})
+	ctx, w := newTestContext(http.MethodPost, "/command?stream=false", body)
+	NewCodeInterpretingController(ctx).RunCommand()
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var summary model.ExecutionSummary
+	if err := json.Unmarshal(w.Body.Bytes(), &summary); err != nil {
+		t.Fatalf("invalid summary: %v", err)
+	}
+	if summary.Stdout != "one\ntwo\n" || summary.Stderr != "oops\n" {
+		t.Fatalf("unexpected output: stdout %q, stderr %q", summary.Stdout, summary.Stderr)
+	}
+	if summary.Exit == nil || summary.Exit.ExitCode != 3 || summary.Error == nil || summary.TimedOut {
+		t.Fatalf("unexpected summary: %+v", summary)
+	}
+}
+
+func TestRunCommand_SummaryTimesOutWithPartialOutput(t *testing.T) {
+	if goruntime.GOOS == "windows" {
+		t.Skip("bash not available on windows")
+	}
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not found in PATH")
+	}
+	originalRunner, originalGrace := codeRunner, flag.ApiGracefulShutdownTimeout
+	previous := [...]int64{flag.CommandMaxAddressSpace, flag.CommandMaxCPUSeconds, flag.CommandMaxOpenFiles, flag.CommandMaxCoreSize}
+	defer func() {
+		codeRunner, flag.ApiGracefulShutdownTimeout = originalRunner, originalGrace
+		flag.CommandMaxAddressSpace, flag.CommandMaxCPUSeconds, flag.CommandMaxOpenFiles, flag.CommandMaxCoreSize = previous[0], previous[1], previous[2], previous[3]
+	}()
+	codeRunner = runtime.NewController("", "")
+	flag.ApiGracefulShutdownTimeout = 0
+	flag.CommandMaxAddressSpace, flag.CommandMaxCPUSeconds, flag.CommandMaxOpenFiles, flag.CommandMaxCoreSize = -1, -1, -1, -1
+
+	body, _ := json.Marshal(model.RunCommandRequest{Command: "echo started; sleep 30", Cwd: t.TempDir(), TimeoutSeconds: 1})
+	ctx, w := newTestContext(http.MethodPost, "/command", body)
+	ctx.Request.Header.Set("Accept", "application/json")
+	NewCodeInterpretingController(ctx).RunCommand()
+
+	if w.Code != http.StatusRequestTimeout {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusRequestTimeout, w.Code, w.Body.String())
+	}
+	var summary model.ExecutionSummary
+	if err := json.Unmarshal(w.Body.Bytes(), &summary); err != nil {
+		t.Fatalf("invalid summary: %v", err)
+	}
+	if !summary.TimedOut || summary.Stdout != "started\n" {
+		t.Fatalf("expected the partial output of a timed out run, got %+v", summary)
+	}
+}
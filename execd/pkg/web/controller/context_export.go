@@ -0,0 +1,86 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/alibaba/opensandbox/execd/pkg/runtime"
+	"github.com/alibaba/opensandbox/execd/pkg/web/model"
+)
+
+// ExportContext downloads the code run in a context: ?format=ipynb (the
+// default) as a notebook with the outputs of every cell, ?format=py as a plain
+// script of the cells.
+func (c *CodeInterpretingController) ExportContext() {
+	contextID := c.ctx.Param("contextId")
+
+	format := c.ctx.DefaultQuery("format", "ipynb")
+	if format != "ipynb" && format != "py" {
+		c.RespondError(
+			http.StatusBadRequest,
+			model.ErrorCodeInvalidRequest,
+			fmt.Sprintf("unsupported export format %q, use ipynb or py", format),
+		)
+		return
+	}
+
+	codeContext, err := codeRunner.GetContext(contextID, false)
+	if err == nil {
+		var executions []runtime.Execution
+		executions, err = codeRunner.ContextHistory(contextID)
+		if err == nil {
+			c.writeExport(contextID, format, codeContext.Language, executions)
+			return
+		}
+	}
+	if errors.Is(err, runtime.ErrContextNotFound) {
+		c.RespondError(
+			http.StatusNotFound,
+			model.ErrorCodeContextNotFound,
+			fmt.Sprintf("context %s not found", contextID),
+		)
+		return
+	}
+	c.RespondError(
+		http.StatusInternalServerError,
+		model.ErrorCodeRuntimeError,
+		fmt.Sprintf("error exporting code context %s. %v", contextID, err),
+	)
+}
+
+// writeExport answers with the executions of a context in format as a download.
+func (c *CodeInterpretingController) writeExport(contextID, format string, language runtime.Language, executions []runtime.Execution) {
+	if format == "py" {
+		filename := contextID + "." + runtime.ScriptExtension(language)
+		c.ctx.Header("Content-Disposition", "attachment; filename="+filename)
+		c.ctx.Data(http.StatusOK, "text/plain; charset=utf-8", runtime.ExportScript(language, executions))
+		return
+	}
+
+	data, err := runtime.ExportNotebook(language, executions)
+	if err != nil {
+		c.RespondError(
+			http.StatusInternalServerError,
+			model.ErrorCodeRuntimeError,
+			fmt.Sprintf("error exporting code context %s. %v", contextID, err),
+		)
+		return
+	}
+	c.ctx.Header("Content-Disposition", "attachment; filename="+contextID+".ipynb")
+	c.ctx.Data(http.StatusOK, "application/x-ipynb+json", data)
+}
@@ -0,0 +1,100 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/alibaba/opensandbox/execd/pkg/runtime"
+	"github.com/alibaba/opensandbox/execd/pkg/util/safego"
+	"github.com/alibaba/opensandbox/execd/pkg/web/model"
+)
+
+// AttachContextOutput streams the output of code run in a context: the
+// buffered recent events first, then live ones until the client disconnects
+// or the context is deleted. A client reconnecting passes the seq of the last
+// event it received as ?since= (or Last-Event-ID) to resume without repeats.
+func (c *CodeInterpretingController) AttachContextOutput() {
+	contextID := c.ctx.Param("contextId")
+
+	since := c.QueryInt64(c.ctx.Query("since"), -1)
+	if since < 0 {
+		since = c.QueryInt64(c.ctx.GetHeader("Last-Event-ID"), 0)
+	}
+
+	subscription, err := codeRunner.AttachOutput(contextID, since)
+	if errors.Is(err, runtime.ErrContextNotFound) {
+		c.RespondError(
+			http.StatusNotFound,
+			model.ErrorCodeContextNotFound,
+			fmt.Sprintf("context %s not found", contextID),
+		)
+		return
+	}
+	defer subscription.Close()
+
+	ctx, cancel := context.WithCancel(c.ctx.Request.Context())
+	pinged := make(chan struct{})
+	defer func() {
+		cancel()
+		// the writer must not be used once the handler returns.
+		<-pinged
+	}()
+
+	c.setupStreamResponse()
+	safego.Go(func() {
+		defer close(pinged)
+		c.ping(ctx)
+	})
+
+	for _, event := range subscription.Replay {
+		c.writeSingleEvent("AttachOutput", outputStreamEvent(event).ToJSON(), true)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-subscription.Events:
+			if !ok {
+				return
+			}
+			c.writeSingleEvent("AttachOutput", outputStreamEvent(event).ToJSON(), true)
+		}
+	}
+}
+
+// outputStreamEvent converts a buffered output event to a stream event.
+func outputStreamEvent(event runtime.OutputEvent) model.ServerStreamEvent {
+	streamEvent := model.ServerStreamEvent{
+		Type:      model.ServerStreamEventType(event.Type),
+		Seq:       event.Seq,
+		Timestamp: event.Time.UnixMilli(),
+	}
+	switch event.Type {
+	case runtime.OutputStdout, runtime.OutputStderr:
+		streamEvent.Text = event.Text
+	case runtime.OutputResult:
+		streamEvent.Results = renameResultMimeTypes(event.Results)
+		streamEvent.ExecutionCount = event.ExecutionCount
+	case runtime.OutputError:
+		streamEvent.Error = event.Error
+	case runtime.OutputComplete:
+		streamEvent.ExecutionTime = event.ExecutionTime.Milliseconds()
+	}
+	return streamEvent
+}
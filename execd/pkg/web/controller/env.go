@@ -0,0 +1,128 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/alibaba/opensandbox/execd/pkg/runtime"
+	"github.com/alibaba/opensandbox/execd/pkg/web/model"
+)
+
+// EnvController manages the env file (EXECD_ENVS) whose variables every
+// command and new context receives.
+type EnvController struct {
+	*basicController
+}
+
+func NewEnvController(ctx *gin.Context) *EnvController {
+	return &EnvController{basicController: newBasicController(ctx)}
+}
+
+// GetEnvs lists the variables of the env file with masked values, or with
+// their values as written when ?reveal=true comes with the admin token.
+func (c *EnvController) GetEnvs() {
+	reveal := false
+	switch c.ctx.Query("reveal") {
+	case "true", "1":
+		reveal = true
+	}
+	if reveal && !c.isAdmin() {
+		c.RespondError(
+			http.StatusForbidden,
+			model.ErrorCodeAdminForbidden,
+			"revealing env values requires the admin token in "+model.AdminTokenHeader,
+		)
+		return
+	}
+
+	envs, err := runtime.ReadEnvFile()
+	if err != nil {
+		c.respondEnvError(err)
+		return
+	}
+	c.RespondSuccess(envVars(envs, reveal))
+}
+
+// SetEnvs sets variables of the env file; commands and contexts started
+// afterwards receive them without a restart.
+func (c *EnvController) SetEnvs() {
+	var request model.EnvUpdateRequest
+	if err := c.bindJSON(&request); err != nil {
+		c.RespondError(
+			http.StatusBadRequest,
+			model.ErrorCodeInvalidRequest,
+			fmt.Sprintf("error parsing request, MAYBE invalid body format. %v", err),
+		)
+		return
+	}
+	if err := request.Validate(); err != nil {
+		c.RespondValidationError(err)
+		return
+	}
+
+	envs, err := runtime.UpdateEnvFile(request.Envs, nil, request.Replace)
+	if err != nil {
+		c.respondEnvError(err)
+		return
+	}
+	logger.Info("env file updated by %s: %d variables set, replace %v", c.ctx.ClientIP(), len(request.Envs), request.Replace)
+	c.RespondSuccess(envVars(envs, false))
+}
+
+// DeleteEnvs removes the variables named by the key query from the env file.
+func (c *EnvController) DeleteEnvs() {
+	keys := c.ctx.QueryArray("key")
+	if len(keys) == 0 {
+		c.RespondError(
+			http.StatusBadRequest,
+			model.ErrorCodeMissingQuery,
+			"missing query parameter 'key'",
+		)
+		return
+	}
+
+	envs, err := runtime.UpdateEnvFile(nil, keys, false)
+	if err != nil {
+		c.respondEnvError(err)
+		return
+	}
+	logger.Info("env file updated by %s: %d variables removed", c.ctx.ClientIP(), len(keys))
+	c.RespondSuccess(envVars(envs, false))
+}
+
+func (c *EnvController) respondEnvError(err error) {
+	if errors.Is(err, runtime.ErrEnvFileNotConfigured) {
+		c.RespondError(http.StatusNotFound, model.ErrorCodeEnvFileNotConfigured, err.Error())
+		return
+	}
+	c.RespondError(http.StatusInternalServerError, model.ErrorCodeRuntimeError, err.Error())
+}
+
+// envVars returns envs as a response, masking the values unless reveal is set.
+func envVars(envs map[string]string, reveal bool) model.EnvVars {
+	resp := model.EnvVars{Envs: make(map[string]string, len(envs)), Revealed: reveal}
+	for key, value := range envs {
+		if !reveal {
+			value = model.MaskedEnvValue
+		}
+		resp.Envs[key] = value
+	}
+	return resp
+}
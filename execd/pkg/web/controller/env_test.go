@@ -0,0 +1,78 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/alibaba/opensandbox/execd/pkg/flag"
+	"github.com/alibaba/opensandbox/execd/pkg/web/model"
+)
+
+func TestEnvController_MasksValuesUnlessRevealedByAdmin(t *testing.T) {
+	t.Setenv("EXECD_ENVS", filepath.Join(t.TempDir(), ".env"))
+	original := flag.ServerAdminToken
+	t.Cleanup(func() { flag.ServerAdminToken = original })
+	flag.ServerAdminToken = "admin-secret"
+
+	ctx, rec := newTestContext(http.MethodPut, "/env", []byte(`{"envs":{"API_KEY":"sk-123"}}`))
+	NewEnvController(ctx).SetEnvs()
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	ctx, rec = newTestContext(http.MethodGet, "/env", nil)
+	NewEnvController(ctx).GetEnvs()
+	var envs model.EnvVars
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &envs))
+	assert.Equal(t, model.MaskedEnvValue, envs.Envs["API_KEY"])
+
+	ctx, rec = newTestContext(http.MethodGet, "/env?reveal=true", nil)
+	NewEnvController(ctx).GetEnvs()
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	ctx, rec = newTestContext(http.MethodGet, "/env?reveal=true", nil)
+	ctx.Request.Header.Set(model.AdminTokenHeader, "admin-secret")
+	NewEnvController(ctx).GetEnvs()
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &envs))
+	assert.Equal(t, "sk-123", envs.Envs["API_KEY"])
+	assert.True(t, envs.Revealed)
+
+	ctx, rec = newTestContext(http.MethodDelete, "/env?key=API_KEY", nil)
+	NewEnvController(ctx).DeleteEnvs()
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var remaining model.EnvVars
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &remaining))
+	assert.Empty(t, remaining.Envs)
+}
+
+func TestEnvController_RejectsInvalidVariables(t *testing.T) {
+	t.Setenv("EXECD_ENVS", filepath.Join(t.TempDir(), ".env"))
+
+	for _, body := range []string{`{"envs":{"1BAD":"x"}}`, `{"envs":{"A":"x\ny"}}`, `{}`} {
+		ctx, rec := newTestContext(http.MethodPut, "/env", []byte(body))
+		NewEnvController(ctx).SetEnvs()
+		assert.Equal(t, http.StatusBadRequest, rec.Code, body)
+	}
+
+	t.Setenv("EXECD_ENVS", "")
+	ctx, rec := newTestContext(http.MethodGet, "/env", nil)
+	NewEnvController(ctx).GetEnvs()
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
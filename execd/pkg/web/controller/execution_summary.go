@@ -0,0 +1,248 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alibaba/opensandbox/execd/pkg/jupyter/execute"
+	"github.com/alibaba/opensandbox/execd/pkg/runtime"
+	"github.com/alibaba/opensandbox/execd/pkg/util/safego"
+	"github.com/alibaba/opensandbox/execd/pkg/web/model"
+)
+
+// summaryTimeoutGrace lets a run stopped by its timeout report its last events
+// before a non-streaming request gives up waiting for it.
+const summaryTimeoutGrace = time.Second
+
+// wantsStream reports whether the run is answered with an event stream, the
+// default, rather than a single summary asked for by ?stream=false or an
+// Accept header naming only application/json.
+func (c *CodeInterpretingController) wantsStream() bool {
+	switch c.ctx.Query("stream") {
+	case "false", "0":
+		return false
+	case "true", "1":
+		return true
+	}
+	accept := c.ctx.GetHeader("Accept")
+	return !strings.Contains(accept, "application/json") ||
+		strings.Contains(accept, "text/event-stream") ||
+		strings.Contains(accept, ndjsonContentType)
+}
+
+// runToCompletion executes request and answers with its summary once it ends,
+// or with 408 and the output so far once its timeout elapses. A non-nil tracker
+// adds the artifacts of the run to the summary.
+func (c *CodeInterpretingController) runToCompletion(request *runtime.ExecuteCodeRequest, tracker *artifactTracker) {
+	collector := newExecutionCollector(request.Language == runtime.Command)
+	request.Hooks = collector.hooks()
+
+	start := time.Now()
+	timedOut, err := awaitRun(func() error { return codeRunner.Execute(request) }, request.Timeout)
+	if err != nil && !endedByErrorEvent(err) {
+		c.RespondError(
+			http.StatusInternalServerError,
+			model.ErrorCodeRuntimeError,
+			fmt.Sprintf("error running codes %v", err),
+		)
+		return
+	}
+
+	summary := collector.summary(time.Since(start))
+	summary.TimedOut = timedOut
+	summary.CwdCreated = c.cwdCreated
+	if tracker != nil {
+		summary.Artifacts = tracker.artifacts()
+	}
+	c.respondSummary(timedOut, summary)
+}
+
+// runCellsToCompletion executes the cells of request and answers with one
+// summary per cell run, the same way runToCompletion answers a single run.
+func (c *CodeInterpretingController) runCellsToCompletion(request *runtime.ExecuteBatchRequest) {
+	collector := newExecutionCollector(false)
+	request.Hooks = collector.hooks()
+	request.OnSnippetStart = func(int) { collector.startCell() }
+	request.OnSnippetEnd = func(_ int, elapsed time.Duration, _ bool) { collector.endCell(elapsed) }
+
+	timedOut, err := awaitRun(func() error { return codeRunner.ExecuteBatch(c.ctx.Request.Context(), request) }, request.Timeout)
+	if err != nil {
+		c.RespondError(
+			http.StatusInternalServerError,
+			model.ErrorCodeRuntimeError,
+			fmt.Sprintf("error running codes %v", err),
+		)
+		return
+	}
+
+	c.respondSummary(timedOut, collector.cellSummaries(timedOut))
+}
+
+// respondSummary answers with body, as 408 when the run timed out.
+func (c *CodeInterpretingController) respondSummary(timedOut bool, body any) {
+	if timedOut {
+		c.ctx.JSON(http.StatusRequestTimeout, body)
+		return
+	}
+	c.RespondSuccess(body)
+}
+
+// awaitRun calls execute and waits for it to return, or for timeout plus a
+// grace once timeout is set. It reports whether the run hit its timeout, and
+// the error of a run that failed otherwise.
+func awaitRun(execute func() error, timeout time.Duration) (bool, error) {
+	start := time.Now()
+	done := make(chan error, 1)
+	safego.Go(func() {
+		defer close(done)
+		done <- execute()
+	})
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout + summaryTimeoutGrace)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	select {
+	case err := <-done:
+		// the runtime cancels a run at its timeout, which fails code runs.
+		if timeout > 0 && time.Since(start) >= timeout {
+			return true, nil
+		}
+		return false, err
+	case <-expired:
+		return true, nil
+	}
+}
+
+// executionCollector gathers the events of a run into an ExecutionSummary.
+type executionCollector struct {
+	// lines is set for shell commands, whose output arrives line by line
+	// without line endings.
+	lines bool
+
+	mu      sync.Mutex
+	stdout  strings.Builder
+	stderr  strings.Builder
+	current model.ExecutionSummary
+
+	// cells holds the summaries of the cells run so far, and cellStart the
+	// start of the running cell, zero between cells.
+	cells     []model.ExecutionSummary
+	cellStart time.Time
+}
+
+func newExecutionCollector(lines bool) *executionCollector {
+	return &executionCollector{lines: lines}
+}
+
+func (e *executionCollector) hooks() runtime.ExecuteResultHook {
+	return runtime.ExecuteResultHook{
+		OnExecuteInit: func(string) {},
+		OnExecuteResult: func(result map[string]any, count int) {
+			e.mu.Lock()
+			defer e.mu.Unlock()
+			if count > 0 {
+				e.current.ExecutionCount = count
+			}
+			if len(result) > 0 {
+				e.current.Results = append(e.current.Results, renameResultMimeTypes(result))
+			}
+		},
+		OnExecuteStatus:   func(string) {},
+		OnExecuteStdout:   func(text string) { e.write(&e.stdout, text) },
+		OnExecuteStderr:   func(text string) { e.write(&e.stderr, text) },
+		OnExecuteProgress: func(runtime.Progress) {},
+		OnExecuteError: func(err *execute.ErrorOutput) {
+			if err == nil {
+				return
+			}
+			e.mu.Lock()
+			defer e.mu.Unlock()
+			e.current.Error = err
+		},
+		OnExecuteComplete: func(time.Duration) {},
+		OnCommandExit: func(status runtime.CommandExitStatus) {
+			e.mu.Lock()
+			defer e.mu.Unlock()
+			e.current.Exit = commandExit(status)
+		},
+	}
+}
+
+func (e *executionCollector) write(output *strings.Builder, text string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	output.WriteString(text)
+	if e.lines {
+		output.WriteByte('\n')
+	}
+}
+
+// summary returns what the run reported so far.
+func (e *executionCollector) summary(duration time.Duration) model.ExecutionSummary {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.summaryLocked(duration)
+}
+
+// startCell starts collecting the output of the next cell.
+func (e *executionCollector) startCell() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.stdout.Reset()
+	e.stderr.Reset()
+	e.current = model.ExecutionSummary{}
+	e.cellStart = time.Now()
+}
+
+// endCell records the summary of the running cell.
+func (e *executionCollector) endCell(elapsed time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.cells = append(e.cells, e.summaryLocked(elapsed))
+	e.cellStart = time.Time{}
+}
+
+// cellSummaries returns the summaries of the cells run so far, followed by the
+// output of the running cell, if any, marked timed out when timedOut is set.
+func (e *executionCollector) cellSummaries(timedOut bool) []model.ExecutionSummary {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	cells := append([]model.ExecutionSummary{}, e.cells...)
+	if !e.cellStart.IsZero() {
+		running := e.summaryLocked(time.Since(e.cellStart))
+		running.TimedOut = timedOut
+		cells = append(cells, running)
+	}
+	return cells
+}
+
+func (e *executionCollector) summaryLocked(duration time.Duration) model.ExecutionSummary {
+	summary := e.current
+	summary.Results = append([]map[string]any(nil), e.current.Results...)
+	summary.Stdout = e.stdout.String()
+	summary.Stderr = e.stderr.String()
+	summary.Duration = duration.Milliseconds()
+	return summary
+}
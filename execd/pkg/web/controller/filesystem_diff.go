@@ -0,0 +1,124 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+
+	"github.com/alibaba/opensandbox/execd/pkg/web/model"
+)
+
+// maxDiffFileSize caps both the file and the proposed content of a diff.
+const maxDiffFileSize = 4 << 20
+
+// DiffFile returns the unified diff a content change would make to a file, without writing it.
+func (c *FilesystemController) DiffFile() {
+	var request model.FileDiffRequest
+	if err := c.bindJSON(&request); err != nil {
+		c.RespondError(
+			http.StatusBadRequest,
+			model.ErrorCodeInvalidRequest,
+			fmt.Sprintf("error parsing request, MAYBE invalid body format. %v", err),
+		)
+		return
+	}
+	if err := request.Validate(); err != nil {
+		c.RespondValidationError(err)
+		return
+	}
+
+	file, err := filepath.Abs(request.Path)
+	if err != nil {
+		c.handleFileError(err)
+		return
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		c.handleFileError(err)
+		return
+	}
+	if info.IsDir() {
+		c.RespondError(http.StatusBadRequest, model.ErrorCodeInvalidFile, fmt.Sprintf("%s is a directory", file))
+		return
+	}
+	if info.Size() > maxDiffFileSize {
+		c.respondDiffTooLarge(file)
+		return
+	}
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		c.handleFileError(err)
+		return
+	}
+
+	var proposed string
+	if request.Content != nil {
+		proposed = *request.Content
+	} else {
+		proposed = strings.ReplaceAll(string(content), request.Replace.Old, request.Replace.New)
+	}
+	if len(proposed) > maxDiffFileSize {
+		c.respondDiffTooLarge(file)
+		return
+	}
+
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        diffLines(string(content)),
+		B:        diffLines(proposed),
+		FromFile: "a" + filepath.ToSlash(file),
+		ToFile:   "b" + filepath.ToSlash(file),
+		Context:  3,
+	})
+	if err != nil {
+		c.RespondError(
+			http.StatusInternalServerError,
+			model.ErrorCodeRuntimeError,
+			fmt.Sprintf("error computing diff. %v", err),
+		)
+		return
+	}
+
+	c.RespondSuccess(model.FileDiff{
+		Path:    file,
+		Changed: proposed != string(content),
+		Diff:    diff,
+	})
+}
+
+func (c *FilesystemController) respondDiffTooLarge(file string) {
+	c.RespondError(
+		http.StatusRequestEntityTooLarge,
+		model.ErrorCodeFileTooLarge,
+		fmt.Sprintf("%s exceeds the diff limit of %d bytes", file, maxDiffFileSize),
+	)
+}
+
+// diffLines splits text into newline-terminated lines. Unlike difflib.SplitLines
+// it doesn't turn the final newline of a file into an extra empty line.
+func diffLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		return lines[:len(lines)-1]
+	}
+	lines[len(lines)-1] += "\n"
+	return lines
+}
@@ -0,0 +1,115 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alibaba/opensandbox/execd/pkg/web/model"
+)
+
+func TestFilesystemControllerDiffFile(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "greeting.txt")
+	if err := os.WriteFile(target, []byte("one\ntwo\nhello world\nthree\n"), 0o644); err != nil {
+		t.Fatalf("write temp file: %v", err)
+	}
+
+	body, _ := json.Marshal(model.FileDiffRequest{
+		Path:    target,
+		Replace: &model.ReplaceFileContentItem{Old: "world", New: "universe"},
+	})
+	ctrl, rec := newFilesystemController(t, http.MethodPost, "/files/diff", body)
+
+	ctrl.DiffFile()
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var diff model.FileDiff
+	if err := json.Unmarshal(rec.Body.Bytes(), &diff); err != nil {
+		t.Fatalf("unmarshal response: %v", err)
+	}
+	want := strings.Join([]string{
+		"--- a" + target,
+		"+++ b" + target,
+		"@@ -1,4 +1,4 @@",
+		" one",
+		" two",
+		"-hello world",
+		"+hello universe",
+		" three",
+		"",
+	}, "\n")
+	if !diff.Changed || diff.Diff != want {
+		t.Fatalf("unexpected diff:\n%s\nwant:\n%s", diff.Diff, want)
+	}
+
+	data, _ := os.ReadFile(target)
+	if string(data) != "one\ntwo\nhello world\nthree\n" {
+		t.Fatalf("diff must not modify the file, got %q", data)
+	}
+}
+
+func TestFilesystemControllerDiffFileUnchanged(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "same.txt")
+	if err := os.WriteFile(target, []byte("same\n"), 0o644); err != nil {
+		t.Fatalf("write temp file: %v", err)
+	}
+	content := "same\n"
+	body, _ := json.Marshal(model.FileDiffRequest{Path: target, Content: &content})
+	ctrl, rec := newFilesystemController(t, http.MethodPost, "/files/diff", body)
+
+	ctrl.DiffFile()
+
+	var diff model.FileDiff
+	if err := json.Unmarshal(rec.Body.Bytes(), &diff); err != nil {
+		t.Fatalf("unmarshal response: %v", err)
+	}
+	if rec.Code != http.StatusOK || diff.Changed || diff.Diff != "" {
+		t.Fatalf("expected empty diff, got %d %+v", rec.Code, diff)
+	}
+}
+
+func TestFilesystemControllerDiffFileRejectsLargeContent(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "small.txt")
+	if err := os.WriteFile(target, []byte("small\n"), 0o644); err != nil {
+		t.Fatalf("write temp file: %v", err)
+	}
+	content := strings.Repeat("x", maxDiffFileSize+1)
+	body, _ := json.Marshal(model.FileDiffRequest{Path: target, Content: &content})
+	ctrl, rec := newFilesystemController(t, http.MethodPost, "/files/diff", body)
+
+	ctrl.DiffFile()
+
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected status 413, got %d", rec.Code)
+	}
+}
+
+func TestFilesystemControllerDiffFileRequiresOneChange(t *testing.T) {
+	body, _ := json.Marshal(model.FileDiffRequest{Path: "/tmp/anything"})
+	ctrl, rec := newFilesystemController(t, http.MethodPost, "/files/diff", body)
+
+	ctrl.DiffFile()
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", rec.Code)
+	}
+}
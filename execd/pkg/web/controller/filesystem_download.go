@@ -17,15 +17,19 @@ package controller
 import (
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strconv"
+	"time"
 
 	"github.com/alibaba/opensandbox/execd/pkg/web/model"
 )
 
-// DownloadFile serves a file for download with support for range requests.
+// DownloadFile serves a file with support for range requests. Its type comes
+// from its extension or, failing that, its first bytes, so browsers display
+// images and PDFs inline; download=true makes it an attachment instead.
 func (c *FilesystemController) DownloadFile() {
 	filePath := c.ctx.Query("path")
 	if filePath == "" {
@@ -54,12 +58,43 @@ func (c *FilesystemController) DownloadFile() {
 		return
 	}
 
-	c.ctx.Header("Content-Type", "application/octet-stream")
-	c.ctx.Header("Content-Disposition", "attachment; filename="+filepath.Base(filePath))
-	c.ctx.Header("Content-Length", strconv.FormatInt(fileInfo.Size(), 10))
+	name := filepath.Base(filePath)
+	c.ctx.Header("Content-Type", detectContentType(name, file))
+	// the type is trusted as detected, browsers must not sniff a riskier one.
+	c.ctx.Header("X-Content-Type-Options", "nosniff")
+	disposition := "inline"
+	if c.ctx.Query("download") == "true" {
+		disposition = "attachment"
+	}
+	c.serveContent(file, disposition, name, fileInfo.Size(), fileInfo.ModTime())
+}
+
+// detectContentType returns the media type of a file by the extension of its
+// name, or by sniffing its first bytes when the extension is unknown. content
+// is left at its start.
+func detectContentType(name string, content io.ReadSeeker) string {
+	if contentType := mime.TypeByExtension(filepath.Ext(name)); contentType != "" {
+		return contentType
+	}
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(content, head)
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "application/octet-stream"
+	}
+	return http.DetectContentType(head[:n])
+}
+
+// serveContent sends content named name, inline or as an attachment as
+// disposition says, answering a Range request with the first range it asks for.
+func (c *basicController) serveContent(content io.ReadSeeker, disposition, name string, size int64, modTime time.Time) {
+	// file bodies may be large, don't bound them by the per-response deadline.
+	c.clearWriteDeadline()
+
+	c.ctx.Header("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": name}))
+	c.ctx.Header("Content-Length", strconv.FormatInt(size, 10))
 
 	if rangeHeader := c.ctx.GetHeader("Range"); rangeHeader != "" {
-		ranges, err := ParseRange(rangeHeader, fileInfo.Size())
+		ranges, err := ParseRange(rangeHeader, size)
 		if err != nil {
 			c.RespondError(
 				http.StatusRequestedRangeNotSatisfiable,
@@ -70,14 +105,14 @@ func (c *FilesystemController) DownloadFile() {
 		if len(ranges) > 0 {
 			r := ranges[0]
 			c.ctx.Status(http.StatusPartialContent)
-			c.ctx.Header("Content-Range", fmt.Sprintf("bytes %d-%d/%d", r.start, r.start+r.length-1, fileInfo.Size()))
+			c.ctx.Header("Content-Range", fmt.Sprintf("bytes %d-%d/%d", r.start, r.start+r.length-1, size))
 			c.ctx.Header("Content-Length", strconv.FormatInt(r.length, 10))
 
-			_, _ = file.Seek(r.start, io.SeekStart)
-			_, _ = io.CopyN(c.ctx.Writer, file, r.length)
+			_, _ = content.Seek(r.start, io.SeekStart)
+			_, _ = io.CopyN(c.ctx.Writer, content, r.length)
 			return
 		}
 	}
 
-	http.ServeContent(c.ctx.Writer, c.ctx.Request, filepath.Base(filePath), fileInfo.ModTime(), file)
+	http.ServeContent(c.ctx.Writer, c.ctx.Request, name, modTime, content)
 }
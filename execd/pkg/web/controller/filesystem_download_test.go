@@ -13,3 +13,58 @@
 // limitations under the License.
 
 package controller
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestFilesystemControllerDownloadFileContentType(t *testing.T) {
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, image.NewGray(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	named := filepath.Join(dir, "plot.png")
+	// without an extension the type is sniffed from the content.
+	unnamed := filepath.Join(dir, "plot")
+	for _, path := range []string{named, unnamed} {
+		if err := os.WriteFile(path, encoded.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tc := range []struct {
+		path, query, disposition string
+	}{
+		{named, "", `inline; filename=plot.png`},
+		{unnamed, "", `inline; filename=plot`},
+		{named, "&download=true", `attachment; filename=plot.png`},
+	} {
+		ctrl, rec := newFilesystemController(t, http.MethodGet, "/files/download?path="+url.QueryEscape(tc.path)+tc.query, nil)
+		ctrl.DownloadFile()
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s%s: expected 200, got %d: %s", tc.path, tc.query, rec.Code, rec.Body.String())
+		}
+		if got := rec.Header().Get("Content-Type"); got != "image/png" {
+			t.Fatalf("%s%s: expected image/png, got %q", tc.path, tc.query, got)
+		}
+		if got := rec.Header().Get("Content-Disposition"); got != tc.disposition {
+			t.Fatalf("%s%s: expected disposition %q, got %q", tc.path, tc.query, tc.disposition, got)
+		}
+		if got := rec.Header().Get("Content-Length"); got != strconv.Itoa(encoded.Len()) {
+			t.Fatalf("%s%s: expected Content-Length %d, got %q", tc.path, tc.query, encoded.Len(), got)
+		}
+		if !bytes.Equal(rec.Body.Bytes(), encoded.Bytes()) {
+			t.Fatalf("%s%s: the sniffed bytes are missing from the body", tc.path, tc.query)
+		}
+	}
+}
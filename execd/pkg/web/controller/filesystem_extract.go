@@ -0,0 +1,195 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alibaba/opensandbox/execd/pkg/web/model"
+)
+
+const (
+	// maxExtractedSize caps the uncompressed size of an extracted archive.
+	maxExtractedSize = 1 << 30
+	// maxExtractedEntries caps the number of entries of an extracted archive.
+	maxExtractedEntries = 10000
+)
+
+var (
+	errArchiveTooLarge = fmt.Errorf("archive expands beyond %d bytes or %d entries", maxExtractedSize, maxExtractedEntries)
+	errUnsafeEntry     = errors.New("unsafe archive entry")
+)
+
+// extractZip extracts the zip archive r of size bytes into dir. Every entry is
+// checked before anything but dir is written: entries escaping dir, lexically
+// or through a symbolic link already under dir, links and archives expanding
+// beyond the limits reject the whole archive. Entries keep the
+// permission bits they were archived with, and get owner and group of perms.
+func extractZip(r io.ReaderAt, size int64, dir string, perms model.Permission) error {
+	archive, err := zip.NewReader(r, size)
+	if errors.Is(err, zip.ErrInsecurePath) {
+		return fmt.Errorf("%w: %v", errUnsafeEntry, err)
+	}
+	if err != nil {
+		return err
+	}
+	if len(archive.File) > maxExtractedEntries {
+		return errArchiveTooLarge
+	}
+
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+	root, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return err
+	}
+
+	var total uint64
+	for _, entry := range archive.File {
+		if !filepath.IsLocal(filepath.FromSlash(entry.Name)) {
+			return fmt.Errorf("%w: %s escapes the target directory", errUnsafeEntry, entry.Name)
+		}
+		if err := checkEntryLinks(root, filepath.FromSlash(entry.Name)); err != nil {
+			return fmt.Errorf("%w: %s %v", errUnsafeEntry, entry.Name, err)
+		}
+		if !entry.Mode().IsRegular() && !entry.Mode().IsDir() {
+			return fmt.Errorf("%w: %s is not a regular file or directory", errUnsafeEntry, entry.Name)
+		}
+		total += entry.UncompressedSize64
+		if total > maxExtractedSize {
+			return errArchiveTooLarge
+		}
+	}
+
+	// the sizes in the entry headers are not trusted while copying.
+	remaining := int64(maxExtractedSize)
+	for _, entry := range archive.File {
+		target := filepath.Join(dir, filepath.FromSlash(entry.Name))
+		if entry.Mode().IsDir() {
+			err = os.MkdirAll(target, os.ModePerm)
+		} else {
+			remaining, err = extractZipEntry(entry, target, remaining)
+		}
+		if err != nil {
+			return fmt.Errorf("error extracting %s: %w", entry.Name, err)
+		}
+		if err := os.Chmod(target, entryMode(entry)); err != nil {
+			return err
+		}
+		if err := SetFileOwnership(target, perms.Owner, perms.Group); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkEntryLinks follows the symbolic links already on the path of the local
+// name under root and fails if one resolves outside root or doesn't resolve.
+func checkEntryLinks(root, name string) error {
+	current := root
+	for _, part := range strings.Split(filepath.Clean(name), string(filepath.Separator)) {
+		current = filepath.Join(current, part)
+		info, err := os.Lstat(current)
+		if errors.Is(err, fs.ErrNotExist) {
+			// the rest of the path is created by the extraction.
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink == 0 {
+			continue
+		}
+		resolved, err := filepath.EvalSymlinks(current)
+		if err != nil {
+			return fmt.Errorf("goes through the unresolvable link %s", current)
+		}
+		if rel, err := filepath.Rel(root, resolved); err != nil || (rel != "." && !filepath.IsLocal(rel)) {
+			return fmt.Errorf("escapes the target directory through the link %s", current)
+		}
+		current = resolved
+	}
+	return nil
+}
+
+// extractZipEntry writes the file entry to target, failing once more than
+// remaining bytes were written in total. It returns the bytes left.
+func extractZipEntry(entry *zip.File, target string, remaining int64) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+		return remaining, err
+	}
+
+	src, err := entry.Open()
+	if err != nil {
+		return remaining, err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return remaining, err
+	}
+	written, err := io.Copy(dst, io.LimitReader(src, remaining+1))
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return remaining, err
+	}
+	if written > remaining {
+		return remaining, errArchiveTooLarge
+	}
+	return remaining - written, nil
+}
+
+// entryMode returns the permission bits of entry, defaulting to 0755 for
+// directories and 0644 for files archived without any.
+func entryMode(entry *zip.File) os.FileMode {
+	mode := entry.Mode().Perm()
+	switch {
+	case mode != 0:
+		return mode
+	case entry.Mode().IsDir():
+		return 0o755
+	default:
+		return 0o644
+	}
+}
+
+// respondExtractError maps a failed archive extraction to its response.
+func (c *FilesystemController) respondExtractError(dir string, err error) {
+	switch {
+	case errors.Is(err, errArchiveTooLarge):
+		c.RespondError(http.StatusRequestEntityTooLarge, model.ErrorCodeFileTooLarge, err.Error())
+	case errors.Is(err, errUnsafeEntry), errors.Is(err, zip.ErrFormat),
+		errors.Is(err, zip.ErrAlgorithm), errors.Is(err, zip.ErrChecksum):
+		c.RespondError(http.StatusBadRequest, model.ErrorCodeInvalidArchive, err.Error())
+	default:
+		c.RespondError(
+			http.StatusInternalServerError,
+			model.ErrorCodeRuntimeError,
+			fmt.Sprintf("error extracting archive into %s. %v", dir, err),
+		)
+	}
+}
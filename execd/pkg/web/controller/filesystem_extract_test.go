@@ -0,0 +1,187 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	goruntime "runtime"
+	"testing"
+
+	"github.com/alibaba/opensandbox/execd/pkg/web/model"
+)
+
+type zipEntry struct {
+	name    string
+	content string
+	mode    os.FileMode
+}
+
+func buildZip(t *testing.T, entries ...zipEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for _, entry := range entries {
+		header := &zip.FileHeader{Name: entry.name, Method: zip.Deflate}
+		header.SetMode(entry.mode)
+		w, err := archive.CreateHeader(header)
+		if err != nil {
+			t.Fatalf("create zip entry %s: %v", entry.name, err)
+		}
+		if _, err := w.Write([]byte(entry.content)); err != nil {
+			t.Fatalf("write zip entry %s: %v", entry.name, err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatalf("close zip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestExtractZip(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "project")
+	data := buildZip(t,
+		zipEntry{name: "src/", mode: os.ModeDir | 0o755},
+		zipEntry{name: "src/main.py", content: "print('hi')\n", mode: 0o644},
+		zipEntry{name: "run.sh", content: "#!/bin/sh\n", mode: 0o755},
+	)
+
+	if err := extractZip(bytes.NewReader(data), int64(len(data)), dir, model.Permission{}); err != nil {
+		t.Fatalf("extractZip returned error: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "src", "main.py"))
+	if err != nil || string(content) != "print('hi')\n" {
+		t.Fatalf("unexpected extracted content %q: %v", content, err)
+	}
+	if goruntime.GOOS != "windows" {
+		info, err := os.Stat(filepath.Join(dir, "run.sh"))
+		if err != nil || info.Mode().Perm() != 0o755 {
+			t.Fatalf("expected run.sh to keep its mode, got %v: %v", info, err)
+		}
+	}
+}
+
+func TestExtractZip_RejectsUnsafeEntries(t *testing.T) {
+	for name, entry := range map[string]zipEntry{
+		"traversal": {name: "../evil.txt", content: "pwned", mode: 0o644},
+		"absolute":  {name: "/tmp/evil.txt", content: "pwned", mode: 0o644},
+		"symlink":   {name: "link", content: "/etc/passwd", mode: os.ModeSymlink | 0o777},
+	} {
+		t.Run(name, func(t *testing.T) {
+			root := t.TempDir()
+			dir := filepath.Join(root, "project")
+			data := buildZip(t, zipEntry{name: "ok.txt", content: "ok", mode: 0o644}, entry)
+
+			err := extractZip(bytes.NewReader(data), int64(len(data)), dir, model.Permission{})
+			if !errors.Is(err, errUnsafeEntry) {
+				t.Fatalf("expected an unsafe entry error, got %v", err)
+			}
+			if _, err := os.Stat(filepath.Join(dir, "ok.txt")); !os.IsNotExist(err) {
+				t.Fatalf("expected nothing to be extracted, got %v", err)
+			}
+			if _, err := os.Stat(filepath.Join(root, "evil.txt")); !os.IsNotExist(err) {
+				t.Fatalf("expected no file outside the target, got %v", err)
+			}
+		})
+	}
+}
+
+func TestExtractZip_RejectsEntriesThroughEscapingLinks(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "project")
+	outside := filepath.Join(root, "outside")
+	for _, path := range []string{filepath.Join(dir, "sub"), outside} {
+		if err := os.MkdirAll(path, 0o755); err != nil {
+			t.Fatalf("mkdir %s: %v", path, err)
+		}
+	}
+	for link, target := range map[string]string{
+		"out":      outside,
+		"file.txt": filepath.Join(outside, "file.txt"),
+		"dangling": filepath.Join(root, "missing"),
+		"inside":   filepath.Join(dir, "sub"),
+	} {
+		if err := os.Symlink(target, filepath.Join(dir, link)); err != nil {
+			t.Skipf("symbolic links unavailable: %v", err)
+		}
+	}
+
+	for _, entry := range []zipEntry{
+		{name: "out/evil.txt", content: "pwned", mode: 0o644},
+		{name: "out/", mode: os.ModeDir | 0o777},
+		{name: "file.txt", content: "pwned", mode: 0o644},
+		{name: "dangling/evil.txt", content: "pwned", mode: 0o644},
+	} {
+		data := buildZip(t, zipEntry{name: "ok.txt", content: "ok", mode: 0o644}, entry)
+		err := extractZip(bytes.NewReader(data), int64(len(data)), dir, model.Permission{})
+		if !errors.Is(err, errUnsafeEntry) {
+			t.Fatalf("%s: expected an unsafe entry error, got %v", entry.name, err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "ok.txt")); !os.IsNotExist(err) {
+			t.Fatalf("%s: expected nothing to be extracted, got %v", entry.name, err)
+		}
+	}
+	if entries, _ := os.ReadDir(outside); len(entries) != 0 {
+		t.Fatalf("expected nothing written outside the target, got %v", entries)
+	}
+	if _, err := os.Stat(filepath.Join(root, "missing")); !os.IsNotExist(err) {
+		t.Fatalf("expected the dangling link not to be followed, got %v", err)
+	}
+
+	data := buildZip(t, zipEntry{name: "inside/kept.txt", content: "kept", mode: 0o644})
+	if err := extractZip(bytes.NewReader(data), int64(len(data)), dir, model.Permission{}); err != nil {
+		t.Fatalf("expected a link within the target to be followed, got %v", err)
+	}
+	if content, err := os.ReadFile(filepath.Join(dir, "sub", "kept.txt")); err != nil || string(content) != "kept" {
+		t.Fatalf("unexpected extracted content %q: %v", content, err)
+	}
+}
+
+func TestUploadFile_ExtractsArchive(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "project")
+	uploads := [][]byte{
+		buildZip(t, zipEntry{name: "a.txt", content: "a", mode: 0o644}),
+		buildZip(t, zipEntry{name: "../b.txt", content: "b", mode: 0o644}),
+	}
+
+	for i, expected := range []int{http.StatusOK, http.StatusBadRequest} {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		meta, _ := json.Marshal(model.FileMetadata{Path: dir, Extract: true})
+		part, _ := form.CreateFormFile("metadata", "metadata.json")
+		_, _ = part.Write(meta)
+		part, _ = form.CreateFormFile("file", "project.zip")
+		_, _ = part.Write(uploads[i])
+		_ = form.Close()
+
+		ctx, w := newTestContext(http.MethodPost, "/files/upload", body.Bytes())
+		ctx.Request.Header.Set("Content-Type", form.FormDataContentType())
+		NewFilesystemController(ctx).UploadFile()
+		if w.Code != expected {
+			t.Fatalf("upload %d: expected %d, got %d: %s", i, expected, w.Code, w.Body.String())
+		}
+	}
+
+	if content, err := os.ReadFile(filepath.Join(dir, "a.txt")); err != nil || string(content) != "a" {
+		t.Fatalf("unexpected extracted content %q: %v", content, err)
+	}
+}
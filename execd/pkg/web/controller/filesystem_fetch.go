@@ -0,0 +1,385 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/alibaba/opensandbox/execd/pkg/runtime"
+	"github.com/alibaba/opensandbox/execd/pkg/util/safego"
+	"github.com/alibaba/opensandbox/execd/pkg/web/model"
+)
+
+const (
+	// fetchMaxRedirects bounds the redirects a fetch follows.
+	fetchMaxRedirects = 5
+	// fetchDefaultMaxSize caps downloads whose request sets no max_size.
+	fetchDefaultMaxSize = 4 << 30
+	// fetchDefaultTimeout bounds fetches whose request sets no timeout.
+	fetchDefaultTimeout = 30 * time.Minute
+	// fetchProgressInterval is the period of the progress lines of background fetches.
+	fetchProgressInterval = time.Second
+)
+
+var (
+	errFetchFailed      = errors.New("fetch failed")
+	errFetchTooLarge    = errors.New("download exceeds the size limit")
+	errFetchContentType = errors.New("unsupported content type")
+	errFetchChecksum    = errors.New("sha256 checksum mismatch")
+)
+
+// fetchClient follows at most fetchMaxRedirects redirects, to http and https
+// URLs only.
+var fetchClient = &http.Client{
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= fetchMaxRedirects {
+			return fmt.Errorf("stopped after %d redirects", fetchMaxRedirects)
+		}
+		if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+			return fmt.Errorf("redirect to unsupported scheme %q", req.URL.Scheme)
+		}
+		return nil
+	},
+}
+
+// FetchFile downloads a URL into a file server-side. The download is written
+// to a temporary file next to dest_path and renamed over it once complete, so
+// readers never see a partial file. A background fetch answers 202 at once
+// with the command session id the fetch is tracked under, like a push.
+func (c *FilesystemController) FetchFile() {
+	var request model.FileFetchRequest
+	if err := c.bindJSON(&request); err != nil {
+		c.RespondError(
+			http.StatusBadRequest,
+			model.ErrorCodeInvalidRequest,
+			fmt.Sprintf("error parsing request, MAYBE invalid body format. %v", err),
+		)
+		return
+	}
+	if err := request.Validate(); err != nil {
+		c.RespondValidationError(err)
+		return
+	}
+
+	timeout := fetchDefaultTimeout
+	if request.TimeoutSeconds > 0 {
+		timeout = time.Duration(request.TimeoutSeconds) * time.Second
+	}
+	job := &fetchJob{request: request, start: time.Now()}
+
+	if request.Background {
+		task, ctx, err := codeRunner.StartTask(context.Background(), fmt.Sprintf("fetch %s into %s", redactURL(request.URL), request.DestPath))
+		if err != nil {
+			c.RespondError(http.StatusInternalServerError, model.ErrorCodeRuntimeError, err.Error())
+			return
+		}
+		job.task = task
+		safego.Go(func() {
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			err := job.run(ctx)
+			result := job.snapshot()
+			task.Logf("fetched %d bytes in %d ms", result.BytesWritten, result.Duration)
+			if err == nil {
+				task.Logf("sha256 %s", result.SHA256)
+			}
+			task.Finish(err)
+		})
+		c.ctx.JSON(http.StatusAccepted, model.FileFetchResult{
+			ID:       task.ID(),
+			URL:      redactURL(request.URL),
+			DestPath: request.DestPath,
+			Status:   model.FetchStatusRunning,
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.ctx.Request.Context(), timeout)
+	defer cancel()
+	if err := job.run(ctx); err != nil {
+		status, code := fetchErrorStatus(err)
+		c.RespondError(status, code, err.Error())
+		return
+	}
+	c.RespondSuccess(job.snapshot())
+}
+
+// fetchJob is one fetch and its progress, logging it to task when run in the
+// background.
+type fetchJob struct {
+	request model.FileFetchRequest
+	task    *runtime.Task
+	start   time.Time
+	written atomic.Int64
+	total   atomic.Int64
+
+	mu       sync.Mutex
+	finished time.Time
+	digest   string
+	file     *model.FileInfo
+	err      error
+}
+
+// Write counts the bytes downloaded.
+func (j *fetchJob) Write(p []byte) (int, error) {
+	j.written.Add(int64(len(p)))
+	return len(p), nil
+}
+
+// run fetches the file and records how it went.
+func (j *fetchJob) run(ctx context.Context) error {
+	file, digest, err := j.fetch(ctx)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.finished = time.Now()
+	j.file, j.digest, j.err = file, digest, err
+	if err != nil {
+		logger.Warning("fetching %s into %s failed: %v", redactURL(j.request.URL), j.request.DestPath, err)
+	}
+	return err
+}
+
+// fetch downloads the URL of the request to its destination and returns the
+// placed file with its sha256 digest.
+func (j *fetchJob) fetch(ctx context.Context) (*model.FileInfo, string, error) {
+	dest, err := filepath.Abs(j.request.DestPath)
+	if err != nil {
+		return nil, "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.request.URL, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %w", errFetchFailed, redactURLError(err))
+	}
+	for name, value := range j.request.Headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := fetchClient.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %w", errFetchFailed, redactURLError(err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, "", fmt.Errorf("%w: %s answered %s", errFetchFailed, redactURL(j.request.URL), resp.Status)
+	}
+	if err := checkContentType(resp.Header.Get("Content-Type"), j.request.ContentTypes); err != nil {
+		return nil, "", err
+	}
+	maxSize := j.request.MaxSize
+	if maxSize <= 0 {
+		maxSize = fetchDefaultMaxSize
+	}
+	if resp.ContentLength > maxSize {
+		return nil, "", fmt.Errorf("%w: %d bytes announced, at most %d allowed", errFetchTooLarge, resp.ContentLength, maxSize)
+	}
+	if resp.ContentLength > 0 {
+		j.total.Store(resp.ContentLength)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dest), os.ModePerm); err != nil {
+		return nil, "", err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dest), "."+filepath.Base(dest)+".fetch-*")
+	if err != nil {
+		return nil, "", err
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck
+
+	hash := sha256.New()
+	stop := j.reportProgress()
+	n, err := io.Copy(io.MultiWriter(tmp, hash, j), io.LimitReader(resp.Body, maxSize+1))
+	stop()
+	if err == nil && n > maxSize {
+		err = fmt.Errorf("%w: more than %d bytes downloaded", errFetchTooLarge, maxSize)
+	}
+	var pathErr *fs.PathError
+	if err != nil && !errors.Is(err, errFetchTooLarge) && !errors.As(err, &pathErr) {
+		err = fmt.Errorf("%w: error downloading %s: %w", errFetchFailed, redactURL(j.request.URL), err)
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	digest := hex.EncodeToString(hash.Sum(nil))
+	if j.request.SHA256 != "" && !strings.EqualFold(digest, j.request.SHA256) {
+		return nil, "", fmt.Errorf("%w: expected %s, got %s", errFetchChecksum, strings.ToLower(j.request.SHA256), digest)
+	}
+
+	// temporary files are private, the placed file gets the usual mode.
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return nil, "", err
+	}
+	if err := ChmodFile(tmp.Name(), j.request.Permission); err != nil {
+		return nil, "", fmt.Errorf("error chmoding file %s. %w", dest, err)
+	}
+	if err := os.Rename(tmp.Name(), dest); err != nil {
+		return nil, "", err
+	}
+	info, err := GetFileInfo(dest)
+	if err != nil {
+		return nil, "", err
+	}
+	return &info, digest, nil
+}
+
+// reportProgress logs the bytes downloaded every fetchProgressInterval until
+// the returned stop is called; it logs nothing for foreground fetches.
+func (j *fetchJob) reportProgress() (stop func()) {
+	if j.task == nil {
+		return func() {}
+	}
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	safego.Go(func() {
+		defer close(stopped)
+		ticker := time.NewTicker(fetchProgressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if total := j.total.Load(); total > 0 {
+					j.task.Logf("downloaded %d of %d bytes", j.written.Load(), total)
+				} else {
+					j.task.Logf("downloaded %d bytes", j.written.Load())
+				}
+			}
+		}
+	})
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// snapshot reports the fetch as it stands.
+func (j *fetchJob) snapshot() model.FileFetchResult {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	result := model.FileFetchResult{
+		URL:          redactURL(j.request.URL),
+		DestPath:     j.request.DestPath,
+		Status:       model.FetchStatusRunning,
+		BytesWritten: j.written.Load(),
+		TotalBytes:   j.total.Load(),
+	}
+	end := j.finished
+	if end.IsZero() {
+		end = time.Now()
+	}
+	result.Duration = end.Sub(j.start).Milliseconds()
+
+	switch {
+	case j.err != nil:
+		result.Status = model.FetchStatusFailed
+		_, result.Code = fetchErrorStatus(j.err)
+		result.Error = j.err.Error()
+	case !j.finished.IsZero():
+		result.Status = model.FetchStatusCompleted
+		result.SHA256 = j.digest
+		result.File = j.file
+	}
+	return result
+}
+
+// checkContentType rejects a response whose media type isn't listed in
+// accepted, where "type/*" accepts every subtype. Any type passes when
+// accepted is empty.
+func checkContentType(contentType string, accepted []string) error {
+	if len(accepted) == 0 {
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return fmt.Errorf("%w: %q", errFetchContentType, contentType)
+	}
+	major, _, _ := strings.Cut(mediaType, "/")
+	if slices.ContainsFunc(accepted, func(allowed string) bool {
+		allowed = strings.ToLower(strings.TrimSpace(allowed))
+		return allowed == mediaType || allowed == major+"/*"
+	}) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s, expected one of %s", errFetchContentType, mediaType, strings.Join(accepted, ", "))
+}
+
+// fetchErrorStatus maps a fetch error to the status and code it is answered with.
+func fetchErrorStatus(err error) (int, model.ErrorCode) {
+	switch {
+	case errors.Is(err, errFetchTooLarge):
+		return http.StatusRequestEntityTooLarge, model.ErrorCodeFileTooLarge
+	case errors.Is(err, errFetchContentType):
+		return http.StatusUnsupportedMediaType, model.ErrorCodeUnsupportedContentType
+	case errors.Is(err, errFetchChecksum):
+		return http.StatusUnprocessableEntity, model.ErrorCodeChecksumMismatch
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, model.ErrorCodeFetchFailed
+	case errors.Is(err, errFetchFailed):
+		return http.StatusBadGateway, model.ErrorCodeFetchFailed
+	default:
+		return http.StatusInternalServerError, model.ErrorCodeRuntimeError
+	}
+}
+
+// redactURL drops the credentials and query, which carry the signature of
+// presigned URLs, from a URL before it is logged.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "<invalid url>"
+	}
+	u.User = nil
+	u.RawQuery = ""
+	u.Fragment = ""
+	return u.String()
+}
+
+// redactURLError redacts the URL that a *url.Error, as returned by
+// http.Client and url.Parse, repeats in its message.
+func redactURLError(err error) error {
+	uerr, ok := err.(*url.Error)
+	if !ok {
+		return err
+	}
+	redacted := *uerr
+	redacted.URL = redactURL(uerr.URL)
+	return &redacted
+}
@@ -0,0 +1,203 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	goruntime "runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alibaba/opensandbox/execd/pkg/runtime"
+	"github.com/alibaba/opensandbox/execd/pkg/web/model"
+)
+
+// newFetchServer serves a CSV dataset at /data.csv, a stream without length
+// at /stream and endless redirects at /loop.
+func newFetchServer(t *testing.T) (*httptest.Server, string) {
+	t.Helper()
+	const dataset = "id,value\n1,a\n2,b\n"
+	mux := http.NewServeMux()
+	mux.HandleFunc("/data.csv", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		_, _ = w.Write([]byte(dataset))
+	})
+	mux.HandleFunc("/stream", func(w http.ResponseWriter, _ *http.Request) {
+		for range 4 {
+			_, _ = w.Write([]byte(strings.Repeat("x", 1024)))
+			w.(http.Flusher).Flush()
+		}
+	})
+	mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server, dataset
+}
+
+func fetchFile(t *testing.T, request model.FileFetchRequest) *httptest.ResponseRecorder {
+	t.Helper()
+	body, _ := json.Marshal(request)
+	ctrl, rec := newFilesystemController(t, http.MethodPost, "/files/fetch", body)
+	ctrl.FetchFile()
+	return rec
+}
+
+func TestFilesystemControllerFetchFile(t *testing.T) {
+	server, dataset := newFetchServer(t)
+	sum := sha256.Sum256([]byte(dataset))
+	dest := filepath.Join(t.TempDir(), "data", "set.csv")
+
+	rec := fetchFile(t, model.FileFetchRequest{
+		URL:          server.URL + "/data.csv",
+		DestPath:     dest,
+		ContentTypes: []string{"text/*"},
+		SHA256:       strings.ToUpper(hex.EncodeToString(sum[:])),
+		Permission:   model.Permission{Mode: 600},
+	})
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var result model.FileFetchResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("unmarshal response: %v", err)
+	}
+	if result.Status != model.FetchStatusCompleted || result.BytesWritten != int64(len(dataset)) ||
+		result.SHA256 != hex.EncodeToString(sum[:]) || result.File == nil {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if goruntime.GOOS != "windows" && result.File.Mode != 600 {
+		t.Fatalf("expected mode 600, got %d", result.File.Mode)
+	}
+	data, err := os.ReadFile(dest)
+	if err != nil || string(data) != dataset {
+		t.Fatalf("unexpected fetched file %q: %v", data, err)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(dest)); len(entries) != 1 {
+		t.Fatalf("expected no temporary file left, got %v", entries)
+	}
+}
+
+func TestFilesystemControllerFetchFileRejections(t *testing.T) {
+	server, _ := newFetchServer(t)
+
+	cases := []struct {
+		name    string
+		request model.FileFetchRequest
+		status  int
+		code    model.ErrorCode
+	}{
+		{"checksum", model.FileFetchRequest{URL: server.URL + "/data.csv", SHA256: strings.Repeat("0", 64)}, http.StatusUnprocessableEntity, model.ErrorCodeChecksumMismatch},
+		{"announced size", model.FileFetchRequest{URL: server.URL + "/data.csv", MaxSize: 4}, http.StatusRequestEntityTooLarge, model.ErrorCodeFileTooLarge},
+		{"streamed size", model.FileFetchRequest{URL: server.URL + "/stream", MaxSize: 2048}, http.StatusRequestEntityTooLarge, model.ErrorCodeFileTooLarge},
+		{"content type", model.FileFetchRequest{URL: server.URL + "/data.csv", ContentTypes: []string{"application/json"}}, http.StatusUnsupportedMediaType, model.ErrorCodeUnsupportedContentType},
+		{"redirects", model.FileFetchRequest{URL: server.URL + "/loop"}, http.StatusBadGateway, model.ErrorCodeFetchFailed},
+		{"status", model.FileFetchRequest{URL: server.URL + "/missing"}, http.StatusBadGateway, model.ErrorCodeFetchFailed},
+	}
+	for _, tc := range cases {
+		tc.request.DestPath = filepath.Join(t.TempDir(), "out")
+		rec := fetchFile(t, tc.request)
+
+		if rec.Code != tc.status {
+			t.Fatalf("%s: expected status %d, got %d: %s", tc.name, tc.status, rec.Code, rec.Body.String())
+		}
+		var resp model.ErrorResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.Code != tc.code {
+			t.Fatalf("%s: unexpected error response %s", tc.name, rec.Body.String())
+		}
+		if entries, _ := os.ReadDir(filepath.Dir(tc.request.DestPath)); len(entries) != 0 {
+			t.Fatalf("%s: expected nothing placed, got %v", tc.name, entries)
+		}
+	}
+}
+
+func TestFilesystemControllerFetchFileRedactsURLs(t *testing.T) {
+	server, _ := newFetchServer(t)
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	for _, base := range []string{server.URL, closed.URL} {
+		url := strings.Replace(base, "http://", "http://user:hunter2@", 1) + "/missing?X-Amz-Signature=s3cret"
+		rec := fetchFile(t, model.FileFetchRequest{URL: url, DestPath: filepath.Join(t.TempDir(), "out")})
+
+		if rec.Code != http.StatusBadGateway {
+			t.Fatalf("%s: expected status 502, got %d: %s", base, rec.Code, rec.Body.String())
+		}
+		if body := rec.Body.String(); strings.Contains(body, "hunter2") || strings.Contains(body, "s3cret") {
+			t.Fatalf("%s: expected the credentials to be redacted, got %s", base, body)
+		}
+	}
+}
+
+func TestFilesystemControllerFetchFileInBackground(t *testing.T) {
+	originalRunner := codeRunner
+	defer func() { codeRunner = originalRunner }()
+	codeRunner = runtime.NewController("", "")
+
+	server, dataset := newFetchServer(t)
+	dest := filepath.Join(t.TempDir(), "set.csv")
+
+	rec := fetchFile(t, model.FileFetchRequest{URL: server.URL + "/data.csv?signature=secret", DestPath: dest, Background: true})
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected status 202, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var started model.FileFetchResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &started); err != nil || started.ID == "" || strings.Contains(started.URL, "secret") {
+		t.Fatalf("expected a session id and a redacted URL, got %s", rec.Body.String())
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		status, err := codeRunner.GetCommandStatus(started.ID)
+		if err != nil {
+			t.Fatalf("GetCommandStatus: %v", err)
+		}
+		if !status.Running {
+			if status.ExitCode == nil || *status.ExitCode != 0 || status.Error != "" {
+				t.Fatalf("unexpected status %+v", status)
+			}
+			if strings.Contains(status.Content, "secret") {
+				t.Fatalf("the status leaks the signature of the URL: %s", status.Content)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the background fetch did not finish")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	output, _, err := codeRunner.SeekBackgroundCommandOutput(started.ID, 0)
+	if err != nil {
+		t.Fatalf("read fetch log: %v", err)
+	}
+	sum := sha256.Sum256([]byte(dataset))
+	if !strings.Contains(string(output), hex.EncodeToString(sum[:])) || strings.Contains(string(output), "secret") {
+		t.Fatalf("unexpected fetch log %q", output)
+	}
+	data, err := os.ReadFile(dest)
+	if err != nil || string(data) != dataset {
+		t.Fatalf("unexpected fetched file %q: %v", data, err)
+	}
+}
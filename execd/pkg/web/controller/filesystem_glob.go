@@ -0,0 +1,43 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"net/http"
+
+	"github.com/alibaba/opensandbox/execd/pkg/util/glob"
+	"github.com/alibaba/opensandbox/execd/pkg/web/model"
+)
+
+// ValidateGlob tells whether the search pattern of the query is well-formed,
+// so that clients can check it before calling SearchFiles.
+func (c *FilesystemController) ValidateGlob() {
+	pattern, ok := c.ctx.GetQuery("pattern")
+	if !ok {
+		c.RespondError(
+			http.StatusBadRequest,
+			model.ErrorCodeMissingQuery,
+			"missing query parameter 'pattern'",
+		)
+		return
+	}
+
+	resp := model.GlobValidation{Pattern: pattern, Valid: true}
+	if err := glob.ValidatePattern(pattern); err != nil {
+		resp.Valid = false
+		resp.Error = err.Error()
+	}
+	c.RespondSuccess(resp)
+}
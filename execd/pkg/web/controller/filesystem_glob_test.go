@@ -0,0 +1,62 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/alibaba/opensandbox/execd/pkg/web/model"
+)
+
+func TestFilesystemControllerValidateGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		valid   bool
+		reason  string
+	}{
+		{"**/*.{go,md}", true, ""},
+		{"[!.]*", true, ""},
+		{"data-[0-9", false, "unterminated character class"},
+		{"{a,b", false, "unclosed '{'"},
+	}
+	for _, tt := range tests {
+		ctrl, rec := newFilesystemController(t, http.MethodGet, "/files/validate-glob?pattern="+url.QueryEscape(tt.pattern), nil)
+		ctrl.ValidateGlob()
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%#q: expected status 200, got %d: %s", tt.pattern, rec.Code, rec.Body.String())
+		}
+		var resp model.GlobValidation
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("unmarshal response: %v", err)
+		}
+		if resp.Pattern != tt.pattern || resp.Valid != tt.valid || !strings.Contains(resp.Error, tt.reason) {
+			t.Fatalf("%#q: unexpected validation %+v", tt.pattern, resp)
+		}
+	}
+}
+
+func TestFilesystemControllerValidateGlob_MissingPattern(t *testing.T) {
+	ctrl, rec := newFilesystemController(t, http.MethodGet, "/files/validate-glob", nil)
+	ctrl.ValidateGlob()
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/alibaba/opensandbox/execd/pkg/web/model"
+)
+
+// errNotDirectory reports a path MakeDir was asked to create that holds a file.
+var errNotDirectory = errors.New("path exists and is not a directory")
+
+// MakeDirs creates directories with specified permissions, reporting for each
+// whether it was created or already existed.
+func (c *FilesystemController) MakeDirs() {
+	var request map[string]model.Permission
+	if err := c.bindJSON(&request); err != nil {
+		c.RespondError(
+			http.StatusBadRequest,
+			model.ErrorCodeInvalidRequest,
+			fmt.Sprintf("error parsing request, MAYBE invalid body format. %v", err),
+		)
+		return
+	}
+
+	resp := make(map[string]model.MakeDirResult, len(request))
+	for dir, perm := range request {
+		created, err := MakeDir(dir, perm)
+		if err != nil {
+			if errors.Is(err, errNotDirectory) || errors.Is(err, syscall.ENOTDIR) {
+				c.RespondError(
+					http.StatusConflict,
+					model.ErrorCodeNotDirectory,
+					fmt.Sprintf("error creating directory %s. %v", dir, err),
+				)
+				return
+			}
+			c.handleFileError(err)
+			return
+		}
+		status := model.DirStatusExisted
+		if created {
+			status = model.DirStatusCreated
+		}
+		resp[dir] = model.MakeDirResult{Status: status}
+	}
+
+	c.RespondSuccess(resp)
+}
+
+// MakeDir creates dir and its parents and applies perm to it. It reports whether
+// dir was created, an existing directory is not an error but a file is.
+func MakeDir(dir string, perm model.Permission) (bool, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return false, err
+	}
+	if err := os.MkdirAll(filepath.Dir(abs), os.ModePerm); err != nil {
+		return false, err
+	}
+
+	// creating the directory itself tells a concurrent creator apart from us.
+	created := true
+	if err := os.Mkdir(abs, os.ModePerm); err != nil {
+		if !errors.Is(err, fs.ErrExist) {
+			return false, err
+		}
+		info, err := os.Stat(abs)
+		if err != nil {
+			return false, err
+		}
+		if !info.IsDir() {
+			return false, fmt.Errorf("%w: %s", errNotDirectory, abs)
+		}
+		created = false
+	}
+
+	return created, ChmodFile(abs, perm)
+}
@@ -0,0 +1,86 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	goruntime "runtime"
+	"testing"
+
+	"github.com/alibaba/opensandbox/execd/pkg/web/model"
+)
+
+func TestFilesystemControllerMakeDirs(t *testing.T) {
+	root := t.TempDir()
+	existing := filepath.Join(root, "existing")
+	if err := os.Mkdir(existing, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	created := filepath.Join(root, "a", "b")
+
+	body, _ := json.Marshal(map[string]model.Permission{
+		existing: {Mode: 700},
+		created:  {Mode: 750},
+	})
+	ctrl, rec := newFilesystemController(t, http.MethodPost, "/directories", body)
+	ctrl.MakeDirs()
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp map[string]model.MakeDirResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("unmarshal response: %v", err)
+	}
+	if resp[existing].Status != model.DirStatusExisted || resp[created].Status != model.DirStatusCreated {
+		t.Fatalf("unexpected statuses: %+v", resp)
+	}
+
+	if goruntime.GOOS == "windows" {
+		return
+	}
+	// permissions apply to existing directories too.
+	for dir, mode := range map[string]os.FileMode{existing: 0o700, created: 0o750} {
+		info, err := os.Stat(dir)
+		if err != nil {
+			t.Fatalf("stat %s: %v", dir, err)
+		}
+		if info.Mode().Perm() != mode {
+			t.Fatalf("expected mode %o for %s, got %o", mode, dir, info.Mode().Perm())
+		}
+	}
+}
+
+func TestFilesystemControllerMakeDirsRejectsFiles(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file.txt")
+	if err := os.WriteFile(file, []byte("x"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	for _, dir := range []string{file, filepath.Join(file, "child")} {
+		body, _ := json.Marshal(map[string]model.Permission{dir: {}})
+		ctrl, rec := newFilesystemController(t, http.MethodPost, "/directories", body)
+		ctrl.MakeDirs()
+
+		var resp model.ErrorResponse
+		_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+		if rec.Code != http.StatusConflict || resp.Code != model.ErrorCodeNotDirectory {
+			t.Fatalf("expected 409 %s for %s, got %d %s", model.ErrorCodeNotDirectory, dir, rec.Code, resp.Code)
+		}
+	}
+}
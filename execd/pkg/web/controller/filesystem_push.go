@@ -0,0 +1,259 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/alibaba/opensandbox/execd/pkg/runtime"
+	"github.com/alibaba/opensandbox/execd/pkg/util/safego"
+	"github.com/alibaba/opensandbox/execd/pkg/web/model"
+)
+
+const (
+	// pushMaxAttempts bounds the attempts of a PUT push.
+	pushMaxAttempts = 3
+	// pushUpstreamBodyLimit caps the upstream answer kept in the result.
+	pushUpstreamBodyLimit = 4 << 10
+	// pushProgressInterval is the period of the progress lines of background pushes.
+	pushProgressInterval = time.Second
+)
+
+// pushRetryDelay is the wait before the first retry of a push, doubled after each one.
+var pushRetryDelay = time.Second
+
+var errPushFailed = errors.New("push failed")
+
+// PushFile uploads a sandbox file to a URL server-side, retrying PUT pushes
+// failing with a network error, a 429 or a 5xx. A background push answers 202
+// at once with a command session id: GET /command/status/:id reports it,
+// /command/:id/logs holds its progress and interrupting the session cancels it.
+func (c *FilesystemController) PushFile() {
+	var request model.FilePushRequest
+	if err := c.bindJSON(&request); err != nil {
+		c.RespondError(
+			http.StatusBadRequest,
+			model.ErrorCodeInvalidRequest,
+			fmt.Sprintf("error parsing request, MAYBE invalid body format. %v", err),
+		)
+		return
+	}
+	if err := request.Validate(); err != nil {
+		c.RespondValidationError(err)
+		return
+	}
+
+	info, err := os.Stat(request.Path)
+	if err != nil {
+		c.handleFileError(err)
+		return
+	}
+	if info.IsDir() {
+		c.RespondError(http.StatusBadRequest, model.ErrorCodeInvalidRequest, fmt.Sprintf("%s is a directory", request.Path))
+		return
+	}
+	job := &pushJob{request: request, size: info.Size()}
+
+	if request.Background {
+		task, ctx, err := codeRunner.StartTask(context.Background(), fmt.Sprintf("push %s to %s", request.Path, redactURL(request.URL)))
+		if err != nil {
+			c.RespondError(http.StatusInternalServerError, model.ErrorCodeRuntimeError, err.Error())
+			return
+		}
+		job.task = task
+		safego.Go(func() {
+			result, err := job.run(ctx)
+			task.Logf("pushed %d bytes in %d ms after %d attempt(s)", result.BytesSent, result.Duration, result.Attempts)
+			task.Finish(err)
+		})
+		c.ctx.JSON(http.StatusAccepted, model.FilePushResult{ID: task.ID(), Path: request.Path, TotalBytes: job.size})
+		return
+	}
+
+	result, err := job.run(c.ctx.Request.Context())
+	if err != nil {
+		message := err.Error()
+		if result.UpstreamBody != "" {
+			message += ": " + result.UpstreamBody
+		}
+		status := http.StatusBadGateway
+		if !errors.Is(err, errPushFailed) {
+			status = http.StatusInternalServerError
+		}
+		c.RespondError(status, model.ErrorCodePushFailed, message)
+		return
+	}
+	c.RespondSuccess(result)
+}
+
+// pushJob is one push, logging its progress to task when run in the background.
+type pushJob struct {
+	request model.FilePushRequest
+	size    int64
+	task    *runtime.Task
+}
+
+// run pushes the file, retrying PUT pushes that may succeed on another attempt.
+func (j *pushJob) run(ctx context.Context) (model.FilePushResult, error) {
+	method := j.request.Method
+	if method == "" {
+		method = http.MethodPut
+	}
+	attempts := 1
+	// POST may create a resource per attempt; PUT is idempotent.
+	if method == http.MethodPut {
+		attempts = pushMaxAttempts
+	}
+
+	start := time.Now()
+	delay := pushRetryDelay
+	var result model.FilePushResult
+	var err error
+	for attempt := 1; ; attempt++ {
+		result, err = j.attempt(ctx, method)
+		result.Attempts = attempt
+		if err == nil || attempt == attempts || !retryablePush(result.UpstreamStatus, err) {
+			break
+		}
+		j.logf("attempt %d failed: %v, retrying in %v", attempt, err, delay)
+		select {
+		case <-ctx.Done():
+		case <-time.After(delay):
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		delay *= 2
+	}
+	result.Duration = time.Since(start).Milliseconds()
+	if err != nil {
+		logger.Warning("pushing %s to %s failed: %v", j.request.Path, redactURL(j.request.URL), err)
+	}
+	return result, err
+}
+
+// attempt uploads the file once.
+func (j *pushJob) attempt(ctx context.Context, method string) (model.FilePushResult, error) {
+	result := model.FilePushResult{Path: j.request.Path, TotalBytes: j.size}
+	file, err := os.Open(j.request.Path)
+	if err != nil {
+		return result, err
+	}
+	defer file.Close()
+
+	sent := &countingReader{reader: file}
+	var body io.Reader = sent
+	if j.size == 0 {
+		// a zero ContentLength with a body would be sent chunked.
+		body = http.NoBody
+	}
+	req, err := http.NewRequestWithContext(ctx, method, j.request.URL, body)
+	if err != nil {
+		return result, fmt.Errorf("%w: %w", errPushFailed, redactURLError(err))
+	}
+	req.ContentLength = j.size
+	for name, value := range j.request.Headers {
+		req.Header.Set(name, value)
+	}
+	if j.request.ContentType != "" {
+		req.Header.Set("Content-Type", j.request.ContentType)
+	}
+
+	start := time.Now()
+	stop := j.reportProgress(sent, start)
+	resp, err := fetchClient.Do(req)
+	stop()
+	elapsed := time.Since(start)
+	result.BytesSent = sent.n.Load()
+	if seconds := elapsed.Seconds(); seconds > 0 {
+		result.Rate = float64(result.BytesSent) / seconds
+	}
+	if err != nil {
+		return result, fmt.Errorf("%w: %w", errPushFailed, redactURLError(err))
+	}
+	defer resp.Body.Close()
+
+	answer, _ := io.ReadAll(io.LimitReader(resp.Body, pushUpstreamBodyLimit))
+	result.UpstreamStatus = resp.StatusCode
+	result.UpstreamBody = string(answer)
+	j.logf("%s answered %s", redactURL(j.request.URL), resp.Status)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return result, fmt.Errorf("%w: %s answered %s", errPushFailed, redactURL(j.request.URL), resp.Status)
+	}
+	return result, nil
+}
+
+// reportProgress logs the bytes sent every pushProgressInterval until the
+// returned stop is called; it logs nothing for foreground pushes.
+func (j *pushJob) reportProgress(sent *countingReader, start time.Time) (stop func()) {
+	if j.task == nil {
+		return func() {}
+	}
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	safego.Go(func() {
+		defer close(stopped)
+		ticker := time.NewTicker(pushProgressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				n := sent.n.Load()
+				j.logf("sent %d of %d bytes (%.0f B/s)", n, j.size, float64(n)/time.Since(start).Seconds())
+			}
+		}
+	})
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// logf adds a line to the log of a background push.
+func (j *pushJob) logf(format string, args ...any) {
+	if j.task != nil {
+		j.task.Logf(format, args...)
+	}
+}
+
+// retryablePush reports whether a failed attempt may succeed when repeated:
+// network errors, throttling and server errors may, local errors won't.
+func retryablePush(status int, err error) bool {
+	if !errors.Is(err, errPushFailed) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	return status == 0 || status == http.StatusTooManyRequests || status >= 500
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	reader io.Reader
+	n      atomic.Int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.n.Add(int64(n))
+	return n, err
+}
@@ -0,0 +1,195 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alibaba/opensandbox/execd/pkg/runtime"
+	"github.com/alibaba/opensandbox/execd/pkg/web/model"
+)
+
+// pushServer records the uploads it receives, failing the first failures
+// requests with a 503.
+type pushServer struct {
+	mu       sync.Mutex
+	failures int
+	uploads  []string
+	methods  []string
+	types    []string
+}
+
+func newPushServer(t *testing.T, failures int) (*pushServer, *httptest.Server) {
+	t.Helper()
+	recorder := &pushServer{failures: failures}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		recorder.mu.Lock()
+		defer recorder.mu.Unlock()
+		recorder.methods = append(recorder.methods, r.Method)
+		if recorder.failures > 0 {
+			recorder.failures--
+			http.Error(w, "slow down", http.StatusServiceUnavailable)
+			return
+		}
+		recorder.uploads = append(recorder.uploads, string(body))
+		recorder.types = append(recorder.types, r.Header.Get("Content-Type"))
+		w.Header().Set("ETag", "\"abc\"")
+		_, _ = w.Write([]byte("<ETag>abc</ETag>"))
+	}))
+	t.Cleanup(server.Close)
+	return recorder, server
+}
+
+func pushFile(t *testing.T, request model.FilePushRequest) *httptest.ResponseRecorder {
+	t.Helper()
+	body, _ := json.Marshal(request)
+	ctrl, rec := newFilesystemController(t, http.MethodPost, "/files/push", body)
+	ctrl.PushFile()
+	return rec
+}
+
+func writePushSource(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "result.csv")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestFilesystemControllerPushFile(t *testing.T) {
+	original := pushRetryDelay
+	defer func() { pushRetryDelay = original }()
+	pushRetryDelay = time.Millisecond
+
+	recorder, server := newPushServer(t, 1)
+	path := writePushSource(t, "id,value\n1,a\n")
+
+	rec := pushFile(t, model.FilePushRequest{
+		Path:        path,
+		URL:         server.URL + "/bucket/result.csv?X-Amz-Signature=secret",
+		ContentType: "text/csv",
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var result model.FilePushResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if result.BytesSent != 13 || result.TotalBytes != 13 || result.Attempts != 2 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if result.UpstreamStatus != http.StatusOK || result.UpstreamBody != "<ETag>abc</ETag>" {
+		t.Fatalf("unexpected upstream answer %+v", result)
+	}
+	if len(recorder.uploads) != 1 || recorder.uploads[0] != "id,value\n1,a\n" || recorder.types[0] != "text/csv" {
+		t.Fatalf("unexpected uploads %q with types %q", recorder.uploads, recorder.types)
+	}
+}
+
+func TestFilesystemControllerPushFileFailures(t *testing.T) {
+	original := pushRetryDelay
+	defer func() { pushRetryDelay = original }()
+	pushRetryDelay = time.Millisecond
+
+	recorder, server := newPushServer(t, 5)
+	path := writePushSource(t, "data")
+
+	rec := pushFile(t, model.FilePushRequest{Path: path, URL: server.URL, Method: http.MethodPost})
+	if rec.Code != http.StatusBadGateway || !strings.Contains(rec.Body.String(), string(model.ErrorCodePushFailed)) {
+		t.Fatalf("expected 502 %s, got %d: %s", model.ErrorCodePushFailed, rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), "slow down") {
+		t.Fatalf("expected the upstream body in the error, got %s", rec.Body.String())
+	}
+	if len(recorder.methods) != 1 {
+		t.Fatalf("expected POST not to be retried, got %d attempts", len(recorder.methods))
+	}
+
+	rec = pushFile(t, model.FilePushRequest{Path: path, URL: server.URL})
+	if rec.Code != http.StatusBadGateway || len(recorder.methods) != 1+pushMaxAttempts {
+		t.Fatalf("expected %d PUT attempts then 502, got %d attempts and %d", pushMaxAttempts, len(recorder.methods)-1, rec.Code)
+	}
+
+	rec = pushFile(t, model.FilePushRequest{Path: filepath.Join(t.TempDir(), "missing"), URL: server.URL})
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for a missing file, got %d", rec.Code)
+	}
+	rec = pushFile(t, model.FilePushRequest{Path: path, URL: server.URL, Method: http.MethodPatch})
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an unsupported method, got %d", rec.Code)
+	}
+}
+
+func TestFilesystemControllerPushFileInBackground(t *testing.T) {
+	originalRunner := codeRunner
+	defer func() { codeRunner = originalRunner }()
+	codeRunner = runtime.NewController("", "")
+
+	recorder, server := newPushServer(t, 0)
+	path := writePushSource(t, "payload")
+
+	rec := pushFile(t, model.FilePushRequest{Path: path, URL: server.URL + "/upload?signature=secret", Background: true})
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var accepted model.FilePushResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &accepted); err != nil || accepted.ID == "" {
+		t.Fatalf("expected a session id, got %s", rec.Body.String())
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		status, err := codeRunner.GetCommandStatus(accepted.ID)
+		if err != nil {
+			t.Fatalf("GetCommandStatus: %v", err)
+		}
+		if !status.Running {
+			if status.ExitCode == nil || *status.ExitCode != 0 || status.Error != "" {
+				t.Fatalf("unexpected status %+v", status)
+			}
+			if strings.Contains(status.Content, "secret") {
+				t.Fatalf("the status leaks the signature of the URL: %s", status.Content)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the background push did not finish")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	output, _, err := codeRunner.SeekBackgroundCommandOutput(accepted.ID, 0)
+	if err != nil {
+		t.Fatalf("read push log: %v", err)
+	}
+	if !strings.Contains(string(output), "200 OK") || strings.Contains(string(output), "secret") {
+		t.Fatalf("unexpected push log %q", output)
+	}
+	if len(recorder.uploads) != 1 || recorder.uploads[0] != "payload" {
+		t.Fatalf("unexpected uploads %q", recorder.uploads)
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"text/template"
+
+	"github.com/alibaba/opensandbox/execd/pkg/web/model"
+)
+
+// maxRenderedFileSize caps the output of a rendered template.
+const maxRenderedFileSize = 16 << 20
+
+var errRenderTooLarge = fmt.Errorf("rendered output exceeds %d bytes", maxRenderedFileSize)
+
+// RenderFile renders a text/template with the given variables and writes the
+// output to a file. Nothing is written when the template fails to parse or execute.
+func (c *FilesystemController) RenderFile() {
+	var request model.FileRenderRequest
+	if err := c.bindJSON(&request); err != nil {
+		c.RespondError(
+			http.StatusBadRequest,
+			model.ErrorCodeInvalidRequest,
+			fmt.Sprintf("error parsing request, MAYBE invalid body format. %v", err),
+		)
+		return
+	}
+	if err := request.Validate(); err != nil {
+		c.RespondValidationError(err)
+		return
+	}
+
+	tmpl, err := template.New(filepath.Base(request.Path)).Option("missingkey=error").Parse(request.Template)
+	if err != nil {
+		c.RespondError(
+			http.StatusBadRequest,
+			model.ErrorCodeInvalidTemplate,
+			fmt.Sprintf("error parsing template. %v", err),
+		)
+		return
+	}
+
+	var out bytes.Buffer
+	if err := tmpl.Execute(&limitedBuffer{buf: &out, limit: maxRenderedFileSize}, request.Variables); err != nil {
+		if errors.Is(err, errRenderTooLarge) {
+			c.RespondError(http.StatusRequestEntityTooLarge, model.ErrorCodeFileTooLarge, err.Error())
+			return
+		}
+		c.RespondError(
+			http.StatusBadRequest,
+			model.ErrorCodeInvalidTemplate,
+			fmt.Sprintf("error executing template. %v", err),
+		)
+		return
+	}
+
+	file, err := filepath.Abs(request.Path)
+	if err != nil {
+		c.handleFileError(err)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(file), os.ModePerm); err != nil {
+		c.handleFileError(err)
+		return
+	}
+	if err := os.WriteFile(file, out.Bytes(), 0o644); err != nil {
+		c.handleFileError(err)
+		return
+	}
+	if err := ChmodFile(file, request.Permission); err != nil {
+		c.RespondError(
+			http.StatusInternalServerError,
+			model.ErrorCodeRuntimeError,
+			fmt.Sprintf("error chmoding file %s. %v", file, err),
+		)
+		return
+	}
+
+	info, err := GetFileInfo(file)
+	if err != nil {
+		c.handleFileError(err)
+		return
+	}
+	c.RespondSuccess(info)
+}
+
+// limitedBuffer fails writes that would grow buf beyond limit.
+type limitedBuffer struct {
+	buf   *bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.buf.Len()+len(p) > b.limit {
+		return 0, errRenderTooLarge
+	}
+	return b.buf.Write(p)
+}
@@ -0,0 +1,89 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alibaba/opensandbox/execd/pkg/web/model"
+)
+
+func TestFilesystemControllerRenderFile(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "app", "config.yaml")
+	body, _ := json.Marshal(model.FileRenderRequest{
+		Path:     target,
+		Template: "name: {{ .name }}\nports:\n{{- range .ports }}\n  - {{ . }}\n{{- end }}\n",
+		Variables: map[string]any{
+			"name":  "demo",
+			"ports": []int{3000, 8080},
+		},
+		Permission: model.Permission{Mode: 600},
+	})
+	ctrl, rec := newFilesystemController(t, http.MethodPost, "/files/render", body)
+
+	ctrl.RenderFile()
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	data, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatalf("read rendered file: %v", err)
+	}
+	want := "name: demo\nports:\n  - 3000\n  - 8080\n"
+	if string(data) != want {
+		t.Fatalf("rendered %q, want %q", data, want)
+	}
+	var info model.FileInfo
+	if err := json.Unmarshal(rec.Body.Bytes(), &info); err != nil {
+		t.Fatalf("unmarshal response: %v", err)
+	}
+	if info.Path != target || info.Size != int64(len(want)) {
+		t.Fatalf("unexpected file info: %+v", info)
+	}
+}
+
+func TestFilesystemControllerRenderFileRejectsBadTemplates(t *testing.T) {
+	cases := map[string]model.FileRenderRequest{
+		"error parsing template":   {Template: "{{ .name "},
+		"error executing template": {Template: "{{ .missing }}", Variables: map[string]any{"name": "demo"}},
+	}
+	for message, request := range cases {
+		request.Path = filepath.Join(t.TempDir(), "out.txt")
+		body, _ := json.Marshal(request)
+		ctrl, rec := newFilesystemController(t, http.MethodPost, "/files/render", body)
+
+		ctrl.RenderFile()
+
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected status 400, got %d", message, rec.Code)
+		}
+		var resp model.ErrorResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("unmarshal response: %v", err)
+		}
+		if resp.Code != model.ErrorCodeInvalidTemplate || !strings.Contains(resp.Message, message) {
+			t.Fatalf("unexpected error response: %+v", resp)
+		}
+		if _, err := os.Stat(request.Path); !os.IsNotExist(err) {
+			t.Fatalf("%s: no file should be written, stat err=%v", message, err)
+		}
+	}
+}
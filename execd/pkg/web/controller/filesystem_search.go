@@ -0,0 +1,181 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/alibaba/opensandbox/execd/pkg/util/glob"
+	"github.com/alibaba/opensandbox/execd/pkg/web/model"
+)
+
+// searchExcludes returns the exclude patterns of a search with / turned into
+// the platform separator, answering 400 when one is malformed.
+func (c *FilesystemController) searchExcludes() ([]string, bool) {
+	excludes := c.ctx.QueryArray("exclude")
+	for i, exclude := range excludes {
+		exclude = filepath.FromSlash(exclude)
+		if err := glob.ValidatePattern(exclude); err != nil {
+			c.RespondError(
+				http.StatusBadRequest,
+				model.ErrorCodeInvalidRequest,
+				fmt.Sprintf("invalid exclude pattern %s. %v", excludes[i], err),
+			)
+			return nil, false
+		}
+		excludes[i] = exclude
+	}
+	return excludes, true
+}
+
+// excludedFromSearch tells whether a walked entry, given by its path relative
+// to the search root, matches an exclude pattern. Excluded directories are
+// skipped with their whole subtree.
+func excludedFromSearch(excludes []string, rel string) bool {
+	for _, exclude := range excludes {
+		if match, _ := glob.PathMatch(exclude, rel); match {
+			return true
+		}
+	}
+	return false
+}
+
+// searchWalkError decides what an error walking filePath does to a search of
+// root. Entries vanishing mid-walk and entries below root execd may not read
+// are skipped, the latter with a warning, so one unreadable subdirectory
+// doesn't fail the search; other errors end it.
+func searchWalkError(root, filePath string, err error) error {
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if errors.Is(err, fs.ErrPermission) && filePath != root {
+		logger.Warning("searching %s: skipping %s: %v", root, filePath, err)
+		return nil
+	}
+	return fmt.Errorf("error accessing path %s: %w", filePath, err)
+}
+
+// symlinkMode tells how a search treats symbolic links.
+type symlinkMode int
+
+const (
+	// symlinksListed reports links as entries without descending into them.
+	symlinksListed symlinkMode = iota
+	// symlinksFollowed reports and walks the targets of links.
+	symlinksFollowed
+	// symlinksSkipped leaves links out.
+	symlinksSkipped
+)
+
+// searchSymlinkMode reads the followSymlinks query of a search: absent lists
+// links, true follows them and false skips them. It answers 400 when invalid.
+func (c *FilesystemController) searchSymlinkMode() (symlinkMode, bool) {
+	query := c.ctx.Query("followSymlinks")
+	if query == "" {
+		return symlinksListed, true
+	}
+	follow, err := strconv.ParseBool(query)
+	if err != nil {
+		c.RespondError(
+			http.StatusBadRequest,
+			model.ErrorCodeInvalidRequest,
+			fmt.Sprintf("invalid followSymlinks %s. %v", query, err),
+		)
+		return 0, false
+	}
+	if follow {
+		return symlinksFollowed, true
+	}
+	return symlinksSkipped, true
+}
+
+// walkSearch walks the tree of root in lexical order like filepath.Walk,
+// treating links as mode says. Followed links report the info of their
+// target; links that don't resolve are skipped, and a directory reached
+// again, through another link or a cycle, is walked only once.
+func walkSearch(root string, mode symlinkMode, fn filepath.WalkFunc) error {
+	info, err := os.Lstat(root)
+	if err == nil && mode == symlinksFollowed && info.Mode()&fs.ModeSymlink != 0 {
+		info, err = os.Stat(root)
+	}
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		w := &searchWalker{mode: mode, fn: fn, visited: make(map[fileKey]bool)}
+		err = w.walk(root, info)
+	}
+	if errors.Is(err, filepath.SkipDir) || errors.Is(err, filepath.SkipAll) {
+		return nil
+	}
+	return err
+}
+
+// searchWalker holds the state of one walkSearch.
+type searchWalker struct {
+	mode    symlinkMode
+	fn      filepath.WalkFunc
+	visited map[fileKey]bool
+}
+
+func (w *searchWalker) walk(path string, info fs.FileInfo) error {
+	if !info.IsDir() {
+		return w.fn(path, info, nil)
+	}
+	if w.mode == symlinksFollowed {
+		if key, ok := fileKeyOf(path, info); ok {
+			if w.visited[key] {
+				return nil
+			}
+			w.visited[key] = true
+		}
+	}
+
+	entries, readErr := os.ReadDir(path)
+	if err := w.fn(path, info, readErr); err != nil || readErr != nil {
+		return err
+	}
+	for _, entry := range entries {
+		name := filepath.Join(path, entry.Name())
+		entryInfo, err := os.Lstat(name)
+		if err == nil && entryInfo.Mode()&fs.ModeSymlink != 0 {
+			switch w.mode {
+			case symlinksSkipped:
+				continue
+			case symlinksFollowed:
+				if entryInfo, err = os.Stat(name); err != nil {
+					continue
+				}
+			}
+		}
+		if err != nil {
+			if err := w.fn(name, nil, err); err != nil && !errors.Is(err, filepath.SkipDir) {
+				return err
+			}
+			continue
+		}
+		if err := w.walk(name, entryInfo); err != nil {
+			if !entryInfo.IsDir() || !errors.Is(err, filepath.SkipDir) {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -16,13 +16,18 @@ package controller
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/alibaba/opensandbox/execd/pkg/web/model"
 )
@@ -90,6 +95,173 @@ func TestFilesystemControllerSearchFiles(t *testing.T) {
 	}
 }
 
+func TestFilesystemControllerSearchFilesPrunesExcludedDirs(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"node_modules/pkg/index.js", "src/main.js", "src/vendor/lib.js", "src/debug.log"} {
+		target := filepath.Join(tmpDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(target, []byte(name), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	// node_modules matches the directory only, so its files are left out
+	// because its subtree is pruned.
+	query := url.Values{"path": {tmpDir}, "exclude": {"node_modules", "**/vendor", "**/*.log"}}
+	ctrl, rec := newFilesystemController(t, http.MethodGet, "/files/search?"+query.Encode(), nil)
+	ctrl.SearchFiles()
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var files []model.FileInfo
+	if err := json.Unmarshal(rec.Body.Bytes(), &files); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(files) != 1 || files[0].Path != filepath.Join(tmpDir, "src", "main.js") {
+		t.Fatalf("expected only src/main.js, got %#v", files)
+	}
+
+	query.Set("exclude", "{a,b")
+	ctrl, rec = newFilesystemController(t, http.MethodGet, "/files/search?"+query.Encode(), nil)
+	ctrl.SearchFiles()
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400 for a malformed exclude, got %d", rec.Code)
+	}
+}
+
+func TestFilesystemControllerSearchFilesSkipsUnreadableDirs(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root reads directories regardless of their mode")
+	}
+	tmpDir := t.TempDir()
+	locked := filepath.Join(tmpDir, "locked")
+	if err := os.Mkdir(locked, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(locked, "hidden.txt"), []byte("hidden"), 0o644); err != nil {
+		t.Fatalf("write hidden: %v", err)
+	}
+	visible := filepath.Join(tmpDir, "visible.txt")
+	if err := os.WriteFile(visible, []byte("visible"), 0o644); err != nil {
+		t.Fatalf("write visible: %v", err)
+	}
+	if err := os.Chmod(locked, 0); err != nil {
+		t.Fatalf("chmod: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chmod(locked, 0o755) })
+
+	rawURL := fmt.Sprintf("/files/search?path=%s&pattern=%s", url.QueryEscape(tmpDir), url.QueryEscape("*.txt"))
+	ctrl, rec := newFilesystemController(t, http.MethodGet, rawURL, nil)
+	ctrl.SearchFiles()
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var files []model.FileInfo
+	if err := json.Unmarshal(rec.Body.Bytes(), &files); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(files) != 1 || files[0].Path != visible {
+		t.Fatalf("expected only %s, got %#v", visible, files)
+	}
+}
+
+func TestFilesystemControllerSearchFilesSymlinks(t *testing.T) {
+	tmpDir := t.TempDir()
+	root := filepath.Join(tmpDir, "root")
+	external := filepath.Join(tmpDir, "external")
+	for _, name := range []string{"root/a.txt", "root/sub/b.txt", "external/c.txt"} {
+		target := filepath.Join(tmpDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(target, []byte(name), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	links := map[string]string{
+		"file-link.txt":     filepath.Join(root, "a.txt"),
+		"external-link":     external,
+		"sub/loop":          root,
+		"sub/self":          ".",
+		"dangling-link.txt": filepath.Join(tmpDir, "missing.txt"),
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(root, filepath.FromSlash(name))); err != nil {
+			t.Fatalf("symlink %s: %v", name, err)
+		}
+	}
+
+	search := func(follow string) []string {
+		t.Helper()
+		query := url.Values{"path": {root}, "pattern": {"*.txt"}}
+		if follow != "" {
+			query.Set("followSymlinks", follow)
+		}
+		ctrl, rec := newFilesystemController(t, http.MethodGet, "/files/search?"+query.Encode(), nil)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			ctrl.SearchFiles()
+		}()
+		select {
+		case <-done:
+		case <-time.After(10 * time.Second):
+			t.Fatalf("search with followSymlinks=%q did not finish", follow)
+		}
+		if rec.Code != http.StatusOK {
+			t.Fatalf("followSymlinks=%q: expected status 200, got %d: %s", follow, rec.Code, rec.Body.String())
+		}
+		var files []model.FileInfo
+		if err := json.Unmarshal(rec.Body.Bytes(), &files); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		paths := make([]string, 0, len(files))
+		for _, file := range files {
+			rel, _ := filepath.Rel(root, file.Path)
+			paths = append(paths, filepath.ToSlash(rel))
+		}
+		return paths
+	}
+
+	if got, want := search(""), []string{"a.txt", "dangling-link.txt", "file-link.txt", "sub/b.txt"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected links listed but not followed, %v, got %v", want, got)
+	}
+	if got, want := search("true"), []string{"a.txt", "external-link/c.txt", "file-link.txt", "sub/b.txt"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected links followed once each, %v, got %v", want, got)
+	}
+	if got, want := search("false"), []string{"a.txt", "sub/b.txt"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected links skipped, %v, got %v", want, got)
+	}
+
+	ctrl, rec := newFilesystemController(t, http.MethodGet, "/files/search?path="+url.QueryEscape(root)+"&followSymlinks=maybe", nil)
+	ctrl.SearchFiles()
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400 for an invalid followSymlinks, got %d", rec.Code)
+	}
+}
+
+func TestSearchWalkError(t *testing.T) {
+	root := filepath.Join("/data", "project")
+	denied := &os.PathError{Op: "open", Path: filepath.Join(root, "locked"), Err: fs.ErrPermission}
+
+	if err := searchWalkError(root, filepath.Join(root, "locked"), denied); err != nil {
+		t.Fatalf("expected a denied subdirectory to be skipped, got %v", err)
+	}
+	if err := searchWalkError(root, filepath.Join(root, "gone"), fs.ErrNotExist); err != nil {
+		t.Fatalf("expected a vanished entry to be skipped, got %v", err)
+	}
+	if err := searchWalkError(root, root, denied); !errors.Is(err, fs.ErrPermission) {
+		t.Fatalf("expected a denied root to end the search, got %v", err)
+	}
+	if err := searchWalkError(root, filepath.Join(root, "bad"), syscall.EIO); !errors.Is(err, syscall.EIO) {
+		t.Fatalf("expected an I/O error to end the search, got %v", err)
+	}
+}
+
 func TestFilesystemControllerReplaceContent(t *testing.T) {
 	tmpDir := t.TempDir()
 	target := filepath.Join(tmpDir, "content.txt")
@@ -18,11 +18,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"os"
 	"path/filepath"
 
-	"github.com/alibaba/opensandbox/execd/pkg/log"
 	"github.com/alibaba/opensandbox/execd/pkg/web/model"
 )
 
@@ -111,6 +111,13 @@ func (c *FilesystemController) UploadFile() {
 			return
 		}
 
+		if meta.Extract {
+			if !c.extractUpload(fileParts[i], meta) {
+				return
+			}
+			continue
+		}
+
 		targetDir := filepath.Dir(targetPath)
 		if err := os.MkdirAll(targetDir, os.ModePerm); err != nil {
 			c.RespondError(
@@ -155,10 +162,10 @@ func (c *FilesystemController) UploadFile() {
 		}
 
 		if err := dst.Sync(); err != nil {
-			log.Error("failed to sync target file: %v", err)
+			logger.Error("failed to sync target file: %v", err)
 		}
 		if err := dst.Close(); err != nil {
-			log.Error("failed to close target file: %v", err)
+			logger.Error("failed to close target file: %v", err)
 		}
 		file.Close()
 
@@ -174,3 +181,32 @@ func (c *FilesystemController) UploadFile() {
 
 	c.RespondSuccess(nil)
 }
+
+// extractUpload extracts the uploaded zip archive into the directory named by
+// meta. It responds with the error and returns false when extraction fails.
+func (c *FilesystemController) extractUpload(fileHeader *multipart.FileHeader, meta model.FileMetadata) bool {
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.RespondError(
+			http.StatusInternalServerError,
+			model.ErrorCodeRuntimeError,
+			fmt.Sprintf("error opening file %s. %v", fileHeader.Filename, err),
+		)
+		return false
+	}
+	defer file.Close()
+
+	if err := extractZip(file, fileHeader.Size, meta.Path, meta.Permission); err != nil {
+		c.respondExtractError(meta.Path, err)
+		return false
+	}
+	if err := ChmodFile(meta.Path, meta.Permission); err != nil {
+		c.RespondError(
+			http.StatusInternalServerError,
+			model.ErrorCodeRuntimeError,
+			fmt.Sprintf("error chmoding directory %s. %v", meta.Path, err),
+		)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,70 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/alibaba/opensandbox/execd/pkg/web/model"
+)
+
+// SetFileTimes sets the access and modification times of files and returns
+// their updated metadata keyed by path. Every item is validated before any
+// file is touched.
+func (c *FilesystemController) SetFileTimes() {
+	var request []model.FileTimesItem
+	if err := c.bindJSON(&request); err != nil {
+		c.RespondError(
+			http.StatusBadRequest,
+			model.ErrorCodeInvalidRequest,
+			fmt.Sprintf("error parsing request, MAYBE invalid body format. %v", err),
+		)
+		return
+	}
+	for i := range request {
+		if err := request[i].Validate(); err != nil {
+			c.RespondValidationError(err)
+			return
+		}
+	}
+
+	resp := make(map[string]model.FileInfo, len(request))
+	for _, item := range request {
+		// a zero time leaves the corresponding file time unchanged.
+		var atime, mtime time.Time
+		if item.Atime != nil {
+			atime = *item.Atime
+		}
+		if item.Mtime != nil {
+			mtime = *item.Mtime
+		}
+		if err := os.Chtimes(item.Path, atime, mtime); err != nil {
+			c.handleFileError(err)
+			return
+		}
+
+		fileInfo, err := GetFileInfo(item.Path)
+		if err != nil {
+			c.handleFileError(err)
+			return
+		}
+		resp[item.Path] = fileInfo
+	}
+
+	c.RespondSuccess(resp)
+}
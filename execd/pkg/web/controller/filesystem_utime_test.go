@@ -0,0 +1,98 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alibaba/opensandbox/execd/pkg/web/model"
+)
+
+func TestFilesystemControllerSetFileTimes(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "main.o")
+	if err := os.WriteFile(target, []byte("obj"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	mtime := time.Date(2024, 1, 2, 3, 4, 5, 123456000, time.UTC)
+	body, _ := json.Marshal([]model.FileTimesItem{{Path: target, Mtime: &mtime}})
+	ctrl, rec := newFilesystemController(t, http.MethodPost, "/files/utime", body)
+
+	ctrl.SetFileTimes()
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp map[string]model.FileInfo
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("unmarshal response: %v", err)
+	}
+	if !resp[target].ModifiedAt.Equal(mtime) {
+		t.Fatalf("response mtime %v, want %v", resp[target].ModifiedAt, mtime)
+	}
+
+	info, err := GetFileInfo(target)
+	if err != nil {
+		t.Fatalf("GetFileInfo: %v", err)
+	}
+	if !info.ModifiedAt.Equal(mtime) {
+		t.Fatalf("read back mtime %v, want %v", info.ModifiedAt, mtime)
+	}
+
+	// setting only the access time keeps the modification time.
+	atime := time.Date(2025, 6, 7, 8, 9, 10, 0, time.UTC)
+	body, _ = json.Marshal([]model.FileTimesItem{{Path: target, Atime: &atime}})
+	ctrl, rec = newFilesystemController(t, http.MethodPost, "/files/utime", body)
+	ctrl.SetFileTimes()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	info, err = GetFileInfo(target)
+	if err != nil {
+		t.Fatalf("GetFileInfo: %v", err)
+	}
+	if !info.ModifiedAt.Equal(mtime) {
+		t.Fatalf("mtime changed to %v after setting atime", info.ModifiedAt)
+	}
+	if info.AccessedAt != nil && !info.AccessedAt.Equal(atime) {
+		t.Fatalf("read back atime %v, want %v", *info.AccessedAt, atime)
+	}
+}
+
+func TestFilesystemControllerSetFileTimesRejectsBadItems(t *testing.T) {
+	mtime := time.Now()
+	cases := map[string]struct {
+		items  []model.FileTimesItem
+		status int
+	}{
+		"no times":     {[]model.FileTimesItem{{Path: filepath.Join(t.TempDir(), "a")}}, http.StatusBadRequest},
+		"no path":      {[]model.FileTimesItem{{Mtime: &mtime}}, http.StatusBadRequest},
+		"missing file": {[]model.FileTimesItem{{Path: filepath.Join(t.TempDir(), "missing"), Mtime: &mtime}}, http.StatusNotFound},
+	}
+	for name, tc := range cases {
+		body, _ := json.Marshal(tc.items)
+		ctrl, rec := newFilesystemController(t, http.MethodPost, "/files/utime", body)
+
+		ctrl.SetFileTimes()
+
+		if rec.Code != tc.status {
+			t.Fatalf("%s: expected status %d, got %d: %s", name, tc.status, rec.Code, rec.Body.String())
+		}
+	}
+}
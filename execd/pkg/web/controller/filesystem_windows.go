@@ -143,28 +143,6 @@ func (c *FilesystemController) RenameFiles() {
 	c.RespondSuccess(nil)
 }
 
-// MakeDirs creates directories with specified permissions
-func (c *FilesystemController) MakeDirs() {
-	var request map[string]model.Permission
-	if err := c.bindJSON(&request); err != nil {
-		c.RespondError(
-			http.StatusBadRequest,
-			model.ErrorCodeInvalidRequest,
-			fmt.Sprintf("error parsing request, MAYBE invalid body format. %v", err),
-		)
-		return
-	}
-
-	for dir, perm := range request {
-		if err := MakeDir(dir, perm); err != nil {
-			c.handleFileError(err)
-			return
-		}
-	}
-
-	c.RespondSuccess(nil)
-}
-
 // RemoveDirs recursively removes directories
 func (c *FilesystemController) RemoveDirs() {
 	paths := c.ctx.QueryArray("path")
@@ -182,7 +160,9 @@ func (c *FilesystemController) RemoveDirs() {
 	c.RespondSuccess(nil)
 }
 
-// SearchFiles searches for files matching a pattern in a directory
+// SearchFiles searches for files matching a pattern in a directory, skipping
+// the files and subtrees whose relative path matches an exclude pattern.
+// Symbolic links are listed, followed or skipped as followSymlinks says.
 func (c *FilesystemController) SearchFiles() {
 	path := c.ctx.Query("path")
 	if path == "" {
@@ -214,14 +194,25 @@ func (c *FilesystemController) SearchFiles() {
 	if pattern == "" {
 		pattern = "**"
 	}
+	excludes, ok := c.searchExcludes()
+	if !ok {
+		return
+	}
+	symlinks, ok := c.searchSymlinkMode()
+	if !ok {
+		return
+	}
 
 	files := make([]model.FileInfo, 0, 16)
-	err = filepath.Walk(path, func(filePath string, info os.FileInfo, err error) error {
-		if os.IsNotExist(err) {
-			return nil
-		}
+	err = walkSearch(path, symlinks, func(filePath string, info os.FileInfo, err error) error {
 		if err != nil {
-			return fmt.Errorf("error accessing path %s: %w", filePath, err)
+			return searchWalkError(path, filePath, err)
+		}
+		if rel, err := filepath.Rel(path, filePath); err == nil && rel != "." && excludedFromSearch(excludes, rel) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
 		}
 		if info.IsDir() {
 			return nil
@@ -313,3 +304,15 @@ func (c *FilesystemController) ReplaceContent() {
 
 	c.RespondSuccess(nil)
 }
+
+// fileKey identifies a directory of a search following links by its path
+// with every link resolved.
+type fileKey string
+
+func fileKeyOf(path string, _ os.FileInfo) (fileKey, bool) {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", false
+	}
+	return fileKey(resolved), true
+}
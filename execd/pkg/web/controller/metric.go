@@ -16,19 +16,48 @@ package controller
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"os"
 	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/shirou/gopsutil/cpu"
+	"github.com/shirou/gopsutil/disk"
+	"github.com/shirou/gopsutil/load"
 	"github.com/shirou/gopsutil/mem"
+	"github.com/shirou/gopsutil/process"
 
-	"github.com/alibaba/opensandbox/execd/pkg/log"
+	"github.com/alibaba/opensandbox/execd/pkg/flag"
+	"github.com/alibaba/opensandbox/execd/pkg/util/safego"
 	"github.com/alibaba/opensandbox/execd/pkg/web/model"
 )
 
+const (
+	// processSampleInterval is the window the CPU usage of processes is measured over.
+	processSampleInterval = 500 * time.Millisecond
+	// cpuSampleInterval is how often the shared sampler refreshes the CPU usage.
+	cpuSampleInterval = time.Second
+)
+
+// cpuUsage is the latest CPU usage of the shared sampler, read by every
+// metrics handler so that concurrent readers neither wait for a sample window
+// each nor report different values for the same moment.
+var cpuUsage = newCPUUsageCache(cpuSampleInterval)
+
+// InitCPUSampler starts the shared CPU sampler, so that the first metrics
+// request doesn't wait for a sample window.
+func InitCPUSampler() {
+	cpuUsage.start()
+}
+
 // MetricController handles system metrics requests
 type MetricController struct {
 	*basicController
@@ -38,8 +67,15 @@ func NewMetricController(ctx *gin.Context) *MetricController {
 	return &MetricController{basicController: newBasicController(ctx)}
 }
 
-// GetMetrics returns current system metrics
+// GetMetrics returns current system metrics as JSON, or in the Prometheus text
+// format when asked for by the format query or the Accept header.
 func (c *MetricController) GetMetrics() {
+	format, err := metricsFormat(c.ctx)
+	if err != nil {
+		c.RespondError(http.StatusBadRequest, model.ErrorCodeInvalidRequest, err.Error())
+		return
+	}
+
 	metrics, err := c.readMetrics()
 	if err != nil {
 		c.RespondError(
@@ -50,62 +86,386 @@ func (c *MetricController) GetMetrics() {
 		return
 	}
 
+	if format == "prometheus" {
+		c.ctx.Data(http.StatusOK, prometheusContentType, []byte(renderPrometheusMetrics(metrics)))
+		return
+	}
 	c.RespondSuccess(metrics)
 }
 
-// WatchMetrics streams system metrics via SSE
+// GetProcessMetrics returns the resource usage of tracked commands and kernels,
+// highest CPU usage first, optionally capped by the limit query.
+func (c *MetricController) GetProcessMetrics() {
+	limit := c.QueryInt64(c.ctx.Query("limit"), 0)
+	if limit < 0 {
+		c.RespondError(
+			http.StatusBadRequest,
+			model.ErrorCodeInvalidRequest,
+			fmt.Sprintf("invalid limit %d, must not be negative", limit),
+		)
+		return
+	}
+
+	processes := make([]model.ProcessMetrics, 0)
+	if codeRunner != nil {
+		usages, err := codeRunner.ProcessUsage(processSampleInterval)
+		if err != nil {
+			c.RespondError(
+				http.StatusInternalServerError,
+				model.ErrorCodeRuntimeError,
+				fmt.Sprintf("error reading process metrics. %v", err),
+			)
+			return
+		}
+		if limit > 0 && int64(len(usages)) > limit {
+			usages = usages[:limit]
+		}
+		for _, usage := range usages {
+			processes = append(processes, model.ProcessMetrics{
+				Session:       usage.Session,
+				Kind:          string(usage.Kind),
+				Pid:           usage.PID,
+				Running:       usage.Running,
+				CpuPercent:    usage.CPUPercent,
+				RssMiB:        float64(usage.RSSBytes) / 1024 / 1024,
+				Threads:       usage.Threads,
+				UptimeSeconds: usage.Uptime.Seconds(),
+				ExitCode:      usage.ExitCode,
+				FinishedAt:    usage.FinishedAt,
+				Error:         usage.Error,
+			})
+		}
+	}
+
+	c.RespondSuccess(processes)
+}
+
+// WatchMetrics streams system metrics via SSE, one sample per interval query
+// (default 1s, bounded by the configured limits) until the client disconnects
+// or max_duration elapses, which ends the stream with an end event.
 func (c *MetricController) WatchMetrics() {
+	interval, err := watchDuration(c.ctx.Query("interval"), time.Second)
+	if err != nil {
+		c.RespondError(http.StatusBadRequest, model.ErrorCodeInvalidRequest, fmt.Sprintf("invalid interval. %v", err))
+		return
+	}
+	interval = boundWatchInterval(interval)
+	maxDuration, err := watchDuration(c.ctx.Query("max_duration"), 0)
+	if err != nil {
+		c.RespondError(http.StatusBadRequest, model.ErrorCodeInvalidRequest, fmt.Sprintf("invalid max_duration. %v", err))
+		return
+	}
+
 	c.setupSSEResponse()
 
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var deadline <-chan time.Time
+	if maxDuration > 0 {
+		timer := time.NewTimer(maxDuration)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
 	for {
 		select {
 		case <-c.ctx.Request.Context().Done():
 			return
-		case <-time.After(time.Second * 1):
-			func() {
-				if flusher, ok := c.ctx.Writer.(http.Flusher); ok {
-					defer flusher.Flush()
-				}
-				metrics, err := c.readMetrics()
-				if err != nil {
-					msg, _ := json.Marshal(map[string]string{ //nolint:errchkjson
-						"error": err.Error(),
-					})
-					_, err = c.ctx.Writer.Write(append(msg, '\n'))
-					if err != nil {
-						log.Error("WatchMetrics write data %s error: %v", string(msg), err)
-					}
-				} else {
-					msg, _ := json.Marshal(metrics) //nolint:errchkjson
-					_, err = c.ctx.Writer.Write(append(msg, '\n'))
-					if err != nil {
-						log.Error("WatchMetrics write data %s error: %v", string(msg), err)
-					}
-				}
-			}()
+		case <-deadline:
+			c.writeWatchEvent(map[string]string{"type": "end"})
+			return
+		case <-ticker.C:
+			metrics, err := collectMetrics()
+			if err != nil {
+				c.writeWatchEvent(map[string]string{"error": err.Error()})
+			} else {
+				c.writeWatchEvent(metrics)
+			}
+		}
+	}
+}
+
+// writeWatchEvent writes one JSON line of the metrics stream and flushes it.
+func (c *MetricController) writeWatchEvent(event any) {
+	msg, _ := json.Marshal(event) //nolint:errchkjson
+	if _, err := c.ctx.Writer.Write(append(msg, '\n')); err != nil {
+		logger.Error("WatchMetrics write data %s error: %v", string(msg), err)
+	}
+	if flusher, ok := c.ctx.Writer.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// boundWatchInterval clamps interval to the configured limits; unset limits are ignored.
+func boundWatchInterval(interval time.Duration) time.Duration {
+	if flag.MetricsWatchMaxInterval > 0 {
+		interval = min(interval, flag.MetricsWatchMaxInterval)
+	}
+	interval = max(interval, flag.MetricsWatchMinInterval)
+	if interval <= 0 {
+		return time.Second
+	}
+	return interval
+}
+
+// watchDuration parses a duration query such as 10s, or a bare number of seconds.
+func watchDuration(query string, defaultValue time.Duration) (time.Duration, error) {
+	if query == "" {
+		return defaultValue, nil
+	}
+	duration, err := time.ParseDuration(query)
+	if err != nil {
+		seconds, convErr := strconv.ParseFloat(query, 64)
+		if convErr != nil {
+			return 0, err
 		}
+		duration = time.Duration(seconds * float64(time.Second))
 	}
+	if duration < 0 {
+		return 0, fmt.Errorf("%q must not be negative", query)
+	}
+	return duration, nil
 }
 
-// readMetrics collects current CPU and memory metrics
+// readMetrics collects current CPU, memory and disk metrics
 func (c *MetricController) readMetrics() (*model.Metrics, error) {
+	return collectMetrics()
+}
+
+// collectMetrics reads the latest CPU usage of the shared sampler along with
+// memory and disk metrics. CPU and memory are measured against the limits of
+// execd's cgroup when it has any.
+func collectMetrics() (*model.Metrics, error) {
 	metric := model.NewMetrics()
+	cgroup := readCgroupStats()
 
-	metric.CpuCount = float64(runtime.GOMAXPROCS(-1))
-	cpuPercent, err := cpu.Percent(time.Second, false)
+	cpuPercent, err := cpuUsage.get()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get CPU percent: %w", err)
 	}
-	if len(cpuPercent) > 0 {
-		metric.CpuUsedPct = cpuPercent[0]
-	}
+	metric.CpuUsedPct = cpuPercent
 
 	vmStat, err := mem.VirtualMemory()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get memory info: %w", err)
 	}
+
+	metric.Source = model.MetricsSourceHost
+	// GOMAXPROCS follows the CPU quota too, as set by automaxprocs.
+	metric.CpuCount = float64(runtime.GOMAXPROCS(-1))
+	if cgroup.cpus > 0 {
+		metric.CpuCount = cgroup.cpus
+		metric.Source = model.MetricsSourceCgroup
+	}
 	metric.MemTotalMiB = float64(vmStat.Total) / 1024 / 1024
 	metric.MemUsedMiB = float64(vmStat.Used) / 1024 / 1024
+	// cgroup v1 reports a huge limit rather than none when memory is unlimited.
+	if cgroup.memLimit > 0 && cgroup.memLimit < vmStat.Total {
+		metric.MemTotalMiB = float64(cgroup.memLimit) / 1024 / 1024
+		metric.MemUsedMiB = float64(cgroup.memUsage) / 1024 / 1024
+		metric.Source = model.MetricsSourceCgroup
+	}
+
+	metric.Disks = readDiskMetrics(metricsDiskPaths())
+	readLoadMetrics(metric)
 
 	return metric, nil
 }
+
+// readLoadMetrics fills in the load averages and the process, thread, descriptor
+// and goroutine counts. Metrics the platform doesn't provide are left at zero.
+func readLoadMetrics(metric *model.Metrics) {
+	if avg, err := load.Avg(); err == nil {
+		metric.Load1, metric.Load5, metric.Load15 = avg.Load1, avg.Load5, avg.Load15
+	}
+	if pids, err := process.Pids(); err == nil {
+		metric.ProcessCount = len(pids)
+	}
+	// ProcsTotal counts the scheduling entities of /proc/loadavg, i.e. threads.
+	if misc, err := load.Misc(); err == nil {
+		metric.ThreadCount = misc.ProcsTotal
+	}
+	metric.ExecdOpenFDs = countOpenFDs()
+	metric.ExecdGoroutines = runtime.NumGoroutine()
+	metric.ExecdPanics = safego.PanicCount()
+}
+
+// readDiskMetrics reports the filesystem usage of each path; paths that can't be
+// read are skipped so that a missing mount doesn't hide the other metrics.
+func readDiskMetrics(paths []string) []model.DiskMetrics {
+	disks := make([]model.DiskMetrics, 0, len(paths))
+	for _, path := range paths {
+		usage, err := disk.Usage(path)
+		if err != nil {
+			logger.Warning("failed to get disk usage of %s: %v", path, err)
+			continue
+		}
+		disks = append(disks, model.DiskMetrics{
+			Path:         path,
+			DiskTotalMiB: float64(usage.Total) / 1024 / 1024,
+			DiskUsedMiB:  float64(usage.Used) / 1024 / 1024,
+			DiskUsedPct:  usage.UsedPercent,
+		})
+	}
+	return disks
+}
+
+// metricsDiskPaths returns the paths configured by --metrics-disk-paths, defaulting
+// to the root and the working directory commands run in.
+func metricsDiskPaths() []string {
+	var paths []string
+	if flag.MetricsDiskPaths != "" {
+		for _, path := range strings.Split(flag.MetricsDiskPaths, ",") {
+			if path = strings.TrimSpace(path); path != "" && !slices.Contains(paths, path) {
+				paths = append(paths, path)
+			}
+		}
+		return paths
+	}
+
+	paths = append(paths, "/")
+	if wd, err := os.Getwd(); err == nil && wd != "/" {
+		paths = append(paths, wd)
+	}
+	return paths
+}
+
+// cgroupStats describes the cgroup execd runs in; zero limits mean unlimited.
+type cgroupStats struct {
+	// cpus is the CPU quota in CPUs, e.g. 1.5.
+	cpus     float64
+	memLimit uint64
+	memUsage uint64
+}
+
+// cpuSampler measures CPU usage between its own successive samples, so that
+// concurrent watchers don't skew each other the way cpu.Percent(0, ...) would.
+type cpuSampler struct {
+	last cpu.TimesStat
+	// lastUsage is the CPU time used by execd's cgroup at lastAt, zero when unknown.
+	lastUsage time.Duration
+	lastAt    time.Time
+}
+
+func newCPUSampler() (*cpuSampler, error) {
+	times, err := totalCPUTimes()
+	if err != nil {
+		return nil, err
+	}
+	sampler := &cpuSampler{last: times}
+	if usage, ok := readCgroupCPUUsage(); ok {
+		sampler.lastUsage, sampler.lastAt = usage, time.Now()
+	}
+	return sampler, nil
+}
+
+// percent returns the CPU usage since the previous call, or since the sampler was
+// created. With a cgroup quota of cpus the usage is relative to the quota,
+// otherwise to all CPUs of the host.
+func (s *cpuSampler) percent(cpus float64) (float64, error) {
+	cur, err := totalCPUTimes()
+	if err != nil {
+		return 0, err
+	}
+	prev := s.last
+	s.last = cur
+
+	usage, haveUsage := readCgroupCPUUsage()
+	now := time.Now()
+	prevUsage, prevAt := s.lastUsage, s.lastAt
+	s.lastUsage, s.lastAt = 0, time.Time{}
+	if haveUsage {
+		s.lastUsage, s.lastAt = usage, now
+	}
+	if cpus > 0 && haveUsage && !prevAt.IsZero() {
+		elapsed := now.Sub(prevAt)
+		if usage <= prevUsage || elapsed <= 0 {
+			return 0, nil
+		}
+		return math.Min(100, float64(usage-prevUsage)/(float64(elapsed)*cpus)*100), nil
+	}
+
+	// iowait counts as busy, as in cpu.Percent.
+	busy := func(t cpu.TimesStat) float64 { return t.Total() - t.Idle }
+	deltaBusy, deltaTotal := busy(cur)-busy(prev), cur.Total()-prev.Total()
+	if deltaBusy <= 0 || deltaTotal <= 0 {
+		return 0, nil
+	}
+	return math.Min(100, deltaBusy/deltaTotal*100), nil
+}
+
+// cpuUsageCache refreshes the CPU usage every interval from a background
+// goroutine, started by the first reader unless InitCPUSampler did earlier.
+type cpuUsageCache struct {
+	interval time.Duration
+	once     sync.Once
+	// ready is closed once the first sample is stored.
+	ready     chan struct{}
+	readyOnce sync.Once
+
+	mu      sync.RWMutex
+	percent float64
+	err     error
+}
+
+func newCPUUsageCache(interval time.Duration) *cpuUsageCache {
+	return &cpuUsageCache{interval: interval, ready: make(chan struct{})}
+}
+
+func (c *cpuUsageCache) start() {
+	c.once.Do(func() { safego.Go(func() { c.sample(nil) }) })
+}
+
+// sample stores the CPU usage since the previous sample every interval until
+// stop is closed.
+func (c *cpuUsageCache) sample(stop <-chan struct{}) {
+	// readers must not wait forever when sampling can't start or panics.
+	defer c.readyOnce.Do(func() { close(c.ready) })
+
+	sampler, err := newCPUSampler()
+	if err != nil {
+		c.store(0, err)
+		return
+	}
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			c.store(sampler.percent(readCgroupStats().cpus))
+		}
+	}
+}
+
+func (c *cpuUsageCache) store(percent float64, err error) {
+	c.mu.Lock()
+	c.percent, c.err = percent, err
+	c.mu.Unlock()
+	c.readyOnce.Do(func() { close(c.ready) })
+}
+
+// get returns the latest CPU usage, waiting for the first sample when the
+// sampler has just started.
+func (c *cpuUsageCache) get() (float64, error) {
+	c.start()
+	<-c.ready
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.percent, c.err
+}
+
+// totalCPUTimes returns the CPU times summed over all CPUs.
+func totalCPUTimes() (cpu.TimesStat, error) {
+	times, err := cpu.Times(false)
+	if err != nil {
+		return cpu.TimesStat{}, fmt.Errorf("failed to get CPU times: %w", err)
+	}
+	if len(times) == 0 {
+		return cpu.TimesStat{}, errors.New("failed to get CPU times: none reported")
+	}
+	return times[0], nil
+}
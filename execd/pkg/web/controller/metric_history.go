@@ -0,0 +1,173 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/alibaba/opensandbox/execd/pkg/flag"
+	"github.com/alibaba/opensandbox/execd/pkg/util/safego"
+	"github.com/alibaba/opensandbox/execd/pkg/web/model"
+)
+
+const (
+	// minMetricsHistoryInterval keeps CPU usage samples meaningful.
+	minMetricsHistoryInterval = time.Second
+	// maxMetricsHistorySize bounds the memory of the history, a day of samples at the minimum interval.
+	maxMetricsHistorySize = 86400
+)
+
+// metricsHistory holds the samples of the background sampler, nil when the history is disabled.
+var metricsHistory *metricsRing
+
+// InitMetricsHistory starts sampling metrics for /metrics/history unless
+// --metrics-history-size disables it.
+func InitMetricsHistory() {
+	size := flag.MetricsHistorySize
+	if size <= 0 {
+		return
+	}
+	if size > maxMetricsHistorySize {
+		logger.Warning("metrics history size %d exceeds %d, capping it", size, maxMetricsHistorySize)
+		size = maxMetricsHistorySize
+	}
+	interval := flag.MetricsHistoryInterval
+	if interval < minMetricsHistoryInterval {
+		logger.Warning("metrics history interval %v is below %v, raising it", interval, minMetricsHistoryInterval)
+		interval = minMetricsHistoryInterval
+	}
+
+	metricsHistory = newMetricsRing(size, interval)
+	safego.Go(func() { metricsHistory.sample(nil) })
+}
+
+// metricsRing is a fixed size buffer of the latest metric samples.
+type metricsRing struct {
+	interval time.Duration
+
+	mu      sync.RWMutex
+	samples []model.Metrics
+	next    int
+	full    bool
+}
+
+func newMetricsRing(size int, interval time.Duration) *metricsRing {
+	return &metricsRing{interval: interval, samples: make([]model.Metrics, size)}
+}
+
+// sample records metrics every interval until stop is closed. CPU usage is
+// the latest of the shared sampler.
+func (r *metricsRing) sample(stop <-chan struct{}) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			metrics, err := collectMetrics()
+			if err != nil {
+				logger.Warning("failed to sample metrics history: %v", err)
+				continue
+			}
+			r.add(*metrics)
+		}
+	}
+}
+
+// add stores a sample, overwriting the oldest one once the buffer is full.
+func (r *metricsRing) add(metrics model.Metrics) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.samples[r.next] = metrics
+	r.next = (r.next + 1) % len(r.samples)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// since returns the samples taken at or after the unix millisecond since,
+// oldest first, keeping at most one sample per step when step is positive.
+func (r *metricsRing) since(since int64, step time.Duration) []model.Metrics {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ordered := r.samples[:r.next]
+	if r.full {
+		ordered = append(append([]model.Metrics{}, r.samples[r.next:]...), r.samples[:r.next]...)
+	}
+
+	series := make([]model.Metrics, 0, len(ordered))
+	var last int64
+	for _, metrics := range ordered {
+		if metrics.Timestamp < since {
+			continue
+		}
+		if len(series) > 0 && step > 0 && metrics.Timestamp-last < step.Milliseconds() {
+			continue
+		}
+		series = append(series, metrics)
+		last = metrics.Timestamp
+	}
+	return series
+}
+
+// GetMetricsHistory returns the buffered metric samples, filtered by the since
+// query (unix milliseconds) and thinned out to one per step (a duration such as 30s).
+func (c *MetricController) GetMetricsHistory() {
+	history := metricsHistory
+	if history == nil {
+		c.RespondError(
+			http.StatusNotFound,
+			model.ErrorCodeMetricsHistoryDisabled,
+			"metrics history is disabled, set --metrics-history-size to enable it",
+		)
+		return
+	}
+
+	since := c.QueryInt64(c.ctx.Query("since"), 0)
+	if since < 0 {
+		c.RespondError(
+			http.StatusBadRequest,
+			model.ErrorCodeInvalidRequest,
+			fmt.Sprintf("invalid since %d, must not be negative", since),
+		)
+		return
+	}
+
+	var step time.Duration
+	if raw := c.ctx.Query("step"); raw != "" {
+		var err error
+		step, err = time.ParseDuration(raw)
+		if err != nil || step < 0 {
+			c.RespondError(
+				http.StatusBadRequest,
+				model.ErrorCodeInvalidRequest,
+				fmt.Sprintf("invalid step %q, must be a non-negative duration such as 30s", raw),
+			)
+			return
+		}
+	}
+
+	c.RespondSuccess(model.MetricsHistory{
+		IntervalSeconds: history.interval.Seconds(),
+		Samples:         history.since(since, step),
+	})
+}
@@ -0,0 +1,113 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/alibaba/opensandbox/execd/pkg/web/model"
+)
+
+func timestamps(samples []model.Metrics) []int64 {
+	stamps := make([]int64, 0, len(samples))
+	for _, sample := range samples {
+		stamps = append(stamps, sample.Timestamp)
+	}
+	return stamps
+}
+
+func TestMetricsRingKeepsLatestSamples(t *testing.T) {
+	ring := newMetricsRing(3, time.Second)
+	assert.Empty(t, ring.since(0, 0))
+
+	for ts := int64(1000); ts <= 5000; ts += 1000 {
+		ring.add(model.Metrics{Timestamp: ts})
+	}
+
+	assert.Equal(t, []int64{3000, 4000, 5000}, timestamps(ring.since(0, 0)))
+	assert.Equal(t, []int64{4000, 5000}, timestamps(ring.since(3500, 0)))
+	assert.Equal(t, []int64{3000, 5000}, timestamps(ring.since(0, 2*time.Second)))
+}
+
+func TestMetricsRingSampleStops(t *testing.T) {
+	ring := newMetricsRing(2, 10*time.Millisecond)
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ring.sample(stop)
+	}()
+
+	assert.Eventually(t, func() bool { return len(ring.since(0, 0)) == 2 }, 5*time.Second, 10*time.Millisecond)
+	close(stop)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("sampler did not stop")
+	}
+}
+
+func TestMetricsRingSampleGrowsAndFiltersBySince(t *testing.T) {
+	ring := newMetricsRing(16, 20*time.Millisecond)
+	stop := make(chan struct{})
+	defer close(stop)
+	go ring.sample(stop)
+
+	assert.Eventually(t, func() bool { return len(ring.since(0, 0)) >= 2 }, 5*time.Second, 10*time.Millisecond)
+	first := ring.since(0, 0)
+	assert.Eventually(t, func() bool { return len(ring.since(0, 0)) > len(first) }, 5*time.Second, 10*time.Millisecond)
+
+	samples := ring.since(0, 0)
+	since := samples[1].Timestamp
+	filtered := ring.since(since, 0)
+	assert.NotEmpty(t, filtered)
+	assert.Less(t, len(filtered), len(ring.since(0, 0)))
+	for _, sample := range filtered {
+		assert.GreaterOrEqual(t, sample.Timestamp, since)
+	}
+}
+
+func TestGetMetricsHistory(t *testing.T) {
+	prev := metricsHistory
+	t.Cleanup(func() { metricsHistory = prev })
+
+	metricsHistory = nil
+	ctrl, w := setupMetricController("GET", "/api/metrics/history")
+	ctrl.GetMetricsHistory()
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	metricsHistory = newMetricsRing(4, 5*time.Second)
+	metricsHistory.add(model.Metrics{Timestamp: 1000, CpuUsedPct: 10})
+	metricsHistory.add(model.Metrics{Timestamp: 6000, CpuUsedPct: 95})
+
+	ctrl, w = setupMetricController("GET", "/api/metrics/history?since=2000")
+	ctrl.GetMetricsHistory()
+	assert.Equal(t, http.StatusOK, w.Code)
+	var history model.MetricsHistory
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &history))
+	assert.Equal(t, 5.0, history.IntervalSeconds)
+	if assert.Equal(t, []int64{6000}, timestamps(history.Samples)) {
+		assert.Equal(t, 95.0, history.Samples[0].CpuUsedPct)
+	}
+
+	ctrl, w = setupMetricController("GET", "/api/metrics/history?step=soon")
+	ctrl.GetMetricsHistory()
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
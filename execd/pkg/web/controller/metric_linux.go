@@ -0,0 +1,145 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package controller
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// countOpenFDs returns the number of file descriptors execd holds open.
+func countOpenFDs() int {
+	entries, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		return 0
+	}
+	// reading the directory holds one descriptor of its own.
+	return max(len(entries)-1, 0)
+}
+
+// cgroupRoot is where the cgroup controllers are mounted.
+const cgroupRoot = "/sys/fs/cgroup"
+
+// cgroupFS reads the v1 or v2 cgroup execd belongs to, as listed by procCgroup,
+// from the controllers mounted under root.
+type cgroupFS struct {
+	root       string
+	procCgroup string
+}
+
+func readCgroupStats() cgroupStats {
+	return cgroupFS{root: cgroupRoot, procCgroup: "/proc/self/cgroup"}.stats()
+}
+
+func readCgroupCPUUsage() (time.Duration, bool) {
+	return cgroupFS{root: cgroupRoot, procCgroup: "/proc/self/cgroup"}.cpuUsage()
+}
+
+// stats reads the CPU quota and the memory limit and usage of the cgroup.
+func (fs cgroupFS) stats() cgroupStats {
+	var stats cgroupStats
+	paths := fs.paths()
+	if fs.unified() {
+		// cpu.max holds "$MAX $PERIOD", where $MAX may be "max".
+		if fields := strings.Fields(fs.read(paths, "", "cpu.max")); len(fields) == 2 {
+			stats.cpus = cpuQuota(fields[0], fields[1])
+		}
+		if limit := fs.read(paths, "", "memory.max"); limit != "max" {
+			stats.memLimit, _ = strconv.ParseUint(limit, 10, 64)
+		}
+		stats.memUsage, _ = strconv.ParseUint(fs.read(paths, "", "memory.current"), 10, 64)
+		return stats
+	}
+
+	stats.cpus = cpuQuota(fs.read(paths, "cpu", "cpu.cfs_quota_us"), fs.read(paths, "cpu", "cpu.cfs_period_us"))
+	stats.memLimit, _ = strconv.ParseUint(fs.read(paths, "memory", "memory.limit_in_bytes"), 10, 64)
+	stats.memUsage, _ = strconv.ParseUint(fs.read(paths, "memory", "memory.usage_in_bytes"), 10, 64)
+	return stats
+}
+
+// cpuUsage returns the CPU time consumed by the cgroup so far.
+func (fs cgroupFS) cpuUsage() (time.Duration, bool) {
+	paths := fs.paths()
+	if fs.unified() {
+		for _, line := range strings.Split(fs.read(paths, "", "cpu.stat"), "\n") {
+			if value, ok := strings.CutPrefix(line, "usage_usec "); ok {
+				usec, err := strconv.ParseInt(value, 10, 64)
+				return time.Duration(usec) * time.Microsecond, err == nil
+			}
+		}
+		return 0, false
+	}
+
+	nsec, err := strconv.ParseInt(fs.read(paths, "cpuacct", "cpuacct.usage"), 10, 64)
+	return time.Duration(nsec), err == nil
+}
+
+// unified reports whether the cgroup v2 hierarchy is mounted at root.
+func (fs cgroupFS) unified() bool {
+	_, err := os.Stat(filepath.Join(fs.root, "cgroup.controllers"))
+	return err == nil
+}
+
+// paths maps each v1 controller, or "" for v2, to the cgroup execd belongs to.
+func (fs cgroupFS) paths() map[string]string {
+	paths := make(map[string]string)
+	data, err := os.ReadFile(fs.procCgroup)
+	if err != nil {
+		return paths
+	}
+	// each line reads "hierarchy-ID:controller-list:cgroup-path".
+	for _, line := range strings.Split(string(data), "\n") {
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		for _, controller := range strings.Split(parts[1], ",") {
+			paths[controller] = parts[2]
+		}
+	}
+	return paths
+}
+
+// read returns the trimmed content of file for controller, or "" when it can't be
+// read. Without a cgroup namespace the listed path doesn't exist inside the
+// container, whose own cgroup is then mounted at the controller root.
+func (fs cgroupFS) read(paths map[string]string, controller, file string) string {
+	mount := filepath.Join(fs.root, controller)
+	for _, dir := range []string{filepath.Join(mount, paths[controller]), mount} {
+		if data, err := os.ReadFile(filepath.Join(dir, file)); err == nil {
+			return strings.TrimSpace(string(data))
+		}
+	}
+	return ""
+}
+
+// cpuQuota converts a CFS quota and period into a number of CPUs, 0 when unlimited.
+func cpuQuota(quota, period string) float64 {
+	q, err := strconv.ParseFloat(quota, 64)
+	if err != nil || q <= 0 {
+		return 0
+	}
+	p, err := strconv.ParseFloat(period, 64)
+	if err != nil || p <= 0 {
+		return 0
+	}
+	return q / p
+}
@@ -0,0 +1,108 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package controller
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeCgroupFiles creates files, keyed by path relative to root, with their content.
+func writeCgroupFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		assert.NoError(t, os.WriteFile(path, []byte(content+"\n"), 0o644))
+	}
+}
+
+func TestCgroupFS_V2(t *testing.T) {
+	root := t.TempDir()
+	procCgroup := filepath.Join(t.TempDir(), "cgroup")
+	writeCgroupFiles(t, root, map[string]string{
+		"cgroup.controllers":       "cpu memory",
+		"sandbox/cpu.max":          "150000 100000",
+		"sandbox/memory.max":       "4294967296",
+		"sandbox/memory.current":   "1073741824",
+		"sandbox/cpu.stat":         "usage_usec 2500000\nuser_usec 2000000",
+		"cpu.max":                  "max 100000",
+		"unrelated/memory.current": "1",
+	})
+	writeCgroupFiles(t, filepath.Dir(procCgroup), map[string]string{"cgroup": "0::/sandbox"})
+	fs := cgroupFS{root: root, procCgroup: procCgroup}
+
+	assert.Equal(t, cgroupStats{cpus: 1.5, memLimit: 4 << 30, memUsage: 1 << 30}, fs.stats())
+	usage, ok := fs.cpuUsage()
+	assert.True(t, ok)
+	assert.Equal(t, 2500*time.Millisecond, usage)
+}
+
+func TestCgroupFS_V2Unlimited(t *testing.T) {
+	root := t.TempDir()
+	procCgroup := filepath.Join(t.TempDir(), "cgroup")
+	// without a cgroup namespace the listed path is missing inside the container.
+	writeCgroupFiles(t, root, map[string]string{
+		"cgroup.controllers": "cpu memory",
+		"cpu.max":            "max 100000",
+		"memory.max":         "max",
+		"memory.current":     "1024",
+	})
+	writeCgroupFiles(t, filepath.Dir(procCgroup), map[string]string{"cgroup": "0::/kubepods/pod1/ctr"})
+	fs := cgroupFS{root: root, procCgroup: procCgroup}
+
+	assert.Equal(t, cgroupStats{memUsage: 1024}, fs.stats())
+	_, ok := fs.cpuUsage()
+	assert.False(t, ok)
+}
+
+func TestCgroupFS_V1(t *testing.T) {
+	root := t.TempDir()
+	procCgroup := filepath.Join(t.TempDir(), "cgroup")
+	writeCgroupFiles(t, root, map[string]string{
+		"cpu/docker/abc/cpu.cfs_quota_us":           "200000",
+		"cpu/docker/abc/cpu.cfs_period_us":          "100000",
+		"cpuacct/docker/abc/cpuacct.usage":          "3000000000",
+		"memory/docker/abc/memory.limit_in_bytes":   "2147483648",
+		"memory/docker/abc/memory.usage_in_bytes":   "536870912",
+		"memory/memory.limit_in_bytes":              "9223372036854771712",
+		"cpu/cpu.cfs_quota_us":                      "-1",
+		"cpuacct/docker/other/cpuacct.usage":        "1",
+		"memory/docker/other/memory.usage_in_bytes": "1",
+	})
+	writeCgroupFiles(t, filepath.Dir(procCgroup), map[string]string{
+		"cgroup": "4:memory:/docker/abc\n3:cpu,cpuacct:/docker/abc\n0::/",
+	})
+	fs := cgroupFS{root: root, procCgroup: procCgroup}
+
+	assert.Equal(t, cgroupStats{cpus: 2, memLimit: 2 << 30, memUsage: 512 << 20}, fs.stats())
+	usage, ok := fs.cpuUsage()
+	assert.True(t, ok)
+	assert.Equal(t, 3*time.Second, usage)
+}
+
+func TestCPUQuota(t *testing.T) {
+	assert.Equal(t, 0.5, cpuQuota("50000", "100000"))
+	assert.Zero(t, cpuQuota("max", "100000"))
+	assert.Zero(t, cpuQuota("-1", "100000"))
+	assert.Zero(t, cpuQuota("100000", ""))
+}
@@ -0,0 +1,34 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !linux
+// +build !linux
+
+package controller
+
+import "time"
+
+// countOpenFDs is only implemented on Linux.
+func countOpenFDs() int {
+	return 0
+}
+
+// readCgroupStats reports no limits, cgroups only exist on Linux.
+func readCgroupStats() cgroupStats {
+	return cgroupStats{}
+}
+
+func readCgroupCPUUsage() (time.Duration, bool) {
+	return 0, false
+}
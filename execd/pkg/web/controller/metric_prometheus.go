@@ -0,0 +1,115 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/alibaba/opensandbox/execd/pkg/web/model"
+)
+
+// prometheusContentType is the content type of the Prometheus text exposition format.
+const prometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// metricsFormat returns the format /metrics is rendered in: the format query when
+// given, otherwise prometheus for scrapers accepting the text exposition format.
+func metricsFormat(ctx *gin.Context) (string, error) {
+	switch format := ctx.Query("format"); format {
+	case "json", "prometheus":
+		return format, nil
+	case "":
+	default:
+		return "", fmt.Errorf("unsupported format %q, must be json or prometheus", format)
+	}
+
+	accept := ctx.GetHeader("Accept")
+	if strings.Contains(accept, "text/plain") || strings.Contains(accept, "application/openmetrics-text") {
+		return "prometheus", nil
+	}
+	return "json", nil
+}
+
+// renderPrometheusMetrics renders metrics as gauges and counters in the Prometheus text format.
+func renderPrometheusMetrics(metrics *model.Metrics) string {
+	var b strings.Builder
+	gauge := func(name, help string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+	}
+	counter := func(name, help string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+	}
+	sample := func(name string, value float64, labels ...string) {
+		b.WriteString(name)
+		if len(labels) > 0 {
+			b.WriteByte('{')
+			for i := 0; i+1 < len(labels); i += 2 {
+				if i > 0 {
+					b.WriteByte(',')
+				}
+				fmt.Fprintf(&b, "%s=\"%s\"", labels[i], escapePrometheusLabel(labels[i+1]))
+			}
+			b.WriteByte('}')
+		}
+		b.WriteByte(' ')
+		b.WriteString(strconv.FormatFloat(value, 'f', -1, 64))
+		b.WriteByte('\n')
+	}
+	single := func(name, help string, value float64) {
+		gauge(name, help)
+		sample(name, value)
+	}
+
+	single("execd_cpu_count", "Number of CPUs available to execd.", metrics.CpuCount)
+	single("execd_cpu_used_percent", "CPU usage of the host in percent.", metrics.CpuUsedPct)
+	single("execd_memory_total_bytes", "Total memory of the host in bytes.", metrics.MemTotalMiB*1024*1024)
+	single("execd_memory_used_bytes", "Used memory of the host in bytes.", metrics.MemUsedMiB*1024*1024)
+
+	if len(metrics.Disks) > 0 {
+		gauge("execd_disk_total_bytes", "Size of the filesystem holding path in bytes.")
+		for _, d := range metrics.Disks {
+			sample("execd_disk_total_bytes", d.DiskTotalMiB*1024*1024, "path", d.Path)
+		}
+		gauge("execd_disk_used_bytes", "Used space of the filesystem holding path in bytes.")
+		for _, d := range metrics.Disks {
+			sample("execd_disk_used_bytes", d.DiskUsedMiB*1024*1024, "path", d.Path)
+		}
+		gauge("execd_disk_used_percent", "Used space of the filesystem holding path in percent.")
+		for _, d := range metrics.Disks {
+			sample("execd_disk_used_percent", d.DiskUsedPct, "path", d.Path)
+		}
+	}
+
+	gauge("execd_load_average", "System load average over period.")
+	sample("execd_load_average", metrics.Load1, "period", "1m")
+	sample("execd_load_average", metrics.Load5, "period", "5m")
+	sample("execd_load_average", metrics.Load15, "period", "15m")
+	single("execd_processes", "Number of processes on the host.", float64(metrics.ProcessCount))
+	single("execd_threads", "Number of threads on the host.", float64(metrics.ThreadCount))
+	single("execd_open_fds", "Number of file descriptors open in execd.", float64(metrics.ExecdOpenFDs))
+	single("execd_goroutines", "Number of goroutines in execd.", float64(metrics.ExecdGoroutines))
+	counter("execd_panics_total", "Goroutine panics recovered by execd.")
+	sample("execd_panics_total", float64(metrics.ExecdPanics))
+
+	return b.String()
+}
+
+// escapePrometheusLabel escapes a label value for the text exposition format.
+func escapePrometheusLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
@@ -0,0 +1,102 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/alibaba/opensandbox/execd/pkg/web/model"
+)
+
+func TestRenderPrometheusMetrics(t *testing.T) {
+	metrics := &model.Metrics{
+		CpuCount:    4,
+		CpuUsedPct:  12.5,
+		MemTotalMiB: 1024,
+		MemUsedMiB:  256,
+		Disks: []model.DiskMetrics{
+			{Path: "/", DiskTotalMiB: 2048, DiskUsedMiB: 1024, DiskUsedPct: 50},
+			{Path: `/odd "dir"`, DiskTotalMiB: 1, DiskUsedMiB: 0, DiskUsedPct: 0},
+		},
+		Load1:           0.5,
+		Load5:           0.25,
+		Load15:          0.125,
+		ProcessCount:    10,
+		ThreadCount:     20,
+		ExecdOpenFDs:    7,
+		ExecdGoroutines: 3,
+		ExecdPanics:     2,
+	}
+
+	out := renderPrometheusMetrics(metrics)
+
+	for _, line := range []string{
+		"# TYPE execd_cpu_used_percent gauge",
+		"execd_cpu_count 4",
+		"execd_cpu_used_percent 12.5",
+		"execd_memory_total_bytes 1073741824",
+		"execd_memory_used_bytes 268435456",
+		`execd_disk_total_bytes{path="/"} 2147483648`,
+		`execd_disk_used_percent{path="/"} 50`,
+		`execd_disk_used_bytes{path="/odd \"dir\""} 0`,
+		`execd_load_average{period="15m"} 0.125`,
+		"execd_processes 10",
+		"execd_threads 20",
+		"execd_open_fds 7",
+		"execd_goroutines 3",
+		"# TYPE execd_panics_total counter",
+		"execd_panics_total 2",
+	} {
+		assert.Contains(t, strings.Split(out, "\n"), line)
+	}
+	assert.Equal(t, 1, strings.Count(out, "# TYPE execd_disk_total_bytes gauge"))
+}
+
+func TestGetMetricsPrometheus(t *testing.T) {
+	cases := []struct {
+		path   string
+		accept string
+	}{
+		{path: "/metrics?format=prometheus"},
+		{path: "/metrics", accept: "application/openmetrics-text;version=1.0.0,text/plain;version=0.0.4;q=0.5,*/*;q=0.1"},
+	}
+	for _, tc := range cases {
+		ctrl, w := setupMetricController(http.MethodGet, tc.path)
+		ctrl.ctx.Request.Header.Set("Accept", tc.accept)
+
+		ctrl.GetMetrics()
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, prometheusContentType, w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Body.String(), "\nexecd_memory_total_bytes ")
+		assert.Contains(t, w.Body.String(), `execd_disk_used_bytes{path="/"} `)
+	}
+}
+
+func TestGetMetricsFormat(t *testing.T) {
+	ctrl, w := setupMetricController(http.MethodGet, "/metrics?format=json")
+	ctrl.ctx.Request.Header.Set("Accept", "text/plain")
+	ctrl.GetMetrics()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+
+	ctrl, w = setupMetricController(http.MethodGet, "/metrics?format=xml")
+	ctrl.GetMetrics()
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
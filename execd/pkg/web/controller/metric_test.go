@@ -15,14 +15,21 @@
 package controller
 
 import (
+	"bufio"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
+	goruntime "runtime"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/alibaba/opensandbox/execd/pkg/flag"
 	"github.com/alibaba/opensandbox/execd/pkg/web/model"
 )
 
@@ -52,6 +59,24 @@ func TestReadMetrics(t *testing.T) {
 	assert.Greater(t, metrics.MemTotalMiB, 0.0)
 	assert.GreaterOrEqual(t, metrics.MemUsedMiB, 0.0)
 	assert.LessOrEqual(t, metrics.MemUsedMiB, metrics.MemTotalMiB) // Used memory should not exceed total
+	assert.Contains(t, []model.MetricsSource{model.MetricsSourceHost, model.MetricsSourceCgroup}, metrics.Source)
+
+	// Validate disk usage of the root filesystem
+	if assert.NotEmpty(t, metrics.Disks) {
+		assert.Equal(t, "/", metrics.Disks[0].Path)
+		assert.Greater(t, metrics.Disks[0].DiskTotalMiB, 0.0)
+		assert.LessOrEqual(t, metrics.Disks[0].DiskUsedMiB, metrics.Disks[0].DiskTotalMiB)
+		assert.LessOrEqual(t, metrics.Disks[0].DiskUsedPct, 100.0)
+	}
+
+	// Validate load and process counts
+	assert.GreaterOrEqual(t, metrics.Load1, 0.0)
+	assert.Greater(t, metrics.ExecdGoroutines, 0)
+	if goruntime.GOOS == "linux" {
+		assert.Greater(t, metrics.ProcessCount, 0)
+		assert.GreaterOrEqual(t, metrics.ThreadCount, metrics.ProcessCount)
+		assert.Greater(t, metrics.ExecdOpenFDs, 2) // stdin, stdout and stderr at least
+	}
 
 	// Validate timestamps
 	currentTime := time.Now().UnixMilli()
@@ -61,6 +86,37 @@ func TestReadMetrics(t *testing.T) {
 }
 
 // TestGetMetricsEndpoint covers the happy path.
+func TestCPUUsageCacheSharesSamples(t *testing.T) {
+	const window = 300 * time.Millisecond
+	cache := newCPUUsageCache(window)
+	stop := make(chan struct{})
+	defer close(stop)
+	cache.once.Do(func() { go cache.sample(stop) })
+
+	// concurrent readers wait for the same first sample rather than one window each.
+	start := time.Now()
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			percent, err := cache.get()
+			assert.NoError(t, err)
+			assert.GreaterOrEqual(t, percent, 0.0)
+		}()
+	}
+	wg.Wait()
+	assert.Less(t, time.Since(start), 2*window)
+
+	// once sampled, reads return the cached value without waiting.
+	start = time.Now()
+	for range 8 {
+		_, err := cache.get()
+		assert.NoError(t, err)
+	}
+	assert.Less(t, time.Since(start), window/2)
+}
+
 func TestGetMetricsEndpoint(t *testing.T) {
 	ctrl, w := setupMetricController("GET", "/api/metrics")
 
@@ -106,6 +162,9 @@ func TestMetricSerialization(t *testing.T) {
 		MemTotalMiB: 8192,
 		MemUsedMiB:  4096,
 		Timestamp:   time.Now().UnixMilli(),
+		Disks: []model.DiskMetrics{
+			{Path: "/", DiskTotalMiB: 10240, DiskUsedMiB: 2560, DiskUsedPct: 25},
+		},
 	}
 
 	data, err := json.Marshal(metrics)
@@ -119,6 +178,14 @@ func TestMetricSerialization(t *testing.T) {
 	assert.Equal(t, metrics.MemTotalMiB, decodedMetrics.MemTotalMiB)
 	assert.Equal(t, metrics.MemUsedMiB, decodedMetrics.MemUsedMiB)
 	assert.Equal(t, metrics.Timestamp, decodedMetrics.Timestamp)
+	assert.Equal(t, metrics.Disks, decodedMetrics.Disks)
+	assert.Contains(t, string(data), `"disks":[{"path":"/","disk_total_mib":10240,"disk_used_mib":2560,"disk_used_pct":25}]`)
+
+	// metrics without disks keep the previous shape
+	data, err = json.Marshal(&model.Metrics{CpuCount: 1})
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "disks")
+	assert.Contains(t, string(data), `"load1":0,"load5":0,"load15":0,"process_count":0,"thread_count":0,"execd_open_fds":0,"execd_goroutines":0`)
 
 	errorMsg := map[string]string{"error": "test error"}
 	errorData, err := json.Marshal(errorMsg)
@@ -129,3 +196,135 @@ func TestMetricSerialization(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "test error", decodedError["error"])
 }
+
+// TestReadDiskMetricsPaths verifies configured paths and skipping unreadable ones.
+func TestReadDiskMetricsPaths(t *testing.T) {
+	original := flag.MetricsDiskPaths
+	defer func() { flag.MetricsDiskPaths = original }()
+
+	dir := t.TempDir()
+	flag.MetricsDiskPaths = " / ," + dir + ",/,"
+	assert.Equal(t, []string{"/", dir}, metricsDiskPaths())
+
+	disks := readDiskMetrics([]string{dir, filepath.Join(dir, "missing")})
+	if assert.Len(t, disks, 1) {
+		assert.Equal(t, dir, disks[0].Path)
+		assert.Greater(t, disks[0].DiskTotalMiB, 0.0)
+	}
+}
+
+// TestGetProcessMetricsRejectsNegativeLimit ensures limit is validated before sampling.
+func TestGetProcessMetricsRejectsNegativeLimit(t *testing.T) {
+	ctrl, w := setupMetricController("GET", "/api/metrics/processes?limit=-1")
+
+	ctrl.GetProcessMetrics()
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var resp model.ErrorResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, model.ErrorCodeInvalidRequest, resp.Code)
+}
+
+// newWatchServer serves WatchMetrics with a 10ms minimum interval; handled is
+// closed once the handler returns.
+func newWatchServer(t *testing.T) (*httptest.Server, <-chan struct{}) {
+	t.Helper()
+	prevMin := flag.MetricsWatchMinInterval
+	flag.MetricsWatchMinInterval = 10 * time.Millisecond
+	t.Cleanup(func() { flag.MetricsWatchMinInterval = prevMin })
+
+	gin.SetMode(gin.TestMode)
+	handled := make(chan struct{})
+	r := gin.New()
+	r.GET("/metrics/watch", func(ctx *gin.Context) {
+		defer close(handled)
+		NewMetricController(ctx).WatchMetrics()
+	})
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+	return server, handled
+}
+
+// TestWatchMetricsEndsAfterMaxDuration streams samples at a short interval until the end event.
+func TestWatchMetricsEndsAfterMaxDuration(t *testing.T) {
+	server, _ := newWatchServer(t)
+
+	resp, err := http.Get(server.URL + "/metrics/watch?interval=20ms&max_duration=300ms")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var lines []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	assert.NoError(t, scanner.Err())
+
+	if assert.GreaterOrEqual(t, len(lines), 3) {
+		assert.JSONEq(t, `{"type":"end"}`, lines[len(lines)-1])
+		var metrics model.Metrics
+		assert.NoError(t, json.Unmarshal([]byte(lines[0]), &metrics))
+		assert.Greater(t, metrics.MemTotalMiB, 0.0)
+	}
+}
+
+// TestWatchMetricsStopsOnDisconnect ensures the handler returns once the client goes away.
+func TestWatchMetricsStopsOnDisconnect(t *testing.T) {
+	server, handled := newWatchServer(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/metrics/watch?interval=10ms", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if !assert.NoError(t, err) {
+		cancel()
+		return
+	}
+	scanner := bufio.NewScanner(resp.Body)
+	assert.True(t, scanner.Scan(), "expected a first sample")
+	cancel()
+	_ = resp.Body.Close()
+
+	select {
+	case <-handled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("WatchMetrics kept running after the client disconnected")
+	}
+}
+
+// TestWatchMetricsRejectsInvalidQuery validates the interval and max_duration queries.
+func TestWatchMetricsRejectsInvalidQuery(t *testing.T) {
+	for _, query := range []string{"interval=soon", "max_duration=-1s"} {
+		ctrl, w := setupMetricController("GET", "/api/metrics/watch?"+query)
+		ctrl.WatchMetrics()
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func TestWatchDuration(t *testing.T) {
+	d, err := watchDuration("", time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Second, d)
+
+	d, err = watchDuration("10s", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 10*time.Second, d)
+
+	d, err = watchDuration("2.5", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 2500*time.Millisecond, d)
+}
+
+func TestBoundWatchInterval(t *testing.T) {
+	defer func(minInterval, maxInterval time.Duration) {
+		flag.MetricsWatchMinInterval, flag.MetricsWatchMaxInterval = minInterval, maxInterval
+	}(flag.MetricsWatchMinInterval, flag.MetricsWatchMaxInterval)
+	flag.MetricsWatchMinInterval, flag.MetricsWatchMaxInterval = time.Second, time.Minute
+
+	assert.Equal(t, time.Second, boundWatchInterval(0))
+	assert.Equal(t, 10*time.Second, boundWatchInterval(10*time.Second))
+	assert.Equal(t, time.Minute, boundWatchInterval(time.Hour))
+}
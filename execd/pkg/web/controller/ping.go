@@ -14,7 +14,19 @@
 
 package controller
 
-import "github.com/gin-gonic/gin"
+import (
+	"net/http"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/alibaba/opensandbox/execd/pkg/flag"
+	"github.com/alibaba/opensandbox/execd/pkg/runtime"
+	"github.com/alibaba/opensandbox/execd/pkg/version"
+	"github.com/alibaba/opensandbox/execd/pkg/web/model"
+)
 
 // MainController handles basic server operations.
 type MainController struct {
@@ -30,7 +42,77 @@ func (c *MainController) Ping() {
 	c.RespondSuccess(nil)
 }
 
+// Info returns the build info and uptime of the server.
+func (c *MainController) Info() {
+	build := version.Get()
+	start := version.StartTime()
+	c.RespondSuccess(model.ServerInfo{
+		Version:   build.Version,
+		Commit:    build.GitCommit,
+		BuildDate: build.BuildDate,
+		GoVersion: build.GoVersion,
+		OS:        build.OS,
+		Arch:      build.Arch,
+		StartTime: start,
+		Uptime:    time.Since(start).Round(time.Second).String(),
+	})
+}
+
+// Capabilities reports the languages, working directory, limits and features
+// of the sandbox. Language availability is probed once and cached; refresh=true
+// probes again, e.g. after installing a kernel.
+func (c *MainController) Capabilities() {
+	cwd, err := os.Getwd()
+	if err != nil {
+		c.RespondError(http.StatusInternalServerError, model.ErrorCodeRuntimeError, err.Error())
+		return
+	}
+
+	probed := codeRunner.ProbeLanguages(c.ctx.Query("refresh") == "true")
+	sqlAvailable := slices.ContainsFunc(probed, func(l runtime.LanguageSupport) bool {
+		return l.Language == runtime.SQL && l.Available
+	})
+	languages := make([]model.LanguageSupport, 0, len(probed))
+	for _, l := range probed {
+		languages = append(languages, model.LanguageSupport{
+			Language:  l.Language.String(),
+			Available: l.Available,
+			Kernel:    l.Kernel,
+			Reason:    l.Reason,
+		})
+	}
+	c.RespondSuccess(model.Capabilities{
+		Version:    version.Get().Version,
+		Languages:  languages,
+		DefaultCwd: cwd,
+		Limits: model.CapabilityLimits{
+			MaxHeaderBytes:         flag.ServerMaxHeaderBytes,
+			HandlerTimeout:         flag.ServerHandlerTimeout.String(),
+			StreamMaxOutputRate:    flag.StreamMaxOutputRate,
+			CommandMaxAddressSpace: flag.CommandMaxAddressSpace,
+			CommandMaxCPUSeconds:   flag.CommandMaxCPUSeconds,
+			CommandMaxOpenFiles:    flag.CommandMaxOpenFiles,
+		},
+		Features: model.CapabilityFeatures{
+			Proxy:          true,
+			SQL:            sqlAvailable,
+			MetricsHistory: flag.MetricsHistorySize > 0,
+			H2C:            flag.ServerEnableH2C,
+		},
+	})
+}
+
 // PingHandler is the Gin adapter.
 func PingHandler(ctx *gin.Context) {
 	NewMainController(ctx).Ping()
 }
+
+// InfoHandler is the Gin adapter.
+func InfoHandler(ctx *gin.Context) {
+	NewMainController(ctx).Info()
+}
+
+// CapabilitiesHandler is the Gin adapter.
+func CapabilitiesHandler(ctx *gin.Context) {
+	NewMainController(ctx).Capabilities()
+}
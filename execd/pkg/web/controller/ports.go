@@ -0,0 +1,168 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/alibaba/opensandbox/execd/pkg/runtime"
+	"github.com/alibaba/opensandbox/execd/pkg/web/model"
+)
+
+// minPortWatchInterval bounds how often a port watch polls the socket tables.
+const minPortWatchInterval = 200 * time.Millisecond
+
+// listListeningPorts is replaced in tests.
+var listListeningPorts = runtime.ListListeningPorts
+
+// PortController reports the ports processes of the sandbox listen on, so
+// clients know which ones to reach through /proxy.
+type PortController struct {
+	*basicController
+}
+
+func NewPortController(ctx *gin.Context) *PortController {
+	return &PortController{basicController: newBasicController(ctx)}
+}
+
+// ListPorts returns the listening TCP and UDP sockets ordered by port. With
+// ?watch=true it streams a snapshot of them, then an event each time ports
+// open or close, polling every ?interval= (1s by default).
+func (c *PortController) ListPorts() {
+	if c.ctx.Query("watch") == "true" {
+		c.watchPorts()
+		return
+	}
+
+	ports, err := listListeningPorts()
+	if err != nil {
+		c.respondPortsError(err)
+		return
+	}
+	c.RespondSuccess(listeningPorts(ports))
+}
+
+func (c *PortController) watchPorts() {
+	interval, err := watchDuration(c.ctx.Query("interval"), time.Second)
+	if err != nil {
+		c.RespondError(http.StatusBadRequest, model.ErrorCodeInvalidRequest, fmt.Sprintf("invalid interval. %v", err))
+		return
+	}
+	interval = max(interval, minPortWatchInterval)
+
+	ports, err := listListeningPorts()
+	if err != nil {
+		c.respondPortsError(err)
+		return
+	}
+
+	c.setupSSEResponse()
+	c.writePortEvent(model.PortEvent{Type: model.PortEventSnapshot, Ports: listeningPorts(ports)})
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.ctx.Request.Context().Done():
+			return
+		case <-ticker.C:
+			current, err := listListeningPorts()
+			if err != nil {
+				c.writePortEvent(model.PortEvent{Type: model.PortEventError, Error: err.Error()})
+				continue
+			}
+			opened, closed := diffPorts(ports, current)
+			if len(closed) > 0 {
+				c.writePortEvent(model.PortEvent{Type: model.PortEventClosed, Ports: listeningPorts(closed)})
+			}
+			if len(opened) > 0 {
+				c.writePortEvent(model.PortEvent{Type: model.PortEventOpened, Ports: listeningPorts(opened)})
+			}
+			ports = current
+		}
+	}
+}
+
+// writePortEvent writes one event of a port watch and flushes it.
+func (c *PortController) writePortEvent(event model.PortEvent) {
+	event.Timestamp = time.Now().UnixMilli()
+	msg, _ := json.Marshal(event) //nolint:errchkjson
+	if _, err := c.ctx.Writer.Write(append(msg, '\n', '\n')); err != nil {
+		logger.Error("WatchPorts write data %s error: %v", string(msg), err)
+	}
+	if flusher, ok := c.ctx.Writer.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (c *PortController) respondPortsError(err error) {
+	if errors.Is(err, runtime.ErrPortsUnsupported) {
+		c.RespondError(http.StatusNotImplemented, model.ErrorCodeRuntimeError, err.Error())
+		return
+	}
+	c.RespondError(
+		http.StatusInternalServerError,
+		model.ErrorCodeRuntimeError,
+		fmt.Sprintf("error listing listening ports. %v", err),
+	)
+}
+
+// diffPorts returns the ports of current missing from previous, and the ports
+// of previous missing from current. A port is identified by its socket, not
+// its owner.
+func diffPorts(previous, current []runtime.ListeningPort) ([]runtime.ListeningPort, []runtime.ListeningPort) {
+	key := func(port runtime.ListeningPort) string {
+		return fmt.Sprintf("%s/%s/%s/%d", port.Protocol, port.Family, port.Address, port.Port)
+	}
+	before := make(map[string]bool, len(previous))
+	for _, port := range previous {
+		before[key(port)] = true
+	}
+	after := make(map[string]bool, len(current))
+	var opened, closed []runtime.ListeningPort
+	for _, port := range current {
+		after[key(port)] = true
+		if !before[key(port)] {
+			opened = append(opened, port)
+		}
+	}
+	for _, port := range previous {
+		if !after[key(port)] {
+			closed = append(closed, port)
+		}
+	}
+	return opened, closed
+}
+
+func listeningPorts(ports []runtime.ListeningPort) []model.ListeningPort {
+	resp := make([]model.ListeningPort, 0, len(ports))
+	for _, port := range ports {
+		resp = append(resp, model.ListeningPort{
+			Port:     port.Port,
+			Protocol: port.Protocol,
+			Family:   port.Family,
+			Address:  port.Address,
+			Pid:      port.PID,
+			Process:  port.Process,
+		})
+	}
+	return resp
+}
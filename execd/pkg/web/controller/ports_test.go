@@ -0,0 +1,90 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/alibaba/opensandbox/execd/pkg/runtime"
+	"github.com/alibaba/opensandbox/execd/pkg/web/model"
+)
+
+func stubListeningPorts(t *testing.T, list func() ([]runtime.ListeningPort, error)) {
+	t.Helper()
+	original := listListeningPorts
+	t.Cleanup(func() { listListeningPorts = original })
+	listListeningPorts = list
+}
+
+func TestListPorts(t *testing.T) {
+	stubListeningPorts(t, func() ([]runtime.ListeningPort, error) {
+		return []runtime.ListeningPort{{Port: 3000, Protocol: runtime.ProtocolTCP, Family: runtime.FamilyIPv4, Address: "0.0.0.0", PID: 42, Process: "node"}}, nil
+	})
+
+	ctx, rec := newTestContext(http.MethodGet, "/ports", nil)
+	NewPortController(ctx).ListPorts()
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var ports []model.ListeningPort
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &ports))
+	assert.Equal(t, []model.ListeningPort{{Port: 3000, Protocol: "tcp", Family: "ipv4", Address: "0.0.0.0", Pid: 42, Process: "node"}}, ports)
+}
+
+func TestListPorts_WatchReportsOpenedAndClosedPorts(t *testing.T) {
+	web := runtime.ListeningPort{Port: 3000, Protocol: runtime.ProtocolTCP, Family: runtime.FamilyIPv4, Address: "0.0.0.0"}
+	api := runtime.ListeningPort{Port: 8000, Protocol: runtime.ProtocolTCP, Family: runtime.FamilyIPv4, Address: "127.0.0.1"}
+	var polls atomic.Int32
+	stubListeningPorts(t, func() ([]runtime.ListeningPort, error) {
+		if polls.Add(1) == 1 {
+			return []runtime.ListeningPort{web}, nil
+		}
+		return []runtime.ListeningPort{api}, nil
+	})
+
+	ctx, rec := newTestContext(http.MethodGet, "/ports?watch=true&interval=10ms", nil)
+	requestCtx, cancel := context.WithCancel(context.Background())
+	ctx.Request = ctx.Request.WithContext(requestCtx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		NewPortController(ctx).ListPorts()
+	}()
+	assert.Eventually(t, func() bool { return polls.Load() >= 3 }, 5*time.Second, 10*time.Millisecond)
+	cancel()
+	<-done
+
+	var events []model.PortEvent
+	for _, frame := range strings.Split(strings.TrimSpace(rec.Body.String()), "\n\n") {
+		var event model.PortEvent
+		assert.NoError(t, json.Unmarshal([]byte(frame), &event))
+		events = append(events, event)
+	}
+	if assert.Len(t, events, 3, "later polls without changes must not emit events") {
+		assert.Equal(t, model.PortEventSnapshot, events[0].Type)
+		assert.Equal(t, 3000, events[0].Ports[0].Port)
+		assert.Equal(t, model.PortEventClosed, events[1].Type)
+		assert.Equal(t, 3000, events[1].Ports[0].Port)
+		assert.Equal(t, model.PortEventOpened, events[2].Type)
+		assert.Equal(t, 8000, events[2].Ports[0].Port)
+	}
+}
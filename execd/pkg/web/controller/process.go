@@ -0,0 +1,134 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/alibaba/opensandbox/execd/pkg/runtime"
+	"github.com/alibaba/opensandbox/execd/pkg/web/model"
+)
+
+const (
+	// defaultProcessPageSize is the page size of GET /processes without a limit.
+	defaultProcessPageSize = 100
+	// maxProcessPageSize caps the limit of GET /processes.
+	maxProcessPageSize = 1000
+)
+
+// ProcessController lists and signals the processes of the sandbox, including
+// the ones execd doesn't track such as servers started by notebook cells.
+type ProcessController struct {
+	*basicController
+}
+
+func NewProcessController(ctx *gin.Context) *ProcessController {
+	return &ProcessController{basicController: newBasicController(ctx)}
+}
+
+// ListProcesses returns a page of the processes of the sandbox ordered by pid,
+// filtered by the name query and paged by the offset and limit queries.
+func (c *ProcessController) ListProcesses() {
+	offset := c.QueryInt64(c.ctx.Query("offset"), 0)
+	limit := c.QueryInt64(c.ctx.Query("limit"), defaultProcessPageSize)
+	if offset < 0 || limit <= 0 || limit > maxProcessPageSize {
+		c.RespondError(
+			http.StatusBadRequest,
+			model.ErrorCodeInvalidRequest,
+			fmt.Sprintf("invalid offset %d or limit %d, limit must be between 1 and %d", offset, limit, maxProcessPageSize),
+		)
+		return
+	}
+
+	processes, total, err := runtime.ListProcesses(runtime.ProcessFilter{
+		Name:   c.ctx.Query("name"),
+		Offset: int(offset),
+		Limit:  int(limit),
+	}, processSampleInterval)
+	if err != nil {
+		c.RespondError(
+			http.StatusInternalServerError,
+			model.ErrorCodeRuntimeError,
+			fmt.Sprintf("error listing processes. %v", err),
+		)
+		return
+	}
+
+	resp := model.ProcessList{Total: total, Processes: make([]model.ProcessInfo, 0, len(processes))}
+	for _, process := range processes {
+		info := model.ProcessInfo{
+			Pid:        process.PID,
+			Ppid:       process.PPID,
+			Name:       process.Name,
+			User:       process.User,
+			Cmdline:    process.Cmdline,
+			CpuPercent: process.CPUPercent,
+			RssMiB:     float64(process.RSSBytes) / 1024 / 1024,
+		}
+		if !process.StartedAt.IsZero() {
+			info.StartedAt = &process.StartedAt
+		}
+		resp.Processes = append(resp.Processes, info)
+	}
+	c.RespondSuccess(resp)
+}
+
+// KillProcess sends the signal query, TERM by default, to a process. It needs
+// the admin token since it reaches processes execd didn't start.
+func (c *ProcessController) KillProcess() {
+	if !c.isAdmin() {
+		c.RespondError(
+			http.StatusForbidden,
+			model.ErrorCodeAdminForbidden,
+			"signalling processes requires the admin token in "+model.AdminTokenHeader,
+		)
+		return
+	}
+
+	pid, err := strconv.ParseInt(c.ctx.Param("pid"), 10, 32)
+	if err != nil {
+		c.RespondError(
+			http.StatusBadRequest,
+			model.ErrorCodeInvalidRequest,
+			fmt.Sprintf("invalid pid %q", c.ctx.Param("pid")),
+		)
+		return
+	}
+	signal := c.ctx.DefaultQuery("signal", "TERM")
+
+	err = runtime.SignalProcess(int32(pid), signal)
+	switch {
+	case err == nil:
+		logger.Info("process %d sent %s by %s", pid, signal, c.ctx.ClientIP())
+		c.RespondSuccess(nil)
+	case errors.Is(err, runtime.ErrProcessNotFound):
+		c.RespondError(http.StatusNotFound, model.ErrorCodeProcessNotFound, fmt.Sprintf("process %d not found", pid))
+	case errors.Is(err, runtime.ErrProcessProtected):
+		c.RespondError(http.StatusForbidden, model.ErrorCodeProcessProtected, err.Error())
+	case errors.Is(err, runtime.ErrUnsupportedSignal):
+		c.RespondError(http.StatusBadRequest, model.ErrorCodeInvalidSignal, fmt.Sprintf("unsupported signal %q", signal))
+	default:
+		c.RespondError(
+			http.StatusInternalServerError,
+			model.ErrorCodeRuntimeError,
+			fmt.Sprintf("error signalling process %d. %v", pid, err),
+		)
+	}
+}
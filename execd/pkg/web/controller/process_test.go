@@ -0,0 +1,64 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/alibaba/opensandbox/execd/pkg/flag"
+	"github.com/alibaba/opensandbox/execd/pkg/web/model"
+)
+
+func TestProcessController_ListProcesses(t *testing.T) {
+	ctx, rec := newTestContext(http.MethodGet, "/processes?limit=1", nil)
+	NewProcessController(ctx).ListProcesses()
+
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var list model.ProcessList
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	assert.Len(t, list.Processes, 1)
+	assert.GreaterOrEqual(t, list.Total, 1)
+
+	ctx, rec = newTestContext(http.MethodGet, "/processes?limit=5000", nil)
+	NewProcessController(ctx).ListProcesses()
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestProcessController_KillProcessRequiresAdmin(t *testing.T) {
+	original := flag.ServerAdminToken
+	t.Cleanup(func() { flag.ServerAdminToken = original })
+	flag.ServerAdminToken = "admin-secret"
+	self := strconv.Itoa(os.Getpid())
+
+	ctx, rec := newTestContext(http.MethodDelete, "/processes/"+self, nil)
+	ctx.Params = gin.Params{{Key: "pid", Value: self}}
+	NewProcessController(ctx).KillProcess()
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Contains(t, rec.Body.String(), string(model.ErrorCodeAdminForbidden))
+
+	ctx, rec = newTestContext(http.MethodDelete, "/processes/"+self, nil)
+	ctx.Params = gin.Params{{Key: "pid", Value: self}}
+	ctx.Request.Header.Set(model.AdminTokenHeader, "admin-secret")
+	NewProcessController(ctx).KillProcess()
+	assert.Equal(t, http.StatusForbidden, rec.Code, "execd must never signal itself")
+	assert.Contains(t, rec.Body.String(), string(model.ErrorCodeProcessProtected))
+}
@@ -0,0 +1,125 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/alibaba/opensandbox/execd/pkg/runtime"
+	"github.com/alibaba/opensandbox/execd/pkg/web/model"
+)
+
+// ListSQLQueries returns the SQL executions that are currently in flight.
+func (c *CodeInterpretingController) ListSQLQueries() {
+	c.RespondSuccess(codeRunner.ListSQLQueries())
+}
+
+// CancelSQLQuery aborts a running SQL execution by id.
+func (c *CodeInterpretingController) CancelSQLQuery() {
+	id := c.ctx.Param("id")
+	if id == "" {
+		c.RespondError(
+			http.StatusBadRequest,
+			model.ErrorCodeMissingQuery,
+			"missing path parameter 'id'",
+		)
+		return
+	}
+
+	err := codeRunner.CancelSQLQuery(id)
+	if err != nil {
+		if errors.Is(err, runtime.ErrSQLQueryNotFound) {
+			c.RespondError(
+				http.StatusNotFound,
+				model.ErrorCodeSQLQueryNotFound,
+				fmt.Sprintf("sql query %s not found", id),
+			)
+			return
+		}
+		c.RespondError(
+			http.StatusInternalServerError,
+			model.ErrorCodeRuntimeError,
+			fmt.Sprintf("error cancelling sql query %s. %v", id, err),
+		)
+		return
+	}
+
+	c.RespondSuccess(nil)
+}
+
+// ListSQLConnections returns the connections sql code may pick, without their DSNs.
+func (c *CodeInterpretingController) ListSQLConnections() {
+	c.RespondSuccess(codeRunner.ListSQLConnections())
+}
+
+// AddSQLConnection registers a connection sql code may pick by name. It is
+// opened and pooled once a request first picks it.
+func (c *CodeInterpretingController) AddSQLConnection() {
+	var request model.SQLConnectionRequest
+	if err := c.bindJSON(&request); err != nil {
+		c.RespondError(
+			http.StatusBadRequest,
+			model.ErrorCodeInvalidRequest,
+			fmt.Sprintf("error parsing request, MAYBE invalid body format. %v", err),
+		)
+		return
+	}
+	if err := request.Validate(); err != nil {
+		c.RespondValidationError(err)
+		return
+	}
+
+	connection := runtime.SQLConnection{Name: request.Name, Driver: request.Driver, DSN: request.DSN}
+	if err := codeRunner.AddSQLConnection(connection); err != nil {
+		switch {
+		case errors.Is(err, runtime.ErrSQLConnectionExists):
+			c.RespondError(http.StatusConflict, model.ErrorCodeSQLConnectionExists, err.Error())
+		case errors.Is(err, runtime.ErrSQLDriverUnsupported):
+			c.RespondError(http.StatusBadRequest, model.ErrorCodeInvalidRequest, err.Error())
+		default:
+			c.RespondError(http.StatusInternalServerError, model.ErrorCodeRuntimeError, err.Error())
+		}
+		return
+	}
+	c.RespondSuccess(connection)
+}
+
+// DeleteSQLConnection unregisters a connection added at startup or through
+// AddSQLConnection and closes its pool; otherwise it lives as long as execd.
+func (c *CodeInterpretingController) DeleteSQLConnection() {
+	name := c.ctx.Param("name")
+	if err := codeRunner.RemoveSQLConnection(name); err != nil {
+		switch {
+		case errors.Is(err, runtime.ErrSQLConnectionNotFound):
+			c.RespondError(http.StatusNotFound, model.ErrorCodeSQLConnectionNotFound, fmt.Sprintf("sql connection %s not found", name))
+		case errors.Is(err, runtime.ErrSQLConnectionBuiltin):
+			c.RespondError(http.StatusBadRequest, model.ErrorCodeInvalidRequest, err.Error())
+		default:
+			c.RespondError(http.StatusInternalServerError, model.ErrorCodeRuntimeError, fmt.Sprintf("error closing sql connection %s. %v", name, err))
+		}
+		return
+	}
+	c.RespondSuccess(nil)
+}
+
+// sqlConnectionExists reports whether sql code may pick the named connection.
+func sqlConnectionExists(name string) bool {
+	return slices.ContainsFunc(codeRunner.ListSQLConnections(), func(connection runtime.SQLConnection) bool {
+		return connection.Name == name
+	})
+}
@@ -16,19 +16,25 @@ package controller
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"os"
+	"strings"
 	"time"
 
 	"k8s.io/apimachinery/pkg/util/wait"
 
+	"github.com/alibaba/opensandbox/execd/pkg/flag"
 	"github.com/alibaba/opensandbox/execd/pkg/jupyter/execute"
-	"github.com/alibaba/opensandbox/execd/pkg/log"
 	"github.com/alibaba/opensandbox/execd/pkg/runtime"
 	"github.com/alibaba/opensandbox/execd/pkg/util/safego"
 	"github.com/alibaba/opensandbox/execd/pkg/web/model"
 )
 
+var errSSEWriteTimeout = errors.New("sse write timed out")
+
 var sseHeaders = map[string]string{
 	"Content-Type":      "text/event-stream",
 	"Cache-Control":     "no-cache",
@@ -36,8 +42,22 @@ var sseHeaders = map[string]string{
 	"X-Accel-Buffering": "no",
 }
 
+// ndjsonContentType selects newline-delimited JSON instead of SSE when accepted by the client.
+const ndjsonContentType = "application/x-ndjson"
+
+var ndjsonHeaders = map[string]string{
+	"Content-Type":      ndjsonContentType,
+	"Cache-Control":     "no-cache",
+	"X-Accel-Buffering": "no",
+}
+
 func (c *basicController) setupSSEResponse() {
-	for key, value := range sseHeaders {
+	c.setupStreamHeaders(sseHeaders)
+}
+
+func (c *basicController) setupStreamHeaders(headers map[string]string) {
+	c.clearWriteDeadline()
+	for key, value := range headers {
 		c.ctx.Writer.Header().Set(key, value)
 	}
 	if flusher, ok := c.ctx.Writer.(http.Flusher); ok {
@@ -45,57 +65,61 @@ func (c *basicController) setupSSEResponse() {
 	}
 }
 
+// setupStreamResponse starts the event stream, as NDJSON when the client's
+// Accept header asks for it and as SSE otherwise.
+func (c *CodeInterpretingController) setupStreamResponse() {
+	if strings.Contains(c.ctx.GetHeader("Accept"), ndjsonContentType) {
+		c.ndjson = true
+		c.setupStreamHeaders(ndjsonHeaders)
+		return
+	}
+	c.setupSSEResponse()
+}
+
 // setServerEventsHandler adapts runtime callbacks to SSE events.
 func (c *CodeInterpretingController) setServerEventsHandler(ctx context.Context) runtime.ExecuteResultHook {
+	ctx, c.cancelStream = context.WithCancel(ctx)
+	c.outputLimiter = newOutputLimiter(flag.StreamMaxOutputRate)
+
 	return runtime.ExecuteResultHook{
 		OnExecuteInit: func(session string) {
-			payload := model.ServerStreamEvent{
-				Type:      model.StreamEventTypeInit,
-				Text:      session,
-				Timestamp: time.Now().UnixMilli(),
-			}.ToJSON()
+			payload := c.eventPayload(model.ServerStreamEvent{
+				Type:       model.StreamEventTypeInit,
+				Text:       session,
+				Timestamp:  time.Now().UnixMilli(),
+				CwdCreated: c.cwdCreated,
+			})
 
 			c.writeSingleEvent("OnExecuteInit", payload, true)
 
 			safego.Go(func() { c.ping(ctx) })
 		},
 		OnExecuteResult: func(result map[string]any, count int) {
-			var mutated map[string]any
-			if len(result) > 0 {
-				mutated = make(map[string]any)
-				for k, v := range result {
-					switch k {
-					case "text/plain":
-						mutated["text"] = v
-					default:
-						mutated[k] = v
-					}
-				}
-			}
+			mutated := renameResultMimeTypes(result)
 
 			if count > 0 {
-				payload := model.ServerStreamEvent{
+				payload := c.eventPayload(model.ServerStreamEvent{
 					Type:           model.StreamEventTypeCount,
 					ExecutionCount: count,
 					Timestamp:      time.Now().UnixMilli(),
-				}.ToJSON()
+				})
 				c.writeSingleEvent("OnExecuteResult", payload, true)
 			}
 			if len(mutated) > 0 {
-				payload := model.ServerStreamEvent{
+				payload := c.eventPayload(model.ServerStreamEvent{
 					Type:      model.StreamEventTypeResult,
 					Results:   mutated,
 					Timestamp: time.Now().UnixMilli(),
-				}.ToJSON()
+				})
 				c.writeSingleEvent("OnExecuteResult", payload, true)
 			}
 		},
 		OnExecuteComplete: func(executionTime time.Duration) {
-			payload := model.ServerStreamEvent{
+			payload := c.eventPayload(model.ServerStreamEvent{
 				Type:          model.StreamEventTypeComplete,
 				ExecutionTime: executionTime.Milliseconds(),
 				Timestamp:     time.Now().UnixMilli(),
-			}.ToJSON()
+			})
 
 			c.writeSingleEvent("OnExecuteComplete", payload, true)
 		},
@@ -104,53 +128,94 @@ func (c *CodeInterpretingController) setServerEventsHandler(ctx context.Context)
 				return
 			}
 
-			payload := model.ServerStreamEvent{
+			payload := c.eventPayload(model.ServerStreamEvent{
 				Type:      model.StreamEventTypeError,
 				Error:     err,
 				Timestamp: time.Now().UnixMilli(),
-			}.ToJSON()
+			})
 
 			c.writeSingleEvent("OnExecuteError", payload, true)
 		},
 		OnExecuteStatus: func(status string) {
-			payload := model.ServerStreamEvent{
+			payload := c.eventPayload(model.ServerStreamEvent{
 				Type:      model.StreamEventTypeStatus,
 				Text:      status,
 				Timestamp: time.Now().UnixMilli(),
-			}.ToJSON()
+			})
 
 			c.writeSingleEvent("OnExecuteStatus", payload, true)
 		},
 		OnExecuteStdout: func(text string) {
-			if text == "" {
-				return
-			}
-
-			payload := model.ServerStreamEvent{
-				Type:      model.StreamEventTypeStdout,
-				Text:      text,
-				Timestamp: time.Now().UnixMilli(),
-			}.ToJSON()
-
-			c.writeSingleEvent("OnExecuteStdout", payload, true)
+			c.writeOutputEvent(model.ServerStreamEvent{Type: model.StreamEventTypeStdout, Text: text})
 		},
 		OnExecuteStderr: func(text string) {
-			if text == "" {
-				return
-			}
+			c.writeOutputEvent(model.ServerStreamEvent{Type: model.StreamEventTypeStderr, Text: text})
+		},
+		OnExecuteProgress: func(progress runtime.Progress) {
+			payload := c.eventPayload(model.ServerStreamEvent{
+				Type: model.StreamEventTypeProgress,
+				Text: progress.Line,
+				Progress: &model.CommandProgress{
+					Percent: progress.Percent,
+					Stage:   progress.Stage,
+				},
+				Timestamp: time.Now().UnixMilli(),
+			})
 
-			payload := model.ServerStreamEvent{
-				Type:      model.StreamEventTypeStderr,
-				Text:      text,
+			c.writeSingleEvent("OnExecuteProgress", payload, true)
+		},
+		OnCommandExit: func(status runtime.CommandExitStatus) {
+			payload := c.eventPayload(model.ServerStreamEvent{
+				Type:      model.StreamEventTypeExit,
+				Exit:      commandExit(status),
 				Timestamp: time.Now().UnixMilli(),
-			}.ToJSON()
+			})
 
-			c.writeSingleEvent("OnExecuteStderr", payload, true)
+			c.writeSingleEvent("OnCommandExit", payload, true)
 		},
 	}
 }
 
-// writeSingleEvent serializes one SSE frame.
+// writeOutputEvent writes a stdout or stderr event unless it is empty or the
+// output rate limit drops it.
+func (c *CodeInterpretingController) writeOutputEvent(event model.ServerStreamEvent) {
+	if event.Text == "" || !c.outputLimiter.allow(time.Now()) {
+		return
+	}
+
+	name := "OnExecuteStdout"
+	if event.Type == model.StreamEventTypeStderr {
+		name = "OnExecuteStderr"
+	}
+	event.Timestamp = time.Now().UnixMilli()
+	c.writeSingleEvent(name, c.eventPayload(event), true)
+}
+
+// renameResultMimeTypes returns result with text/plain renamed to text, the
+// name clients read plain results under; nil when result is empty.
+func renameResultMimeTypes(result map[string]any) map[string]any {
+	if len(result) == 0 {
+		return nil
+	}
+	mutated := make(map[string]any, len(result))
+	for k, v := range result {
+		switch k {
+		case "text/plain":
+			mutated["text"] = v
+		default:
+			mutated[k] = v
+		}
+	}
+	return mutated
+}
+
+// eventPayload serializes event, tagging it with the running cell if any.
+func (c *CodeInterpretingController) eventPayload(event model.ServerStreamEvent) []byte {
+	event.CellIndex = c.cellIndex.Load()
+	return event.ToJSON()
+}
+
+// writeSingleEvent serializes one SSE frame, or one line in NDJSON mode.
 func (c *CodeInterpretingController) writeSingleEvent(handler string, data []byte, verbose bool) {
 	if c == nil || c.ctx == nil || c.ctx.Writer == nil {
 		return
@@ -158,34 +223,123 @@ func (c *CodeInterpretingController) writeSingleEvent(handler string, data []byt
 
 	select {
 	case <-c.ctx.Request.Context().Done():
-		log.Error("StreamEvent.%s: client disconnected", handler)
+		logger.Error("StreamEvent.%s: client disconnected", handler)
 		return
 	default:
 	}
 
 	c.chunkWriter.Lock()
 	defer c.chunkWriter.Unlock()
-	defer func() {
-		if flusher, ok := c.ctx.Writer.(http.Flusher); ok {
-			flusher.Flush()
+
+	if c.streamAborted.Load() {
+		return
+	}
+
+	// report dropped output before anything that follows it.
+	if dropped := c.outputLimiter.takeDropped(); dropped > 0 {
+		c.writeFrame("RateLimited", c.eventPayload(model.ServerStreamEvent{
+			Type:      model.StreamEventTypeRateLimited,
+			Text:      fmt.Sprintf("%d output events dropped, the stream is limited to %d per second", dropped, c.outputLimiter.rate),
+			Dropped:   dropped,
+			Timestamp: time.Now().UnixMilli(),
+		}), true)
+		if c.streamAborted.Load() {
+			return
 		}
-	}()
+	}
+	c.writeFrame(handler, data, verbose)
+}
 
-	payload := append(data, '\n', '\n')
-	n, err := c.ctx.Writer.Write(payload)
-	if err == nil && n != len(payload) {
-		err = io.ErrShortWrite
+// writeFrame writes one event in the stream's framing. Callers must hold chunkWriter.
+func (c *CodeInterpretingController) writeFrame(handler string, data []byte, verbose bool) {
+	payload := append(data, '\n')
+	if !c.ndjson {
+		payload = append(payload, '\n')
+	}
+	err := c.writeWithDeadline(payload, flag.ApiSSEWriteTimeout)
+	if errors.Is(err, errSSEWriteTimeout) {
+		logger.Error("StreamEvent.%s write timed out after %v, aborting stream", handler, flag.ApiSSEWriteTimeout)
+		c.abortStream()
+		return
 	}
 
 	if err != nil {
-		log.Error("StreamEvent.%s write data %s error: %v", handler, string(data), err)
+		logger.Error("StreamEvent.%s write data %s error: %v", handler, string(data), err)
 	} else {
-		if verbose {
-			log.Info("StreamEvent.%s write data %s", handler, string(data))
+		if verbose && c.sampleEventLog() {
+			logger.Info("StreamEvent.%s write data %s", handler, string(data))
 		}
 	}
 }
 
+// sampleEventLog reports whether the current event is logged, which is one in
+// every --log-stream-event-sample events of the stream starting with the first.
+// Callers must hold chunkWriter.
+func (c *CodeInterpretingController) sampleEventLog() bool {
+	n := flag.LogStreamEventSample
+	if n <= 0 {
+		return false
+	}
+	logged := c.verboseEvents%n == 0
+	c.verboseEvents++
+	return logged
+}
+
+// writeWithDeadline writes and flushes payload, giving up once timeout elapses.
+// The connection deadline unblocks the pending write on real connections, so
+// the write is awaited; writers without deadline support are abandoned instead.
+func (c *CodeInterpretingController) writeWithDeadline(payload []byte, timeout time.Duration) error {
+	writer := c.ctx.Writer
+	write := func() error {
+		n, err := writer.Write(payload)
+		if err == nil && n != len(payload) {
+			err = io.ErrShortWrite
+		}
+		if flusher, ok := writer.(http.Flusher); ok {
+			flusher.Flush()
+		}
+		return err
+	}
+
+	if timeout <= 0 {
+		return write()
+	}
+
+	rc := http.NewResponseController(writer)
+	deadlineErr := rc.SetWriteDeadline(time.Now().Add(timeout))
+
+	done := make(chan error, 1)
+	safego.Go(func() {
+		// closing also releases the waiter if write panics.
+		defer close(done)
+		done <- write()
+	})
+
+	select {
+	case err := <-done:
+		_ = rc.SetWriteDeadline(time.Time{})
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			return errSSEWriteTimeout
+		}
+		return err
+	case <-time.After(timeout):
+		if deadlineErr == nil {
+			// the expired connection deadline fails the pending write; wait for
+			// it so the writer is never used concurrently.
+			<-done
+		}
+		return errSSEWriteTimeout
+	}
+}
+
+// abortStream treats the client as disconnected and stops further stream writes.
+func (c *CodeInterpretingController) abortStream() {
+	c.streamAborted.Store(true)
+	if c.cancelStream != nil {
+		c.cancelStream()
+	}
+}
+
 // ping periodically keeps the SSE connection alive.
 func (c *CodeInterpretingController) ping(ctx context.Context) {
 	wait.Until(func() {
@@ -0,0 +1,71 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"sync"
+	"time"
+)
+
+// outputLimiter is a token bucket capping the stdout and stderr events of one
+// stream at rate per second, with bursts of up to rate events. It counts the
+// events it turns away so the stream can report them. A nil limiter allows all.
+type outputLimiter struct {
+	mu      sync.Mutex
+	rate    int
+	tokens  float64
+	last    time.Time
+	dropped int
+}
+
+func newOutputLimiter(rate int) *outputLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	return &outputLimiter{rate: rate, tokens: float64(rate)}
+}
+
+// allow reports whether an output event may be written at now.
+func (l *outputLimiter) allow(now time.Time) bool {
+	if l == nil {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.last.IsZero() {
+		l.tokens = min(float64(l.rate), l.tokens+now.Sub(l.last).Seconds()*float64(l.rate))
+	}
+	l.last = now
+	if l.tokens < 1 {
+		l.dropped++
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// takeDropped returns the events dropped since the last call.
+func (l *outputLimiter) takeDropped() int {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	dropped := l.dropped
+	l.dropped = 0
+	return dropped
+}
@@ -0,0 +1,269 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/alibaba/opensandbox/execd/pkg/flag"
+	"github.com/alibaba/opensandbox/execd/pkg/jupyter/execute"
+	"github.com/alibaba/opensandbox/execd/pkg/web/model"
+)
+
+type blockingWriter struct {
+	header  http.Header
+	release chan struct{}
+}
+
+func (w *blockingWriter) Header() http.Header {
+	if w.header == nil {
+		w.header = make(http.Header)
+	}
+	return w.header
+}
+
+func (w *blockingWriter) Write(b []byte) (int, error) {
+	<-w.release
+	return len(b), nil
+}
+
+func (w *blockingWriter) WriteHeader(int) {}
+
+func (w *blockingWriter) Flush() {}
+
+func TestWriteSingleEventAbortsOnWriteTimeout(t *testing.T) {
+	original := flag.ApiSSEWriteTimeout
+	flag.ApiSSEWriteTimeout = 100 * time.Millisecond
+	defer func() { flag.ApiSSEWriteTimeout = original }()
+
+	gin.SetMode(gin.TestMode)
+	writer := &blockingWriter{release: make(chan struct{})}
+	defer close(writer.release)
+	ctx, _ := gin.CreateTestContext(writer)
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/code", nil)
+
+	ctrl := NewCodeInterpretingController(ctx)
+	ctrl.setServerEventsHandler(context.Background())
+	if ctrl.cancelStream == nil {
+		t.Fatalf("expected stream cancel func to be set")
+	}
+
+	start := time.Now()
+	ctrl.writeSingleEvent("Test", []byte(`{"type":"stdout"}`), false)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("write was not bounded by deadline, took %v", elapsed)
+	}
+	if !ctrl.streamAborted.Load() {
+		t.Fatalf("expected stream to be aborted after write timeout")
+	}
+
+	// subsequent events are dropped without blocking
+	start = time.Now()
+	ctrl.writeSingleEvent("Test", []byte(`{"type":"stdout"}`), false)
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Fatalf("expected aborted stream to skip writes, took %v", elapsed)
+	}
+}
+
+// trackingWriter records whether a write to the real connection is in flight.
+type trackingWriter struct {
+	http.ResponseWriter
+	writing atomic.Bool
+}
+
+func (w *trackingWriter) Write(b []byte) (int, error) {
+	w.writing.Store(true)
+	defer w.writing.Store(false)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *trackingWriter) Flush() {
+	w.ResponseWriter.(http.Flusher).Flush()
+}
+
+func (w *trackingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func TestWriteSingleEventHonoursConnectionDeadline(t *testing.T) {
+	original := flag.ApiSSEWriteTimeout
+	flag.ApiSSEWriteTimeout = 200 * time.Millisecond
+	defer func() { flag.ApiSSEWriteTimeout = original }()
+
+	type outcome struct {
+		elapsed time.Duration
+		aborted bool
+		writing bool
+	}
+	result := make(chan outcome, 1)
+
+	gin.SetMode(gin.TestMode)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writer := &trackingWriter{ResponseWriter: w}
+		ctx, _ := gin.CreateTestContext(writer)
+		ctx.Request = r
+
+		ctrl := NewCodeInterpretingController(ctx)
+		ctrl.setServerEventsHandler(context.Background())
+
+		// large enough to fill the socket buffers of a client that never reads.
+		start := time.Now()
+		ctrl.writeSingleEvent("Test", bytes.Repeat([]byte("x"), 64<<20), false)
+		result <- outcome{time.Since(start), ctrl.streamAborted.Load(), writer.writing.Load()}
+	}))
+	defer ts.Close()
+
+	conn, err := net.Dial("tcp", ts.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("GET / HTTP/1.1\r\nHost: execd\r\n\r\n")); err != nil {
+		t.Fatalf("send request: %v", err)
+	}
+
+	select {
+	case got := <-result:
+		if !got.aborted {
+			t.Fatalf("expected stream to be aborted after write timeout")
+		}
+		if got.writing {
+			t.Fatalf("write was abandoned while still in flight")
+		}
+		if got.elapsed > 5*time.Second {
+			t.Fatalf("write was not bounded by the connection deadline, took %v", got.elapsed)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("write was never unblocked")
+	}
+}
+
+func TestEventPayloadTagsCellIndex(t *testing.T) {
+	c := &CodeInterpretingController{}
+	event := model.ServerStreamEvent{Type: model.StreamEventTypeStdout, Text: "hi"}
+
+	if got := string(c.eventPayload(event)); strings.Contains(got, `"cell_index"`) {
+		t.Fatalf("expected no cell index outside cells runs, got %s", got)
+	}
+
+	index := 0
+	c.cellIndex.Store(&index)
+	if got := string(c.eventPayload(event)); !strings.Contains(got, `"cell_index":0`) {
+		t.Fatalf("expected cell index 0 to be tagged, got %s", got)
+	}
+}
+
+func TestSampleEventLog(t *testing.T) {
+	original := flag.LogStreamEventSample
+	defer func() { flag.LogStreamEventSample = original }()
+
+	cases := []struct {
+		sample   int
+		expected []bool
+	}{
+		{0, []bool{false, false, false}},
+		{1, []bool{true, true, true}},
+		{3, []bool{true, false, false, true, false}},
+	}
+	for _, tc := range cases {
+		flag.LogStreamEventSample = tc.sample
+		c := &CodeInterpretingController{}
+		for i, want := range tc.expected {
+			if got := c.sampleEventLog(); got != want {
+				t.Fatalf("sample %d, event %d: expected %v, got %v", tc.sample, i, want, got)
+			}
+		}
+	}
+}
+
+func TestOutputRateLimitDropsExcessOutputWithMarker(t *testing.T) {
+	original := flag.StreamMaxOutputRate
+	flag.StreamMaxOutputRate = 5
+	defer func() { flag.StreamMaxOutputRate = original }()
+
+	ctx, rec := newTestContext(http.MethodPost, "/command", nil)
+	c := NewCodeInterpretingController(ctx)
+	hooks := c.setServerEventsHandler(context.Background())
+	defer c.cancelStream()
+
+	for i := 0; i < 50; i++ {
+		hooks.OnExecuteStdout("line")
+	}
+	hooks.OnExecuteError(&execute.ErrorOutput{EName: "Boom"})
+	hooks.OnExecuteComplete(time.Millisecond)
+
+	var events []model.ServerStreamEvent
+	for _, frame := range strings.Split(strings.TrimSpace(rec.Body.String()), "\n\n") {
+		var event model.ServerStreamEvent
+		if err := json.Unmarshal([]byte(frame), &event); err != nil {
+			t.Fatalf("invalid frame %q: %v", frame, err)
+		}
+		events = append(events, event)
+	}
+
+	var stdout int
+	for _, event := range events {
+		if event.Type == model.StreamEventTypeStdout {
+			stdout++
+		}
+	}
+	// the burst is the rate, plus whatever refilled while the loop ran.
+	if stdout < 5 || stdout > 6 {
+		t.Fatalf("expected about 5 stdout events, got %d", stdout)
+	}
+	n := len(events)
+	if n < 3 || events[n-3].Type != model.StreamEventTypeRateLimited {
+		t.Fatalf("expected a rate_limited marker before the terminal events, got %+v", events)
+	}
+	if marker := events[n-3]; marker.Dropped != 50-stdout || !strings.Contains(marker.Text, "dropped") {
+		t.Fatalf("unexpected marker %+v with %d stdout events", marker, stdout)
+	}
+	if events[n-2].Type != model.StreamEventTypeError || events[n-1].Type != model.StreamEventTypeComplete {
+		t.Fatalf("error and completion must never be dropped, got %+v", events[n-2:])
+	}
+}
+
+func TestOutputLimiterRefills(t *testing.T) {
+	l := newOutputLimiter(2)
+	now := time.Now()
+	if !l.allow(now) || !l.allow(now) || l.allow(now) {
+		t.Fatalf("expected a burst of exactly 2 events")
+	}
+	if !l.allow(now.Add(600 * time.Millisecond)) {
+		t.Fatalf("expected a token after the refill")
+	}
+	if got := l.takeDropped(); got != 1 {
+		t.Fatalf("expected 1 dropped event, got %d", got)
+	}
+	if got := l.takeDropped(); got != 0 {
+		t.Fatalf("expected the count to reset, got %d", got)
+	}
+
+	var unlimited *outputLimiter
+	if newOutputLimiter(0) != nil || !unlimited.allow(now) || unlimited.takeDropped() != 0 {
+		t.Fatalf("expected a nil limiter to allow everything")
+	}
+}
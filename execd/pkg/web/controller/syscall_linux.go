@@ -31,3 +31,12 @@ func getFileCreateTime(fileInfo os.FileInfo) time.Time {
 
 	return time.Unix(stat.Ctim.Sec, stat.Ctim.Nsec)
 }
+
+func getFileAccessTime(fileInfo os.FileInfo) (time.Time, bool) {
+	stat, ok := fileInfo.Sys().(*syscall.Stat_t)
+	if !ok || stat == nil {
+		return time.Time{}, false
+	}
+
+	return time.Unix(stat.Atim.Sec, stat.Atim.Nsec), true
+}
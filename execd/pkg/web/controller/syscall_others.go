@@ -25,3 +25,7 @@ import (
 func getFileCreateTime(_ os.FileInfo) time.Time {
 	return time.Now()
 }
+
+func getFileAccessTime(_ os.FileInfo) (time.Time, bool) {
+	return time.Time{}, false
+}
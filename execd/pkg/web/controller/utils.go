@@ -26,8 +26,8 @@ import (
 	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
-	"github.com/alibaba/opensandbox/execd/pkg/log"
 	"github.com/alibaba/opensandbox/execd/pkg/web/model"
 )
 
@@ -80,11 +80,11 @@ func SetFileOwnership(absPath string, owner string, group string) error {
 	if owner != "" {
 		userInfo, err := user.Lookup(owner)
 		if err != nil {
-			log.Warning("Failed to lookup user %s: %v", owner, err)
+			logger.Warning("Failed to lookup user %s: %v", owner, err)
 		} else {
 			uid, err = strconv.Atoi(userInfo.Uid)
 			if err != nil {
-				log.Warning("Failed to convert uid for user %s: %v", owner, err)
+				logger.Warning("Failed to convert uid for user %s: %v", owner, err)
 				uid = -1
 			}
 		}
@@ -94,11 +94,11 @@ func SetFileOwnership(absPath string, owner string, group string) error {
 	if group != "" {
 		groupInfo, err := user.LookupGroup(group)
 		if err != nil {
-			log.Warning("Failed to lookup group %s: %v", group, err)
+			logger.Warning("Failed to lookup group %s: %v", group, err)
 		} else {
 			gid, err = strconv.Atoi(groupInfo.Gid)
 			if err != nil {
-				log.Warning("Failed to convert gid for group %s: %v", group, err)
+				logger.Warning("Failed to convert gid for group %s: %v", group, err)
 				gid = -1
 			}
 		}
@@ -148,19 +148,6 @@ func RenameFile(item model.RenameFileItem) error {
 	return nil
 }
 
-func MakeDir(dir string, perm model.Permission) error {
-	abs, err := filepath.Abs(dir)
-	if err != nil {
-		return err
-	}
-	err = os.MkdirAll(abs, os.ModePerm)
-	if err != nil {
-		return err
-	}
-
-	return ChmodFile(abs, perm)
-}
-
 func GetFileInfo(filePath string) (model.FileInfo, error) {
 	absPath, err := filepath.Abs(filePath)
 	if err != nil {
@@ -189,11 +176,17 @@ func GetFileInfo(filePath string) (model.FileInfo, error) {
 
 	mode := strconv.FormatInt(int64(fileInfo.Mode().Perm()), 8)
 
+	var accessedAt *time.Time
+	if atime, ok := getFileAccessTime(fileInfo); ok {
+		accessedAt = &atime
+	}
+
 	return model.FileInfo{
 		Path:       absPath,
 		Size:       fileInfo.Size(),
 		ModifiedAt: fileInfo.ModTime(),
 		CreatedAt:  getFileCreateTime(fileInfo),
+		AccessedAt: accessedAt,
 		Permission: model.Permission{
 			Owner: owner,
 			Group: group,
@@ -112,19 +112,6 @@ func RenameFile(item model.RenameFileItem) error {
 	return nil
 }
 
-func MakeDir(dir string, perm model.Permission) error {
-	abs, err := filepath.Abs(dir)
-	if err != nil {
-		return err
-	}
-	err = os.MkdirAll(abs, os.ModePerm)
-	if err != nil {
-		return err
-	}
-
-	return ChmodFile(abs, perm)
-}
-
 func GetFileInfo(filePath string) (model.FileInfo, error) {
 	absPath, err := filepath.Abs(filePath)
 	if err != nil {
@@ -140,8 +127,11 @@ func GetFileInfo(filePath string) (model.FileInfo, error) {
 	}
 
 	createdAt := getFileCreateTime(fileInfo)
+	var accessedAt *time.Time
 	if data, ok := fileInfo.Sys().(*syscall.Win32FileAttributeData); ok && data != nil {
 		createdAt = time.Unix(0, data.CreationTime.Nanoseconds())
+		atime := time.Unix(0, data.LastAccessTime.Nanoseconds())
+		accessedAt = &atime
 	}
 
 	mode := strconv.FormatInt(int64(fileInfo.Mode().Perm()), 8)
@@ -151,6 +141,7 @@ func GetFileInfo(filePath string) (model.FileInfo, error) {
 		Size:       fileInfo.Size(),
 		ModifiedAt: fileInfo.ModTime(),
 		CreatedAt:  createdAt,
+		AccessedAt: accessedAt,
 		Permission: model.Permission{
 			Owner: "",
 			Group: "",
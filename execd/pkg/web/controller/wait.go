@@ -0,0 +1,167 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/alibaba/opensandbox/execd/pkg/web/model"
+)
+
+const (
+	// defaultWaitTimeout and maxWaitTimeout bound the timeout query of /wait.
+	defaultWaitTimeout = 30 * time.Second
+	maxWaitTimeout     = 10 * time.Minute
+	// minWaitPollInterval is the first pause between two readiness checks,
+	// doubled after each one up to maxWaitPollInterval.
+	minWaitPollInterval = 50 * time.Millisecond
+	maxWaitPollInterval = time.Second
+	// waitDialTimeout bounds one connection attempt to an awaited port.
+	waitDialTimeout = time.Second
+)
+
+// WaitController blocks until something in the sandbox gets ready, sparing
+// clients shell loops polling for it.
+type WaitController struct {
+	*basicController
+}
+
+func NewWaitController(ctx *gin.Context) *WaitController {
+	return &WaitController{basicController: newBasicController(ctx)}
+}
+
+// Wait blocks until ?port= accepts TCP connections on localhost or ?path=
+// exists, then returns how long it took. It answers 408 once ?timeout= (30s
+// by default, at most 10m) elapsed, and 409 as soon as the command of
+// ?session=, typically the background command starting the awaited server,
+// exited non-zero.
+func (c *WaitController) Wait() {
+	port, path := c.ctx.Query("port"), c.ctx.Query("path")
+	if (port == "") == (path == "") {
+		c.RespondError(http.StatusBadRequest, model.ErrorCodeMissingQuery, "exactly one of query parameters 'port' and 'path' is required")
+		return
+	}
+	timeout, err := watchDuration(c.ctx.Query("timeout"), defaultWaitTimeout)
+	if err == nil && timeout > maxWaitTimeout {
+		err = fmt.Errorf("must not exceed %s", maxWaitTimeout)
+	}
+	if err != nil {
+		c.RespondError(http.StatusBadRequest, model.ErrorCodeInvalidRequest, fmt.Sprintf("invalid timeout. %v", err))
+		return
+	}
+
+	var result model.WaitResult
+	var ready func() bool
+	target := path
+	if port != "" {
+		result.Port, err = strconv.Atoi(port)
+		if err != nil || result.Port < 1 || result.Port > 65535 {
+			c.RespondError(http.StatusBadRequest, model.ErrorCodeInvalidRequest, fmt.Sprintf("invalid port %q", port))
+			return
+		}
+		target = "port " + port
+		ready = func() bool { return portAccepts(result.Port) }
+	} else {
+		result.Path, err = filepath.Abs(path)
+		if err != nil {
+			c.RespondError(
+				http.StatusInternalServerError,
+				model.ErrorCodeRuntimeError,
+				fmt.Sprintf("error converting path %s to absolute. %v", path, err),
+			)
+			return
+		}
+		ready = func() bool {
+			_, err := os.Stat(result.Path)
+			return err == nil
+		}
+	}
+
+	session := c.ctx.Query("session")
+	if session != "" {
+		if _, err := codeRunner.GetCommandStatus(session); err != nil {
+			c.RespondError(http.StatusNotFound, model.ErrorCodeInvalidRequest, err.Error())
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(c.ctx.Request.Context(), timeout)
+	defer cancel()
+	start := time.Now()
+	interval := minWaitPollInterval
+	for {
+		result.Attempts++
+		if ready() {
+			result.Elapsed = time.Since(start).Milliseconds()
+			c.RespondSuccess(result)
+			return
+		}
+		if exitCode, failed := commandFailed(session); failed {
+			c.RespondError(
+				http.StatusConflict,
+				model.ErrorCodeCommandExited,
+				fmt.Sprintf("command %s exited with code %d before %s was ready", session, exitCode, target),
+			)
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			if c.ctx.Request.Context().Err() != nil {
+				return
+			}
+			c.RespondError(
+				http.StatusRequestTimeout,
+				model.ErrorCodeWaitTimeout,
+				fmt.Sprintf("%s was not ready after %s", target, timeout),
+			)
+			return
+		case <-time.After(interval):
+		}
+		interval = min(interval*2, maxWaitPollInterval)
+	}
+}
+
+// portAccepts tells whether a TCP connection to port on localhost succeeds.
+func portAccepts(port int) bool {
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort("localhost", strconv.Itoa(port)), waitDialTimeout)
+	if err != nil {
+		return false
+	}
+	_ = conn.Close()
+	return true
+}
+
+// commandFailed returns the exit code of the command of session once it
+// finished with a non-zero one. An empty session never fails.
+func commandFailed(session string) (int, bool) {
+	if session == "" {
+		return 0, false
+	}
+	status, err := codeRunner.GetCommandStatus(session)
+	if err != nil || status.Running || status.ExitCode == nil || *status.ExitCode == 0 {
+		return 0, false
+	}
+	return *status.ExitCode, true
+}
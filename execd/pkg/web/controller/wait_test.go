@@ -0,0 +1,117 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alibaba/opensandbox/execd/pkg/web/model"
+)
+
+func waitFor(t *testing.T, query url.Values) *httptest.ResponseRecorder {
+	t.Helper()
+	ctx, rec := newTestContext(http.MethodGet, "/wait?"+query.Encode(), nil)
+	NewWaitController(ctx).Wait()
+	return rec
+}
+
+func TestWait_PortAcceptsConnections(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	rec := waitFor(t, url.Values{"port": {strconv.Itoa(port)}, "timeout": {"5s"}})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var result model.WaitResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if result.Port != port || result.Attempts != 1 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+}
+
+func TestWait_FileAppearsLater(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ready.flag")
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		_ = os.WriteFile(path, nil, 0o644)
+	}()
+
+	rec := waitFor(t, url.Values{"path": {path}, "timeout": {"5s"}})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var result model.WaitResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if result.Path != path || result.Elapsed < 200 || result.Attempts < 2 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+}
+
+func TestWait_TimesOut(t *testing.T) {
+	rec := waitFor(t, url.Values{"path": {filepath.Join(t.TempDir(), "never")}, "timeout": {"200ms"}})
+	if rec.Code != http.StatusRequestTimeout {
+		t.Fatalf("expected status 408, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestWait_AbortsWhenSessionFails(t *testing.T) {
+	session := runCommandSession(t, "sleep 0.2; exit 3", true)
+
+	start := time.Now()
+	rec := waitFor(t, url.Values{"path": {filepath.Join(t.TempDir(), "never")}, "timeout": {"10s"}, "session": {session}})
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected status 409, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("expected the wait to end with the command, took %s", elapsed)
+	}
+
+	rec = waitFor(t, url.Values{"path": {"/tmp"}, "session": {"missing"}})
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected status 404 for an unknown session, got %d", rec.Code)
+	}
+}
+
+func TestWait_InvalidQuery(t *testing.T) {
+	for name, query := range map[string]url.Values{
+		"no target":    {},
+		"both targets": {"port": {"3000"}, "path": {"/tmp"}},
+		"bad port":     {"port": {"70000"}},
+		"bad timeout":  {"port": {"3000"}, "timeout": {"soon"}},
+		"long timeout": {"port": {"3000"}, "timeout": {"1h"}},
+	} {
+		if rec := waitFor(t, query); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", name, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/alibaba/opensandbox/execd/pkg/web/model"
+)
+
+// unboundedRoutes lists the routes, relative to the API version, exempt from
+// the handler timeout: event streams and file transfers run for as long as
+// the client needs.
+var unboundedRoutes = map[string]bool{
+	"POST /code":                           true,
+	"POST /code/execute-batch":             true,
+	"GET /code/contexts/:contextId/output": true,
+	"POST /command":                        true,
+	"GET /command/:id/logs/download":       true,
+	"GET /command/:id/logs/stream":         true,
+	"GET /metrics/watch":                   true,
+	"GET /ports":                           true,
+	"GET /wait":                            true,
+	"GET /files/download":                  true,
+	"POST /files/upload":                   true,
+	"POST /files/fetch":                    true,
+	"POST /files/push":                     true,
+}
+
+// handlerTimeoutMiddleware cancels the request context of bounded routes once
+// timeout elapses, and answers 503 when the handler gave up without responding.
+func handlerTimeoutMiddleware(basePath string, timeout time.Duration) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if timeout <= 0 || ctx.FullPath() == "" || unboundedRoutes[routeKey(ctx, basePath)] {
+			ctx.Next()
+			return
+		}
+
+		requestCtx, cancel := context.WithTimeout(ctx.Request.Context(), timeout)
+		defer cancel()
+		ctx.Request = ctx.Request.WithContext(requestCtx)
+		ctx.Next()
+
+		if errors.Is(requestCtx.Err(), context.DeadlineExceeded) && !ctx.Writer.Written() {
+			ctx.AbortWithStatusJSON(http.StatusServiceUnavailable, model.ErrorResponse{
+				Code:    model.ErrorCodeRequestTimeout,
+				Message: "request handling exceeded " + timeout.String(),
+			})
+		}
+	}
+}
+
+// routeKey returns the method and route of the request without the base path
+// and API version, e.g. "POST /code".
+func routeKey(ctx *gin.Context, basePath string) string {
+	route := strings.TrimPrefix(ctx.FullPath(), basePath)
+	route = strings.TrimPrefix(route, "/"+model.APIVersionV1)
+	return ctx.Request.Method + " " + route
+}
@@ -0,0 +1,58 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import "time"
+
+// LogLevelRequest changes the log level of Component, or the default level
+// when it is empty, optionally only for Duration (a Go duration such as "10m")
+// before reverting.
+type LogLevelRequest struct {
+	Level     string `json:"level" validate:"required,oneof=debug info warn error"`
+	Component string `json:"component,omitempty"`
+	Duration  string `json:"duration,omitempty"`
+}
+
+func (r *LogLevelRequest) Validate() error {
+	if err := validateStruct(r); err != nil {
+		return err
+	}
+	if r.Duration == "" {
+		return nil
+	}
+	if d, err := time.ParseDuration(r.Duration); err != nil || d <= 0 {
+		return &ValidationError{Fields: []FieldError{{
+			Field:   "duration",
+			Message: "must be a positive duration such as 10m",
+		}}}
+	}
+	return nil
+}
+
+// LogLevel is the current log level. RevertAt is set while a temporary level is in effect.
+type LogLevel struct {
+	Level    string     `json:"level"`
+	RevertAt *time.Time `json:"revert_at,omitempty"`
+	// Components holds the level of every named logger, e.g. "proxy".
+	Components map[string]ComponentLogLevel `json:"components,omitempty"`
+}
+
+// ComponentLogLevel is the level of one component. Inherited is set while it
+// follows the default level.
+type ComponentLogLevel struct {
+	Level     string     `json:"level"`
+	Inherited bool       `json:"inherited"`
+	RevertAt  *time.Time `json:"revert_at,omitempty"`
+}
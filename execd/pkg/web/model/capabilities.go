@@ -0,0 +1,56 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+// Capabilities describes what the sandbox offers, so clients can adapt
+// before sending work: the languages that can run, the working directory
+// relative paths resolve against, the configured limits and features.
+type Capabilities struct {
+	Version    string             `json:"version"`
+	Languages  []LanguageSupport  `json:"languages"`
+	DefaultCwd string             `json:"default_cwd"`
+	Limits     CapabilityLimits   `json:"limits"`
+	Features   CapabilityFeatures `json:"features"`
+}
+
+// LanguageSupport tells whether code of a language can run, and on which kernel.
+type LanguageSupport struct {
+	Language  string `json:"language"`
+	Available bool   `json:"available"`
+	// Kernel names the kernel spec running the language, for kernel languages.
+	Kernel string `json:"kernel,omitempty"`
+	// Reason tells why an unavailable language can't run.
+	Reason string `json:"reason,omitempty"`
+}
+
+// CapabilityLimits are the configured limits of requests and commands.
+// Zero or negative values mean unlimited, as for the matching flags.
+type CapabilityLimits struct {
+	MaxHeaderBytes         int    `json:"max_header_bytes"`
+	HandlerTimeout         string `json:"handler_timeout"`
+	StreamMaxOutputRate    int    `json:"stream_max_output_rate"`
+	CommandMaxAddressSpace int64  `json:"command_max_address_space"`
+	CommandMaxCPUSeconds   int64  `json:"command_max_cpu_seconds"`
+	CommandMaxOpenFiles    int64  `json:"command_max_open_files"`
+}
+
+// CapabilityFeatures tells which optional features are enabled.
+type CapabilityFeatures struct {
+	Proxy          bool `json:"proxy"`
+	SQL            bool `json:"sql"`
+	GPUMetrics     bool `json:"gpu_metrics"`
+	MetricsHistory bool `json:"metrics_history"`
+	H2C            bool `json:"h2c"`
+}
@@ -16,44 +16,322 @@ package model
 
 import (
 	"encoding/json"
-
-	"github.com/go-playground/validator/v10"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"unicode/utf8"
 
 	"github.com/alibaba/opensandbox/execd/pkg/jupyter/execute"
 )
 
-// RunCodeRequest represents a code execution request.
+// RunCodeRequest represents a code execution request. It runs either Code or,
+// one after another in the same context, the Cells of a notebook.
 type RunCodeRequest struct {
 	Context CodeContext `json:"context,omitempty"`
-	Code    string      `json:"code" validate:"required"`
+	Code    string      `json:"code"`
+	Cells   []string    `json:"cells,omitempty" validate:"omitempty,dive,required"`
+	// StopOnError skips the remaining cells once one raises an error.
+	StopOnError bool `json:"stop_on_error,omitempty"`
+	// TimeoutSeconds bounds the run; the kernel is interrupted once it elapses,
+	// a stream ends with a Timeout error event and a non-streaming run answers
+	// 408 with the output so far.
+	TimeoutSeconds int64 `json:"timeout_seconds,omitempty" validate:"min=0"`
+	// TrackArtifacts reports the files the run created or modified in the
+	// working directory of its context.
+	TrackArtifacts bool `json:"track_artifacts,omitempty"`
+	// SaveDisplayDataDir, when set, saves large image/* and text/html results
+	// to files in that directory, relative to the context cwd, and sends
+	// {"file", "mime"} in their place.
+	SaveDisplayDataDir string `json:"save_display_data_dir,omitempty"`
+	// Connection names the registered SQL connection sql code runs against,
+	// the default local database when unset.
+	Connection string `json:"connection,omitempty"`
 }
 
 func (r *RunCodeRequest) Validate() error {
-	validate := validator.New()
-	return validate.Struct(r)
+	if err := validateStruct(r); err != nil {
+		return err
+	}
+	switch {
+	case r.Code == "" && len(r.Cells) == 0:
+		return &ValidationError{Fields: []FieldError{{Field: "code", Message: "is required"}}}
+	case r.Code != "" && len(r.Cells) > 0:
+		return &ValidationError{Fields: []FieldError{{Field: "cells", Message: "must not be set together with code"}}}
+	case r.StopOnError && len(r.Cells) == 0:
+		return &ValidationError{Fields: []FieldError{{Field: "stop_on_error", Message: "is only supported with cells"}}}
+	case r.TrackArtifacts && len(r.Cells) > 0:
+		return &ValidationError{Fields: []FieldError{{Field: "track_artifacts", Message: "is not supported with cells"}}}
+	case r.Connection != "" && len(r.Cells) > 0:
+		return &ValidationError{Fields: []FieldError{{Field: "connection", Message: "is not supported with cells"}}}
+	case r.Connection != "" && r.Context.Language != "sql":
+		return &ValidationError{Fields: []FieldError{{Field: "connection", Message: "is only supported for sql"}}}
+	}
+	return nil
+}
+
+// RunCodeBatchRequest represents an ordered list of snippets executed in one context.
+//
+// Deprecated: use the Cells of RunCodeRequest.
+type RunCodeBatchRequest struct {
+	Context     CodeContext `json:"context,omitempty"`
+	Codes       []string    `json:"codes" validate:"required,min=1,dive,required"`
+	StopOnError bool        `json:"stop_on_error,omitempty"`
+	// TimeoutSeconds bounds the whole batch; the kernel is interrupted once it elapses.
+	TimeoutSeconds int64 `json:"timeout_seconds,omitempty" validate:"min=0"`
+}
+
+func (r *RunCodeBatchRequest) Validate() error {
+	return validateStruct(r)
+}
+
+// CodeCompletionRequest asks the kernel of a context for completions at a cursor.
+type CodeCompletionRequest struct {
+	Context CodeContext `json:"context,omitempty"`
+	Code    string      `json:"code"`
+	// CursorPos is the cursor offset in code, counted in unicode code points.
+	CursorPos int `json:"cursor_pos" validate:"min=0"`
+}
+
+func (r *CodeCompletionRequest) Validate() error {
+	if err := validateStruct(r); err != nil {
+		return err
+	}
+	return validateCursor(r.Code, r.CursorPos)
+}
+
+// CodeInspectionRequest asks the kernel of a context to document the object at a cursor.
+type CodeInspectionRequest struct {
+	CodeCompletionRequest `json:",inline"`
+	// DetailLevel is 0 for a summary or 1 for more detail, such as source code.
+	DetailLevel int `json:"detail_level,omitempty" validate:"oneof=0 1"`
+}
+
+func (r *CodeInspectionRequest) Validate() error {
+	if err := validateStruct(r); err != nil {
+		return err
+	}
+	return validateCursor(r.Code, r.CursorPos)
+}
+
+// validateCursor rejects cursor positions past the end of code.
+func validateCursor(code string, cursorPos int) error {
+	if cursorPos > utf8.RuneCountInString(code) {
+		return &ValidationError{Fields: []FieldError{{
+			Field:   "cursor_pos",
+			Message: "must not exceed the length of code",
+		}}}
+	}
+	return nil
+}
+
+// CodeCompletion lists the completions offered by a kernel.
+type CodeCompletion struct {
+	Matches []string `json:"matches"`
+	// CursorStart and CursorEnd delimit the text of code a match replaces.
+	CursorStart int            `json:"cursor_start"`
+	CursorEnd   int            `json:"cursor_end"`
+	Metadata    map[string]any `json:"metadata,omitempty"`
+}
+
+// CodeInspection holds the documentation a kernel found for an object.
+type CodeInspection struct {
+	Found bool `json:"found"`
+	// Data holds the documentation keyed by mimetype, e.g. text/plain.
+	Data     map[string]any `json:"data,omitempty"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+// InterruptResult is the response of an interrupt. Interrupted is false when
+// nothing was running in the session.
+type InterruptResult struct {
+	Interrupted bool `json:"interrupted"`
 }
 
 // CodeContext tracks session metadata.
 type CodeContext struct {
 	ID                 string `json:"id,omitempty"`
 	CodeContextRequest `json:",inline"`
+	// Setup reports the setup cells of a created context, if any.
+	Setup *ContextSetup `json:"setup,omitempty"`
+	// Reused is set when the context was created for an earlier request with
+	// the same idempotency key.
+	Reused bool `json:"reused,omitempty"`
+	// CwdCreated is set when Cwd was created for the context.
+	CwdCreated bool `json:"cwd_created,omitempty"`
 }
 
 type CodeContextRequest struct {
-	Language string `json:"language,omitempty"`
+	Language string `json:"language,omitempty" validate:"omitempty,language"`
 	Cwd      string `json:"cwd,omitempty"`
+	// CreateCwd creates a missing Cwd, which fails the request otherwise.
+	CreateCwd   bool `json:"create_cwd,omitempty"`
+	EnvSnapshot bool `json:"env_snapshot,omitempty"`
+	// Env is exported into the kernel before the Setup cells run.
+	Env map[string]string `json:"env,omitempty"`
+	// Setup lists code cells run in order right after the context is created.
+	Setup []string `json:"setup,omitempty" validate:"omitempty,dive,required"`
+	// KeepOnSetupFailure keeps a context whose setup raised, which is deleted otherwise.
+	KeepOnSetupFailure bool `json:"keep_on_setup_failure,omitempty"`
+	// ClientRef is the idempotency key of clients unable to send the
+	// Idempotency-Key header, which takes precedence.
+	ClientRef string `json:"client_ref,omitempty"`
+}
+
+// envNamePattern matches the environment variable names accepted by Env.
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func (r *CodeContextRequest) Validate() error {
+	if err := validateStruct(r); err != nil {
+		return err
+	}
+
+	var fields []FieldError
+	for _, name := range slices.Sorted(maps.Keys(r.Env)) {
+		if !envNamePattern.MatchString(name) {
+			fields = append(fields, FieldError{
+				Field:   fmt.Sprintf("env[%s]", name),
+				Message: "must be a valid environment variable name",
+			})
+		}
+	}
+	if r.CreateCwd && r.Cwd == "" {
+		fields = append(fields, FieldError{Field: "cwd", Message: "is required with create_cwd"})
+	}
+	if len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+	return nil
+}
+
+// ContextSetup reports how the setup cells of a new context ran.
+type ContextSetup struct {
+	// Duration is the time spent running the cells in milliseconds.
+	Duration int64 `json:"duration"`
+	// FailedCell is the index of the cell that raised Error; the cells after it were skipped.
+	FailedCell *int                 `json:"failed_cell,omitempty"`
+	Error      *execute.ErrorOutput `json:"error,omitempty"`
 }
 
 // RunCommandRequest represents a shell command execution request.
 type RunCommandRequest struct {
-	Command    string `json:"command" validate:"required"`
-	Cwd        string `json:"cwd,omitempty"`
-	Background bool   `json:"background,omitempty"`
+	Command string `json:"command" validate:"required"`
+	Cwd     string `json:"cwd,omitempty"`
+	// CreateCwd creates a missing Cwd, which fails the request otherwise.
+	CreateCwd  bool `json:"create_cwd,omitempty"`
+	Background bool `json:"background,omitempty"`
+	// SeparateStreams keeps stdout and stderr of background commands apart so
+	// they can be fetched individually from /command/:id/logs.
+	SeparateStreams bool `json:"separate_streams,omitempty"`
+	// Shell runs the command: bash, the default outside Windows, or on Windows
+	// cmd, the default there, powershell or pwsh.
+	Shell string `json:"shell,omitempty" validate:"omitempty,oneof=bash cmd powershell pwsh"`
+	// User and Group, names or numeric ids, run the command with dropped privileges.
+	User  string `json:"user,omitempty"`
+	Group string `json:"group,omitempty"`
+	// Limits narrows the server's resource limits for this command.
+	Limits *ResourceLimits `json:"limits,omitempty"`
+	// Stdin is written to the standard input of a foreground command.
+	Stdin string `json:"stdin,omitempty"`
+	// PTY runs a foreground command in a pseudo-terminal; its stdout and stderr
+	// are merged into the stdout events.
+	PTY *TerminalOptions `json:"pty,omitempty"`
+	// Progress turns output lines of a foreground command into progress events.
+	Progress []ProgressPattern `json:"progress,omitempty" validate:"max=16,dive"`
+	// TimeoutSeconds bounds a foreground command, which is killed once it
+	// elapses; a non-streaming run then answers 408 with the output so far.
+	TimeoutSeconds int64 `json:"timeout_seconds,omitempty" validate:"min=0"`
+	// TrackArtifacts reports the files a foreground command created or
+	// modified in its working directory.
+	TrackArtifacts bool `json:"track_artifacts,omitempty"`
+	// SuccessExitCodes lists the exit codes besides 0 with which a foreground
+	// command completes rather than fails, e.g. 1 for grep finding nothing.
+	SuccessExitCodes []int `json:"success_exit_codes,omitempty" validate:"max=256,dive,min=0,max=255"`
+	// OutputPositions numbers the stdout and stderr events of a foreground
+	// command and adds the log offset following each line.
+	OutputPositions bool `json:"output_positions,omitempty"`
+}
+
+// ProgressPattern turns output lines matching Regex, an RE2 expression, into
+// progress events. The named groups "percent", or "current" and "total", give
+// the amount and "stage" the label; Stage labels the events when Regex has no
+// stage group.
+type ProgressPattern struct {
+	Regex string `json:"regex" validate:"required"`
+	Stage string `json:"stage,omitempty"`
+}
+
+// TerminalOptions sizes the pseudo-terminal of a command; omitted fields default to 80x24.
+type TerminalOptions struct {
+	Rows uint16 `json:"rows,omitempty"`
+	Cols uint16 `json:"cols,omitempty"`
+}
+
+// ResourceLimits caps the resources of a command; omitted fields use the server defaults.
+type ResourceLimits struct {
+	// AddressSpace is the maximum virtual memory size in bytes.
+	AddressSpace *uint64 `json:"address_space,omitempty"`
+	CPUSeconds   *uint64 `json:"cpu_seconds,omitempty"`
+	OpenFiles    *uint64 `json:"open_files,omitempty"`
+	// CoreSize is the maximum core dump size in bytes; 0 disables core dumps.
+	CoreSize *uint64 `json:"core_size,omitempty"`
 }
 
 func (r *RunCommandRequest) Validate() error {
-	validate := validator.New()
-	return validate.Struct(r)
+	if err := validateStruct(r); err != nil {
+		return err
+	}
+
+	var fields []FieldError
+	for i, pattern := range r.Progress {
+		re, err := regexp.Compile(pattern.Regex)
+		if err != nil {
+			fields = append(fields, FieldError{Field: fmt.Sprintf("progress[%d].regex", i), Message: err.Error()})
+			continue
+		}
+		hasAmount := re.SubexpIndex("percent") > 0 || (re.SubexpIndex("current") > 0 && re.SubexpIndex("total") > 0)
+		if !hasAmount && re.SubexpIndex("stage") <= 0 && pattern.Stage == "" {
+			fields = append(fields, FieldError{
+				Field:   fmt.Sprintf("progress[%d].regex", i),
+				Message: "must name a percent group, current and total groups, or a stage",
+			})
+		}
+	}
+	if r.CreateCwd && r.Cwd == "" {
+		fields = append(fields, FieldError{Field: "cwd", Message: "is required with create_cwd"})
+	}
+	if !r.Background {
+		if len(fields) > 0 {
+			return &ValidationError{Fields: fields}
+		}
+		return nil
+	}
+
+	if r.Stdin != "" {
+		fields = append(fields, FieldError{Field: "stdin", Message: "is not supported for background commands"})
+	}
+	if r.PTY != nil {
+		fields = append(fields, FieldError{Field: "pty", Message: "is not supported for background commands"})
+	}
+	if len(r.Progress) > 0 {
+		fields = append(fields, FieldError{Field: "progress", Message: "is not supported for background commands"})
+	}
+	if r.TimeoutSeconds > 0 {
+		fields = append(fields, FieldError{Field: "timeout_seconds", Message: "is not supported for background commands"})
+	}
+	if r.TrackArtifacts {
+		fields = append(fields, FieldError{Field: "track_artifacts", Message: "is not supported for background commands"})
+	}
+	if len(r.SuccessExitCodes) > 0 {
+		fields = append(fields, FieldError{Field: "success_exit_codes", Message: "is not supported for background commands"})
+	}
+	if r.OutputPositions {
+		fields = append(fields, FieldError{Field: "output_positions", Message: "is not supported for background commands"})
+	}
+	if len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+	return nil
 }
 
 type ServerStreamEventType string
@@ -68,6 +346,18 @@ const (
 	StreamEventTypeComplete ServerStreamEventType = "execution_complete"
 	StreamEventTypeCount    ServerStreamEventType = "execution_count"
 	StreamEventTypePing     ServerStreamEventType = "ping"
+	// StreamEventTypeExit is the last event of a foreground command stream.
+	StreamEventTypeExit ServerStreamEventType = "exit"
+	// StreamEventTypeRateLimited reports stdout and stderr events dropped by the output rate limit.
+	StreamEventTypeRateLimited ServerStreamEventType = "rate_limited"
+	// StreamEventTypeProgress reports progress parsed from command output, after the line in Text.
+	StreamEventTypeProgress ServerStreamEventType = "progress"
+	// StreamEventTypeCellComplete ends each cell of a cells run, Text telling
+	// whether it succeeded ("ok") or raised an error ("error").
+	StreamEventTypeCellComplete ServerStreamEventType = "cell_complete"
+	// StreamEventTypeArtifacts lists the files a run tracking artifacts
+	// created or modified, after its other events.
+	StreamEventTypeArtifacts ServerStreamEventType = "artifacts"
 )
 
 // ServerStreamEvent is emitted to clients over SSE.
@@ -79,6 +369,78 @@ type ServerStreamEvent struct {
 	Timestamp      int64                 `json:"timestamp,omitempty"`
 	Results        map[string]any        `json:"results,omitempty"`
 	Error          *execute.ErrorOutput  `json:"error,omitempty"`
+	// Seq numbers the output events of a context, set on attached output streams
+	// only; on followed command logs it is the cursor following the line.
+	Seq int64 `json:"seq,omitempty"`
+	// Stream names the log a followed command line was read from.
+	Stream string `json:"stream,omitempty"`
+	// Line and Offset locate an output line of a foreground command run with
+	// output_positions in the log of its stream: the line number from 1 and
+	// the cursor following the line, for /command/:id/logs?cursor=.
+	Line   int64 `json:"line,omitempty"`
+	Offset int64 `json:"offset,omitempty"`
+	// CellIndex identifies the cell of a cells or execute-batch run the event
+	// belongs to.
+	CellIndex *int `json:"cell_index,omitempty"`
+	// Exit describes how the command ended, set on exit events and on the
+	// execution_complete events of foreground commands.
+	Exit *CommandExit `json:"exit,omitempty"`
+	// Dropped counts the output events skipped, set on rate_limited events only.
+	Dropped int `json:"dropped,omitempty"`
+	// Progress is set on progress events only.
+	Progress *CommandProgress `json:"progress,omitempty"`
+	// Artifacts is set on artifacts events only.
+	Artifacts *Artifacts `json:"artifacts,omitempty"`
+	// CwdCreated is set on the init event of a command whose working
+	// directory was created for it.
+	CwdCreated bool `json:"cwd_created,omitempty"`
+}
+
+// ExecutionSummary is the single response of a run that isn't streamed. Stdout
+// and Stderr hold the whole output, Results the rich results in order and
+// Error the last error raised.
+type ExecutionSummary struct {
+	Stdout         string               `json:"stdout"`
+	Stderr         string               `json:"stderr"`
+	Results        []map[string]any     `json:"results,omitempty"`
+	Error          *execute.ErrorOutput `json:"error,omitempty"`
+	ExecutionCount int                  `json:"execution_count,omitempty"`
+	// Duration is the wall time of the run in milliseconds.
+	Duration int64 `json:"duration"`
+	// Exit is how a shell command ended, unset for code.
+	Exit *CommandExit `json:"exit,omitempty"`
+	// TimedOut is set when the request timeout elapsed before the run ended.
+	TimedOut bool `json:"timed_out,omitempty"`
+	// Artifacts is set for runs tracking artifacts.
+	Artifacts *Artifacts `json:"artifacts,omitempty"`
+	// CwdCreated is set when the working directory of a command was created for it.
+	CwdCreated bool `json:"cwd_created,omitempty"`
+}
+
+// Artifacts lists the files a run created or modified in its working
+// directory, compared by size and modification time.
+type Artifacts struct {
+	Dir      string     `json:"dir,omitempty"`
+	Created  []FileInfo `json:"created"`
+	Modified []FileInfo `json:"modified"`
+	// Skipped tells why the directory wasn't tracked, e.g. it holds too many
+	// entries; Created and Modified are empty then.
+	Skipped string `json:"skipped,omitempty"`
+}
+
+// CommandProgress is the progress of a command parsed from its output.
+type CommandProgress struct {
+	// Percent is omitted when the line names a stage without an amount.
+	Percent *float64 `json:"percent,omitempty"`
+	Stage   string   `json:"stage,omitempty"`
+}
+
+// CommandExit is the exit status of a foreground command.
+type CommandExit struct {
+	ExitCode int `json:"exit_code"`
+	// Signal names the signal that killed the command, e.g. SIGKILL.
+	Signal  string `json:"signal,omitempty"`
+	Success bool   `json:"success"`
 }
 
 // ToJSON serializes the event for streaming.
@@ -86,3 +448,16 @@ func (s ServerStreamEvent) ToJSON() []byte {
 	bytes, _ := json.Marshal(s)
 	return bytes
 }
+
+// SQLConnectionRequest registers a database SQL requests may pick by name.
+type SQLConnectionRequest struct {
+	Name string `json:"name" validate:"required,max=64"`
+	// Driver is a database/sql driver built into execd, such as mysql.
+	Driver string `json:"driver" validate:"required"`
+	// DSN is the data source name of the driver; it is never reported back.
+	DSN string `json:"dsn" validate:"required"`
+}
+
+func (r *SQLConnectionRequest) Validate() error {
+	return validateStruct(r)
+}
@@ -16,9 +16,33 @@ package model
 
 import (
 	"encoding/json"
+	"errors"
+	"os"
 	"testing"
 )
 
+// TestMain sets the languages the controller sets from the runtime.
+func TestMain(m *testing.M) {
+	SetLanguages([]string{"command", "bash", "python", "java", "javascript", "typescript", "go", "sql", "background-command"})
+	os.Exit(m.Run())
+}
+
+func assertFieldErrors(t *testing.T, err error, want ...FieldError) {
+	t.Helper()
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected *ValidationError, got %T: %v", err, err)
+	}
+	if len(validationErr.Fields) != len(want) {
+		t.Fatalf("expected field errors %v, got %v", want, validationErr.Fields)
+	}
+	for i, fe := range want {
+		if validationErr.Fields[i] != fe {
+			t.Fatalf("expected field error %v, got %v", fe, validationErr.Fields[i])
+		}
+	}
+}
+
 func TestRunCodeRequestValidate(t *testing.T) {
 	req := RunCodeRequest{
 		Code: "print('hi')",
@@ -33,6 +57,17 @@ func TestRunCodeRequestValidate(t *testing.T) {
 	}
 }
 
+func TestRunCodeRequestValidate_Connection(t *testing.T) {
+	req := RunCodeRequest{Code: "SELECT 1", Connection: "analytics"}
+	req.Context.Language = "sql"
+	if err := req.Validate(); err != nil {
+		t.Fatalf("expected validation success: %v", err)
+	}
+
+	req.Context.Language = "python"
+	assertFieldErrors(t, req.Validate(), FieldError{Field: "connection", Message: "is only supported for sql"})
+}
+
 func TestRunCommandRequestValidate(t *testing.T) {
 	req := RunCommandRequest{Command: "ls"}
 	if err := req.Validate(); err != nil {
@@ -45,6 +80,179 @@ func TestRunCommandRequestValidate(t *testing.T) {
 	}
 }
 
+func TestRunCodeRequestValidate_FieldErrors(t *testing.T) {
+	req := RunCodeRequest{}
+	assertFieldErrors(t, req.Validate(), FieldError{Field: "code", Message: "is required"})
+
+	req = RunCodeRequest{Code: "print(1)"}
+	req.Context.Language = "cobol"
+	err := req.Validate()
+	assertFieldErrors(t, err, FieldError{
+		Field:   "context.language",
+		Message: "must be one of: command, bash, python, java, javascript, typescript, go, sql, background-command",
+	})
+	if err.Error() != "context.language must be one of: command, bash, python, java, javascript, typescript, go, sql, background-command" {
+		t.Fatalf("unexpected summary: %s", err.Error())
+	}
+}
+
+func TestRunCodeRequestValidate_Cells(t *testing.T) {
+	req := RunCodeRequest{Cells: []string{"x = 1", "print(x)"}, StopOnError: true}
+	if err := req.Validate(); err != nil {
+		t.Fatalf("expected cells validation success: %v", err)
+	}
+
+	req.Code = "print(1)"
+	assertFieldErrors(t, req.Validate(), FieldError{Field: "cells", Message: "must not be set together with code"})
+
+	req = RunCodeRequest{Cells: []string{"x = 1", ""}}
+	assertFieldErrors(t, req.Validate(), FieldError{Field: "cells[1]", Message: "is required"})
+
+	req = RunCodeRequest{Code: "print(1)", StopOnError: true}
+	assertFieldErrors(t, req.Validate(), FieldError{Field: "stop_on_error", Message: "is only supported with cells"})
+
+	req = RunCodeRequest{Cells: []string{"print(1)"}, TrackArtifacts: true}
+	assertFieldErrors(t, req.Validate(), FieldError{Field: "track_artifacts", Message: "is not supported with cells"})
+
+	req = RunCodeRequest{Cells: []string{"SELECT 1"}, Connection: "reports", Context: CodeContext{CodeContextRequest: CodeContextRequest{Language: "sql"}}}
+	assertFieldErrors(t, req.Validate(), FieldError{Field: "connection", Message: "is not supported with cells"})
+}
+
+func TestCodeContextRequestValidate_Env(t *testing.T) {
+	req := CodeContextRequest{Env: map[string]string{"PYTHONPATH": "/opt/lib", "_SEED": "1"}, Setup: []string{"import os"}}
+	if err := req.Validate(); err != nil {
+		t.Fatalf("expected validation success: %v", err)
+	}
+
+	req.Env["BAD-NAME"] = "x"
+	assertFieldErrors(t, req.Validate(), FieldError{Field: "env[BAD-NAME]", Message: "must be a valid environment variable name"})
+
+	req = CodeContextRequest{Setup: []string{""}}
+	assertFieldErrors(t, req.Validate(), FieldError{Field: "setup[0]", Message: "is required"})
+}
+
+func TestCreateCwdRequiresCwd(t *testing.T) {
+	command := RunCommandRequest{Command: "ls", CreateCwd: true}
+	assertFieldErrors(t, command.Validate(), FieldError{Field: "cwd", Message: "is required with create_cwd"})
+	command.Cwd = "/workspace/new"
+	if err := command.Validate(); err != nil {
+		t.Fatalf("expected validation success: %v", err)
+	}
+
+	codeContext := CodeContextRequest{CreateCwd: true}
+	assertFieldErrors(t, codeContext.Validate(), FieldError{Field: "cwd", Message: "is required with create_cwd"})
+	codeContext.Cwd = "/workspace/new"
+	if err := codeContext.Validate(); err != nil {
+		t.Fatalf("expected validation success: %v", err)
+	}
+}
+
+func TestRunCommandRequestValidate_FieldErrors(t *testing.T) {
+	req := RunCommandRequest{Cwd: "/tmp"}
+	assertFieldErrors(t, req.Validate(), FieldError{Field: "command", Message: "is required"})
+
+	req = RunCommandRequest{Command: "top", Stdin: "q", PTY: &TerminalOptions{Rows: 40}, TimeoutSeconds: 10}
+	if err := req.Validate(); err != nil {
+		t.Fatalf("expected foreground pty validation success: %v", err)
+	}
+
+	req.Background = true
+	assertFieldErrors(t, req.Validate(),
+		FieldError{Field: "stdin", Message: "is not supported for background commands"},
+		FieldError{Field: "pty", Message: "is not supported for background commands"},
+		FieldError{Field: "timeout_seconds", Message: "is not supported for background commands"},
+	)
+}
+
+func TestRunCommandRequestValidate_ProgressPatterns(t *testing.T) {
+	req := RunCommandRequest{Command: "pip install numpy", Progress: []ProgressPattern{
+		{Regex: `(?P<percent>\d+)%`},
+		{Regex: `\[(?P<current>\d+)/(?P<total>\d+)\]`},
+		{Regex: `^Collecting (?P<stage>\S+)`},
+		{Regex: `^Installing collected packages`, Stage: "install"},
+	}}
+	if err := req.Validate(); err != nil {
+		t.Fatalf("expected progress validation success: %v", err)
+	}
+
+	req.Progress = []ProgressPattern{{Regex: `(?P<percent>\d+)%`}, {}}
+	assertFieldErrors(t, req.Validate(), FieldError{Field: "progress[1].regex", Message: "is required"})
+
+	req.Progress = []ProgressPattern{{Regex: `(?P<percent>\d+`}, {Regex: `done`}}
+	err := req.Validate()
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || len(validationErr.Fields) != 2 ||
+		validationErr.Fields[0].Field != "progress[0].regex" ||
+		validationErr.Fields[1] != (FieldError{Field: "progress[1].regex", Message: "must name a percent group, current and total groups, or a stage"}) {
+		t.Fatalf("unexpected progress errors: %v", err)
+	}
+
+	req = RunCommandRequest{Command: "make", Background: true, Progress: []ProgressPattern{{Regex: `(?P<percent>\d+)%`}}}
+	assertFieldErrors(t, req.Validate(), FieldError{Field: "progress", Message: "is not supported for background commands"})
+}
+
+func TestRunCommandRequestValidate_SuccessExitCodes(t *testing.T) {
+	req := RunCommandRequest{Command: "grep -q x log", SuccessExitCodes: []int{1, 255}}
+	if err := req.Validate(); err != nil {
+		t.Fatalf("expected success exit codes validation success: %v", err)
+	}
+
+	req.SuccessExitCodes = []int{1, 256}
+	assertFieldErrors(t, req.Validate(), FieldError{Field: "success_exit_codes[1]", Message: "failed max=255 validation"})
+
+	req = RunCommandRequest{Command: "grep -q x log", Background: true, SuccessExitCodes: []int{1}}
+	assertFieldErrors(t, req.Validate(), FieldError{Field: "success_exit_codes", Message: "is not supported for background commands"})
+}
+
+func TestRunCommandRequestValidate_Shell(t *testing.T) {
+	req := RunCommandRequest{Command: "Get-Date", Shell: "pwsh"}
+	if err := req.Validate(); err != nil {
+		t.Fatalf("expected shell validation success: %v", err)
+	}
+
+	req.Shell = "zsh"
+	assertFieldErrors(t, req.Validate(), FieldError{Field: "shell", Message: "must be one of: bash, cmd, powershell, pwsh"})
+}
+
+func TestRunCommandRequestValidate_OutputPositions(t *testing.T) {
+	req := RunCommandRequest{Command: "tail log", OutputPositions: true}
+	if err := req.Validate(); err != nil {
+		t.Fatalf("expected output positions validation success: %v", err)
+	}
+
+	req.Background = true
+	assertFieldErrors(t, req.Validate(), FieldError{Field: "output_positions", Message: "is not supported for background commands"})
+}
+
+func TestRunCodeBatchRequestValidate_FieldErrors(t *testing.T) {
+	req := RunCodeBatchRequest{Codes: []string{}}
+	assertFieldErrors(t, req.Validate(), FieldError{Field: "codes", Message: "must contain at least 1 item(s)"})
+
+	req.Codes = []string{"a = 1", ""}
+	assertFieldErrors(t, req.Validate(), FieldError{Field: "codes[1]", Message: "is required"})
+}
+
+func TestCodeInspectionRequestValidate_FieldErrors(t *testing.T) {
+	req := CodeInspectionRequest{CodeCompletionRequest: CodeCompletionRequest{Code: "print", CursorPos: 5}}
+	if err := req.Validate(); err != nil {
+		t.Fatalf("expected validation success: %v", err)
+	}
+
+	req.CursorPos = -1
+	assertFieldErrors(t, req.Validate(), FieldError{Field: "cursor_pos", Message: "must be at least 0"})
+
+	// the cursor counts code points, so it may sit right after a multi-byte character.
+	req.Code, req.CursorPos = "é", 1
+	if err := req.Validate(); err != nil {
+		t.Fatalf("expected validation success: %v", err)
+	}
+	req.CursorPos = 2
+	assertFieldErrors(t, req.Validate(), FieldError{Field: "cursor_pos", Message: "must not exceed the length of code"})
+
+	req.CursorPos, req.DetailLevel = 0, 2
+	assertFieldErrors(t, req.Validate(), FieldError{Field: "detail_level", Message: "must be one of: 0, 1"})
+}
+
 func TestServerStreamEventToJSON(t *testing.T) {
 	event := ServerStreamEvent{
 		Type:           StreamEventTypeStdout,
@@ -0,0 +1,72 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// MaskedEnvValue replaces the values of env file variables that aren't revealed.
+const MaskedEnvValue = "[REDACTED]"
+
+// EnvUpdateRequest sets variables of the env file, dropping the others when
+// Replace is set. Values are stored verbatim and expanded when read.
+type EnvUpdateRequest struct {
+	Envs    map[string]string `json:"envs"`
+	Replace bool              `json:"replace,omitempty"`
+}
+
+func (r *EnvUpdateRequest) Validate() error {
+	if len(r.Envs) == 0 && !r.Replace {
+		return &ValidationError{Fields: []FieldError{{Field: "envs", Message: "is required"}}}
+	}
+
+	var fields []FieldError
+	for _, name := range slices.Sorted(maps.Keys(r.Envs)) {
+		value := r.Envs[name]
+		switch {
+		case !envNamePattern.MatchString(name):
+			fields = append(fields, FieldError{
+				Field:   fmt.Sprintf("envs[%s]", name),
+				Message: "must be a valid environment variable name",
+			})
+		case strings.ContainsAny(value, "\r\n"):
+			fields = append(fields, FieldError{
+				Field:   fmt.Sprintf("envs[%s]", name),
+				Message: "must not contain line breaks",
+			})
+		case strings.TrimRightFunc(value, unicode.IsSpace) != value:
+			fields = append(fields, FieldError{
+				Field:   fmt.Sprintf("envs[%s]", name),
+				Message: "must not end with whitespace",
+			})
+		}
+	}
+	if len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+	return nil
+}
+
+// EnvVars lists the variables of the env file, their values masked unless
+// Revealed is set.
+type EnvVars struct {
+	Envs     map[string]string `json:"envs"`
+	Revealed bool              `json:"revealed"`
+}
@@ -17,18 +17,59 @@ package model
 type ErrorCode string
 
 const (
-	ErrorCodeInvalidRequest      ErrorCode = "INVALID_REQUEST_BODY"
-	ErrorCodeMissingQuery        ErrorCode = "MISSING_QUERY"
-	ErrorCodeRuntimeError        ErrorCode = "RUNTIME_ERROR"
-	ErrorCodeInvalidFile         ErrorCode = "INVALID_FILE"
-	ErrorCodeInvalidFileContent  ErrorCode = "INVALID_FILE_CONTENT"
-	ErrorCodeInvalidFileMetadata ErrorCode = "INVALID_FILE_METADATA"
-	ErrorCodeFileNotFound        ErrorCode = "FILE_NOT_FOUND"
-	ErrorCodeUnknown             ErrorCode = "UNKNOWN"
-	ErrorCodeContextNotFound     ErrorCode = "CONTEXT_NOT_FOUND"
+	ErrorCodeInvalidRequest         ErrorCode = "INVALID_REQUEST_BODY"
+	ErrorCodeMissingQuery           ErrorCode = "MISSING_QUERY"
+	ErrorCodeRuntimeError           ErrorCode = "RUNTIME_ERROR"
+	ErrorCodeInvalidFile            ErrorCode = "INVALID_FILE"
+	ErrorCodeInvalidFileContent     ErrorCode = "INVALID_FILE_CONTENT"
+	ErrorCodeInvalidFileMetadata    ErrorCode = "INVALID_FILE_METADATA"
+	ErrorCodeFileNotFound           ErrorCode = "FILE_NOT_FOUND"
+	ErrorCodeFileTooLarge           ErrorCode = "FILE_TOO_LARGE"
+	ErrorCodeInvalidArchive         ErrorCode = "INVALID_ARCHIVE"
+	ErrorCodeNotDirectory           ErrorCode = "NOT_A_DIRECTORY"
+	ErrorCodeInvalidTemplate        ErrorCode = "INVALID_TEMPLATE"
+	ErrorCodeMetricsHistoryDisabled ErrorCode = "METRICS_HISTORY_DISABLED"
+	ErrorCodeUnknown                ErrorCode = "UNKNOWN"
+	ErrorCodeRequestTimeout         ErrorCode = "REQUEST_TIMEOUT"
+	ErrorCodeContextNotFound        ErrorCode = "CONTEXT_NOT_FOUND"
+	ErrorCodeContextBusy            ErrorCode = "CONTEXT_BUSY"
+	ErrorCodeContextPreambleFailed  ErrorCode = "CONTEXT_PREAMBLE_FAILED"
+	ErrorCodeContextSetupFailed     ErrorCode = "CONTEXT_SETUP_FAILED"
+	ErrorCodeUnsupportedLanguage    ErrorCode = "UNSUPPORTED_LANGUAGE"
+	ErrorCodeSQLQueryNotFound       ErrorCode = "SQL_QUERY_NOT_FOUND"
+	ErrorCodeSQLConnectionNotFound  ErrorCode = "SQL_CONNECTION_NOT_FOUND"
+	ErrorCodeSQLConnectionExists    ErrorCode = "SQL_CONNECTION_EXISTS"
+	ErrorCodeInvalidProxyPort       ErrorCode = "INVALID_PROXY_PORT"
+	ErrorCodeProxyPortForbidden     ErrorCode = "PROXY_PORT_FORBIDDEN"
+	ErrorCodeInvalidProxyTarget     ErrorCode = "INVALID_PROXY_TARGET"
+	ErrorCodeProxyTargetForbidden   ErrorCode = "PROXY_TARGET_FORBIDDEN"
+	ErrorCodeProxyTargetUnreachable ErrorCode = "PROXY_TARGET_UNREACHABLE"
+	ErrorCodeProxyConnectRefused    ErrorCode = "PROXY_CONNECT_REFUSED"
+	ErrorCodeProxyTimeout           ErrorCode = "PROXY_TIMEOUT"
+	ErrorCodeProxyStreamInterrupted ErrorCode = "PROXY_STREAM_INTERRUPTED"
+	ErrorCodeOriginForbidden        ErrorCode = "ORIGIN_FORBIDDEN"
+	ErrorCodeIdempotencyConflict    ErrorCode = "IDEMPOTENCY_KEY_CONFLICT"
+	ErrorCodeInvalidCommandUser     ErrorCode = "INVALID_COMMAND_USER"
+	ErrorCodeCommandUserForbidden   ErrorCode = "COMMAND_USER_FORBIDDEN"
+	ErrorCodeEnvFileNotConfigured   ErrorCode = "ENV_FILE_NOT_CONFIGURED"
+	ErrorCodeAdminForbidden         ErrorCode = "ADMIN_FORBIDDEN"
+	ErrorCodeProcessNotFound        ErrorCode = "PROCESS_NOT_FOUND"
+	ErrorCodeProcessProtected       ErrorCode = "PROCESS_PROTECTED"
+	ErrorCodeInvalidSignal          ErrorCode = "INVALID_SIGNAL"
+	ErrorCodeCommandOutputRemoved   ErrorCode = "COMMAND_OUTPUT_REMOVED"
+	ErrorCodeFetchFailed            ErrorCode = "FETCH_FAILED"
+	ErrorCodePushFailed             ErrorCode = "PUSH_FAILED"
+	ErrorCodeUnsupportedContentType ErrorCode = "UNSUPPORTED_CONTENT_TYPE"
+	ErrorCodeChecksumMismatch       ErrorCode = "CHECKSUM_MISMATCH"
+	ErrorCodeWaitTimeout            ErrorCode = "WAIT_TIMEOUT"
+	ErrorCodeCommandExited          ErrorCode = "COMMAND_EXITED"
+	ErrorCodeInvalidCwd             ErrorCode = "INVALID_CWD"
+	ErrorCodeCwdForbidden           ErrorCode = "CWD_FORBIDDEN"
 )
 
 type ErrorResponse struct {
 	Code    ErrorCode `json:"code,omitempty"`
 	Message string    `json:"message,omitempty"`
+	// Errors lists the offending fields when a request body fails validation.
+	Errors []FieldError `json:"errors,omitempty"`
 }
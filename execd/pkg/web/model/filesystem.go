@@ -22,12 +22,17 @@ type FileInfo struct {
 	Size       int64     `json:"size"`
 	ModifiedAt time.Time `json:"modified_at,omitempty"`
 	CreatedAt  time.Time `json:"created_at,omitempty"`
+	// AccessedAt is omitted where the platform doesn't report access times.
+	AccessedAt *time.Time `json:"accessed_at,omitempty"`
 	Permission `json:",inline"`
 }
 
 type FileMetadata struct {
 	Path       string `json:"path,omitempty"`
 	Permission `json:",inline"`
+	// Extract treats the file as a zip archive extracted into the directory
+	// Path. Entries keep their archived modes, Mode applies to Path itself.
+	Extract bool `json:"extract,omitempty"`
 }
 
 // Permission represents file ownership and mode
@@ -37,14 +42,186 @@ type Permission struct {
 	Mode  int    `json:"mode"`
 }
 
+// DirStatus tells whether a directory was created or already existed
+type DirStatus string
+
+const (
+	DirStatusCreated DirStatus = "created"
+	DirStatusExisted DirStatus = "existed"
+)
+
+// MakeDirResult reports the outcome of creating one directory
+type MakeDirResult struct {
+	Status DirStatus `json:"status"`
+}
+
 // RenameFileItem represents a file rename operation
 type RenameFileItem struct {
 	Src  string `json:"src,omitempty"`
 	Dest string `json:"dest,omitempty"`
 }
 
+// FileTimesItem sets the access and modification times of a file. An omitted
+// time is left unchanged.
+type FileTimesItem struct {
+	Path  string     `json:"path" validate:"required"`
+	Atime *time.Time `json:"atime,omitempty"`
+	Mtime *time.Time `json:"mtime,omitempty"`
+}
+
+func (r *FileTimesItem) Validate() error {
+	if err := validateStruct(r); err != nil {
+		return err
+	}
+	if r.Atime == nil && r.Mtime == nil {
+		return &ValidationError{Fields: []FieldError{{
+			Field:   "mtime",
+			Message: "at least one of atime or mtime is required",
+		}}}
+	}
+	return nil
+}
+
 // ReplaceFileContentItem represents a content replacement operation
 type ReplaceFileContentItem struct {
 	Old string `json:"old,omitempty"`
 	New string `json:"new,omitempty"`
 }
+
+// FileDiffRequest previews a change to a file, given either its full new
+// content or a replacement applied the way /files/replace would.
+type FileDiffRequest struct {
+	Path    string                  `json:"path" validate:"required"`
+	Content *string                 `json:"content,omitempty"`
+	Replace *ReplaceFileContentItem `json:"replace,omitempty"`
+}
+
+func (r *FileDiffRequest) Validate() error {
+	if err := validateStruct(r); err != nil {
+		return err
+	}
+	if (r.Content == nil) == (r.Replace == nil) {
+		return &ValidationError{Fields: []FieldError{{
+			Field:   "content",
+			Message: "exactly one of content or replace is required",
+		}}}
+	}
+	return nil
+}
+
+// FileDiff is the unified diff between a file and its proposed content.
+type FileDiff struct {
+	Path    string `json:"path"`
+	Changed bool   `json:"changed"`
+	Diff    string `json:"diff"`
+}
+
+// FileRenderRequest writes the output of a Go text/template to a file.
+type FileRenderRequest struct {
+	Path       string         `json:"path" validate:"required"`
+	Template   string         `json:"template" validate:"required"`
+	Variables  map[string]any `json:"variables,omitempty"`
+	Permission `json:",inline"`
+}
+
+func (r *FileRenderRequest) Validate() error {
+	return validateStruct(r)
+}
+
+// GlobValidation tells whether a search pattern is well-formed, and why not.
+type GlobValidation struct {
+	Pattern string `json:"pattern"`
+	Valid   bool   `json:"valid"`
+	Error   string `json:"error,omitempty"`
+}
+
+// FileFetchRequest downloads a URL into the sandbox server-side.
+type FileFetchRequest struct {
+	URL      string            `json:"url" validate:"required,http_url"`
+	DestPath string            `json:"dest_path" validate:"required"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	// MaxSize caps the bytes downloaded; 0 uses the server limit.
+	MaxSize int64 `json:"max_size,omitempty" validate:"min=0"`
+	// ContentTypes, when set, lists the media types the response may have;
+	// "type/*" accepts every subtype.
+	ContentTypes []string `json:"content_types,omitempty"`
+	// SHA256, hex encoded, must match the download before it is placed.
+	SHA256         string `json:"sha256,omitempty" validate:"omitempty,len=64,hexadecimal"`
+	TimeoutSeconds int64  `json:"timeout_seconds,omitempty" validate:"min=0"`
+	// Background answers at once with the command session id the fetch is
+	// tracked under.
+	Background bool `json:"background,omitempty"`
+	Permission `json:",inline"`
+}
+
+func (r *FileFetchRequest) Validate() error {
+	return validateStruct(r)
+}
+
+// FetchStatus is the state of a fetch.
+type FetchStatus string
+
+const (
+	FetchStatusRunning   FetchStatus = "running"
+	FetchStatusCompleted FetchStatus = "completed"
+	FetchStatusFailed    FetchStatus = "failed"
+)
+
+// FileFetchResult reports a finished fetch, or the session of one started in
+// the background.
+type FileFetchResult struct {
+	// ID is the command session of a background fetch.
+	ID       string      `json:"id,omitempty"`
+	URL      string      `json:"url"`
+	DestPath string      `json:"dest_path"`
+	Status   FetchStatus `json:"status"`
+	// BytesWritten counts the bytes downloaded so far.
+	BytesWritten int64 `json:"bytes_written"`
+	// TotalBytes is the announced size of the download, unset when unknown.
+	TotalBytes int64 `json:"total_bytes,omitempty"`
+	// Duration is the wall time of the fetch so far in milliseconds.
+	Duration int64 `json:"duration"`
+	// SHA256 is the hex encoded digest of a completed download.
+	SHA256 string    `json:"sha256,omitempty"`
+	File   *FileInfo `json:"file,omitempty"`
+	// Code and Error tell why a fetch failed.
+	Code  ErrorCode `json:"code,omitempty"`
+	Error string    `json:"error,omitempty"`
+}
+
+// FilePushRequest uploads a sandbox file to a URL, such as a presigned object
+// storage URL, server-side.
+type FilePushRequest struct {
+	Path string `json:"path" validate:"required"`
+	URL  string `json:"url" validate:"required,http_url"`
+	// Method is PUT or POST, PUT when unset. Only PUT pushes are retried.
+	Method  string            `json:"method,omitempty" validate:"omitempty,oneof=PUT POST"`
+	Headers map[string]string `json:"headers,omitempty"`
+	// ContentType is sent as the Content-Type of the upload when set.
+	ContentType string `json:"content_type,omitempty"`
+	// Background answers at once with a command session id: GET
+	// /command/status/:id reports the push and /command/:id/logs its progress.
+	Background bool `json:"background,omitempty"`
+}
+
+func (r *FilePushRequest) Validate() error {
+	return validateStruct(r)
+}
+
+// FilePushResult reports a push, or the session of a background one.
+type FilePushResult struct {
+	ID   string `json:"id,omitempty"`
+	Path string `json:"path"`
+	// BytesSent counts the bytes uploaded by the last attempt.
+	BytesSent  int64 `json:"bytes_sent"`
+	TotalBytes int64 `json:"total_bytes"`
+	// Duration is the wall time of the push in milliseconds, retries included.
+	Duration int64 `json:"duration"`
+	// Rate is the transfer rate of the last attempt in bytes per second.
+	Rate     float64 `json:"rate"`
+	Attempts int     `json:"attempts,omitempty"`
+	// UpstreamStatus and UpstreamBody are the status code and the start of the
+	// body the URL answered with.
+	UpstreamStatus int    `json:"upstream_status,omitempty"`
+	UpstreamBody   string `json:"upstream_body,omitempty"`
+}
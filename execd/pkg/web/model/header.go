@@ -17,4 +17,22 @@ package model
 const (
 	// ApiAccessTokenHeader carries the auth token.
 	ApiAccessTokenHeader = "X-EXECD-ACCESS-TOKEN"
+
+	// AdminTokenHeader carries the admin token authorizing admin requests.
+	AdminTokenHeader = "X-EXECD-ADMIN-TOKEN"
+
+	// StrictValidationHeader opts a single request into strict JSON binding.
+	StrictValidationHeader = "X-Strict-Validation"
+
+	// ApiVersionHeader requests a response shape on unversioned routes.
+	ApiVersionHeader = "X-EXECD-API-VERSION"
+
+	// IdempotencyKeyHeader makes retried context creations return the same context.
+	IdempotencyKeyHeader = "Idempotency-Key"
+
+	// ProxyTimeoutHeader bounds a single proxied request, capped by --proxy-max-timeout.
+	ProxyTimeoutHeader = "X-Proxy-Timeout"
+
+	// RequestIDHeader correlates a proxied request across execd and the upstream.
+	RequestIDHeader = "X-Request-Id"
 )
@@ -16,6 +16,14 @@ package model
 
 import "time"
 
+// MetricsSource tells where the CPU and memory figures of Metrics come from.
+type MetricsSource string
+
+const (
+	MetricsSourceHost   MetricsSource = "host"
+	MetricsSourceCgroup MetricsSource = "cgroup"
+)
+
 // Metrics represents system resource usage metrics
 type Metrics struct {
 	CpuCount    float64 `json:"cpu_count"`
@@ -23,6 +31,55 @@ type Metrics struct {
 	MemTotalMiB float64 `json:"mem_total_mib"`
 	MemUsedMiB  float64 `json:"mem_used_mib"`
 	Timestamp   int64   `json:"timestamp"`
+	// Source is cgroup when CpuCount or MemTotalMiB are the limits of execd's
+	// cgroup, with CpuUsedPct and MemUsedMiB relative to them. Resources the
+	// cgroup doesn't limit report the host values.
+	Source MetricsSource `json:"source"`
+	// Disks reports the usage of the filesystems holding the monitored paths.
+	Disks []DiskMetrics `json:"disks,omitempty"`
+	// Load1, Load5 and Load15 are the system load averages, ProcessCount and
+	// ThreadCount the system wide totals. They are zero where unavailable.
+	Load1        float64 `json:"load1"`
+	Load5        float64 `json:"load5"`
+	Load15       float64 `json:"load15"`
+	ProcessCount int     `json:"process_count"`
+	ThreadCount  int     `json:"thread_count"`
+	// ExecdOpenFDs, ExecdGoroutines and ExecdPanics describe the execd process
+	// itself; ExecdPanics counts the goroutine panics recovered since startup.
+	ExecdOpenFDs    int    `json:"execd_open_fds"`
+	ExecdGoroutines int    `json:"execd_goroutines"`
+	ExecdPanics     uint64 `json:"execd_panics"`
+}
+
+// DiskMetrics represents the usage of the filesystem holding Path
+type DiskMetrics struct {
+	Path         string  `json:"path"`
+	DiskTotalMiB float64 `json:"disk_total_mib"`
+	DiskUsedMiB  float64 `json:"disk_used_mib"`
+	DiskUsedPct  float64 `json:"disk_used_pct"`
+}
+
+// ProcessMetrics represents the resource usage of a tracked command or kernel,
+// child processes included
+type ProcessMetrics struct {
+	Session       string  `json:"session"`
+	Kind          string  `json:"kind"`
+	Pid           int     `json:"pid,omitempty"`
+	Running       bool    `json:"running"`
+	CpuPercent    float64 `json:"cpu_percent"`
+	RssMiB        float64 `json:"rss_mib"`
+	Threads       int32   `json:"threads"`
+	UptimeSeconds float64 `json:"uptime_seconds"`
+	// ExitCode, FinishedAt and Error report the final status of exited commands.
+	ExitCode   *int       `json:"exit_code,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// MetricsHistory is the series of metrics sampled in the background, oldest first
+type MetricsHistory struct {
+	IntervalSeconds float64   `json:"interval_seconds"`
+	Samples         []Metrics `json:"samples"`
 }
 
 func NewMetrics() *Metrics {
@@ -0,0 +1,48 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+// ListeningPort is a TCP socket listening for connections, or a UDP socket
+// bound without a peer; Pid and Process are unset when execd can't inspect the owner
+type ListeningPort struct {
+	Port     int    `json:"port"`
+	Protocol string `json:"protocol"`
+	Family   string `json:"family"`
+	Address  string `json:"address"`
+	Pid      int    `json:"pid,omitempty"`
+	Process  string `json:"process,omitempty"`
+}
+
+// PortEventType tells what a PortEvent reports.
+type PortEventType string
+
+const (
+	// PortEventSnapshot lists every listening port when a watch starts.
+	PortEventSnapshot PortEventType = "snapshot"
+	// PortEventOpened lists the ports that started listening since the last event.
+	PortEventOpened PortEventType = "opened"
+	// PortEventClosed lists the ports that stopped listening since the last event.
+	PortEventClosed PortEventType = "closed"
+	// PortEventError reports a failed poll; the watch goes on.
+	PortEventError PortEventType = "error"
+)
+
+// PortEvent is an event of GET /ports?watch=true
+type PortEvent struct {
+	Type      PortEventType   `json:"type"`
+	Ports     []ListeningPort `json:"ports,omitempty"`
+	Error     string          `json:"error,omitempty"`
+	Timestamp int64           `json:"timestamp"`
+}
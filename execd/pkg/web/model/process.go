@@ -0,0 +1,37 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import "time"
+
+// ProcessInfo describes a process of the sandbox, whether execd started it or not
+type ProcessInfo struct {
+	Pid     int32  `json:"pid"`
+	Ppid    int32  `json:"ppid"`
+	Name    string `json:"name"`
+	User    string `json:"user,omitempty"`
+	Cmdline string `json:"cmdline"`
+	// CpuPercent is the CPU usage sampled while listing
+	CpuPercent float64    `json:"cpu_percent"`
+	RssMiB     float64    `json:"rss_mib"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+}
+
+// ProcessList is a page of the processes of the sandbox, Total counting all
+// the processes matching the filter
+type ProcessList struct {
+	Total     int           `json:"total"`
+	Processes []ProcessInfo `json:"processes"`
+}
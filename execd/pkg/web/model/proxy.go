@@ -0,0 +1,55 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+// ProxyErrorResponse is returned when a proxied request fails at the upstream.
+type ProxyErrorResponse struct {
+	ErrorResponse
+	// Target is the port or host:port named in the proxy path.
+	Target    string `json:"target"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// ProxyStatus lists the proxy targets that saw traffic recently.
+type ProxyStatus struct {
+	Targets []ProxyTargetStatus `json:"targets"`
+}
+
+// ProxyTargetStatus summarizes the traffic proxied to one target.
+type ProxyTargetStatus struct {
+	Target string `json:"target"`
+	// Requests counts every proxied request; Errors those the proxy failed
+	// itself, broken down by error code. Upstream error statuses are successes.
+	Requests   uint64               `json:"requests"`
+	Successes  uint64               `json:"successes"`
+	Errors     uint64               `json:"errors"`
+	ErrorCodes map[ErrorCode]uint64 `json:"error_codes,omitempty"`
+	Latency    LatencyHistogram     `json:"latency"`
+	// LastSeen is the unix time in milliseconds of the latest request.
+	LastSeen int64 `json:"last_seen"`
+}
+
+// LatencyHistogram is a cumulative histogram of request durations in seconds.
+type LatencyHistogram struct {
+	Buckets []LatencyBucket `json:"buckets"`
+	Count   uint64          `json:"count"`
+	Sum     float64         `json:"sum"`
+}
+
+// LatencyBucket counts the requests that completed within LE seconds.
+type LatencyBucket struct {
+	LE    float64 `json:"le"`
+	Count uint64  `json:"count"`
+}
@@ -0,0 +1,138 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	// inlineFieldName marks embedded structs whose fields are flattened into the parent JSON object.
+	inlineFieldName = "-inline-"
+	// languageTag validates a field against the languages set by SetLanguages.
+	languageTag = "language"
+)
+
+// FieldError describes a single invalid field of a request body.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError is returned by Validate when one or more fields are invalid.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// Error joins the field errors into a human-readable summary.
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		msgs = append(msgs, f.Field+" "+f.Message)
+	}
+	return strings.Join(msgs, "; ")
+}
+
+var structValidator = newStructValidator()
+
+// languages are the values fields tagged language accept.
+var languages []string
+
+// SetLanguages sets the languages fields tagged language accept. The
+// controller sets the languages of the runtime when it is initialized.
+func SetLanguages(names []string) {
+	languages = slices.Clone(names)
+}
+
+func newStructValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" && (field.Anonymous || strings.Contains(opts, "inline")) {
+			return inlineFieldName
+		}
+		return name
+	})
+	_ = v.RegisterValidation(languageTag, func(fl validator.FieldLevel) bool {
+		return slices.Contains(languages, fl.Field().String())
+	})
+	return v
+}
+
+// validateStruct validates v and reports failures as a *ValidationError keyed by JSON field paths.
+func validateStruct(v any) error {
+	err := structValidator.Struct(v)
+	if err == nil {
+		return nil
+	}
+
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		return err
+	}
+
+	fields := make([]FieldError, 0, len(errs))
+	for _, fe := range errs {
+		fields = append(fields, FieldError{
+			Field:   fieldPath(fe.Namespace()),
+			Message: fieldMessage(fe),
+		})
+	}
+	return &ValidationError{Fields: fields}
+}
+
+// fieldPath turns a validator namespace such as "RunCodeRequest.context.-inline-.language"
+// into the JSON path "context.language".
+func fieldPath(namespace string) string {
+	segments := strings.Split(namespace, ".")
+	path := make([]string, 0, len(segments))
+	for _, s := range segments[1:] {
+		if s == "" || s == inlineFieldName {
+			continue
+		}
+		path = append(path, s)
+	}
+	return strings.Join(path, ".")
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case languageTag:
+		return "must be one of: " + strings.Join(languages, ", ")
+	case "min":
+		if fe.Kind() == reflect.Slice || fe.Kind() == reflect.Map {
+			return fmt.Sprintf("must contain at least %s item(s)", fe.Param())
+		}
+		return "must be at least " + fe.Param()
+	case "oneof":
+		return "must be one of: " + strings.Join(strings.Fields(fe.Param()), ", ")
+	default:
+		if fe.Param() != "" {
+			return fmt.Sprintf("failed %s=%s validation", fe.Tag(), fe.Param())
+		}
+		return fmt.Sprintf("failed %s validation", fe.Tag())
+	}
+}
@@ -0,0 +1,25 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+const (
+	// APIVersionLegacy identifies the original unprefixed routes.
+	APIVersionLegacy = "v0"
+	// APIVersionV1 identifies routes served under /v1.
+	APIVersionV1 = "v1"
+
+	// APIVersionContextKey stores the route's API version in the gin context.
+	APIVersionContextKey = "execd.api_version"
+)
@@ -0,0 +1,25 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+// WaitResult answers GET /wait once the awaited port or file is ready.
+type WaitResult struct {
+	Port int    `json:"port,omitempty"`
+	Path string `json:"path,omitempty"`
+	// Elapsed is how long the wait took in milliseconds.
+	Elapsed int64 `json:"elapsed"`
+	// Attempts counts the readiness checks made.
+	Attempts int `json:"attempts"`
+}
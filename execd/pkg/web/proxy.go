@@ -15,21 +15,88 @@
 package web
 
 import (
-	"net"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 
+	"github.com/alibaba/opensandbox/execd/pkg/flag"
 	"github.com/alibaba/opensandbox/execd/pkg/log"
+	"github.com/alibaba/opensandbox/execd/pkg/web/model"
 )
 
-func ProxyMiddleware() gin.HandlerFunc {
+// proxyLogger logs proxied requests and their failures.
+var proxyLogger = log.For("proxy")
+
+// ProxyMiddleware forwards <basePath>/proxy/:target/... requests, where target is a local
+// port or an allowlisted host:port; /proxy/https/:target/... reaches the target over TLS.
+// GET <basePath>/proxy/status reports the targets proxied to recently.
+// It fails when the configured policy, TLS settings or injected headers are malformed.
+func ProxyMiddleware(basePath string) (gin.HandlerFunc, error) {
+	policy, err := newProxyPolicy(flag.ProxyAllowedPorts, flag.ProxyDeniedPorts, flag.ProxyAllowedHosts, flag.ServerPort)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy policy: %w", err)
+	}
+	transports, err := newProxyTransports(flag.ProxyTLSInsecure, flag.ProxyTLSCAFile, proxyTimeouts{
+		dial:           flag.ProxyDialTimeout,
+		responseHeader: flag.ProxyResponseHeaderTimeout,
+		idle:           flag.ProxyIdleTimeout,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy TLS settings: %w", err)
+	}
+	headers, err := parseInjectedHeaders(flag.ProxyInjectHeaders)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy header injection: %w", err)
+	}
+	origins, err := newOriginChecker(flag.WebSocketAllowedOrigins)
+	if err != nil {
+		return nil, fmt.Errorf("invalid websocket allowed origins: %w", err)
+	}
+	return proxyHandler(&proxyOptions{
+		basePath:       basePath,
+		policy:         policy,
+		transports:     transports,
+		injectBaseHref: flag.ProxyInjectBaseHref,
+		headers:        headers,
+		origins:        origins,
+		flushInterval:  flag.ProxyFlushInterval,
+		maxTimeout:     flag.ProxyMaxTimeout,
+	}), nil
+}
+
+// proxyOptions configures how proxyHandler reaches and rewrites upstreams.
+type proxyOptions struct {
+	basePath       string
+	policy         *proxyPolicy
+	transports     *proxyTransports
+	injectBaseHref bool
+	// headers are added to requests proxied to the keyed local port.
+	headers map[int]http.Header
+	// origins decides which websocket upgrades are tunneled; nil allows the same origin only.
+	origins *originChecker
+	// flushInterval applies to responses of known length; event streams and
+	// chunked responses are always flushed immediately by ReverseProxy.
+	flushInterval time.Duration
+	// maxTimeout caps the per-request X-Proxy-Timeout; zero leaves it uncapped.
+	maxTimeout time.Duration
+}
+
+func proxyHandler(opts *proxyOptions) gin.HandlerFunc {
+	basePath, policy, transports := opts.basePath, opts.policy, opts.transports
+	stats := newProxyStats()
 	return func(c *gin.Context) {
-		if !strings.HasPrefix(c.Request.URL.Path, "/proxy/") {
+		if !strings.HasPrefix(c.Request.URL.Path, basePath+"/proxy/") {
 			c.Next()
 			return
 		}
@@ -37,82 +104,223 @@ func ProxyMiddleware() gin.HandlerFunc {
 		r := c.Request
 		w := c.Writer
 
-		rest := strings.TrimPrefix(r.URL.Path, "/proxy/")
-		parts := strings.SplitN(rest, "/", 2)
-		if len(parts) == 0 || parts[0] == "" {
+		scheme, prefix := "http", basePath+"/proxy/"
+		// work on the escaped path so that encodings such as %2F reach the upstream.
+		rest, ok := strings.CutPrefix(r.URL.EscapedPath(), prefix)
+		if !ok {
+			// the client escaped part of the prefix itself, fall back to the canonical encoding.
+			rest = strings.TrimPrefix((&url.URL{Path: r.URL.Path}).EscapedPath(), prefix)
+		}
+		if after, ok := strings.CutPrefix(rest, "https/"); ok {
+			scheme, prefix, rest = "https", prefix+"https/", after
+		}
+		if rest == "status" && scheme == "http" && r.Method == http.MethodGet {
+			c.AbortWithStatusJSON(http.StatusOK, stats.status())
+			return
+		}
+		name, path, err := splitProxyPath(rest)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, model.ErrorResponse{
+				Code:    model.ErrorCodeInvalidRequest,
+				Message: err.Error(),
+			})
+			return
+		}
+		if name == "" {
 			http.Error(w, "port is required", http.StatusBadRequest)
 			c.Abort()
 			return
 		}
 
-		port := parts[0]
-		path := "/"
-		if len(parts) == 2 && parts[1] != "" {
-			path += parts[1]
+		if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") && !opts.origins.Allowed(r) {
+			c.AbortWithStatusJSON(http.StatusForbidden, model.ErrorResponse{
+				Code:    model.ErrorCodeOriginForbidden,
+				Message: fmt.Sprintf("websocket origin %s is not allowed", r.Header.Get("Origin")),
+			})
+			return
+		}
+
+		upstream, err := policy.resolve(r.Context(), name)
+		if err != nil {
+			var targetErr *proxyTargetError
+			if !errors.As(err, &targetErr) {
+				targetErr = &proxyTargetError{http.StatusBadGateway, model.ErrorCodeProxyTargetUnreachable, err.Error()}
+			}
+			c.AbortWithStatusJSON(targetErr.status, model.ErrorResponse{
+				Code:    targetErr.code,
+				Message: targetErr.message,
+			})
+			return
+		}
+
+		timeout, err := parseProxyTimeout(r.Header.Get(model.ProxyTimeoutHeader), opts.maxTimeout)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, model.ErrorResponse{
+				Code:    model.ErrorCodeInvalidRequest,
+				Message: err.Error(),
+			})
+			return
+		}
+
+		requestID := r.Header.Get(model.RequestIDHeader)
+		if requestID == "" {
+			requestID = uuid.New().String()
+			r.Header.Set(model.RequestIDHeader, requestID)
+		}
+		w.Header().Set(model.RequestIDHeader, requestID)
+
+		var failure model.ErrorCode
+		start := time.Now()
+		// deferred so that aborted streams, which ReverseProxy ends with a panic, are counted too.
+		defer func() { stats.record(name, failure, time.Since(start)) }()
+		fail := func(rw http.ResponseWriter, err error) {
+			var status int
+			status, failure = classifyProxyError(err)
+			writeProxyError(rw, status, model.ProxyErrorResponse{
+				ErrorResponse: model.ErrorResponse{
+					Code:    failure,
+					Message: fmt.Sprintf("proxy to %s failed: %v", name, err),
+				},
+				Target:    name,
+				RequestID: requestID,
+			})
 		}
 
 		target := &url.URL{
-			Scheme: "http",
-			Host:   "127.0.0.1:" + port,
-			Path:   path,
+			Scheme:  scheme,
+			Host:    upstream.host,
+			Path:    path.Path,
+			RawPath: path.RawPath,
 		}
 
 		isWebSocket := strings.ToLower(r.Header.Get("Upgrade")) == "websocket"
+		proxyLogger.Info("Proxy: %s %s -> %s://%s via %s (WebSocket: %v, request %s)", r.Method, r.RequestURI, scheme, upstream.host, upstream.addr, isWebSocket, requestID)
+
+		var injected http.Header
+		if upstream.local {
+			injected = opts.headers[upstream.port]
+		}
+
+		if isWebSocket {
+			var tlsConfig *tls.Config
+			if scheme == "https" {
+				tlsConfig = transports.clientTLSConfig(upstream)
+			}
+			handshake := timeout
+			if handshake == 0 {
+				handshake = websocketHandshakeTimeout
+			}
+			proxyWebSocket(w, r, target, upstream.addr, tlsConfig, injected, websocketTimeouts{
+				dial:      transports.timeouts.dial,
+				handshake: handshake,
+			}, fail)
+			c.Abort()
+			return
+		}
+
+		if timeout > 0 {
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+			r = r.WithContext(ctx)
+		}
 
 		proxy := httputil.NewSingleHostReverseProxy(target)
-		// Flush SSE chunks promptly; a small interval avoids buffering breaks chunked streams.
-		proxy.FlushInterval = 200 * time.Millisecond
+		proxy.FlushInterval = opts.flushInterval
 
 		proxy.Director = func(req *http.Request) {
-			req.URL.Scheme = "http"
-			req.URL.Host = "127.0.0.1:" + port
-			req.URL.Path = path
+			req.URL.Scheme = scheme
+			// dial the address resolved above rather than resolving the name again.
+			req.URL.Host = upstream.addr
+			if !upstream.local {
+				req.Host = upstream.host
+			}
+			req.URL.Path = path.Path
+			req.URL.RawPath = path.RawPath
 			req.URL.RawQuery = r.URL.RawQuery
-			req.URL.RawPath = ""
+			req.URL.ForceQuery = r.URL.ForceQuery
 			req.RequestURI = ""
 
-			req.Header.Set("X-Forwarded-For", getClientIP(r))
-			req.Header.Set("X-Forwarded-Proto", "http")
-			req.Header.Del("X-Forwarded-Host")
-
-			if isWebSocket {
-				req.Header.Set("Connection", "Upgrade")
-				req.Header.Set("Upgrade", "websocket")
-				req.Header.Set("Sec-WebSocket-Version", "13")
-				if key := r.Header.Get("Sec-WebSocket-Key"); key != "" {
-					req.Header.Set("Sec-WebSocket-Key", key)
-				}
-			}
+			// ReverseProxy appends the client address to X-Forwarded-For on its own.
+			prepareForwardedRequest(req, r, injected)
+			req.Header.Del(model.ProxyTimeoutHeader)
 		}
 
-		proxy.Transport = &http.Transport{
-			DialContext: (&net.Dialer{
-				Timeout:   600 * time.Second,
-				KeepAlive: 30 * time.Second,
-			}).DialContext,
-			MaxIdleConns:        100,
-			MaxIdleConnsPerHost: 100,
-			IdleConnTimeout:     600 * time.Second,
+		proxy.Transport = transports.get(scheme, upstream)
+
+		// keep redirects and cookies of apps unaware of the prefix inside the proxy.
+		rewrite := rewriteProxyResponse(prefix+name, opts.injectBaseHref)
+		proxy.ModifyResponse = func(resp *http.Response) error {
+			resp.Body = &streamErrorBody{ReadCloser: resp.Body, onError: func(err error) {
+				switch ctxErr := r.Context().Err(); {
+				case ctxErr == nil:
+					failure = model.ErrorCodeProxyStreamInterrupted
+				case errors.Is(ctxErr, context.DeadlineExceeded):
+					failure = model.ErrorCodeProxyTimeout
+				default:
+					// the client went away, the upstream is not to blame.
+					return
+				}
+				proxyLogger.Error("Proxy stream error: %v, request: %s %s (request %s)", err, r.Method, r.RequestURI, requestID)
+			}}
+			return rewrite(resp)
 		}
 
 		proxy.ErrorHandler = func(rw http.ResponseWriter, req *http.Request, err error) {
-			log.Error("Proxy error: %v, request: %s %s", err, req.Method, req.RequestURI)
-			http.Error(rw, "Bad Gateway", http.StatusBadGateway)
+			proxyLogger.Error("Proxy error: %v, request: %s %s (request %s)", err, req.Method, req.RequestURI, requestID)
+			fail(rw, err)
 		}
 
-		log.Info("Proxy: %s %s -> %s (WebSocket: %v)", r.Method, r.RequestURI, target.Host, isWebSocket)
-
 		proxy.ServeHTTP(w, r)
 		c.Abort()
 	}
 }
 
-func getClientIP(r *http.Request) string {
-	if ip := r.Header.Get("X-Forwarded-For"); ip != "" {
-		return strings.Split(ip, ",")[0]
+// splitProxyPath splits the escaped path following the proxy prefix into the decoded
+// target and the upstream path. A missing path, as in /proxy/8080, is the root; the
+// upstream path keeps the client's encoding and duplicate slashes in RawPath.
+func splitProxyPath(rest string) (string, *url.URL, error) {
+	rawTarget, rawPath, _ := strings.Cut(rest, "/")
+	target, err := url.PathUnescape(rawTarget)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid proxy target %q: %w", rawTarget, err)
+	}
+
+	rawPath = "/" + rawPath
+	path, err := url.PathUnescape(rawPath)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid proxy path %q: %w", rawPath, err)
+	}
+	return target, &url.URL{Path: path, RawPath: rawPath}, nil
+}
+
+// writeProxyError answers a failed upstream exchange with a JSON body.
+func writeProxyError(w http.ResponseWriter, status int, body model.ProxyErrorResponse) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// parseProxyTimeout reads an X-Proxy-Timeout value, a Go duration or a number of
+// seconds, clamped to limit when limit is set. An empty value means no timeout.
+func parseProxyTimeout(value string, limit time.Duration) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		seconds, convErr := strconv.Atoi(value)
+		if convErr != nil {
+			return 0, fmt.Errorf("invalid %s %q", model.ProxyTimeoutHeader, value)
+		}
+		timeout = time.Duration(seconds) * time.Second
+	}
+	if timeout <= 0 {
+		return 0, fmt.Errorf("invalid %s %q, must be positive", model.ProxyTimeoutHeader, value)
 	}
-	if ip := r.Header.Get("X-Real-IP"); ip != "" {
-		return ip
+	if limit > 0 && timeout > limit {
+		timeout = limit
 	}
-	return r.RemoteAddr
+	return timeout, nil
 }
@@ -0,0 +1,88 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/alibaba/opensandbox/execd/pkg/web/model"
+)
+
+// parseInjectedHeaders parses "PORT:Name=Value" entries separated by ";" into the
+// static headers added to requests proxied to each local port.
+func parseInjectedHeaders(spec string) (map[int]http.Header, error) {
+	headers := make(map[int]http.Header)
+	for _, item := range strings.Split(spec, ";") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		rawPort, header, ok := strings.Cut(item, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid header injection %q, expected PORT:Name=Value", item)
+		}
+		port, err := parsePort(strings.TrimSpace(rawPort))
+		if err != nil {
+			return nil, err
+		}
+		name, value, ok := strings.Cut(header, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid header injection %q, expected PORT:Name=Value", item)
+		}
+
+		if headers[port] == nil {
+			headers[port] = make(http.Header)
+		}
+		headers[port].Add(name, strings.TrimSpace(value))
+	}
+	return headers, nil
+}
+
+// prepareForwardedRequest readies the outgoing copy of r: execd credentials are
+// removed so user services never see them, forwarding headers describe the
+// original request and the configured static headers are applied.
+// X-Forwarded-For is left to the caller since ReverseProxy appends it itself.
+func prepareForwardedRequest(out, r *http.Request, injected http.Header) {
+	out.Header.Del(model.ApiAccessTokenHeader)
+	out.Header.Del(model.AdminTokenHeader)
+
+	out.Header.Set("X-Forwarded-Host", r.Host)
+	if r.TLS != nil {
+		out.Header.Set("X-Forwarded-Proto", "https")
+	} else {
+		out.Header.Set("X-Forwarded-Proto", "http")
+	}
+
+	for name, values := range injected {
+		out.Header[name] = append([]string(nil), values...)
+	}
+}
+
+// appendForwardedFor adds the client address of r to the X-Forwarded-For chain of out.
+func appendForwardedFor(out, r *http.Request) {
+	clientIP, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		clientIP = r.RemoteAddr
+	}
+	if prior := r.Header.Values("X-Forwarded-For"); len(prior) > 0 {
+		clientIP = strings.Join(prior, ", ") + ", " + clientIP
+	}
+	out.Header.Set("X-Forwarded-For", clientIP)
+}
@@ -0,0 +1,106 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const maxPort = 65535
+
+// portRange is an inclusive range of TCP ports.
+type portRange struct {
+	low, high int
+}
+
+// portPolicy decides which local ports the proxy may forward to.
+type portPolicy struct {
+	allowed []portRange
+	denied  []portRange
+}
+
+// newPortPolicy builds a policy from allow/deny specs. selfPort is always denied
+// so the proxy cannot loop back into execd.
+func newPortPolicy(allowedSpec, deniedSpec string, selfPort int) (*portPolicy, error) {
+	allowed, err := parsePortRanges(allowedSpec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid allowed ports %q: %w", allowedSpec, err)
+	}
+	denied, err := parsePortRanges(deniedSpec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid denied ports %q: %w", deniedSpec, err)
+	}
+	if selfPort > 0 {
+		denied = append(denied, portRange{low: selfPort, high: selfPort})
+	}
+	return &portPolicy{allowed: allowed, denied: denied}, nil
+}
+
+// permits reports whether port may be proxied; denials take precedence.
+func (p *portPolicy) permits(port int) bool {
+	if containsPort(p.denied, port) {
+		return false
+	}
+	return len(p.allowed) == 0 || containsPort(p.allowed, port)
+}
+
+// parsePort validates a port path segment.
+func parsePort(raw string) (int, error) {
+	port, err := strconv.Atoi(raw)
+	if err != nil || port < 1 || port > maxPort {
+		return 0, fmt.Errorf("invalid port %q: must be a number between 1 and %d", raw, maxPort)
+	}
+	return port, nil
+}
+
+// parsePortRanges parses a comma separated list of ports and ranges, e.g. "80,3000-3999".
+func parsePortRanges(spec string) ([]portRange, error) {
+	var ranges []portRange
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		lowRaw, highRaw, isRange := strings.Cut(item, "-")
+		low, err := parsePort(strings.TrimSpace(lowRaw))
+		if err != nil {
+			return nil, err
+		}
+		high := low
+		if isRange {
+			high, err = parsePort(strings.TrimSpace(highRaw))
+			if err != nil {
+				return nil, err
+			}
+			if high < low {
+				return nil, fmt.Errorf("invalid port range %q", item)
+			}
+		}
+		ranges = append(ranges, portRange{low: low, high: high})
+	}
+	return ranges, nil
+}
+
+func containsPort(ranges []portRange, port int) bool {
+	for _, r := range ranges {
+		if port >= r.low && port <= r.high {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxBaseHrefBody caps the HTML documents buffered for base-href injection.
+const maxBaseHrefBody = 8 << 20
+
+var (
+	headTagPattern = regexp.MustCompile(`(?i)<head(\s[^>]*)?>`)
+	baseTagPattern = regexp.MustCompile(`(?i)<base[\s>]`)
+)
+
+// rewriteProxyResponse returns a ModifyResponse hook keeping upstream redirects and
+// cookies under prefix (e.g. /proxy/3000), optionally injecting a <base> tag into HTML.
+func rewriteProxyResponse(prefix string, injectBaseHref bool) func(*http.Response) error {
+	return func(resp *http.Response) error {
+		if location := resp.Header.Get("Location"); location != "" {
+			resp.Header.Set("Location", prefixPath(prefix, location))
+		}
+
+		if cookies := resp.Header.Values("Set-Cookie"); len(cookies) > 0 {
+			resp.Header.Del("Set-Cookie")
+			for _, cookie := range cookies {
+				resp.Header.Add("Set-Cookie", rewriteCookiePath(prefix, cookie))
+			}
+		}
+
+		if injectBaseHref {
+			return injectBaseTag(resp, prefix+"/")
+		}
+		return nil
+	}
+}
+
+// prefixPath prepends prefix to absolute paths; URLs and relative paths are kept.
+func prefixPath(prefix, path string) string {
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") {
+		return path
+	}
+	if path == prefix || strings.HasPrefix(path, prefix+"/") {
+		return path
+	}
+	return prefix + path
+}
+
+// rewriteCookiePath moves the Path attribute of a Set-Cookie value under prefix.
+func rewriteCookiePath(prefix, cookie string) string {
+	attrs := strings.Split(cookie, ";")
+	for i, attr := range attrs {
+		key, value, ok := strings.Cut(strings.TrimSpace(attr), "=")
+		if !ok || !strings.EqualFold(strings.TrimSpace(key), "path") {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if value == "/" {
+			value = prefix + "/"
+		} else {
+			value = prefixPath(prefix, value)
+		}
+		attrs[i] = " Path=" + value
+	}
+	return strings.Join(attrs, ";")
+}
+
+// injectBaseTag adds <base href> to uncompressed HTML documents lacking one, so
+// absolute asset URLs resolve through the proxy.
+func injectBaseTag(resp *http.Response, href string) error {
+	contentType := resp.Header.Get("Content-Type")
+	if !strings.HasPrefix(strings.ToLower(contentType), "text/html") {
+		return nil
+	}
+	if encoding := resp.Header.Get("Content-Encoding"); encoding != "" && encoding != "identity" {
+		return nil
+	}
+	if resp.ContentLength > maxBaseHrefBody {
+		return nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBaseHrefBody+1))
+	if err != nil {
+		return err
+	}
+	if len(body) > maxBaseHrefBody {
+		// too large to rewrite; hand back what was buffered followed by the rest of the stream.
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return nil
+	}
+	_ = resp.Body.Close()
+
+	if !baseTagPattern.Match(body) {
+		if loc := headTagPattern.FindIndex(body); loc != nil {
+			tag := []byte(`<base href="` + href + `">`)
+			body = append(body[:loc[1]:loc[1]], append(tag, body[loc[1]:]...)...)
+		}
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	resp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	return nil
+}
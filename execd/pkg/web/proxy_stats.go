@@ -0,0 +1,156 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/alibaba/opensandbox/execd/pkg/web/model"
+)
+
+// proxyStatusWindow is how long a target stays listed after its last request.
+const proxyStatusWindow = 15 * time.Minute
+
+// proxyLatencyBuckets are the upper bounds, in seconds, of the latency histograms.
+var proxyLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// proxyStats counts the requests proxied to each target.
+type proxyStats struct {
+	mu      sync.Mutex
+	targets map[string]*proxyTargetStats
+}
+
+// proxyTargetStats holds the counters of a single target.
+type proxyTargetStats struct {
+	requests   uint64
+	errors     uint64
+	errorCodes map[model.ErrorCode]uint64
+	// buckets counts requests per latency bucket, the last one being +Inf.
+	buckets  []uint64
+	sum      float64
+	lastSeen time.Time
+}
+
+func newProxyStats() *proxyStats {
+	return &proxyStats{targets: make(map[string]*proxyTargetStats)}
+}
+
+// record accounts one request to target; code is empty when it was proxied successfully.
+func (s *proxyStats) record(target string, code model.ErrorCode, elapsed time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats, ok := s.targets[target]
+	if !ok {
+		stats = &proxyTargetStats{
+			errorCodes: make(map[model.ErrorCode]uint64),
+			buckets:    make([]uint64, len(proxyLatencyBuckets)+1),
+		}
+		s.targets[target] = stats
+	}
+
+	stats.requests++
+	if code != "" {
+		stats.errors++
+		stats.errorCodes[code]++
+	}
+	seconds := elapsed.Seconds()
+	stats.buckets[sort.SearchFloat64s(proxyLatencyBuckets, seconds)]++
+	stats.sum += seconds
+	stats.lastSeen = time.Now()
+}
+
+// status lists the targets seen within proxyStatusWindow, forgetting older ones.
+func (s *proxyStats) status() model.ProxyStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := model.ProxyStatus{Targets: make([]model.ProxyTargetStatus, 0, len(s.targets))}
+	for target, stats := range s.targets {
+		if time.Since(stats.lastSeen) > proxyStatusWindow {
+			delete(s.targets, target)
+			continue
+		}
+
+		histogram := model.LatencyHistogram{
+			Buckets: make([]model.LatencyBucket, 0, len(proxyLatencyBuckets)),
+			Count:   stats.requests,
+			Sum:     stats.sum,
+		}
+		var cumulative uint64
+		for i, le := range proxyLatencyBuckets {
+			cumulative += stats.buckets[i]
+			histogram.Buckets = append(histogram.Buckets, model.LatencyBucket{LE: le, Count: cumulative})
+		}
+
+		var codes map[model.ErrorCode]uint64
+		if len(stats.errorCodes) > 0 {
+			codes = make(map[model.ErrorCode]uint64, len(stats.errorCodes))
+			for code, count := range stats.errorCodes {
+				codes[code] = count
+			}
+		}
+
+		status.Targets = append(status.Targets, model.ProxyTargetStatus{
+			Target:     target,
+			Requests:   stats.requests,
+			Successes:  stats.requests - stats.errors,
+			Errors:     stats.errors,
+			ErrorCodes: codes,
+			Latency:    histogram,
+			LastSeen:   stats.lastSeen.UnixMilli(),
+		})
+	}
+	sort.Slice(status.Targets, func(i, j int) bool { return status.Targets[i].Target < status.Targets[j].Target })
+	return status
+}
+
+// classifyProxyError maps a failed upstream exchange to its status and error code.
+func classifyProxyError(err error) (int, model.ErrorCode) {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()):
+		return http.StatusGatewayTimeout, model.ErrorCodeProxyTimeout
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return http.StatusBadGateway, model.ErrorCodeProxyConnectRefused
+	case errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET):
+		// the upstream accepted the connection but dropped it before answering.
+		return http.StatusBadGateway, model.ErrorCodeProxyStreamInterrupted
+	default:
+		return http.StatusBadGateway, model.ErrorCodeProxyTargetUnreachable
+	}
+}
+
+// streamErrorBody reports read errors of an upstream body that is already being relayed.
+type streamErrorBody struct {
+	io.ReadCloser
+	onError func(error)
+}
+
+func (b *streamErrorBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && !errors.Is(err, io.EOF) {
+		b.onError(err)
+	}
+	return n, err
+}
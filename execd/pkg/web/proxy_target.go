@@ -0,0 +1,194 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/alibaba/opensandbox/execd/pkg/web/model"
+)
+
+// proxyTarget is the upstream a proxied request is forwarded to.
+type proxyTarget struct {
+	// host is the host:port named in the request path and sent as Host header.
+	host string
+	// addr is the ip:port to dial. It is fixed when the target is resolved so a
+	// later DNS answer cannot redirect the connection elsewhere.
+	addr string
+	// port is the upstream port.
+	port int
+	// local reports whether the target is a service on this machine.
+	local bool
+}
+
+// proxyTargetError carries the HTTP status and error code for a rejected target.
+type proxyTargetError struct {
+	status  int
+	code    model.ErrorCode
+	message string
+}
+
+func (e *proxyTargetError) Error() string {
+	return e.message
+}
+
+// hostPolicy lists the remote hosts the proxy may reach, by name or network.
+type hostPolicy struct {
+	names    map[string]struct{}
+	networks []*net.IPNet
+}
+
+// parseHostPolicy parses a comma separated list of hostnames, IPs and CIDRs.
+func parseHostPolicy(spec string) (*hostPolicy, error) {
+	policy := &hostPolicy{names: make(map[string]struct{})}
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if item == "" {
+			continue
+		}
+
+		if strings.Contains(item, "/") {
+			_, network, err := net.ParseCIDR(item)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CIDR %q: %w", item, err)
+			}
+			policy.networks = append(policy.networks, network)
+			continue
+		}
+		if ip := net.ParseIP(item); ip != nil {
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			policy.networks = append(policy.networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		policy.names[item] = struct{}{}
+	}
+	return policy, nil
+}
+
+func (h *hostPolicy) allowsName(name string) bool {
+	_, ok := h.names[strings.ToLower(name)]
+	return ok
+}
+
+func (h *hostPolicy) allowsIP(ip net.IP) bool {
+	for _, network := range h.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// proxyPolicy resolves /proxy targets and enforces the port and host policies.
+type proxyPolicy struct {
+	ports *portPolicy
+	hosts *hostPolicy
+	// lookup resolves hostnames, replaceable in tests.
+	lookup func(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// newProxyPolicy builds the policy from the flag values.
+func newProxyPolicy(allowedPorts, deniedPorts, allowedHosts string, selfPort int) (*proxyPolicy, error) {
+	ports, err := newPortPolicy(allowedPorts, deniedPorts, selfPort)
+	if err != nil {
+		return nil, err
+	}
+	hosts, err := parseHostPolicy(allowedHosts)
+	if err != nil {
+		return nil, fmt.Errorf("invalid allowed hosts %q: %w", allowedHosts, err)
+	}
+	return &proxyPolicy{ports: ports, hosts: hosts, lookup: net.DefaultResolver.LookupIPAddr}, nil
+}
+
+// resolve turns the target path segment, a port or host:port, into a dialable target.
+func (p *proxyPolicy) resolve(ctx context.Context, raw string) (*proxyTarget, error) {
+	host, rawPort := "", raw
+	if strings.Contains(raw, ":") {
+		var err error
+		host, rawPort, err = net.SplitHostPort(raw)
+		if err != nil || host == "" {
+			return nil, &proxyTargetError{http.StatusBadRequest, model.ErrorCodeInvalidProxyTarget, fmt.Sprintf("invalid proxy target %q", raw)}
+		}
+	}
+
+	port, err := parsePort(rawPort)
+	if err != nil {
+		return nil, &proxyTargetError{http.StatusBadRequest, model.ErrorCodeInvalidProxyPort, err.Error()}
+	}
+
+	if host == "" || strings.EqualFold(host, "localhost") || isLoopback(host) {
+		if !p.ports.permits(port) {
+			return nil, &proxyTargetError{http.StatusForbidden, model.ErrorCodeProxyPortForbidden, fmt.Sprintf("proxying to port %d is not allowed", port)}
+		}
+		addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+		return &proxyTarget{host: addr, addr: addr, port: port, local: true}, nil
+	}
+
+	ip, err := p.resolveHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	return &proxyTarget{
+		host: net.JoinHostPort(host, strconv.Itoa(port)),
+		addr: net.JoinHostPort(ip.String(), strconv.Itoa(port)),
+		port: port,
+	}, nil
+}
+
+// resolveHost returns an allowed address of a remote host. Loopback and
+// unspecified addresses are never returned, so a rebinding DNS answer can't
+// sneak past the local port policy.
+func (p *proxyPolicy) resolveHost(ctx context.Context, host string) (net.IP, error) {
+	forbidden := &proxyTargetError{http.StatusForbidden, model.ErrorCodeProxyTargetForbidden, fmt.Sprintf("proxying to host %s is not allowed", host)}
+
+	if ip := net.ParseIP(host); ip != nil {
+		if ip.IsUnspecified() || !p.hosts.allowsIP(ip) {
+			return nil, forbidden
+		}
+		return ip, nil
+	}
+
+	nameAllowed := p.hosts.allowsName(host)
+	if !nameAllowed && len(p.hosts.networks) == 0 {
+		return nil, forbidden
+	}
+
+	addrs, err := p.lookup(ctx, host)
+	if err != nil {
+		return nil, &proxyTargetError{http.StatusBadGateway, model.ErrorCodeProxyTargetUnreachable, fmt.Sprintf("failed to resolve %s: %v", host, err)}
+	}
+	for _, addr := range addrs {
+		if addr.IP.IsLoopback() || addr.IP.IsUnspecified() {
+			continue
+		}
+		if nameAllowed || p.hosts.allowsIP(addr.IP) {
+			return addr.IP, nil
+		}
+	}
+	return nil, forbidden
+}
+
+func isLoopback(host string) bool {
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
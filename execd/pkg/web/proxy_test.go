@@ -0,0 +1,1010 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+
+	"github.com/alibaba/opensandbox/execd/pkg/flag"
+	"github.com/alibaba/opensandbox/execd/pkg/web/model"
+)
+
+// newProxyServer serves ProxyMiddleware the same way NewRouter mounts it.
+func newProxyServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	gin.SetMode(gin.ReleaseMode)
+	r := gin.New()
+	r.Use(mustProxyMiddleware(t))
+	return httptest.NewServer(r)
+}
+
+// mustProxyMiddleware builds ProxyMiddleware from the current flags.
+func mustProxyMiddleware(t *testing.T) gin.HandlerFunc {
+	t.Helper()
+	proxy, err := ProxyMiddleware("")
+	if err != nil {
+		t.Fatalf("ProxyMiddleware returned error: %v", err)
+	}
+	return proxy
+}
+
+// proxiedWebSocketURL returns the proxy URL reaching path on the upstream server.
+func proxiedWebSocketURL(t *testing.T, proxy, upstream *httptest.Server, path string) string {
+	t.Helper()
+	u, err := url.Parse(upstream.URL)
+	if err != nil {
+		t.Fatalf("parse upstream url: %v", err)
+	}
+	return "ws" + strings.TrimPrefix(proxy.URL, "http") + "/proxy/" + u.Port() + path
+}
+
+func TestProxyWebSocketEchoAndClose(t *testing.T) {
+	upgrader := websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+	gotQuery := make(chan string, 1)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ws" {
+			t.Errorf("unexpected upstream path: %s", r.URL.Path)
+		}
+		gotQuery <- r.URL.RawQuery
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade: %v", err)
+			return
+		}
+		defer conn.Close()
+		for {
+			mt, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := conn.WriteMessage(mt, data); err != nil {
+				return
+			}
+		}
+	}))
+	defer upstream.Close()
+
+	proxy := newProxyServer(t)
+	defer proxy.Close()
+
+	conn, resp, err := websocket.DefaultDialer.Dial(proxiedWebSocketURL(t, proxy, upstream, "/ws?token=abc"), nil)
+	if err != nil {
+		t.Fatalf("dial through proxy: %v", err)
+	}
+	defer conn.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("expected 101, got %d", resp.StatusCode)
+	}
+	if q := <-gotQuery; q != "token=abc" {
+		t.Fatalf("expected query to be forwarded, got %q", q)
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for _, msg := range []string{"hello", "world"} {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		if string(data) != msg {
+			t.Fatalf("expected echo %q, got %q", msg, data)
+		}
+	}
+
+	// the upstream echoes the close frame, which must travel back through the tunnel.
+	closeMsg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "bye")
+	if err := conn.WriteControl(websocket.CloseMessage, closeMsg, time.Now().Add(time.Second)); err != nil {
+		t.Fatalf("write close: %v", err)
+	}
+	_, _, err = conn.ReadMessage()
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) || closeErr.Code != websocket.CloseNormalClosure {
+		t.Fatalf("expected normal close frame, got %v", err)
+	}
+}
+
+func TestProxyWebSocketUpstreamClosePropagates(t *testing.T) {
+	upgrader := websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade: %v", err)
+			return
+		}
+		defer conn.Close()
+		msg := websocket.FormatCloseMessage(websocket.CloseGoingAway, "shutting down")
+		_ = conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+	}))
+	defer upstream.Close()
+
+	proxy := newProxyServer(t)
+	defer proxy.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial(proxiedWebSocketURL(t, proxy, upstream, "/"), nil)
+	if err != nil {
+		t.Fatalf("dial through proxy: %v", err)
+	}
+	defer conn.Close()
+
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, _, err = conn.ReadMessage()
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) || closeErr.Code != websocket.CloseGoingAway {
+		t.Fatalf("expected going-away close frame, got %v", err)
+	}
+}
+
+func TestProxyWebSocketRejectedUpgrade(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+	}))
+	defer upstream.Close()
+
+	proxy := newProxyServer(t)
+	defer proxy.Close()
+
+	_, resp, err := websocket.DefaultDialer.Dial(proxiedWebSocketURL(t, proxy, upstream, "/"), nil)
+	if err == nil {
+		t.Fatalf("expected handshake to fail")
+	}
+	if resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected upstream status to be relayed, got %+v", resp)
+	}
+}
+
+func TestParsePortRanges(t *testing.T) {
+	ranges, err := parsePortRanges(" 80, 3000-3999 ,,8080")
+	if err != nil {
+		t.Fatalf("parsePortRanges returned error: %v", err)
+	}
+	expected := []portRange{{80, 80}, {3000, 3999}, {8080, 8080}}
+	if len(ranges) != len(expected) {
+		t.Fatalf("unexpected ranges: %+v", ranges)
+	}
+	for i := range expected {
+		if ranges[i] != expected[i] {
+			t.Fatalf("unexpected range %d: got %+v want %+v", i, ranges[i], expected[i])
+		}
+	}
+
+	for _, spec := range []string{"abc", "0", "65536", "3000-2000", "1-x"} {
+		if _, err := parsePortRanges(spec); err == nil {
+			t.Fatalf("expected error for %q", spec)
+		}
+	}
+}
+
+func TestProxyRejectsDisallowedPorts(t *testing.T) {
+	oldAllowed, oldDenied, oldPort := flag.ProxyAllowedPorts, flag.ProxyDeniedPorts, flag.ServerPort
+	defer func() {
+		flag.ProxyAllowedPorts, flag.ProxyDeniedPorts, flag.ServerPort = oldAllowed, oldDenied, oldPort
+	}()
+	flag.ProxyAllowedPorts = "3000-3999"
+	flag.ProxyDeniedPorts = "3306"
+	flag.ServerPort = 3500
+
+	gin.SetMode(gin.ReleaseMode)
+	r := gin.New()
+	r.Use(mustProxyMiddleware(t))
+
+	cases := []struct {
+		path   string
+		status int
+		code   model.ErrorCode
+	}{
+		{"/proxy/abc/", http.StatusBadRequest, model.ErrorCodeInvalidProxyPort},
+		{"/proxy/70000/", http.StatusBadRequest, model.ErrorCodeInvalidProxyPort},
+		{"/proxy/8080/", http.StatusForbidden, model.ErrorCodeProxyPortForbidden},
+		{"/proxy/3306/", http.StatusForbidden, model.ErrorCodeProxyPortForbidden},
+		{"/proxy/3500/", http.StatusForbidden, model.ErrorCodeProxyPortForbidden},
+	}
+	for _, tc := range cases {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		if rec.Code != tc.status {
+			t.Fatalf("%s: expected status %d, got %d", tc.path, tc.status, rec.Code)
+		}
+		var resp model.ErrorResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%s: expected structured error, got %q", tc.path, rec.Body.String())
+		}
+		if resp.Code != tc.code {
+			t.Fatalf("%s: expected code %s, got %s", tc.path, tc.code, resp.Code)
+		}
+	}
+}
+
+func TestProxyPolicyResolve(t *testing.T) {
+	policy, err := newProxyPolicy("", "", "browser, rebind, 10.0.0.0/8", 44772)
+	if err != nil {
+		t.Fatalf("newProxyPolicy returned error: %v", err)
+	}
+	policy.lookup = func(_ context.Context, host string) ([]net.IPAddr, error) {
+		switch host {
+		case "browser":
+			return []net.IPAddr{{IP: net.ParseIP("172.18.0.5")}}, nil
+		case "rebind":
+			return []net.IPAddr{{IP: net.ParseIP("127.0.0.1")}}, nil
+		case "internal":
+			return []net.IPAddr{{IP: net.ParseIP("10.1.2.3")}}, nil
+		}
+		return nil, errors.New("no such host")
+	}
+
+	cases := []struct {
+		raw    string
+		host   string
+		addr   string
+		local  bool
+		status int
+	}{
+		{raw: "3000", host: "127.0.0.1:3000", addr: "127.0.0.1:3000", local: true},
+		{raw: "localhost:3000", host: "127.0.0.1:3000", addr: "127.0.0.1:3000", local: true},
+		{raw: "browser:9222", host: "browser:9222", addr: "172.18.0.5:9222"},
+		{raw: "internal:80", host: "internal:80", addr: "10.1.2.3:80"},
+		{raw: "10.9.9.9:80", host: "10.9.9.9:80", addr: "10.9.9.9:80"},
+		{raw: "44772", status: http.StatusForbidden},
+		{raw: "rebind:80", status: http.StatusForbidden},
+		{raw: "8.8.8.8:53", status: http.StatusForbidden},
+		{raw: "unknown:80", status: http.StatusBadGateway},
+		{raw: "browser:99999", status: http.StatusBadRequest},
+		{raw: ":80", status: http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		target, err := policy.resolve(context.Background(), tc.raw)
+		if tc.status != 0 {
+			var targetErr *proxyTargetError
+			if !errors.As(err, &targetErr) || targetErr.status != tc.status {
+				t.Fatalf("%s: expected status %d, got %v", tc.raw, tc.status, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.raw, err)
+		}
+		if target.host != tc.host || target.addr != tc.addr || target.local != tc.local {
+			t.Fatalf("%s: unexpected target %+v", tc.raw, target)
+		}
+	}
+}
+
+func TestProxyPolicyWithoutAllowedHostsStaysLocal(t *testing.T) {
+	policy, err := newProxyPolicy("", "", "", 0)
+	if err != nil {
+		t.Fatalf("newProxyPolicy returned error: %v", err)
+	}
+	policy.lookup = func(context.Context, string) ([]net.IPAddr, error) {
+		t.Fatalf("no lookup expected without allowed hosts")
+		return nil, nil
+	}
+
+	_, err = policy.resolve(context.Background(), "browser:9222")
+	var targetErr *proxyTargetError
+	if !errors.As(err, &targetErr) || targetErr.code != model.ErrorCodeProxyTargetForbidden {
+		t.Fatalf("expected forbidden target, got %v", err)
+	}
+}
+
+// newProxyHandlerServer serves proxyHandler with the default local-only policy,
+// letting configure adjust the options.
+func newProxyHandlerServer(t *testing.T, caFile string, configure func(*proxyOptions)) *httptest.Server {
+	t.Helper()
+	policy, err := newProxyPolicy("", "", "", 0)
+	if err != nil {
+		t.Fatalf("newProxyPolicy returned error: %v", err)
+	}
+	transports, err := newProxyTransports(false, caFile, proxyTimeouts{})
+	if err != nil {
+		t.Fatalf("newProxyTransports returned error: %v", err)
+	}
+	opts := &proxyOptions{policy: policy, transports: transports}
+	if configure != nil {
+		configure(opts)
+	}
+	gin.SetMode(gin.ReleaseMode)
+	r := gin.New()
+	r.Use(proxyHandler(opts))
+	return httptest.NewServer(r)
+}
+
+// newRewriteUpstream serves redirects, cookies and an HTML page to exercise response rewriting.
+func newRewriteUpstream() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/moved":
+			http.Redirect(w, r, "/login", http.StatusMovedPermanently)
+		case "/found":
+			http.Redirect(w, r, "/next?step=2", http.StatusFound)
+		case "/external":
+			http.Redirect(w, r, "https://example.com/login", http.StatusFound)
+		case "/cookies":
+			w.Header().Add("Set-Cookie", "sid=1; Path=/; HttpOnly")
+			w.Header().Add("Set-Cookie", "pref=dark; path=/settings; Max-Age=60")
+			w.Header().Add("Set-Cookie", "plain=1")
+			w.WriteHeader(http.StatusOK)
+		case "/page":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_, _ = w.Write([]byte(`<html><head><title>app</title></head><body><script src="/app.js"></script></body></html>`))
+		case "/large":
+			// streamed without a length, so only the body size can rule out rewriting.
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = io.WriteString(w, "<html><head></head><body>")
+			w.(http.Flusher).Flush()
+			_, _ = w.Write(bytes.Repeat([]byte("x"), maxBaseHrefBody+1024))
+			_, _ = io.WriteString(w, "</body></html>")
+		}
+	}))
+}
+
+func TestProxyRewritesRedirectsAndCookies(t *testing.T) {
+	upstream := newRewriteUpstream()
+	defer upstream.Close()
+
+	proxy := newProxyServer(t)
+	defer proxy.Close()
+
+	u, _ := url.Parse(upstream.URL)
+	prefix := "/proxy/" + u.Port()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+
+	redirects := []struct {
+		path     string
+		status   int
+		location string
+	}{
+		{"/moved", http.StatusMovedPermanently, prefix + "/login"},
+		{"/found", http.StatusFound, prefix + "/next?step=2"},
+		{"/external", http.StatusFound, "https://example.com/login"},
+	}
+	for _, tc := range redirects {
+		resp, err := client.Get(proxy.URL + prefix + tc.path)
+		if err != nil {
+			t.Fatalf("%s: request failed: %v", tc.path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tc.status {
+			t.Fatalf("%s: expected status %d, got %d", tc.path, tc.status, resp.StatusCode)
+		}
+		if got := resp.Header.Get("Location"); got != tc.location {
+			t.Fatalf("%s: expected Location %q, got %q", tc.path, tc.location, got)
+		}
+	}
+
+	resp, err := client.Get(proxy.URL + prefix + "/cookies")
+	if err != nil {
+		t.Fatalf("cookies request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	cookies := resp.Header.Values("Set-Cookie")
+	expected := []string{
+		"sid=1; Path=" + prefix + "/; HttpOnly",
+		"pref=dark; Path=" + prefix + "/settings; Max-Age=60",
+		"plain=1",
+	}
+	if len(cookies) != len(expected) {
+		t.Fatalf("expected %d cookies, got %v", len(expected), cookies)
+	}
+	for i := range expected {
+		if cookies[i] != expected[i] {
+			t.Fatalf("cookie %d: expected %q, got %q", i, expected[i], cookies[i])
+		}
+	}
+}
+
+func TestProxyInjectsBaseHref(t *testing.T) {
+	upstream := newRewriteUpstream()
+	defer upstream.Close()
+
+	proxy := newProxyHandlerServer(t, "", func(opts *proxyOptions) { opts.injectBaseHref = true })
+	defer proxy.Close()
+
+	u, _ := url.Parse(upstream.URL)
+	prefix := "/proxy/" + u.Port()
+	resp, err := http.Get(proxy.URL + prefix + "/page")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	if !strings.Contains(string(body), `<head><base href="`+prefix+`/"><title>`) {
+		t.Fatalf("expected base tag after <head>, got %s", body)
+	}
+	if resp.ContentLength != int64(len(body)) {
+		t.Fatalf("expected Content-Length %d, got %d", len(body), resp.ContentLength)
+	}
+}
+
+func TestProxyStreamsOversizedHTMLUntouched(t *testing.T) {
+	upstream := newRewriteUpstream()
+	defer upstream.Close()
+
+	proxy := newProxyHandlerServer(t, "", func(opts *proxyOptions) { opts.injectBaseHref = true })
+	defer proxy.Close()
+
+	u, _ := url.Parse(upstream.URL)
+	resp, err := http.Get(proxy.URL + "/proxy/" + u.Port() + "/large")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+
+	want := len("<html><head></head><body>") + maxBaseHrefBody + 1024 + len("</body></html>")
+	if len(body) != want {
+		t.Fatalf("expected the full %d byte body, got %d bytes", want, len(body))
+	}
+	if !bytes.HasSuffix(body, []byte("</body></html>")) || bytes.Contains(body, []byte("<base ")) {
+		t.Fatalf("oversized page must be passed through unchanged")
+	}
+}
+
+// newTLSProxyServer proxies with a transport pool trusting the given upstream's certificate.
+func newTLSProxyServer(t *testing.T, upstream *httptest.Server) *httptest.Server {
+	t.Helper()
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	block := &pem.Block{Type: "CERTIFICATE", Bytes: upstream.Certificate().Raw}
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatalf("write CA bundle: %v", err)
+	}
+
+	return newProxyHandlerServer(t, caFile, nil)
+}
+
+func TestProxyHTTPSUpstream(t *testing.T) {
+	upstream := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/moved" {
+			http.Redirect(w, r, "/login", http.StatusFound)
+			return
+		}
+		_, _ = io.WriteString(w, "secure "+r.URL.Path)
+	}))
+	defer upstream.Close()
+
+	u, _ := url.Parse(upstream.URL)
+	prefix := "/proxy/https/" + u.Port()
+
+	proxy := newTLSProxyServer(t, upstream)
+	defer proxy.Close()
+
+	resp, err := http.Get(proxy.URL + prefix + "/hello")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != "secure /hello" {
+		t.Fatalf("unexpected response %d: %s", resp.StatusCode, body)
+	}
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err = client.Get(proxy.URL + prefix + "/moved")
+	if err != nil {
+		t.Fatalf("redirect request failed: %v", err)
+	}
+	resp.Body.Close()
+	if got := resp.Header.Get("Location"); got != prefix+"/login" {
+		t.Fatalf("expected Location %q, got %q", prefix+"/login", got)
+	}
+
+	// without the CA bundle the self-signed upstream is rejected.
+	untrusted := newProxyServer(t)
+	defer untrusted.Close()
+	resp, err = http.Get(untrusted.URL + prefix + "/hello")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadGateway {
+		t.Fatalf("expected 502 for untrusted upstream, got %d", resp.StatusCode)
+	}
+}
+
+func TestProxyWebSocketOverTLS(t *testing.T) {
+	upgrader := websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+	upstream := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade: %v", err)
+			return
+		}
+		defer conn.Close()
+		mt, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		_ = conn.WriteMessage(mt, data)
+	}))
+	defer upstream.Close()
+
+	proxy := newTLSProxyServer(t, upstream)
+	defer proxy.Close()
+
+	u, _ := url.Parse(upstream.URL)
+	target := "ws" + strings.TrimPrefix(proxy.URL, "http") + "/proxy/https/" + u.Port() + "/ws"
+	conn, _, err := websocket.DefaultDialer.Dial(target, nil)
+	if err != nil {
+		t.Fatalf("dial through proxy: %v", err)
+	}
+	defer conn.Close()
+
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if err := conn.WriteMessage(websocket.TextMessage, []byte("over tls")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if string(data) != "over tls" {
+		t.Fatalf("expected echo %q, got %q", "over tls", data)
+	}
+}
+
+func TestParseInjectedHeaders(t *testing.T) {
+	headers, err := parseInjectedHeaders(" 3000:Authorization=Bearer a=b ; 3000:X-Team=core;8080:X-Token=t ")
+	if err != nil {
+		t.Fatalf("parseInjectedHeaders returned error: %v", err)
+	}
+	if got := headers[3000].Get("Authorization"); got != "Bearer a=b" {
+		t.Fatalf("unexpected Authorization header: %q", got)
+	}
+	if got := headers[3000].Get("X-Team"); got != "core" {
+		t.Fatalf("unexpected X-Team header: %q", got)
+	}
+	if got := headers[8080].Get("X-Token"); got != "t" {
+		t.Fatalf("unexpected X-Token header: %q", got)
+	}
+
+	for _, spec := range []string{"Authorization=x", "3000:=x", "3000:Authorization", "70000:A=b"} {
+		if _, err := parseInjectedHeaders(spec); err == nil {
+			t.Fatalf("expected error for %q", spec)
+		}
+	}
+}
+
+func TestProxyStripsCredentialsAndForwardsHeaders(t *testing.T) {
+	upgrader := websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+	received := make(chan http.Header, 2)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Clone()
+		if r.URL.Path == "/ws" {
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err == nil {
+				conn.Close()
+			}
+		}
+	}))
+	defer upstream.Close()
+	u, _ := url.Parse(upstream.URL)
+
+	port, _ := parsePort(u.Port())
+	proxy := newProxyHandlerServer(t, "", func(opts *proxyOptions) {
+		opts.headers = map[int]http.Header{port: {"Authorization": {"Bearer upstream"}}}
+	})
+	defer proxy.Close()
+	proxyURL, _ := url.Parse(proxy.URL)
+
+	check := func(kind string, header http.Header) {
+		t.Helper()
+		if v := header.Get(model.ApiAccessTokenHeader); v != "" {
+			t.Fatalf("%s: access token leaked to upstream: %q", kind, v)
+		}
+		if v := header.Get(model.AdminTokenHeader); v != "" {
+			t.Fatalf("%s: admin token leaked to upstream: %q", kind, v)
+		}
+		if v := header.Get("X-Forwarded-For"); v != "203.0.113.7, 127.0.0.1" {
+			t.Fatalf("%s: unexpected X-Forwarded-For: %q", kind, v)
+		}
+		if v := header.Get("X-Forwarded-Host"); v != proxyURL.Host {
+			t.Fatalf("%s: unexpected X-Forwarded-Host: %q", kind, v)
+		}
+		if v := header.Get("X-Forwarded-Proto"); v != "http" {
+			t.Fatalf("%s: unexpected X-Forwarded-Proto: %q", kind, v)
+		}
+		if v := header.Get("Authorization"); v != "Bearer upstream" {
+			t.Fatalf("%s: expected injected Authorization header, got %q", kind, v)
+		}
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, proxy.URL+"/proxy/"+u.Port()+"/app", nil)
+	req.Header.Set(model.ApiAccessTokenHeader, "secret")
+	req.Header.Set(model.AdminTokenHeader, "admin")
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	check("http", <-received)
+
+	header := http.Header{}
+	header.Set(model.ApiAccessTokenHeader, "secret")
+	header.Set(model.AdminTokenHeader, "admin")
+	header.Set("X-Forwarded-For", "203.0.113.7")
+	conn, _, err := websocket.DefaultDialer.Dial(proxiedWebSocketURL(t, proxy, upstream, "/ws"), header)
+	if err != nil {
+		t.Fatalf("dial through proxy: %v", err)
+	}
+	conn.Close()
+	check("websocket", <-received)
+}
+
+func TestParseProxyTimeout(t *testing.T) {
+	cases := []struct {
+		value string
+		limit time.Duration
+		want  time.Duration
+	}{
+		{"", time.Minute, 0},
+		{"1500ms", time.Minute, 1500 * time.Millisecond},
+		{"30", time.Minute, 30 * time.Second},
+		{"2h", time.Minute, time.Minute},
+		{"2h", 0, 2 * time.Hour},
+	}
+	for _, tc := range cases {
+		got, err := parseProxyTimeout(tc.value, tc.limit)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", tc.value, err)
+		}
+		if got != tc.want {
+			t.Fatalf("%q: expected %v, got %v", tc.value, tc.want, got)
+		}
+	}
+
+	for _, value := range []string{"soon", "-5s", "0"} {
+		if _, err := parseProxyTimeout(value, time.Minute); err == nil {
+			t.Fatalf("expected error for %q", value)
+		}
+	}
+}
+
+func TestProxyTimeoutHeader(t *testing.T) {
+	received := make(chan http.Header, 2)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Clone()
+		if r.URL.Path == "/slow" {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(2 * time.Second):
+			}
+		}
+		_, _ = io.WriteString(w, "done")
+	}))
+	defer upstream.Close()
+	u, _ := url.Parse(upstream.URL)
+
+	proxy := newProxyHandlerServer(t, "", func(opts *proxyOptions) { opts.maxTimeout = time.Minute })
+	defer proxy.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, proxy.URL+"/proxy/"+u.Port()+"/slow", nil)
+	req.Header.Set(model.ProxyTimeoutHeader, "100ms")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	var body model.ProxyErrorResponse
+	_ = json.NewDecoder(resp.Body).Decode(&body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusGatewayTimeout {
+		t.Fatalf("expected 504, got %d", resp.StatusCode)
+	}
+	if body.Code != model.ErrorCodeProxyTimeout {
+		t.Fatalf("expected %s, got %q", model.ErrorCodeProxyTimeout, body.Code)
+	}
+	if v := (<-received).Get(model.ProxyTimeoutHeader); v != "" {
+		t.Fatalf("timeout header must not reach the upstream, got %q", v)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, proxy.URL+"/proxy/"+u.Port()+"/slow", nil)
+	req.Header.Set(model.ProxyTimeoutHeader, "later")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for malformed timeout, got %d", resp.StatusCode)
+	}
+}
+
+func TestProxyWebSocketHonoursTimeoutHeader(t *testing.T) {
+	// the upstream accepts the connection and reads the upgrade but never answers it.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+	received := make(chan http.Header, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		if req, err := http.ReadRequest(bufio.NewReader(conn)); err == nil {
+			received <- req.Header
+		}
+		_, _ = io.Copy(io.Discard, conn)
+	}()
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+
+	proxy := newProxyHandlerServer(t, "", func(opts *proxyOptions) { opts.maxTimeout = time.Minute })
+	defer proxy.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, proxy.URL+"/proxy/"+port+"/ws", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set(model.ProxyTimeoutHeader, "200ms")
+
+	start := time.Now()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusGatewayTimeout {
+		t.Fatalf("expected 504, got %d", resp.StatusCode)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("handshake was not bounded by %s, took %v", model.ProxyTimeoutHeader, elapsed)
+	}
+	if v := (<-received).Get(model.ProxyTimeoutHeader); v != "" {
+		t.Fatalf("timeout header must not reach the upstream, got %q", v)
+	}
+}
+
+func TestProxyFlushesEventStreamsImmediately(t *testing.T) {
+	release := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "data: first\n\n")
+		w.(http.Flusher).Flush()
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer upstream.Close()
+	defer close(release)
+	u, _ := url.Parse(upstream.URL)
+
+	// a huge interval would hold back the event if streams were buffered.
+	proxy := newProxyHandlerServer(t, "", func(opts *proxyOptions) { opts.flushInterval = time.Hour })
+	defer proxy.Close()
+
+	resp, err := http.Get(proxy.URL + "/proxy/" + u.Port() + "/events")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	got := make(chan string, 1)
+	go func() {
+		buf := make([]byte, 64)
+		n, _ := resp.Body.Read(buf)
+		got <- string(buf[:n])
+	}()
+	select {
+	case data := <-got:
+		if data != "data: first\n\n" {
+			t.Fatalf("unexpected event data: %q", data)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("event was not flushed through the proxy")
+	}
+}
+
+func fetchProxyStatus(t *testing.T, proxy *httptest.Server) map[string]model.ProxyTargetStatus {
+	t.Helper()
+	resp, err := http.Get(proxy.URL + "/proxy/status")
+	if err != nil {
+		t.Fatalf("status request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 from status, got %d", resp.StatusCode)
+	}
+	var status model.ProxyStatus
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		t.Fatalf("decode status: %v", err)
+	}
+	targets := make(map[string]model.ProxyTargetStatus, len(status.Targets))
+	for _, target := range status.Targets {
+		targets[target.Target] = target
+	}
+	return targets
+}
+
+func TestProxyConnectRefusedReportsJSON(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	listener.Close()
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.Header.Get(model.RequestIDHeader))
+	}))
+	defer upstream.Close()
+	u, _ := url.Parse(upstream.URL)
+
+	proxy := newProxyHandlerServer(t, "", nil)
+	defer proxy.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, proxy.URL+"/proxy/"+port+"/", nil)
+	req.Header.Set(model.RequestIDHeader, "req-1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	var body model.ProxyErrorResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("expected a JSON error body: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadGateway {
+		t.Fatalf("expected 502, got %d", resp.StatusCode)
+	}
+	if body.Code != model.ErrorCodeProxyConnectRefused || body.Target != port || body.RequestID != "req-1" {
+		t.Fatalf("unexpected error body: %+v", body)
+	}
+
+	// a request without an ID gets one, forwarded upstream and echoed back.
+	resp, err = http.Get(proxy.URL + "/proxy/" + u.Port() + "/")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	forwarded, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if id := resp.Header.Get(model.RequestIDHeader); id == "" || id != string(forwarded) {
+		t.Fatalf("expected the generated request ID upstream and in the response, got %q and %q", forwarded, id)
+	}
+
+	targets := fetchProxyStatus(t, proxy)
+	refused := targets[port]
+	if refused.Requests != 1 || refused.Errors != 1 || refused.ErrorCodes[model.ErrorCodeProxyConnectRefused] != 1 {
+		t.Fatalf("unexpected status for refused target: %+v", refused)
+	}
+	ok := targets[u.Port()]
+	if ok.Requests != 1 || ok.Successes != 1 || ok.Latency.Count != 1 {
+		t.Fatalf("unexpected status for healthy target: %+v", ok)
+	}
+}
+
+func TestProxyCountsInterruptedStreams(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "100")
+		_, _ = io.WriteString(w, "partial")
+		w.(http.Flusher).Flush()
+		conn, _, err := http.NewResponseController(w).Hijack()
+		if err == nil {
+			conn.Close()
+		}
+	}))
+	defer upstream.Close()
+	u, _ := url.Parse(upstream.URL)
+
+	proxy := newProxyHandlerServer(t, "", nil)
+	defer proxy.Close()
+
+	// depending on what was flushed, the client sees a cut body or no response at all.
+	if resp, err := http.Get(proxy.URL + "/proxy/" + u.Port() + "/"); err == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+
+	// the proxy records the failure while unwinding, possibly after the client has seen the cut.
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		target := fetchProxyStatus(t, proxy)[u.Port()]
+		if target.ErrorCodes[model.ErrorCodeProxyStreamInterrupted] == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("interrupted stream was not recorded: %+v", target)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestClassifyProxyError(t *testing.T) {
+	cases := []struct {
+		err    error
+		status int
+		code   model.ErrorCode
+	}{
+		{context.DeadlineExceeded, http.StatusGatewayTimeout, model.ErrorCodeProxyTimeout},
+		{&net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, http.StatusBadGateway, model.ErrorCodeProxyConnectRefused},
+		{io.ErrUnexpectedEOF, http.StatusBadGateway, model.ErrorCodeProxyStreamInterrupted},
+		{errors.New("tls: bad certificate"), http.StatusBadGateway, model.ErrorCodeProxyTargetUnreachable},
+	}
+	for _, tc := range cases {
+		status, code := classifyProxyError(tc.err)
+		if status != tc.status || code != tc.code {
+			t.Fatalf("classifyProxyError(%v) = %d %s, want %d %s", tc.err, status, code, tc.status, tc.code)
+		}
+	}
+}
+
+func TestProxyPreservesPathEncodingAndQuery(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.RequestURI)
+	}))
+	defer upstream.Close()
+	u, _ := url.Parse(upstream.URL)
+
+	proxy := newProxyHandlerServer(t, "", nil)
+	defer proxy.Close()
+
+	cases := []struct {
+		name     string
+		path     string
+		expected string
+	}{
+		{"root without slash", "", "/"},
+		{"root with slash", "/", "/"},
+		{"encoded slash", "/files/a%2Fb/c", "/files/a%2Fb/c"},
+		{"duplicate slashes", "//a//b/", "//a//b/"},
+		{"encoded space", "/a%20b", "/a%20b"},
+		{"encoded hash", "/notes%23draft", "/notes%23draft"},
+		{"question marks in query values", "/search?q=a?b&next=%3Fx", "/search?q=a?b&next=%3Fx"},
+		{"empty query", "/page?", "/page?"},
+		{"query on root", "?x=1", "/?x=1"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := http.Get(proxy.URL + "/proxy/" + u.Port() + tc.path)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", resp.StatusCode, body)
+			}
+			if string(body) != tc.expected {
+				t.Fatalf("upstream saw %q, want %q", body, tc.expected)
+			}
+		})
+	}
+}
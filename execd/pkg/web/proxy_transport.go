@@ -0,0 +1,112 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// proxyTimeouts bounds the stages of an upstream exchange; zero disables a bound.
+type proxyTimeouts struct {
+	dial           time.Duration
+	responseHeader time.Duration
+	idle           time.Duration
+}
+
+// proxyTransports pools upstream connections, one transport per scheme and host.
+type proxyTransports struct {
+	// tlsConfig is the base configuration for HTTPS upstreams.
+	tlsConfig *tls.Config
+	timeouts  proxyTimeouts
+
+	mu         sync.Mutex
+	transports map[string]*http.Transport
+}
+
+// newProxyTransports builds the pool. caFile, when set, is a PEM bundle trusted
+// in addition to the system roots; insecure disables verification entirely.
+func newProxyTransports(insecure bool, caFile string, timeouts proxyTimeouts) (*proxyTransports, error) {
+	config := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: insecure, //nolint:gosec // opt-in for self-signed local services
+	}
+
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("read CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", caFile)
+		}
+		config.RootCAs = pool
+	}
+
+	return &proxyTransports{
+		tlsConfig:  config,
+		timeouts:   timeouts,
+		transports: make(map[string]*http.Transport),
+	}, nil
+}
+
+// get returns the pooled transport reaching upstream over scheme.
+func (p *proxyTransports) get(scheme string, upstream *proxyTarget) *http.Transport {
+	key := scheme + "://" + upstream.host
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if transport, ok := p.transports[key]; ok {
+		return transport
+	}
+
+	transport := &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout:   p.timeouts.dial,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   100,
+		IdleConnTimeout:       p.timeouts.idle,
+		ResponseHeaderTimeout: p.timeouts.responseHeader,
+	}
+	if scheme == "https" {
+		transport.TLSClientConfig = p.clientTLSConfig(upstream)
+		transport.TLSHandshakeTimeout = 30 * time.Second
+	}
+	p.transports[key] = transport
+	return transport
+}
+
+// clientTLSConfig verifies the upstream against the name it was requested by,
+// not the pinned address the connection is dialed to.
+func (p *proxyTransports) clientTLSConfig(upstream *proxyTarget) *tls.Config {
+	config := p.tlsConfig.Clone()
+	if host, _, err := net.SplitHostPort(upstream.host); err == nil {
+		config.ServerName = host
+	}
+	return config
+}
@@ -0,0 +1,167 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/alibaba/opensandbox/execd/pkg/web/model"
+)
+
+const (
+	// websocketHandshakeTimeout bounds dialing the upstream and completing the
+	// upgrade when the request doesn't carry its own X-Proxy-Timeout.
+	websocketHandshakeTimeout = 30 * time.Second
+	// websocketCloseGracePeriod lets the remaining direction forward the closing
+	// handshake after the other side has gone away.
+	websocketCloseGracePeriod = 5 * time.Second
+)
+
+// websocketTimeouts bounds establishing a websocket tunnel; zero disables a bound.
+type websocketTimeouts struct {
+	// dial bounds connecting to the upstream, as --proxy-dial-timeout does for HTTP.
+	dial time.Duration
+	// handshake bounds the whole upgrade, TLS included.
+	handshake time.Duration
+}
+
+// proxyWebSocket tunnels a websocket upgrade to target, connecting to addr over TLS
+// when tlsConfig is set and adding the injected headers. The handshake is relayed verbatim and, once the upstream
+// switches protocols, frames (close frames included) are copied as raw bytes in
+// both directions. Failures before the upgrade are answered through fail.
+func proxyWebSocket(w http.ResponseWriter, r *http.Request, target *url.URL, addr string, tlsConfig *tls.Config, injected http.Header, timeouts websocketTimeouts, fail func(http.ResponseWriter, error)) {
+	ctx := r.Context()
+	if timeouts.handshake > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeouts.handshake)
+		defer cancel()
+	}
+
+	dialer := &net.Dialer{Timeout: timeouts.dial}
+	upstream, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		proxyLogger.Error("Proxy websocket dial error: %v, request: %s %s", err, r.Method, r.RequestURI)
+		fail(w, err)
+		return
+	}
+	defer upstream.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = upstream.SetDeadline(deadline)
+	}
+
+	if tlsConfig != nil {
+		tlsConn := tls.Client(upstream, tlsConfig)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			proxyLogger.Error("Proxy websocket TLS handshake error: %v, request: %s %s", err, r.Method, r.RequestURI)
+			fail(w, err)
+			return
+		}
+		upstream = tlsConn
+	}
+
+	outReq := r.Clone(r.Context())
+	outReq.URL = &url.URL{Path: target.Path, RawPath: target.RawPath, RawQuery: r.URL.RawQuery}
+	outReq.Host = target.Host
+	outReq.RequestURI = ""
+	prepareForwardedRequest(outReq, r, injected)
+	appendForwardedFor(outReq, r)
+	outReq.Header.Del(model.ProxyTimeoutHeader)
+	if err := outReq.Write(upstream); err != nil {
+		proxyLogger.Error("Proxy websocket handshake error: %v, request: %s %s", err, r.Method, r.RequestURI)
+		fail(w, err)
+		return
+	}
+
+	upstreamReader := bufio.NewReader(upstream)
+	resp, err := http.ReadResponse(upstreamReader, outReq)
+	if err != nil {
+		proxyLogger.Error("Proxy websocket handshake error: %v, request: %s %s", err, r.Method, r.RequestURI)
+		fail(w, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		// upstream refused the upgrade, hand its answer back as a plain response.
+		for k, vv := range resp.Header {
+			for _, v := range vv {
+				w.Header().Add(k, v)
+			}
+		}
+		w.WriteHeader(resp.StatusCode)
+		_, _ = io.Copy(w, resp.Body)
+		return
+	}
+
+	client, clientBuf, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		proxyLogger.Error("Proxy websocket hijack error: %v, request: %s %s", err, r.Method, r.RequestURI)
+		http.Error(w, "Bad Gateway", http.StatusBadGateway)
+		return
+	}
+	defer client.Close()
+
+	// the tunnel lives as long as both peers keep it open.
+	_ = client.SetDeadline(time.Time{})
+	_ = upstream.SetDeadline(time.Time{})
+
+	if err := writeSwitchingProtocols(clientBuf.Writer, resp); err != nil {
+		proxyLogger.Error("Proxy websocket handshake relay error: %v, request: %s %s", err, r.Method, r.RequestURI)
+		return
+	}
+
+	done := make(chan struct{}, 2)
+	go tunnel(upstream, clientBuf.Reader, done)
+	go tunnel(client, upstreamReader, done)
+
+	<-done
+	// one peer is gone; give the other direction a moment to flush its close frame.
+	deadline := time.Now().Add(websocketCloseGracePeriod)
+	_ = client.SetDeadline(deadline)
+	_ = upstream.SetDeadline(deadline)
+	<-done
+}
+
+// writeSwitchingProtocols relays the upstream 101 response to the hijacked client.
+func writeSwitchingProtocols(w *bufio.Writer, resp *http.Response) error {
+	if _, err := fmt.Fprintf(w, "HTTP/1.1 %s\r\n", resp.Status); err != nil {
+		return err
+	}
+	if err := resp.Header.Write(w); err != nil {
+		return err
+	}
+	if _, err := w.WriteString("\r\n"); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// tunnel copies src into dst and half-closes dst once src is exhausted.
+func tunnel(dst net.Conn, src io.Reader, done chan<- struct{}) {
+	_, _ = io.Copy(dst, src)
+	if cw, ok := dst.(interface{ CloseWrite() error }); ok {
+		_ = cw.CloseWrite()
+	}
+	done <- struct{}{}
+}
@@ -15,68 +15,162 @@
 package web
 
 import (
+	"fmt"
 	"net/http"
+	"slices"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/alibaba/opensandbox/execd/pkg/flag"
 	"github.com/alibaba/opensandbox/execd/pkg/log"
 	"github.com/alibaba/opensandbox/execd/pkg/web/controller"
 	"github.com/alibaba/opensandbox/execd/pkg/web/model"
 )
 
-// NewRouter builds a Gin engine with all execd routes.
-func NewRouter(accessToken string) *gin.Engine {
+// httpLogger logs requests and their bodies.
+var httpLogger = log.For("http")
+
+// NewRouter builds a Gin engine with all execd routes, mounted under the configured base path.
+// It fails when the proxy flags are malformed.
+func NewRouter(accessToken string) (*gin.Engine, error) {
+	basePath := normalizeBasePath(flag.ServerBasePath)
+	proxy, err := ProxyMiddleware(basePath)
+	if err != nil {
+		return nil, err
+	}
+
 	gin.SetMode(gin.ReleaseMode)
 	r := gin.New()
 	r.Use(gin.Recovery())
-	r.Use(logMiddleware(), accessTokenMiddleware(accessToken), ProxyMiddleware())
+	var publicPaths []string
+	if flag.ServerPublicInfo {
+		publicPaths = []string{basePath + "/info", basePath + "/" + model.APIVersionV1 + "/info"}
+	}
+	r.Use(logMiddleware(), accessTokenMiddleware(accessToken, publicPaths...), proxy, writeDeadlineMiddleware(flag.ServerWriteTimeout), handlerTimeoutMiddleware(basePath, flag.ServerHandlerTimeout))
 
+	logBody := func(ctx *gin.Context) { ctx.Next() }
+	if flag.ServerLogBodies {
+		logBody = bodyLogMiddleware(flag.ServerLogBodyLimit, httpLogger.Info)
+	}
+
+	base := r.Group(basePath)
+	registerRoutes(base.Group("/"+model.APIVersionV1, apiVersionMiddleware(model.APIVersionV1)), logBody)
+	// unprefixed paths are kept as aliases for SDKs pinned to the legacy layout.
+	registerRoutes(base.Group("", apiVersionMiddleware(model.APIVersionLegacy), deprecationMiddleware(basePath)), logBody)
+
+	return r, nil
+}
+
+// normalizeBasePath turns a configured prefix into "/a/b" form, or "" for the root.
+func normalizeBasePath(basePath string) string {
+	basePath = strings.Trim(strings.TrimSpace(basePath), "/")
+	if basePath == "" {
+		return ""
+	}
+	return "/" + basePath
+}
+
+// registerRoutes mounts every execd API route under the given group. Routes
+// whose bodies are small JSON free of secrets list logBody; file transfers,
+// code and command runs, and streams leave it out.
+func registerRoutes(r *gin.RouterGroup, logBody gin.HandlerFunc) {
 	r.GET("/ping", controller.PingHandler)
+	r.GET("/info", logBody, controller.InfoHandler)
+	r.GET("/capabilities", logBody, controller.CapabilitiesHandler)
 
 	files := r.Group("/files")
 	{
-		files.DELETE("", withFilesystem(func(c *controller.FilesystemController) { c.RemoveFiles() }))
-		files.GET("/info", withFilesystem(func(c *controller.FilesystemController) { c.GetFilesInfo() }))
-		files.POST("/mv", withFilesystem(func(c *controller.FilesystemController) { c.RenameFiles() }))
-		files.POST("/permissions", withFilesystem(func(c *controller.FilesystemController) { c.ChmodFiles() }))
-		files.GET("/search", withFilesystem(func(c *controller.FilesystemController) { c.SearchFiles() }))
-		files.POST("/replace", withFilesystem(func(c *controller.FilesystemController) { c.ReplaceContent() }))
+		files.DELETE("", logBody, withFilesystem(func(c *controller.FilesystemController) { c.RemoveFiles() }))
+		files.GET("/info", logBody, withFilesystem(func(c *controller.FilesystemController) { c.GetFilesInfo() }))
+		files.POST("/mv", logBody, withFilesystem(func(c *controller.FilesystemController) { c.RenameFiles() }))
+		files.POST("/permissions", logBody, withFilesystem(func(c *controller.FilesystemController) { c.ChmodFiles() }))
+		files.GET("/search", logBody, withFilesystem(func(c *controller.FilesystemController) { c.SearchFiles() }))
+		files.GET("/validate-glob", logBody, withFilesystem(func(c *controller.FilesystemController) { c.ValidateGlob() }))
+		files.POST("/replace", logBody, withFilesystem(func(c *controller.FilesystemController) { c.ReplaceContent() }))
+		files.POST("/diff", logBody, withFilesystem(func(c *controller.FilesystemController) { c.DiffFile() }))
+		files.POST("/render", withFilesystem(func(c *controller.FilesystemController) { c.RenderFile() }))
+		files.POST("/utime", logBody, withFilesystem(func(c *controller.FilesystemController) { c.SetFileTimes() }))
 		files.POST("/upload", withFilesystem(func(c *controller.FilesystemController) { c.UploadFile() }))
+		files.POST("/fetch", withFilesystem(func(c *controller.FilesystemController) { c.FetchFile() }))
+		files.POST("/push", withFilesystem(func(c *controller.FilesystemController) { c.PushFile() }))
 		files.GET("/download", withFilesystem(func(c *controller.FilesystemController) { c.DownloadFile() }))
 	}
 
 	directories := r.Group("/directories")
 	{
-		directories.POST("", withFilesystem(func(c *controller.FilesystemController) { c.MakeDirs() }))
-		directories.DELETE("", withFilesystem(func(c *controller.FilesystemController) { c.RemoveDirs() }))
+		directories.POST("", logBody, withFilesystem(func(c *controller.FilesystemController) { c.MakeDirs() }))
+		directories.DELETE("", logBody, withFilesystem(func(c *controller.FilesystemController) { c.RemoveDirs() }))
 	}
 
 	code := r.Group("/code")
 	{
 		code.POST("", withCode(func(c *controller.CodeInterpretingController) { c.RunCode() }))
-		code.DELETE("", withCode(func(c *controller.CodeInterpretingController) { c.InterruptCode() }))
-		code.POST("/context", withCode(func(c *controller.CodeInterpretingController) { c.CreateContext() }))
-		code.GET("/contexts", withCode(func(c *controller.CodeInterpretingController) { c.ListContexts() }))
-		code.DELETE("/contexts", withCode(func(c *controller.CodeInterpretingController) { c.DeleteContextsByLanguage() }))
-		code.DELETE("/contexts/:contextId", withCode(func(c *controller.CodeInterpretingController) { c.DeleteContext() }))
-		code.GET("/contexts/:contextId", withCode(func(c *controller.CodeInterpretingController) { c.GetContext() }))
+		code.DELETE("", logBody, withCode(func(c *controller.CodeInterpretingController) { c.InterruptCode() }))
+		code.POST("/execute-batch", withCode(func(c *controller.CodeInterpretingController) { c.RunCodeBatch() }))
+		code.POST("/complete", logBody, withCode(func(c *controller.CodeInterpretingController) { c.CompleteCode() }))
+		code.POST("/inspect", logBody, withCode(func(c *controller.CodeInterpretingController) { c.InspectCode() }))
+		code.POST("/context", logBody, withCode(func(c *controller.CodeInterpretingController) { c.CreateContext() }))
+		code.GET("/contexts", logBody, withCode(func(c *controller.CodeInterpretingController) { c.ListContexts() }))
+		code.DELETE("/contexts", logBody, withCode(func(c *controller.CodeInterpretingController) { c.DeleteContextsByLanguage() }))
+		code.DELETE("/contexts/:contextId", logBody, withCode(func(c *controller.CodeInterpretingController) { c.DeleteContext() }))
+		code.GET("/contexts/:contextId", logBody, withCode(func(c *controller.CodeInterpretingController) { c.GetContext() }))
+		code.GET("/contexts/:contextId/output", withCode(func(c *controller.CodeInterpretingController) { c.AttachContextOutput() }))
+		code.GET("/contexts/:contextId/export", withCode(func(c *controller.CodeInterpretingController) { c.ExportContext() }))
+		code.GET("/contexts/:contextId/variables", withCode(func(c *controller.CodeInterpretingController) { c.ListContextVariables() }))
+		code.POST("/contexts/:contextId/reset", logBody, withCode(func(c *controller.CodeInterpretingController) { c.ResetContext() }))
 	}
 
 	command := r.Group("/command")
 	{
 		command.POST("", withCode(func(c *controller.CodeInterpretingController) { c.RunCommand() }))
-		command.DELETE("", withCode(func(c *controller.CodeInterpretingController) { c.InterruptCommand() }))
-		command.GET("/status/:id", withCode(func(c *controller.CodeInterpretingController) { c.GetCommandStatus() }))
-		command.GET("/:id/logs", withCode(func(c *controller.CodeInterpretingController) { c.GetBackgroundCommandOutput() }))
+		command.DELETE("", logBody, withCode(func(c *controller.CodeInterpretingController) { c.InterruptCommand() }))
+		command.GET("/status/:id", logBody, withCode(func(c *controller.CodeInterpretingController) { c.GetCommandStatus() }))
+		command.GET("/:id/logs", withCode(func(c *controller.CodeInterpretingController) { c.GetCommandOutput() }))
+		command.GET("/:id/logs/download", withCode(func(c *controller.CodeInterpretingController) { c.DownloadCommandOutput() }))
+		command.GET("/:id/logs/stream", withCode(func(c *controller.CodeInterpretingController) { c.FollowCommandOutput() }))
+	}
+
+	sql := r.Group("/sql")
+	{
+		sql.GET("/queries", logBody, withCode(func(c *controller.CodeInterpretingController) { c.ListSQLQueries() }))
+		sql.DELETE("/queries/:id", logBody, withCode(func(c *controller.CodeInterpretingController) { c.CancelSQLQuery() }))
+		sql.GET("/connections", logBody, withCode(func(c *controller.CodeInterpretingController) { c.ListSQLConnections() }))
+		sql.POST("/connections", withCode(func(c *controller.CodeInterpretingController) { c.AddSQLConnection() }))
+		sql.DELETE("/connections/:name", logBody, withCode(func(c *controller.CodeInterpretingController) { c.DeleteSQLConnection() }))
 	}
 
 	metric := r.Group("/metrics")
 	{
-		metric.GET("", withMetric(func(c *controller.MetricController) { c.GetMetrics() }))
+		metric.GET("", logBody, withMetric(func(c *controller.MetricController) { c.GetMetrics() }))
 		metric.GET("/watch", withMetric(func(c *controller.MetricController) { c.WatchMetrics() }))
+		metric.GET("/processes", logBody, withMetric(func(c *controller.MetricController) { c.GetProcessMetrics() }))
+		metric.GET("/history", logBody, withMetric(func(c *controller.MetricController) { c.GetMetricsHistory() }))
 	}
 
-	return r
+	r.GET("/ports", withPort(func(c *controller.PortController) { c.ListPorts() }))
+	r.GET("/wait", logBody, withWait(func(c *controller.WaitController) { c.Wait() }))
+
+	processes := r.Group("/processes")
+	{
+		processes.GET("", withProcess(func(c *controller.ProcessController) { c.ListProcesses() }))
+		processes.DELETE("/:pid", logBody, withProcess(func(c *controller.ProcessController) { c.KillProcess() }))
+	}
+
+	env := r.Group("/env")
+	{
+		env.GET("", withEnv(func(c *controller.EnvController) { c.GetEnvs() }))
+		env.PUT("", withEnv(func(c *controller.EnvController) { c.SetEnvs() }))
+		env.DELETE("", withEnv(func(c *controller.EnvController) { c.DeleteEnvs() }))
+	}
+
+	admin := r.Group("/admin")
+	{
+		admin.GET("/loglevel", logBody, withAdmin(func(c *controller.AdminController) { c.GetLogLevel() }))
+		admin.PUT("/loglevel", logBody, withAdmin(func(c *controller.AdminController) { c.SetLogLevel() }))
+	}
+
+	r.GET("/debug/config", withAdmin(func(c *controller.AdminController) { c.GetConfig() }))
 }
 
 func withFilesystem(fn func(*controller.FilesystemController)) gin.HandlerFunc {
@@ -97,9 +191,46 @@ func withMetric(fn func(*controller.MetricController)) gin.HandlerFunc {
 	}
 }
 
-func accessTokenMiddleware(token string) gin.HandlerFunc {
+func withPort(fn func(*controller.PortController)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		fn(controller.NewPortController(ctx))
+	}
+}
+
+func withWait(fn func(*controller.WaitController)) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		if token == "" {
+		fn(controller.NewWaitController(ctx))
+	}
+}
+
+func withProcess(fn func(*controller.ProcessController)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		fn(controller.NewProcessController(ctx))
+	}
+}
+
+func withEnv(fn func(*controller.EnvController)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		fn(controller.NewEnvController(ctx))
+	}
+}
+
+// withAdmin requires the admin token, once one is configured, on every
+// AdminController route.
+func withAdmin(fn func(*controller.AdminController)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		c := controller.NewAdminController(ctx)
+		if !c.Authorize() {
+			return
+		}
+		fn(c)
+	}
+}
+
+// accessTokenMiddleware requires the access token on every path except publicPaths.
+func accessTokenMiddleware(token string, publicPaths ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if token == "" || (ctx.Request.Method == http.MethodGet && slices.Contains(publicPaths, ctx.Request.URL.Path)) {
 			ctx.Next()
 			return
 		}
@@ -116,9 +247,27 @@ func accessTokenMiddleware(token string) gin.HandlerFunc {
 	}
 }
 
+// apiVersionMiddleware records the API version implied by the matched route.
+func apiVersionMiddleware(version string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Set(model.APIVersionContextKey, version)
+		ctx.Next()
+	}
+}
+
+// deprecationMiddleware flags legacy unprefixed routes and points to their successor.
+func deprecationMiddleware(basePath string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		path := strings.TrimPrefix(ctx.Request.URL.Path, basePath)
+		ctx.Header("Deprecation", "true")
+		ctx.Header("Link", fmt.Sprintf("<%s/%s%s>; rel=\"successor-version\"", basePath, model.APIVersionV1, path))
+		ctx.Next()
+	}
+}
+
 func logMiddleware() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		log.Info("Requested: %v - %v", ctx.Request.Method, ctx.Request.URL.String())
+		httpLogger.Info("Requested: %v - %v", ctx.Request.Method, ctx.Request.URL.String())
 		ctx.Next()
 	}
 }
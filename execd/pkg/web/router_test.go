@@ -0,0 +1,346 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	goruntime "runtime"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/alibaba/opensandbox/execd/pkg/flag"
+	"github.com/alibaba/opensandbox/execd/pkg/web/model"
+)
+
+// mustNewRouter builds the router from the current flags.
+func mustNewRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	r, err := NewRouter("")
+	if err != nil {
+		t.Fatalf("NewRouter returned error: %v", err)
+	}
+	return r
+}
+
+func TestNewRouterRejectsInvalidProxyFlags(t *testing.T) {
+	cases := []struct {
+		name  string
+		apply func()
+	}{
+		{"allowed ports", func() { flag.ProxyAllowedPorts = "not-a-port" }},
+		{"denied ports", func() { flag.ProxyDeniedPorts = "70000" }},
+		{"tls", func() { flag.ProxyTLSCAFile = filepath.Join(t.TempDir(), "missing.pem") }},
+		{"headers", func() { flag.ProxyInjectHeaders = "3000" }},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer func(allowed, denied, caFile, headers string) {
+				flag.ProxyAllowedPorts, flag.ProxyDeniedPorts, flag.ProxyTLSCAFile, flag.ProxyInjectHeaders = allowed, denied, caFile, headers
+			}(flag.ProxyAllowedPorts, flag.ProxyDeniedPorts, flag.ProxyTLSCAFile, flag.ProxyInjectHeaders)
+			tc.apply()
+
+			if _, err := NewRouter(""); err == nil {
+				t.Fatalf("expected invalid %s flags to be rejected", tc.name)
+			}
+		})
+	}
+}
+
+func TestNewRouterRegistersVersionedAndLegacyRoutes(t *testing.T) {
+	r := mustNewRouter(t)
+
+	legacy := make(map[string]string)
+	versioned := make(map[string]string)
+	prefix := "/" + model.APIVersionV1
+	for _, route := range r.Routes() {
+		if route.Path == prefix || strings.HasPrefix(route.Path, prefix+"/") {
+			versioned[route.Method+" "+strings.TrimPrefix(route.Path, prefix)] = route.Handler
+		} else {
+			legacy[route.Method+" "+route.Path] = route.Handler
+		}
+	}
+
+	if len(versioned) == 0 {
+		t.Fatalf("expected routes under %s", prefix)
+	}
+	if len(versioned) != len(legacy) {
+		t.Fatalf("route trees differ in size: %d versioned vs %d legacy", len(versioned), len(legacy))
+	}
+	for key, handler := range versioned {
+		if legacy[key] != handler {
+			t.Fatalf("route %s resolves to %q under %s but %q without prefix", key, handler, prefix, legacy[key])
+		}
+	}
+}
+
+func TestLegacyRoutesEmitDeprecationHeader(t *testing.T) {
+	r := mustNewRouter(t)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ping", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected legacy ping to succeed, got %d", rec.Code)
+	}
+	if rec.Header().Get("Deprecation") != "true" {
+		t.Fatalf("expected Deprecation header on legacy route")
+	}
+	if link := rec.Header().Get("Link"); !strings.Contains(link, "/v1/ping") {
+		t.Fatalf("unexpected Link header: %q", link)
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/ping", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected versioned ping to succeed, got %d", rec.Code)
+	}
+	if rec.Header().Get("Deprecation") != "" {
+		t.Fatalf("versioned route must not be marked deprecated")
+	}
+}
+
+func TestNewRouterMountsRoutesUnderBasePath(t *testing.T) {
+	previous := flag.ServerBasePath
+	flag.ServerBasePath = "execd/"
+	defer func() { flag.ServerBasePath = previous }()
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "upstream "+r.URL.Path)
+	}))
+	defer upstream.Close()
+	u, _ := url.Parse(upstream.URL)
+
+	server := httptest.NewServer(mustNewRouter(t))
+	defer server.Close()
+
+	for _, tc := range []struct {
+		path   string
+		status int
+	}{
+		{"/execd/v1/ping", http.StatusOK},
+		{"/execd/ping", http.StatusOK},
+		{"/execd/v1/metrics", http.StatusOK},
+		{"/ping", http.StatusNotFound},
+		{"/v1/ping", http.StatusNotFound},
+		{"/proxy/" + u.Port() + "/app", http.StatusNotFound},
+	} {
+		resp, err := http.Get(server.URL + tc.path)
+		if err != nil {
+			t.Fatalf("%s: request failed: %v", tc.path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tc.status {
+			t.Fatalf("%s: expected status %d, got %d", tc.path, tc.status, resp.StatusCode)
+		}
+		if tc.path == "/execd/ping" && resp.Header.Get("Link") != `</execd/v1/ping>; rel="successor-version"` {
+			t.Fatalf("unexpected Link header: %q", resp.Header.Get("Link"))
+		}
+	}
+
+	resp, err := http.Get(server.URL + "/execd/proxy/" + u.Port() + "/app")
+	if err != nil {
+		t.Fatalf("proxy request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != "upstream /app" {
+		t.Fatalf("unexpected proxy response %d: %s", resp.StatusCode, body)
+	}
+}
+
+func TestRegisterRoutesLogsBodiesOfSafeRoutesOnly(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logBody := func(ctx *gin.Context) {}
+	logBodyName := goruntime.FuncForPC(reflect.ValueOf(logBody).Pointer()).Name()
+	logged := map[string]bool{}
+	r := gin.New()
+	// inspect each matched chain without running the handlers.
+	group := r.Group("", func(ctx *gin.Context) {
+		logged[ctx.Request.Method+" "+ctx.FullPath()] = slices.Contains(ctx.HandlerNames(), logBodyName)
+		ctx.AbortWithStatus(http.StatusNoContent)
+	})
+	registerRoutes(group, logBody)
+
+	for _, route := range r.Routes() {
+		parts := strings.Split(route.Path, "/")
+		for i, part := range parts {
+			if strings.HasPrefix(part, ":") {
+				parts[i] = "x"
+			}
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(route.Method, strings.Join(parts, "/"), nil))
+	}
+
+	if len(logged) != len(r.Routes()) {
+		t.Fatalf("expected every route to be requested, got %v", logged)
+	}
+	if !logged["POST /files/replace"] {
+		t.Fatalf("expected POST /files/replace to log bodies, got %v", logged)
+	}
+	for _, route := range []string{
+		"POST /files/upload",
+		"GET /files/download",
+		"POST /code",
+		"POST /code/execute-batch",
+		"POST /command",
+		"GET /command/:id/logs",
+		"GET /code/contexts/:contextId/variables",
+		"POST /files/render",
+		"GET /code/contexts/:contextId/output",
+		"GET /code/contexts/:contextId/export",
+		"GET /env",
+		"PUT /env",
+		"DELETE /env",
+		"GET /processes",
+		"GET /ports",
+		"GET /command/:id/logs/download",
+		"GET /command/:id/logs/stream",
+		"POST /files/fetch",
+		"POST /files/push",
+		"GET /debug/config",
+		"POST /sql/connections",
+		"GET /metrics/watch",
+	} {
+		if logged[route] {
+			t.Fatalf("%s must not log bodies", route)
+		}
+	}
+}
+
+func TestInfoRouteRequiresTokenUnlessPublic(t *testing.T) {
+	for _, public := range []bool{false, true} {
+		previous := flag.ServerPublicInfo
+		flag.ServerPublicInfo = public
+		r, err := NewRouter("secret")
+		flag.ServerPublicInfo = previous
+		if err != nil {
+			t.Fatalf("NewRouter returned error: %v", err)
+		}
+
+		wantInfo := http.StatusUnauthorized
+		if public {
+			wantInfo = http.StatusOK
+		}
+		for _, tc := range []struct {
+			path   string
+			status int
+		}{
+			{"/info", wantInfo},
+			{"/v1/info", wantInfo},
+			{"/v1/ping", http.StatusUnauthorized},
+		} {
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+			if rec.Code != tc.status {
+				t.Fatalf("public=%v %s: expected status %d, got %d", public, tc.path, tc.status, rec.Code)
+			}
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/info", nil)
+		req.Header.Set(model.ApiAccessTokenHeader, "secret")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		var info model.ServerInfo
+		if err := json.Unmarshal(rec.Body.Bytes(), &info); err != nil || rec.Code != http.StatusOK {
+			t.Fatalf("public=%v: unexpected info response %d: %s", public, rec.Code, rec.Body.String())
+		}
+		if info.Version == "" || info.GoVersion != goruntime.Version() || info.OS != goruntime.GOOS || info.StartTime.IsZero() {
+			t.Fatalf("public=%v: incomplete info: %+v", public, info)
+		}
+	}
+}
+
+func TestDebugConfigRequiresTokenAndMasksSecrets(t *testing.T) {
+	previousHost, previousPort, previousToken := flag.JupyterServerHost, flag.ServerPort, flag.ServerAccessToken
+	defer func() {
+		flag.JupyterServerHost, flag.ServerPort, flag.ServerAccessToken = previousHost, previousPort, previousToken
+	}()
+	flag.JupyterServerHost, flag.ServerPort, flag.ServerAccessToken = "http://jupyter:8888", 44772, "secret"
+	r, err := NewRouter("secret")
+	if err != nil {
+		t.Fatalf("NewRouter returned error: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/debug/config", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without the access token, got %d", rec.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/debug/config", nil)
+	req.Header.Set(model.ApiAccessTokenHeader, "secret")
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	var config map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &config); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("unexpected config response %d: %s", rec.Code, rec.Body.String())
+	}
+	if config["jupyter-host"] != "http://jupyter:8888" || config["port"] != float64(44772) {
+		t.Fatalf("expected the effective settings, got %v", config)
+	}
+	if _, ok := config["sse-write-timeout"]; !ok {
+		t.Fatalf("expected every setting to be listed, got %v", config)
+	}
+	if config["access-token"] != "[REDACTED]" || strings.Contains(rec.Body.String(), "secret") {
+		t.Fatalf("expected the access token to be masked, got %s", rec.Body.String())
+	}
+}
+
+func TestAdminRoutesRequireAdminTokenWhenConfigured(t *testing.T) {
+	previous := flag.ServerAdminToken
+	defer func() { flag.ServerAdminToken = previous }()
+	flag.ServerAdminToken = "admin"
+	r := mustNewRouter(t)
+
+	for _, tc := range []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{http.MethodGet, "/v1/admin/loglevel", ""},
+		{http.MethodPut, "/v1/admin/loglevel", `{"level":"info"}`},
+		{http.MethodGet, "/v1/debug/config", ""},
+	} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body)))
+		if rec.Code != http.StatusForbidden {
+			t.Fatalf("%s %s: expected 403 without the admin token, got %d", tc.method, tc.path, rec.Code)
+		}
+
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		req.Header.Set(model.AdminTokenHeader, "admin")
+		rec = httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s %s: expected 200 with the admin token, got %d: %s", tc.method, tc.path, rec.Code, rec.Body.String())
+		}
+	}
+
+	flag.ServerAdminToken = ""
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/admin/loglevel", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected admin routes open without a configured admin token, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"net/http"
+
+	"github.com/alibaba/opensandbox/execd/pkg/flag"
+)
+
+// NewServer wraps the handler in an http.Server configured from flags.
+//
+// WriteTimeout is intentionally left zero so long-lived SSE streams are not
+// cut off; non-streaming responses get a per-response deadline instead.
+func NewServer(addr string, handler http.Handler) *http.Server {
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: flag.ServerReadHeaderTimeout,
+		IdleTimeout:       flag.ServerIdleTimeout,
+		MaxHeaderBytes:    flag.ServerMaxHeaderBytes,
+	}
+
+	if flag.ServerEnableH2C {
+		protocols := new(http.Protocols)
+		protocols.SetHTTP1(true)
+		protocols.SetUnencryptedHTTP2(true)
+		server.Protocols = protocols
+	}
+
+	return server
+}
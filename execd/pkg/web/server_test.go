@@ -0,0 +1,221 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/alibaba/opensandbox/execd/pkg/flag"
+)
+
+func TestNewServerAppliesFlags(t *testing.T) {
+	defer func(header, idle time.Duration, maxHeader int, h2c bool) {
+		flag.ServerReadHeaderTimeout, flag.ServerIdleTimeout = header, idle
+		flag.ServerMaxHeaderBytes, flag.ServerEnableH2C = maxHeader, h2c
+	}(flag.ServerReadHeaderTimeout, flag.ServerIdleTimeout, flag.ServerMaxHeaderBytes, flag.ServerEnableH2C)
+
+	flag.ServerReadHeaderTimeout = 3 * time.Second
+	flag.ServerIdleTimeout = 7 * time.Second
+	flag.ServerMaxHeaderBytes = 4096
+	flag.ServerEnableH2C = false
+
+	server := NewServer(":0", http.NotFoundHandler())
+	if server.ReadHeaderTimeout != 3*time.Second || server.IdleTimeout != 7*time.Second || server.MaxHeaderBytes != 4096 {
+		t.Fatalf("server limits not taken from flags: %+v", server)
+	}
+	if server.WriteTimeout != 0 {
+		t.Fatalf("WriteTimeout must stay zero for streaming responses, got %s", server.WriteTimeout)
+	}
+	if server.Protocols != nil {
+		t.Fatalf("h2c must be off by default")
+	}
+}
+
+func TestNewServerServesH2C(t *testing.T) {
+	defer func(h2c bool) { flag.ServerEnableH2C = h2c }(flag.ServerEnableH2C)
+	flag.ServerEnableH2C = true
+
+	ts := httptest.NewUnstartedServer(nil)
+	ts.Config = NewServer("", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.Proto)
+	}))
+	ts.Start()
+	defer ts.Close()
+
+	protocols := new(http.Protocols)
+	protocols.SetUnencryptedHTTP2(true)
+	client := &http.Client{Transport: &http.Transport{Protocols: protocols}}
+
+	resp, err := client.Get(ts.URL)
+	if err != nil {
+		t.Fatalf("h2c request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.ProtoMajor != 2 || string(body) != "HTTP/2.0" {
+		t.Fatalf("expected an HTTP/2 exchange, got %s handled as %q", resp.Proto, body)
+	}
+}
+
+func newDeadlineServer(t *testing.T, timeout time.Duration, handler gin.HandlerFunc) *httptest.Server {
+	t.Helper()
+	r := gin.New()
+	r.Use(writeDeadlineMiddleware(timeout))
+	r.GET("/", handler)
+	ts := httptest.NewServer(r)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestWriteDeadlineStartsWithResponse(t *testing.T) {
+	ts := newDeadlineServer(t, 100*time.Millisecond, func(ctx *gin.Context) {
+		// simulates a slow upload or kernel start-up before anything is written.
+		time.Sleep(300 * time.Millisecond)
+		ctx.String(http.StatusOK, "done")
+	})
+
+	resp, err := http.Get(ts.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil || resp.StatusCode != http.StatusOK || string(body) != "done" {
+		t.Fatalf("slow handler should still respond, got %d %q (%v)", resp.StatusCode, body, err)
+	}
+}
+
+func TestWriteDeadlineBoundsResponseWrite(t *testing.T) {
+	writeErr := make(chan error, 1)
+	ts := newDeadlineServer(t, 100*time.Millisecond, func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+		ctx.Writer.Flush()
+		time.Sleep(300 * time.Millisecond)
+		_, err := ctx.Writer.Write(bytes.Repeat([]byte("x"), 1<<20))
+		writeErr <- err
+	})
+
+	resp, err := http.Get(ts.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if err := <-writeErr; err == nil {
+		t.Fatalf("expected write after the deadline to fail")
+	}
+}
+
+func TestWriteDeadlineCanBeCleared(t *testing.T) {
+	writeErr := make(chan error, 1)
+	ts := newDeadlineServer(t, 100*time.Millisecond, func(ctx *gin.Context) {
+		if err := http.NewResponseController(ctx.Writer).SetWriteDeadline(time.Time{}); err != nil {
+			writeErr <- err
+			return
+		}
+		ctx.Status(http.StatusOK)
+		ctx.Writer.Flush()
+		time.Sleep(300 * time.Millisecond)
+		_, err := ctx.Writer.Write([]byte("late"))
+		writeErr <- err
+	})
+
+	resp, err := http.Get(ts.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	if err := <-writeErr; err != nil || string(body) != "late" {
+		t.Fatalf("cleared deadline should allow late writes, got %q (%v)", body, err)
+	}
+}
+
+func TestServerDropsSlowHeadersButNotStreams(t *testing.T) {
+	defer func(header time.Duration) { flag.ServerReadHeaderTimeout = header }(flag.ServerReadHeaderTimeout)
+	flag.ServerReadHeaderTimeout = 100 * time.Millisecond
+
+	r := gin.New()
+	r.Use(handlerTimeoutMiddleware("", 100*time.Millisecond))
+	r.GET("/ping", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+	r.POST("/code", func(ctx *gin.Context) {
+		ctx.Header("Content-Type", "text/event-stream")
+		for i := range 3 {
+			time.Sleep(100 * time.Millisecond)
+			_, _ = fmt.Fprintf(ctx.Writer, "data: %d\n\n", i)
+			ctx.Writer.Flush()
+		}
+	})
+	ts := httptest.NewUnstartedServer(nil)
+	ts.Config = NewServer("", r)
+	ts.Start()
+	defer ts.Close()
+
+	conn, err := net.Dial("tcp", ts.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+	_, _ = io.WriteString(conn, "GET /ping HTTP/1.1\r\nHost: execd\r\n")
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, err = bufio.NewReader(conn).ReadByte()
+	var netErr net.Error
+	if err == nil || (errors.As(err, &netErr) && netErr.Timeout()) {
+		t.Fatalf("expected the slow-header connection to be closed, got %v", err)
+	}
+
+	resp, err := http.Post(ts.URL+"/code", "application/json", nil)
+	if err != nil {
+		t.Fatalf("stream request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil || resp.StatusCode != http.StatusOK || string(body) != "data: 0\n\ndata: 1\n\ndata: 2\n\n" {
+		t.Fatalf("stream must outlive the timeouts, got %d %q (%v)", resp.StatusCode, body, err)
+	}
+}
+
+func TestHandlerTimeoutAnswersSlowRequests(t *testing.T) {
+	r := gin.New()
+	r.Use(handlerTimeoutMiddleware("/execd", 50*time.Millisecond))
+	r.GET("/execd/v1/code/contexts", func(ctx *gin.Context) {
+		<-ctx.Request.Context().Done()
+	})
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/execd/v1/code/contexts")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusServiceUnavailable || !bytes.Contains(body, []byte("REQUEST_TIMEOUT")) {
+		t.Fatalf("expected a 503 timeout answer, got %d %q", resp.StatusCode, body)
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// originChecker decides whether a websocket upgrade may proceed based on its
+// Origin header, guarding against cross-site websocket hijacking. Its Allowed
+// method matches websocket.Upgrader.CheckOrigin.
+type originChecker struct {
+	anyOrigin bool
+	// origins holds the allowed "scheme://host[:port]" origins, lowercased.
+	origins map[string]struct{}
+}
+
+// newOriginChecker parses a comma separated list of origins; "*" allows any
+// origin and an empty list only allows the same origin.
+func newOriginChecker(spec string) (*originChecker, error) {
+	checker := &originChecker{origins: make(map[string]struct{})}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		switch entry {
+		case "":
+			continue
+		case "*":
+			checker.anyOrigin = true
+			continue
+		}
+		u, err := url.Parse(entry)
+		if err != nil || u.Scheme == "" || u.Host == "" || (u.Path != "" && u.Path != "/") || u.RawQuery != "" {
+			return nil, fmt.Errorf("invalid origin %q, must look like https://host[:port]", entry)
+		}
+		checker.origins[strings.ToLower(u.Scheme+"://"+u.Host)] = struct{}{}
+	}
+	return checker, nil
+}
+
+// Allowed reports whether r may be upgraded. Requests without an Origin header
+// don't come from a browser and are allowed, as are those from the host they
+// are addressed to. A nil checker only allows the same origin.
+func (o *originChecker) Allowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || (o != nil && o.anyOrigin) {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	if strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	if o == nil {
+		return false
+	}
+	_, ok := o.origins[strings.ToLower(u.Scheme+"://"+u.Host)]
+	return ok
+}
@@ -0,0 +1,113 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestOriginChecker(t *testing.T) {
+	checker, err := newOriginChecker(" https://App.example.com ,http://localhost:3000,")
+	if err != nil {
+		t.Fatalf("newOriginChecker returned error: %v", err)
+	}
+
+	cases := []struct {
+		origin string
+		host   string
+		want   bool
+	}{
+		{origin: "", host: "sandbox:44772", want: true},
+		{origin: "http://sandbox:44772", host: "sandbox:44772", want: true},
+		{origin: "https://app.example.com", host: "sandbox:44772", want: true},
+		{origin: "http://localhost:3000", host: "sandbox:44772", want: true},
+		{origin: "http://app.example.com", host: "sandbox:44772", want: false},
+		{origin: "https://evil.example.com", host: "sandbox:44772", want: false},
+		{origin: "null", host: "sandbox:44772", want: false},
+	}
+	for _, tc := range cases {
+		r := httptest.NewRequest(http.MethodGet, "http://"+tc.host+"/proxy/3000/ws", nil)
+		if tc.origin != "" {
+			r.Header.Set("Origin", tc.origin)
+		}
+		if got := checker.Allowed(r); got != tc.want {
+			t.Errorf("Allowed(origin %q) = %v, want %v", tc.origin, got, tc.want)
+		}
+	}
+
+	wildcard, err := newOriginChecker("*")
+	if err != nil {
+		t.Fatalf("newOriginChecker returned error: %v", err)
+	}
+	r := httptest.NewRequest(http.MethodGet, "http://sandbox/", nil)
+	r.Header.Set("Origin", "https://evil.example.com")
+	if !wildcard.Allowed(r) {
+		t.Fatalf("expected * to allow any origin")
+	}
+	var none *originChecker
+	if none.Allowed(r) {
+		t.Fatalf("expected a nil checker to reject cross origins")
+	}
+
+	for _, spec := range []string{"app.example.com", "https://app.example.com/path", "https://"} {
+		if _, err := newOriginChecker(spec); err == nil {
+			t.Errorf("expected %q to be rejected", spec)
+		}
+	}
+}
+
+func TestProxyWebSocketRejectsDisallowedOrigin(t *testing.T) {
+	var upgrades atomic.Int32
+	upgrader := websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upgrades.Add(1)
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		_ = conn.Close()
+	}))
+	defer upstream.Close()
+
+	checker, err := newOriginChecker("https://app.example.com")
+	if err != nil {
+		t.Fatalf("newOriginChecker returned error: %v", err)
+	}
+	proxy := newProxyHandlerServer(t, "", func(opts *proxyOptions) { opts.origins = checker })
+	defer proxy.Close()
+	target := proxiedWebSocketURL(t, proxy, upstream, "/ws")
+
+	_, resp, err := websocket.DefaultDialer.Dial(target, http.Header{"Origin": {"https://evil.example.com"}})
+	if err == nil {
+		t.Fatalf("expected the upgrade from a foreign origin to fail")
+	}
+	if resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403, got %+v", resp)
+	}
+	if upgrades.Load() != 0 {
+		t.Fatalf("expected the upstream not to be contacted")
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(target, http.Header{"Origin": {"https://app.example.com"}})
+	if err != nil {
+		t.Fatalf("expected the allowed origin to connect: %v", err)
+	}
+	_ = conn.Close()
+}
@@ -0,0 +1,93 @@
+// Copyright 2026 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// writeDeadlineMiddleware bounds how long a response may take to be written.
+// The deadline starts with the response rather than the request, so slow work
+// such as uploads or kernel start-up doesn't eat into it. Streaming handlers
+// lift the deadline once they switch to SSE.
+func writeDeadlineMiddleware(timeout time.Duration) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if timeout > 0 {
+			ctx.Writer = &deadlineWriter{ResponseWriter: ctx.Writer, timeout: timeout}
+		}
+		ctx.Next()
+	}
+}
+
+// deadlineWriter arms the write deadline right before the response is first written.
+type deadlineWriter struct {
+	gin.ResponseWriter
+	timeout time.Duration
+	// settled is set once the deadline was armed or explicitly managed by a handler.
+	settled bool
+}
+
+func (w *deadlineWriter) arm() {
+	if w.settled {
+		return
+	}
+	w.settled = true
+	if err := w.setDeadline(time.Now().Add(w.timeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		httpLogger.Warning("failed to set write deadline: %v", err)
+	}
+}
+
+func (w *deadlineWriter) setDeadline(deadline time.Time) error {
+	return http.NewResponseController(w.ResponseWriter).SetWriteDeadline(deadline)
+}
+
+// SetWriteDeadline lets handlers override the deadline, e.g. to clear it for streams.
+func (w *deadlineWriter) SetWriteDeadline(deadline time.Time) error {
+	w.settled = true
+	return w.setDeadline(deadline)
+}
+
+func (w *deadlineWriter) WriteHeader(code int) {
+	w.arm()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *deadlineWriter) WriteHeaderNow() {
+	w.arm()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *deadlineWriter) Write(data []byte) (int, error) {
+	w.arm()
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *deadlineWriter) WriteString(s string) (int, error) {
+	w.arm()
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *deadlineWriter) Flush() {
+	w.arm()
+	w.ResponseWriter.Flush()
+}
+
+func (w *deadlineWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}